@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+func handleCreateAcls(s session, rb *request.CreateAclsV2) *response.CreateAclsV2 {
+	if s.clusterAuthorized(acl.OperationAlter) {
+		return authorizer.CreateAcls(rb)
+	}
+	res := &response.CreateAclsV2{Results: make([]response.AclCreationResult, len(rb.Creations))}
+	for i := range res.Results {
+		res.Results[i].ErrorCode = constant.CLUSTER_AUTHORIZATION_FAILED
+		res.Results[i].ErrorMessage = toNullableString("Not authorized to create ACLs")
+	}
+	return res
+}
+
+func handleDescribeAcls(s session, rb *request.DescribeAclsV2) *response.DescribeAclsV2 {
+	if s.clusterAuthorized(acl.OperationDescribe) {
+		return authorizer.DescribeAcls(rb)
+	}
+	return &response.DescribeAclsV2{
+		ErrorCode:    constant.CLUSTER_AUTHORIZATION_FAILED,
+		ErrorMessage: toNullableString("Not authorized to describe ACLs"),
+		Resources:    []response.DescribeAclsResource{},
+	}
+}
+
+func handleDeleteAcls(s session, rb *request.DeleteAclsV2) *response.DeleteAclsV2 {
+	if s.clusterAuthorized(acl.OperationAlter) {
+		return authorizer.DeleteAcls(rb)
+	}
+	res := &response.DeleteAclsV2{FilterResults: make([]response.DeleteAclsFilterResult, len(rb.Filters))}
+	for i := range res.FilterResults {
+		res.FilterResults[i].ErrorCode = constant.CLUSTER_AUTHORIZATION_FAILED
+		res.FilterResults[i].ErrorMessage = toNullableString("Not authorized to delete ACLs")
+		res.FilterResults[i].MatchingAcls = []response.DeleteAclsMatchingAcl{}
+	}
+	return res
+}
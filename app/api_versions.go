@@ -0,0 +1,88 @@
+package main
+
+import (
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+// versionRange is a range of versions of an API that the broker serves.
+type versionRange struct {
+	apiKey   int16
+	min, max int16
+}
+
+// supportedVersions are the APIs the broker serves, in the order they are
+// advertised by ApiVersions.
+var supportedVersions = []versionRange{
+	{constant.ApiVersions, 0, 4},
+	{constant.Metadata, 12, 12},
+	{constant.DescribeTopicPartitions, 0, 0},
+	{constant.ConsumerGroupHeartbeat, 0, 0},
+	{constant.ConsumerGroupDescribe, 0, 0},
+	{constant.InitProducerId, 2, 4},
+	{constant.Produce, 9, 11},
+	{constant.Fetch, 12, 16},
+	{constant.AddPartitionsToTxn, 3, 3},
+	{constant.AddOffsetsToTxn, 3, 3},
+	{constant.EndTxn, 3, 4},
+	{constant.WriteTxnMarkers, 1, 1},
+	{constant.TxnOffsetCommit, 3, 3},
+	{constant.DescribeConfigs, 4, 4},
+	{constant.IncrementalAlterConfigs, 1, 1},
+	{constant.SaslHandshake, 1, 1},
+	{constant.SaslAuthenticate, 0, 2},
+	{constant.DescribeUserScramCredentials, 0, 0},
+	{constant.AlterUserScramCredentials, 0, 0},
+	{constant.CreateAcls, 2, 3},
+	{constant.DescribeAcls, 2, 3},
+	{constant.DeleteAcls, 2, 3},
+	{constant.DescribeClientQuotas, 1, 1},
+	{constant.AlterClientQuotas, 1, 1},
+	{constant.DescribeProducers, 0, 0},
+	{constant.DescribeTransactions, 0, 0},
+	{constant.ListTransactions, 0, 1},
+	{constant.CreateTopics, 7, 7},
+	{constant.DeleteTopics, 6, 6},
+	{constant.ListOffsets, 6, 8},
+	{constant.ListGroups, 5, 5},
+	{constant.OffsetCommit, 9, 9},
+	{constant.OffsetFetch, 9, 9},
+}
+
+// supportedRange returns the versions of an API that the broker serves,
+// and false when it does not serve the API at all.
+func supportedRange(apiKey int16) (versionRange, bool) {
+	for _, r := range supportedVersions {
+		if r.apiKey == apiKey {
+			return r, true
+		}
+	}
+	return versionRange{}, false
+}
+
+// supported tells whether the broker serves a version of an API.
+func supported(apiKey, version int16) bool {
+	r, ok := supportedRange(apiKey)
+	return ok && version >= r.min && version <= r.max
+}
+
+// handleApiVersions advertises the supported versions of every API. A
+// client asking for a version the broker does not serve still gets them in
+// a version 0 response, with UNSUPPORTED_VERSION, so that it can retry with
+// one it does.
+func handleApiVersions(rh *request.RequestHeaderV2) *response.APIVersionsResponseV4 {
+	res := &response.APIVersionsResponseV4{Version: rh.RequestApiVersion, FinalizedFeaturesEpoch: -1}
+	if !supported(rh.RequestApiKey, rh.RequestApiVersion) {
+		res.Version = errorResponseVersion(rh.RequestApiKey, rh.RequestApiVersion)
+		res.ErrorCode = constant.UNSUPPORTED_VERSION
+	}
+	for _, r := range supportedVersions {
+		res.ApiVersions = append(res.ApiVersions, response.APIVersion{
+			ApiKey:     r.apiKey,
+			MinVersion: r.min,
+			MaxVersion: r.max,
+		})
+	}
+	return res
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+)
+
+// authorizedOperationsOmitted is reported when the client did not ask for
+// the authorized operations of a resource.
+const authorizedOperationsOmitted int32 = -2147483648
+
+// session identifies who sent a request, for authorization.
+type session struct {
+	principal string
+	host      string
+}
+
+func (s session) authorized(op acl.Operation, resourceType acl.ResourceType, name string) bool {
+	return authorizer.Authorize(s.principal, s.host, op, resourceType, name)
+}
+
+func (s session) clusterAuthorized(op acl.Operation) bool {
+	return s.authorized(op, acl.ResourceCluster, acl.ClusterName)
+}
+
+// newAuthorizer builds the authorizer of the broker configuration.
+func newAuthorizer(log *metadata.Log) *acl.Authorizer {
+	get := func(name string) string {
+		v, _ := configs.Get("", name)
+		return v
+	}
+	var superUsers []string
+	for _, u := range strings.Split(get("super.users"), ";") {
+		if u = strings.TrimSpace(u); u != "" {
+			superUsers = append(superUsers, u)
+		}
+	}
+	return acl.NewAuthorizer(log, acl.Config{
+		Enabled:                   get("authorizer.class.name") != "",
+		SuperUsers:                superUsers,
+		AllowEveryoneIfNoAclFound: get("allow.everyone.if.no.acl.found") == "true",
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+// configErrorCode returns the error to report when s may not perform op on
+// the configurations of a resource.
+func configErrorCode(s session, op acl.Operation, resourceType int8, name string) int16 {
+	switch config.ResourceType(resourceType) {
+	case config.ResourceTopic:
+		if !s.authorized(op, acl.ResourceTopic, name) {
+			return constant.TOPIC_AUTHORIZATION_FAILED
+		}
+	case config.ResourceBroker:
+		if !s.clusterAuthorized(op) {
+			return constant.CLUSTER_AUTHORIZATION_FAILED
+		}
+	}
+	// unknown resource types are rejected by the registry
+	return constant.NONE
+}
+
+func handleDescribeConfigs(s session, rb *request.DescribeConfigsV4) *response.DescribeConfigsV4 {
+	errorCodes := make([]int16, len(rb.Resources))
+	authorized := *rb
+	authorized.Resources = nil
+	for i, r := range rb.Resources {
+		if errorCodes[i] = configErrorCode(s, acl.OperationDescribeConfigs, r.ResourceType, string(r.ResourceName)); errorCodes[i] == constant.NONE {
+			authorized.Resources = append(authorized.Resources, r)
+		}
+	}
+	described := configs.DescribeConfigs(&authorized).Results
+	res := &response.DescribeConfigsV4{Results: make([]response.DescribeConfigsResult, len(rb.Resources))}
+	for i, r := range rb.Resources {
+		if errorCodes[i] != constant.NONE {
+			res.Results[i] = response.DescribeConfigsResult{
+				ErrorCode:    errorCodes[i],
+				ErrorMessage: toNullableString(fmt.Sprintf("Not authorized to describe the configurations of %s", r.ResourceName)),
+				ResourceType: r.ResourceType,
+				ResourceName: r.ResourceName,
+				Configs:      []response.DescribeConfigsResourceResult{},
+			}
+			continue
+		}
+		res.Results[i] = described[0]
+		described = described[1:]
+	}
+	return res
+}
+
+func handleIncrementalAlterConfigs(s session, rb *request.IncrementalAlterConfigsV1) *response.IncrementalAlterConfigsV1 {
+	errorCodes := make([]int16, len(rb.Resources))
+	authorized := *rb
+	authorized.Resources = nil
+	for i, r := range rb.Resources {
+		if errorCodes[i] = configErrorCode(s, acl.OperationAlterConfigs, r.ResourceType, string(r.ResourceName)); errorCodes[i] == constant.NONE {
+			authorized.Resources = append(authorized.Resources, r)
+		}
+	}
+	altered := configs.IncrementalAlterConfigs(&authorized).Responses
+	res := &response.IncrementalAlterConfigsV1{Responses: make([]response.AlterConfigsResourceResponse, len(rb.Resources))}
+	for i, r := range rb.Resources {
+		if errorCodes[i] != constant.NONE {
+			res.Responses[i] = response.AlterConfigsResourceResponse{
+				ErrorCode:    errorCodes[i],
+				ErrorMessage: toNullableString(fmt.Sprintf("Not authorized to alter the configurations of %s", r.ResourceName)),
+				ResourceType: r.ResourceType,
+				ResourceName: r.ResourceName,
+			}
+			continue
+		}
+		res.Responses[i] = altered[0]
+		altered = altered[1:]
+	}
+	return res
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+	"github.com/codecrafters-io/kafka-starter-go/internal/listener"
+)
+
+const (
+	// shutdownTimeout bounds how long in-flight requests may take to finish
+	// once the broker is asked to stop.
+	shutdownTimeout = 30 * time.Second
+
+	// idleCheckInterval is how often connections are checked for being
+	// idle for longer than connections.max.idle.ms.
+	idleCheckInterval = time.Second
+)
+
+var (
+	errStopping     = errors.New("the broker is stopping")
+	errTooManyForIP = errors.New("too many connections from the address")
+)
+
+// connection is an open client connection.
+type connection struct {
+	conn      net.Conn
+	listener  string
+	ip        string
+	connected time.Time
+
+	busy       int // requests read whose response is not sent yet
+	lastActive time.Time
+	clientId   string
+	principal  string
+}
+
+// connectionInfo describes a connection for admin and metrics code.
+type connectionInfo struct {
+	Listener   string
+	Remote     string
+	ClientId   string
+	Principal  string
+	Connected  time.Time
+	LastActive time.Time
+	InFlight   int
+}
+
+// connections is the registry of open client connections. It enforces the
+// connection limits, closes idle connections, and tracks how many requests
+// each connection is serving, so that the broker can stop without cutting
+// requests short.
+type connections struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // signaled when connections close or limits change
+	closing bool
+	open    map[net.Conn]*connection
+	perIP   map[string]int
+	wg      sync.WaitGroup
+
+	maxConnections int
+	maxPerIP       int
+	perIPOverrides map[string]int
+	maxIdle        time.Duration
+}
+
+var conns = newConnections()
+
+func newConnections() *connections {
+	c := &connections{
+		open:           make(map[net.Conn]*connection),
+		perIP:          make(map[string]int),
+		maxConnections: math.MaxInt32,
+		maxPerIP:       math.MaxInt32,
+		maxIdle:        10 * time.Minute,
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// reconfigure applies the connection configurations of the broker, which
+// may be changed dynamically.
+func (c *connections) reconfigure(topic string) {
+	if topic != "" {
+		return
+	}
+	get := func(name string) string {
+		value, _ := configs.Get("", name)
+		return value
+	}
+	maxConnections, _ := strconv.Atoi(get("max.connections"))
+	maxPerIP, _ := strconv.Atoi(get("max.connections.per.ip"))
+	maxIdleMs, _ := strconv.ParseInt(get("connections.max.idle.ms"), 10, 64)
+	hosts, _ := config.ParseHostCounts(get("max.connections.per.ip.overrides"))
+	overrides := make(map[string]int)
+	for host, n := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			overrides[ip.String()] = n
+			continue
+		}
+		addrs, err := net.LookupHost(host)
+		if err != nil {
+			slog.Warn("Ignoring connection limit of unknown host", "host", host, "error", err)
+			continue
+		}
+		for _, addr := range addrs {
+			overrides[addr] = n
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxConnections = maxConnections
+	c.maxPerIP = maxPerIP
+	c.perIPOverrides = overrides
+	c.maxIdle = time.Duration(maxIdleMs) * time.Millisecond
+	c.cond.Broadcast()
+}
+
+func (c *connections) limitOf(ip string) int {
+	if n, ok := c.perIPOverrides[ip]; ok {
+		return n
+	}
+	return c.maxPerIP
+}
+
+// add registers a new connection, waiting while max.connections are open.
+// It fails when the broker is stopping, or when the address of the client
+// already has max.connections.per.ip open.
+func (c *connections) add(conn net.Conn, l *listener.Listener) error {
+	ip := remoteIP(conn)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closing && c.perIP[ip] >= c.limitOf(ip) {
+		return errTooManyForIP
+	}
+	for !c.closing && len(c.open) >= c.maxConnections {
+		c.cond.Wait()
+	}
+	if c.closing {
+		return errStopping
+	}
+	// the limit of the address may have been reached while waiting
+	if c.perIP[ip] >= c.limitOf(ip) {
+		return errTooManyForIP
+	}
+	now := time.Now()
+	c.open[conn] = &connection{conn: conn, listener: l.Name, ip: ip, connected: now, lastActive: now}
+	c.perIP[ip]++
+	c.wg.Add(1)
+	return nil
+}
+
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
+
+func (c *connections) remove(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cn, ok := c.open[conn]; ok {
+		delete(c.open, conn)
+		if c.perIP[cn.ip]--; c.perIP[cn.ip] == 0 {
+			delete(c.perIP, cn.ip)
+		}
+		c.wg.Done()
+		c.cond.Signal()
+	}
+}
+
+// list describes the open connections, oldest first.
+func (c *connections) list() []connectionInfo {
+	c.mu.Lock()
+	infos := make([]connectionInfo, 0, len(c.open))
+	for _, cn := range c.open {
+		infos = append(infos, connectionInfo{
+			Listener:   cn.listener,
+			Remote:     cn.conn.RemoteAddr().String(),
+			ClientId:   cn.clientId,
+			Principal:  cn.principal,
+			Connected:  cn.connected,
+			LastActive: cn.lastActive,
+			InFlight:   cn.busy,
+		})
+	}
+	c.mu.Unlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Connected.Before(infos[j].Connected) })
+	return infos
+}
+
+// identify records the client id and principal of the last request read
+// from a connection.
+func (c *connections) identify(conn net.Conn, clientId, principal string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cn, ok := c.open[conn]; ok {
+		cn.clientId = clientId
+		cn.principal = principal
+	}
+}
+
+// begin counts a request read from a connection, and reports false when
+// the broker is stopping and the connection should not read any more.
+func (c *connections) begin(conn net.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closing {
+		return false
+	}
+	if cn, ok := c.open[conn]; ok {
+		cn.busy++
+		cn.lastActive = time.Now()
+	}
+	return true
+}
+
+// end counts a request whose response is sent, and reports false when the
+// broker is stopping and the connection has no more requests to serve, so
+// it should close.
+func (c *connections) end(conn net.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cn, ok := c.open[conn]
+	if !ok {
+		return !c.closing
+	}
+	cn.busy--
+	cn.lastActive = time.Now()
+	return !c.closing || cn.busy > 0
+}
+
+// reapIdle closes the connections that have had no request in flight for
+// longer than connections.max.idle.ms, until stop is closed.
+func (c *connections) reapIdle(stop <-chan struct{}) {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for conn, cn := range c.open {
+				if cn.busy == 0 && now.Sub(cn.lastActive) > c.maxIdle {
+					slog.Debug("Closing idle connection", "listener", cn.listener, "remote", conn.RemoteAddr().String(), "idle", now.Sub(cn.lastActive))
+					conn.Close()
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// drain closes the idle connections and waits for the others to finish
+// their requests, closing them anyway after timeout. It reports whether
+// every request finished in time.
+func (c *connections) drain(timeout time.Duration) bool {
+	c.mu.Lock()
+	c.closing = true
+	c.cond.Broadcast()
+	for conn, cn := range c.open {
+		if cn.busy == 0 {
+			conn.Close()
+		}
+	}
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+	}
+
+	c.mu.Lock()
+	for conn := range c.open {
+		conn.Close()
+	}
+	c.mu.Unlock()
+	return false
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// handleDescribeTopicPartitions describes the requested topics, or every
+// topic s may describe when none is requested, in name order. A response
+// describes at most ResponsePartitionLimit partitions; NextCursor then tells
+// where the next page starts.
+func handleDescribeTopicPartitions(s session, rb *request.DescribeTopicPartitionsV0) *response.DescribeTopicPartitionsV0 {
+	fetchAll := len(rb.Topics) == 0
+	var names []string
+	if fetchAll {
+		for _, t := range cluster.Topics() {
+			names = append(names, t.Name)
+		}
+	} else {
+		for _, rt := range rb.Topics {
+			names = append(names, string(rt.Name))
+		}
+		sort.Strings(names)
+		names = slices.Compact(names)
+		if rb.Cursor != nil && !slices.Contains(names, string(rb.Cursor.TopicName)) {
+			res := &response.DescribeTopicPartitionsV0{}
+			for _, name := range names {
+				res.Topics = append(res.Topics, response.Topic{ErrorCode: constant.INVALID_REQUEST, TopicName: types.CompactString(name)})
+			}
+			return res
+		}
+	}
+
+	var firstPartition int32
+	if rb.Cursor != nil {
+		i, _ := slices.BinarySearch(names, string(rb.Cursor.TopicName))
+		names = names[i:]
+		// a cursor naming a topic that no longer exists starts at the next one
+		if len(names) > 0 && names[0] == string(rb.Cursor.TopicName) {
+			firstPartition = rb.Cursor.PartitionIndex
+		}
+	}
+	limit := int(partitionLimit(rb.ResponsePartitionLimit))
+
+	res := &response.DescribeTopicPartitionsV0{}
+	for _, name := range names {
+		if limit == 0 {
+			res.NextCursor = &response.Cursor{TopicName: types.CompactString(name)}
+			break
+		}
+		t, ok := cluster.TopicByName(name)
+		switch {
+		case !s.authorized(acl.OperationDescribe, acl.ResourceTopic, name):
+			if !fetchAll {
+				res.Topics = append(res.Topics, response.Topic{ErrorCode: constant.TOPIC_AUTHORIZATION_FAILED, TopicName: types.CompactString(name)})
+			}
+		case !ok:
+			res.Topics = append(res.Topics, response.Topic{ErrorCode: constant.UNKNOWN_TOPIC_OR_PARTITION, TopicName: types.CompactString(name)})
+		default:
+			topic, next := describeTopic(s, t, firstPartition, limit)
+			res.Topics = append(res.Topics, topic)
+			limit -= len(topic.Partitions.Partitions)
+			if next >= 0 {
+				res.NextCursor = &response.Cursor{TopicName: topic.TopicName, PartitionIndex: next}
+				return res
+			}
+		}
+		// the cursor's partition only applies to the topic it names
+		firstPartition = 0
+	}
+	return res
+}
+
+// partitionLimit bounds the partition limit of a request by the broker's.
+func partitionLimit(requested int32) int32 {
+	v, _ := configs.Get("", "max.request.partition.size.limit")
+	limit, _ := strconv.Atoi(v)
+	if requested <= 0 {
+		return int32(limit)
+	}
+	return min(requested, int32(limit))
+}
+
+// describeTopic describes up to limit partitions of t from firstPartition on,
+// and returns the index of the first partition left out, or -1.
+func describeTopic(s session, t metadata.Topic, firstPartition int32, limit int) (response.Topic, int32) {
+	topic := response.Topic{
+		TopicName:                types.CompactString(t.Name),
+		TopicId:                  t.TopicId,
+		TopicAuthorizeOperations: authorizer.AuthorizedOperations(s.principal, s.host, acl.ResourceTopic, t.Name),
+		Partitions:               response.Partitions{Partitions: []response.Partition{}},
+	}
+	if t.IsInternal {
+		topic.IsInternal = 1
+	}
+	partitions := slices.Clone(t.Partitions)
+	slices.SortFunc(partitions, func(a, b metadata.Partition) int { return cmp.Compare(a.PartitionIndex, b.PartitionIndex) })
+	for _, p := range partitions {
+		if p.PartitionIndex < firstPartition {
+			continue
+		}
+		if len(topic.Partitions.Partitions) == limit {
+			return topic, p.PartitionIndex
+		}
+		topic.Partitions.Partitions = append(topic.Partitions.Partitions, response.Partition{
+			PartitionIndex:         p.PartitionIndex,
+			LeaderId:               p.LeaderId,
+			LeaderEpoch:            p.LeaderEpoch,
+			ReplicaNodes:           toNodes(p.ReplicaNodes),
+			ISRNodes:               toNodes(p.ISRNodes),
+			EligibleLeaderReplicas: response.Nodes{},
+			LastKnownELRs:          response.Nodes{},
+			OfflineReplicas:        response.Nodes{},
+		})
+	}
+	return topic, -1
+}
+
+func toNodes(ids []int32) response.Nodes {
+	nodes := make(response.Nodes, len(ids))
+	for i, id := range ids {
+		nodes[i].NodeId = id
+	}
+	return nodes
+}
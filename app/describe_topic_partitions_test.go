@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// describedPartitions lists the partitions of a response as topic/index,
+// and the topics that failed as topic:errorCode.
+func describedPartitions(res *response.DescribeTopicPartitionsV0) []string {
+	var got []string
+	for _, t := range res.Topics {
+		if t.ErrorCode != constant.NONE {
+			got = append(got, fmt.Sprintf("%s:%d", t.TopicName, t.ErrorCode))
+			continue
+		}
+		for _, p := range t.Partitions.Partitions {
+			got = append(got, fmt.Sprintf("%s/%d", t.TopicName, p.PartitionIndex))
+		}
+	}
+	return got
+}
+
+func TestDescribeTopicPartitionsPages(t *testing.T) {
+	startTestBroker(t, nil)
+	// topics a, b and c of 3, 5 and 1 partitions
+	for i, n := range []int32{3, 5, 1} {
+		topic := metadata.Topic{Name: string(rune('a' + i)), TopicId: [16]byte{byte(i + 1)}}
+		// partitions out of order, to be sorted by index
+		for p := n - 1; p >= 0; p-- {
+			topic.Partitions = append(topic.Partitions, metadata.Partition{PartitionIndex: p, LeaderId: 1})
+		}
+		cluster.PutTopic(topic)
+	}
+
+	tests := []struct {
+		name   string
+		topics []string
+		limit  int32
+		cursor *request.Cursor
+		want   []string
+		next   string // the next cursor as topic/index, empty on the last page
+	}{
+		{"every topic", nil, 0, nil, []string{"a/0", "a/1", "a/2", "b/0", "b/1", "b/2", "b/3", "b/4", "c/0"}, ""},
+		{"first page", nil, 4, nil, []string{"a/0", "a/1", "a/2", "b/0"}, "b/1"},
+		{"cursor mid-topic", nil, 4, &request.Cursor{TopicName: "b", PartitionIndex: 1}, []string{"b/1", "b/2", "b/3", "b/4"}, "c/0"},
+		{"last page", nil, 4, &request.Cursor{TopicName: "c"}, []string{"c/0"}, ""},
+		{"limit ending a topic", nil, 3, nil, []string{"a/0", "a/1", "a/2"}, "b/0"},
+		{"limit smaller than a topic", []string{"b"}, 2, nil, []string{"b/0", "b/1"}, "b/2"},
+		{"limit smaller than a topic, next page", []string{"b"}, 2, &request.Cursor{TopicName: "b", PartitionIndex: 2}, []string{"b/2", "b/3"}, "b/4"},
+		{"requested topics from a cursor", []string{"c", "a", "b"}, 10, &request.Cursor{TopicName: "b", PartitionIndex: 3}, []string{"b/3", "b/4", "c/0"}, ""},
+		{"cursor past the partitions", []string{"a", "c"}, 10, &request.Cursor{TopicName: "a", PartitionIndex: 7}, []string{"c/0"}, ""},
+		{"duplicate requested topics", []string{"c", "c"}, 0, nil, []string{"c/0"}, ""},
+		{"unknown requested topic", []string{"x", "a"}, 0, nil, []string{"a/0", "a/1", "a/2", fmt.Sprintf("x:%d", constant.UNKNOWN_TOPIC_OR_PARTITION)}, ""},
+		{"cursor naming a topic not requested", []string{"a", "c"}, 0, &request.Cursor{TopicName: "b"},
+			[]string{fmt.Sprintf("a:%d", constant.INVALID_REQUEST), fmt.Sprintf("c:%d", constant.INVALID_REQUEST)}, ""},
+		{"cursor naming an unknown topic", nil, 0, &request.Cursor{TopicName: "bb", PartitionIndex: 2}, []string{"c/0"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rb := &request.DescribeTopicPartitionsV0{ResponsePartitionLimit: tt.limit, Cursor: tt.cursor}
+			for _, name := range tt.topics {
+				rb.Topics = append(rb.Topics, request.Topic{Name: types.CompactString(name)})
+			}
+			res := handleDescribeTopicPartitions(session{principal: "User:ANONYMOUS", host: "127.0.0.1"}, rb)
+			if got := describedPartitions(res); !slices.Equal(got, tt.want) {
+				t.Errorf("described %v, want %v", got, tt.want)
+			}
+			next := ""
+			if res.NextCursor != nil {
+				next = fmt.Sprintf("%s/%d", res.NextCursor.TopicName, res.NextCursor.PartitionIndex)
+			}
+			if next != tt.next {
+				t.Errorf("next cursor %q, want %q", next, tt.next)
+			}
+		})
+	}
+}
+
+// TestDescribeTopicPartitionsWalk follows the cursors from the first page
+// to the last: every partition is described once, in order.
+func TestDescribeTopicPartitionsWalk(t *testing.T) {
+	startTestBroker(t, nil)
+	var want []string
+	for i, n := range []int32{4, 1, 6} {
+		topic := metadata.Topic{Name: fmt.Sprintf("t%d", i), TopicId: [16]byte{byte(i + 1)}}
+		for p := range n {
+			topic.Partitions = append(topic.Partitions, metadata.Partition{PartitionIndex: p, LeaderId: 1})
+			want = append(want, fmt.Sprintf("%s/%d", topic.Name, p))
+		}
+		cluster.PutTopic(topic)
+	}
+
+	for limit := int32(1); limit <= 12; limit++ {
+		var got []string
+		rb := &request.DescribeTopicPartitionsV0{ResponsePartitionLimit: limit}
+		for pages := 0; ; pages++ {
+			if pages > len(want) {
+				t.Fatalf("limit %d: no last page after %d pages", limit, pages)
+			}
+			res := handleDescribeTopicPartitions(session{principal: "User:ANONYMOUS", host: "127.0.0.1"}, rb)
+			page := describedPartitions(res)
+			if len(page) > int(limit) {
+				t.Errorf("limit %d: page of %d partitions", limit, len(page))
+			}
+			got = append(got, page...)
+			if res.NextCursor == nil {
+				break
+			}
+			rb.Cursor = &request.Cursor{TopicName: res.NextCursor.TopicName, PartitionIndex: res.NextCursor.PartitionIndex}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("limit %d: described %v, want %v", limit, got, want)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// emptyBodies make an empty response body of the given version for the
+// APIs whose responses have a top-level error code, which errorResponse
+// sets.
+var emptyBodies = map[int16]func(version int16) response.ErrorCoded{
+	constant.ApiVersions: func(v int16) response.ErrorCoded {
+		return &response.APIVersionsResponseV4{Version: v, FinalizedFeaturesEpoch: -1}
+	},
+	constant.ConsumerGroupHeartbeat: func(int16) response.ErrorCoded { return &response.ConsumerGroupHeartbeatV0{} },
+	constant.InitProducerId: func(int16) response.ErrorCoded {
+		return &response.InitProducerIdV2{ProducerId: -1, ProducerEpoch: -1}
+	},
+	constant.Fetch:                        func(v int16) response.ErrorCoded { return &response.FetchV12{Version: v} },
+	constant.AddOffsetsToTxn:              func(int16) response.ErrorCoded { return &response.ErrorOnlyV0{} },
+	constant.EndTxn:                       func(int16) response.ErrorCoded { return &response.ErrorOnlyV0{} },
+	constant.SaslHandshake:                func(int16) response.ErrorCoded { return &response.SaslHandshakeV1{} },
+	constant.SaslAuthenticate:             func(v int16) response.ErrorCoded { return &response.SaslAuthenticateV2{Version: v} },
+	constant.DescribeUserScramCredentials: func(int16) response.ErrorCoded { return &response.DescribeUserScramCredentialsV0{} },
+	constant.DescribeAcls:                 func(int16) response.ErrorCoded { return &response.DescribeAclsV2{} },
+	constant.DescribeClientQuotas:         func(int16) response.ErrorCoded { return &response.DescribeClientQuotasV1{} },
+	constant.ListTransactions:             func(int16) response.ErrorCoded { return &response.ListTransactionsV0{} },
+	constant.ListGroups:                   func(int16) response.ErrorCoded { return &response.ListGroupsV5{} },
+}
+
+// errorBody builds the error response to a request, or returns nil when
+// the request body is not of the type of its API. A nil request body gets
+// a response without resources.
+type errorBody func(req request.RequestBody, code int16) response.ResponseBody
+
+// requestErrorBodies build the error response of the APIs that report
+// errors per topic, partition or other resource, with code set on each of
+// the resources the request names, as Kafka does.
+var requestErrorBodies = map[int16]errorBody{
+	constant.Metadata: perResource(func(rb *request.MetadataV12, code int16) response.ResponseBody {
+		res := &response.MetadataV12{ControllerId: -1}
+		for _, t := range rb.Topics {
+			res.Topics = append(res.Topics, response.MetadataTopic{ErrorCode: code, Name: t.Name, TopicId: t.TopicId})
+		}
+		return res
+	}),
+	constant.DescribeTopicPartitions: perResource(func(rb *request.DescribeTopicPartitionsV0, code int16) response.ResponseBody {
+		res := &response.DescribeTopicPartitionsV0{}
+		for _, t := range rb.Topics {
+			res.Topics = append(res.Topics, response.Topic{ErrorCode: code, TopicName: t.Name})
+		}
+		return res
+	}),
+	constant.ConsumerGroupDescribe: perResource(func(rb *request.ConsumerGroupDescribeV0, code int16) response.ResponseBody {
+		res := &response.ConsumerGroupDescribeV0{}
+		for _, id := range rb.GroupIds {
+			res.Groups = append(res.Groups, response.DescribedGroup{ErrorCode: code, GroupId: id})
+		}
+		return res
+	}),
+	constant.Produce: perResource(func(rb *request.ProduceV9, code int16) response.ResponseBody {
+		res := &response.ProduceV9{}
+		for _, td := range rb.TopicData {
+			tr := response.ProduceTopicResponse{Name: td.Name}
+			for _, pd := range td.PartitionData {
+				tr.PartitionResponses = append(tr.PartitionResponses, response.ProducePartitionResponse{
+					Index:           pd.Index,
+					ErrorCode:       code,
+					BaseOffset:      -1,
+					LogAppendTimeMs: -1,
+					LogStartOffset:  -1,
+				})
+			}
+			res.Responses = append(res.Responses, tr)
+		}
+		return res
+	}),
+	constant.Fetch: perResource(func(rb *request.FetchV12, code int16) response.ResponseBody {
+		res := &response.FetchV12{Version: rb.Version, ErrorCode: code}
+		for _, ft := range rb.Topics {
+			tr := response.FetchTopicResponse{Topic: ft.Topic, TopicId: ft.TopicId}
+			for _, fp := range ft.Partitions {
+				tr.Partitions = append(tr.Partitions, response.FetchPartitionResponse{
+					PartitionIndex:       fp.Partition,
+					ErrorCode:            code,
+					HighWatermark:        -1,
+					LastStableOffset:     -1,
+					LogStartOffset:       -1,
+					PreferredReadReplica: -1,
+				})
+			}
+			res.Responses = append(res.Responses, tr)
+		}
+		return res
+	}),
+	constant.ListOffsets: perResource(func(rb *request.ListOffsetsV6, code int16) response.ResponseBody {
+		res := &response.ListOffsetsV6{}
+		for _, t := range rb.Topics {
+			tr := response.ListOffsetsTopic{Name: t.Name}
+			for _, p := range t.Partitions {
+				tr.Partitions = append(tr.Partitions, response.ListOffsetsPartition{
+					PartitionIndex: p.PartitionIndex,
+					ErrorCode:      code,
+					Timestamp:      -1,
+					Offset:         -1,
+					LeaderEpoch:    -1,
+				})
+			}
+			res.Topics = append(res.Topics, tr)
+		}
+		return res
+	}),
+	constant.OffsetCommit: perResource(func(rb *request.OffsetCommitV9, code int16) response.ResponseBody {
+		res := &response.OffsetCommitV9{}
+		for _, t := range rb.Topics {
+			partitions := make([]int32, len(t.Partitions))
+			for i, p := range t.Partitions {
+				partitions[i] = p.PartitionIndex
+			}
+			res.Topics = append(res.Topics, txnTopicResult(t.Name, partitions, code))
+		}
+		return res
+	}),
+	constant.OffsetFetch: perResource(func(rb *request.OffsetFetchV9, code int16) response.ResponseBody {
+		res := &response.OffsetFetchV9{}
+		for _, g := range rb.Groups {
+			res.Groups = append(res.Groups, response.OffsetFetchGroup{GroupId: g.GroupId, ErrorCode: code})
+		}
+		return res
+	}),
+	constant.AddPartitionsToTxn: perResource(func(rb *request.AddPartitionsToTxnV3, code int16) response.ResponseBody {
+		res := &response.AddPartitionsToTxnV3{}
+		for _, t := range rb.Topics {
+			res.Results = append(res.Results, txnTopicResult(t.Name, t.Partitions, code))
+		}
+		return res
+	}),
+	constant.TxnOffsetCommit: perResource(func(rb *request.TxnOffsetCommitV3, code int16) response.ResponseBody {
+		res := &response.TxnOffsetCommitV3{}
+		for _, t := range rb.Topics {
+			partitions := make([]int32, len(t.Partitions))
+			for i, p := range t.Partitions {
+				partitions[i] = p.PartitionIndex
+			}
+			res.Topics = append(res.Topics, txnTopicResult(t.Name, partitions, code))
+		}
+		return res
+	}),
+	constant.WriteTxnMarkers: perResource(func(rb *request.WriteTxnMarkersV1, code int16) response.ResponseBody {
+		res := &response.WriteTxnMarkersV1{}
+		for _, m := range rb.Markers {
+			mr := response.TxnMarkerResult{ProducerId: m.ProducerId}
+			for _, t := range m.Topics {
+				mr.Topics = append(mr.Topics, txnTopicResult(t.Name, t.Partitions, code))
+			}
+			res.Markers = append(res.Markers, mr)
+		}
+		return res
+	}),
+	constant.CreateTopics: perResource(func(rb *request.CreateTopicsV7, code int16) response.ResponseBody {
+		res := &response.CreateTopicsV7{}
+		for _, t := range rb.Topics {
+			res.Topics = append(res.Topics, response.CreatableTopicResult{
+				Name:              t.Name,
+				ErrorCode:         code,
+				NumPartitions:     -1,
+				ReplicationFactor: -1,
+			})
+		}
+		return res
+	}),
+	constant.DeleteTopics: perResource(func(rb *request.DeleteTopicsV6, code int16) response.ResponseBody {
+		res := &response.DeleteTopicsV6{}
+		for _, t := range rb.Topics {
+			res.Responses = append(res.Responses, response.DeletableTopicResult{Name: t.Name, TopicId: t.TopicId, ErrorCode: code})
+		}
+		return res
+	}),
+	constant.DescribeConfigs: perResource(func(rb *request.DescribeConfigsV4, code int16) response.ResponseBody {
+		res := &response.DescribeConfigsV4{}
+		for _, r := range rb.Resources {
+			res.Results = append(res.Results, response.DescribeConfigsResult{
+				ErrorCode:    code,
+				ResourceType: r.ResourceType,
+				ResourceName: r.ResourceName,
+			})
+		}
+		return res
+	}),
+	constant.IncrementalAlterConfigs: perResource(func(rb *request.IncrementalAlterConfigsV1, code int16) response.ResponseBody {
+		res := &response.IncrementalAlterConfigsV1{}
+		for _, r := range rb.Resources {
+			res.Responses = append(res.Responses, response.AlterConfigsResourceResponse{
+				ErrorCode:    code,
+				ResourceType: r.ResourceType,
+				ResourceName: r.ResourceName,
+			})
+		}
+		return res
+	}),
+	constant.AlterUserScramCredentials: perResource(func(rb *request.AlterUserScramCredentialsV0, code int16) response.ResponseBody {
+		res := &response.AlterUserScramCredentialsV0{}
+		seen := make(map[types.CompactString]bool)
+		addUser := func(user types.CompactString) {
+			if !seen[user] {
+				seen[user] = true
+				res.Results = append(res.Results, response.AlterUserScramCredentialsResult{User: user, ErrorCode: code})
+			}
+		}
+		for _, d := range rb.Deletions {
+			addUser(d.Name)
+		}
+		for _, u := range rb.Upsertions {
+			addUser(u.Name)
+		}
+		return res
+	}),
+	constant.CreateAcls: perResource(func(rb *request.CreateAclsV2, code int16) response.ResponseBody {
+		res := &response.CreateAclsV2{Results: make([]response.AclCreationResult, len(rb.Creations))}
+		for i := range res.Results {
+			res.Results[i].ErrorCode = code
+		}
+		return res
+	}),
+	constant.DeleteAcls: perResource(func(rb *request.DeleteAclsV2, code int16) response.ResponseBody {
+		res := &response.DeleteAclsV2{FilterResults: make([]response.DeleteAclsFilterResult, len(rb.Filters))}
+		for i := range res.FilterResults {
+			res.FilterResults[i].ErrorCode = code
+		}
+		return res
+	}),
+	constant.AlterClientQuotas: perResource(func(rb *request.AlterClientQuotasV1, code int16) response.ResponseBody {
+		res := &response.AlterClientQuotasV1{}
+		for _, e := range rb.Entries {
+			entry := response.AlterClientQuotasEntry{ErrorCode: code}
+			for _, entity := range e.Entity {
+				entry.Entity = append(entry.Entity, response.QuotaEntity{EntityType: entity.EntityType, EntityName: entity.EntityName})
+			}
+			res.Entries = append(res.Entries, entry)
+		}
+		return res
+	}),
+	constant.DescribeProducers: perResource(func(rb *request.DescribeProducersV0, code int16) response.ResponseBody {
+		res := &response.DescribeProducersV0{}
+		for _, t := range rb.Topics {
+			tr := response.DescribeProducersTopic{Name: t.Name}
+			for _, p := range t.PartitionIndexes {
+				tr.Partitions = append(tr.Partitions, response.DescribeProducersPartition{PartitionIndex: p, ErrorCode: code})
+			}
+			res.Topics = append(res.Topics, tr)
+		}
+		return res
+	}),
+	constant.DescribeTransactions: perResource(func(rb *request.DescribeTransactionsV0, code int16) response.ResponseBody {
+		res := &response.DescribeTransactionsV0{}
+		for _, id := range rb.TransactionalIds {
+			res.TransactionStates = append(res.TransactionStates, response.TransactionState{
+				ErrorCode:       code,
+				TransactionalId: id,
+				ProducerId:      -1,
+				ProducerEpoch:   -1,
+			})
+		}
+		return res
+	}),
+}
+
+// perResource makes an errorBody of a builder taking the request body type
+// of its API. Without a request body, the builder is given an empty one.
+func perResource[T any, PT interface {
+	*T
+	request.RequestBody
+}](build func(rb PT, code int16) response.ResponseBody) errorBody {
+	return func(req request.RequestBody, code int16) response.ResponseBody {
+		if req == nil {
+			return build(PT(new(T)), code)
+		}
+		rb, ok := req.(PT)
+		if !ok {
+			return nil
+		}
+		return build(rb, code)
+	}
+}
+
+func txnTopicResult(name types.CompactString, partitions []int32, code int16) response.TxnTopicResult {
+	tr := response.TxnTopicResult{Name: name}
+	for _, p := range partitions {
+		tr.Results = append(tr.Results, response.TxnPartitionResult{PartitionIndex: p, ErrorCode: code})
+	}
+	return tr
+}
+
+// answerableWithoutBody tells whether a request whose body could not be
+// read can still be answered with an error. That takes a top-level error
+// code: an API that reports errors per resource would answer with an empty
+// response, which the client would take for a success.
+func answerableWithoutBody(apiKey int16) bool {
+	_, perResource := requestErrorBodies[apiKey]
+	_, topLevel := emptyBodies[apiKey]
+	return topLevel || !perResource
+}
+
+// errorResponse answers a request that could not be served with a response
+// carrying code. APIs that report errors per resource have code set on each
+// of the resources the request names; the others have it set on their
+// top-level error. A request whose body was not read, such as one of a
+// version the broker does not serve, gets the top-level error where the API
+// has one and no resources otherwise. Unknown APIs get a throttle time and
+// an error code, which is how every recent API response starts.
+//
+// The header and body are both written in the requested version, or the
+// closest supported one when it is not served. ApiVersions falls back to
+// version 0 instead, which every client can read.
+func errorResponse(rh *request.RequestHeaderV2, req request.RequestBody, code int16) *response.Response {
+	version := errorResponseVersion(rh.RequestApiKey, rh.RequestApiVersion)
+	var body response.ResponseBody
+	build, perResource := requestErrorBodies[rh.RequestApiKey]
+	empty, topLevel := emptyBodies[rh.RequestApiKey]
+	switch {
+	case perResource && req != nil:
+		body = build(req, code)
+	case topLevel:
+	case perResource:
+		body = build(nil, code)
+	}
+	if body == nil {
+		b := response.ErrorCoded(&response.ErrorOnlyV0{})
+		if topLevel {
+			b = empty(version)
+		}
+		b.SetErrorCode(code)
+		body = b
+	}
+	return &response.Response{
+		Header: response.NewResponseHeader(rh.RequestApiKey, version, rh.CorrelationId),
+		Body:   body,
+	}
+}
+
+// errorResponseVersion returns the version an error response to a request
+// of the given API and version is written in.
+func errorResponseVersion(apiKey, version int16) int16 {
+	r, ok := supportedRange(apiKey)
+	switch {
+	case !ok || supported(apiKey, version):
+		return version
+	case apiKey == constant.ApiVersions:
+		return 0
+	}
+	return min(max(version, r.min), r.max)
+}
+
+func toNullableString(s string) *types.CompactString {
+	cs := types.CompactString(s)
+	return &cs
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"slices"
+	"testing"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// encodeRequest returns a size-prefixed request with the given header and
+// an empty body.
+func encodeRequest(apiKey, version int16, correlationId int32) []byte {
+	rh := &request.RequestHeaderV2{
+		RequestApiKey:     apiKey,
+		RequestApiVersion: version,
+		CorrelationId:     correlationId,
+		ClientId:          types.NullableString{Length: 4, Data: "test"},
+	}
+	header := rh.WriteRequestHeader()
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(header))), header...)
+}
+
+func TestUnsupportedVersions(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	for i, r := range supportedVersions {
+		version := r.max + 1
+		if r.min > 0 {
+			version = r.min - 1
+		}
+		t.Run(apiKeyName(r.apiKey), func(t *testing.T) {
+			correlationId := int32(100 + i)
+			req, errorCode, err := readRequest(log, encodeRequest(r.apiKey, version, correlationId))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rh := req.Header.(*request.RequestHeaderV2)
+
+			var res *response.Response
+			if r.apiKey == constant.ApiVersions {
+				// answered by its handler rather than with an error response
+				if errorCode != 0 {
+					t.Fatalf("error code %d, want the request to be handled", errorCode)
+				}
+				res = &response.Response{Header: &response.ResponseHeaderV0{CorrelationId: correlationId}, Body: handleApiVersions(rh)}
+			} else {
+				if errorCode != constant.UNSUPPORTED_VERSION {
+					t.Fatalf("error code %d, want %d", errorCode, constant.UNSUPPORTED_VERSION)
+				}
+				res = errorResponse(rh, req.Body, errorCode)
+			}
+			message, err := res.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if _, err := message.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+
+			// the client reads the reply in the version the broker answers in
+			replyVersion := errorResponseVersion(r.apiKey, version)
+			if replyVersion < r.min || replyVersion > r.max {
+				t.Fatalf("reply version %d is not supported, want %d to %d", replyVersion, r.min, r.max)
+			}
+			reader := bytes.NewReader(buf.Bytes()[4:])
+			header, err := response.ReadResponseHeader(reader, r.apiKey, replyVersion)
+			if err != nil {
+				t.Fatalf("reading header: %v", err)
+			}
+			body, err := response.ParseResponseBody(r.apiKey, replyVersion, reader)
+			if err != nil {
+				t.Fatalf("reading body: %v", err)
+			}
+			if reader.Len() > 0 {
+				t.Errorf("%d bytes left after the body", reader.Len())
+			}
+			var got int32
+			switch h := header.(type) {
+			case *response.ResponseHeaderV0:
+				got = h.CorrelationId
+			case *response.ResponseHeaderV1:
+				got = h.CorrelationId
+			}
+			if got != correlationId {
+				t.Errorf("correlation id %d, want %d", got, correlationId)
+			}
+
+			// the decoded reply is what was sent
+			var reencoded bytes.Buffer
+			if err := body.Write(&reencoded); err != nil {
+				t.Fatal(err)
+			}
+			sent := buf.Bytes()[len(buf.Bytes())-reencoded.Len():]
+			if !bytes.Equal(reencoded.Bytes(), sent) {
+				t.Errorf("body decodes to %x, sent %x", reencoded.Bytes(), sent)
+			}
+
+			reporter, ok := body.(response.ErrorReporter)
+			if !ok {
+				t.Fatalf("%T does not report its error codes", body)
+			}
+			want := []int16(nil)
+			if _, topLevel := emptyBodies[r.apiKey]; topLevel {
+				want = []int16{constant.UNSUPPORTED_VERSION}
+			}
+			if got := reporter.ErrorCodes(); !slices.Equal(got, want) {
+				t.Errorf("error codes %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestErrorResponsePerResource(t *testing.T) {
+	rh := &request.RequestHeaderV2{RequestApiKey: constant.Metadata, RequestApiVersion: 12, CorrelationId: 1}
+	name := types.CompactString("t1")
+	req := &request.MetadataV12{Topics: []request.MetadataTopic{{Name: &name}}}
+
+	res := errorResponse(rh, req, constant.UNKNOWN_SERVER_ERROR)
+	body, ok := res.Body.(*response.MetadataV12)
+	if !ok {
+		t.Fatalf("body is %T, want *response.MetadataV12", res.Body)
+	}
+	if len(body.Topics) != 1 || body.Topics[0].ErrorCode != constant.UNKNOWN_SERVER_ERROR || *body.Topics[0].Name != name {
+		t.Errorf("topics %+v, want t1 with error %d", body.Topics, constant.UNKNOWN_SERVER_ERROR)
+	}
+	if codes := body.ErrorCodes(); !slices.Equal(codes, []int16{constant.UNKNOWN_SERVER_ERROR}) {
+		t.Errorf("error codes %v, want one %d", codes, constant.UNKNOWN_SERVER_ERROR)
+	}
+
+	res = errorResponse(rh, nil, constant.UNSUPPORTED_VERSION)
+	if body, ok := res.Body.(*response.MetadataV12); !ok || len(body.Topics) != 0 {
+		t.Errorf("body without a request is %+v, want a metadata response without topics", res.Body)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+)
+
+const readCommitted int8 = 1
+
+// notifier wakes up fetches waiting for new data.
+type notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+var appendNotifier = &notifier{ch: make(chan struct{})}
+
+// wait returns a channel closed on the next notify.
+func (n *notifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ch
+}
+
+func (n *notifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.ch)
+	n.ch = make(chan struct{})
+}
+
+// handleFetch serves a sessionless fetch, waiting up to MaxWaitMs for
+// MinBytes to become available. It also returns how long it waited.
+func handleFetch(s session, rb *request.FetchV12) (*response.FetchV12, time.Duration) {
+	var waited time.Duration
+	deadline := time.Now().Add(time.Duration(rb.MaxWaitMs) * time.Millisecond)
+	for {
+		// subscribe before reading so an append in between is not missed
+		appended := appendNotifier.wait()
+		res, size := fetchOnce(s, rb)
+		remaining := time.Until(deadline)
+		if size >= int(rb.MinBytes) || remaining <= 0 || res.ErrorCode != constant.NONE {
+			observeFetched(res)
+			return res, waited
+		}
+		// the records are read again once there may be more of them
+		res.CloseFileRecords()
+		waitStart := time.Now()
+		select {
+		case <-appended:
+		case <-time.After(remaining):
+		}
+		waited += time.Since(waitStart)
+	}
+}
+
+func fetchOnce(s session, rb *request.FetchV12) (*response.FetchV12, int) {
+	res := &response.FetchV12{
+		Version:   rb.Version,
+		Responses: make([]response.FetchTopicResponse, len(rb.Topics)),
+	}
+	if rb.SessionId != 0 || rb.SessionEpoch > 0 {
+		res.ErrorCode = constant.FETCH_SESSION_ID_NOT_FOUND
+		res.Responses = nil
+		return res, 0
+	}
+
+	size := 0
+	for i, ft := range rb.Topics {
+		tr := &res.Responses[i]
+		tr.Topic = ft.Topic
+		tr.TopicId = ft.TopicId
+		tr.Partitions = make([]response.FetchPartitionResponse, len(ft.Partitions))
+
+		var topic metadata.Topic
+		var topicExists bool
+		if rb.Version >= 13 {
+			topic, topicExists = cluster.TopicById(ft.TopicId)
+		} else {
+			topic, topicExists = cluster.TopicByName(string(ft.Topic))
+		}
+		// topics fetched by name are checked whether they exist or not, so
+		// that unauthorized clients can't tell
+		name := string(ft.Topic)
+		if topicExists {
+			name = topic.Name
+		}
+		authorized := s.authorized(acl.OperationRead, acl.ResourceTopic, name)
+		for j, fp := range ft.Partitions {
+			pr := &tr.Partitions[j]
+			pr.PartitionIndex = fp.Partition
+			pr.HighWatermark = -1
+			pr.LastStableOffset = -1
+			pr.LogStartOffset = -1
+			pr.PreferredReadReplica = -1
+			switch {
+			case !topicExists && rb.Version >= 13:
+				pr.ErrorCode = constant.UNKNOWN_TOPIC_ID
+				continue
+			case !authorized:
+				pr.ErrorCode = constant.TOPIC_AUTHORIZATION_FAILED
+				continue
+			case !topicExists || fp.Partition < 0 || int(fp.Partition) >= len(topic.Partitions):
+				pr.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+				continue
+			}
+
+			maxBytes := min(int(fp.PartitionMaxBytes), int(rb.MaxBytes)-size)
+			if err := readPartition(topic.Name, fp, rb.IsolationLevel, max(maxBytes, 0), pr); err != nil {
+				slog.Error("Failed to fetch", "topic", topic.Name, "partition", fp.Partition, "error", err)
+				pr.ErrorCode = storage.ErrorCode(err)
+			}
+			size += pr.RecordsSize()
+		}
+	}
+	return res, size
+}
+
+// readPartition reads the records of one partition up to the high watermark,
+// or up to the last stable offset for read_committed consumers, who also get
+// the aborted transactions to skip. The records are not read into memory but
+// referenced as regions of the segment files, which are sent from there.
+func readPartition(topicName string, fp request.FetchPartition, isolationLevel int8, maxBytes int, pr *response.FetchPartitionResponse) error {
+	log, err := logManager.GetOrCreateLog(topicName, fp.Partition)
+	if err != nil {
+		return err
+	}
+	pr.HighWatermark = log.HighWatermark()
+	pr.LastStableOffset = log.LastStableOffset()
+	pr.LogStartOffset = log.LogStartOffset()
+
+	maxOffset := pr.HighWatermark
+	if isolationLevel == readCommitted {
+		maxOffset = pr.LastStableOffset
+	}
+	pr.Records = []byte{}
+	if maxBytes == 0 {
+		return nil
+	}
+	regions, err := log.ReadRegions(fp.FetchOffset, maxBytes, maxOffset)
+	if err != nil {
+		if errors.Is(err, storage.ErrOffsetOutOfRange) {
+			pr.ErrorCode = constant.OFFSET_OUT_OF_RANGE
+			return nil
+		}
+		return err
+	}
+	pr.FileRecords = regions
+
+	if isolationLevel == readCommitted {
+		pr.AbortedTransactions = []response.AbortedTransaction{}
+		for _, txn := range log.AbortedTransactions(fp.FetchOffset, maxOffset) {
+			pr.AbortedTransactions = append(pr.AbortedTransactions, response.AbortedTransaction{
+				ProducerId:  txn.ProducerId,
+				FirstOffset: txn.FirstOffset,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+func handleConsumerGroupHeartbeat(s session, clientId, clientHost string, rb *request.ConsumerGroupHeartbeatV0) *response.ConsumerGroupHeartbeatV0 {
+	if !s.authorized(acl.OperationRead, acl.ResourceGroup, string(rb.GroupId)) {
+		return &response.ConsumerGroupHeartbeatV0{ErrorCode: constant.GROUP_AUTHORIZATION_FAILED}
+	}
+	for _, topic := range rb.SubscribedTopicNames {
+		if !s.authorized(acl.OperationDescribe, acl.ResourceTopic, string(topic)) {
+			return &response.ConsumerGroupHeartbeatV0{ErrorCode: constant.TOPIC_AUTHORIZATION_FAILED}
+		}
+	}
+	return groupCoordinator.ConsumerGroupHeartbeat(clientId, clientHost, rb)
+}
+
+func handleConsumerGroupDescribe(s session, rb *request.ConsumerGroupDescribeV0) *response.ConsumerGroupDescribeV0 {
+	allowed := make([]bool, len(rb.GroupIds))
+	authorized := *rb
+	authorized.GroupIds = nil
+	for i, id := range rb.GroupIds {
+		if allowed[i] = s.authorized(acl.OperationDescribe, acl.ResourceGroup, string(id)); allowed[i] {
+			authorized.GroupIds = append(authorized.GroupIds, id)
+		}
+	}
+	described := groupCoordinator.ConsumerGroupDescribe(&authorized).Groups
+	res := &response.ConsumerGroupDescribeV0{Groups: make([]response.DescribedGroup, len(rb.GroupIds))}
+	for i, id := range rb.GroupIds {
+		if !allowed[i] {
+			res.Groups[i] = response.DescribedGroup{
+				ErrorCode:            constant.GROUP_AUTHORIZATION_FAILED,
+				GroupId:              id,
+				Members:              []response.Member{},
+				AuthorizedOperations: authorizedOperationsOmitted,
+			}
+			continue
+		}
+		res.Groups[i] = described[0]
+		described = described[1:]
+		if rb.IncludeAuthorizedOperations && res.Groups[i].ErrorCode == constant.NONE {
+			res.Groups[i].AuthorizedOperations = authorizer.AuthorizedOperations(s.principal, s.host, acl.ResourceGroup, string(id))
+		}
+	}
+	return res
+}
+
+// handleListGroups lists every group to those who may describe the
+// cluster, and otherwise the groups s may describe.
+func handleListGroups(s session, rb *request.ListGroupsV5) *response.ListGroupsV5 {
+	res := groupCoordinator.ListGroups(rb)
+	if s.clusterAuthorized(acl.OperationDescribe) {
+		return res
+	}
+	listed := res.Groups[:0]
+	for _, g := range res.Groups {
+		if s.authorized(acl.OperationDescribe, acl.ResourceGroup, string(g.GroupId)) {
+			listed = append(listed, g)
+		}
+	}
+	res.Groups = listed
+	return res
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+)
+
+// handleListOffsets looks up the offset of each partition for a timestamp,
+// or for one of the special timestamps of request.ListOffsetsPartition.
+// read_committed consumers get the last stable offset as the latest.
+func handleListOffsets(s session, rb *request.ListOffsetsV6) *response.ListOffsetsV6 {
+	res := &response.ListOffsetsV6{Topics: make([]response.ListOffsetsTopic, len(rb.Topics))}
+	for i, t := range rb.Topics {
+		res.Topics[i].Name = t.Name
+		res.Topics[i].Partitions = make([]response.ListOffsetsPartition, len(t.Partitions))
+		authorized := s.authorized(acl.OperationDescribe, acl.ResourceTopic, string(t.Name))
+		topic, ok := cluster.TopicByName(string(t.Name))
+		for j, p := range t.Partitions {
+			pr := &res.Topics[i].Partitions[j]
+			*pr = response.ListOffsetsPartition{PartitionIndex: p.PartitionIndex, Timestamp: -1, Offset: -1, LeaderEpoch: -1}
+			switch {
+			case !authorized:
+				pr.ErrorCode = constant.TOPIC_AUTHORIZATION_FAILED
+				continue
+			case !ok || p.PartitionIndex < 0 || int(p.PartitionIndex) >= len(topic.Partitions):
+				pr.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+				continue
+			}
+			if err := listOffset(topic, p, rb.IsolationLevel, pr); err != nil {
+				slog.Error("Failed to list offsets", "topic", t.Name, "partition", p.PartitionIndex, "error", err)
+				pr.ErrorCode = storage.ErrorCode(err)
+			}
+		}
+	}
+	return res
+}
+
+func listOffset(topic metadata.Topic, p request.ListOffsetsPartition, isolationLevel int8, pr *response.ListOffsetsPartition) error {
+	leaderEpoch := topic.Partitions[p.PartitionIndex].LeaderEpoch
+	switch {
+	case p.CurrentLeaderEpoch < 0:
+	case p.CurrentLeaderEpoch < leaderEpoch:
+		pr.ErrorCode = constant.FENCED_LEADER_EPOCH
+		return nil
+	case p.CurrentLeaderEpoch > leaderEpoch:
+		pr.ErrorCode = constant.UNKNOWN_LEADER_EPOCH
+		return nil
+	}
+
+	log, err := logManager.GetOrCreateLog(topic.Name, p.PartitionIndex)
+	if err != nil {
+		return err
+	}
+	offset, timestamp := int64(-1), int64(-1)
+	switch p.Timestamp {
+	case request.LatestTimestamp:
+		offset = log.HighWatermark()
+		if isolationLevel == readCommitted {
+			offset = log.LastStableOffset()
+		}
+	case request.EarliestTimestamp, request.EarliestLocalTimestamp:
+		offset = log.LogStartOffset()
+	case request.MaxTimestamp:
+		offset, timestamp, err = log.OffsetOfMaxTimestamp()
+	default:
+		offset, timestamp, err = log.OffsetForTimestamp(p.Timestamp)
+	}
+	if err != nil {
+		return err
+	}
+	// records found by timestamp must be visible to read_committed consumers
+	if timestamp >= 0 && isolationLevel == readCommitted && offset >= log.LastStableOffset() {
+		offset, timestamp = -1, -1
+	}
+	pr.Offset = offset
+	pr.Timestamp = timestamp
+	if offset >= 0 {
+		pr.LeaderEpoch = leaderEpoch
+	}
+	return nil
+}
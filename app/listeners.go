@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+	"github.com/codecrafters-io/kafka-starter-go/internal/listener"
+	"github.com/codecrafters-io/kafka-starter-go/internal/sasl"
+)
+
+// tlsHandshakeTimeout bounds the TLS handshake of new connections
+const tlsHandshakeTimeout = 10 * time.Second
+
+var principalMapper *listener.PrincipalMapper
+
+// newListeners builds the listeners of the broker configuration, which
+// can only be set statically.
+func newListeners(static config.Static) ([]*listener.Listener, error) {
+	get := static.Get
+	listeners, err := listener.Parse(get("listeners"), get("listener.security.protocol.map"))
+	if err != nil {
+		return nil, err
+	}
+	if err := listener.Advertise(listeners, get("advertised.listeners")); err != nil {
+		return nil, err
+	}
+	for _, l := range listeners {
+		if !l.Secure() {
+			continue
+		}
+		l.TLS, err = listener.NewTLSConfig(get("ssl.keystore.location"), get("ssl.truststore.location"), get("ssl.client.auth"))
+		if err != nil {
+			return nil, fmt.Errorf("error configuring listener %s: %s", l.Name, err)
+		}
+	}
+	if principalMapper, err = listener.ParsePrincipalMappingRules(get("ssl.principal.mapping.rules")); err != nil {
+		return nil, err
+	}
+	return listeners, nil
+}
+
+// accept serves the connections of a listener until it is closed, or
+// reports why it failed on errs. Connections are only accepted within the
+// connection limits.
+func accept(ln net.Listener, l *listener.Listener, errs chan<- error) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				errs <- fmt.Errorf("listener %s: %s", l.Name, err)
+			}
+			return
+		}
+		if err := conns.add(conn, l); err != nil {
+			if err == errTooManyForIP {
+				slog.Warn("Rejected connection", "listener", l.Name, "remote", conn.RemoteAddr().String(), "error", err)
+				connectionsRejected.Inc(l.Name)
+			}
+			conn.Close()
+			continue
+		}
+
+		go handleRequest(conn, l)
+	}
+}
+
+// newAuthenticator completes the TLS handshake of secure connections and
+// returns the authenticator of the connection. Clients of SASL listeners
+// still have to authenticate; on other listeners the principal comes from
+// the client certificate, if any.
+func newAuthenticator(conn net.Conn, l *listener.Listener) (*sasl.Authenticator, error) {
+	principal := sasl.AnonymousPrincipal
+	if tc, ok := conn.(*tls.Conn); ok {
+		tc.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+		if err := tc.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %s", err)
+		}
+		tc.SetDeadline(time.Time{})
+		if certs := tc.ConnectionState().PeerCertificates; len(certs) > 0 {
+			principal = "User:" + principalMapper.Principal(certs[0].Subject.String())
+		}
+	}
+	if l.Sasl() {
+		mechanisms, _ := configs.Get("", "sasl.enabled.mechanisms")
+		return sasl.NewAuthenticator(credentials, config.SplitList(mechanisms)), nil
+	}
+	return sasl.Authenticated(principal), nil
+}
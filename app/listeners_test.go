@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/listener"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/sasl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// selfSigned generates a self-signed certificate for subject, trusted by
+// the returned pool.
+func selfSigned(t *testing.T, subject pkix.Name) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               subject,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, pool
+}
+
+// authenticate connects a client with the given certificates to an SSL
+// listener and returns the authenticator of the server side.
+func authenticate(t *testing.T, clientCerts []tls.Certificate) *sasl.Authenticator {
+	t.Helper()
+	serverCert, serverPool := selfSigned(t, pkix.Name{CommonName: "broker"})
+	clientCert, clientPool := selfSigned(t, pkix.Name{CommonName: "alice", Organization: []string{"Example"}})
+	if clientCerts == nil {
+		clientCerts = []tls.Certificate{clientCert}
+	}
+	l := &listener.Listener{Name: "SSL", SecurityProtocol: listener.Ssl, TLS: &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    clientPool,
+	}}
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	go tls.Client(clientConn, &tls.Config{RootCAs: serverPool, ServerName: "127.0.0.1", Certificates: clientCerts}).Handshake()
+
+	auth, err := newAuthenticator(tls.Server(serverConn, l.TLS), l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth
+}
+
+// setPrincipalMappingRules sets the principal mapper as the broker does for
+// the given rules, and restores the previous one when the test ends.
+func setPrincipalMappingRules(t *testing.T, rules string) {
+	t.Helper()
+	saved := principalMapper
+	t.Cleanup(func() { principalMapper = saved })
+	var err error
+	if principalMapper, err = listener.ParsePrincipalMappingRules(rules); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificatePrincipalMapping(t *testing.T) {
+	tests := []struct {
+		rules string
+		want  string
+	}{
+		{"DEFAULT", "User:CN=alice,O=Example"},
+		{"RULE:^CN=(.*?),O=(.*)$/$1/", "User:alice"},
+		{"RULE:^CN=(.*?),O=(.*)$/$1@$2/U", "User:ALICE@EXAMPLE"},
+		{"RULE:^CN=bob,.*$/bob/,DEFAULT", "User:CN=alice,O=Example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rules, func(t *testing.T) {
+			setPrincipalMappingRules(t, tt.rules)
+			if got := authenticate(t, nil).Principal(); got != tt.want {
+				t.Errorf("principal = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymousWithoutCertificate(t *testing.T) {
+	setPrincipalMappingRules(t, "DEFAULT")
+	if got := authenticate(t, []tls.Certificate{}).Principal(); got != sasl.AnonymousPrincipal {
+		t.Errorf("principal = %q, want %q", got, sasl.AnonymousPrincipal)
+	}
+}
+
+func TestRequestBeforeSaslAuthentication(t *testing.T) {
+	startTestBroker(t, nil)
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go handleRequest(serverConn, &listener.Listener{Name: "SASL", SecurityProtocol: listener.SaslPlaintext})
+
+	message, err := request.Request{
+		Header: &request.RequestHeaderV2{
+			RequestApiKey:     constant.ListGroups,
+			RequestApiVersion: 5,
+			CorrelationId:     7,
+			ClientId:          types.NullableString{Length: 4, Data: "test"},
+		},
+		Body: &request.ListGroupsV5{},
+	}.MarshallRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := clientConn.Write(message); err != nil {
+		t.Fatal(err)
+	}
+
+	sizeBuf := make([]byte, 4)
+	if _, err := io.ReadFull(clientConn, sizeBuf); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(sizeBuf))
+	if _, err := io.ReadFull(clientConn, buf); err != nil {
+		t.Fatal(err)
+	}
+	// correlation id and the tagged fields of a flexible header
+	if correlationId := int32(binary.BigEndian.Uint32(buf)); correlationId != 7 {
+		t.Errorf("correlation id %d, want 7", correlationId)
+	}
+	body, err := response.ParseResponseBody(constant.ListGroups, 5, bytes.NewReader(buf[5:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := body.(*response.ListGroupsV5).ErrorCode; code != constant.ILLEGAL_SASL_STATE {
+		t.Errorf("error %d, want %d", code, constant.ILLEGAL_SASL_STATE)
+	}
+
+	if _, err := clientConn.Read(sizeBuf); err != io.EOF {
+		t.Errorf("read %v after the response, want the connection closed", err)
+	}
+}
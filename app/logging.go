@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// logLevel is the level of the broker logger, which can be changed at
+// runtime through broker.log.level.
+var logLevel = new(slog.LevelVar)
+
+// setupLogging makes the default logger write in the configured format.
+func setupLogging(format string) {
+	opts := &slog.HandlerOptions{Level: logLevel}
+	var h slog.Handler = slog.NewTextHandler(os.Stdout, opts)
+	if format == "json" {
+		h = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	slog.SetDefault(slog.New(h))
+}
+
+// requestLog tells which API keys have their requests and responses
+// logged, as set by request.log.api.keys.
+var requestLog struct {
+	mu   sync.RWMutex
+	all  bool
+	keys map[int16]bool
+}
+
+func requestLogged(apiKey int16) bool {
+	requestLog.mu.RLock()
+	defer requestLog.mu.RUnlock()
+	return requestLog.all || requestLog.keys[apiKey]
+}
+
+// reconfigureLogging applies the logging configurations of the broker,
+// which may be changed dynamically.
+func reconfigureLogging(topic string) {
+	if topic != "" {
+		return
+	}
+	level, _ := configs.Get("", "broker.log.level")
+	logLevel.UnmarshalText([]byte(level))
+
+	apiKeys, _ := configs.Get("", "request.log.api.keys")
+	requestLog.mu.Lock()
+	defer requestLog.mu.Unlock()
+	requestLog.all = false
+	requestLog.keys = make(map[int16]bool)
+	for _, item := range config.SplitList(apiKeys) {
+		if item == "*" {
+			requestLog.all = true
+		} else if key, ok := config.ParseApiKey(item); ok {
+			requestLog.keys[key] = true
+		}
+	}
+}
+
+func apiKeyName(apiKey int16) string {
+	if name, ok := constant.ApiKeyNames[apiKey]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%d)", apiKey)
+}
+
+// redacted logs a request or response as JSON without its byte fields,
+// which hold records, SASL tokens and salted passwords; only their length
+// is kept. Records left in segment files are logged the same way.
+type redacted struct {
+	v any
+}
+
+func (r redacted) LogValue() slog.Value {
+	b, err := json.Marshal(redact(reflect.ValueOf(r.v)))
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+	return slog.StringValue(string(b))
+}
+
+var (
+	uuidType       = reflect.TypeOf([16]byte{})
+	fileRegionType = reflect.TypeOf(types.FileRegion{})
+)
+
+func redact(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type() == uuidType {
+		id := v.Interface().([16]byte)
+		return base64.RawURLEncoding.EncodeToString(id[:])
+	}
+	if v.Type() == fileRegionType {
+		return fmt.Sprintf("[%d bytes redacted]", v.Interface().(types.FileRegion).Size)
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("[%d bytes redacted]", v.Len())
+		}
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = redact(v.Index(i))
+		}
+		return values
+	case reflect.Struct:
+		fields := make(map[string]any)
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() || f.Name == "TagBuffer" {
+				continue
+			}
+			fields[strings.ToLower(f.Name[:1])+f.Name[1:]] = redact(v.Field(i))
+		}
+		return fields
+	}
+	return v.Interface()
+}
+
+// logRequest logs a served request at debug level, or at info level with
+// its redacted payloads when its API key is in the request log. The
+// response is nil when the client does not expect one.
+func logRequest(log *slog.Logger, s session, rh *request.RequestHeaderV2, req, res any, requestBytes, responseBytes int, start time.Time, throttle time.Duration) {
+	level := slog.LevelDebug
+	logged := requestLogged(rh.RequestApiKey)
+	if logged {
+		level = slog.LevelInfo
+	}
+	ctx := context.Background()
+	if !log.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("clientId", rh.ClientId.Data),
+		slog.String("apiKey", apiKeyName(rh.RequestApiKey)),
+		slog.Int("apiVersion", int(rh.RequestApiVersion)),
+		slog.Int("correlationId", int(rh.CorrelationId)),
+		slog.String("principal", s.principal),
+		slog.Int("requestBytes", requestBytes),
+		slog.Int("responseBytes", responseBytes),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if throttle > 0 {
+		attrs = append(attrs, slog.Duration("throttle", throttle))
+	}
+	if logged {
+		attrs = append(attrs, slog.Any("request", redacted{req}))
+		if res != nil {
+			attrs = append(attrs, slog.Any("response", redacted{res}))
+		}
+	}
+	log.LogAttrs(ctx, level, "Request", attrs...)
+}
@@ -1,125 +1,723 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
 	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/group"
+	"github.com/codecrafters-io/kafka-starter-go/internal/listener"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/producer"
+	"github.com/codecrafters-io/kafka-starter-go/internal/quota"
 	"github.com/codecrafters-io/kafka-starter-go/internal/request"
 	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/sasl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/txn"
+)
+
+var (
+	cluster          *metadata.Cluster
+	clusterId        string
+	groupCoordinator *group.Coordinator
+	logManager       *storage.LogManager
+	metadataLog      *metadata.Log
+	producerIds      *producer.IdManager
+	txnCoordinator   *txn.Coordinator
+	configs          *config.Registry
+	credentials      *sasl.CredentialStore
+	authorizer       *acl.Authorizer
+	quotas           *quota.Manager
+
+	// maxRequestSize bounds the size prefix of incoming requests
+	maxRequestSize uint32
 )
 
 func main() {
+	setupLogging("text")
+	var overrides [][2]string
+	flag.Func("override", "set a broker configuration, as name=value; may be repeated", func(s string) error {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("expected name=value")
+		}
+		overrides = append(overrides, [2]string{name, value})
+		return nil
+	})
+	for _, f := range []struct{ flag, name string }{
+		{"node-id", "node.id"},
+		{"listeners", "listeners"},
+		{"advertised-listeners", "advertised.listeners"},
+		{"log-dirs", "log.dirs"},
+		{"num-partitions", "num.partitions"},
+		{"default-replication-factor", "default.replication.factor"},
+		{"message-max-bytes", "message.max.bytes"},
+	} {
+		flag.Func(f.flag, "set "+f.name+"; overrides the configuration file", func(value string) error {
+			overrides = append(overrides, [2]string{f.name, value})
+			return nil
+		})
+	}
+	clusterIdFlag := flag.String("cluster-id", "", "the cluster id a new log directory is formatted for; a random one by default")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: broker [flags] [server.properties]")
+		flag.PrintDefaults()
+	}
+	// flags may also follow the configuration file, as with Kafka
+	flag.Parse()
+	path := flag.Arg(0)
+	if flag.NArg() > 0 {
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	l, err := net.Listen("tcp", "0.0.0.0:9092")
+	static, unknown, err := config.LoadStatic(path)
 	if err != nil {
-		fmt.Println("Failed to bind to port 9092")
+		slog.Error("Failed to load configuration", "error", err)
 		os.Exit(1)
 	}
-
-	for {
-		conn, err := l.Accept()
-		if err != nil {
-			fmt.Println("Error accepting connection: ", err.Error())
+	for _, name := range unknown {
+		slog.Warn("Ignoring unknown configuration", "name", name)
+	}
+	for _, o := range overrides {
+		if err := static.Set(o[0], o[1]); err != nil {
+			slog.Error("Invalid configuration override", "error", err)
 			os.Exit(1)
 		}
+	}
+	setupLogging(static.Get("broker.log.format"))
+	listeners, err := newListeners(static)
+	if err != nil {
+		slog.Error("Invalid listener configuration", "error", err)
+		os.Exit(1)
+	}
+	sockets := make([]net.Listener, len(listeners))
+	for i, l := range listeners {
+		if sockets[i], err = l.Listen(); err != nil {
+			slog.Error("Failed to bind listener", "listener", l.Name, "address", l.Address, "error", err)
+			os.Exit(1)
+		}
+	}
+	var metricsSocket net.Listener
+	if addr := static.Get("metrics.listener"); addr != "" {
+		if metricsSocket, err = net.Listen("tcp", addr); err != nil {
+			slog.Error("Failed to bind metrics listener", "address", addr, "error", err)
+			os.Exit(1)
+		}
+	}
+	maxRequestSize = uint32(static.Int("socket.request.max.bytes"))
+	cluster = metadata.NewCluster(static.NodeId())
+	groupCoordinator = group.NewCoordinator(cluster)
 
-		go handleRequest(conn)
+	logManager, err = storage.NewLogManager(static.LogDir(), storage.DefaultLogConfig)
+	if err != nil {
+		slog.Error("Failed to open log directory", "error", err)
+		os.Exit(1)
+	}
+	clusterId, err = checkMetaProperties(logManager.Dir, cluster.NodeId, *clusterIdFlag)
+	if err != nil {
+		slog.Error("Invalid log directory", "error", err)
+		os.Exit(1)
+	}
+	metadataLog, err = metadata.OpenLog(logManager)
+	if err != nil {
+		slog.Error("Failed to open metadata log", "error", err)
+		os.Exit(1)
+	}
+	metadataLog.Register(cluster.Replay)
+	producerIds = producer.NewIdManager(cluster.NodeId, metadataLog)
+	configs = config.NewRegistry(static, cluster, metadataLog)
+	logManager.TopicConfig = configs.LogConfig
+	configs.Register(logManager.Reconfigure)
+	configs.Register(reconfigureLogging)
+	configs.Register(conns.reconfigure)
+	credentials = sasl.NewCredentialStore(metadataLog)
+	authorizer = newAuthorizer(metadataLog)
+	quotas = newQuotaManager(metadataLog)
+	if err := metadataLog.Replay(); err != nil {
+		slog.Error("Failed to replay metadata log", "error", err)
+		os.Exit(1)
+	}
+	reconfigureLogging("")
+	conns.reconfigure("")
+	txnCoordinator, err = txn.NewCoordinator(cluster, producerIds, logManager, writeTxnMarker)
+	if err != nil {
+		slog.Error("Failed to start transaction coordinator", "error", err)
+		os.Exit(1)
+	}
+	stop := make(chan struct{})
+	txnCoordinator.Start(stop)
+	logManager.Start(stop)
+	go conns.reapIdle(stop)
+
+	logStartup()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	acceptErrors := make(chan error, len(listeners))
+	for i, l := range listeners {
+		go accept(sockets[i], l, acceptErrors)
+	}
+	var metricsServer *http.Server
+	if metricsSocket != nil {
+		metricsServer = serveMetrics(metricsSocket)
+	}
+
+	status := 0
+	select {
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig.String())
+	case err := <-acceptErrors:
+		slog.Error("Failed to accept connections, shutting down", "error", err)
+		status = 1
+	}
+	go func() {
+		<-signals
+		slog.Warn("Received another signal, exiting without closing the logs")
+		os.Exit(1)
+	}()
+	for _, ln := range sockets {
+		ln.Close()
+	}
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+	if !conns.drain(shutdownTimeout) {
+		slog.Warn("Closed connections whose request did not finish in time", "timeout", shutdownTimeout)
+		status = 1
 	}
+	close(stop)
+	if err := logManager.Close(); err != nil {
+		slog.Error("Failed to close logs", "error", err)
+		status = 1
+	}
+	slog.Info("Shut down", "status", status)
+	os.Exit(status)
+}
+
+// logStartup logs the effective configuration of the broker, hiding the
+// values of sensitive configurations.
+func logStartup() {
+	attrs := make([]any, 0, len(configs.BrokerConfigs()))
+	for _, e := range configs.BrokerConfigs() {
+		value := e.Value
+		if e.Definition.Sensitive {
+			value = "[hidden]"
+		}
+		attrs = append(attrs, slog.String(e.Name, value))
+	}
+	slog.Info("Starting broker", "nodeId", cluster.NodeId, "clusterId", clusterId, slog.Group("config", attrs...))
 }
 
-func handleRequest(conn net.Conn) {
+func handleRequest(conn net.Conn, l *listener.Listener) {
 	defer conn.Close()
-	buffer := make([]byte, 1024)
+	defer conns.remove(conn)
+	log := slog.With("listener", l.Name, "remote", conn.RemoteAddr().String())
+	auth, err := newAuthenticator(conn, l)
+	if err != nil {
+		log.Warn("Closing connection", "error", err)
+		return
+	}
+	c := newClientConn(conn, l, auth, log)
+	written := make(chan struct{})
+	go func() {
+		c.writeResponses()
+		close(written)
+	}()
+	defer func() {
+		close(c.queue)
+		<-written
+	}()
+
+	clientHost, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	var last *pipelined
 	for {
-		n, err := conn.Read(buffer)
-		if err != nil {
-			fmt.Println("Error reading from connection: ", err.Error())
+		// requests are handled one at a time until the client is
+		// authenticated, as they change the state of the exchange
+		if last != nil && !auth.Complete() {
+			<-last.written
+		}
+		c.waitUnmuted()
+
+		sizeBuf := make([]byte, 4)
+		if _, err := io.ReadFull(conn, sizeBuf); err != nil {
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				log.Debug("Closing connection", "error", err)
+			}
+			return
+		}
+		if !conns.begin(conn) {
+			return
+		}
+		size := binary.BigEndian.Uint32(sizeBuf)
+		if size > maxRequestSize {
+			log.Warn("Closing connection, request is too large", "requestBytes", size, "max", maxRequestSize)
+			return
+		}
+		buffer := make([]byte, 4+size)
+		copy(buffer, sizeBuf)
+		if _, err := io.ReadFull(conn, buffer[4:]); err != nil {
+			log.Debug("Closing connection", "error", err)
 			return
 		}
 
-		fmt.Printf("Read %d bytes\n", n)
+		start := time.Now()
 
-		req, err := request.UnmarshallRequest(buffer[:n])
+		req, errorCode, err := readRequest(log, buffer)
 		if err != nil {
-			fmt.Printf("Error parsing request: %s", err.Error())
+			log.Warn("Closing connection, malformed request header", "requestBytes", size, "error", err)
 			return
 		}
-		rh, ok := req.Header.(*request.RequestHeaderV2)
-		if !ok {
-			fmt.Printf("Invalid request header type")
+		rh := req.Header.(*request.RequestHeaderV2)
+		if errorCode == constant.INVALID_REQUEST && !answerableWithoutBody(rh.RequestApiKey) {
+			log.Warn("Closing connection, request cannot be answered without its body", "apiKey", apiKeyName(rh.RequestApiKey),
+				"apiVersion", rh.RequestApiVersion)
 			return
 		}
 
-		reqJson, _ := json.MarshalIndent(*req, "", " ")
-		fmt.Println("Request json: ", string(reqJson))
-
-		// create response
-		var res response.Response
-		switch rh.RequestApiKey {
-		case constant.ApiVersions:
-			errorCode := constant.UNSUPPORTED_VERSION
-			if rh.RequestApiVersion >= 0 && rh.RequestApiVersion <= 4 {
-				errorCode = 0
-			}
-			res = response.Response{
-				Header: &response.ResponseHeaderV0{
-					CorrelationId: rh.CorrelationId,
-				},
-				Body: &response.APIVersionsResponseV4{
-					ErrorCode: errorCode,
-					ApiVersions: []response.APIVersion{
-						{
-							ApiKey:     constant.ApiVersions,
-							MinVersion: 0,
-							MaxVersion: 4,
-						},
-						{
-							ApiKey:     constant.DescribeTopicPartitions,
-							MinVersion: 0,
-							MaxVersion: 0,
-						},
-					},
-				},
-			}
-		case constant.DescribeTopicPartitions:
-			rb, ok := req.Body.(*request.DescribeTopicPartitionsV0)
-			if !ok {
-				fmt.Printf("Invalid request body type")
-				return
-			}
-			topics := make([]response.Topic, len(rb.Topics))
-			for i, topic := range rb.Topics {
-				topics[i].ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
-				topics[i].TopicName = topic.Name
-				topics[i].TopicId = [16]byte{}
-			}
-
-			res = response.Response{
-				Header: &response.ResponseHeaderV1{
-					CorrelationId: rh.CorrelationId,
-				},
-				Body: &response.DescribeTopicPartitionsV0{
-					Topics: topics,
-					NextCursor: response.Cursor{
-						TopicName: "first topic",
-					},
-				},
-			}
+		if !auth.Allowed(rh.RequestApiKey) {
+			log.Warn("API key is not allowed before SASL authentication", "apiKey", apiKeyName(rh.RequestApiKey))
+			errorCode = constant.ILLEGAL_SASL_STATE
 		}
+		conns.identify(conn, rh.ClientId.Data, auth.Principal())
 
-		resJson, _ := json.MarshalIndent(res, "", " ")
-		fmt.Println("Response json: ", string(resJson))
+		last = &pipelined{
+			session:   session{principal: auth.Principal(), host: clientHost},
+			header:    rh,
+			request:   req,
+			errorCode: errorCode,
+			size:      len(buffer),
+			start:     start,
+			handled:   make(chan struct{}),
+			written:   make(chan struct{}),
+		}
+		// blocks while max.in.flight requests await their response
+		c.queue <- last
+		c.schedule(last)
+	}
+}
 
-		respBytes := res.MarshallResponse()
-		n, err = conn.Write(respBytes)
+// readRequest decodes a size-prefixed request. The body of a request that
+// cannot be served is not read; the error code it is answered with is
+// returned instead. An error means the header could not be read.
+func readRequest(log *slog.Logger, buffer []byte) (*request.Request, int16, error) {
+	r := bytes.NewReader(buffer[4:])
+	rh, err := request.ReadRequestHeaderV4(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	req := &request.Request{MessageSize: int32(len(buffer) - 4), Header: rh}
+	var errorCode int16
+	switch {
+	case rh.RequestApiKey == constant.ApiVersions && !supported(rh.RequestApiKey, rh.RequestApiVersion):
+		// answered with the supported versions, whatever its body
+	case !supported(rh.RequestApiKey, rh.RequestApiVersion):
+		log.Debug("Unsupported request", "apiKey", apiKeyName(rh.RequestApiKey), "apiVersion", rh.RequestApiVersion)
+		errorCode = constant.UNSUPPORTED_VERSION
+	default:
+		body, err := request.ParseRequestBody(rh, r)
 		if err != nil {
-			fmt.Println("Error sending response payload: ", err.Error())
-			return
+			log.Warn("Invalid request", "apiKey", apiKeyName(rh.RequestApiKey), "apiVersion", rh.RequestApiVersion, "error", err)
+			errorCode = constant.INVALID_REQUEST
+			break
 		}
+		req.Body = body
+	}
+	return req, errorCode, nil
+}
 
-		fmt.Printf("Sent %d bytes\n", n)
+// handle serves one request. It returns no response for produce requests
+// with acks=0, whose producer does not wait for one, and errUnsupported
+// for the APIs it does not serve.
+func (c *clientConn) handle(p *pipelined) (*response.Response, error) {
+	s, rh, req := p.session, p.header, p.request
+	var res response.Response
+	switch rh.RequestApiKey {
+	case constant.ApiVersions:
+		res = response.Response{
+			Header: &response.ResponseHeaderV0{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleApiVersions(rh),
+		}
+	case constant.DescribeTopicPartitions:
+		rb, ok := req.Body.(*request.DescribeTopicPartitionsV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDescribeTopicPartitions(s, rb),
+		}
+	case constant.ConsumerGroupHeartbeat:
+		rb, ok := req.Body.(*request.ConsumerGroupHeartbeatV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleConsumerGroupHeartbeat(s, rh.ClientId.Data, "/"+s.host, rb),
+		}
+	case constant.ConsumerGroupDescribe:
+		rb, ok := req.Body.(*request.ConsumerGroupDescribeV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleConsumerGroupDescribe(s, rb),
+		}
+	case constant.InitProducerId:
+		rb, ok := req.Body.(*request.InitProducerIdV2)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleInitProducerId(s, rb),
+		}
+	case constant.Produce:
+		rb, ok := req.Body.(*request.ProduceV9)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		body := handleProduce(s, rb)
+		if rb.Acks == 0 {
+			// the producer does not wait for a response, but is still
+			// muted when over its quota
+			return nil, nil
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: body,
+		}
+	case constant.Fetch:
+		rb, ok := req.Body.(*request.FetchV12)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		body, waited := handleFetch(s, rb)
+		p.waited = waited
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: body,
+		}
+	case constant.AddPartitionsToTxn:
+		rb, ok := req.Body.(*request.AddPartitionsToTxnV3)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleAddPartitionsToTxn(s, rb),
+		}
+	case constant.AddOffsetsToTxn:
+		rb, ok := req.Body.(*request.AddOffsetsToTxnV3)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleAddOffsetsToTxn(s, rb),
+		}
+	case constant.EndTxn:
+		rb, ok := req.Body.(*request.EndTxnV3)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleEndTxn(s, rb),
+		}
+	case constant.WriteTxnMarkers:
+		rb, ok := req.Body.(*request.WriteTxnMarkersV1)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleWriteTxnMarkers(s, rb),
+		}
+	case constant.TxnOffsetCommit:
+		rb, ok := req.Body.(*request.TxnOffsetCommitV3)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleTxnOffsetCommit(s, rb),
+		}
+	case constant.DescribeProducers:
+		rb, ok := req.Body.(*request.DescribeProducersV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDescribeProducers(s, rb),
+		}
+	case constant.DescribeTransactions:
+		rb, ok := req.Body.(*request.DescribeTransactionsV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDescribeTransactions(s, rb),
+		}
+	case constant.ListTransactions:
+		rb, ok := req.Body.(*request.ListTransactionsV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleListTransactions(s, rb),
+		}
+	case constant.DescribeConfigs:
+		rb, ok := req.Body.(*request.DescribeConfigsV4)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDescribeConfigs(s, rb),
+		}
+	case constant.IncrementalAlterConfigs:
+		rb, ok := req.Body.(*request.IncrementalAlterConfigsV1)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleIncrementalAlterConfigs(s, rb),
+		}
+	case constant.SaslHandshake:
+		rb, ok := req.Body.(*request.SaslHandshakeV1)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV0{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: c.auth.Handshake(rb),
+		}
+	case constant.SaslAuthenticate:
+		rb, ok := req.Body.(*request.SaslAuthenticateV2)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: response.NewResponseHeader(rh.RequestApiKey, rh.RequestApiVersion, rh.CorrelationId),
+			Body:   c.auth.Authenticate(rb),
+		}
+	case constant.DescribeUserScramCredentials:
+		rb, ok := req.Body.(*request.DescribeUserScramCredentialsV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDescribeUserScramCredentials(s, rb),
+		}
+	case constant.AlterUserScramCredentials:
+		rb, ok := req.Body.(*request.AlterUserScramCredentialsV0)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleAlterUserScramCredentials(s, rb),
+		}
+	case constant.CreateAcls:
+		rb, ok := req.Body.(*request.CreateAclsV2)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleCreateAcls(s, rb),
+		}
+	case constant.DescribeAcls:
+		rb, ok := req.Body.(*request.DescribeAclsV2)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDescribeAcls(s, rb),
+		}
+	case constant.DeleteAcls:
+		rb, ok := req.Body.(*request.DeleteAclsV2)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDeleteAcls(s, rb),
+		}
+	case constant.Metadata:
+		rb, ok := req.Body.(*request.MetadataV12)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleMetadata(s, rb, c.listener, c.conn.LocalAddr()),
+		}
+	case constant.DescribeClientQuotas:
+		rb, ok := req.Body.(*request.DescribeClientQuotasV1)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDescribeClientQuotas(s, rb),
+		}
+	case constant.AlterClientQuotas:
+		rb, ok := req.Body.(*request.AlterClientQuotasV1)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleAlterClientQuotas(s, rb),
+		}
+	case constant.CreateTopics:
+		rb, ok := req.Body.(*request.CreateTopicsV7)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleCreateTopics(s, rb),
+		}
+	case constant.DeleteTopics:
+		rb, ok := req.Body.(*request.DeleteTopicsV6)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleDeleteTopics(s, rb),
+		}
+	case constant.ListOffsets:
+		rb, ok := req.Body.(*request.ListOffsetsV6)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleListOffsets(s, rb),
+		}
+	case constant.ListGroups:
+		rb, ok := req.Body.(*request.ListGroupsV5)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleListGroups(s, rb),
+		}
+	case constant.OffsetCommit:
+		rb, ok := req.Body.(*request.OffsetCommitV9)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleOffsetCommit(s, rb),
+		}
+	case constant.OffsetFetch:
+		rb, ok := req.Body.(*request.OffsetFetchV9)
+		if !ok {
+			return nil, errInvalidBody
+		}
+		res = response.Response{
+			Header: &response.ResponseHeaderV1{
+				CorrelationId: rh.CorrelationId,
+			},
+			Body: handleOffsetFetch(s, rb),
+		}
+	default:
+		return nil, errUnsupported
 	}
+
+	return &res, nil
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/producer"
+	"github.com/codecrafters-io/kafka-starter-go/internal/sasl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/txn"
+)
+
+// startTestBroker sets up the broker state as main does, over a temporary
+// log directory and the given configuration, and restores the previous
+// state when the test ends.
+func startTestBroker(t *testing.T, static config.Static) {
+	t.Helper()
+	savedCluster, savedLogManager, savedMetadataLog, savedProducerIds := cluster, logManager, metadataLog, producerIds
+	savedConfigs, savedCredentials, savedAuthorizer, savedQuotas, savedTxn := configs, credentials, authorizer, quotas, txnCoordinator
+	savedMaxRequestSize := maxRequestSize
+	t.Cleanup(func() {
+		cluster, logManager, metadataLog, producerIds = savedCluster, savedLogManager, savedMetadataLog, savedProducerIds
+		configs, credentials, authorizer, quotas, txnCoordinator = savedConfigs, savedCredentials, savedAuthorizer, savedQuotas, savedTxn
+		maxRequestSize = savedMaxRequestSize
+	})
+
+	if static == nil {
+		static = config.Static{}
+	}
+	maxRequestSize = uint32(static.Int("socket.request.max.bytes"))
+	cluster = metadata.NewCluster(static.NodeId())
+	var err error
+	if logManager, err = storage.NewLogManager(t.TempDir(), storage.DefaultLogConfig); err != nil {
+		t.Fatal(err)
+	}
+	logs := logManager
+	t.Cleanup(func() { logs.Close() })
+	if metadataLog, err = metadata.OpenLog(logManager); err != nil {
+		t.Fatal(err)
+	}
+	metadataLog.Register(cluster.Replay)
+	producerIds = producer.NewIdManager(cluster.NodeId, metadataLog)
+	configs = config.NewRegistry(static, cluster, metadataLog)
+	logManager.TopicConfig = configs.LogConfig
+	credentials = sasl.NewCredentialStore(metadataLog)
+	authorizer = newAuthorizer(metadataLog)
+	quotas = newQuotaManager(metadataLog)
+	if err := metadataLog.Replay(); err != nil {
+		t.Fatal(err)
+	}
+	if txnCoordinator, err = txn.NewCoordinator(cluster, producerIds, logManager, writeTxnMarker); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+)
+
+// metaPropertiesFile records which cluster and node a log directory
+// belongs to, as kafka-storage format writes it.
+const metaPropertiesFile = "meta.properties"
+
+// checkMetaProperties returns the id of the cluster the log directory
+// belongs to. A directory without meta.properties is formatted for
+// clusterId, or for a new cluster when clusterId is empty; one that was
+// formatted for another cluster or node is rejected.
+func checkMetaProperties(dir string, nodeId int32, clusterId string) (string, error) {
+	if clusterId != "" {
+		if id, err := base64.RawURLEncoding.DecodeString(clusterId); err != nil || len(id) != 16 {
+			return "", fmt.Errorf("cluster id %s is not a base64url encoded UUID", clusterId)
+		}
+	}
+	path := filepath.Join(dir, metaPropertiesFile)
+	props, err := config.LoadProperties(path)
+	if os.IsNotExist(err) {
+		props, err = make(map[string]string), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	updated := false
+
+	if v, ok := props["node.id"]; ok {
+		if stored, err := strconv.ParseInt(v, 10, 32); err != nil || int32(stored) != nodeId {
+			return "", fmt.Errorf("%s was written for node %s, not node %d", path, v, nodeId)
+		}
+	} else {
+		props["node.id"] = strconv.Itoa(int(nodeId))
+		updated = true
+	}
+	switch stored := props["cluster.id"]; {
+	case stored == "":
+		if clusterId == "" {
+			var id [16]byte
+			rand.Read(id[:])
+			clusterId = base64.RawURLEncoding.EncodeToString(id[:])
+		}
+		props["cluster.id"] = clusterId
+		updated = true
+	case clusterId != "" && stored != clusterId:
+		return "", fmt.Errorf("%s belongs to cluster %s, not cluster %s", path, stored, clusterId)
+	}
+	if _, ok := props["version"]; !ok {
+		props["version"] = "1"
+		updated = true
+	}
+
+	if updated {
+		var buf bytes.Buffer
+		config.WriteProperties(&buf, props)
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+			return "", err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return "", err
+		}
+	}
+	return props["cluster.id"], nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"cmp"
+	"net"
+	"slices"
+	"strconv"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/listener"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// handleMetadata describes this broker at the advertised address of the
+// listener the client connected to, or else at the address the client
+// reached it on, and the requested topics, or every topic s may describe
+// when none is requested. Topics are not created automatically.
+func handleMetadata(s session, rb *request.MetadataV12, l *listener.Listener, local net.Addr) *response.MetadataV12 {
+	endpoint := l.Advertised
+	if endpoint == "" {
+		endpoint = local.String()
+	}
+	host, portStr, _ := net.SplitHostPort(endpoint)
+	port, _ := strconv.Atoi(portStr)
+	res := &response.MetadataV12{
+		Brokers:      []response.MetadataBroker{{NodeId: cluster.NodeId, Host: types.CompactString(host), Port: int32(port)}},
+		ClusterId:    toNullableString(clusterId),
+		ControllerId: cluster.NodeId,
+		Topics:       []response.MetadataTopic{},
+	}
+	if rb.Topics == nil {
+		for _, t := range cluster.Topics() {
+			if s.authorized(acl.OperationDescribe, acl.ResourceTopic, t.Name) {
+				res.Topics = append(res.Topics, metadataTopic(s, rb, t))
+			}
+		}
+		return res
+	}
+	for _, rt := range rb.Topics {
+		var t metadata.Topic
+		var ok bool
+		errorTopic := response.MetadataTopic{Name: rt.Name, TopicId: rt.TopicId, TopicAuthorizedOperations: authorizedOperationsOmitted}
+		if rt.Name != nil {
+			t, ok = cluster.TopicByName(string(*rt.Name))
+			switch {
+			case !s.authorized(acl.OperationDescribe, acl.ResourceTopic, string(*rt.Name)):
+				errorTopic.ErrorCode = constant.TOPIC_AUTHORIZATION_FAILED
+			case !ok:
+				errorTopic.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+			}
+		} else {
+			t, ok = cluster.TopicById(rt.TopicId)
+			switch {
+			case !ok:
+				errorTopic.ErrorCode = constant.UNKNOWN_TOPIC_ID
+			case !s.authorized(acl.OperationDescribe, acl.ResourceTopic, t.Name):
+				errorTopic.ErrorCode = constant.TOPIC_AUTHORIZATION_FAILED
+			}
+		}
+		if errorTopic.ErrorCode != constant.NONE {
+			res.Topics = append(res.Topics, errorTopic)
+			continue
+		}
+		res.Topics = append(res.Topics, metadataTopic(s, rb, t))
+	}
+	return res
+}
+
+func metadataTopic(s session, rb *request.MetadataV12, t metadata.Topic) response.MetadataTopic {
+	topic := response.MetadataTopic{
+		Name:                      toNullableString(t.Name),
+		TopicId:                   t.TopicId,
+		IsInternal:                t.IsInternal,
+		Partitions:                []response.MetadataPartition{},
+		TopicAuthorizedOperations: authorizedOperationsOmitted,
+	}
+	if rb.IncludeTopicAuthorizedOperations {
+		topic.TopicAuthorizedOperations = authorizer.AuthorizedOperations(s.principal, s.host, acl.ResourceTopic, t.Name)
+	}
+	partitions := slices.Clone(t.Partitions)
+	slices.SortFunc(partitions, func(a, b metadata.Partition) int { return cmp.Compare(a.PartitionIndex, b.PartitionIndex) })
+	for _, p := range partitions {
+		topic.Partitions = append(topic.Partitions, response.MetadataPartition{
+			PartitionIndex:  p.PartitionIndex,
+			LeaderId:        p.LeaderId,
+			LeaderEpoch:     p.LeaderEpoch,
+			ReplicaNodes:    p.ReplicaNodes,
+			IsrNodes:        p.ISRNodes,
+			OfflineReplicas: []int32{},
+		})
+	}
+	return topic
+}
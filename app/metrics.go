@@ -0,0 +1,129 @@
+package main
+
+import (
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/metrics"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+var (
+	registry = metrics.NewRegistry()
+
+	requestsTotal = registry.NewCounterVec("kafka_network_requests_total",
+		"The number of requests served.", "api_key", "api_version")
+	requestDuration = registry.NewHistogramVec("kafka_network_request_duration_seconds",
+		"The time from reading a request to sending its response.", metrics.DefaultBuckets, "api_key", "api_version")
+	errorsTotal = registry.NewCounterVec("kafka_network_errors_total",
+		"The number of error codes in responses, each partition or resource counting once.", "api_key", "error_code")
+	bytesInTotal = registry.NewCounterVec("kafka_server_topic_bytes_in_total",
+		"The number of record bytes appended to a topic.", "topic")
+	bytesOutTotal = registry.NewCounterVec("kafka_server_topic_bytes_out_total",
+		"The number of record bytes fetched from a topic.", "topic")
+	connectionsRejected = registry.NewCounterVec("kafka_server_connections_rejected_total",
+		"The number of connections closed for exceeding max.connections.per.ip.", "listener")
+)
+
+func init() {
+	registry.NewGaugeFunc("kafka_server_active_connections",
+		"The number of open client connections.", []string{"listener"},
+		func(set func(float64, ...string)) {
+			counts := make(map[string]int)
+			for _, info := range conns.list() {
+				counts[info.Listener]++
+			}
+			for _, name := range slices.Sorted(maps.Keys(counts)) {
+				set(float64(counts[name]), name)
+			}
+		})
+	registry.NewGaugeFunc("kafka_log_end_offset",
+		"The offset of the next record appended to a partition.", []string{"topic", "partition"},
+		func(set func(float64, ...string)) {
+			for _, t := range cluster.Topics() {
+				for _, p := range t.Partitions {
+					if log, ok := logManager.GetLog(t.Name, p.PartitionIndex); ok {
+						set(float64(log.LogEndOffset()), t.Name, strconv.Itoa(int(p.PartitionIndex)))
+					}
+				}
+			}
+		})
+	registry.NewGaugeFunc("kafka_consumergroup_lag",
+		"The number of records between the committed offset of a group and the high watermark of a partition.", []string{"group", "topic", "partition"},
+		func(set func(float64, ...string)) {
+			for _, o := range groupCoordinator.CommittedOffsets() {
+				log, ok := logManager.GetLog(o.Topic, o.Partition)
+				if !ok {
+					continue
+				}
+				set(float64(max(log.HighWatermark()-o.Offset, 0)), o.GroupId, o.Topic, strconv.Itoa(int(o.Partition)))
+			}
+		})
+	registry.NewGaugeFunc("kafka_server_under_replicated_partitions",
+		"The number of partitions with fewer in-sync replicas than replicas.", nil,
+		func(set func(float64, ...string)) {
+			n := 0
+			for _, t := range cluster.Topics() {
+				for _, p := range t.Partitions {
+					if len(p.ISRNodes) < len(p.ReplicaNodes) {
+						n++
+					}
+				}
+			}
+			set(float64(n))
+		})
+}
+
+// serveMetrics serves the metrics endpoint on ln until it is closed.
+func serveMetrics(ln net.Listener) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	return srv
+}
+
+// observeRequest records the metrics of a served request. The response
+// body is nil when the client does not expect one.
+func observeRequest(rh *request.RequestHeaderV2, res response.ResponseBody, start time.Time) {
+	apiKey := apiKeyName(rh.RequestApiKey)
+	apiVersion := strconv.Itoa(int(rh.RequestApiVersion))
+	requestsTotal.Inc(apiKey, apiVersion)
+	requestDuration.Observe(time.Since(start).Seconds(), apiKey, apiVersion)
+	if r, ok := res.(response.ErrorReporter); ok {
+		for _, code := range r.ErrorCodes() {
+			errorsTotal.Inc(apiKey, strconv.Itoa(int(code)))
+		}
+	}
+}
+
+// observeProduced records the bytes appended by a produce request.
+func observeProduced(rb *request.ProduceV9, res *response.ProduceV9) {
+	for i, td := range rb.TopicData {
+		for j, pd := range td.PartitionData {
+			if res.Responses[i].PartitionResponses[j].ErrorCode == 0 {
+				bytesInTotal.Add(float64(len(pd.Records)), string(td.Name))
+			}
+		}
+	}
+}
+
+// observeFetched records the bytes returned by a fetch response.
+func observeFetched(res *response.FetchV12) {
+	for _, tr := range res.Responses {
+		name := string(tr.Topic)
+		if t, ok := cluster.TopicById(tr.TopicId); ok {
+			name = t.Name
+		}
+		for _, pr := range tr.Partitions {
+			if size := pr.RecordsSize(); size > 0 {
+				bytesOutTotal.Add(float64(size), name)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+// handleOffsetCommit commits the offsets of the topics s may read and
+// rejects the others.
+func handleOffsetCommit(s session, rb *request.OffsetCommitV9) *response.OffsetCommitV9 {
+	fail := func(errorCode func(topic string) int16) []response.TxnTopicResult {
+		results := make([]response.TxnTopicResult, len(rb.Topics))
+		for i, t := range rb.Topics {
+			results[i].Name = t.Name
+			results[i].Results = make([]response.TxnPartitionResult, len(t.Partitions))
+			for j, p := range t.Partitions {
+				results[i].Results[j] = response.TxnPartitionResult{PartitionIndex: p.PartitionIndex, ErrorCode: errorCode(string(t.Name))}
+			}
+		}
+		return results
+	}
+	if !s.authorized(acl.OperationRead, acl.ResourceGroup, string(rb.GroupId)) {
+		return &response.OffsetCommitV9{Topics: fail(func(string) int16 { return constant.GROUP_AUTHORIZATION_FAILED })}
+	}
+
+	allowed := make([]bool, len(rb.Topics))
+	authorized := *rb
+	authorized.Topics = nil
+	for i, t := range rb.Topics {
+		if allowed[i] = s.authorized(acl.OperationRead, acl.ResourceTopic, string(t.Name)); allowed[i] {
+			authorized.Topics = append(authorized.Topics, t)
+		}
+	}
+	if len(authorized.Topics) == len(rb.Topics) {
+		return groupCoordinator.OffsetCommit(rb)
+	}
+	res := &response.OffsetCommitV9{Topics: fail(func(string) int16 { return constant.TOPIC_AUTHORIZATION_FAILED })}
+	if len(authorized.Topics) == 0 {
+		return res
+	}
+	committed := groupCoordinator.OffsetCommit(&authorized).Topics
+	for i := range rb.Topics {
+		if allowed[i] {
+			res.Topics[i] = committed[0]
+			committed = committed[1:]
+		}
+	}
+	return res
+}
+
+// handleOffsetFetch returns the offsets of the groups s may describe.
+// Partitions of topics s may not describe are left out when fetching every
+// offset of a group, and rejected when requested.
+func handleOffsetFetch(s session, rb *request.OffsetFetchV9) *response.OffsetFetchV9 {
+	res := &response.OffsetFetchV9{Groups: make([]response.OffsetFetchGroup, len(rb.Groups))}
+	for i, g := range rb.Groups {
+		if !s.authorized(acl.OperationDescribe, acl.ResourceGroup, string(g.GroupId)) {
+			res.Groups[i] = response.OffsetFetchGroup{
+				GroupId:   g.GroupId,
+				Topics:    []response.OffsetFetchTopic{},
+				ErrorCode: constant.GROUP_AUTHORIZATION_FAILED,
+			}
+			continue
+		}
+		fetched := groupCoordinator.OffsetFetch(g, rb.RequireStable)
+		topics := fetched.Topics[:0]
+		for _, t := range fetched.Topics {
+			if !s.authorized(acl.OperationDescribe, acl.ResourceTopic, string(t.Name)) {
+				if g.Topics == nil {
+					continue
+				}
+				for j := range t.Partitions {
+					t.Partitions[j] = response.OffsetFetchPartition{
+						PartitionIndex:       t.Partitions[j].PartitionIndex,
+						CommittedOffset:      -1,
+						CommittedLeaderEpoch: -1,
+						Metadata:             toNullableString(""),
+						ErrorCode:            constant.TOPIC_AUTHORIZATION_FAILED,
+					}
+				}
+			}
+			topics = append(topics, t)
+		}
+		fetched.Topics = topics
+		res.Groups[i] = fetched
+	}
+	return res
+}
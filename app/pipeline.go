@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/listener"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/sasl"
+)
+
+var (
+	errInvalidBody = errors.New("invalid request body type")
+	errUnsupported = errors.New("unsupported API")
+)
+
+// concurrentApiKeys are the requests that don't change the state of the
+// broker, which are handled concurrently with each other. Any other request
+// waits for the requests read before it and holds back those read after
+// it, so that the requests of a connection appear to take effect in order.
+var concurrentApiKeys = map[int16]bool{
+	constant.ApiVersions:                  true,
+	constant.Metadata:                     true,
+	constant.DescribeTopicPartitions:      true,
+	constant.Fetch:                        true,
+	constant.ListOffsets:                  true,
+	constant.OffsetFetch:                  true,
+	constant.ListGroups:                   true,
+	constant.ConsumerGroupDescribe:        true,
+	constant.DescribeConfigs:              true,
+	constant.DescribeAcls:                 true,
+	constant.DescribeClientQuotas:         true,
+	constant.DescribeUserScramCredentials: true,
+	constant.DescribeProducers:            true,
+	constant.DescribeTransactions:         true,
+	constant.ListTransactions:             true,
+}
+
+// pipelined is a request read from a connection. Its response is written
+// once it and every request read before it are handled.
+type pipelined struct {
+	session session
+	header  *request.RequestHeaderV2
+	request *request.Request
+	size    int
+	start   time.Time
+
+	// answered with an error response rather than handled when not zero,
+	// for requests that could not be read
+	errorCode int16
+
+	// how long the handler waited for data rather than worked, which is
+	// not counted against the request quota
+	waited time.Duration
+
+	// set before handled is closed; a nil response is not written
+	body     response.ResponseBody
+	response *response.Message
+	throttle time.Duration
+
+	handled chan struct{}
+	written chan struct{}
+}
+
+// clientConn is a client connection whose requests are read ahead, up to
+// max.in.flight of them, and whose responses are written in the order the
+// requests were read.
+type clientConn struct {
+	conn     net.Conn
+	listener *listener.Listener
+	auth     *sasl.Authenticator
+	log      *slog.Logger
+	queue    chan *pipelined
+
+	// the last request that is not handled concurrently, and the
+	// concurrent ones read since, which are only used by the reader
+	exclusive  <-chan struct{}
+	concurrent []<-chan struct{}
+
+	mu         sync.Mutex
+	mutedUntil time.Time
+}
+
+func newClientConn(conn net.Conn, l *listener.Listener, auth *sasl.Authenticator, log *slog.Logger) *clientConn {
+	value, _ := configs.Get("", "max.in.flight")
+	maxInFlight, _ := strconv.Atoi(value)
+	return &clientConn{
+		conn:     conn,
+		listener: l,
+		auth:     auth,
+		log:      log,
+		queue:    make(chan *pipelined, max(maxInFlight, 1)),
+	}
+}
+
+// schedule handles a request once the requests it must follow are handled.
+func (c *clientConn) schedule(p *pipelined) {
+	var after []<-chan struct{}
+	if concurrentApiKeys[p.header.RequestApiKey] {
+		if c.exclusive != nil {
+			after = append(after, c.exclusive)
+		}
+		// forget the requests that are already handled
+		pending := c.concurrent[:0]
+		for _, handled := range c.concurrent {
+			select {
+			case <-handled:
+			default:
+				pending = append(pending, handled)
+			}
+		}
+		c.concurrent = append(pending, p.handled)
+	} else {
+		after = c.concurrent
+		if c.exclusive != nil {
+			after = append(after, c.exclusive)
+		}
+		c.exclusive = p.handled
+		c.concurrent = nil
+	}
+
+	go func() {
+		defer close(p.handled)
+		for _, handled := range after {
+			<-handled
+		}
+		begin := time.Now()
+		res := c.respond(p)
+		if res != nil {
+			p.body = res.Body
+		}
+		handleTime := time.Since(begin) - p.waited
+		p.throttle = quotaThrottle(p.session, p.header.ClientId.Data, p.header.RequestApiKey, p.size, p.body, handleTime)
+		if res != nil {
+			if p.throttle > 0 {
+				setThrottleTime(res.Body, p.throttle)
+			}
+			message, err := res.Encode()
+			if err != nil {
+				c.log.Error("Failed to encode response", "apiKey", apiKeyName(p.header.RequestApiKey), "error", err)
+				// the records of a fetch are not sent, so their files are
+				// closed here rather than once written
+				if body, ok := res.Body.(*response.FetchV12); ok {
+					body.CloseFileRecords()
+				}
+				message, _ = errorResponse(p.header, p.request.Body, constant.UNKNOWN_SERVER_ERROR).Encode()
+			}
+			p.response = message
+		}
+	}()
+}
+
+// respond handles a request, answering with an error response when it
+// could not be read or handled. A panic while handling a request is
+// recovered and answered with UNKNOWN_SERVER_ERROR, so that it does not
+// take down the broker.
+func (c *clientConn) respond(p *pipelined) (res *response.Response) {
+	rh := p.header
+	if p.errorCode != 0 {
+		return errorResponse(rh, p.request.Body, p.errorCode)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Error("Request panicked", "apiKey", apiKeyName(rh.RequestApiKey), "apiVersion", rh.RequestApiVersion,
+				"panic", r, "stack", string(debug.Stack()))
+			res = errorResponse(rh, p.request.Body, constant.UNKNOWN_SERVER_ERROR)
+		}
+	}()
+	res, err := c.handle(p)
+	switch {
+	case errors.Is(err, errUnsupported):
+		return errorResponse(rh, p.request.Body, constant.UNSUPPORTED_VERSION)
+	case err != nil:
+		c.log.Warn("Invalid request", "apiKey", apiKeyName(rh.RequestApiKey), "apiVersion", rh.RequestApiVersion, "error", err)
+		return errorResponse(rh, p.request.Body, constant.INVALID_REQUEST)
+	}
+	return res
+}
+
+// writeResponses writes the response of every queued request in order,
+// until the queue is closed. Once the connection is closed the remaining
+// requests are still waited for, but their responses are dropped.
+func (c *clientConn) writeResponses() {
+	open := true
+	for p := range c.queue {
+		<-p.handled
+		if open {
+			open = c.write(p)
+			if !open {
+				c.conn.Close()
+			}
+		}
+		if p.response != nil {
+			p.response.Close()
+		}
+		close(p.written)
+	}
+}
+
+// write sends the response of a request, and reports false when the
+// connection must be closed.
+func (c *clientConn) write(p *pipelined) bool {
+	rh := p.header
+	responseSize := 0
+	if p.response != nil {
+		responseSize = p.response.Size()
+		if _, err := p.response.WriteTo(c.conn); err != nil {
+			c.log.Debug("Closing connection", "error", err)
+			return false
+		}
+	}
+	logRequest(c.log, p.session, rh, p.request.Body, p.body, p.size, responseSize, p.start, p.throttle)
+	observeRequest(rh, p.body, p.start)
+
+	if c.auth.Failed() {
+		c.log.Warn("Closing connection after failed SASL authentication", "principal", p.session.principal)
+		return false
+	}
+	if p.throttle > 0 {
+		// the client is expected to back off for the throttle time; the
+		// connection is muted meanwhile in case it does not
+		c.mu.Lock()
+		c.mutedUntil = time.Now().Add(p.throttle)
+		c.mu.Unlock()
+	}
+	return conns.end(c.conn)
+}
+
+// waitUnmuted waits until the connection is no longer throttled.
+func (c *clientConn) waitUnmuted() {
+	c.mu.Lock()
+	until := c.mutedUntil
+	c.mu.Unlock()
+	time.Sleep(time.Until(until))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+func handleProduce(s session, rb *request.ProduceV9) *response.ProduceV9 {
+	txnAuthorized := rb.TransactionalId == nil ||
+		s.authorized(acl.OperationWrite, acl.ResourceTransactionalId, string(*rb.TransactionalId))
+	res := &response.ProduceV9{
+		Responses: make([]response.ProduceTopicResponse, len(rb.TopicData)),
+	}
+	for i, td := range rb.TopicData {
+		res.Responses[i].Name = td.Name
+		res.Responses[i].PartitionResponses = make([]response.ProducePartitionResponse, len(td.PartitionData))
+		topicAuthorized := s.authorized(acl.OperationWrite, acl.ResourceTopic, string(td.Name))
+		for j, pd := range td.PartitionData {
+			pr := &res.Responses[i].PartitionResponses[j]
+			pr.Index = pd.Index
+			pr.BaseOffset = -1
+			pr.LogAppendTimeMs = -1
+			pr.LogStartOffset = -1
+
+			switch {
+			case !txnAuthorized:
+				pr.ErrorCode = constant.TRANSACTIONAL_ID_AUTHORIZATION_FAILED
+				continue
+			case !topicAuthorized:
+				pr.ErrorCode = constant.TOPIC_AUTHORIZATION_FAILED
+				continue
+			case rb.Acks != 0 && rb.Acks != 1 && rb.Acks != -1:
+				pr.ErrorCode = constant.INVALID_REQUIRED_ACKS
+				continue
+			}
+			if err := appendRecords(string(td.Name), pd, pr); err != nil {
+				message := types.CompactString(err.Error())
+				pr.ErrorMessage = &message
+			}
+		}
+	}
+	observeProduced(rb, res)
+	return res
+}
+
+// appendRecords appends the batches of one partition and fills in its response.
+func appendRecords(topicName string, pd request.ProducePartitionData, pr *response.ProducePartitionResponse) error {
+	topic, ok := cluster.TopicByName(topicName)
+	if !ok || pd.Index < 0 || int(pd.Index) >= len(topic.Partitions) {
+		pr.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+		return nil
+	}
+
+	var batches []*record.RecordBatch
+	r := bytes.NewReader(pd.Records)
+	for r.Len() > 0 {
+		b, err := record.ReadRecordBatch(r)
+		if err != nil {
+			pr.ErrorCode = constant.CORRUPT_MESSAGE
+			return fmt.Errorf("error reading record batch %d: %s", len(batches), err)
+		}
+		batches = append(batches, b)
+	}
+	if len(batches) == 0 {
+		pr.ErrorCode = constant.INVALID_RECORD
+		return fmt.Errorf("produce request to %s-%d has no record batches", topicName, pd.Index)
+	}
+
+	log, err := logManager.GetOrCreateLog(topicName, pd.Index)
+	if err != nil {
+		pr.ErrorCode = storage.ErrorCode(err)
+		return err
+	}
+	defer appendNotifier.notify()
+	for i, b := range batches {
+		info, err := log.Append(b)
+		if err != nil {
+			pr.ErrorCode = storage.ErrorCode(err)
+			return err
+		}
+		if i == 0 {
+			pr.BaseOffset = info.FirstOffset
+		}
+		pr.LogStartOffset = info.LogStartOffset
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// handleDescribeProducers reports the producers each partition keeps state
+// for, which shows the transactions holding back its last stable offset.
+func handleDescribeProducers(s session, rb *request.DescribeProducersV0) *response.DescribeProducersV0 {
+	res := &response.DescribeProducersV0{
+		Topics: make([]response.DescribeProducersTopic, len(rb.Topics)),
+	}
+	for i, t := range rb.Topics {
+		res.Topics[i].Name = t.Name
+		res.Topics[i].Partitions = make([]response.DescribeProducersPartition, len(t.PartitionIndexes))
+		topic, ok := cluster.TopicByName(string(t.Name))
+		authorized := s.authorized(acl.OperationRead, acl.ResourceTopic, string(t.Name))
+		for j, p := range t.PartitionIndexes {
+			pr := &res.Topics[i].Partitions[j]
+			pr.PartitionIndex = p
+			pr.ActiveProducers = []response.ProducerState{}
+			if !authorized {
+				pr.ErrorCode = constant.TOPIC_AUTHORIZATION_FAILED
+				continue
+			}
+			if !ok || p < 0 || int(p) >= len(topic.Partitions) {
+				pr.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+				continue
+			}
+			log, err := logManager.GetOrCreateLog(topic.Name, p)
+			if err != nil {
+				pr.ErrorCode = storage.ErrorCode(err)
+				message := types.CompactString(err.Error())
+				pr.ErrorMessage = &message
+				continue
+			}
+			for _, e := range log.ProducerState() {
+				pr.ActiveProducers = append(pr.ActiveProducers, response.ProducerState{
+					ProducerId:            e.ProducerId,
+					ProducerEpoch:         int32(e.ProducerEpoch),
+					LastSequence:          e.LastSequence(),
+					LastTimestamp:         e.LastTimestamp,
+					CoordinatorEpoch:      e.CoordinatorEpoch,
+					CurrentTxnStartOffset: e.CurrentTxnFirstOffset,
+				})
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/quota"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+// newQuotaManager builds the quota manager of the broker configuration.
+func newQuotaManager(log *metadata.Log) *quota.Manager {
+	numSamples, _ := configs.Get("", "quota.window.num")
+	windowSeconds, _ := configs.Get("", "quota.window.size.seconds")
+	n, _ := strconv.Atoi(numSamples)
+	window, _ := strconv.Atoi(windowSeconds)
+	return quota.NewManager(log, n, time.Duration(window)*time.Second)
+}
+
+// quotaThrottle records the usage of a request against the quotas of its
+// client and returns how long the client must be throttled: produce
+// requests count their size, fetch responses the records they return and
+// every request the time its handler ran, leaving out the time it queued
+// behind earlier requests and the time a fetch waited for data.
+func quotaThrottle(s session, clientId string, apiKey int16, requestSize int, body response.ResponseBody, handleTime time.Duration) time.Duration {
+	switch apiKey {
+	case constant.ApiVersions, constant.SaslHandshake, constant.SaslAuthenticate:
+		// clients must be able to connect whatever their usage
+		return 0
+	}
+	user := strings.TrimPrefix(s.principal, "User:")
+	now := time.Now()
+	throttle := quotas.Record(quota.Request, user, clientId, handleTime.Seconds()*100, now)
+	switch apiKey {
+	case constant.Produce:
+		throttle = max(throttle, quotas.Record(quota.Produce, user, clientId, float64(requestSize), now))
+	case constant.Fetch:
+		if res, ok := body.(*response.FetchV12); ok {
+			throttle = max(throttle, quotas.Record(quota.Fetch, user, clientId, float64(fetchSize(res)), now))
+		}
+	}
+	return throttle
+}
+
+func fetchSize(res *response.FetchV12) int {
+	size := 0
+	for _, t := range res.Responses {
+		for _, p := range t.Partitions {
+			size += p.RecordsSize()
+		}
+	}
+	return size
+}
+
+// setThrottleTime reports throttle in the response bodies that have a
+// throttle time.
+func setThrottleTime(body response.ResponseBody, throttle time.Duration) {
+	if t, ok := body.(response.Throttled); ok {
+		t.SetThrottleTime(int32(throttle.Milliseconds()))
+	}
+}
+
+func handleDescribeClientQuotas(s session, rb *request.DescribeClientQuotasV1) *response.DescribeClientQuotasV1 {
+	if s.clusterAuthorized(acl.OperationDescribeConfigs) {
+		return quotas.DescribeClientQuotas(rb)
+	}
+	return &response.DescribeClientQuotasV1{
+		ErrorCode:    constant.CLUSTER_AUTHORIZATION_FAILED,
+		ErrorMessage: toNullableString("Not authorized to describe client quotas"),
+	}
+}
+
+func handleAlterClientQuotas(s session, rb *request.AlterClientQuotasV1) *response.AlterClientQuotasV1 {
+	if s.clusterAuthorized(acl.OperationAlterConfigs) {
+		return quotas.AlterClientQuotas(rb)
+	}
+	res := &response.AlterClientQuotasV1{Entries: make([]response.AlterClientQuotasEntry, len(rb.Entries))}
+	for i, entry := range rb.Entries {
+		res.Entries[i].ErrorCode = constant.CLUSTER_AUTHORIZATION_FAILED
+		res.Entries[i].ErrorMessage = toNullableString("Not authorized to alter client quotas")
+		for _, c := range entry.Entity {
+			res.Entries[i].Entity = append(res.Entries[i].Entity, response.QuotaEntity{EntityType: c.EntityType, EntityName: c.EntityName})
+		}
+	}
+	return res
+}
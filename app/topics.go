@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/group"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// maxTopicNameLength leaves room for the partition suffix of log
+// directory names.
+const maxTopicNameLength = 249
+
+var (
+	topicNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+	// topicsMu serializes topic creations and deletions, which check the
+	// cluster before appending to the metadata log.
+	topicsMu sync.Mutex
+)
+
+// topicError is the error of creating or deleting a topic.
+type topicError struct {
+	code    int16
+	message string
+}
+
+func (e *topicError) Error() string {
+	return e.message
+}
+
+func newTopicError(code int16, format string, args ...any) *topicError {
+	return &topicError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func validateTopicName(name string) error {
+	switch {
+	case name == "":
+		return newTopicError(constant.INVALID_TOPIC_EXCEPTION, "Topic name is illegal, it can't be empty")
+	case name == "." || name == "..":
+		return newTopicError(constant.INVALID_TOPIC_EXCEPTION, "Topic name cannot be \".\" or \"..\"")
+	case len(name) > maxTopicNameLength:
+		return newTopicError(constant.INVALID_TOPIC_EXCEPTION, "Topic name is illegal, it can't be longer than %d characters, topic name: %s", maxTopicNameLength, name)
+	case !topicNamePattern.MatchString(name):
+		return newTopicError(constant.INVALID_TOPIC_EXCEPTION, "Topic name %s is illegal, it contains a character other than ASCII alphanumerics, '.', '_' and '-'", name)
+	case name == metadata.ClusterMetadataTopic || name == group.OffsetsTopic:
+		return newTopicError(constant.INVALID_REQUEST, "Creation of internal topic %s is prohibited.", name)
+	}
+	return nil
+}
+
+// handleCreateTopics creates topics whose partitions are all led by this
+// broker, which is the only replica there can be. Topics named more than
+// once in the request are not created.
+func handleCreateTopics(s session, rb *request.CreateTopicsV7) *response.CreateTopicsV7 {
+	clusterCreate := s.clusterAuthorized(acl.OperationCreate)
+	count := make(map[types.CompactString]int)
+	for _, t := range rb.Topics {
+		count[t.Name]++
+	}
+
+	topicsMu.Lock()
+	defer topicsMu.Unlock()
+
+	res := &response.CreateTopicsV7{Topics: make([]response.CreatableTopicResult, len(rb.Topics))}
+	for i, t := range rb.Topics {
+		result := &res.Topics[i]
+		result.Name = t.Name
+		var err error
+		switch {
+		case count[t.Name] > 1:
+			err = newTopicError(constant.INVALID_REQUEST, "Found multiple entries for topic %s.", t.Name)
+		case !clusterCreate && !s.authorized(acl.OperationCreate, acl.ResourceTopic, string(t.Name)):
+			err = newTopicError(constant.TOPIC_AUTHORIZATION_FAILED, "Authorization failed.")
+		default:
+			err = createTopic(s, t, rb.ValidateOnly, result)
+		}
+		if err != nil {
+			result.NumPartitions = -1
+			result.ReplicationFactor = -1
+			result.Configs = nil
+			switch e := err.(type) {
+			case *topicError:
+				result.ErrorCode = e.code
+			default:
+				result.ErrorCode = config.ErrorCode(err)
+			}
+			result.ErrorMessage = toNullableString(err.Error())
+		}
+	}
+	return res
+}
+
+// createTopic validates a topic and, unless validateOnly, appends the
+// records creating it and opens the logs of its partitions.
+func createTopic(s session, t request.CreatableTopic, validateOnly bool, result *response.CreatableTopicResult) error {
+	name := string(t.Name)
+	if err := validateTopicName(name); err != nil {
+		return err
+	}
+	if _, ok := cluster.TopicByName(name); ok {
+		return newTopicError(constant.TOPIC_ALREADY_EXISTS, "Topic '%s' already exists.", name)
+	}
+	numPartitions, err := topicPartitions(t)
+	if err != nil {
+		return err
+	}
+	configRecords, topicConfigs, err := configs.CreateTopicConfigs(name, t.Configs)
+	if err != nil {
+		return err
+	}
+
+	var topicId [16]byte
+	rand.Read(topicId[:])
+	result.TopicId = topicId
+	result.NumPartitions = numPartitions
+	result.ReplicationFactor = 1
+	if s.authorized(acl.OperationDescribeConfigs, acl.ResourceTopic, name) {
+		result.Configs = topicConfigs
+	}
+	if validateOnly {
+		return nil
+	}
+
+	records := []metadata.Record{&metadata.TopicRecord{Name: t.Name, TopicId: topicId}}
+	for p := range numPartitions {
+		records = append(records, &metadata.PartitionRecord{
+			PartitionId:      p,
+			TopicId:          topicId,
+			Replicas:         []int32{cluster.NodeId},
+			Isr:              []int32{cluster.NodeId},
+			RemovingReplicas: []int32{},
+			AddingReplicas:   []int32{},
+			Leader:           cluster.NodeId,
+		})
+	}
+	records = append(records, configRecords...)
+	if err := metadataLog.Append(records...); err != nil {
+		return newTopicError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+	}
+	for p := range numPartitions {
+		if _, err := logManager.GetOrCreateLog(name, p); err != nil {
+			slog.Error("Failed to create log", "topic", name, "partition", p, "error", err)
+		}
+	}
+	return nil
+}
+
+// topicPartitions returns the number of partitions of a new topic, which
+// either sets a partition count and replication factor, defaulting to the
+// broker's when -1, or assigns replicas to each partition.
+func topicPartitions(t request.CreatableTopic) (int32, error) {
+	if len(t.Assignments) > 0 {
+		if t.NumPartitions != -1 || t.ReplicationFactor != -1 {
+			return 0, newTopicError(constant.INVALID_REQUEST, "Both numPartitions or replicationFactor and replicasAssignments were set. Both cannot be used at the same time.")
+		}
+		seen := make(map[int32]bool)
+		for _, a := range t.Assignments {
+			if a.PartitionIndex < 0 || int(a.PartitionIndex) >= len(t.Assignments) || seen[a.PartitionIndex] {
+				return 0, newTopicError(constant.INVALID_REPLICA_ASSIGNMENT, "Partitions should be numbered consecutively from 0, found %d.", a.PartitionIndex)
+			}
+			seen[a.PartitionIndex] = true
+			if len(a.BrokerIds) != 1 || a.BrokerIds[0] != cluster.NodeId {
+				return 0, newTopicError(constant.INVALID_REPLICA_ASSIGNMENT, "Partition %d must be assigned to broker %d only.", a.PartitionIndex, cluster.NodeId)
+			}
+		}
+		return int32(len(t.Assignments)), nil
+	}
+
+	numPartitions := t.NumPartitions
+	if numPartitions == -1 {
+		v, _ := configs.Get("", "num.partitions")
+		n, _ := strconv.Atoi(v)
+		numPartitions = int32(n)
+	}
+	if numPartitions <= 0 {
+		return 0, newTopicError(constant.INVALID_PARTITIONS, "Number of partitions was set to an invalid non-positive value.")
+	}
+	replicationFactor := t.ReplicationFactor
+	if replicationFactor == -1 {
+		v, _ := configs.Get("", "default.replication.factor")
+		n, _ := strconv.Atoi(v)
+		replicationFactor = int16(n)
+	}
+	if replicationFactor <= 0 {
+		return 0, newTopicError(constant.INVALID_REPLICATION_FACTOR, "Replication factor must be larger than 0, or -1 to use the default value.")
+	}
+	if replicationFactor > 1 {
+		return 0, newTopicError(constant.INVALID_REPLICATION_FACTOR, "Unable to replicate the partition %d time(s): The target replication factor of %d cannot be reached because only 1 broker(s) are registered.", replicationFactor, replicationFactor)
+	}
+	return numPartitions, nil
+}
+
+// handleDeleteTopics deletes topics named or identified by id, along with
+// their logs, configurations and committed offsets.
+func handleDeleteTopics(s session, rb *request.DeleteTopicsV6) *response.DeleteTopicsV6 {
+	topicsMu.Lock()
+	defer topicsMu.Unlock()
+
+	res := &response.DeleteTopicsV6{Responses: make([]response.DeletableTopicResult, len(rb.Topics))}
+	for i, rt := range rb.Topics {
+		result := &res.Responses[i]
+		result.Name = rt.Name
+		result.TopicId = rt.TopicId
+		var t metadata.Topic
+		var ok bool
+		if rt.Name != nil {
+			t, ok = cluster.TopicByName(string(*rt.Name))
+		} else {
+			t, ok = cluster.TopicById(rt.TopicId)
+		}
+
+		name := t.Name
+		if !ok && rt.Name != nil {
+			name = string(*rt.Name)
+		}
+
+		var err *topicError
+		switch {
+		case rt.Name != nil && rt.TopicId != [16]byte{}:
+			err = newTopicError(constant.INVALID_REQUEST, "You may not specify both topic name and topic id.")
+		case rt.Name == nil && !ok:
+			err = newTopicError(constant.UNKNOWN_TOPIC_ID, "This server does not host this topic ID.")
+		case rt.Name == nil && !s.authorized(acl.OperationDescribe, acl.ResourceTopic, name):
+			err = newTopicError(constant.TOPIC_AUTHORIZATION_FAILED, "Authorization failed.")
+		case !s.authorized(acl.OperationDelete, acl.ResourceTopic, name):
+			err = newTopicError(constant.TOPIC_AUTHORIZATION_FAILED, "Authorization failed.")
+		case !ok:
+			err = newTopicError(constant.UNKNOWN_TOPIC_OR_PARTITION, "This server does not host this topic-partition.")
+		default:
+			result.Name = toNullableString(t.Name)
+			result.TopicId = t.TopicId
+			err = deleteTopic(t)
+		}
+		if err != nil {
+			result.ErrorCode = err.code
+			result.ErrorMessage = toNullableString(err.message)
+		}
+	}
+	return res
+}
+
+func deleteTopic(t metadata.Topic) *topicError {
+	records := append(configs.DeleteTopicConfigs(t.Name), &metadata.RemoveTopicRecord{TopicId: t.TopicId})
+	if err := metadataLog.Append(records...); err != nil {
+		return newTopicError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+	}
+	if err := logManager.DeleteTopic(t.Name); err != nil {
+		slog.Error("Failed to delete logs", "topic", t.Name, "error", err)
+	}
+	groupCoordinator.DeleteTopicOffsets(t.Name)
+	return nil
+}
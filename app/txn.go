@@ -0,0 +1,242 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/group"
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/txn"
+)
+
+// writeTxnMarker ends a producer's transaction on one partition. Offsets
+// committed in the transaction live in the group coordinator rather than in
+// a log, so for the offsets partition the marker is applied there.
+func writeTxnMarker(producerId int64, producerEpoch int16, commit bool, tp txn.TopicPartition) error {
+	if tp.Topic == group.OffsetsTopic {
+		groupCoordinator.CompleteTxnOffsets(producerId, commit)
+		return nil
+	}
+	log, err := logManager.GetOrCreateLog(tp.Topic, tp.Partition)
+	if err != nil {
+		return err
+	}
+	marker := record.NewEndTxnMarker(producerId, producerEpoch, 0, commit, time.Now().UnixMilli())
+	if _, err := log.Append(marker); err != nil {
+		return err
+	}
+	appendNotifier.notify()
+	return nil
+}
+
+// handleWriteTxnMarkers is only called by brokers, which need CLUSTER_ACTION
+// on the cluster.
+func handleWriteTxnMarkers(s session, rb *request.WriteTxnMarkersV1) *response.WriteTxnMarkersV1 {
+	authorized := s.clusterAuthorized(acl.OperationClusterAction)
+	res := &response.WriteTxnMarkersV1{
+		Markers: make([]response.TxnMarkerResult, len(rb.Markers)),
+	}
+	for i, m := range rb.Markers {
+		res.Markers[i].ProducerId = m.ProducerId
+		res.Markers[i].Topics = make([]response.TxnTopicResult, len(m.Topics))
+		for j, t := range m.Topics {
+			res.Markers[i].Topics[j].Name = t.Name
+			res.Markers[i].Topics[j].Results = make([]response.TxnPartitionResult, len(t.Partitions))
+			topic, ok := cluster.TopicByName(string(t.Name))
+			for k, p := range t.Partitions {
+				pr := &res.Markers[i].Topics[j].Results[k]
+				pr.PartitionIndex = p
+				if !authorized {
+					pr.ErrorCode = constant.CLUSTER_AUTHORIZATION_FAILED
+					continue
+				}
+				if string(t.Name) != group.OffsetsTopic && (!ok || p < 0 || int(p) >= len(topic.Partitions)) {
+					pr.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+					continue
+				}
+				tp := txn.TopicPartition{Topic: string(t.Name), Partition: p}
+				if err := writeTxnMarker(m.ProducerId, m.ProducerEpoch, m.TransactionResult, tp); err != nil {
+					slog.Error("Failed to write transaction marker", "topic", tp.Topic, "partition", tp.Partition, "error", err)
+					pr.ErrorCode = storage.ErrorCode(err)
+				}
+			}
+		}
+	}
+	return res
+}
+
+func handleInitProducerId(s session, rb *request.InitProducerIdV2) *response.InitProducerIdV2 {
+	errorCode := constant.NONE
+	if rb.TransactionalId != nil && *rb.TransactionalId != "" {
+		if !s.authorized(acl.OperationWrite, acl.ResourceTransactionalId, string(*rb.TransactionalId)) {
+			errorCode = constant.TRANSACTIONAL_ID_AUTHORIZATION_FAILED
+		}
+	} else if !idempotentWriteAuthorized(s) {
+		errorCode = constant.CLUSTER_AUTHORIZATION_FAILED
+	}
+	if errorCode != constant.NONE {
+		return &response.InitProducerIdV2{ErrorCode: errorCode, ProducerId: -1, ProducerEpoch: -1}
+	}
+	return txnCoordinator.InitProducerId(rb)
+}
+
+// idempotentWriteAuthorized tells whether s may produce idempotently, which
+// takes IDEMPOTENT_WRITE on the cluster or WRITE on some topic.
+func idempotentWriteAuthorized(s session) bool {
+	if s.clusterAuthorized(acl.OperationIdempotentWrite) {
+		return true
+	}
+	for _, t := range cluster.Topics() {
+		if s.authorized(acl.OperationWrite, acl.ResourceTopic, t.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// txnTopicErrors reports errorCode for every partition of topics.
+func txnTopicErrors(topics []request.TxnTopic, errorCode func(topic string) int16) []response.TxnTopicResult {
+	results := make([]response.TxnTopicResult, len(topics))
+	for i, t := range topics {
+		results[i].Name = t.Name
+		results[i].Results = make([]response.TxnPartitionResult, len(t.Partitions))
+		for j, p := range t.Partitions {
+			results[i].Results[j] = response.TxnPartitionResult{PartitionIndex: p, ErrorCode: errorCode(string(t.Name))}
+		}
+	}
+	return results
+}
+
+// handleAddPartitionsToTxn adds no partition when the producer may not
+// write one of the topics; partitions of the other topics are reported as
+// not attempted.
+func handleAddPartitionsToTxn(s session, rb *request.AddPartitionsToTxnV3) *response.AddPartitionsToTxnV3 {
+	if !s.authorized(acl.OperationWrite, acl.ResourceTransactionalId, string(rb.TransactionalId)) {
+		return &response.AddPartitionsToTxnV3{Results: txnTopicErrors(rb.Topics, func(string) int16 {
+			return constant.TRANSACTIONAL_ID_AUTHORIZATION_FAILED
+		})}
+	}
+	unauthorized := make(map[string]bool)
+	for _, t := range rb.Topics {
+		if !s.authorized(acl.OperationWrite, acl.ResourceTopic, string(t.Name)) {
+			unauthorized[string(t.Name)] = true
+		}
+	}
+	if len(unauthorized) > 0 {
+		return &response.AddPartitionsToTxnV3{Results: txnTopicErrors(rb.Topics, func(topic string) int16 {
+			if unauthorized[topic] {
+				return constant.TOPIC_AUTHORIZATION_FAILED
+			}
+			return constant.OPERATION_NOT_ATTEMPTED
+		})}
+	}
+	return txnCoordinator.AddPartitionsToTxn(rb)
+}
+
+func handleAddOffsetsToTxn(s session, rb *request.AddOffsetsToTxnV3) *response.ErrorOnlyV0 {
+	if !s.authorized(acl.OperationWrite, acl.ResourceTransactionalId, string(rb.TransactionalId)) {
+		return &response.ErrorOnlyV0{ErrorCode: constant.TRANSACTIONAL_ID_AUTHORIZATION_FAILED}
+	}
+	if !s.authorized(acl.OperationRead, acl.ResourceGroup, string(rb.GroupId)) {
+		return &response.ErrorOnlyV0{ErrorCode: constant.GROUP_AUTHORIZATION_FAILED}
+	}
+	return txnCoordinator.AddOffsetsToTxn(rb)
+}
+
+func handleEndTxn(s session, rb *request.EndTxnV3) *response.ErrorOnlyV0 {
+	if !s.authorized(acl.OperationWrite, acl.ResourceTransactionalId, string(rb.TransactionalId)) {
+		return &response.ErrorOnlyV0{ErrorCode: constant.TRANSACTIONAL_ID_AUTHORIZATION_FAILED}
+	}
+	return txnCoordinator.EndTxn(rb)
+}
+
+// handleTxnOffsetCommit commits the offsets of the topics the producer may
+// read and rejects the others.
+func handleTxnOffsetCommit(s session, rb *request.TxnOffsetCommitV3) *response.TxnOffsetCommitV3 {
+	fail := func(errorCode func(topic string) int16) []response.TxnTopicResult {
+		results := make([]response.TxnTopicResult, len(rb.Topics))
+		for i, t := range rb.Topics {
+			results[i].Name = t.Name
+			results[i].Results = make([]response.TxnPartitionResult, len(t.Partitions))
+			for j, p := range t.Partitions {
+				results[i].Results[j] = response.TxnPartitionResult{PartitionIndex: p.PartitionIndex, ErrorCode: errorCode(string(t.Name))}
+			}
+		}
+		return results
+	}
+	switch {
+	case !s.authorized(acl.OperationWrite, acl.ResourceTransactionalId, string(rb.TransactionalId)):
+		return &response.TxnOffsetCommitV3{Topics: fail(func(string) int16 { return constant.TRANSACTIONAL_ID_AUTHORIZATION_FAILED })}
+	case !s.authorized(acl.OperationRead, acl.ResourceGroup, string(rb.GroupId)):
+		return &response.TxnOffsetCommitV3{Topics: fail(func(string) int16 { return constant.GROUP_AUTHORIZATION_FAILED })}
+	}
+
+	allowed := make([]bool, len(rb.Topics))
+	authorized := *rb
+	authorized.Topics = nil
+	for i, t := range rb.Topics {
+		if allowed[i] = s.authorized(acl.OperationRead, acl.ResourceTopic, string(t.Name)); allowed[i] {
+			authorized.Topics = append(authorized.Topics, t)
+		}
+	}
+	if len(authorized.Topics) == len(rb.Topics) {
+		return groupCoordinator.TxnOffsetCommit(rb)
+	}
+	res := &response.TxnOffsetCommitV3{Topics: fail(func(string) int16 { return constant.TOPIC_AUTHORIZATION_FAILED })}
+	if len(authorized.Topics) == 0 {
+		return res
+	}
+	committed := groupCoordinator.TxnOffsetCommit(&authorized).Topics
+	for i := range rb.Topics {
+		if allowed[i] {
+			res.Topics[i] = committed[0]
+			committed = committed[1:]
+		}
+	}
+	return res
+}
+
+func handleDescribeTransactions(s session, rb *request.DescribeTransactionsV0) *response.DescribeTransactionsV0 {
+	allowed := make([]bool, len(rb.TransactionalIds))
+	authorized := &request.DescribeTransactionsV0{}
+	for i, id := range rb.TransactionalIds {
+		if allowed[i] = s.authorized(acl.OperationDescribe, acl.ResourceTransactionalId, string(id)); allowed[i] {
+			authorized.TransactionalIds = append(authorized.TransactionalIds, id)
+		}
+	}
+	described := txnCoordinator.DescribeTransactions(authorized).TransactionStates
+	res := &response.DescribeTransactionsV0{TransactionStates: make([]response.TransactionState, len(rb.TransactionalIds))}
+	for i, id := range rb.TransactionalIds {
+		if allowed[i] {
+			res.TransactionStates[i] = described[0]
+			described = described[1:]
+			continue
+		}
+		res.TransactionStates[i] = response.TransactionState{
+			ErrorCode:       constant.TRANSACTIONAL_ID_AUTHORIZATION_FAILED,
+			TransactionalId: id,
+			ProducerId:      -1,
+			ProducerEpoch:   -1,
+			Topics:          []response.TransactionTopic{},
+		}
+	}
+	return res
+}
+
+// handleListTransactions only lists the transactions s may describe.
+func handleListTransactions(s session, rb *request.ListTransactionsV0) *response.ListTransactionsV0 {
+	res := txnCoordinator.ListTransactions(rb)
+	listed := res.TransactionStates[:0]
+	for _, t := range res.TransactionStates {
+		if s.authorized(acl.OperationDescribe, acl.ResourceTransactionalId, string(t.TransactionalId)) {
+			listed = append(listed, t)
+		}
+	}
+	res.TransactionStates = listed
+	return res
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+func handleDescribeUserScramCredentials(s session, rb *request.DescribeUserScramCredentialsV0) *response.DescribeUserScramCredentialsV0 {
+	if !s.clusterAuthorized(acl.OperationDescribe) {
+		return &response.DescribeUserScramCredentialsV0{
+			ErrorCode:    constant.CLUSTER_AUTHORIZATION_FAILED,
+			ErrorMessage: toNullableString("Not authorized to describe SCRAM credentials"),
+			Results:      []response.DescribeUserScramCredentialsResult{},
+		}
+	}
+	return credentials.DescribeUserScramCredentials(rb)
+}
+
+func handleAlterUserScramCredentials(s session, rb *request.AlterUserScramCredentialsV0) *response.AlterUserScramCredentialsV0 {
+	if s.clusterAuthorized(acl.OperationAlter) {
+		return credentials.AlterUserScramCredentials(rb)
+	}
+	res := &response.AlterUserScramCredentialsV0{Results: []response.AlterUserScramCredentialsResult{}}
+	seen := make(map[types.CompactString]bool)
+	addUser := func(user types.CompactString) {
+		if !seen[user] {
+			seen[user] = true
+			res.Results = append(res.Results, response.AlterUserScramCredentialsResult{
+				User:         user,
+				ErrorCode:    constant.CLUSTER_AUTHORIZATION_FAILED,
+				ErrorMessage: toNullableString("Not authorized to alter SCRAM credentials"),
+			})
+		}
+	}
+	for _, d := range rb.Deletions {
+		addUser(d.Name)
+	}
+	for _, u := range rb.Upsertions {
+		addUser(u.Name)
+	}
+	return res
+}
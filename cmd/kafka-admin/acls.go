@@ -0,0 +1,336 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/acl"
+	"github.com/codecrafters-io/kafka-starter-go/internal/cli"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+var (
+	resourceTypeNames = map[string]acl.ResourceType{
+		"any":              acl.ResourceAny,
+		"topic":            acl.ResourceTopic,
+		"group":            acl.ResourceGroup,
+		"cluster":          acl.ResourceCluster,
+		"transactional-id": acl.ResourceTransactionalId,
+		"delegation-token": acl.ResourceDelegationToken,
+		"user":             acl.ResourceUser,
+	}
+	patternTypeNames = map[string]acl.PatternType{
+		"any":      acl.PatternAny,
+		"match":    acl.PatternMatch,
+		"literal":  acl.PatternLiteral,
+		"prefixed": acl.PatternPrefixed,
+	}
+	operationNames = map[string]acl.Operation{
+		"any":              acl.OperationAny,
+		"all":              acl.OperationAll,
+		"read":             acl.OperationRead,
+		"write":            acl.OperationWrite,
+		"create":           acl.OperationCreate,
+		"delete":           acl.OperationDelete,
+		"alter":            acl.OperationAlter,
+		"describe":         acl.OperationDescribe,
+		"cluster-action":   acl.OperationClusterAction,
+		"describe-configs": acl.OperationDescribeConfigs,
+		"alter-configs":    acl.OperationAlterConfigs,
+		"idempotent-write": acl.OperationIdempotentWrite,
+		"create-tokens":    acl.OperationCreateTokens,
+		"describe-tokens":  acl.OperationDescribeTokens,
+	}
+	permissionNames = map[string]acl.Permission{
+		"any":   acl.PermissionAny,
+		"deny":  acl.PermissionDeny,
+		"allow": acl.PermissionAllow,
+	}
+)
+
+// lookupName returns the value of a name given on the command line.
+func lookupName[V comparable](names map[string]V, flagName, name string) (V, error) {
+	v, ok := names[strings.ToLower(name)]
+	if !ok {
+		var zero V
+		return zero, fmt.Errorf("unknown --%s %q", flagName, name)
+	}
+	return v, nil
+}
+
+// nameOf returns the name of a value for output.
+func nameOf[V comparable](names map[string]V, v V) string {
+	for name, value := range names {
+		if value == v {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// aclFlags describe bindings when adding ACLs, and filters otherwise, in
+// which case the flags left out match everything.
+type aclFlags struct {
+	resourceType *string
+	resourceName *string
+	patternType  *string
+	principal    *string
+	host         *string
+	operations   cli.ListFlag
+	permission   *string
+}
+
+func addAclFlags(fs *flag.FlagSet, adding bool) *aclFlags {
+	resourceType, host, patternType, permission := "any", "", "any", "any"
+	if adding {
+		resourceType, host, patternType, permission = "", acl.Wildcard, "literal", "allow"
+	}
+	f := &aclFlags{
+		resourceType: fs.String("resource-type", resourceType, "the resource `type`: topic, group, cluster, transactional-id, delegation-token or user"),
+		resourceName: fs.String("resource-name", "", "the resource `name`, or * for every resource of literal patterns"),
+		patternType:  fs.String("pattern-type", patternType, "the resource pattern `type`: literal or prefixed, and any or match in filters"),
+		principal:    fs.String("principal", "", "the `principal`, as User:name or User:*"),
+		host:         fs.String("host", host, "the `host` the principal connects from, or * for every host"),
+		permission:   fs.String("permission", permission, "the permission `type`: allow or deny"),
+	}
+	fs.Var(&f.operations, "operation", "the `operation`, such as read, write, create or describe; may be repeated")
+	return f
+}
+
+func (f *aclFlags) filter() (request.AclFilter, error) {
+	var filter request.AclFilter
+	resourceType, err := lookupName(resourceTypeNames, "resource-type", *f.resourceType)
+	if err != nil {
+		return filter, err
+	}
+	patternType, err := lookupName(patternTypeNames, "pattern-type", *f.patternType)
+	if err != nil {
+		return filter, err
+	}
+	permission, err := lookupName(permissionNames, "permission", *f.permission)
+	if err != nil {
+		return filter, err
+	}
+	operation := acl.OperationAny
+	switch len(f.operations) {
+	case 0:
+	case 1:
+		if operation, err = lookupName(operationNames, "operation", f.operations[0]); err != nil {
+			return filter, err
+		}
+	default:
+		return filter, fmt.Errorf("filters match at most one --operation")
+	}
+	filter = request.AclFilter{
+		ResourceTypeFilter: int8(resourceType),
+		PatternTypeFilter:  int8(patternType),
+		Operation:          int8(operation),
+		PermissionType:     int8(permission),
+	}
+	for _, s := range []struct {
+		value string
+		field **types.CompactString
+	}{
+		{*f.resourceName, &filter.ResourceNameFilter},
+		{*f.principal, &filter.PrincipalFilter},
+		{*f.host, &filter.HostFilter},
+	} {
+		if s.value != "" {
+			*s.field = toNullableString(s.value)
+		}
+	}
+	return filter, nil
+}
+
+type aclBinding struct {
+	ResourceType string `json:"resourceType"`
+	ResourceName string `json:"resourceName"`
+	PatternType  string `json:"patternType"`
+	Principal    string `json:"principal"`
+	Host         string `json:"host"`
+	Operation    string `json:"operation"`
+	Permission   string `json:"permission"`
+	Error        string `json:"error,omitempty"`
+}
+
+func newAclBinding(resourceType int8, resourceName types.CompactString, patternType int8, principal, host types.CompactString, operation, permission int8) aclBinding {
+	return aclBinding{
+		ResourceType: nameOf(resourceTypeNames, acl.ResourceType(resourceType)),
+		ResourceName: string(resourceName),
+		PatternType:  nameOf(patternTypeNames, acl.PatternType(patternType)),
+		Principal:    string(principal),
+		Host:         string(host),
+		Operation:    nameOf(operationNames, acl.Operation(operation)),
+		Permission:   nameOf(permissionNames, acl.Permission(permission)),
+	}
+}
+
+func bindingsTable(bindings []aclBinding, withStatus bool, done string) *table {
+	t := &table{header: []string{"RESOURCE-TYPE", "RESOURCE-NAME", "PATTERN-TYPE", "PRINCIPAL", "HOST", "OPERATION", "PERMISSION"}}
+	if withStatus {
+		t.header = append(t.header, "STATUS")
+	}
+	for _, b := range bindings {
+		row := []string{b.ResourceType, b.ResourceName, b.PatternType, b.Principal, b.Host, b.Operation, b.Permission}
+		if withStatus {
+			s := done
+			if b.Error != "" {
+				s = "error: " + b.Error
+			}
+			row = append(row, s)
+		}
+		t.add(row...)
+	}
+	return t
+}
+
+func aclsList(opts *options, args []string) error {
+	fs := newFlagSet("acls list", opts)
+	f := addAclFlags(fs, false)
+	if _, err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	filter, err := f.filter()
+	if err != nil {
+		return err
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.DescribeAcls(&request.DescribeAclsV2{AclFilter: filter})
+	if err != nil {
+		return err
+	}
+	if err := protocolError(res.ErrorCode, res.ErrorMessage); err != nil {
+		return err
+	}
+	bindings := []aclBinding{}
+	for _, r := range res.Resources {
+		for _, a := range r.Acls {
+			bindings = append(bindings, newAclBinding(r.ResourceType, r.ResourceName, r.PatternType, a.Principal, a.Host, a.Operation, a.PermissionType))
+		}
+	}
+	return printResult(opts, bindings, bindingsTable(bindings, false, ""))
+}
+
+func aclsAdd(opts *options, args []string) error {
+	fs := newFlagSet("acls add", opts)
+	f := addAclFlags(fs, true)
+	if _, err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	if *f.principal == "" || len(f.operations) == 0 {
+		return fmt.Errorf("ACLs need a --principal and at least one --operation")
+	}
+	resourceName := *f.resourceName
+	resourceType, err := lookupName(resourceTypeNames, "resource-type", *f.resourceType)
+	if err != nil {
+		return err
+	}
+	if resourceType == acl.ResourceCluster && resourceName == "" {
+		resourceName = acl.ClusterName
+	}
+	patternType, err := lookupName(patternTypeNames, "pattern-type", *f.patternType)
+	if err != nil {
+		return err
+	}
+	permission, err := lookupName(permissionNames, "permission", *f.permission)
+	if err != nil {
+		return err
+	}
+
+	req := &request.CreateAclsV2{}
+	for _, name := range f.operations {
+		operation, err := lookupName(operationNames, "operation", name)
+		if err != nil {
+			return err
+		}
+		req.Creations = append(req.Creations, request.AclCreation{
+			ResourceType:        int8(resourceType),
+			ResourceName:        types.CompactString(resourceName),
+			ResourcePatternType: int8(patternType),
+			Principal:           types.CompactString(*f.principal),
+			Host:                types.CompactString(*f.host),
+			Operation:           int8(operation),
+			PermissionType:      int8(permission),
+		})
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.CreateAcls(req)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	bindings := []aclBinding{}
+	for i, r := range res.Results {
+		if i >= len(req.Creations) {
+			break
+		}
+		cr := req.Creations[i]
+		b := newAclBinding(cr.ResourceType, cr.ResourceName, cr.ResourcePatternType, cr.Principal, cr.Host, cr.Operation, cr.PermissionType)
+		if err := protocolError(r.ErrorCode, r.ErrorMessage); err != nil {
+			failed = true
+			b.Error = err.Error()
+		}
+		bindings = append(bindings, b)
+	}
+	if err := printResult(opts, bindings, bindingsTable(bindings, true, "added")); err != nil {
+		return err
+	}
+	return failure(failed)
+}
+
+func aclsRemove(opts *options, args []string) error {
+	fs := newFlagSet("acls remove", opts)
+	f := addAclFlags(fs, false)
+	if _, err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	if strings.EqualFold(*f.resourceType, "any") && *f.principal == "" {
+		return fmt.Errorf("refusing to remove every ACL: give a --resource-type or --principal")
+	}
+	filter, err := f.filter()
+	if err != nil {
+		return err
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.DeleteAcls(&request.DeleteAclsV2{Filters: []request.DeleteAclsFilter{{AclFilter: filter}}})
+	if err != nil {
+		return err
+	}
+	if len(res.FilterResults) != 1 {
+		return fmt.Errorf("expected 1 filter result in the response, got %d", len(res.FilterResults))
+	}
+	r := res.FilterResults[0]
+	if err := protocolError(r.ErrorCode, r.ErrorMessage); err != nil {
+		return err
+	}
+
+	failed := false
+	bindings := []aclBinding{}
+	for _, m := range r.MatchingAcls {
+		b := newAclBinding(m.ResourceType, m.ResourceName, m.PatternType, m.Principal, m.Host, m.Operation, m.PermissionType)
+		if err := protocolError(m.ErrorCode, m.ErrorMessage); err != nil {
+			failed = true
+			b.Error = err.Error()
+		}
+		bindings = append(bindings, b)
+	}
+	if err := printResult(opts, bindings, bindingsTable(bindings, true, "removed")); err != nil {
+		return err
+	}
+	return failure(failed)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/cli"
+	"github.com/codecrafters-io/kafka-starter-go/internal/config"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+var configSources = map[config.Source]string{
+	config.SourceDynamicTopic:         "DYNAMIC_TOPIC_CONFIG",
+	config.SourceDynamicBroker:        "DYNAMIC_BROKER_CONFIG",
+	config.SourceDynamicDefaultBroker: "DYNAMIC_DEFAULT_BROKER_CONFIG",
+	config.SourceStaticBroker:         "STATIC_BROKER_CONFIG",
+	config.SourceDefault:              "DEFAULT_CONFIG",
+}
+
+func configSourceName(source int8) string {
+	if name, ok := configSources[config.Source(source)]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// entityFlags select the resource whose configurations are described or
+// altered.
+type entityFlags struct {
+	entityType    *string
+	entityName    *string
+	entityDefault *bool
+}
+
+func addEntityFlags(fs *flag.FlagSet) entityFlags {
+	return entityFlags{
+		entityType:    fs.String("entity-type", "", "the `type` of the resource, topic or broker"),
+		entityName:    fs.String("entity-name", "", "the `name` of the topic, or the id of the broker"),
+		entityDefault: fs.Bool("entity-default", false, "select the cluster-wide default broker configurations"),
+	}
+}
+
+func (e entityFlags) resource() (config.ResourceType, string, error) {
+	switch *e.entityType {
+	case "topic":
+		if *e.entityName == "" || *e.entityDefault {
+			return 0, "", fmt.Errorf("topics need an --entity-name and have no --entity-default")
+		}
+		return config.ResourceTopic, *e.entityName, nil
+	case "broker":
+		if (*e.entityName == "") != *e.entityDefault {
+			return 0, "", fmt.Errorf("expected either --entity-name or --entity-default for brokers")
+		}
+		return config.ResourceBroker, *e.entityName, nil
+	default:
+		return 0, "", fmt.Errorf("expected an --entity-type of topic or broker")
+	}
+}
+
+type configDescription struct {
+	Name      string  `json:"name"`
+	Value     *string `json:"value"`
+	Source    string  `json:"source"`
+	ReadOnly  bool    `json:"readOnly"`
+	Sensitive bool    `json:"sensitive"`
+}
+
+func configsDescribe(opts *options, args []string) error {
+	fs := newFlagSet("configs describe", opts)
+	entity := addEntityFlags(fs)
+	all := fs.Bool("all", false, "also list the configurations left to their static or default value")
+	if _, err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	resourceType, name, err := entity.resource()
+	if err != nil {
+		return err
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.DescribeConfigs(&request.DescribeConfigsV4{
+		Resources: []request.DescribeConfigsResource{{ResourceType: int8(resourceType), ResourceName: types.CompactString(name)}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Results) != 1 {
+		return fmt.Errorf("expected 1 resource in the response, got %d", len(res.Results))
+	}
+	result := res.Results[0]
+	if err := protocolError(result.ErrorCode, result.ErrorMessage); err != nil {
+		return err
+	}
+
+	configs := []configDescription{}
+	t := &table{header: []string{"NAME", "VALUE", "SOURCE", "READ-ONLY", "SENSITIVE"}}
+	for _, rc := range result.Configs {
+		source := config.Source(rc.ConfigSource)
+		if !*all && (source == config.SourceStaticBroker || source == config.SourceDefault) {
+			continue
+		}
+		d := configDescription{Name: string(rc.Name), Source: configSourceName(rc.ConfigSource), ReadOnly: rc.ReadOnly, Sensitive: rc.IsSensitive}
+		value := "(sensitive)"
+		if rc.Value != nil {
+			v := string(*rc.Value)
+			d.Value, value = &v, v
+		}
+		configs = append(configs, d)
+		t.add(d.Name, value, d.Source, strconv.FormatBool(d.ReadOnly), strconv.FormatBool(d.Sensitive))
+	}
+	return printResult(opts, configs, t)
+}
+
+type configAlteration struct {
+	Status string `json:"status"`
+}
+
+func configsAlter(opts *options, args []string) error {
+	fs := newFlagSet("configs alter", opts)
+	entity := addEntityFlags(fs)
+	var set, del, appendItems, subtractItems cli.ListFlag
+	fs.Var(&set, "set", "set a configuration, as `name=value`; may be repeated")
+	fs.Var(&del, "delete", "remove the value set for a configuration, given by `name`; may be repeated")
+	fs.Var(&appendItems, "append", "add items to a list configuration, as `name=item,...`; may be repeated")
+	fs.Var(&subtractItems, "subtract", "remove items from a list configuration, as `name=item,...`; may be repeated")
+	validateOnly := fs.Bool("validate-only", false, "only check that the configurations could be altered")
+	if _, err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	resourceType, name, err := entity.resource()
+	if err != nil {
+		return err
+	}
+
+	resource := request.AlterConfigsResource{ResourceType: int8(resourceType), ResourceName: types.CompactString(name)}
+	for _, op := range []struct {
+		args      cli.ListFlag
+		operation int8
+	}{
+		{set, request.ConfigOperationSet},
+		{appendItems, request.ConfigOperationAppend},
+		{subtractItems, request.ConfigOperationSubtract},
+	} {
+		for _, kv := range op.args {
+			configName, value, err := parseKeyValue(kv)
+			if err != nil {
+				return err
+			}
+			resource.Configs = append(resource.Configs, request.AlterableConfig{
+				Name:            types.CompactString(configName),
+				ConfigOperation: op.operation,
+				Value:           toNullableString(value),
+			})
+		}
+	}
+	for _, configName := range del {
+		resource.Configs = append(resource.Configs, request.AlterableConfig{
+			Name:            types.CompactString(configName),
+			ConfigOperation: request.ConfigOperationDelete,
+		})
+	}
+	if len(resource.Configs) == 0 {
+		return fmt.Errorf("nothing to alter: expected --set, --delete, --append or --subtract")
+	}
+
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.IncrementalAlterConfigs(&request.IncrementalAlterConfigsV1{
+		Resources:    []request.AlterConfigsResource{resource},
+		ValidateOnly: *validateOnly,
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Responses) != 1 {
+		return fmt.Errorf("expected 1 resource in the response, got %d", len(res.Responses))
+	}
+	r := res.Responses[0]
+	if err := protocolError(r.ErrorCode, r.ErrorMessage); err != nil {
+		return err
+	}
+	done := "altered"
+	if *validateOnly {
+		done = "valid"
+	}
+	t := &table{header: []string{"STATUS"}}
+	t.add(done)
+	return printResult(opts, configAlteration{Status: done}, t)
+}
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/cli"
+	"github.com/codecrafters-io/kafka-starter-go/internal/client"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// sortedPartitions returns the keys of m sorted by topic and partition.
+func sortedPartitions[V any](m map[topicPartition]V) []topicPartition {
+	tps := make([]topicPartition, 0, len(m))
+	for tp := range m {
+		tps = append(tps, tp)
+	}
+	sort.Slice(tps, func(i, j int) bool {
+		if tps[i].topic != tps[j].topic {
+			return tps[i].topic < tps[j].topic
+		}
+		return tps[i].partition < tps[j].partition
+	})
+	return tps
+}
+
+type groupSummary struct {
+	Group string `json:"group"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+}
+
+func groupsList(opts *options, args []string) error {
+	fs := newFlagSet("groups list", opts)
+	var states, groupTypes cli.ListFlag
+	fs.Var(&states, "state", "only list groups in this `state`; may be repeated")
+	fs.Var(&groupTypes, "type", "only list groups of this `type`, consumer or classic; may be repeated")
+	if _, err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	req := &request.ListGroupsV5{StatesFilter: []types.CompactString{}, TypesFilter: []types.CompactString{}}
+	for _, s := range states {
+		req.StatesFilter = append(req.StatesFilter, types.CompactString(s))
+	}
+	for _, t := range groupTypes {
+		req.TypesFilter = append(req.TypesFilter, types.CompactString(t))
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.ListGroups(req)
+	if err != nil {
+		return err
+	}
+	if err := protocolError(res.ErrorCode, nil); err != nil {
+		return err
+	}
+
+	groups := []groupSummary{}
+	t := &table{header: []string{"GROUP", "TYPE", "STATE"}}
+	for _, g := range res.Groups {
+		s := groupSummary{Group: string(g.GroupId), Type: string(g.GroupType), State: string(g.GroupState)}
+		groups = append(groups, s)
+		t.add(s.Group, s.Type, s.State)
+	}
+	return printResult(opts, groups, t)
+}
+
+type groupDescription struct {
+	Group   string              `json:"group"`
+	State   string              `json:"state"`
+	Members []memberDescription `json:"members"`
+	Offsets []partitionOffset   `json:"offsets"`
+	Error   string              `json:"error,omitempty"`
+}
+
+type memberDescription struct {
+	MemberId    string   `json:"memberId"`
+	ClientId    string   `json:"clientId"`
+	Host        string   `json:"host"`
+	MemberEpoch int32    `json:"memberEpoch"`
+	Assignment  []string `json:"assignment"`
+}
+
+// partitionOffset has no current offset or lag when the group committed no
+// offset for the partition.
+type partitionOffset struct {
+	Topic         string `json:"topic"`
+	Partition     int32  `json:"partition"`
+	CurrentOffset *int64 `json:"currentOffset"`
+	LogEndOffset  int64  `json:"logEndOffset"`
+	Lag           *int64 `json:"lag"`
+	MemberId      string `json:"memberId,omitempty"`
+}
+
+func groupsDescribe(opts *options, args []string) error {
+	fs := newFlagSet("groups describe <group...>", opts)
+	groupIds, err := parse(fs, opts, args)
+	if err != nil {
+		return err
+	}
+	if len(groupIds) == 0 {
+		return fmt.Errorf("no group given")
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	failed := false
+	described := []groupDescription{}
+	offsets := &table{header: []string{"GROUP", "TOPIC", "PARTITION", "CURRENT-OFFSET", "LOG-END-OFFSET", "LAG", "MEMBER-ID"}}
+	members := &table{header: []string{"GROUP", "MEMBER-ID", "CLIENT-ID", "HOST", "EPOCH", "ASSIGNMENT"}}
+	for _, groupId := range groupIds {
+		d, err := describeGroup(c, groupId)
+		if err != nil {
+			failed = true
+			d = groupDescription{Group: groupId, Members: []memberDescription{}, Offsets: []partitionOffset{}, Error: err.Error()}
+			offsets.add(groupId, "", "", "", "", "", "error: "+err.Error())
+		}
+		described = append(described, d)
+		for _, o := range d.Offsets {
+			current, lag := "-", "-"
+			if o.CurrentOffset != nil {
+				current = strconv.FormatInt(*o.CurrentOffset, 10)
+				lag = strconv.FormatInt(*o.Lag, 10)
+			}
+			memberId := o.MemberId
+			if memberId == "" {
+				memberId = "-"
+			}
+			offsets.add(groupId, o.Topic, strconv.Itoa(int(o.Partition)), current, strconv.FormatInt(o.LogEndOffset, 10), lag, memberId)
+		}
+		for _, m := range d.Members {
+			members.add(groupId, m.MemberId, m.ClientId, m.Host, strconv.Itoa(int(m.MemberEpoch)), strings.Join(m.Assignment, ","))
+		}
+	}
+	tables := []*table{offsets}
+	if len(members.rows) > 0 {
+		tables = append(tables, members)
+	}
+	if err := printResult(opts, described, tables...); err != nil {
+		return err
+	}
+	return failure(failed)
+}
+
+// describeGroup describes a group's members and the offsets of the
+// partitions it committed offsets for or is assigned. Groups that only
+// have committed offsets have no members.
+func describeGroup(c *client.Client, groupId string) (groupDescription, error) {
+	d := groupDescription{Group: groupId, Members: []memberDescription{}, Offsets: []partitionOffset{}}
+	state, groupMembers, err := groupMembers(c, groupId)
+	if err != nil {
+		return d, err
+	}
+	committed, err := committedOffsets(c, groupId)
+	if err != nil {
+		return d, err
+	}
+	if state == "" {
+		if len(committed) == 0 {
+			return d, fmt.Errorf("group %s does not exist", groupId)
+		}
+		state = "Empty"
+	}
+	d.State = state
+
+	owners := make(map[topicPartition]string)
+	for _, m := range groupMembers {
+		md := memberDescription{
+			MemberId:    string(m.MemberId),
+			ClientId:    string(m.ClientId),
+			Host:        string(m.ClientHost),
+			MemberEpoch: m.MemberEpoch,
+			Assignment:  []string{},
+		}
+		for _, t := range m.Assignment.TopicPartitions {
+			for _, p := range t.Partitions {
+				owners[topicPartition{string(t.TopicName), p}] = md.MemberId
+				md.Assignment = append(md.Assignment, fmt.Sprintf("%s-%d", t.TopicName, p))
+			}
+		}
+		d.Members = append(d.Members, md)
+	}
+
+	partitions := make(map[topicPartition]bool)
+	for tp := range committed {
+		partitions[tp] = true
+	}
+	for tp := range owners {
+		partitions[tp] = true
+	}
+	ends, err := listOffsets(c, partitions, request.LatestTimestamp)
+	if err != nil {
+		return d, err
+	}
+	for _, tp := range sortedPartitions(partitions) {
+		o := partitionOffset{Topic: tp.topic, Partition: tp.partition, LogEndOffset: ends[tp], MemberId: owners[tp]}
+		if current, ok := committed[tp]; ok {
+			lag := max(ends[tp]-current, 0)
+			o.CurrentOffset, o.Lag = &current, &lag
+		}
+		d.Offsets = append(d.Offsets, o)
+	}
+	return d, nil
+}
+
+// groupMembers returns the state and members of a consumer group, or no
+// state when there is no such consumer group.
+func groupMembers(c *client.Client, groupId string) (string, []response.Member, error) {
+	res, err := c.ConsumerGroupDescribe(&request.ConsumerGroupDescribeV0{GroupIds: []types.CompactString{types.CompactString(groupId)}})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(res.Groups) != 1 {
+		return "", nil, fmt.Errorf("expected 1 group in the response, got %d", len(res.Groups))
+	}
+	g := res.Groups[0]
+	if g.ErrorCode == constant.GROUP_ID_NOT_FOUND {
+		return "", nil, nil
+	}
+	if err := protocolError(g.ErrorCode, g.ErrorMessage); err != nil {
+		return "", nil, err
+	}
+	return string(g.GroupState), g.Members, nil
+}
+
+// committedOffsets returns every offset a group committed.
+func committedOffsets(c *client.Client, groupId string) (map[topicPartition]int64, error) {
+	res, err := c.OffsetFetch(&request.OffsetFetchV9{
+		Groups: []request.OffsetFetchGroup{{GroupId: types.CompactString(groupId), MemberEpoch: -1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Groups) != 1 {
+		return nil, fmt.Errorf("expected 1 group in the response, got %d", len(res.Groups))
+	}
+	g := res.Groups[0]
+	if err := protocolError(g.ErrorCode, nil); err != nil {
+		return nil, err
+	}
+	offsets := make(map[topicPartition]int64)
+	for _, t := range g.Topics {
+		for _, p := range t.Partitions {
+			if err := protocolError(p.ErrorCode, nil); err != nil {
+				return nil, fmt.Errorf("fetching the offset of %s-%d: %w", t.Name, p.PartitionIndex, err)
+			}
+			if p.CommittedOffset >= 0 {
+				offsets[topicPartition{string(t.Name), p.PartitionIndex}] = p.CommittedOffset
+			}
+		}
+	}
+	return offsets, nil
+}
+
+// listOffsets looks up the offset of each partition for a timestamp, which
+// is -1 for the partitions with no record at or after it.
+func listOffsets(c *client.Client, partitions map[topicPartition]bool, timestamp int64) (map[topicPartition]int64, error) {
+	offsets := make(map[topicPartition]int64)
+	if len(partitions) == 0 {
+		return offsets, nil
+	}
+	req := &request.ListOffsetsV6{ReplicaId: -1}
+	index := make(map[string]int)
+	for _, tp := range sortedPartitions(partitions) {
+		i, ok := index[tp.topic]
+		if !ok {
+			i = len(req.Topics)
+			index[tp.topic] = i
+			req.Topics = append(req.Topics, request.ListOffsetsTopic{Name: types.CompactString(tp.topic)})
+		}
+		req.Topics[i].Partitions = append(req.Topics[i].Partitions, request.ListOffsetsPartition{
+			PartitionIndex:     tp.partition,
+			CurrentLeaderEpoch: -1,
+			Timestamp:          timestamp,
+		})
+	}
+	res, err := c.ListOffsets(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range res.Topics {
+		for _, p := range t.Partitions {
+			if err := protocolError(p.ErrorCode, nil); err != nil {
+				return nil, fmt.Errorf("listing the offsets of %s-%d: %w", t.Name, p.PartitionIndex, err)
+			}
+			offsets[topicPartition{string(t.Name), p.PartitionIndex}] = p.Offset
+		}
+	}
+	return offsets, nil
+}
+
+type offsetReset struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	NewOffset int64  `json:"newOffset"`
+	Error     string `json:"error,omitempty"`
+}
+
+func groupsResetOffsets(opts *options, args []string) error {
+	fs := newFlagSet("groups reset-offsets <group>", opts)
+	var topics cli.ListFlag
+	fs.Var(&topics, "topic", "reset the offsets of this topic, as `topic` or topic:partition,...; may be repeated")
+	allTopics := fs.Bool("all-topics", false, "reset the offsets of every topic the group committed offsets for")
+	toEarliest := fs.Bool("to-earliest", false, "reset to the earliest offsets")
+	toLatest := fs.Bool("to-latest", false, "reset to the latest offsets")
+	toOffset := fs.Int64("to-offset", -1, "reset to this `offset`")
+	toDatetime := fs.String("to-datetime", "", "reset to the first offsets at or after this RFC 3339 `time`")
+	shiftBy := fs.Int64("shift-by", 0, "move the current offsets by `n`, which may be negative")
+	execute := fs.Bool("execute", false, "commit the new offsets; they are only printed otherwise")
+	args, err := parse(fs, opts, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one group")
+	}
+	groupId := args[0]
+	if len(topics) == 0 && !*allTopics || len(topics) > 0 && *allTopics {
+		return fmt.Errorf("expected either --topic or --all-topics")
+	}
+	specs := 0
+	for _, set := range []bool{*toEarliest, *toLatest, *toOffset >= 0, *toDatetime != "", *shiftBy != 0} {
+		if set {
+			specs++
+		}
+	}
+	if specs != 1 {
+		return fmt.Errorf("expected exactly one of --to-earliest, --to-latest, --to-offset, --to-datetime and --shift-by")
+	}
+	var datetime time.Time
+	if *toDatetime != "" {
+		if datetime, err = time.Parse(time.RFC3339, *toDatetime); err != nil {
+			return fmt.Errorf("invalid --to-datetime: %w", err)
+		}
+	}
+
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	state, members, err := groupMembers(c, groupId)
+	if err != nil {
+		return err
+	}
+	if len(members) > 0 {
+		return fmt.Errorf("offsets of group %s can only be reset while it has no members, but it is %s with %d member(s)", groupId, state, len(members))
+	}
+	committed, err := committedOffsets(c, groupId)
+	if err != nil {
+		return err
+	}
+	partitions, err := resetPartitions(c, topics, *allTopics, committed)
+	if err != nil {
+		return err
+	}
+	earliest, err := listOffsets(c, partitions, request.EarliestTimestamp)
+	if err != nil {
+		return err
+	}
+	latest, err := listOffsets(c, partitions, request.LatestTimestamp)
+	if err != nil {
+		return err
+	}
+	var byTime map[topicPartition]int64
+	if !datetime.IsZero() {
+		if byTime, err = listOffsets(c, partitions, datetime.UnixMilli()); err != nil {
+			return err
+		}
+	}
+
+	targets := make(map[topicPartition]int64)
+	for tp := range partitions {
+		var offset int64
+		switch {
+		case *toEarliest:
+			offset = earliest[tp]
+		case *toLatest:
+			offset = latest[tp]
+		case *toOffset >= 0:
+			offset = *toOffset
+		case byTime != nil:
+			offset = byTime[tp]
+			if offset < 0 {
+				offset = latest[tp]
+			}
+		default:
+			current, ok := committed[tp]
+			if !ok {
+				return fmt.Errorf("group %s has no committed offset to shift for %s-%d", groupId, tp.topic, tp.partition)
+			}
+			offset = current + *shiftBy
+		}
+		targets[tp] = min(max(offset, earliest[tp]), latest[tp])
+	}
+
+	errs := make(map[topicPartition]error)
+	if *execute {
+		if errs, err = commitOffsets(c, groupId, targets); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, "Dry run: pass --execute to commit these offsets.")
+	}
+
+	failed := false
+	resets := []offsetReset{}
+	t := &table{header: []string{"GROUP", "TOPIC", "PARTITION", "NEW-OFFSET", "STATUS"}}
+	for _, tp := range sortedPartitions(targets) {
+		done := "planned"
+		if *execute {
+			done = "committed"
+		}
+		r := offsetReset{Topic: tp.topic, Partition: tp.partition, NewOffset: targets[tp]}
+		if err := errs[tp]; err != nil {
+			failed = true
+			r.Error = err.Error()
+		}
+		resets = append(resets, r)
+		t.add(groupId, tp.topic, strconv.Itoa(int(tp.partition)), strconv.FormatInt(r.NewOffset, 10), status(errs[tp], done))
+	}
+	if err := printResult(opts, resets, t); err != nil {
+		return err
+	}
+	return failure(failed)
+}
+
+// resetPartitions returns the partitions named by --topic arguments, or
+// the partitions a group committed offsets for.
+func resetPartitions(c *client.Client, topics []string, allTopics bool, committed map[topicPartition]int64) (map[topicPartition]bool, error) {
+	partitions := make(map[topicPartition]bool)
+	if allTopics {
+		for tp := range committed {
+			partitions[tp] = true
+		}
+		return partitions, nil
+	}
+
+	var names []string
+	explicit := make(map[string][]int32)
+	for _, arg := range topics {
+		name, list, ok := strings.Cut(arg, ":")
+		names = append(names, name)
+		if !ok {
+			continue
+		}
+		for _, s := range strings.Split(list, ",") {
+			p, err := strconv.ParseInt(s, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid partition %q in --topic %s", s, arg)
+			}
+			explicit[name] = append(explicit[name], int32(p))
+		}
+	}
+	res, err := c.Metadata(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range res.Topics {
+		name := nullableString(t.Name)
+		if err := protocolError(t.ErrorCode, nil); err != nil {
+			return nil, fmt.Errorf("describing topic %s: %w", name, err)
+		}
+		if ps, ok := explicit[name]; ok {
+			for _, p := range ps {
+				if p < 0 || int(p) >= len(t.Partitions) {
+					return nil, fmt.Errorf("topic %s has no partition %d", name, p)
+				}
+				partitions[topicPartition{name, p}] = true
+			}
+			continue
+		}
+		for _, p := range t.Partitions {
+			partitions[topicPartition{name, p.PartitionIndex}] = true
+		}
+	}
+	return partitions, nil
+}
+
+// commitOffsets commits offsets on behalf of a group without members and
+// returns the error of each partition that failed.
+func commitOffsets(c *client.Client, groupId string, offsets map[topicPartition]int64) (map[topicPartition]error, error) {
+	req := &request.OffsetCommitV9{GroupId: types.CompactString(groupId), GenerationIdOrMemberEpoch: -1}
+	index := make(map[string]int)
+	for _, tp := range sortedPartitions(offsets) {
+		i, ok := index[tp.topic]
+		if !ok {
+			i = len(req.Topics)
+			index[tp.topic] = i
+			req.Topics = append(req.Topics, request.OffsetCommitTopic{Name: types.CompactString(tp.topic)})
+		}
+		req.Topics[i].Partitions = append(req.Topics[i].Partitions, request.OffsetCommitPartition{
+			PartitionIndex:       tp.partition,
+			CommittedOffset:      offsets[tp],
+			CommittedLeaderEpoch: -1,
+		})
+	}
+	res, err := c.OffsetCommit(req)
+	if err != nil {
+		return nil, err
+	}
+	errs := make(map[topicPartition]error)
+	for _, t := range res.Topics {
+		for _, p := range t.Results {
+			if err := protocolError(p.ErrorCode, nil); err != nil {
+				errs[topicPartition{string(t.Name), p.PartitionIndex}] = err
+			}
+		}
+	}
+	return errs, nil
+}
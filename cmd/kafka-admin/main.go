@@ -0,0 +1,243 @@
+// Command kafka-admin manages the topics, consumer groups, configurations
+// and ACLs of a broker:
+//
+//	kafka-admin [--bootstrap-server host:port] [--output table|json] <resource> <action> [arguments]
+//
+// where resource and action are one of
+//
+//	topics list|describe|create|delete
+//	groups list|describe|reset-offsets
+//	configs describe|alter
+//	acls list|add|remove
+//
+// Run an action with -h for its arguments.
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/client"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// options are the flags every action accepts.
+type options struct {
+	bootstrapServer string
+	output          string
+}
+
+// command runs an action with the arguments that follow it.
+type command func(opts *options, args []string) error
+
+var commands = map[string]map[string]command{
+	"topics": {
+		"list":     topicsList,
+		"describe": topicsDescribe,
+		"create":   topicsCreate,
+		"delete":   topicsDelete,
+	},
+	"groups": {
+		"list":          groupsList,
+		"describe":      groupsDescribe,
+		"reset-offsets": groupsResetOffsets,
+	},
+	"configs": {
+		"describe": configsDescribe,
+		"alter":    configsAlter,
+	},
+	"acls": {
+		"list":   aclsList,
+		"add":    aclsAdd,
+		"remove": aclsRemove,
+	},
+}
+
+// errFailed is returned by actions that reported the failures themselves.
+var errFailed = errors.New("")
+
+func main() {
+	opts := &options{bootstrapServer: "localhost:9092", output: "table"}
+	flag.Usage = usage
+	addOptions(flag.CommandLine, opts)
+	flag.Parse()
+	if flag.NArg() < 2 {
+		usage()
+		os.Exit(2)
+	}
+	actions, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown resource %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := actions[flag.Arg(1)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown action %q for %s\n", flag.Arg(1), flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd(opts, flag.Args()[2:]); err != nil {
+		if err != errFailed {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: kafka-admin [--bootstrap-server host:port] [--output table|json] <resource> <action> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	resources := make([]string, 0, len(commands))
+	for r := range commands {
+		resources = append(resources, r)
+	}
+	sort.Strings(resources)
+	for _, r := range resources {
+		actions := make([]string, 0, len(commands[r]))
+		for a := range commands[r] {
+			actions = append(actions, a)
+		}
+		sort.Strings(actions)
+		fmt.Fprintf(os.Stderr, "  %s %s\n", r, strings.Join(actions, "|"))
+	}
+	fmt.Fprintln(os.Stderr)
+	flag.PrintDefaults()
+}
+
+// addOptions registers the options, defaulting to their current values so
+// they may be given before or after the action.
+func addOptions(fs *flag.FlagSet, opts *options) {
+	fs.StringVar(&opts.bootstrapServer, "bootstrap-server", opts.bootstrapServer, "the `host:port` of the broker")
+	fs.StringVar(&opts.output, "output", opts.output, "the output `format`, table or json")
+}
+
+// newFlagSet returns the flag set of an action, which also accepts the
+// options.
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	addOptions(fs, opts)
+	return fs
+}
+
+// parse parses the flags of an action, which may come before, after or
+// between its positional arguments, and returns the positional arguments.
+func parse(fs *flag.FlagSet, opts *options, args []string) ([]string, error) {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if opts.output != "table" && opts.output != "json" {
+		return nil, fmt.Errorf("unknown output format %q, expected table or json", opts.output)
+	}
+	return positional, nil
+}
+
+func connect(opts *options) (*client.Client, error) {
+	return client.Dial(opts.bootstrapServer, client.Config{
+		ClientId:       "kafka-admin",
+		DialTimeout:    10 * time.Second,
+		RequestTimeout: 30 * time.Second,
+	})
+}
+
+// table is the table output of an action.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// printResult writes v as JSON, or the tables otherwise.
+func printResult(opts *options, v any, tables ...*table) error {
+	if opts.output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Println()
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failure returns errFailed when some result reported an error.
+func failure(failed bool) error {
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// protocolError describes an error code returned by the broker.
+func protocolError(code int16, message *types.CompactString) error {
+	if code == constant.NONE {
+		return nil
+	}
+	if message != nil && *message != "" {
+		return fmt.Errorf("%s (error code %d)", *message, code)
+	}
+	return fmt.Errorf("error code %d", code)
+}
+
+// status is the result column of an action applied to several resources.
+func status(err error, done string) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return done
+}
+
+func nullableString(s *types.CompactString) string {
+	if s == nil {
+		return ""
+	}
+	return string(*s)
+}
+
+func toNullableString(s string) *types.CompactString {
+	cs := types.CompactString(s)
+	return &cs
+}
+
+// parseKeyValue splits a name=value argument.
+func parseKeyValue(s string) (string, string, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("expected name=value, got %q", s)
+	}
+	return name, value, nil
+}
+
+// formatUuid formats a topic id the way the Java tools do.
+func formatUuid(id [16]byte) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/cli"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type topicSummary struct {
+	Name       string `json:"name"`
+	TopicId    string `json:"topicId"`
+	Partitions int    `json:"partitions"`
+	Internal   bool   `json:"internal"`
+}
+
+func topicsList(opts *options, args []string) error {
+	fs := newFlagSet("topics list", opts)
+	excludeInternal := fs.Bool("exclude-internal", false, "leave out internal topics")
+	if _, err := parse(fs, opts, args); err != nil {
+		return err
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	res, err := c.Metadata(nil)
+	if err != nil {
+		return err
+	}
+	topics := []topicSummary{}
+	t := &table{header: []string{"TOPIC", "TOPIC ID", "PARTITIONS", "INTERNAL"}}
+	for _, mt := range res.Topics {
+		if mt.IsInternal && *excludeInternal {
+			continue
+		}
+		s := topicSummary{Name: nullableString(mt.Name), TopicId: formatUuid(mt.TopicId), Partitions: len(mt.Partitions), Internal: mt.IsInternal}
+		topics = append(topics, s)
+		t.add(s.Name, s.TopicId, strconv.Itoa(s.Partitions), strconv.FormatBool(s.Internal))
+	}
+	return printResult(opts, topics, t)
+}
+
+type topicDescription struct {
+	Name       string                 `json:"name"`
+	TopicId    string                 `json:"topicId,omitempty"`
+	Internal   bool                   `json:"internal"`
+	Partitions []partitionDescription `json:"partitions"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+type partitionDescription struct {
+	Partition   int32   `json:"partition"`
+	Leader      int32   `json:"leader"`
+	LeaderEpoch int32   `json:"leaderEpoch"`
+	Replicas    []int32 `json:"replicas"`
+	Isr         []int32 `json:"isr"`
+}
+
+func topicsDescribe(opts *options, args []string) error {
+	fs := newFlagSet("topics describe [topic...]", opts)
+	names, err := parse(fs, opts, args)
+	if err != nil {
+		return err
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// names is nil, which describes every topic, when none is given
+	res, err := c.Metadata(names)
+	if err != nil {
+		return err
+	}
+	failed := false
+	described := []topicDescription{}
+	t := &table{header: []string{"TOPIC", "PARTITION", "LEADER", "LEADER EPOCH", "REPLICAS", "ISR", "ERROR"}}
+	for _, mt := range res.Topics {
+		d := topicDescription{Name: nullableString(mt.Name), Internal: mt.IsInternal, Partitions: []partitionDescription{}}
+		if err := protocolError(mt.ErrorCode, nil); err != nil {
+			failed = true
+			d.Error = err.Error()
+			described = append(described, d)
+			t.add(d.Name, "", "", "", "", "", d.Error)
+			continue
+		}
+		d.TopicId = formatUuid(mt.TopicId)
+		for _, p := range mt.Partitions {
+			pd := partitionDescription{Partition: p.PartitionIndex, Leader: p.LeaderId, LeaderEpoch: p.LeaderEpoch, Replicas: p.ReplicaNodes, Isr: p.IsrNodes}
+			d.Partitions = append(d.Partitions, pd)
+			t.add(d.Name, strconv.Itoa(int(pd.Partition)), strconv.Itoa(int(pd.Leader)), strconv.Itoa(int(pd.LeaderEpoch)), formatIds(pd.Replicas), formatIds(pd.Isr), "")
+		}
+		described = append(described, d)
+	}
+	if err := printResult(opts, described, t); err != nil {
+		return err
+	}
+	return failure(failed)
+}
+
+func formatIds(ids []int32) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(int(id))
+	}
+	return strings.Join(s, ",")
+}
+
+type topicResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+func topicsCreate(opts *options, args []string) error {
+	fs := newFlagSet("topics create <topic...>", opts)
+	partitions := fs.Int("partitions", -1, "the number of partitions, or -1 for the broker's default")
+	replicationFactor := fs.Int("replication-factor", -1, "the replication factor, or -1 for the broker's default")
+	validateOnly := fs.Bool("validate-only", false, "only check that the topics could be created")
+	var configs cli.ListFlag
+	fs.Var(&configs, "config", "a topic configuration, as `name=value`; may be repeated")
+	names, err := parse(fs, opts, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no topic given")
+	}
+	var topicConfigs []request.CreatableTopicConfig
+	for _, kv := range configs {
+		name, value, err := parseKeyValue(kv)
+		if err != nil {
+			return err
+		}
+		topicConfigs = append(topicConfigs, request.CreatableTopicConfig{Name: types.CompactString(name), Value: toNullableString(value)})
+	}
+
+	req := &request.CreateTopicsV7{TimeoutMs: 30000, ValidateOnly: *validateOnly}
+	for _, name := range names {
+		req.Topics = append(req.Topics, request.CreatableTopic{
+			Name:              types.CompactString(name),
+			NumPartitions:     int32(*partitions),
+			ReplicationFactor: int16(*replicationFactor),
+			Configs:           topicConfigs,
+		})
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.CreateTopics(req)
+	if err != nil {
+		return err
+	}
+
+	done := "created"
+	if *validateOnly {
+		done = "valid"
+	}
+	failed := false
+	results := []topicResult{}
+	t := &table{header: []string{"TOPIC", "STATUS"}}
+	for _, r := range res.Topics {
+		err := protocolError(r.ErrorCode, r.ErrorMessage)
+		failed = failed || err != nil
+		results = append(results, topicResult{Name: string(r.Name), Status: status(err, done)})
+		t.add(string(r.Name), status(err, done))
+	}
+	if err := printResult(opts, results, t); err != nil {
+		return err
+	}
+	return failure(failed)
+}
+
+func topicsDelete(opts *options, args []string) error {
+	fs := newFlagSet("topics delete <topic...>", opts)
+	names, err := parse(fs, opts, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no topic given")
+	}
+	req := &request.DeleteTopicsV6{TimeoutMs: 30000}
+	for _, name := range names {
+		req.Topics = append(req.Topics, request.DeleteTopicState{Name: toNullableString(name)})
+	}
+	c, err := connect(opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	res, err := c.DeleteTopics(req)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	results := []topicResult{}
+	t := &table{header: []string{"TOPIC", "STATUS"}}
+	for _, r := range res.Responses {
+		err := protocolError(r.ErrorCode, r.ErrorMessage)
+		failed = failed || err != nil
+		results = append(results, topicResult{Name: nullableString(r.Name), Status: status(err, "deleted")})
+		t.add(nullableString(r.Name), status(err, "deleted"))
+	}
+	if err := printResult(opts, results, t); err != nil {
+		return err
+	}
+	return failure(failed)
+}
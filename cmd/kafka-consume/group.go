@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/client"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/group"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// groupMember is the consumer's membership of a consumer group, which it
+// keeps up with heartbeats that also carry the partitions it owns.
+type groupMember struct {
+	c       *client.Client
+	groupId string
+	topics  []string
+
+	memberId      string
+	epoch         int32 // group.JoinGroupMemberEpoch until the member joins
+	nextHeartbeat time.Time
+	// reported are the partitions the last heartbeat said the member owns
+	reported  map[topicPartition]bool
+	committed map[topicPartition]int64
+
+	topicIds   map[string][16]byte
+	topicNames map[[16]byte]string
+}
+
+func newGroupMember(c *client.Client, groupId string, topics []string) *groupMember {
+	return &groupMember{
+		c:          c,
+		groupId:    groupId,
+		topics:     topics,
+		epoch:      group.JoinGroupMemberEpoch,
+		reported:   make(map[topicPartition]bool),
+		committed:  make(map[topicPartition]int64),
+		topicIds:   make(map[string][16]byte),
+		topicNames: make(map[[16]byte]string),
+	}
+}
+
+// poll sends a heartbeat when one is due, or when the member joins or gave
+// up partitions, and returns the new assignment of the member when it
+// changed. Members fenced by the group lose their partitions and join it
+// again.
+func (m *groupMember) poll(owned map[topicPartition]int64) (map[topicPartition]bool, error) {
+	changed := len(owned) != len(m.reported)
+	for tp := range owned {
+		changed = changed || !m.reported[tp]
+	}
+	if m.epoch != group.JoinGroupMemberEpoch && !changed && time.Now().Before(m.nextHeartbeat) {
+		return nil, nil
+	}
+
+	req := &request.ConsumerGroupHeartbeatV0{
+		GroupId:            types.CompactString(m.groupId),
+		MemberId:           types.CompactString(m.memberId),
+		MemberEpoch:        m.epoch,
+		RebalanceTimeoutMs: -1,
+	}
+	reported := make(map[topicPartition]bool)
+	if m.epoch == group.JoinGroupMemberEpoch {
+		req.RebalanceTimeoutMs = rebalanceTimeoutMs
+		for _, topic := range m.topics {
+			req.SubscribedTopicNames = append(req.SubscribedTopicNames, types.CompactString(topic))
+		}
+	} else {
+		req.TopicPartitions = []request.TopicPartitions{}
+		index := make(map[string]int)
+		for _, tp := range sortedPartitions(owned) {
+			i, ok := index[tp.topic]
+			if !ok {
+				i = len(req.TopicPartitions)
+				index[tp.topic] = i
+				req.TopicPartitions = append(req.TopicPartitions, request.TopicPartitions{TopicId: m.topicIds[tp.topic]})
+			}
+			req.TopicPartitions[i].Partitions = append(req.TopicPartitions[i].Partitions, tp.partition)
+			reported[tp] = true
+		}
+	}
+	res, err := m.c.ConsumerGroupHeartbeat(req)
+	if err != nil {
+		return nil, err
+	}
+	switch res.ErrorCode {
+	case constant.NONE:
+	case constant.UNKNOWN_MEMBER_ID, constant.FENCED_MEMBER_EPOCH:
+		fmt.Fprintf(os.Stderr, "fenced from group %s (error code %d), giving up the partitions and joining again\n", m.groupId, res.ErrorCode)
+		m.epoch = group.JoinGroupMemberEpoch
+		m.reported = make(map[topicPartition]bool)
+		return make(map[topicPartition]bool), nil
+	default:
+		return nil, fmt.Errorf("heartbeat to group %s failed: %s", m.groupId, errorMessage(res.ErrorCode, res.ErrorMessage))
+	}
+
+	if res.MemberId != nil {
+		m.memberId = string(*res.MemberId)
+	}
+	m.epoch = res.MemberEpoch
+	m.reported = reported
+	m.nextHeartbeat = time.Now().Add(time.Duration(res.HeartbeatIntervalMs) * time.Millisecond)
+	if res.Assignment == nil {
+		return nil, nil
+	}
+
+	assignment := make(map[topicPartition]bool)
+	for _, tp := range res.Assignment.TopicPartitions {
+		name, err := m.topicName(tp.TopicId)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range tp.Partitions {
+			assignment[topicPartition{name, p}] = true
+		}
+	}
+	return assignment, nil
+}
+
+// topicName returns the name of an assigned topic, looking up the ids of
+// the subscribed topics again when the id is not known.
+func (m *groupMember) topicName(topicId [16]byte) (string, error) {
+	if name, ok := m.topicNames[topicId]; ok {
+		return name, nil
+	}
+	res, err := m.c.Metadata(m.topics)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range res.Topics {
+		if t.ErrorCode == constant.NONE && t.Name != nil {
+			m.topicIds[string(*t.Name)] = t.TopicId
+			m.topicNames[t.TopicId] = string(*t.Name)
+		}
+	}
+	name, ok := m.topicNames[topicId]
+	if !ok {
+		return "", fmt.Errorf("group %s assigned an unknown topic", m.groupId)
+	}
+	return name, nil
+}
+
+// reassign commits the positions of the partitions the group takes away
+// and starts the partitions it assigns from their committed offsets.
+func (cs *consumer) reassign(assignment map[topicPartition]bool) error {
+	revoked := make(map[topicPartition]int64)
+	for tp, position := range cs.positions {
+		if !assignment[tp] {
+			revoked[tp] = position
+		}
+	}
+	cs.commit(revoked)
+	for tp := range revoked {
+		delete(cs.positions, tp)
+		delete(cs.member.committed, tp)
+	}
+
+	added := make(map[topicPartition]bool)
+	for tp := range assignment {
+		if _, ok := cs.positions[tp]; !ok {
+			added[tp] = true
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	committed, err := cs.committedOffsets(added)
+	if err != nil {
+		return err
+	}
+	uncommitted := make(map[topicPartition]bool)
+	for tp := range added {
+		if offset, ok := committed[tp]; ok {
+			cs.positions[tp] = offset
+			cs.member.committed[tp] = offset
+		} else {
+			uncommitted[tp] = true
+		}
+	}
+	if len(uncommitted) == 0 {
+		return nil
+	}
+	return cs.reset(uncommitted, cs.resetPolicy())
+}
+
+// committedOffsets returns the offsets the group committed for the
+// partitions it committed an offset for.
+func (cs *consumer) committedOffsets(partitions map[topicPartition]bool) (map[topicPartition]int64, error) {
+	memberId := types.CompactString(cs.member.memberId)
+	g := request.OffsetFetchGroup{
+		GroupId:     types.CompactString(cs.member.groupId),
+		MemberId:    &memberId,
+		MemberEpoch: cs.member.epoch,
+		Topics:      []request.OffsetFetchTopic{},
+	}
+	index := make(map[string]int)
+	for _, tp := range sortedPartitions(partitions) {
+		i, ok := index[tp.topic]
+		if !ok {
+			i = len(g.Topics)
+			index[tp.topic] = i
+			g.Topics = append(g.Topics, request.OffsetFetchTopic{Name: types.CompactString(tp.topic)})
+		}
+		g.Topics[i].PartitionIndexes = append(g.Topics[i].PartitionIndexes, tp.partition)
+	}
+	res, err := cs.c.OffsetFetch(&request.OffsetFetchV9{
+		Groups:        []request.OffsetFetchGroup{g},
+		RequireStable: cs.opts.isolationLevel == readCommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Groups) != 1 {
+		return nil, fmt.Errorf("expected 1 group in the response, got %d", len(res.Groups))
+	}
+	if code := res.Groups[0].ErrorCode; code != constant.NONE {
+		return nil, fmt.Errorf("fetching the offsets of group %s failed with error code %d", cs.member.groupId, code)
+	}
+	offsets := make(map[topicPartition]int64)
+	for _, t := range res.Groups[0].Topics {
+		for _, p := range t.Partitions {
+			tp := topicPartition{string(t.Name), p.PartitionIndex}
+			if p.ErrorCode != constant.NONE {
+				return nil, fmt.Errorf("fetching the offset of %s failed with error code %d", tp, p.ErrorCode)
+			}
+			if p.CommittedOffset >= 0 {
+				offsets[tp] = p.CommittedOffset
+			}
+		}
+	}
+	return offsets, nil
+}
+
+// commit commits the positions that moved since they were last committed.
+// Failures are reported on standard error: the records are read again by
+// the next owner of the partition.
+func (cs *consumer) commit(positions map[topicPartition]int64) {
+	m := cs.member
+	if m == nil || m.epoch == group.JoinGroupMemberEpoch {
+		return
+	}
+	req := &request.OffsetCommitV9{
+		GroupId:                   types.CompactString(m.groupId),
+		GenerationIdOrMemberEpoch: m.epoch,
+		MemberId:                  types.CompactString(m.memberId),
+	}
+	index := make(map[string]int)
+	for _, tp := range sortedPartitions(positions) {
+		if committed, ok := m.committed[tp]; ok && committed == positions[tp] {
+			continue
+		}
+		i, ok := index[tp.topic]
+		if !ok {
+			i = len(req.Topics)
+			index[tp.topic] = i
+			req.Topics = append(req.Topics, request.OffsetCommitTopic{Name: types.CompactString(tp.topic)})
+		}
+		req.Topics[i].Partitions = append(req.Topics[i].Partitions, request.OffsetCommitPartition{
+			PartitionIndex:       tp.partition,
+			CommittedOffset:      positions[tp],
+			CommittedLeaderEpoch: -1,
+		})
+	}
+	if len(req.Topics) == 0 {
+		return
+	}
+	res, err := cs.c.OffsetCommit(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "committing the offsets of group %s failed: %s\n", m.groupId, err)
+		return
+	}
+	committed := maps.Clone(positions)
+	for _, t := range res.Topics {
+		for _, p := range t.Results {
+			tp := topicPartition{string(t.Name), p.PartitionIndex}
+			if p.ErrorCode != constant.NONE {
+				fmt.Fprintf(os.Stderr, "committing the offset of %s failed with error code %d\n", tp, p.ErrorCode)
+				delete(committed, tp)
+			}
+		}
+	}
+	maps.Copy(m.committed, committed)
+}
+
+// leave commits the positions of the consumer and leaves the group, which
+// assigns its partitions to the other members.
+func (cs *consumer) leave() {
+	m := cs.member
+	if m.epoch == group.JoinGroupMemberEpoch {
+		return
+	}
+	cs.commit(cs.positions)
+	res, err := cs.c.ConsumerGroupHeartbeat(&request.ConsumerGroupHeartbeatV0{
+		GroupId:            types.CompactString(m.groupId),
+		MemberId:           types.CompactString(m.memberId),
+		MemberEpoch:        group.LeaveGroupMemberEpoch,
+		RebalanceTimeoutMs: -1,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "leaving group %s failed: %s\n", m.groupId, err)
+		return
+	}
+	if res.ErrorCode != constant.NONE {
+		fmt.Fprintf(os.Stderr, "leaving group %s failed: %s\n", m.groupId, errorMessage(res.ErrorCode, res.ErrorMessage))
+	}
+}
+
+func errorMessage(code int16, message *types.CompactString) string {
+	if message != nil && *message != "" {
+		return fmt.Sprintf("%s (error code %d)", *message, code)
+	}
+	return fmt.Sprintf("error code %d", code)
+}
@@ -0,0 +1,534 @@
+// Command kafka-consume reads the records of topics and writes them to
+// standard output, one per line:
+//
+//	kafka-consume [flags] --topic <topic> [--topic <topic>...]
+//
+// Without --group it reads every partition of the topics, or the ones
+// given with --partition, from --offset. With --group it joins the
+// consumer group, reads the partitions the group assigns it from the
+// group's committed offsets and commits its progress.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/cli"
+	"github.com/codecrafters-io/kafka-starter-go/internal/client"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+const (
+	fetchMaxWait       = 500 * time.Millisecond
+	fetchMaxBytes      = 50 << 20
+	partitionMaxBytes  = 1 << 20
+	readUncommitted    = 0
+	readCommitted      = 1
+	rebalanceTimeoutMs = 30000
+)
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+func (tp topicPartition) String() string {
+	return fmt.Sprintf("%s-%d", tp.topic, tp.partition)
+}
+
+// sortedPartitions returns the keys of m sorted by topic and partition.
+func sortedPartitions[V any](m map[topicPartition]V) []topicPartition {
+	tps := make([]topicPartition, 0, len(m))
+	for tp := range m {
+		tps = append(tps, tp)
+	}
+	sort.Slice(tps, func(i, j int) bool {
+		if tps[i].topic != tps[j].topic {
+			return tps[i].topic < tps[j].topic
+		}
+		return tps[i].partition < tps[j].partition
+	})
+	return tps
+}
+
+// formatOptions select what is written of each record.
+type formatOptions struct {
+	json           bool
+	separator      string
+	printKey       bool
+	printHeaders   bool
+	printTimestamp bool
+	printPartition bool
+	printOffset    bool
+}
+
+type options struct {
+	topics         []string
+	partitions     []int32
+	offset         string
+	group          string
+	isolationLevel int8
+	maxMessages    int
+	idleTimeout    time.Duration
+	commitInterval time.Duration
+	format         formatOptions
+}
+
+// consumer reads the partitions it is assigned from their positions, the
+// offsets of the next records to read.
+type consumer struct {
+	c         *client.Client
+	opts      options
+	out       *bufio.Writer
+	positions map[topicPartition]int64
+	member    *groupMember // nil without a group
+	consumed  int
+}
+
+func main() {
+	var opts options
+	bootstrapServer := flag.String("bootstrap-server", "localhost:9092", "the `host:port` of the broker")
+	var topics, partitions cli.ListFlag
+	flag.Var(&topics, "topic", "a `topic` to read; may be repeated")
+	flag.Var(&partitions, "partition", "only read this `partition` of the topics; may be repeated, and is not allowed with --group")
+	flag.StringVar(&opts.offset, "offset", "latest", "where to start reading: earliest, latest or an `offset`; with --group, where to start the partitions the group committed no offset for")
+	flag.StringVar(&opts.group, "group", "", "the consumer `group` to join")
+	isolationLevel := flag.String("isolation-level", "read_uncommitted", "read_committed to skip aborted and ongoing transactions, or read_uncommitted")
+	flag.IntVar(&opts.maxMessages, "max-messages", 0, "exit after reading this many records, or never when 0")
+	flag.DurationVar(&opts.idleTimeout, "timeout", 0, "exit when no record arrives for this long, or never when 0")
+	flag.DurationVar(&opts.commitInterval, "commit-interval", 5*time.Second, "how often the group's offsets are committed")
+	flag.BoolVar(&opts.format.json, "json", false, "write every record as a JSON object with all its fields")
+	flag.StringVar(&opts.format.separator, "separator", "\t", "the `separator` between the fields of a record")
+	flag.BoolVar(&opts.format.printKey, "print-key", false, "write the key of records")
+	flag.BoolVar(&opts.format.printHeaders, "print-headers", false, "write the headers of records")
+	flag.BoolVar(&opts.format.printTimestamp, "print-timestamp", false, "write the timestamp of records")
+	flag.BoolVar(&opts.format.printPartition, "print-partition", false, "write the partition of records")
+	flag.BoolVar(&opts.format.printOffset, "print-offset", false, "write the offset of records")
+	flag.Parse()
+
+	if err := opts.parse(topics, partitions, *isolationLevel); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	c, err := client.Dial(*bootstrapServer, client.Config{
+		ClientId:       "kafka-consume",
+		DialTimeout:    10 * time.Second,
+		RequestTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cs := &consumer{c: c, opts: opts, out: bufio.NewWriter(os.Stdout), positions: make(map[topicPartition]int64)}
+	err = cs.run(ctx)
+	cs.out.Flush()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func (o *options) parse(topics, partitions []string, isolationLevel string) error {
+	if len(topics) == 0 {
+		return fmt.Errorf("no --topic given")
+	}
+	o.topics = topics
+	for _, p := range partitions {
+		partition, err := strconv.ParseInt(p, 10, 32)
+		if err != nil || partition < 0 {
+			return fmt.Errorf("invalid --partition %q", p)
+		}
+		o.partitions = append(o.partitions, int32(partition))
+	}
+	if o.offset != "earliest" && o.offset != "latest" {
+		offset, err := strconv.ParseInt(o.offset, 10, 64)
+		if err != nil || offset < 0 {
+			return fmt.Errorf("invalid --offset %q, expected earliest, latest or an offset", o.offset)
+		}
+		if o.group != "" {
+			return fmt.Errorf("--offset must be earliest or latest with --group")
+		}
+	}
+	if o.group != "" && len(o.partitions) > 0 {
+		return fmt.Errorf("--partition is not allowed with --group, which assigns the partitions")
+	}
+	switch isolationLevel {
+	case "read_uncommitted":
+		o.isolationLevel = readUncommitted
+	case "read_committed":
+		o.isolationLevel = readCommitted
+	default:
+		return fmt.Errorf("unknown --isolation-level %q, expected read_committed or read_uncommitted", isolationLevel)
+	}
+	if o.format.json && (o.format.printKey || o.format.printHeaders || o.format.printTimestamp || o.format.printPartition || o.format.printOffset) {
+		return fmt.Errorf("--json writes every field, so it takes no --print flag")
+	}
+	return nil
+}
+
+func (cs *consumer) run(ctx context.Context) error {
+	if cs.opts.group != "" {
+		cs.member = newGroupMember(cs.c, cs.opts.group, cs.opts.topics)
+		defer cs.leave()
+	} else if err := cs.assignAll(); err != nil {
+		return err
+	}
+
+	lastRecord := time.Now()
+	lastCommit := time.Now()
+	for ctx.Err() == nil {
+		maxWait := fetchMaxWait
+		if cs.member != nil {
+			assignment, err := cs.member.poll(cs.positions)
+			if err != nil {
+				return err
+			}
+			if assignment != nil {
+				if err := cs.reassign(assignment); err != nil {
+					return err
+				}
+			}
+			maxWait = min(maxWait, time.Until(cs.member.nextHeartbeat))
+			if time.Since(lastCommit) >= cs.opts.commitInterval {
+				cs.commit(cs.positions)
+				lastCommit = time.Now()
+			}
+		}
+
+		consumed := cs.consumed
+		if len(cs.positions) == 0 {
+			// wait for the group to assign partitions
+			select {
+			case <-ctx.Done():
+			case <-time.After(maxWait):
+			}
+		} else if err := cs.fetch(maxWait); err != nil {
+			return err
+		}
+		if err := cs.out.Flush(); err != nil {
+			return err
+		}
+
+		if cs.opts.maxMessages > 0 && cs.consumed >= cs.opts.maxMessages {
+			return nil
+		}
+		if cs.consumed > consumed {
+			lastRecord = time.Now()
+		} else if cs.opts.idleTimeout > 0 && time.Since(lastRecord) >= cs.opts.idleTimeout {
+			return nil
+		}
+	}
+	return nil
+}
+
+// assignAll assigns the consumer the partitions of its topics.
+func (cs *consumer) assignAll() error {
+	res, err := cs.c.Metadata(cs.opts.topics)
+	if err != nil {
+		return err
+	}
+	partitions := make(map[topicPartition]bool)
+	for _, t := range res.Topics {
+		name := ""
+		if t.Name != nil {
+			name = string(*t.Name)
+		}
+		if t.ErrorCode == constant.UNKNOWN_TOPIC_OR_PARTITION {
+			return fmt.Errorf("topic %s does not exist", name)
+		}
+		if t.ErrorCode != constant.NONE {
+			return fmt.Errorf("describing topic %s failed with error code %d", name, t.ErrorCode)
+		}
+		for _, p := range t.Partitions {
+			partitions[topicPartition{name, p.PartitionIndex}] = true
+		}
+	}
+	if len(cs.opts.partitions) > 0 {
+		selected := make(map[topicPartition]bool)
+		for _, topic := range cs.opts.topics {
+			for _, p := range cs.opts.partitions {
+				tp := topicPartition{topic, p}
+				if !partitions[tp] {
+					return fmt.Errorf("topic %s has no partition %d", topic, p)
+				}
+				selected[tp] = true
+			}
+		}
+		partitions = selected
+	}
+
+	if offset, err := strconv.ParseInt(cs.opts.offset, 10, 64); err == nil {
+		for tp := range partitions {
+			cs.positions[tp] = offset
+		}
+		return nil
+	}
+	return cs.reset(partitions, cs.opts.offset)
+}
+
+// reset moves the partitions to the start of their logs when policy is
+// earliest, and to their end otherwise.
+func (cs *consumer) reset(partitions map[topicPartition]bool, policy string) error {
+	timestamp := request.LatestTimestamp
+	if policy == "earliest" {
+		timestamp = request.EarliestTimestamp
+	}
+	req := &request.ListOffsetsV6{ReplicaId: -1, IsolationLevel: cs.opts.isolationLevel}
+	index := make(map[string]int)
+	for _, tp := range sortedPartitions(partitions) {
+		i, ok := index[tp.topic]
+		if !ok {
+			i = len(req.Topics)
+			index[tp.topic] = i
+			req.Topics = append(req.Topics, request.ListOffsetsTopic{Name: types.CompactString(tp.topic)})
+		}
+		req.Topics[i].Partitions = append(req.Topics[i].Partitions, request.ListOffsetsPartition{
+			PartitionIndex:     tp.partition,
+			CurrentLeaderEpoch: -1,
+			Timestamp:          timestamp,
+		})
+	}
+	res, err := cs.c.ListOffsets(req)
+	if err != nil {
+		return err
+	}
+	for _, t := range res.Topics {
+		for _, p := range t.Partitions {
+			tp := topicPartition{string(t.Name), p.PartitionIndex}
+			if p.ErrorCode != constant.NONE {
+				return fmt.Errorf("listing the offsets of %s failed with error code %d", tp, p.ErrorCode)
+			}
+			cs.positions[tp] = p.Offset
+		}
+	}
+	return nil
+}
+
+// fetch reads the next records of every partition, waiting up to maxWait
+// for some to arrive.
+func (cs *consumer) fetch(maxWait time.Duration) error {
+	req := &request.FetchV12{
+		ReplicaId:      -1,
+		MaxWaitMs:      int32(max(maxWait, 0) / time.Millisecond),
+		MinBytes:       1,
+		MaxBytes:       fetchMaxBytes,
+		IsolationLevel: cs.opts.isolationLevel,
+		SessionEpoch:   -1,
+	}
+	index := make(map[string]int)
+	for _, tp := range sortedPartitions(cs.positions) {
+		i, ok := index[tp.topic]
+		if !ok {
+			i = len(req.Topics)
+			index[tp.topic] = i
+			req.Topics = append(req.Topics, request.FetchTopic{Topic: types.CompactString(tp.topic)})
+		}
+		req.Topics[i].Partitions = append(req.Topics[i].Partitions, request.FetchPartition{
+			Partition:          tp.partition,
+			CurrentLeaderEpoch: -1,
+			FetchOffset:        cs.positions[tp],
+			LastFetchedEpoch:   -1,
+			LogStartOffset:     -1,
+			PartitionMaxBytes:  partitionMaxBytes,
+		})
+	}
+	res, err := cs.c.Fetch(req)
+	if err != nil {
+		return err
+	}
+	if res.ErrorCode != constant.NONE {
+		return fmt.Errorf("fetch failed with error code %d", res.ErrorCode)
+	}
+
+	outOfRange := make(map[topicPartition]bool)
+	for _, t := range res.Responses {
+		for _, p := range t.Partitions {
+			tp := topicPartition{string(t.Topic), p.PartitionIndex}
+			if _, ok := cs.positions[tp]; !ok {
+				continue
+			}
+			switch p.ErrorCode {
+			case constant.NONE:
+			case constant.OFFSET_OUT_OF_RANGE:
+				fmt.Fprintf(os.Stderr, "offset %d is out of range for %s, resetting it to the %s offset\n", cs.positions[tp], tp, cs.resetPolicy())
+				outOfRange[tp] = true
+				continue
+			default:
+				return fmt.Errorf("fetching %s failed with error code %d", tp, p.ErrorCode)
+			}
+			if err := cs.consume(tp, p.Records, p.AbortedTransactions); err != nil {
+				return err
+			}
+			if cs.opts.maxMessages > 0 && cs.consumed >= cs.opts.maxMessages {
+				return nil
+			}
+		}
+	}
+	if len(outOfRange) > 0 {
+		return cs.reset(outOfRange, cs.resetPolicy())
+	}
+	return nil
+}
+
+// resetPolicy is where the partitions whose position is out of range, or
+// that the group committed no offset for, start.
+func (cs *consumer) resetPolicy() string {
+	if cs.opts.offset == "earliest" {
+		return "earliest"
+	}
+	return "latest"
+}
+
+// consume writes the records of the batches fetched for a partition and
+// moves its position past them. Under read_committed, the batches of the
+// aborted transactions are skipped: a producer's batches are aborted from
+// the first offset of an aborted transaction up to its abort marker.
+func (cs *consumer) consume(tp topicPartition, records []byte, aborted []response.AbortedTransaction) error {
+	batches, err := record.ReadRecordBatches(records)
+	if err != nil {
+		return fmt.Errorf("decoding the records of %s: %w", tp, err)
+	}
+	sort.Slice(aborted, func(i, j int) bool { return aborted[i].FirstOffset < aborted[j].FirstOffset })
+	abortedProducers := make(map[int64]bool)
+
+	for _, b := range batches {
+		if b.LastOffset() < cs.positions[tp] {
+			continue
+		}
+		for len(aborted) > 0 && aborted[0].FirstOffset <= b.LastOffset() {
+			abortedProducers[aborted[0].ProducerId] = true
+			aborted = aborted[1:]
+		}
+		skip := false
+		switch {
+		case b.IsControl():
+			if controlType, ok := b.ControlType(); ok && controlType == record.ControlTypeAbort {
+				delete(abortedProducers, b.ProducerId)
+			}
+			skip = true
+		case b.IsTransactional() && abortedProducers[b.ProducerId]:
+			skip = true
+		case b.Compression() != 0:
+			return fmt.Errorf("the records of %s at offset %d are compressed, which is not supported", tp, b.BaseOffset)
+		}
+		if !skip {
+			for _, rec := range b.Records {
+				offset := b.BaseOffset + int64(rec.OffsetDelta)
+				if offset < cs.positions[tp] {
+					continue
+				}
+				if err := cs.write(tp, offset, b, rec); err != nil {
+					return err
+				}
+				cs.positions[tp] = offset + 1
+				cs.consumed++
+				if cs.opts.maxMessages > 0 && cs.consumed >= cs.opts.maxMessages {
+					return nil
+				}
+			}
+		}
+		cs.positions[tp] = b.LastOffset() + 1
+	}
+	return nil
+}
+
+type jsonRecord struct {
+	Topic         string            `json:"topic"`
+	Partition     int32             `json:"partition"`
+	Offset        int64             `json:"offset"`
+	Timestamp     int64             `json:"timestamp"`
+	TimestampType string            `json:"timestampType"`
+	Key           *string           `json:"key"`
+	Value         *string           `json:"value"`
+	Headers       map[string]string `json:"headers"`
+}
+
+func (cs *consumer) write(tp topicPartition, offset int64, b *record.RecordBatch, rec record.Record) error {
+	timestampType := "CreateTime"
+	timestamp := b.BaseTimestamp + rec.TimestampDelta
+	if b.Attributes&record.TimestampTypeMask != 0 {
+		timestampType, timestamp = "LogAppendTime", b.MaxTimestamp
+	}
+
+	f := cs.opts.format
+	if f.json {
+		j := jsonRecord{
+			Topic:         tp.topic,
+			Partition:     tp.partition,
+			Offset:        offset,
+			Timestamp:     timestamp,
+			TimestampType: timestampType,
+			Key:           nullableString(rec.Key),
+			Value:         nullableString(rec.Value),
+			Headers:       make(map[string]string),
+		}
+		for _, h := range rec.Headers {
+			j.Headers[h.Key] = string(h.Value)
+		}
+		return json.NewEncoder(cs.out).Encode(j)
+	}
+
+	var fields []string
+	if f.printTimestamp {
+		fields = append(fields, timestampType+":"+strconv.FormatInt(timestamp, 10))
+	}
+	if f.printPartition {
+		fields = append(fields, "Partition:"+strconv.Itoa(int(tp.partition)))
+	}
+	if f.printOffset {
+		fields = append(fields, "Offset:"+strconv.FormatInt(offset, 10))
+	}
+	if f.printHeaders {
+		headers := "NO_HEADERS"
+		if len(rec.Headers) > 0 {
+			hs := make([]string, len(rec.Headers))
+			for i, h := range rec.Headers {
+				hs[i] = h.Key + ":" + formatBytes(h.Value)
+			}
+			headers = strings.Join(hs, ",")
+		}
+		fields = append(fields, headers)
+	}
+	if f.printKey {
+		fields = append(fields, formatBytes(rec.Key))
+	}
+	fields = append(fields, formatBytes(rec.Value))
+	_, err := fmt.Fprintln(cs.out, strings.Join(fields, f.separator))
+	return err
+}
+
+func nullableString(b []byte) *string {
+	if b == nil {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
+// formatBytes writes null keys, values and headers as null, like the Java
+// console consumer.
+func formatBytes(b []byte) string {
+	if b == nil {
+		return "null"
+	}
+	return string(b)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+)
+
+type indexEntryDump struct {
+	Offset   int64 `json:"offset"`
+	Position int32 `json:"position"`
+}
+
+type timeIndexEntryDump struct {
+	Timestamp int64 `json:"timestamp"`
+	Offset    int64 `json:"offset"`
+}
+
+type abortedTxnDump struct {
+	ProducerId       int64 `json:"producerId"`
+	FirstOffset      int64 `json:"firstOffset"`
+	LastOffset       int64 `json:"lastOffset"`
+	LastStableOffset int64 `json:"lastStableOffset"`
+}
+
+type producerDump struct {
+	ProducerId            int64 `json:"producerId"`
+	ProducerEpoch         int16 `json:"producerEpoch"`
+	CoordinatorEpoch      int32 `json:"coordinatorEpoch"`
+	CurrentTxnFirstOffset int64 `json:"currentTxnFirstOffset"`
+	LastTimestamp         int64 `json:"lastTimestamp"`
+	LastSequence          int32 `json:"lastSequence"`
+	LastOffset            int64 `json:"lastOffset"`
+}
+
+// segmentBatch is where a batch of the segment an index belongs to lives.
+type segmentBatch struct {
+	position     int32
+	lastOffset   int64
+	maxTimestamp int64
+}
+
+// segmentBatches reads the batches of the segment at path by base offset,
+// or returns false when there is no such segment.
+func segmentBatches(path string) (map[int64]segmentBatch, bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	batches, err := record.ReadRecordBatches(data)
+	if err != nil {
+		return nil, false, err
+	}
+	byOffset := make(map[int64]segmentBatch, len(batches))
+	position := 0
+	for _, b := range batches {
+		byOffset[b.BaseOffset] = segmentBatch{position: int32(position), lastOffset: b.LastOffset(), maxTimestamp: b.MaxTimestamp}
+		position += b.Size()
+	}
+	return byOffset, true, nil
+}
+
+// dumpIndex checks that the entries of an offset index are in order and,
+// unless only a sanity check is asked for, that each points at the batch
+// starting at its offset.
+func dumpIndex(d *fileDump, data []byte, segment string, opts options) {
+	if len(data)%storage.IndexEntrySize != 0 {
+		d.errorf("index file size %d is not a multiple of the entry size %d", len(data), storage.IndexEntrySize)
+	}
+	for i, e := range storage.DecodeIndex(data) {
+		entry := indexEntryDump{Offset: d.BaseOffset + int64(e.RelativeOffset), Position: e.Position}
+		if i > 0 {
+			previous := d.IndexEntries[i-1]
+			if entry.Offset <= previous.Offset || entry.Position <= previous.Position {
+				d.errorf("entry %d (offset %d, position %d) does not follow entry %d (offset %d, position %d)",
+					i, entry.Offset, entry.Position, i-1, previous.Offset, previous.Position)
+			}
+		}
+		d.IndexEntries = append(d.IndexEntries, entry)
+	}
+
+	if !opts.indexSanity {
+		batches, ok, err := segmentBatches(segment)
+		switch {
+		case err != nil:
+			d.errorf("reading segment %s: %s", segment, err)
+		case !ok:
+			d.errorf("segment %s does not exist", segment)
+		default:
+			for _, e := range d.IndexEntries {
+				b, ok := batches[e.Offset]
+				if !ok || b.position != e.Position {
+					d.errorf("offset %d is indexed at position %d, where no batch starts at that offset", e.Offset, e.Position)
+				}
+			}
+		}
+	}
+	d.entriesHidden = opts.indexSanity || opts.verifyIndexOnly
+	if d.entriesHidden {
+		d.IndexEntries = nil
+	}
+}
+
+// dumpTimeIndex checks that the timestamps of a time index increase and,
+// unless only a sanity check is asked for, that each entry points at the
+// last offset of a batch with that max timestamp.
+func dumpTimeIndex(d *fileDump, data []byte, segment string, opts options) {
+	if len(data)%storage.TimeIndexEntrySize != 0 {
+		d.errorf("time index file size %d is not a multiple of the entry size %d", len(data), storage.TimeIndexEntrySize)
+	}
+	for i, e := range storage.DecodeTimeIndex(data) {
+		entry := timeIndexEntryDump{Timestamp: e.Timestamp, Offset: d.BaseOffset + int64(e.RelativeOffset)}
+		if i > 0 {
+			previous := d.TimeIndexEntries[i-1]
+			if entry.Timestamp <= previous.Timestamp || entry.Offset < previous.Offset {
+				d.errorf("entry %d (timestamp %d, offset %d) does not follow entry %d (timestamp %d, offset %d)",
+					i, entry.Timestamp, entry.Offset, i-1, previous.Timestamp, previous.Offset)
+			}
+		}
+		d.TimeIndexEntries = append(d.TimeIndexEntries, entry)
+	}
+
+	if !opts.indexSanity {
+		batches, ok, err := segmentBatches(segment)
+		switch {
+		case err != nil:
+			d.errorf("reading segment %s: %s", segment, err)
+		case !ok:
+			d.errorf("segment %s does not exist", segment)
+		default:
+			byLastOffset := make(map[int64]segmentBatch, len(batches))
+			for _, b := range batches {
+				byLastOffset[b.lastOffset] = b
+			}
+			for _, e := range d.TimeIndexEntries {
+				b, ok := byLastOffset[e.Offset]
+				if !ok {
+					d.errorf("timestamp %d is indexed at offset %d, which ends no batch", e.Timestamp, e.Offset)
+				} else if b.maxTimestamp != e.Timestamp {
+					d.errorf("timestamp %d is indexed at offset %d, whose batch has a max timestamp of %d", e.Timestamp, e.Offset, b.maxTimestamp)
+				}
+			}
+		}
+	}
+	d.entriesHidden = opts.indexSanity || opts.verifyIndexOnly
+	if d.entriesHidden {
+		d.TimeIndexEntries = nil
+	}
+}
+
+func dumpTxnIndex(d *fileDump, data []byte) {
+	if len(data)%storage.TxnIndexEntrySize != 0 {
+		d.errorf("transaction index file size %d is not a multiple of the entry size %d", len(data), storage.TxnIndexEntrySize)
+	}
+	for _, t := range storage.DecodeTxnIndex(data) {
+		if t.FirstOffset > t.LastOffset {
+			d.errorf("aborted transaction of producer %d starts at offset %d, after its last offset %d", t.ProducerId, t.FirstOffset, t.LastOffset)
+		}
+		d.AbortedTxns = append(d.AbortedTxns, abortedTxnDump(t))
+	}
+}
+
+func dumpSnapshot(d *fileDump, path string) error {
+	producers, err := storage.ReadSnapshot(path)
+	if err != nil {
+		return err
+	}
+	for _, p := range producers {
+		d.Producers = append(d.Producers, producerDump{
+			ProducerId:            p.ProducerId,
+			ProducerEpoch:         p.ProducerEpoch,
+			CoordinatorEpoch:      p.CoordinatorEpoch,
+			CurrentTxnFirstOffset: p.CurrentTxnFirstOffset,
+			LastTimestamp:         p.LastTimestamp,
+			LastSequence:          p.LastSequence(),
+			LastOffset:            p.LastOffset(),
+		})
+	}
+	sort.Slice(d.Producers, func(i, j int) bool { return d.Producers[i].ProducerId < d.Producers[j].ProducerId })
+	return nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+var compressionCodecs = []string{"none", "gzip", "snappy", "lz4", "zstd"}
+
+type batchDump struct {
+	BaseOffset           int64        `json:"baseOffset"`
+	LastOffset           int64        `json:"lastOffset"`
+	Count                int32        `json:"count"`
+	BaseSequence         int32        `json:"baseSequence"`
+	LastSequence         int32        `json:"lastSequence"`
+	ProducerId           int64        `json:"producerId"`
+	ProducerEpoch        int16        `json:"producerEpoch"`
+	PartitionLeaderEpoch int32        `json:"partitionLeaderEpoch"`
+	IsTransactional      bool         `json:"isTransactional"`
+	IsControl            bool         `json:"isControl"`
+	Position             int          `json:"position"`
+	TimestampType        string       `json:"timestampType"`
+	MaxTimestamp         int64        `json:"maxTimestamp"`
+	Size                 int          `json:"size"`
+	Magic                int8         `json:"magic"`
+	Compression          string       `json:"compression"`
+	CRC                  uint32       `json:"crc"`
+	Valid                bool         `json:"isValid"`
+	Records              []recordDump `json:"records,omitempty"`
+}
+
+// recordDump has a size of -1 for null keys and values. Key, Value and
+// Headers are only set with --print-data-log, and Metadata replaces Value
+// for metadata records.
+type recordDump struct {
+	Offset        int64             `json:"offset"`
+	TimestampType string            `json:"timestampType"`
+	Timestamp     int64             `json:"timestamp"`
+	KeySize       int               `json:"keySize"`
+	ValueSize     int               `json:"valueSize"`
+	Sequence      int32             `json:"sequence"`
+	HeaderKeys    []string          `json:"headerKeys"`
+	Key           *string           `json:"key,omitempty"`
+	Value         *string           `json:"value,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	ControlType   string            `json:"controlType,omitempty"`
+	Metadata      *metadataDump     `json:"metadata,omitempty"`
+}
+
+type metadataDump struct {
+	Type    string         `json:"type"`
+	Version int8           `json:"version"`
+	Data    map[string]any `json:"data"`
+}
+
+// dumpSegment decodes every batch of a segment. Batches that fail their
+// CRC check are reported with their header only; decoding stops at a batch whose
+// length is not plausible, since the next batch cannot be found.
+func dumpSegment(d *fileDump, data []byte, opts options) {
+	lastOffset := d.BaseOffset - 1
+	for position := 0; position < len(data); {
+		if len(data)-position < record.BatchHeaderSize {
+			d.errorf("%d trailing bytes at position %d are not a batch", len(data)-position, position)
+			return
+		}
+		size := record.LogOverhead + int(int32(binary.BigEndian.Uint32(data[position+8:])))
+		if size < record.BatchHeaderSize || size > len(data)-position {
+			d.errorf("batch at position %d has an invalid size of %d bytes", position, size)
+			return
+		}
+
+		b, err := record.DecodeRecordBatch(data[position : position+size])
+		if err != nil {
+			d.errorf("batch at position %d: %s", position, err)
+			h, _ := record.DecodeBatchHeader(data[position:])
+			h.RecordsData = data[position+record.BatchHeaderSize : position+size]
+			bd := newBatchDump(h, position, options{})
+			bd.Valid = false
+			d.Batches = append(d.Batches, bd)
+			position += size
+			continue
+		}
+		if b.BaseOffset <= lastOffset {
+			d.errorf("batch at position %d starts at offset %d, not after offset %d", position, b.BaseOffset, lastOffset)
+		}
+		lastOffset = b.LastOffset()
+		d.Batches = append(d.Batches, newBatchDump(b, position, opts))
+		position += size
+	}
+}
+
+func newBatchDump(b *record.RecordBatch, position int, opts options) batchDump {
+	bd := batchDump{
+		BaseOffset:           b.BaseOffset,
+		LastOffset:           b.LastOffset(),
+		Count:                b.RecordCount,
+		BaseSequence:         b.BaseSequence,
+		LastSequence:         b.LastSequence(),
+		ProducerId:           b.ProducerId,
+		ProducerEpoch:        b.ProducerEpoch,
+		PartitionLeaderEpoch: b.PartitionLeaderEpoch,
+		IsTransactional:      b.IsTransactional(),
+		IsControl:            b.IsControl(),
+		Position:             position,
+		TimestampType:        timestampType(b),
+		MaxTimestamp:         b.MaxTimestamp,
+		Size:                 b.Size(),
+		Magic:                b.Magic,
+		Compression:          "unknown",
+		CRC:                  b.CRC,
+		Valid:                true,
+	}
+	if c := int(b.Compression()); c < len(compressionCodecs) {
+		bd.Compression = compressionCodecs[c]
+	}
+	if !opts.deepIteration {
+		return bd
+	}
+
+	for _, rec := range b.Records {
+		rd := recordDump{
+			Offset:        b.BaseOffset + int64(rec.OffsetDelta),
+			TimestampType: bd.TimestampType,
+			Timestamp:     b.BaseTimestamp + rec.TimestampDelta,
+			KeySize:       nullableSize(rec.Key),
+			ValueSize:     nullableSize(rec.Value),
+			Sequence:      record.NoSequence,
+			HeaderKeys:    make([]string, len(rec.Headers)),
+		}
+		if b.Attributes&record.TimestampTypeMask != 0 {
+			rd.Timestamp = b.MaxTimestamp
+		}
+		if b.BaseSequence != record.NoSequence {
+			rd.Sequence = int32((int64(b.BaseSequence) + int64(rec.OffsetDelta)) % (1 << 31))
+		}
+		for i, h := range rec.Headers {
+			rd.HeaderKeys[i] = h.Key
+		}
+		switch {
+		case b.IsControl():
+			if controlType, ok := b.ControlType(); ok && controlType == record.ControlTypeCommit {
+				rd.ControlType = "COMMIT"
+			} else if ok {
+				rd.ControlType = "ABORT"
+			}
+		case opts.printDataLog:
+			rd.Key = printable(rec.Key)
+			if len(rec.Headers) > 0 {
+				rd.Headers = make(map[string]string, len(rec.Headers))
+				for _, h := range rec.Headers {
+					rd.Headers[h.Key] = *printable(h.Value)
+				}
+			}
+			if !opts.metadataDecoder {
+				rd.Value = printable(rec.Value)
+				break
+			}
+			mr, err := metadata.DecodeRecord(rec.Value)
+			if err != nil {
+				message := "undecodable metadata record: " + err.Error()
+				rd.Value = &message
+				break
+			}
+			rd.Metadata = newMetadataDump(mr)
+		}
+		bd.Records = append(bd.Records, rd)
+	}
+	return bd
+}
+
+func timestampType(b *record.RecordBatch) string {
+	if b.Attributes&record.TimestampTypeMask != 0 {
+		return "LogAppendTime"
+	}
+	return "CreateTime"
+}
+
+func nullableSize(b []byte) int {
+	if b == nil {
+		return -1
+	}
+	return len(b)
+}
+
+// printable returns text as is and other bytes base64 encoded, and "null"
+// for null keys, values and headers.
+func printable(b []byte) *string {
+	s := "null"
+	if b != nil {
+		s = string(b)
+		if !utf8.Valid(b) || strings.IndexFunc(s, func(r rune) bool { return !unicode.IsPrint(r) && !unicode.IsSpace(r) }) >= 0 {
+			s = "base64:" + base64.StdEncoding.EncodeToString(b)
+		}
+	}
+	return &s
+}
+
+func newMetadataDump(mr metadata.Record) *metadataDump {
+	v := reflect.ValueOf(mr).Elem()
+	return &metadataDump{
+		Type:    v.Type().Name(),
+		Version: mr.Version(),
+		Data:    structFields(v),
+	}
+}
+
+// structFields turns the fields of a metadata record into JSON values,
+// leaving out tagged fields and the fields describing the record itself.
+func structFields(v reflect.Value) map[string]any {
+	fields := make(map[string]any)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if name == "TagBuffer" || name == "RecordType" || name == "RecordVersion" {
+			continue
+		}
+		fields[strings.ToLower(name[:1])+name[1:]] = jsonValue(v.Field(i))
+	}
+	return fields
+}
+
+var (
+	uuidType          = reflect.TypeOf([16]byte{})
+	compactStringType = reflect.TypeOf(types.CompactString(""))
+)
+
+func jsonValue(v reflect.Value) any {
+	switch {
+	case v.Type() == uuidType:
+		id := v.Interface().([16]byte)
+		return base64.RawURLEncoding.EncodeToString(id[:])
+	case v.Type() == compactStringType:
+		return v.String()
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonValue(v.Elem())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes())
+		}
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = jsonValue(v.Index(i))
+		}
+		return values
+	case reflect.Struct:
+		return structFields(v)
+	}
+	return v.Interface()
+}
+
+func printBatch(b batchDump, opts options) {
+	fmt.Printf("baseOffset: %d lastOffset: %d count: %d baseSequence: %d lastSequence: %d producerId: %d producerEpoch: %d partitionLeaderEpoch: %d isTransactional: %t isControl: %t position: %d %s: %d size: %d magic: %d compresscodec: %s crc: %d isvalid: %t\n",
+		b.BaseOffset, b.LastOffset, b.Count, b.BaseSequence, b.LastSequence, b.ProducerId, b.ProducerEpoch, b.PartitionLeaderEpoch,
+		b.IsTransactional, b.IsControl, b.Position, b.TimestampType, b.MaxTimestamp, b.Size, b.Magic, b.Compression, b.CRC, b.Valid)
+	for _, r := range b.Records {
+		fmt.Printf("| offset: %d %s: %d keySize: %d valueSize: %d sequence: %d headerKeys: [%s]",
+			r.Offset, r.TimestampType, r.Timestamp, r.KeySize, r.ValueSize, r.Sequence, strings.Join(r.HeaderKeys, ","))
+		if r.ControlType != "" {
+			fmt.Printf(" endTxnMarker: %s", r.ControlType)
+		}
+		if r.Key != nil {
+			fmt.Printf(" key: %s", *r.Key)
+		}
+		if len(r.Headers) > 0 {
+			headers := make([]string, 0, len(r.Headers))
+			for _, k := range r.HeaderKeys {
+				headers = append(headers, k+":"+r.Headers[k])
+			}
+			fmt.Printf(" headers: %s", strings.Join(headers, ","))
+		}
+		if r.Metadata != nil {
+			payload, _ := json.Marshal(r.Metadata)
+			fmt.Printf(" payload: %s", payload)
+		} else if r.Value != nil {
+			fmt.Printf(" payload: %s", *r.Value)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,189 @@
+// Command kafka-dump-log decodes the files of a partition directory:
+//
+//	kafka-dump-log [flags] <file...>
+//
+// Segment files (.log) are printed batch by batch, with their records when
+// --deep-iteration or --print-data-log is given; the values printed for
+// __cluster_metadata records are decoded as metadata records. Offset,
+// time and transaction indexes (.index, .timeindex, .txnindex) are printed
+// entry by entry and checked against the segment next to them, and
+// producer state snapshots (.snapshot) producer by producer.
+//
+// The exit status is 1 when a batch fails its CRC check or an index does
+// not match its segment.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+)
+
+type options struct {
+	deepIteration   bool
+	printDataLog    bool
+	metadataDecoder bool
+	indexSanity     bool
+	verifyIndexOnly bool
+	json            bool
+}
+
+// implied returns the options with those implied by the others set.
+func (o options) implied() options {
+	o.printDataLog = o.printDataLog || o.metadataDecoder
+	o.deepIteration = o.deepIteration || o.printDataLog
+	return o
+}
+
+// fileDump is what was decoded from a file; only the fields of its type
+// are set.
+type fileDump struct {
+	File             string               `json:"file"`
+	Type             string               `json:"type"`
+	BaseOffset       int64                `json:"baseOffset"`
+	Batches          []batchDump          `json:"batches,omitempty"`
+	IndexEntries     []indexEntryDump     `json:"indexEntries,omitempty"`
+	TimeIndexEntries []timeIndexEntryDump `json:"timeIndexEntries,omitempty"`
+	AbortedTxns      []abortedTxnDump     `json:"abortedTransactions,omitempty"`
+	Producers        []producerDump       `json:"producers,omitempty"`
+	Errors           []string             `json:"errors,omitempty"`
+	entriesHidden    bool
+	opts             options // the options the file was decoded with
+}
+
+func (d *fileDump) errorf(format string, args ...any) {
+	d.Errors = append(d.Errors, fmt.Sprintf(format, args...))
+}
+
+func main() {
+	var opts options
+	flag.BoolVar(&opts.deepIteration, "deep-iteration", false, "print the records of every batch, not only the batch headers")
+	flag.BoolVar(&opts.printDataLog, "print-data-log", false, "print the keys, values and headers of records; implies --deep-iteration")
+	flag.BoolVar(&opts.metadataDecoder, "cluster-metadata-decoder", false, "decode record values as metadata records, which is the default for the files of "+metadata.ClusterMetadataTopic+"; implies --print-data-log")
+	flag.BoolVar(&opts.indexSanity, "index-sanity-check", false, "only check that index entries are in order, without printing them")
+	flag.BoolVar(&opts.verifyIndexOnly, "verify-index-only", false, "only check index entries against their segment, without printing them")
+	flag.BoolVar(&opts.json, "json", false, "write a JSON object per file")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: kafka-dump-log [flags] <file...>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	failed := false
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	for _, path := range flag.Args() {
+		d, err := dump(path, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", path, err)
+			failed = true
+			continue
+		}
+		failed = failed || len(d.Errors) > 0
+		if opts.json {
+			if err := enc.Encode(d); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		} else {
+			printText(d)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// dump decodes a file according to its suffix. Files are named after the
+// first offset they cover. The segments of the metadata log are decoded
+// with the metadata decoder whatever the options.
+func dump(path string, opts options) (*fileDump, error) {
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext)
+	var baseOffset int64
+	if _, err := fmt.Sscanf(name, "%d", &baseOffset); err != nil {
+		return nil, fmt.Errorf("file name %s is not an offset", filepath.Base(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext == storage.LogFileSuffix && isMetadataLog(path) {
+		opts.metadataDecoder = true
+	}
+	opts = opts.implied()
+	d := &fileDump{File: path, BaseOffset: baseOffset, opts: opts}
+	segment := strings.TrimSuffix(path, ext) + storage.LogFileSuffix
+	switch ext {
+	case storage.LogFileSuffix:
+		d.Type = "segment"
+		dumpSegment(d, data, opts)
+	case storage.IndexFileSuffix:
+		d.Type = "offset index"
+		dumpIndex(d, data, segment, opts)
+	case storage.TimeIndexFileSuffix:
+		d.Type = "time index"
+		dumpTimeIndex(d, data, segment, opts)
+	case storage.TxnIndexFileSuffix:
+		d.Type = "transaction index"
+		dumpTxnIndex(d, data)
+	case storage.SnapshotFileSuffix:
+		d.Type = "producer snapshot"
+		if err := dumpSnapshot(d, path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown file type %q", ext)
+	}
+	return d, nil
+}
+
+// isMetadataLog reports whether a segment belongs to the metadata log,
+// whose directory is named after its only partition.
+func isMetadataLog(path string) bool {
+	dir := filepath.Base(filepath.Dir(path))
+	return dir == metadata.ClusterMetadataTopic+"-0"
+}
+
+func printText(d *fileDump) {
+	fmt.Printf("Dumping %s\n", d.File)
+	switch d.Type {
+	case "segment":
+		fmt.Printf("Log starting offset: %d\n", d.BaseOffset)
+		for _, b := range d.Batches {
+			printBatch(b, d.opts)
+		}
+	case "offset index":
+		for _, e := range d.IndexEntries {
+			fmt.Printf("offset: %d position: %d\n", e.Offset, e.Position)
+		}
+	case "time index":
+		for _, e := range d.TimeIndexEntries {
+			fmt.Printf("timestamp: %d offset: %d\n", e.Timestamp, e.Offset)
+		}
+	case "transaction index":
+		for _, t := range d.AbortedTxns {
+			fmt.Printf("producerId: %d firstOffset: %d lastOffset: %d lastStableOffset: %d\n", t.ProducerId, t.FirstOffset, t.LastOffset, t.LastStableOffset)
+		}
+	case "producer snapshot":
+		for _, p := range d.Producers {
+			fmt.Printf("producerId: %d producerEpoch: %d coordinatorEpoch: %d currentTxnFirstOffset: %d lastTimestamp: %d lastSequence: %d lastOffset: %d\n",
+				p.ProducerId, p.ProducerEpoch, p.CoordinatorEpoch, p.CurrentTxnFirstOffset, p.LastTimestamp, p.LastSequence, p.LastOffset)
+		}
+	}
+	if d.entriesHidden && len(d.Errors) == 0 {
+		fmt.Printf("%s is consistent\n", d.File)
+	}
+	for _, err := range d.Errors {
+		fmt.Println("Error:", err)
+	}
+}
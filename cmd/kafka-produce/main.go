@@ -0,0 +1,436 @@
+// Command kafka-produce writes the lines read from standard input to a
+// topic, one record per line:
+//
+//	kafka-produce [flags] --topic <topic> < input
+//
+// Lines hold the record value, or its key and value when --key-separator
+// is given. With --format json, every line is an object such as
+//
+//	{"key": "k", "value": "v", "headers": {"h": "x"}, "partition": 0, "timestamp": 1700000000000}
+//
+// where every field is optional and key and value may be null.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/cli"
+	"github.com/codecrafters-io/kafka-starter-go/internal/client"
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// maxLineSize bounds the size of an input line.
+const maxLineSize = 1 << 20
+
+type options struct {
+	topic        string
+	partition    int
+	partitioner  string
+	keySeparator string
+	format       string
+	headers      []record.Header
+	acks         int16
+	timeout      time.Duration
+	batchSize    int
+	linger       time.Duration
+}
+
+// input is a record read from standard input, not yet given a partition
+// when partition is -1.
+type input struct {
+	line      int
+	partition int32
+	timestamp int64
+	key       []byte
+	value     []byte
+	headers   []record.Header
+}
+
+// jsonInput is a line of JSON input.
+type jsonInput struct {
+	Key       *string           `json:"key"`
+	Value     *string           `json:"value"`
+	Headers   map[string]string `json:"headers"`
+	Partition *int32            `json:"partition"`
+	Timestamp *int64            `json:"timestamp"`
+}
+
+func main() {
+	bootstrapServer := flag.String("bootstrap-server", "localhost:9092", "the `host:port` of the broker")
+	topic := flag.String("topic", "", "the `topic` to write to")
+	partition := flag.Int("partition", -1, "write every record to this `partition` instead of choosing one")
+	partitioner := flag.String("partitioner", "default", "how records are spread over partitions: default, which hashes keys and sticks to a partition per request for records without one, round-robin or random")
+	keySeparator := flag.String("key-separator", "", "split lines into a key and a value at the first occurrence of this `separator`")
+	format := flag.String("format", "line", "the input `format`, line or json")
+	var headers cli.ListFlag
+	flag.Var(&headers, "header", "add a header to every record, as `name=value`; may be repeated")
+	acks := flag.String("acks", "all", "the acknowledgements to wait for: all, 1 or 0")
+	timeout := flag.Duration("timeout", 30*time.Second, "how long the broker may take to acknowledge a request")
+	batchSize := flag.Int("batch-size", 1000, "the most records sent in a request")
+	linger := flag.Duration("linger", 10*time.Millisecond, "how long to wait for more input before sending a request that is not full")
+	flag.Parse()
+
+	opts := options{
+		topic:        *topic,
+		partition:    *partition,
+		partitioner:  *partitioner,
+		keySeparator: *keySeparator,
+		format:       *format,
+		timeout:      *timeout,
+		batchSize:    *batchSize,
+		linger:       *linger,
+	}
+	if err := opts.parse(*acks, headers); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c, err := client.Dial(*bootstrapServer, client.Config{
+		ClientId:       "kafka-produce",
+		DialTimeout:    10 * time.Second,
+		RequestTimeout: opts.timeout + 10*time.Second,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+	ok, err := produce(c, opts, os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func (o *options) parse(acks string, headers []string) error {
+	if o.topic == "" {
+		return fmt.Errorf("no --topic given")
+	}
+	if o.format != "line" && o.format != "json" {
+		return fmt.Errorf("unknown --format %q, expected line or json", o.format)
+	}
+	if o.partitioner != "default" && o.partitioner != "round-robin" && o.partitioner != "random" {
+		return fmt.Errorf("unknown --partitioner %q, expected default, round-robin or random", o.partitioner)
+	}
+	if o.batchSize <= 0 {
+		return fmt.Errorf("--batch-size must be positive")
+	}
+	switch acks {
+	case "all", "-1":
+		o.acks = -1
+	case "1":
+		o.acks = 1
+	case "0":
+		o.acks = 0
+	default:
+		return fmt.Errorf("unknown --acks %q, expected all, 1 or 0", acks)
+	}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected --header name=value, got %q", h)
+		}
+		o.headers = append(o.headers, record.Header{Key: name, Value: []byte(value)})
+	}
+	return nil
+}
+
+// produce writes the records read from in and reports whether all of them
+// were written.
+func produce(c *client.Client, opts options, in io.Reader) (bool, error) {
+	numPartitions, err := partitionCount(c, opts.topic)
+	if err != nil {
+		return false, err
+	}
+	if opts.partition >= numPartitions {
+		return false, fmt.Errorf("topic %s has no partition %d", opts.topic, opts.partition)
+	}
+
+	inputs := make(chan input)
+	var invalid int
+	var readErr error
+	go func() {
+		invalid, readErr = read(in, opts, inputs)
+		close(inputs)
+	}()
+
+	p := &partitioner{kind: opts.partitioner, numPartitions: int32(numPartitions), sticky: -1}
+	ok := true
+	pending := make(map[int32][]input)
+	count := 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		if !send(c, opts, pending) {
+			ok = false
+		}
+		pending = make(map[int32][]input)
+		count = 0
+		p.next()
+	}
+
+	var linger <-chan time.Time
+	for {
+		select {
+		case in, more := <-inputs:
+			if !more {
+				flush()
+				if readErr != nil {
+					return false, readErr
+				}
+				return ok && invalid == 0, nil
+			}
+			if in.partition < 0 {
+				in.partition = p.partition(in.key)
+			} else if in.partition >= int32(numPartitions) {
+				fmt.Fprintf(os.Stderr, "line %d: topic %s has no partition %d\n", in.line, opts.topic, in.partition)
+				ok = false
+				continue
+			}
+			pending[in.partition] = append(pending[in.partition], in)
+			count++
+			if count >= opts.batchSize {
+				flush()
+				linger = nil
+			} else if linger == nil {
+				linger = time.After(opts.linger)
+			}
+		case <-linger:
+			flush()
+			linger = nil
+		}
+	}
+}
+
+func partitionCount(c *client.Client, topic string) (int, error) {
+	res, err := c.Metadata([]string{topic})
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Topics) != 1 {
+		return 0, fmt.Errorf("expected 1 topic in the response, got %d", len(res.Topics))
+	}
+	t := res.Topics[0]
+	if t.ErrorCode == constant.UNKNOWN_TOPIC_OR_PARTITION {
+		return 0, fmt.Errorf("topic %s does not exist", topic)
+	}
+	if t.ErrorCode != constant.NONE {
+		return 0, fmt.Errorf("describing topic %s failed with error code %d", topic, t.ErrorCode)
+	}
+	return len(t.Partitions), nil
+}
+
+// read sends the records of every line of in. It reports the lines it
+// cannot parse on standard error and returns how many there were.
+func read(in io.Reader, opts options, inputs chan<- input) (int, error) {
+	invalid := 0
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		in, err := parseLine(scanner.Text(), opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "line %d: %s\n", line, err)
+			invalid++
+			continue
+		}
+		in.line = line
+		inputs <- in
+	}
+	return invalid, scanner.Err()
+}
+
+func parseLine(line string, opts options) (input, error) {
+	in := input{partition: int32(opts.partition), timestamp: time.Now().UnixMilli(), headers: opts.headers}
+	if opts.format == "line" {
+		value := line
+		if opts.keySeparator != "" {
+			key, v, ok := strings.Cut(line, opts.keySeparator)
+			if !ok {
+				return in, fmt.Errorf("no key separator %q", opts.keySeparator)
+			}
+			in.key, value = []byte(key), v
+		}
+		in.value = []byte(value)
+		return in, nil
+	}
+
+	var j jsonInput
+	if err := json.Unmarshal([]byte(line), &j); err != nil {
+		return in, err
+	}
+	if j.Key != nil {
+		in.key = []byte(*j.Key)
+	}
+	if j.Value != nil {
+		in.value = []byte(*j.Value)
+	}
+	if len(j.Headers) > 0 {
+		in.headers = append([]record.Header{}, opts.headers...)
+		for name, value := range j.Headers {
+			in.headers = append(in.headers, record.Header{Key: name, Value: []byte(value)})
+		}
+	}
+	if j.Partition != nil {
+		if *j.Partition < 0 {
+			return in, fmt.Errorf("invalid partition %d", *j.Partition)
+		}
+		in.partition = *j.Partition
+	}
+	if j.Timestamp != nil {
+		in.timestamp = *j.Timestamp
+	}
+	return in, nil
+}
+
+// partitioner picks the partition of records sent to no partition in
+// particular.
+type partitioner struct {
+	kind          string
+	numPartitions int32
+	counter       int32
+	// sticky is the partition of the records without a key in the current
+	// request with the default partitioner, -1 until one is picked
+	sticky int32
+}
+
+func (p *partitioner) partition(key []byte) int32 {
+	switch p.kind {
+	case "round-robin":
+		partition := p.counter
+		p.counter = (p.counter + 1) % p.numPartitions
+		return partition
+	case "random":
+		return rand.Int32N(p.numPartitions)
+	}
+	if key != nil {
+		return (murmur2(key) & 0x7fffffff) % p.numPartitions
+	}
+	if p.sticky < 0 {
+		p.sticky = rand.Int32N(p.numPartitions)
+	}
+	return p.sticky
+}
+
+// next is called after every request, so that the default partitioner
+// sends the next records without a key to another partition.
+func (p *partitioner) next() {
+	if p.sticky >= 0 && p.numPartitions > 1 {
+		p.sticky = (p.sticky + 1 + rand.Int32N(p.numPartitions-1)) % p.numPartitions
+	}
+}
+
+// murmur2 is the hash the Java client's default partitioner applies to
+// keys, so that both send a key to the same partition.
+func murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := length &^ 3
+	switch length % 4 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+// send writes a batch of records to each partition and reports whether all
+// of them were written.
+func send(c *client.Client, opts options, pending map[int32][]input) bool {
+	topic := request.ProduceTopicData{Name: types.CompactString(opts.topic)}
+	for partition, inputs := range pending {
+		topic.PartitionData = append(topic.PartitionData, request.ProducePartitionData{
+			Index:   partition,
+			Records: newBatch(inputs).Bytes(),
+		})
+	}
+	res, err := c.Produce(&request.ProduceV9{
+		Acks:      opts.acks,
+		TimeoutMs: int32(opts.timeout / time.Millisecond),
+		TopicData: []request.ProduceTopicData{topic},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return false
+	}
+	if res == nil {
+		// acks=0 gets no response
+		return true
+	}
+
+	ok := true
+	for _, t := range res.Responses {
+		for _, p := range t.PartitionResponses {
+			if p.ErrorCode == constant.NONE {
+				continue
+			}
+			ok = false
+			message := "error code " + strconv.Itoa(int(p.ErrorCode))
+			if p.ErrorMessage != nil && *p.ErrorMessage != "" {
+				message = fmt.Sprintf("%s (%s)", *p.ErrorMessage, message)
+			}
+			fmt.Fprintf(os.Stderr, "writing %d records to %s-%d failed: %s\n", len(pending[p.Index]), t.Name, p.Index, message)
+		}
+	}
+	return ok
+}
+
+func newBatch(inputs []input) *record.RecordBatch {
+	b := &record.RecordBatch{
+		PartitionLeaderEpoch: record.NoPartitionLeaderEpoch,
+		LastOffsetDelta:      int32(len(inputs) - 1),
+		BaseTimestamp:        inputs[0].timestamp,
+		MaxTimestamp:         inputs[0].timestamp,
+		ProducerId:           record.NoProducerId,
+		ProducerEpoch:        record.NoProducerEpoch,
+		BaseSequence:         record.NoSequence,
+		Records:              make([]record.Record, len(inputs)),
+	}
+	for i, in := range inputs {
+		b.BaseTimestamp = min(b.BaseTimestamp, in.timestamp)
+		b.MaxTimestamp = max(b.MaxTimestamp, in.timestamp)
+		b.Records[i] = record.Record{OffsetDelta: int32(i), Key: in.key, Value: in.value, Headers: in.headers}
+	}
+	for i, in := range inputs {
+		b.Records[i].TimestampDelta = in.timestamp - b.BaseTimestamp
+	}
+	return b
+}
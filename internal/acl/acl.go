@@ -0,0 +1,161 @@
+package acl
+
+import "strings"
+
+type ResourceType int8
+
+const (
+	ResourceUnknown ResourceType = iota
+	ResourceAny
+	ResourceTopic
+	ResourceGroup
+	ResourceCluster
+	ResourceTransactionalId
+	ResourceDelegationToken
+	ResourceUser
+)
+
+type PatternType int8
+
+const (
+	PatternUnknown PatternType = iota
+	PatternAny
+	// PatternMatch only appears in filters: it matches the literal and
+	// prefixed patterns that apply to a resource name.
+	PatternMatch
+	PatternLiteral
+	PatternPrefixed
+)
+
+type Operation int8
+
+const (
+	OperationUnknown Operation = iota
+	OperationAny
+	OperationAll
+	OperationRead
+	OperationWrite
+	OperationCreate
+	OperationDelete
+	OperationAlter
+	OperationDescribe
+	OperationClusterAction
+	OperationDescribeConfigs
+	OperationAlterConfigs
+	OperationIdempotentWrite
+	OperationCreateTokens
+	OperationDescribeTokens
+)
+
+type Permission int8
+
+const (
+	PermissionUnknown Permission = iota
+	PermissionAny
+	PermissionDeny
+	PermissionAllow
+)
+
+const (
+	// Wildcard matches every resource name of literal patterns, every
+	// principal as User:* and every host.
+	Wildcard = "*"
+
+	WildcardPrincipal = "User:*"
+
+	// ClusterName is the only name of the cluster resource.
+	ClusterName = "kafka-cluster"
+)
+
+// operations lists the operations that apply to each resource type.
+var operations = map[ResourceType][]Operation{
+	ResourceTopic: {
+		OperationRead, OperationWrite, OperationCreate, OperationDelete, OperationAlter,
+		OperationDescribe, OperationDescribeConfigs, OperationAlterConfigs,
+	},
+	ResourceGroup: {
+		OperationRead, OperationDescribe, OperationDelete, OperationDescribeConfigs, OperationAlterConfigs,
+	},
+	ResourceCluster: {
+		OperationCreate, OperationClusterAction, OperationDescribeConfigs, OperationAlterConfigs,
+		OperationIdempotentWrite, OperationAlter, OperationDescribe,
+	},
+	ResourceTransactionalId: {OperationDescribe, OperationWrite},
+}
+
+// implied lists the operations an allowed operation also allows.
+var implied = map[Operation][]Operation{
+	OperationDescribe:        {OperationRead, OperationWrite, OperationDelete, OperationAlter},
+	OperationDescribeConfigs: {OperationAlterConfigs},
+}
+
+// Binding is an access control entry bound to a resource pattern.
+type Binding struct {
+	ResourceType ResourceType
+	ResourceName string
+	PatternType  PatternType
+	Principal    string
+	Host         string
+	Operation    Operation
+	Permission   Permission
+}
+
+// Filter selects bindings; nil strings and the Any values match everything.
+type Filter struct {
+	ResourceType ResourceType
+	ResourceName *string
+	PatternType  PatternType
+	Principal    *string
+	Host         *string
+	Operation    Operation
+	Permission   Permission
+}
+
+// Matches tells whether the filter selects b.
+func (f *Filter) Matches(b Binding) bool {
+	if f.ResourceType != ResourceAny && f.ResourceType != b.ResourceType {
+		return false
+	}
+	if !f.matchesPattern(b) {
+		return false
+	}
+	if f.Principal != nil && *f.Principal != b.Principal {
+		return false
+	}
+	if f.Host != nil && *f.Host != b.Host {
+		return false
+	}
+	if f.Operation != OperationAny && f.Operation != b.Operation {
+		return false
+	}
+	return f.Permission == PermissionAny || f.Permission == b.Permission
+}
+
+func (f *Filter) matchesPattern(b Binding) bool {
+	switch f.PatternType {
+	case PatternAny:
+		return f.ResourceName == nil || *f.ResourceName == b.ResourceName
+	case PatternMatch:
+		return f.ResourceName == nil || matchesResource(b, *f.ResourceName)
+	default:
+		return f.PatternType == b.PatternType && (f.ResourceName == nil || *f.ResourceName == b.ResourceName)
+	}
+}
+
+// matchesResource tells whether the pattern of b applies to the resource
+// called name.
+func matchesResource(b Binding, name string) bool {
+	switch b.PatternType {
+	case PatternLiteral:
+		return b.ResourceName == name || b.ResourceName == Wildcard
+	case PatternPrefixed:
+		return strings.HasPrefix(name, b.ResourceName)
+	}
+	return false
+}
+
+// validPrincipal tells whether p has the form Type:name.
+func validPrincipal(p string) bool {
+	kind, name, ok := strings.Cut(p, ":")
+	return ok && kind != "" && name != ""
+}
@@ -0,0 +1,384 @@
+package acl
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"sync"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type aclError struct {
+	code    int16
+	message string
+}
+
+func (e *aclError) Error() string {
+	return e.message
+}
+
+func newAclError(code int16, format string, args ...any) *aclError {
+	return &aclError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+var errSecurityDisabled = newAclError(constant.SECURITY_DISABLED, "No Authorizer is configured.")
+
+// Config holds the static configuration of the authorizer.
+type Config struct {
+	// Enabled is false when no authorizer class is configured, in which
+	// case every operation is allowed and the ACL APIs are disabled.
+	Enabled    bool
+	SuperUsers []string
+	// AllowEveryoneIfNoAclFound allows operations on resources no ACL
+	// applies to.
+	AllowEveryoneIfNoAclFound bool
+}
+
+// Authorizer decides which operations principals may perform on resources,
+// from the ACLs persisted as AccessControlEntryRecords in the metadata log.
+type Authorizer struct {
+	mu         sync.RWMutex
+	log        *metadata.Log
+	config     Config
+	superUsers map[string]bool
+	bindings   map[[16]byte]Binding
+}
+
+func NewAuthorizer(log *metadata.Log, config Config) *Authorizer {
+	a := &Authorizer{
+		log:        log,
+		config:     config,
+		superUsers: make(map[string]bool),
+		bindings:   make(map[[16]byte]Binding),
+	}
+	for _, u := range config.SuperUsers {
+		a.superUsers[u] = true
+	}
+	log.Register(a.replay)
+	return a
+}
+
+func (a *Authorizer) replay(rec metadata.Record) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch r := rec.(type) {
+	case *metadata.AccessControlEntryRecord:
+		a.bindings[r.Id] = Binding{
+			ResourceType: ResourceType(r.ResourceType),
+			ResourceName: string(r.ResourceName),
+			PatternType:  PatternType(r.PatternType),
+			Principal:    string(r.PrincipalName),
+			Host:         string(r.Host),
+			Operation:    Operation(r.Operation),
+			Permission:   Permission(r.PermissionType),
+		}
+	case *metadata.RemoveAccessControlEntryRecord:
+		delete(a.bindings, r.Id)
+	}
+}
+
+// Authorize tells whether principal, connected from host, may perform op
+// on the resource of type resourceType called name. A matching DENY wins
+// over any ALLOW.
+func (a *Authorizer) Authorize(principal, host string, op Operation, resourceType ResourceType, name string) bool {
+	if !a.config.Enabled || a.superUsers[principal] {
+		return true
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	found, allowed := false, false
+	for _, b := range a.bindings {
+		if b.ResourceType != resourceType || !matchesResource(b, name) {
+			continue
+		}
+		found = true
+		if b.Principal != principal && b.Principal != WildcardPrincipal {
+			continue
+		}
+		if b.Host != host && b.Host != Wildcard {
+			continue
+		}
+		switch b.Permission {
+		case PermissionDeny:
+			if b.Operation == op || b.Operation == OperationAll {
+				return false
+			}
+		case PermissionAllow:
+			if b.Operation == op || b.Operation == OperationAll || implies(b.Operation, op) {
+				allowed = true
+			}
+		}
+	}
+	return allowed || (!found && a.config.AllowEveryoneIfNoAclFound)
+}
+
+func implies(allowed, op Operation) bool {
+	for _, o := range implied[op] {
+		if o == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizedOperations returns the operations principal may perform on a
+// resource as a bit field, where bit n is set for the operation of code n.
+func (a *Authorizer) AuthorizedOperations(principal, host string, resourceType ResourceType, name string) int32 {
+	var ops int32
+	for _, op := range operations[resourceType] {
+		if a.Authorize(principal, host, op, resourceType, name) {
+			ops |= 1 << op
+		}
+	}
+	return ops
+}
+
+func toNullableString(s string) *types.CompactString {
+	cs := types.CompactString(s)
+	return &cs
+}
+
+func validateBinding(b Binding) error {
+	switch {
+	case b.ResourceType < ResourceTopic || b.ResourceType > ResourceUser:
+		return newAclError(constant.INVALID_REQUEST, "Invalid resource type %d", b.ResourceType)
+	case b.PatternType != PatternLiteral && b.PatternType != PatternPrefixed:
+		return newAclError(constant.INVALID_REQUEST, "Invalid pattern type %d", b.PatternType)
+	case b.Operation < OperationAll || b.Operation > OperationDescribeTokens:
+		return newAclError(constant.INVALID_REQUEST, "Invalid operation %d", b.Operation)
+	case b.Permission != PermissionAllow && b.Permission != PermissionDeny:
+		return newAclError(constant.INVALID_REQUEST, "Invalid permission type %d", b.Permission)
+	case b.ResourceName == "":
+		return newAclError(constant.INVALID_REQUEST, "Resource name should not be empty")
+	case b.ResourceType == ResourceCluster && (b.ResourceName != ClusterName || b.PatternType != PatternLiteral):
+		return newAclError(constant.INVALID_REQUEST, "The only valid name for the CLUSTER resource is %s", ClusterName)
+	case !validPrincipal(b.Principal):
+		return newAclError(constant.INVALID_REQUEST, "Could not parse principal from `%s` (no colon is present separating the principal type from the principal name)", b.Principal)
+	case b.Host == "":
+		return newAclError(constant.INVALID_REQUEST, "Host should not be empty")
+	}
+	return nil
+}
+
+func newFilter(f *request.AclFilter) (*Filter, error) {
+	filter := &Filter{
+		ResourceType: ResourceType(f.ResourceTypeFilter),
+		PatternType:  PatternType(f.PatternTypeFilter),
+		Operation:    Operation(f.Operation),
+		Permission:   Permission(f.PermissionType),
+	}
+	filter.ResourceName = (*string)(f.ResourceNameFilter)
+	filter.Principal = (*string)(f.PrincipalFilter)
+	filter.Host = (*string)(f.HostFilter)
+	switch {
+	case filter.ResourceType <= ResourceUnknown || filter.ResourceType > ResourceUser:
+		return nil, newAclError(constant.INVALID_REQUEST, "Invalid resource type filter %d", filter.ResourceType)
+	case filter.PatternType <= PatternUnknown || filter.PatternType > PatternPrefixed:
+		return nil, newAclError(constant.INVALID_REQUEST, "Invalid pattern type filter %d", filter.PatternType)
+	case filter.Operation <= OperationUnknown || filter.Operation > OperationDescribeTokens:
+		return nil, newAclError(constant.INVALID_REQUEST, "Invalid operation filter %d", filter.Operation)
+	case filter.Permission <= PermissionUnknown || filter.Permission > PermissionAllow:
+		return nil, newAclError(constant.INVALID_REQUEST, "Invalid permission type filter %d", filter.Permission)
+	}
+	return filter, nil
+}
+
+func (a *Authorizer) CreateAcls(req *request.CreateAclsV2) *response.CreateAclsV2 {
+	res := &response.CreateAclsV2{Results: make([]response.AclCreationResult, len(req.Creations))}
+	errs := make([]error, len(req.Creations))
+	var records []metadata.Record
+	var created []int
+
+	a.mu.RLock()
+	existing := make(map[Binding]bool, len(a.bindings))
+	for _, b := range a.bindings {
+		existing[b] = true
+	}
+	a.mu.RUnlock()
+
+	for i, c := range req.Creations {
+		if !a.config.Enabled {
+			errs[i] = errSecurityDisabled
+			continue
+		}
+		b := Binding{
+			ResourceType: ResourceType(c.ResourceType),
+			ResourceName: string(c.ResourceName),
+			PatternType:  PatternType(c.ResourcePatternType),
+			Principal:    string(c.Principal),
+			Host:         string(c.Host),
+			Operation:    Operation(c.Operation),
+			Permission:   Permission(c.PermissionType),
+		}
+		if errs[i] = validateBinding(b); errs[i] != nil {
+			continue
+		}
+		// creating an existing ACL succeeds without adding it twice
+		if existing[b] {
+			continue
+		}
+		existing[b] = true
+		rec := &metadata.AccessControlEntryRecord{
+			ResourceType:   c.ResourceType,
+			ResourceName:   c.ResourceName,
+			PatternType:    c.ResourcePatternType,
+			PrincipalName:  c.Principal,
+			Host:           c.Host,
+			Operation:      c.Operation,
+			PermissionType: c.PermissionType,
+		}
+		rand.Read(rec.Id[:])
+		records = append(records, rec)
+		created = append(created, i)
+	}
+	if len(records) > 0 {
+		if err := a.log.Append(records...); err != nil {
+			for _, i := range created {
+				errs[i] = newAclError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+			}
+		}
+	}
+
+	for i, err := range errs {
+		if ae, ok := err.(*aclError); ok {
+			res.Results[i].ErrorCode = ae.code
+			res.Results[i].ErrorMessage = toNullableString(ae.message)
+		}
+	}
+	return res
+}
+
+// matching returns the bindings selected by f, ordered by resource and then
+// by entry.
+func (a *Authorizer) matching(f *Filter) [][16]byte {
+	var ids [][16]byte
+	for id, b := range a.bindings {
+		if f.Matches(b) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return less(a.bindings[ids[i]], a.bindings[ids[j]])
+	})
+	return ids
+}
+
+func less(x, y Binding) bool {
+	switch {
+	case x.ResourceType != y.ResourceType:
+		return x.ResourceType < y.ResourceType
+	case x.ResourceName != y.ResourceName:
+		return x.ResourceName < y.ResourceName
+	case x.PatternType != y.PatternType:
+		return x.PatternType < y.PatternType
+	case x.Principal != y.Principal:
+		return x.Principal < y.Principal
+	case x.Host != y.Host:
+		return x.Host < y.Host
+	case x.Operation != y.Operation:
+		return x.Operation < y.Operation
+	}
+	return x.Permission < y.Permission
+}
+
+func (a *Authorizer) DescribeAcls(req *request.DescribeAclsV2) *response.DescribeAclsV2 {
+	res := &response.DescribeAclsV2{Resources: []response.DescribeAclsResource{}}
+	filter, err := newFilter(&req.AclFilter)
+	if !a.config.Enabled {
+		err = errSecurityDisabled
+	}
+	if ae, ok := err.(*aclError); ok {
+		res.ErrorCode = ae.code
+		res.ErrorMessage = toNullableString(ae.message)
+		return res
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, id := range a.matching(filter) {
+		b := a.bindings[id]
+		n := len(res.Resources)
+		if n == 0 || res.Resources[n-1].ResourceType != int8(b.ResourceType) ||
+			string(res.Resources[n-1].ResourceName) != b.ResourceName ||
+			res.Resources[n-1].PatternType != int8(b.PatternType) {
+			res.Resources = append(res.Resources, response.DescribeAclsResource{
+				ResourceType: int8(b.ResourceType),
+				ResourceName: types.CompactString(b.ResourceName),
+				PatternType:  int8(b.PatternType),
+			})
+			n++
+		}
+		res.Resources[n-1].Acls = append(res.Resources[n-1].Acls, response.AclDescription{
+			Principal:      types.CompactString(b.Principal),
+			Host:           types.CompactString(b.Host),
+			Operation:      int8(b.Operation),
+			PermissionType: int8(b.Permission),
+		})
+	}
+	return res
+}
+
+// DeleteAcls removes the ACLs matched by each filter. An ACL matched by
+// several filters is reported in each of them.
+func (a *Authorizer) DeleteAcls(req *request.DeleteAclsV2) *response.DeleteAclsV2 {
+	res := &response.DeleteAclsV2{FilterResults: make([]response.DeleteAclsFilterResult, len(req.Filters))}
+	matches := make([][][16]byte, len(req.Filters))
+	removed := make(map[[16]byte]Binding)
+	var records []metadata.Record
+
+	a.mu.RLock()
+	for i := range req.Filters {
+		fr := &res.FilterResults[i]
+		fr.MatchingAcls = []response.DeleteAclsMatchingAcl{}
+		filter, err := newFilter(&req.Filters[i].AclFilter)
+		if !a.config.Enabled {
+			err = errSecurityDisabled
+		}
+		if ae, ok := err.(*aclError); ok {
+			fr.ErrorCode = ae.code
+			fr.ErrorMessage = toNullableString(ae.message)
+			continue
+		}
+		matches[i] = a.matching(filter)
+		for _, id := range matches[i] {
+			if _, ok := removed[id]; !ok {
+				removed[id] = a.bindings[id]
+				records = append(records, &metadata.RemoveAccessControlEntryRecord{Id: id})
+			}
+		}
+	}
+	a.mu.RUnlock()
+
+	var appendErr *aclError
+	if len(records) > 0 {
+		if err := a.log.Append(records...); err != nil {
+			appendErr = newAclError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+		}
+	}
+	for i, ids := range matches {
+		for _, id := range ids {
+			b := removed[id]
+			m := response.DeleteAclsMatchingAcl{
+				ResourceType:   int8(b.ResourceType),
+				ResourceName:   types.CompactString(b.ResourceName),
+				PatternType:    int8(b.PatternType),
+				Principal:      types.CompactString(b.Principal),
+				Host:           types.CompactString(b.Host),
+				Operation:      int8(b.Operation),
+				PermissionType: int8(b.Permission),
+			}
+			if appendErr != nil {
+				m.ErrorCode = appendErr.code
+				m.ErrorMessage = toNullableString(appendErr.message)
+			}
+			res.FilterResults[i].MatchingAcls = append(res.FilterResults[i].MatchingAcls, m)
+		}
+	}
+	return res
+}
@@ -0,0 +1,208 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// newTestAuthorizer returns an authorizer with the given configuration,
+// replaying ACLs created over a temporary metadata log.
+func newTestAuthorizer(t *testing.T, config Config, bindings []Binding) *Authorizer {
+	t.Helper()
+	logs, err := storage.NewLogManager(t.TempDir(), storage.DefaultLogConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logs.Close() })
+	log, err := metadata.OpenLog(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ACLs can only be created with the authorizer enabled
+	creator := NewAuthorizer(log, Config{Enabled: true})
+	req := &request.CreateAclsV2{}
+	for _, b := range bindings {
+		req.Creations = append(req.Creations, request.AclCreation{
+			ResourceType:        int8(b.ResourceType),
+			ResourceName:        types.CompactString(b.ResourceName),
+			ResourcePatternType: int8(b.PatternType),
+			Principal:           types.CompactString(b.Principal),
+			Host:                types.CompactString(b.Host),
+			Operation:           int8(b.Operation),
+			PermissionType:      int8(b.Permission),
+		})
+	}
+	for i, r := range creator.CreateAcls(req).Results {
+		if r.ErrorCode != 0 {
+			t.Fatalf("creating %+v: error %d", bindings[i], r.ErrorCode)
+		}
+	}
+
+	if log, err = metadata.OpenLog(logs); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthorizer(log, config)
+	if err := log.Replay(); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func allow(principal string, op Operation, pattern PatternType, topic string) Binding {
+	return Binding{ResourceType: ResourceTopic, ResourceName: topic, PatternType: pattern, Principal: principal,
+		Host: Wildcard, Operation: op, Permission: PermissionAllow}
+}
+
+func deny(principal string, op Operation, pattern PatternType, topic string) Binding {
+	b := allow(principal, op, pattern, topic)
+	b.Permission = PermissionDeny
+	return b
+}
+
+func TestAuthorize(t *testing.T) {
+	enabled := Config{Enabled: true}
+	tests := []struct {
+		name      string
+		config    Config
+		bindings  []Binding
+		principal string
+		host      string
+		op        Operation
+		topic     string
+		want      bool
+	}{
+		{name: "literal", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternLiteral, "orders")},
+			principal: "User:alice", op: OperationRead, topic: "orders", want: true},
+		{name: "literal is not a prefix", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternLiteral, "orders")},
+			principal: "User:alice", op: OperationRead, topic: "orders-eu", want: false},
+		{name: "prefixed", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternPrefixed, "orders")},
+			principal: "User:alice", op: OperationRead, topic: "orders-eu", want: true},
+		{name: "prefixed matches the prefix itself", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternPrefixed, "orders")},
+			principal: "User:alice", op: OperationRead, topic: "orders", want: true},
+		{name: "prefixed longer than the name", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternPrefixed, "orders")},
+			principal: "User:alice", op: OperationRead, topic: "order", want: false},
+		{name: "prefixed * is not a wildcard", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternPrefixed, Wildcard)},
+			principal: "User:alice", op: OperationRead, topic: "orders", want: false},
+		{name: "literal wildcard name", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternLiteral, Wildcard)},
+			principal: "User:alice", op: OperationRead, topic: "orders", want: true},
+		{name: "other principal", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternLiteral, "orders")},
+			principal: "User:bob", op: OperationRead, topic: "orders", want: false},
+		{name: "wildcard principal", config: enabled, bindings: []Binding{allow(WildcardPrincipal, OperationRead, PatternLiteral, "orders")},
+			principal: "User:bob", op: OperationRead, topic: "orders", want: true},
+		{name: "other operation", config: enabled, bindings: []Binding{allow("User:alice", OperationRead, PatternLiteral, "orders")},
+			principal: "User:alice", op: OperationWrite, topic: "orders", want: false},
+		{name: "implied describe", config: enabled, bindings: []Binding{allow("User:alice", OperationWrite, PatternLiteral, "orders")},
+			principal: "User:alice", op: OperationDescribe, topic: "orders", want: true},
+		{name: "all operations", config: enabled, bindings: []Binding{allow("User:alice", OperationAll, PatternLiteral, "orders")},
+			principal: "User:alice", op: OperationDelete, topic: "orders", want: true},
+		{name: "deny over literal allow", config: enabled, bindings: []Binding{
+			allow("User:alice", OperationRead, PatternLiteral, "orders"),
+			deny("User:alice", OperationRead, PatternLiteral, "orders"),
+		}, principal: "User:alice", op: OperationRead, topic: "orders", want: false},
+		{name: "prefixed deny over literal allow", config: enabled, bindings: []Binding{
+			allow("User:alice", OperationAll, PatternLiteral, "orders"),
+			deny("User:alice", OperationWrite, PatternPrefixed, "ord"),
+		}, principal: "User:alice", op: OperationWrite, topic: "orders", want: false},
+		{name: "deny of another operation", config: enabled, bindings: []Binding{
+			allow("User:alice", OperationAll, PatternLiteral, "orders"),
+			deny("User:alice", OperationWrite, PatternPrefixed, "ord"),
+		}, principal: "User:alice", op: OperationRead, topic: "orders", want: true},
+		{name: "wildcard principal deny over allow", config: enabled, bindings: []Binding{
+			allow("User:alice", OperationRead, PatternLiteral, "orders"),
+			deny(WildcardPrincipal, OperationRead, PatternLiteral, Wildcard),
+		}, principal: "User:alice", op: OperationRead, topic: "orders", want: false},
+		{name: "deny all over allow", config: enabled, bindings: []Binding{
+			allow("User:alice", OperationRead, PatternPrefixed, "orders"),
+			deny("User:alice", OperationAll, PatternLiteral, "orders-eu"),
+		}, principal: "User:alice", op: OperationRead, topic: "orders-eu", want: false},
+		{name: "deny of another principal", config: enabled, bindings: []Binding{
+			allow(WildcardPrincipal, OperationRead, PatternLiteral, "orders"),
+			deny("User:bob", OperationRead, PatternLiteral, "orders"),
+		}, principal: "User:alice", op: OperationRead, topic: "orders", want: true},
+		{name: "other host", config: enabled, bindings: []Binding{
+			{ResourceType: ResourceTopic, ResourceName: "orders", PatternType: PatternLiteral, Principal: "User:alice",
+				Host: "10.0.0.1", Operation: OperationRead, Permission: PermissionAllow},
+		}, principal: "User:alice", host: "10.0.0.2", op: OperationRead, topic: "orders", want: false},
+		{name: "no ACL", config: enabled,
+			principal: "User:alice", op: OperationRead, topic: "orders", want: false},
+		{name: "no ACL allowing everyone", config: Config{Enabled: true, AllowEveryoneIfNoAclFound: true},
+			principal: "User:alice", op: OperationRead, topic: "orders", want: true},
+		{name: "ACL of another principal allowing everyone", config: Config{Enabled: true, AllowEveryoneIfNoAclFound: true},
+			bindings:  []Binding{allow("User:bob", OperationRead, PatternLiteral, "orders")},
+			principal: "User:alice", op: OperationRead, topic: "orders", want: false},
+		{name: "super user", config: Config{Enabled: true, SuperUsers: []string{"User:admin"}},
+			bindings:  []Binding{deny(WildcardPrincipal, OperationAll, PatternLiteral, Wildcard)},
+			principal: "User:admin", op: OperationWrite, topic: "orders", want: true},
+		{name: "disabled", config: Config{},
+			bindings:  []Binding{deny(WildcardPrincipal, OperationAll, PatternLiteral, Wildcard)},
+			principal: "User:alice", op: OperationWrite, topic: "orders", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuthorizer(t, tt.config, tt.bindings)
+			host := tt.host
+			if host == "" {
+				host = "127.0.0.1"
+			}
+			if got := a.Authorize(tt.principal, host, tt.op, ResourceTopic, tt.topic); got != tt.want {
+				t.Errorf("Authorize(%s, %s, %d, %s) = %t, want %t", tt.principal, host, tt.op, tt.topic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizedOperations(t *testing.T) {
+	a := newTestAuthorizer(t, Config{Enabled: true}, []Binding{
+		allow("User:alice", OperationWrite, PatternPrefixed, "orders"),
+		deny("User:alice", OperationAlter, PatternLiteral, "orders"),
+	})
+	want := int32(1<<OperationWrite | 1<<OperationDescribe)
+	if got := a.AuthorizedOperations("User:alice", "127.0.0.1", ResourceTopic, "orders"); got != want {
+		t.Errorf("authorized operations %b, want %b", got, want)
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	literal := allow("User:alice", OperationRead, PatternLiteral, "orders")
+	prefixed := allow("User:alice", OperationRead, PatternPrefixed, "ord")
+	wildcard := allow("User:alice", OperationRead, PatternLiteral, Wildcard)
+	name := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		filter  Filter
+		matches []Binding
+		misses  []Binding
+	}{
+		{name: "any pattern", filter: Filter{ResourceType: ResourceTopic, ResourceName: name("orders"), PatternType: PatternAny},
+			matches: []Binding{literal}, misses: []Binding{prefixed, wildcard}},
+		{name: "match", filter: Filter{ResourceType: ResourceTopic, ResourceName: name("orders"), PatternType: PatternMatch},
+			matches: []Binding{literal, prefixed, wildcard}},
+		{name: "match of another name", filter: Filter{ResourceType: ResourceTopic, ResourceName: name("payments"), PatternType: PatternMatch},
+			matches: []Binding{wildcard}, misses: []Binding{literal, prefixed}},
+		{name: "literal", filter: Filter{ResourceType: ResourceTopic, ResourceName: name("orders"), PatternType: PatternLiteral},
+			matches: []Binding{literal}, misses: []Binding{prefixed, wildcard}},
+		{name: "prefixed", filter: Filter{ResourceType: ResourceTopic, PatternType: PatternPrefixed},
+			matches: []Binding{prefixed}, misses: []Binding{literal, wildcard}},
+		{name: "principal", filter: Filter{ResourceType: ResourceAny, PatternType: PatternAny, Principal: name("User:bob")},
+			misses: []Binding{literal, prefixed, wildcard}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Operation, tt.filter.Permission = OperationAny, PermissionAny
+			for _, b := range tt.matches {
+				if !tt.filter.Matches(b) {
+					t.Errorf("%+v does not match", b)
+				}
+			}
+			for _, b := range tt.misses {
+				if tt.filter.Matches(b) {
+					t.Errorf("%+v matches", b)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+// Package cli holds what the command line tools share.
+package cli
+
+import "strings"
+
+// ListFlag is a flag that may be repeated, collecting every value given.
+type ListFlag []string
+
+func (l *ListFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *ListFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
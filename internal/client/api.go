@@ -0,0 +1,292 @@
+package client
+
+import (
+	"fmt"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// Metadata describes the brokers and the given topics, or every topic when
+// topics is nil.
+func (c *Client) Metadata(topics []string) (*response.MetadataV12, error) {
+	cn, version, err := c.prepare(constant.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	req := &request.MetadataV12{IncludeTopicAuthorizedOperations: true}
+	if topics != nil {
+		req.Topics = make([]request.MetadataTopic, len(topics))
+		for i, name := range topics {
+			cs := types.CompactString(name)
+			req.Topics[i].Name = &cs
+		}
+	}
+	r, err := c.roundTrip(cn, constant.Metadata, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadMetadata(r)
+}
+
+// Produce writes record batches. No response is returned when req.Acks is
+// 0, since the broker sends none.
+func (c *Client) Produce(req *request.ProduceV9) (*response.ProduceV9, error) {
+	cn, version, err := c.prepare(constant.Produce)
+	if err != nil {
+		return nil, err
+	}
+	if req.Acks == 0 {
+		_, _, err := cn.send(constant.Produce, version, req, false)
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.Produce, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadProduce(r)
+}
+
+// Fetch reads record batches. Topics may be given by name whatever the
+// negotiated version: from version 13, which identifies topics by id, the
+// ids of named topics are looked up and the names of the topics in the
+// response are filled in.
+func (c *Client) Fetch(req *request.FetchV12) (*response.FetchV12, error) {
+	cn, version, err := c.prepare(constant.Fetch)
+	if err != nil {
+		return nil, err
+	}
+	req.Version = version
+	names := make(map[[16]byte]types.CompactString)
+	if version >= 13 {
+		if err := c.resolveTopicIds(req.Topics); err != nil {
+			return nil, err
+		}
+		for _, t := range req.Topics {
+			names[t.TopicId] = t.Topic
+		}
+	}
+	r, err := c.roundTrip(cn, constant.Fetch, version, req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := response.ReadFetch(r, version)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res.Responses {
+		if name, ok := names[res.Responses[i].TopicId]; ok {
+			res.Responses[i].Topic = name
+		}
+	}
+	return res, nil
+}
+
+// resolveTopicIds fills in the ids of the topics that only have a name.
+func (c *Client) resolveTopicIds(topics []request.FetchTopic) error {
+	var names []string
+	for _, t := range topics {
+		if t.TopicId == [16]byte{} {
+			names = append(names, string(t.Topic))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	res, err := c.Metadata(names)
+	if err != nil {
+		return err
+	}
+	ids := make(map[types.CompactString][16]byte)
+	for _, t := range res.Topics {
+		// topics are only looked up by name, so one without is not of them
+		if t.Name == nil {
+			continue
+		}
+		if t.ErrorCode != constant.NONE {
+			return fmt.Errorf("looking up topic %s failed with error code %d", *t.Name, t.ErrorCode)
+		}
+		ids[*t.Name] = t.TopicId
+	}
+	for i := range topics {
+		if topics[i].TopicId != [16]byte{} {
+			continue
+		}
+		id, ok := ids[topics[i].Topic]
+		if !ok {
+			return fmt.Errorf("looking up topic %s: not in the metadata response", topics[i].Topic)
+		}
+		topics[i].TopicId = id
+	}
+	return nil
+}
+
+func (c *Client) DescribeTopicPartitions(req *request.DescribeTopicPartitionsV0) (*response.DescribeTopicPartitionsV0, error) {
+	cn, version, err := c.prepare(constant.DescribeTopicPartitions)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.DescribeTopicPartitions, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadDescribeTopicPartitions(r)
+}
+
+func (c *Client) CreateTopics(req *request.CreateTopicsV7) (*response.CreateTopicsV7, error) {
+	cn, version, err := c.prepare(constant.CreateTopics)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.CreateTopics, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadCreateTopics(r)
+}
+
+func (c *Client) DeleteTopics(req *request.DeleteTopicsV6) (*response.DeleteTopicsV6, error) {
+	cn, version, err := c.prepare(constant.DeleteTopics)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.DeleteTopics, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadDeleteTopics(r)
+}
+
+func (c *Client) ListOffsets(req *request.ListOffsetsV6) (*response.ListOffsetsV6, error) {
+	cn, version, err := c.prepare(constant.ListOffsets)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.ListOffsets, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadListOffsets(r)
+}
+
+func (c *Client) ListGroups(req *request.ListGroupsV5) (*response.ListGroupsV5, error) {
+	cn, version, err := c.prepare(constant.ListGroups)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.ListGroups, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadListGroups(r)
+}
+
+func (c *Client) ConsumerGroupHeartbeat(req *request.ConsumerGroupHeartbeatV0) (*response.ConsumerGroupHeartbeatV0, error) {
+	cn, version, err := c.prepare(constant.ConsumerGroupHeartbeat)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.ConsumerGroupHeartbeat, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadConsumerGroupHeartbeat(r)
+}
+
+func (c *Client) ConsumerGroupDescribe(req *request.ConsumerGroupDescribeV0) (*response.ConsumerGroupDescribeV0, error) {
+	cn, version, err := c.prepare(constant.ConsumerGroupDescribe)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.ConsumerGroupDescribe, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadConsumerGroupDescribe(r)
+}
+
+func (c *Client) OffsetCommit(req *request.OffsetCommitV9) (*response.OffsetCommitV9, error) {
+	cn, version, err := c.prepare(constant.OffsetCommit)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.OffsetCommit, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadOffsetCommit(r)
+}
+
+func (c *Client) OffsetFetch(req *request.OffsetFetchV9) (*response.OffsetFetchV9, error) {
+	cn, version, err := c.prepare(constant.OffsetFetch)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.OffsetFetch, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadOffsetFetch(r)
+}
+
+func (c *Client) DescribeConfigs(req *request.DescribeConfigsV4) (*response.DescribeConfigsV4, error) {
+	cn, version, err := c.prepare(constant.DescribeConfigs)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.DescribeConfigs, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadDescribeConfigs(r)
+}
+
+func (c *Client) IncrementalAlterConfigs(req *request.IncrementalAlterConfigsV1) (*response.IncrementalAlterConfigsV1, error) {
+	cn, version, err := c.prepare(constant.IncrementalAlterConfigs)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.IncrementalAlterConfigs, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadIncrementalAlterConfigs(r)
+}
+
+func (c *Client) CreateAcls(req *request.CreateAclsV2) (*response.CreateAclsV2, error) {
+	cn, version, err := c.prepare(constant.CreateAcls)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.CreateAcls, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadCreateAcls(r)
+}
+
+func (c *Client) DescribeAcls(req *request.DescribeAclsV2) (*response.DescribeAclsV2, error) {
+	cn, version, err := c.prepare(constant.DescribeAcls)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.DescribeAcls, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadDescribeAcls(r)
+}
+
+func (c *Client) DeleteAcls(req *request.DeleteAclsV2) (*response.DeleteAclsV2, error) {
+	cn, version, err := c.prepare(constant.DeleteAcls)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.roundTrip(cn, constant.DeleteAcls, version, req)
+	if err != nil {
+		return nil, err
+	}
+	return response.ReadDeleteAcls(r)
+}
@@ -0,0 +1,228 @@
+// Package client talks to a broker with the request and response types the
+// broker itself uses.
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+const (
+	softwareName    = "kafka-starter-go"
+	softwareVersion = "0.1.0"
+)
+
+// ErrClosed is returned by requests made after Close.
+var ErrClosed = errors.New("client is closed")
+
+// UnsupportedVersionError is returned when the client and the broker have
+// no version of an API in common.
+type UnsupportedVersionError struct {
+	ApiKey int16
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("the broker supports no version of API key %d the client supports", e.ApiKey)
+}
+
+// supportedVersions are the versions of each API the client can speak.
+// They are all flexible, so responses other than ApiVersions have a header
+// with tagged fields.
+var supportedVersions = map[int16][2]int16{
+	constant.Produce:                 {9, 11},
+	constant.Fetch:                   {12, 16},
+	constant.Metadata:                {12, 12},
+	constant.ApiVersions:             {3, 4},
+	constant.DescribeTopicPartitions: {0, 0},
+	constant.CreateTopics:            {7, 7},
+	constant.DeleteTopics:            {6, 6},
+	constant.ListOffsets:             {6, 8},
+	constant.ListGroups:              {5, 5},
+	constant.ConsumerGroupHeartbeat:  {0, 0},
+	constant.ConsumerGroupDescribe:   {0, 0},
+	constant.OffsetCommit:            {9, 9},
+	constant.OffsetFetch:             {9, 9},
+	constant.DescribeConfigs:         {4, 4},
+	constant.IncrementalAlterConfigs: {1, 1},
+	constant.CreateAcls:              {2, 3},
+	constant.DescribeAcls:            {2, 3},
+	constant.DeleteAcls:              {2, 3},
+}
+
+type Config struct {
+	ClientId string
+	// DialTimeout and RequestTimeout are unbounded when zero
+	DialTimeout    time.Duration
+	RequestTimeout time.Duration
+}
+
+// Client sends requests to a broker over a single connection, which is
+// opened again when it fails. It is safe for concurrent use: the requests
+// of concurrent callers are pipelined.
+type Client struct {
+	addr   string
+	config Config
+
+	mu     sync.Mutex
+	conn   *conn
+	closed bool
+}
+
+// Dial connects to the broker at addr and negotiates API versions.
+func Dial(addr string, config Config) (*Client, error) {
+	c := &Client{addr: addr, config: config}
+	if _, err := c.connection(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes the connection; requests in flight fail.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn != nil {
+		c.conn.fail(ErrClosed)
+	}
+	return nil
+}
+
+// connection returns the current connection, opening a new one when there
+// is none or it failed.
+func (c *Client) connection() (*conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if c.conn != nil && c.conn.failure() == nil {
+		return c.conn, nil
+	}
+	nc, err := net.DialTimeout("tcp", c.addr, c.config.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cn := newConn(nc, c.config.ClientId)
+	if err := c.negotiate(cn); err != nil {
+		cn.fail(err)
+		return nil, err
+	}
+	c.conn = cn
+	return cn, nil
+}
+
+// negotiate picks the highest version of each API both sides support. A
+// broker that does not serve the ApiVersions version sent answers in v0
+// with the versions it does serve, and the request is sent again in the
+// highest one.
+func (c *Client) negotiate(cn *conn) error {
+	version := supportedVersions[constant.ApiVersions][1]
+	for {
+		r, err := c.roundTrip(cn, constant.ApiVersions, version, &request.ApiVersionsV4{
+			Version:               version,
+			ClientSoftwareName:    softwareName,
+			ClientSoftwareVersion: softwareVersion,
+		})
+		if err != nil {
+			return err
+		}
+		// every version starts with the error code, which tells which
+		// version the rest is in
+		var errorCode int16
+		if err := binary.Read(r, binary.BigEndian, &errorCode); err != nil {
+			return fmt.Errorf("decoding ApiVersions response: %w", err)
+		}
+		r.Seek(-2, io.SeekCurrent)
+		responseVersion := version
+		if errorCode == constant.UNSUPPORTED_VERSION {
+			responseVersion = 0
+		}
+		res, err := response.ReadAPIVersions(r, responseVersion)
+		if err != nil {
+			return fmt.Errorf("decoding ApiVersions response: %w", err)
+		}
+
+		if res.ErrorCode == constant.UNSUPPORTED_VERSION {
+			i := slices.IndexFunc(res.ApiVersions, func(v response.APIVersion) bool {
+				return v.ApiKey == constant.ApiVersions
+			})
+			if i < 0 || res.ApiVersions[i].MaxVersion >= version ||
+				res.ApiVersions[i].MaxVersion < supportedVersions[constant.ApiVersions][0] {
+				return &UnsupportedVersionError{ApiKey: constant.ApiVersions}
+			}
+			version = res.ApiVersions[i].MaxVersion
+			continue
+		}
+		if res.ErrorCode != constant.NONE {
+			return fmt.Errorf("ApiVersions failed with error code %d", res.ErrorCode)
+		}
+		cn.versions = make(map[int16]int16)
+		for _, v := range res.ApiVersions {
+			supported, ok := supportedVersions[v.ApiKey]
+			if !ok {
+				continue
+			}
+			if lo, hi := max(supported[0], v.MinVersion), min(supported[1], v.MaxVersion); lo <= hi {
+				cn.versions[v.ApiKey] = hi
+			}
+		}
+		return nil
+	}
+}
+
+// prepare returns the connection to send a request of an API on and the
+// version to send it with.
+func (c *Client) prepare(apiKey int16) (*conn, int16, error) {
+	cn, err := c.connection()
+	if err != nil {
+		return nil, 0, err
+	}
+	version, ok := cn.versions[apiKey]
+	if !ok {
+		return nil, 0, &UnsupportedVersionError{ApiKey: apiKey}
+	}
+	return cn, version, nil
+}
+
+// roundTrip sends a request and returns its response body.
+func (c *Client) roundTrip(cn *conn, apiKey, version int16, body request.RequestBody) (*bytes.Reader, error) {
+	ch, correlationId, err := cn.send(apiKey, version, body, true)
+	if err != nil {
+		return nil, err
+	}
+	var timeout <-chan time.Time
+	if c.config.RequestTimeout > 0 {
+		timer := time.NewTimer(c.config.RequestTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var payload []byte
+	select {
+	case p, ok := <-ch:
+		if !ok {
+			return nil, cn.failure()
+		}
+		payload = p
+	case <-timeout:
+		cn.forget(correlationId)
+		return nil, fmt.Errorf("request with correlation id %d timed out after %s", correlationId, c.config.RequestTimeout)
+	}
+
+	r := bytes.NewReader(payload)
+	if _, err := response.ReadResponseHeader(r, apiKey, version); err != nil {
+		return nil, fmt.Errorf("decoding response header: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"testing"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+)
+
+// serveApiVersions accepts a connection and answers its ApiVersions
+// requests as a broker serving ApiVersions up to maxVersion would, sending
+// the version of each request on versions.
+func serveApiVersions(t *testing.T, maxVersion int16, versions chan<- int16) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		defer close(versions)
+		for {
+			sizeBuf := make([]byte, 4)
+			if _, err := io.ReadFull(conn, sizeBuf); err != nil {
+				return
+			}
+			buf := make([]byte, 4+binary.BigEndian.Uint32(sizeBuf))
+			copy(buf, sizeBuf)
+			if _, err := io.ReadFull(conn, buf[4:]); err != nil {
+				return
+			}
+			req, err := request.UnmarshallRequest(buf)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rh := req.Header.(*request.RequestHeaderV2)
+			versions <- rh.RequestApiVersion
+
+			body := &response.APIVersionsResponseV4{
+				Version:                rh.RequestApiVersion,
+				FinalizedFeaturesEpoch: -1,
+				ApiVersions: []response.APIVersion{
+					{ApiKey: constant.ApiVersions, MinVersion: 0, MaxVersion: maxVersion},
+					{ApiKey: constant.Metadata, MinVersion: 0, MaxVersion: 12},
+				},
+			}
+			if rh.RequestApiVersion > maxVersion {
+				body.Version, body.ErrorCode = 0, constant.UNSUPPORTED_VERSION
+			}
+			m, err := response.Response{
+				Header: response.NewResponseHeader(constant.ApiVersions, body.Version, rh.CorrelationId),
+				Body:   body,
+			}.Encode()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := m.WriteTo(conn); err != nil {
+				return
+			}
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxVersion   int16
+		wantVersions []int16
+		wantErr      bool
+	}{
+		{name: "latest", maxVersion: 4, wantVersions: []int16{4}},
+		{name: "newer broker", maxVersion: 5, wantVersions: []int16{4}},
+		{name: "older broker", maxVersion: 3, wantVersions: []int16{4, 3}},
+		{name: "no flexible version", maxVersion: 2, wantVersions: []int16{4}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := make(chan int16, 10)
+			c, err := Dial(serveApiVersions(t, tt.maxVersion, versions), Config{ClientId: "test"})
+			if tt.wantErr {
+				var unsupported *UnsupportedVersionError
+				if !errors.As(err, &unsupported) || unsupported.ApiKey != constant.ApiVersions {
+					t.Fatalf("dialing: %v, want the ApiVersions versions unsupported", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if v, ok := c.conn.versions[constant.Metadata]; !ok || v != 12 {
+					t.Errorf("Metadata version %d (%t), want 12", v, ok)
+				}
+				c.Close()
+			}
+
+			var got []int16
+			for v := range versions {
+				got = append(got, v)
+			}
+			if !slices.Equal(got, tt.wantVersions) {
+				t.Errorf("ApiVersions requests in versions %v, want %v", got, tt.wantVersions)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// maxResponseSize bounds the size prefix of incoming responses
+const maxResponseSize = 100 * 1024 * 1024
+
+// conn is one connection to the broker. Requests are written in turn and
+// may be in flight together; responses are matched to their request by
+// correlation id.
+type conn struct {
+	nc       net.Conn
+	clientId string
+
+	writeMu       sync.Mutex
+	correlationId int32
+
+	mu      sync.Mutex
+	pending map[int32]chan []byte
+	err     error // why the connection failed, nil while it is usable
+
+	// versions are the negotiated versions of the APIs both sides support
+	versions map[int16]int16
+}
+
+func newConn(nc net.Conn, clientId string) *conn {
+	c := &conn{nc: nc, clientId: clientId, pending: make(map[int32]chan []byte)}
+	go c.readLoop()
+	return c
+}
+
+// send writes a request and returns the channel its response is delivered
+// on, or nil when the request has no response.
+func (c *conn) send(apiKey, version int16, body request.RequestBody, hasResponse bool) (chan []byte, int32, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.correlationId++
+	header := &request.RequestHeaderV2{
+		RequestApiKey:     apiKey,
+		RequestApiVersion: version,
+		CorrelationId:     c.correlationId,
+		ClientId:          types.NullableString{Length: int16(len(c.clientId)), Data: c.clientId},
+	}
+	b, err := request.Request{Header: header, Body: body}.MarshallRequest()
+	if err != nil {
+		return nil, 0, fmt.Errorf("encoding request: %w", err)
+	}
+
+	var ch chan []byte
+	if hasResponse {
+		ch = make(chan []byte, 1)
+		c.mu.Lock()
+		if c.err != nil {
+			c.mu.Unlock()
+			return nil, 0, c.err
+		}
+		c.pending[header.CorrelationId] = ch
+		c.mu.Unlock()
+	}
+	if _, err := c.nc.Write(b); err != nil {
+		c.fail(err)
+		return nil, 0, c.failure()
+	}
+	return ch, header.CorrelationId, nil
+}
+
+// forget stops waiting for the response of a request that timed out.
+func (c *conn) forget(correlationId int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, correlationId)
+}
+
+func (c *conn) readLoop() {
+	for {
+		var size uint32
+		if err := binary.Read(c.nc, binary.BigEndian, &size); err != nil {
+			c.fail(err)
+			return
+		}
+		if size < 4 || size > maxResponseSize {
+			c.fail(fmt.Errorf("invalid response size %d", size))
+			return
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(c.nc, payload); err != nil {
+			c.fail(err)
+			return
+		}
+		correlationId := int32(binary.BigEndian.Uint32(payload))
+		c.mu.Lock()
+		ch, ok := c.pending[correlationId]
+		delete(c.pending, correlationId)
+		c.mu.Unlock()
+		// responses nobody waits for anymore are dropped
+		if ok {
+			ch <- payload
+		}
+	}
+}
+
+// fail closes the connection and wakes up every request waiting on it.
+func (c *conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = fmt.Errorf("connection to %s failed: %w", c.nc.RemoteAddr(), err)
+	c.nc.Close()
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+// failure returns why the connection failed, or nil.
+func (c *conn) failure() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
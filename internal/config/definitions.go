@@ -0,0 +1,335 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+)
+
+// Type is the type of a configuration as reported by DescribeConfigs.
+type Type int8
+
+const (
+	TypeUnknown Type = iota
+	TypeBoolean
+	TypeString
+	TypeInt
+	TypeShort
+	TypeLong
+	TypeDouble
+	TypeList
+	TypeClass
+	TypePassword
+)
+
+// Source tells where the value of a configuration comes from, from the most
+// to the least specific.
+type Source int8
+
+const (
+	SourceUnknown Source = iota
+	SourceDynamicTopic
+	SourceDynamicBroker
+	SourceDynamicDefaultBroker
+	SourceStaticBroker
+	SourceDefault
+)
+
+type ResourceType int8
+
+const (
+	ResourceTopic  ResourceType = 2
+	ResourceBroker ResourceType = 4
+)
+
+// Definition describes one configuration.
+type Definition struct {
+	Name    string
+	Type    Type
+	Default string
+	Doc     string
+	// BrokerSynonym is the broker configuration a topic configuration
+	// falls back to.
+	BrokerSynonym string
+	// ReadOnly broker configurations can only be set in the static config.
+	ReadOnly  bool
+	Sensitive bool
+	validate  func(value string) error
+}
+
+// Validate checks that value has the definition's type and is allowed.
+func (d *Definition) Validate(value string) error {
+	switch d.Type {
+	case TypeBoolean:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid value %s for configuration %s: expected true or false", value, d.Name)
+		}
+	case TypeInt:
+		if _, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return fmt.Errorf("invalid value %s for configuration %s: not a number of type INT", value, d.Name)
+		}
+	case TypeShort:
+		if _, err := strconv.ParseInt(value, 10, 16); err != nil {
+			return fmt.Errorf("invalid value %s for configuration %s: not a number of type SHORT", value, d.Name)
+		}
+	case TypeLong:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("invalid value %s for configuration %s: not a number of type LONG", value, d.Name)
+		}
+	case TypeDouble:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("invalid value %s for configuration %s: not a number of type DOUBLE", value, d.Name)
+		}
+	}
+	if d.validate != nil {
+		if err := d.validate(value); err != nil {
+			return fmt.Errorf("invalid value %s for configuration %s: %s", value, d.Name, err)
+		}
+	}
+	return nil
+}
+
+func atLeast(min int64) func(string) error {
+	return func(value string) error {
+		if n, _ := strconv.ParseInt(value, 10, 64); n < min {
+			return fmt.Errorf("value must be at least %d", min)
+		}
+		return nil
+	}
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("string must be one of: %s", strings.Join(allowed, ", "))
+	}
+}
+
+func listOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, item := range SplitList(value) {
+			if err := oneOf(allowed...)(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func singleItem(value string) error {
+	if len(SplitList(value)) != 1 {
+		return fmt.Errorf("exactly one item is supported")
+	}
+	return nil
+}
+
+func apiKeys(value string) error {
+	for _, item := range SplitList(value) {
+		if _, ok := ParseApiKey(item); !ok && item != "*" {
+			return fmt.Errorf("unknown API key %s", item)
+		}
+	}
+	return nil
+}
+
+func hostCounts(value string) error {
+	_, err := ParseHostCounts(value)
+	return err
+}
+
+// ParseHostCounts parses a list of host:count items, like the overrides of
+// max.connections.per.ip.
+func ParseHostCounts(value string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, item := range SplitList(value) {
+		i := strings.LastIndexByte(item, ':')
+		if i <= 0 {
+			return nil, fmt.Errorf("%s is not of the form host:count", item)
+		}
+		n, err := strconv.ParseInt(item[i+1:], 10, 32)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s is not of the form host:count", item)
+		}
+		counts[strings.Trim(item[:i], "[]")] = int(n)
+	}
+	return counts, nil
+}
+
+// ParseApiKey returns the API key of a name like Produce, or of a number.
+func ParseApiKey(s string) (int16, bool) {
+	if n, err := strconv.ParseInt(s, 10, 16); err == nil {
+		_, ok := constant.ApiKeyNames[int16(n)]
+		return int16(n), ok
+	}
+	for key, name := range constant.ApiKeyNames {
+		if strings.EqualFold(name, s) {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+// SplitList splits the value of a list configuration into its items.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+var topicDefinitions = []*Definition{
+	{
+		Name: "cleanup.policy", Type: TypeList, Default: "delete", BrokerSynonym: "log.cleanup.policy",
+		Doc:      "The retention policy to use on log segments: delete discards old segments when their retention time or size limit has been reached, compact keeps them.",
+		validate: listOf("delete", "compact"),
+	},
+	{
+		Name: "compression.type", Type: TypeString, Default: "producer", BrokerSynonym: "compression.type",
+		Doc:      "The final compression type for a given topic; producer means retaining the original compression codec set by the producer.",
+		validate: oneOf("uncompressed", "zstd", "lz4", "snappy", "gzip", "producer"),
+	},
+	{
+		Name: "delete.retention.ms", Type: TypeLong, Default: "86400000", BrokerSynonym: "log.cleaner.delete.retention.ms",
+		Doc:      "The amount of time to retain tombstone markers for log compacted topics.",
+		validate: atLeast(0),
+	},
+	{
+		Name: "index.interval.bytes", Type: TypeInt, Default: "4096", BrokerSynonym: "log.index.interval.bytes",
+		Doc:      "How frequently an entry is added to the offset index, in bytes of batches appended.",
+		validate: atLeast(0),
+	},
+	{
+		Name: "max.message.bytes", Type: TypeInt, Default: "1048588", BrokerSynonym: "message.max.bytes",
+		Doc:      "The largest record batch size allowed.",
+		validate: atLeast(0),
+	},
+	{
+		Name: "message.timestamp.type", Type: TypeString, Default: "CreateTime", BrokerSynonym: "log.message.timestamp.type",
+		Doc:      "Define whether the timestamp in the message is message create time or log append time.",
+		validate: oneOf("CreateTime", "LogAppendTime"),
+	},
+	{
+		Name: "min.insync.replicas", Type: TypeInt, Default: "1", BrokerSynonym: "min.insync.replicas",
+		Doc:      "The minimum number of replicas that must acknowledge a write for acks=all to succeed.",
+		validate: atLeast(1),
+	},
+	{
+		Name: "retention.bytes", Type: TypeLong, Default: "-1", BrokerSynonym: "log.retention.bytes",
+		Doc: "The maximum size a partition can grow to before old segments are discarded; -1 means no limit.",
+	},
+	{
+		Name: "retention.ms", Type: TypeLong, Default: "604800000", BrokerSynonym: "log.retention.ms",
+		Doc:      "The maximum time a segment is retained before it is discarded; -1 means no time limit.",
+		validate: atLeast(-1),
+	},
+	{
+		Name: "segment.bytes", Type: TypeInt, Default: "1073741824", BrokerSynonym: "log.segment.bytes",
+		Doc:      "The segment file size for the log.",
+		validate: atLeast(14),
+	},
+	{
+		Name: "segment.ms", Type: TypeLong, Default: "604800000", BrokerSynonym: "log.roll.ms",
+		Doc:      "The period of time after which a segment is rolled even if it is not full.",
+		validate: atLeast(1),
+	},
+	{
+		Name: "unclean.leader.election.enable", Type: TypeBoolean, Default: "false", BrokerSynonym: "unclean.leader.election.enable",
+		Doc: "Whether replicas not in the ISR set may be elected as leader as a last resort.",
+	},
+}
+
+var brokerOnlyDefinitions = []*Definition{
+	{Name: "node.id", Type: TypeInt, Default: "1", ReadOnly: true, Doc: "The node ID of this broker.", validate: atLeast(0)},
+	{Name: "log.dirs", Type: TypeList, Default: "/tmp/kraft-combined-logs", ReadOnly: true, Doc: "The directories in which the log data is kept; only one is supported.", validate: singleItem},
+	{Name: "num.partitions", Type: TypeInt, Default: "1", Doc: "The default number of partitions per topic.", validate: atLeast(1)},
+	{Name: "default.replication.factor", Type: TypeInt, Default: "1", ReadOnly: true, Doc: "The default replication factor for automatically created topics."},
+	{Name: "auto.create.topics.enable", Type: TypeBoolean, Default: "true", ReadOnly: true, Doc: "Enable auto creation of topics on the server."},
+	{Name: "listeners", Type: TypeList, Default: "PLAINTEXT://0.0.0.0:9092", ReadOnly: true, Doc: "The listeners the broker accepts connections on, as NAME://host:port."},
+	{Name: "advertised.listeners", Type: TypeList, Default: "", ReadOnly: true, Doc: "The addresses clients are told to connect to, as NAME://host:port for listeners of the same name; listeners not advertised are described at the address the client connected to."},
+	{Name: "listener.security.protocol.map", Type: TypeList, Default: "PLAINTEXT:PLAINTEXT,SSL:SSL,SASL_PLAINTEXT:SASL_PLAINTEXT,SASL_SSL:SASL_SSL", ReadOnly: true, Doc: "Map between listener names and security protocols."},
+	{Name: "sasl.enabled.mechanisms", Type: TypeList, Default: "SCRAM-SHA-256,SCRAM-SHA-512", Doc: "The SASL mechanisms clients of SASL_PLAINTEXT and SASL_SSL listeners may authenticate with.", validate: listOf("PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512")},
+	{Name: "ssl.keystore.location", Type: TypeString, Default: "", ReadOnly: true, Doc: "The PEM file holding the private key and certificate chain of SSL and SASL_SSL listeners."},
+	{Name: "ssl.truststore.location", Type: TypeString, Default: "", ReadOnly: true, Doc: "The PEM file holding the CA certificates client certificates are verified against."},
+	{Name: "ssl.client.auth", Type: TypeString, Default: "none", ReadOnly: true, Doc: "Whether TLS listeners request or require a client certificate.", validate: oneOf("required", "requested", "none")},
+	{Name: "ssl.principal.mapping.rules", Type: TypeString, Default: "DEFAULT", ReadOnly: true, Doc: "Rules mapping the distinguished name of client certificates to principal names, as RULE:pattern/replacement/[LU] entries or DEFAULT."},
+	{Name: "authorizer.class.name", Type: TypeClass, Default: "", ReadOnly: true, Doc: "The authorizer enforcing ACLs; empty to allow every operation.", validate: oneOf("", "org.apache.kafka.metadata.authorizer.StandardAuthorizer")},
+	{Name: "super.users", Type: TypeString, Default: "", ReadOnly: true, Doc: "Principals allowed every operation regardless of ACLs, separated by semicolons."},
+	{Name: "allow.everyone.if.no.acl.found", Type: TypeBoolean, Default: "false", ReadOnly: true, Doc: "Whether operations on resources no ACL applies to are allowed."},
+	{Name: "socket.request.max.bytes", Type: TypeInt, Default: "104857600", ReadOnly: true, Doc: "The maximum number of bytes in a request.", validate: atLeast(1)},
+	{Name: "max.in.flight", Type: TypeInt, Default: "5", Doc: "The maximum number of requests read from a connection before their responses are sent; changes apply to new connections.", validate: atLeast(1)},
+	{Name: "max.connections", Type: TypeInt, Default: "2147483647", Doc: "The maximum number of client connections; new connections wait for others to close beyond it.", validate: atLeast(0)},
+	{Name: "max.connections.per.ip", Type: TypeInt, Default: "2147483647", Doc: "The maximum number of connections from each IP address; new connections beyond it are closed.", validate: atLeast(0)},
+	{Name: "max.connections.per.ip.overrides", Type: TypeString, Default: "", Doc: "Per host or IP address overrides of max.connections.per.ip, as host:count items separated by commas.", validate: hostCounts},
+	{Name: "connections.max.idle.ms", Type: TypeLong, Default: "600000", Doc: "The time after which connections without requests in flight are closed.", validate: atLeast(1)},
+	{Name: "broker.log.level", Type: TypeString, Default: "INFO", Doc: "The level below which broker log messages are discarded.", validate: oneOf("DEBUG", "INFO", "WARN", "ERROR")},
+	{Name: "broker.log.format", Type: TypeString, Default: "text", ReadOnly: true, Doc: "The format of broker log messages, text or json.", validate: oneOf("text", "json")},
+	{Name: "request.log.api.keys", Type: TypeList, Default: "", Doc: "The API keys, by name or number, whose requests and responses are logged with their payloads redacted; * for every API key.", validate: apiKeys},
+	{Name: "metrics.listener", Type: TypeString, Default: "", ReadOnly: true, Doc: "The host:port of the HTTP listener serving Prometheus metrics at /metrics; empty to disable it."},
+	{Name: "max.request.partition.size.limit", Type: TypeInt, Default: "2000", ReadOnly: true, Doc: "The maximum number of partitions a DescribeTopicPartitions response describes.", validate: atLeast(1)},
+	{Name: "quota.window.num", Type: TypeInt, Default: "11", ReadOnly: true, Doc: "The number of samples client quotas are measured over.", validate: atLeast(1)},
+	{Name: "quota.window.size.seconds", Type: TypeInt, Default: "1", ReadOnly: true, Doc: "The time span of each sample client quotas are measured over.", validate: atLeast(1)},
+	{Name: "log.retention.check.interval.ms", Type: TypeLong, Default: "300000", ReadOnly: true, Doc: "The frequency in milliseconds at which logs are checked for segments to delete.", validate: atLeast(1)},
+}
+
+var (
+	topicConfigs  = make(map[string]*Definition)
+	brokerConfigs = make(map[string]*Definition)
+)
+
+func init() {
+	for _, d := range topicDefinitions {
+		topicConfigs[d.Name] = d
+		// every topic configuration is backed by a broker configuration
+		// with the same type, default and validation
+		synonym := *d
+		synonym.Name = d.BrokerSynonym
+		synonym.BrokerSynonym = ""
+		brokerConfigs[synonym.Name] = &synonym
+	}
+	for _, d := range brokerOnlyDefinitions {
+		brokerConfigs[d.Name] = d
+	}
+}
+
+// Lookup returns the definition of a configuration of a resource type.
+func Lookup(resourceType ResourceType, name string) (*Definition, bool) {
+	var d *Definition
+	switch resourceType {
+	case ResourceTopic:
+		d = topicConfigs[name]
+	case ResourceBroker:
+		d = brokerConfigs[name]
+	}
+	return d, d != nil
+}
+
+// Definitions returns the configurations of a resource type ordered by name.
+func Definitions(resourceType ResourceType) []*Definition {
+	var defs map[string]*Definition
+	switch resourceType {
+	case ResourceTopic:
+		defs = topicConfigs
+	case ResourceBroker:
+		defs = brokerConfigs
+	}
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]*Definition, len(names))
+	for i, name := range names {
+		result[i] = defs[name]
+	}
+	return result
+}
@@ -0,0 +1,508 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type configError struct {
+	code    int16
+	message string
+}
+
+func (e *configError) Error() string {
+	return e.message
+}
+
+func newConfigError(code int16, format string, args ...any) *configError {
+	return &configError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Entry is the effective value of a configuration together with the values
+// it overrides, most specific first.
+type Entry struct {
+	Definition *Definition
+	Name       string
+	Value      string
+	Source     Source
+	Synonyms   []Synonym
+}
+
+type Synonym struct {
+	Name   string
+	Value  string
+	Source Source
+}
+
+// Registry resolves topic and broker configurations from their defaults,
+// the static broker configuration and the dynamic configurations stored as
+// ConfigRecords in the metadata log.
+type Registry struct {
+	mu      sync.RWMutex
+	nodeId  int32
+	log     *metadata.Log
+	cluster *metadata.Cluster
+	static  Static
+	brokers map[string]map[string]string // by broker id, "" for the cluster-wide default
+	topics  map[string]map[string]string
+
+	listeners []func(topic string)
+}
+
+func NewRegistry(static Static, cluster *metadata.Cluster, log *metadata.Log) *Registry {
+	r := &Registry{
+		nodeId:  static.NodeId(),
+		log:     log,
+		cluster: cluster,
+		static:  static,
+		brokers: make(map[string]map[string]string),
+		topics:  make(map[string]map[string]string),
+	}
+	log.Register(r.replay)
+	return r
+}
+
+// Register adds a listener called with the name of a topic whose
+// configuration changed, or with "" when a broker configuration changed.
+func (r *Registry) Register(fn func(topic string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+func (r *Registry) replay(rec metadata.Record) {
+	cr, ok := rec.(*metadata.ConfigRecord)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	var configs map[string]map[string]string
+	topic := ""
+	switch ResourceType(cr.ResourceType) {
+	case ResourceTopic:
+		configs = r.topics
+		topic = string(cr.ResourceName)
+	case ResourceBroker:
+		configs = r.brokers
+	default:
+		r.mu.Unlock()
+		return
+	}
+	resource := configs[string(cr.ResourceName)]
+	if resource == nil {
+		resource = make(map[string]string)
+		configs[string(cr.ResourceName)] = resource
+	}
+	if cr.Value == nil {
+		delete(resource, string(cr.Name))
+	} else {
+		resource[string(cr.Name)] = string(*cr.Value)
+	}
+	listeners := r.listeners
+	r.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(topic)
+	}
+}
+
+// resolve returns the effective value of a configuration of a topic, or of
+// this broker when topic is empty.
+func (r *Registry) resolve(d *Definition, topic string) Entry {
+	e := Entry{Definition: d, Name: d.Name}
+	add := func(name, value string, source Source) {
+		e.Synonyms = append(e.Synonyms, Synonym{Name: name, Value: value, Source: source})
+	}
+
+	brokerName := d.Name
+	if topic != "" {
+		brokerName = d.BrokerSynonym
+		if v, ok := r.topics[topic][d.Name]; ok {
+			add(d.Name, v, SourceDynamicTopic)
+		}
+	}
+	if v, ok := r.brokers[strconv.Itoa(int(r.nodeId))][brokerName]; ok {
+		add(brokerName, v, SourceDynamicBroker)
+	}
+	if v, ok := r.brokers[""][brokerName]; ok {
+		add(brokerName, v, SourceDynamicDefaultBroker)
+	}
+	if v, ok := r.static[brokerName]; ok {
+		add(brokerName, v, SourceStaticBroker)
+	}
+	add(brokerName, d.Default, SourceDefault)
+
+	e.Value = e.Synonyms[0].Value
+	e.Source = e.Synonyms[0].Source
+	return e
+}
+
+// Get returns the effective value of a configuration of a topic, or of
+// this broker when topic is empty.
+func (r *Registry) Get(topic, name string) (string, bool) {
+	resourceType := ResourceBroker
+	if topic != "" {
+		resourceType = ResourceTopic
+	}
+	d, ok := Lookup(resourceType, name)
+	if !ok {
+		return "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.resolve(d, topic).Value, true
+}
+
+func (r *Registry) int64Value(topic, name string) int64 {
+	v, _ := r.Get(topic, name)
+	n, _ := strconv.ParseInt(v, 10, 64)
+	return n
+}
+
+// LogConfig returns the storage configuration of a topic's partitions.
+// Logs that do not belong to a topic, like the ones the broker keeps for
+// itself, get the storage defaults and are never deleted by retention.
+// Compaction is not implemented, so compacted topics keep everything.
+func (r *Registry) LogConfig(topic string) storage.LogConfig {
+	if _, ok := r.cluster.TopicByName(topic); !ok {
+		return storage.DefaultLogConfig
+	}
+	c := storage.LogConfig{
+		SegmentBytes:       r.int64Value(topic, "segment.bytes"),
+		SegmentMs:          r.int64Value(topic, "segment.ms"),
+		IndexIntervalBytes: int(r.int64Value(topic, "index.interval.bytes")),
+		MaxMessageBytes:    int(r.int64Value(topic, "max.message.bytes")),
+		RetentionMs:        r.int64Value(topic, "retention.ms"),
+		RetentionBytes:     r.int64Value(topic, "retention.bytes"),
+	}
+	policy, _ := r.Get(topic, "cleanup.policy")
+	if !slices.Contains(SplitList(policy), "delete") {
+		c.RetentionMs = -1
+		c.RetentionBytes = -1
+	}
+	return c
+}
+
+// checkResource validates the resource named in a request.
+func (r *Registry) checkResource(resourceType ResourceType, name string) error {
+	switch resourceType {
+	case ResourceTopic:
+		if name == "" {
+			return newConfigError(constant.INVALID_REQUEST, "Topic name can't be empty.")
+		}
+		if _, ok := r.cluster.TopicByName(name); !ok {
+			return newConfigError(constant.UNKNOWN_TOPIC_OR_PARTITION, "Topic %s does not exist.", name)
+		}
+	case ResourceBroker:
+		if name != "" && name != strconv.Itoa(int(r.nodeId)) {
+			return newConfigError(constant.INVALID_REQUEST, "Unexpected broker id, expected %d or empty string, but received %s.", r.nodeId, name)
+		}
+	default:
+		return newConfigError(constant.INVALID_REQUEST, "Unsupported resource type %d.", resourceType)
+	}
+	return nil
+}
+
+// describe returns the configurations of a resource. The empty broker name
+// stands for the cluster-wide defaults, of which only the ones set are
+// returned.
+func (r *Registry) describe(resourceType ResourceType, name string) []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []Entry
+	for _, d := range Definitions(resourceType) {
+		if resourceType == ResourceBroker && name == "" {
+			if v, ok := r.brokers[""][d.Name]; ok {
+				entries = append(entries, Entry{
+					Definition: d,
+					Name:       d.Name,
+					Value:      v,
+					Source:     SourceDynamicDefaultBroker,
+					Synonyms:   []Synonym{{Name: d.Name, Value: v, Source: SourceDynamicDefaultBroker}},
+				})
+			}
+			continue
+		}
+		topic := ""
+		if resourceType == ResourceTopic {
+			topic = name
+		}
+		entries = append(entries, r.resolve(d, topic))
+	}
+	return entries
+}
+
+// BrokerConfigs returns the effective configuration of this broker.
+func (r *Registry) BrokerConfigs() []Entry {
+	return r.describe(ResourceBroker, strconv.Itoa(int(r.nodeId)))
+}
+
+func toNullableString(s string) *types.CompactString {
+	cs := types.CompactString(s)
+	return &cs
+}
+
+func (r *Registry) DescribeConfigs(req *request.DescribeConfigsV4) *response.DescribeConfigsV4 {
+	res := &response.DescribeConfigsV4{
+		Results: make([]response.DescribeConfigsResult, len(req.Resources)),
+	}
+	for i, resource := range req.Resources {
+		result := &res.Results[i]
+		result.ResourceType = resource.ResourceType
+		result.ResourceName = resource.ResourceName
+		result.Configs = []response.DescribeConfigsResourceResult{}
+		resourceType := ResourceType(resource.ResourceType)
+		if err := r.checkResource(resourceType, string(resource.ResourceName)); err != nil {
+			ce := err.(*configError)
+			result.ErrorCode = ce.code
+			result.ErrorMessage = toNullableString(ce.message)
+			continue
+		}
+
+		for _, e := range r.describe(resourceType, string(resource.ResourceName)) {
+			if resource.ConfigurationKeys != nil && !slices.Contains(resource.ConfigurationKeys, types.CompactString(e.Name)) {
+				continue
+			}
+			c := response.DescribeConfigsResourceResult{
+				Name:         types.CompactString(e.Name),
+				ReadOnly:     e.Definition.ReadOnly,
+				ConfigSource: int8(e.Source),
+				IsSensitive:  e.Definition.Sensitive,
+				ConfigType:   int8(e.Definition.Type),
+				Synonyms:     []response.DescribeConfigsSynonym{},
+			}
+			if !e.Definition.Sensitive {
+				c.Value = toNullableString(e.Value)
+			}
+			if req.IncludeSynonyms {
+				for _, s := range e.Synonyms {
+					synonym := response.DescribeConfigsSynonym{
+						Name:   types.CompactString(s.Name),
+						Source: int8(s.Source),
+					}
+					if !e.Definition.Sensitive {
+						synonym.Value = toNullableString(s.Value)
+					}
+					c.Synonyms = append(c.Synonyms, synonym)
+				}
+			}
+			if req.IncludeDocumentation {
+				c.Documentation = toNullableString(e.Definition.Doc)
+			}
+			result.Configs = append(result.Configs, c)
+		}
+	}
+	return res
+}
+
+func (r *Registry) IncrementalAlterConfigs(req *request.IncrementalAlterConfigsV1) *response.IncrementalAlterConfigsV1 {
+	res := &response.IncrementalAlterConfigsV1{
+		Responses: make([]response.AlterConfigsResourceResponse, len(req.Resources)),
+	}
+	for i, resource := range req.Resources {
+		result := &res.Responses[i]
+		result.ResourceType = resource.ResourceType
+		result.ResourceName = resource.ResourceName
+
+		records, err := r.alterConfigs(resource)
+		if err == nil && !req.ValidateOnly {
+			err = r.log.Append(records...)
+		}
+		if err != nil {
+			ce, ok := err.(*configError)
+			if !ok {
+				ce = newConfigError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+			}
+			result.ErrorCode = ce.code
+			result.ErrorMessage = toNullableString(ce.message)
+		}
+	}
+	return res
+}
+
+// alterConfigs validates the changes to a resource and returns the records
+// that apply them.
+func (r *Registry) alterConfigs(resource request.AlterConfigsResource) ([]metadata.Record, error) {
+	resourceType := ResourceType(resource.ResourceType)
+	name := string(resource.ResourceName)
+	if err := r.checkResource(resourceType, name); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topic := ""
+	current := r.brokers[name]
+	if resourceType == ResourceTopic {
+		topic = name
+		current = r.topics[name]
+	}
+	seen := make(map[string]bool)
+	var records []metadata.Record
+	for _, c := range resource.Configs {
+		configName := string(c.Name)
+		if seen[configName] {
+			return nil, newConfigError(constant.INVALID_REQUEST, "Error due to duplicate config keys.")
+		}
+		seen[configName] = true
+
+		d, ok := Lookup(resourceType, configName)
+		if !ok {
+			return nil, newConfigError(constant.INVALID_CONFIG, "Unknown configuration %s.", configName)
+		}
+		if d.ReadOnly {
+			return nil, newConfigError(constant.INVALID_REQUEST, "Cannot update configuration %s dynamically.", configName)
+		}
+
+		var value *string
+		switch c.ConfigOperation {
+		case request.ConfigOperationSet:
+			if c.Value == nil {
+				return nil, newConfigError(constant.INVALID_REQUEST, "Null value not supported for SET of %s.", configName)
+			}
+			v := string(*c.Value)
+			value = &v
+		case request.ConfigOperationDelete:
+		case request.ConfigOperationAppend, request.ConfigOperationSubtract:
+			if d.Type != TypeList {
+				return nil, newConfigError(constant.INVALID_CONFIG, "Configuration %s must be of type LIST for APPEND and SUBTRACT.", configName)
+			}
+			if c.Value == nil {
+				return nil, newConfigError(constant.INVALID_REQUEST, "Null value not supported for APPEND or SUBTRACT of %s.", configName)
+			}
+			v := r.updateList(d, topic, current, c.ConfigOperation, string(*c.Value))
+			value = &v
+		default:
+			return nil, newConfigError(constant.INVALID_REQUEST, "Unknown config operation %d.", c.ConfigOperation)
+		}
+
+		if value != nil {
+			if err := d.Validate(*value); err != nil {
+				return nil, newConfigError(constant.INVALID_CONFIG, "%s", err)
+			}
+		}
+		rec := &metadata.ConfigRecord{
+			ResourceType: resource.ResourceType,
+			ResourceName: resource.ResourceName,
+			Name:         c.Name,
+		}
+		if value != nil {
+			rec.Value = toNullableString(*value)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// updateList appends items to, or removes them from, the current value of
+// a list configuration, starting from its effective value when the
+// resource does not set it.
+func (r *Registry) updateList(d *Definition, topic string, current map[string]string, op int8, value string) string {
+	base, ok := current[d.Name]
+	if !ok {
+		base = r.resolve(d, topic).Value
+	}
+	items := SplitList(base)
+	for _, item := range SplitList(value) {
+		i := slices.Index(items, item)
+		switch {
+		case op == request.ConfigOperationAppend && i < 0:
+			items = append(items, item)
+		case op == request.ConfigOperationSubtract && i >= 0:
+			items = slices.Delete(items, i, i+1)
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// ErrorCode maps an error of the registry to the protocol error code sent
+// to clients.
+func ErrorCode(err error) int16 {
+	if ce, ok := err.(*configError); ok {
+		return ce.code
+	}
+	return constant.UNKNOWN_SERVER_ERROR
+}
+
+// CreateTopicConfigs validates the configurations a new topic is created
+// with, and returns the records that set them along with every
+// configuration the topic will have.
+func (r *Registry) CreateTopicConfigs(topic string, configs []request.CreatableTopicConfig) ([]metadata.Record, []response.CreatableTopicConfigs, error) {
+	overrides := make(map[string]string)
+	var records []metadata.Record
+	for _, c := range configs {
+		name := string(c.Name)
+		if _, ok := overrides[name]; ok {
+			return nil, nil, newConfigError(constant.INVALID_REQUEST, "Error due to duplicate config keys.")
+		}
+		d, ok := Lookup(ResourceTopic, name)
+		if !ok {
+			return nil, nil, newConfigError(constant.INVALID_CONFIG, "Unknown topic config name: %s", name)
+		}
+		if c.Value == nil {
+			return nil, nil, newConfigError(constant.INVALID_REQUEST, "Null value not supported for topic configs: %s", name)
+		}
+		if err := d.Validate(string(*c.Value)); err != nil {
+			return nil, nil, newConfigError(constant.INVALID_CONFIG, "%s", err)
+		}
+		overrides[name] = string(*c.Value)
+		records = append(records, &metadata.ConfigRecord{
+			ResourceType: int8(ResourceTopic),
+			ResourceName: types.CompactString(topic),
+			Name:         c.Name,
+			Value:        c.Value,
+		})
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var resolved []response.CreatableTopicConfigs
+	for _, d := range Definitions(ResourceTopic) {
+		e := response.CreatableTopicConfigs{
+			Name:        types.CompactString(d.Name),
+			ReadOnly:    d.ReadOnly,
+			IsSensitive: d.Sensitive,
+		}
+		value, source := overrides[d.Name], SourceDynamicTopic
+		if _, ok := overrides[d.Name]; !ok {
+			entry := r.resolve(d, topic)
+			value, source = entry.Value, entry.Source
+		}
+		e.ConfigSource = int8(source)
+		if !d.Sensitive {
+			e.Value = toNullableString(value)
+		}
+		resolved = append(resolved, e)
+	}
+	return records, resolved, nil
+}
+
+// DeleteTopicConfigs returns the records removing the configurations set
+// on a topic, so a topic later created with the same name does not inherit
+// them.
+func (r *Registry) DeleteTopicConfigs(topic string) []metadata.Record {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var records []metadata.Record
+	for name := range r.topics[topic] {
+		records = append(records, &metadata.ConfigRecord{
+			ResourceType: int8(ResourceTopic),
+			ResourceName: types.CompactString(topic),
+			Name:         types.CompactString(name),
+		})
+	}
+	return records
+}
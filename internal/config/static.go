@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Static is the broker configuration read at startup, from a
+// server.properties file and command-line overrides. It only holds the
+// configurations that were set.
+type Static map[string]string
+
+// LoadStatic reads the configuration file at path, unless path is empty.
+// Kafka ignores the configurations it does not know in server.properties,
+// which hold settings of other components too, so these are returned
+// rather than rejected.
+func LoadStatic(path string) (Static, []string, error) {
+	s := make(Static)
+	if path == "" {
+		return s, nil, nil
+	}
+	props, err := LoadProperties(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unknown []string
+	for name, value := range props {
+		if _, ok := Lookup(ResourceBroker, name); !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if err := s.Set(name, value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	sort.Strings(unknown)
+	return s, unknown, nil
+}
+
+// Set validates and sets a broker configuration.
+func (s Static) Set(name, value string) error {
+	d, ok := Lookup(ResourceBroker, name)
+	if !ok {
+		return fmt.Errorf("unknown broker configuration %s", name)
+	}
+	if err := d.Validate(value); err != nil {
+		return err
+	}
+	s[name] = value
+	return nil
+}
+
+// Get returns the value of a broker configuration, or its default when it
+// is not set.
+func (s Static) Get(name string) string {
+	if v, ok := s[name]; ok {
+		return v
+	}
+	d, _ := Lookup(ResourceBroker, name)
+	return d.Default
+}
+
+// Int returns the value of a numeric broker configuration.
+func (s Static) Int(name string) int64 {
+	n, _ := strconv.ParseInt(s.Get(name), 10, 64)
+	return n
+}
+
+// NodeId returns the id of the broker.
+func (s Static) NodeId() int32 {
+	return int32(s.Int("node.id"))
+}
+
+// LogDir returns the directory the broker keeps its logs in. Only one
+// directory is supported, which the definition of log.dirs enforces.
+func (s Static) LogDir() string {
+	return SplitList(s.Get("log.dirs"))[0]
+}
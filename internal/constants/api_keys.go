@@ -65,3 +65,148 @@ const (
 	AddRaftVoter                 int16 = 80
 	RemoveRaftVoter              int16 = 81
 )
+
+// ApiKeyNames maps API keys to their names.
+var ApiKeyNames = map[int16]string{
+	Produce:                      "Produce",
+	Fetch:                        "Fetch",
+	ListOffsets:                  "ListOffsets",
+	Metadata:                     "Metadata",
+	OffsetCommit:                 "OffsetCommit",
+	OffsetFetch:                  "OffsetFetch",
+	FindCoordinator:              "FindCoordinator",
+	JoinGroup:                    "JoinGroup",
+	Heartbeat:                    "Heartbeat",
+	LeaveGroup:                   "LeaveGroup",
+	SyncGroup:                    "SyncGroup",
+	DescribeGroups:               "DescribeGroups",
+	ListGroups:                   "ListGroups",
+	SaslHandshake:                "SaslHandshake",
+	ApiVersions:                  "ApiVersions",
+	CreateTopics:                 "CreateTopics",
+	DeleteTopics:                 "DeleteTopics",
+	DeleteRecords:                "DeleteRecords",
+	InitProducerId:               "InitProducerId",
+	OffsetForLeaderEpoch:         "OffsetForLeaderEpoch",
+	AddPartitionsToTxn:           "AddPartitionsToTxn",
+	AddOffsetsToTxn:              "AddOffsetsToTxn",
+	EndTxn:                       "EndTxn",
+	WriteTxnMarkers:              "WriteTxnMarkers",
+	TxnOffsetCommit:              "TxnOffsetCommit",
+	DescribeAcls:                 "DescribeAcls",
+	CreateAcls:                   "CreateAcls",
+	DeleteAcls:                   "DeleteAcls",
+	DescribeConfigs:              "DescribeConfigs",
+	AlterConfigs:                 "AlterConfigs",
+	AlterReplicaLogDirs:          "AlterReplicaLogDirs",
+	DescribeLogDirs:              "DescribeLogDirs",
+	SaslAuthenticate:             "SaslAuthenticate",
+	CreatePartitions:             "CreatePartitions",
+	CreateDelegationToken:        "CreateDelegationToken",
+	RenewDelegationToken:         "RenewDelegationToken",
+	ExpireDelegationToken:        "ExpireDelegationToken",
+	DescribeDelegationToken:      "DescribeDelegationToken",
+	DeleteGroups:                 "DeleteGroups",
+	ElectLeaders:                 "ElectLeaders",
+	IncrementalAlterConfigs:      "IncrementalAlterConfigs",
+	AlterPartitionReassignments:  "AlterPartitionReassignments",
+	ListPartitionReassignments:   "ListPartitionReassignments",
+	OffsetDelete:                 "OffsetDelete",
+	DescribeClientQuotas:         "DescribeClientQuotas",
+	AlterClientQuotas:            "AlterClientQuotas",
+	DescribeUserScramCredentials: "DescribeUserScramCredentials",
+	AlterUserScramCredentials:    "AlterUserScramCredentials",
+	DescribeQuorum:               "DescribeQuorum",
+	UpdateFeatures:               "UpdateFeatures",
+	DescribeCluster:              "DescribeCluster",
+	DescribeProducers:            "DescribeProducers",
+	UnregisterBroker:             "UnregisterBroker",
+	DescribeTransactions:         "DescribeTransactions",
+	ListTransactions:             "ListTransactions",
+	ConsumerGroupHeartbeat:       "ConsumerGroupHeartbeat",
+	ConsumerGroupDescribe:        "ConsumerGroupDescribe",
+	GetTelemetrySubscriptions:    "GetTelemetrySubscriptions",
+	PushTelemetry:                "PushTelemetry",
+	ListClientMetricsResources:   "ListClientMetricsResources",
+	DescribeTopicPartitions:      "DescribeTopicPartitions",
+	AddRaftVoter:                 "AddRaftVoter",
+	RemoveRaftVoter:              "RemoveRaftVoter",
+}
+
+// FirstFlexibleVersions are the first versions of each API that use the
+// flexible encoding, whose headers and bodies end with tagged fields. APIs
+// missing from the map, like SaslHandshake, are never flexible.
+var FirstFlexibleVersions = map[int16]int16{
+	Produce:                      9,
+	Fetch:                        12,
+	ListOffsets:                  6,
+	Metadata:                     9,
+	OffsetCommit:                 8,
+	OffsetFetch:                  6,
+	FindCoordinator:              3,
+	JoinGroup:                    6,
+	Heartbeat:                    4,
+	LeaveGroup:                   4,
+	SyncGroup:                    4,
+	DescribeGroups:               5,
+	ListGroups:                   3,
+	ApiVersions:                  3,
+	CreateTopics:                 5,
+	DeleteTopics:                 4,
+	DeleteRecords:                2,
+	InitProducerId:               2,
+	OffsetForLeaderEpoch:         4,
+	AddPartitionsToTxn:           3,
+	AddOffsetsToTxn:              3,
+	EndTxn:                       3,
+	WriteTxnMarkers:              1,
+	TxnOffsetCommit:              3,
+	DescribeAcls:                 2,
+	CreateAcls:                   2,
+	DeleteAcls:                   2,
+	DescribeConfigs:              4,
+	AlterConfigs:                 2,
+	AlterReplicaLogDirs:          2,
+	DescribeLogDirs:              2,
+	SaslAuthenticate:             2,
+	CreatePartitions:             2,
+	CreateDelegationToken:        2,
+	RenewDelegationToken:         2,
+	ExpireDelegationToken:        2,
+	DescribeDelegationToken:      2,
+	DeleteGroups:                 2,
+	ElectLeaders:                 2,
+	IncrementalAlterConfigs:      1,
+	AlterPartitionReassignments:  0,
+	ListPartitionReassignments:   0,
+	DescribeClientQuotas:         1,
+	AlterClientQuotas:            1,
+	DescribeUserScramCredentials: 0,
+	AlterUserScramCredentials:    0,
+	DescribeQuorum:               0,
+	UpdateFeatures:               0,
+	DescribeCluster:              0,
+	DescribeProducers:            0,
+	UnregisterBroker:             0,
+	DescribeTransactions:         0,
+	ListTransactions:             0,
+	ConsumerGroupHeartbeat:       0,
+	ConsumerGroupDescribe:        0,
+	GetTelemetrySubscriptions:    0,
+	PushTelemetry:                0,
+	ListClientMetricsResources:   0,
+	DescribeTopicPartitions:      0,
+	AddRaftVoter:                 0,
+	RemoveRaftVoter:              0,
+}
+
+// FlexibleVersion tells whether a version of an API uses the flexible
+// encoding. Unknown APIs are assumed to be recent, hence flexible.
+func FlexibleVersion(apiKey, version int16) bool {
+	first, ok := FirstFlexibleVersions[apiKey]
+	if !ok {
+		_, known := ApiKeyNames[apiKey]
+		return !known
+	}
+	return version >= first
+}
@@ -0,0 +1,110 @@
+package group
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Assignment maps a topic id to the sorted partitions assigned within it.
+type Assignment map[[16]byte][]int32
+
+func (a Assignment) Clone() Assignment {
+	c := make(Assignment, len(a))
+	for topicId, partitions := range a {
+		c[topicId] = append([]int32(nil), partitions...)
+	}
+	return c
+}
+
+func (a Assignment) Add(topicId [16]byte, partition int32) {
+	partitions := a[topicId]
+	i := sort.Search(len(partitions), func(i int) bool { return partitions[i] >= partition })
+	if i < len(partitions) && partitions[i] == partition {
+		return
+	}
+	partitions = append(partitions, 0)
+	copy(partitions[i+1:], partitions[i:])
+	partitions[i] = partition
+	a[topicId] = partitions
+}
+
+func (a Assignment) Remove(topicId [16]byte, partition int32) {
+	partitions := a[topicId]
+	i := sort.Search(len(partitions), func(i int) bool { return partitions[i] >= partition })
+	if i == len(partitions) || partitions[i] != partition {
+		return
+	}
+	partitions = append(partitions[:i], partitions[i+1:]...)
+	if len(partitions) == 0 {
+		delete(a, topicId)
+		return
+	}
+	a[topicId] = partitions
+}
+
+func (a Assignment) Contains(topicId [16]byte, partition int32) bool {
+	partitions := a[topicId]
+	i := sort.Search(len(partitions), func(i int) bool { return partitions[i] >= partition })
+	return i < len(partitions) && partitions[i] == partition
+}
+
+func (a Assignment) Size() int {
+	n := 0
+	for _, partitions := range a {
+		n += len(partitions)
+	}
+	return n
+}
+
+func (a Assignment) Equal(b Assignment) bool {
+	if a.Size() != b.Size() {
+		return false
+	}
+	return a.Subtract(b).Size() == 0
+}
+
+// Intersect returns the partitions present in both a and b.
+func (a Assignment) Intersect(b Assignment) Assignment {
+	res := make(Assignment)
+	for topicId, partitions := range a {
+		for _, p := range partitions {
+			if b.Contains(topicId, p) {
+				res.Add(topicId, p)
+			}
+		}
+	}
+	return res
+}
+
+// Subtract returns the partitions present in a but not in b.
+func (a Assignment) Subtract(b Assignment) Assignment {
+	res := make(Assignment)
+	for topicId, partitions := range a {
+		for _, p := range partitions {
+			if !b.Contains(topicId, p) {
+				res.Add(topicId, p)
+			}
+		}
+	}
+	return res
+}
+
+func (a Assignment) Union(b Assignment) Assignment {
+	res := a.Clone()
+	for topicId, partitions := range b {
+		for _, p := range partitions {
+			res.Add(topicId, p)
+		}
+	}
+	return res
+}
+
+// TopicIds returns the topic ids in a stable order.
+func (a Assignment) TopicIds() [][16]byte {
+	ids := make([][16]byte, 0, len(a))
+	for topicId := range a {
+		ids = append(ids, topicId)
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+	return ids
+}
@@ -0,0 +1,227 @@
+package group
+
+import (
+	"bytes"
+	"sort"
+)
+
+const (
+	RangeAssignorName   = "range"
+	UniformAssignorName = "uniform"
+
+	DefaultAssignorName = UniformAssignorName
+)
+
+// MemberSpec is what an assignor knows about a member: the topics it is
+// subscribed to and the partitions it was last targeted with.
+type MemberSpec struct {
+	MemberId         string
+	SubscribedTopics [][16]byte
+	Assignment       Assignment
+}
+
+func (m *MemberSpec) subscribedTo(topicId [16]byte) bool {
+	for _, id := range m.SubscribedTopics {
+		if id == topicId {
+			return true
+		}
+	}
+	return false
+}
+
+// Assignor computes the target assignment of a group. partitionCounts holds
+// the number of partitions of every topic subscribed to by any member.
+type Assignor interface {
+	Name() string
+	Assign(members []MemberSpec, partitionCounts map[[16]byte]int32) map[string]Assignment
+}
+
+var assignors = map[string]Assignor{
+	RangeAssignorName:   RangeAssignor{},
+	UniformAssignorName: UniformAssignor{},
+}
+
+func LookupAssignor(name string) (Assignor, bool) {
+	a, ok := assignors[name]
+	return a, ok
+}
+
+func sortedTopicIds(partitionCounts map[[16]byte]int32) [][16]byte {
+	ids := make([][16]byte, 0, len(partitionCounts))
+	for topicId := range partitionCounts {
+		ids = append(ids, topicId)
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+	return ids
+}
+
+func sortedMembers(members []MemberSpec) []MemberSpec {
+	sorted := append([]MemberSpec(nil), members...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MemberId < sorted[j].MemberId })
+	return sorted
+}
+
+// RangeAssignor splits the partitions of each topic into ranges across the
+// members subscribed to it. Members keep partitions they already own as long
+// as that does not exceed their share of the topic.
+type RangeAssignor struct{}
+
+func (RangeAssignor) Name() string {
+	return RangeAssignorName
+}
+
+func (RangeAssignor) Assign(members []MemberSpec, partitionCounts map[[16]byte]int32) map[string]Assignment {
+	members = sortedMembers(members)
+	res := make(map[string]Assignment, len(members))
+	for _, m := range members {
+		res[m.MemberId] = make(Assignment)
+	}
+
+	for _, topicId := range sortedTopicIds(partitionCounts) {
+		var subscribers []MemberSpec
+		for _, m := range members {
+			if m.subscribedTo(topicId) {
+				subscribers = append(subscribers, m)
+			}
+		}
+		if len(subscribers) == 0 {
+			continue
+		}
+
+		numPartitions := partitionCounts[topicId]
+		minQuota := int(numPartitions) / len(subscribers)
+		extra := int(numPartitions) % len(subscribers)
+		quotas := make([]int, len(subscribers))
+		for i := range subscribers {
+			quotas[i] = minQuota
+			if i < extra {
+				quotas[i]++
+			}
+		}
+
+		taken := make([]bool, numPartitions)
+		for i, m := range subscribers {
+			for _, p := range m.Assignment[topicId] {
+				if quotas[i] == 0 {
+					break
+				}
+				if p >= numPartitions || taken[p] {
+					continue
+				}
+				taken[p] = true
+				res[m.MemberId].Add(topicId, p)
+				quotas[i]--
+			}
+		}
+
+		next := int32(0)
+		for i, m := range subscribers {
+			for ; quotas[i] > 0 && next < numPartitions; next++ {
+				if taken[next] {
+					continue
+				}
+				taken[next] = true
+				res[m.MemberId].Add(topicId, next)
+				quotas[i]--
+			}
+		}
+	}
+
+	return res
+}
+
+// UniformAssignor spreads all subscribed partitions as evenly as possible
+// across members, regardless of topic, while moving as few partitions as
+// possible away from their current owner.
+type UniformAssignor struct{}
+
+func (UniformAssignor) Name() string {
+	return UniformAssignorName
+}
+
+func (UniformAssignor) Assign(members []MemberSpec, partitionCounts map[[16]byte]int32) map[string]Assignment {
+	members = sortedMembers(members)
+	res := make(map[string]Assignment, len(members))
+	for _, m := range members {
+		res[m.MemberId] = make(Assignment)
+	}
+	if len(members) == 0 {
+		return res
+	}
+
+	type topicPartition struct {
+		topicId   [16]byte
+		partition int32
+	}
+	var all []topicPartition
+	for _, topicId := range sortedTopicIds(partitionCounts) {
+		for _, m := range members {
+			if m.subscribedTo(topicId) {
+				for p := int32(0); p < partitionCounts[topicId]; p++ {
+					all = append(all, topicPartition{topicId, p})
+				}
+				break
+			}
+		}
+	}
+	maxQuota := (len(all) + len(members) - 1) / len(members)
+
+	// keep current ownership up to the ceiling of an even share
+	owner := make(map[topicPartition]int)
+	for i, m := range members {
+		for _, topicId := range m.Assignment.TopicIds() {
+			if !m.subscribedTo(topicId) {
+				continue
+			}
+			for _, p := range m.Assignment[topicId] {
+				tp := topicPartition{topicId, p}
+				if _, ok := owner[tp]; ok || p >= partitionCounts[topicId] || res[m.MemberId].Size() >= maxQuota {
+					continue
+				}
+				owner[tp] = i
+				res[m.MemberId].Add(topicId, p)
+			}
+		}
+	}
+
+	// hand out the rest to the least loaded eligible member
+	for _, tp := range all {
+		if _, ok := owner[tp]; ok {
+			continue
+		}
+		best := -1
+		for i, m := range members {
+			if !m.subscribedTo(tp.topicId) {
+				continue
+			}
+			if best == -1 || res[m.MemberId].Size() < res[members[best].MemberId].Size() {
+				best = i
+			}
+		}
+		owner[tp] = best
+		res[members[best].MemberId].Add(tp.topicId, tp.partition)
+	}
+
+	// with heterogeneous subscriptions the members left with the most
+	// partitions may still be able to give some away
+	for moved := true; moved; {
+		moved = false
+		for _, tp := range all {
+			from := owner[tp]
+			for to, m := range members {
+				if to == from || !m.subscribedTo(tp.topicId) {
+					continue
+				}
+				if res[m.MemberId].Size() < res[members[from].MemberId].Size()-1 {
+					res[members[from].MemberId].Remove(tp.topicId, tp.partition)
+					res[m.MemberId].Add(tp.topicId, tp.partition)
+					owner[tp] = to
+					moved = true
+					break
+				}
+			}
+		}
+	}
+
+	return res
+}
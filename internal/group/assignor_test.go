@@ -0,0 +1,163 @@
+package group
+
+import (
+	"fmt"
+	"testing"
+)
+
+var (
+	topicA = [16]byte{1}
+	topicB = [16]byte{2}
+)
+
+// specs builds member specs subscribed to topics, with the assignments
+// found in previous.
+func specs(memberIds []string, topics [][16]byte, previous map[string]Assignment) []MemberSpec {
+	res := make([]MemberSpec, len(memberIds))
+	for i, id := range memberIds {
+		res[i] = MemberSpec{MemberId: id, SubscribedTopics: topics, Assignment: previous[id]}
+	}
+	return res
+}
+
+// checkComplete fails unless every partition is assigned to exactly one
+// member and shares differ by at most one partition.
+func checkComplete(t *testing.T, got map[string]Assignment, partitionCounts map[[16]byte]int32) {
+	t.Helper()
+	union := make(Assignment)
+	total := 0
+	minSize, maxSize := -1, 0
+	for _, a := range got {
+		union = union.Union(a)
+		total += a.Size()
+		if minSize == -1 || a.Size() < minSize {
+			minSize = a.Size()
+		}
+		maxSize = max(maxSize, a.Size())
+	}
+	want := 0
+	for topicId, n := range partitionCounts {
+		want += int(n)
+		for p := int32(0); p < n; p++ {
+			if !union.Contains(topicId, p) {
+				t.Errorf("partition %v-%d is not assigned", topicId[0], p)
+			}
+		}
+	}
+	if total != want {
+		t.Errorf("%d partitions assigned, want %d", total, want)
+	}
+	if maxSize-minSize > 1 {
+		t.Errorf("shares range from %d to %d partitions", minSize, maxSize)
+	}
+}
+
+func TestAssignorsBalance(t *testing.T) {
+	tests := []struct {
+		members         []string
+		partitionCounts map[[16]byte]int32
+	}{
+		{[]string{"a"}, map[[16]byte]int32{topicA: 3}},
+		{[]string{"a", "b"}, map[[16]byte]int32{topicA: 4}},
+		{[]string{"a", "b", "c"}, map[[16]byte]int32{topicA: 7}},
+		{[]string{"a", "b", "c"}, map[[16]byte]int32{topicA: 2}},
+		{[]string{"a", "b"}, map[[16]byte]int32{topicA: 3, topicB: 3}},
+	}
+	for _, assignor := range []Assignor{RangeAssignor{}, UniformAssignor{}} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%d members/%v", assignor.Name(), len(tt.members), tt.partitionCounts), func(t *testing.T) {
+				topics := sortedTopicIds(tt.partitionCounts)
+				got := assignor.Assign(specs(tt.members, topics, nil), tt.partitionCounts)
+				if len(got) != len(tt.members) {
+					t.Fatalf("assignments for %d members, want %d", len(got), len(tt.members))
+				}
+				if assignor.Name() == RangeAssignorName && len(topics) > 1 {
+					// ranges are only balanced within each topic
+					for _, topicId := range topics {
+						perTopic := make(map[string]Assignment, len(got))
+						for id, a := range got {
+							perTopic[id] = Assignment{topicId: a[topicId]}
+						}
+						checkComplete(t, perTopic, map[[16]byte]int32{topicId: tt.partitionCounts[topicId]})
+					}
+					return
+				}
+				checkComplete(t, got, tt.partitionCounts)
+			})
+		}
+	}
+}
+
+func TestAssignorsStable(t *testing.T) {
+	tests := []struct {
+		name            string
+		before, after   []string
+		partitionCounts map[[16]byte]int32
+	}{
+		{"join", []string{"a", "b"}, []string{"a", "b", "c"}, map[[16]byte]int32{topicA: 6}},
+		{"join uneven", []string{"a", "b"}, []string{"a", "b", "c"}, map[[16]byte]int32{topicA: 7}},
+		{"join first", []string{"b", "c"}, []string{"a", "b", "c"}, map[[16]byte]int32{topicA: 6}},
+		{"join two topics", []string{"a", "b"}, []string{"a", "b", "c"}, map[[16]byte]int32{topicA: 4, topicB: 5}},
+		{"leave", []string{"a", "b", "c"}, []string{"a", "c"}, map[[16]byte]int32{topicA: 6}},
+		{"leave uneven", []string{"a", "b", "c"}, []string{"b", "c"}, map[[16]byte]int32{topicA: 7}},
+		{"leave two topics", []string{"a", "b", "c"}, []string{"a", "b"}, map[[16]byte]int32{topicA: 4, topicB: 5}},
+		{"unchanged", []string{"a", "b"}, []string{"b", "a"}, map[[16]byte]int32{topicA: 5}},
+	}
+	for _, assignor := range []Assignor{RangeAssignor{}, UniformAssignor{}} {
+		for _, tt := range tests {
+			t.Run(assignor.Name()+"/"+tt.name, func(t *testing.T) {
+				topics := sortedTopicIds(tt.partitionCounts)
+				before := assignor.Assign(specs(tt.before, topics, nil), tt.partitionCounts)
+				after := assignor.Assign(specs(tt.after, topics, before), tt.partitionCounts)
+				if assignor.Name() == UniformAssignorName || len(topics) == 1 {
+					checkComplete(t, after, tt.partitionCounts)
+				}
+
+				for _, id := range tt.after {
+					previous, ok := before[id]
+					if !ok {
+						continue
+					}
+					// members only give partitions away when others join and
+					// only take partitions over when others leave
+					if len(tt.after) >= len(tt.before) {
+						if moved := after[id].Subtract(previous); moved.Size() > 0 {
+							t.Errorf("member %s was given %v it did not own", id, moved)
+						}
+					}
+					if len(tt.after) <= len(tt.before) {
+						if lost := previous.Subtract(after[id]); lost.Size() > 0 {
+							t.Errorf("member %s lost %v", id, lost)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestAssignorsSubscriptions(t *testing.T) {
+	partitionCounts := map[[16]byte]int32{topicA: 4, topicB: 2}
+	members := []MemberSpec{
+		{MemberId: "a", SubscribedTopics: [][16]byte{topicA}},
+		{MemberId: "b", SubscribedTopics: [][16]byte{topicA, topicB}},
+		// partitions of topics a member no longer subscribes to, or which
+		// no longer exist, are not kept
+		{MemberId: "c", SubscribedTopics: [][16]byte{topicB}, Assignment: Assignment{topicA: {0}, topicB: {7}}},
+	}
+	for _, assignor := range []Assignor{RangeAssignor{}, UniformAssignor{}} {
+		t.Run(assignor.Name(), func(t *testing.T) {
+			got := assignor.Assign(members, partitionCounts)
+			for _, m := range members {
+				for _, topicId := range got[m.MemberId].TopicIds() {
+					if !m.subscribedTo(topicId) {
+						t.Errorf("member %s is assigned topic %v it is not subscribed to", m.MemberId, topicId[0])
+					}
+				}
+			}
+			if got["a"].Size()+got["b"].Size()+got["c"].Size() != 6 {
+				t.Errorf("assignment %v does not cover the 6 partitions", got)
+			}
+		})
+	}
+}
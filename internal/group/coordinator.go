@@ -0,0 +1,491 @@
+package group
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+const (
+	DefaultSessionTimeout    = 45 * time.Second
+	DefaultHeartbeatInterval = 5 * time.Second
+
+	// authorized operations are reported as INT32_MIN when not requested
+	authorizedOperationsOmitted int32 = -2147483648
+)
+
+type groupError struct {
+	code    int16
+	message string
+}
+
+func (e *groupError) Error() string {
+	return e.message
+}
+
+func newGroupError(code int16, format string, args ...any) *groupError {
+	return &groupError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Coordinator implements the consumer group protocol of KIP-848: members
+// heartbeat their subscriptions and the coordinator computes assignments.
+type Coordinator struct {
+	mu                sync.Mutex
+	cluster           *metadata.Cluster
+	groups            map[string]*ConsumerGroup
+	SessionTimeout    time.Duration
+	HeartbeatInterval time.Duration
+
+	offsets        map[string]map[topicPartition]OffsetAndMetadata
+	pendingOffsets map[int64]map[string]map[topicPartition]OffsetAndMetadata // by producer id, then group
+}
+
+func NewCoordinator(cluster *metadata.Cluster) *Coordinator {
+	return &Coordinator{
+		cluster:           cluster,
+		groups:            make(map[string]*ConsumerGroup),
+		SessionTimeout:    DefaultSessionTimeout,
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		offsets:           make(map[string]map[topicPartition]OffsetAndMetadata),
+		pendingOffsets:    make(map[int64]map[string]map[topicPartition]OffsetAndMetadata),
+	}
+}
+
+func (c *Coordinator) ConsumerGroupHeartbeat(clientId, clientHost string, req *request.ConsumerGroupHeartbeatV0) *response.ConsumerGroupHeartbeatV0 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, err := c.heartbeat(clientId, clientHost, req, time.Now())
+	if err != nil {
+		ge, ok := err.(*groupError)
+		if !ok {
+			ge = newGroupError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+		}
+		message := types.CompactString(ge.message)
+		return &response.ConsumerGroupHeartbeatV0{
+			ErrorCode:    ge.code,
+			ErrorMessage: &message,
+		}
+	}
+	return res
+}
+
+func validateHeartbeat(req *request.ConsumerGroupHeartbeatV0) error {
+	if req.GroupId == "" {
+		return newGroupError(constant.INVALID_REQUEST, "GroupId can't be empty.")
+	}
+	if req.InstanceId != nil && *req.InstanceId == "" {
+		return newGroupError(constant.INVALID_REQUEST, "InstanceId can't be empty.")
+	}
+	if req.RackId != nil && *req.RackId == "" {
+		return newGroupError(constant.INVALID_REQUEST, "RackId can't be empty.")
+	}
+
+	switch {
+	case req.MemberEpoch == JoinGroupMemberEpoch:
+		if req.RebalanceTimeoutMs == -1 {
+			return newGroupError(constant.INVALID_REQUEST, "RebalanceTimeoutMs must be provided in first request.")
+		}
+		if len(req.TopicPartitions) > 0 {
+			return newGroupError(constant.INVALID_REQUEST, "TopicPartitions must be empty when (re-)joining.")
+		}
+		if len(req.SubscribedTopicNames) == 0 {
+			return newGroupError(constant.INVALID_REQUEST, "SubscribedTopicNames must be set in first request.")
+		}
+	case req.MemberEpoch == LeaveGroupStaticMemberEpoch:
+		if req.InstanceId == nil {
+			return newGroupError(constant.INVALID_REQUEST, "InstanceId can't be null.")
+		}
+		fallthrough
+	default:
+		if req.MemberId == "" {
+			return newGroupError(constant.INVALID_REQUEST, "MemberId can't be empty.")
+		}
+		if req.MemberEpoch < LeaveGroupStaticMemberEpoch {
+			return newGroupError(constant.INVALID_REQUEST, "MemberEpoch %d is invalid.", req.MemberEpoch)
+		}
+	}
+
+	if req.ServerAssignor != nil {
+		if _, ok := LookupAssignor(string(*req.ServerAssignor)); !ok {
+			return newGroupError(constant.UNSUPPORTED_ASSIGNOR, "ServerAssignor %s is not supported. Supported assignors: %s, %s.",
+				*req.ServerAssignor, RangeAssignorName, UniformAssignorName)
+		}
+	}
+	return nil
+}
+
+func (c *Coordinator) heartbeat(clientId, clientHost string, req *request.ConsumerGroupHeartbeatV0, now time.Time) (*response.ConsumerGroupHeartbeatV0, error) {
+	if err := validateHeartbeat(req); err != nil {
+		return nil, err
+	}
+
+	groupId := string(req.GroupId)
+	g, ok := c.groups[groupId]
+	if !ok {
+		if req.MemberEpoch != JoinGroupMemberEpoch {
+			return nil, newGroupError(constant.GROUP_ID_NOT_FOUND, "Group %s not found.", groupId)
+		}
+		g = newConsumerGroup(groupId)
+		c.groups[groupId] = g
+	}
+	g.expireMembers(now, c.SessionTimeout)
+
+	if req.MemberEpoch == LeaveGroupMemberEpoch || req.MemberEpoch == LeaveGroupStaticMemberEpoch {
+		return c.leave(g, req)
+	}
+
+	m, joined, err := c.getOrCreateMember(g, req)
+	if err != nil {
+		return nil, err
+	}
+	// the group epoch moves at most once per heartbeat
+	bumpGroupEpoch := joined
+
+	m.lastHeartbeat = now
+	m.ClientId = clientId
+	m.ClientHost = clientHost
+	if req.RackId != nil {
+		rackId := string(*req.RackId)
+		m.RackId = &rackId
+	}
+	if req.RebalanceTimeoutMs > 0 {
+		m.RebalanceTimeout = time.Duration(req.RebalanceTimeoutMs) * time.Millisecond
+	}
+	if req.ServerAssignor != nil && string(*req.ServerAssignor) != m.ServerAssignor {
+		m.ServerAssignor = string(*req.ServerAssignor)
+		bumpGroupEpoch = true
+	}
+	if req.SubscribedTopicNames != nil {
+		names := make([]string, len(req.SubscribedTopicNames))
+		for i, name := range req.SubscribedTopicNames {
+			names[i] = string(name)
+		}
+		if !equalStrings(names, m.SubscribedTopicNames) {
+			m.SubscribedTopicNames = names
+			bumpGroupEpoch = true
+		}
+	}
+
+	if c.refreshSubscribedTopics(g) || bumpGroupEpoch {
+		g.GroupEpoch++
+	}
+	if g.GroupEpoch > g.AssignmentEpoch {
+		g.computeTargetAssignment()
+	}
+
+	var owned Assignment
+	if req.TopicPartitions != nil {
+		owned = make(Assignment)
+		for _, tp := range req.TopicPartitions {
+			for _, p := range tp.Partitions {
+				owned.Add(tp.TopicId, p)
+			}
+		}
+	}
+	changed := g.reconcile(m, owned, now)
+
+	memberId := types.CompactString(m.MemberId)
+	res := &response.ConsumerGroupHeartbeatV0{
+		MemberId:            &memberId,
+		MemberEpoch:         m.MemberEpoch,
+		HeartbeatIntervalMs: int32(c.HeartbeatInterval / time.Millisecond),
+	}
+	if changed || req.MemberEpoch == JoinGroupMemberEpoch || (owned != nil && !owned.Equal(m.Assigned)) {
+		res.Assignment = toResponseAssignment(m.Assigned)
+	}
+	return res, nil
+}
+
+// getOrCreateMember also reports whether a new member joined the group.
+func (c *Coordinator) getOrCreateMember(g *ConsumerGroup, req *request.ConsumerGroupHeartbeatV0) (*Member, bool, error) {
+	memberId := string(req.MemberId)
+
+	if req.MemberEpoch != JoinGroupMemberEpoch {
+		m, ok := g.Members[memberId]
+		if !ok {
+			return nil, false, newGroupError(constant.UNKNOWN_MEMBER_ID, "Member %s is not a member of group %s.", memberId, g.GroupId)
+		}
+		if req.MemberEpoch == m.MemberEpoch {
+			return m, false, nil
+		}
+		// the member may have missed the response that bumped its epoch
+		if req.MemberEpoch == m.PreviousMemberEpoch && req.TopicPartitions != nil {
+			owned := make(Assignment)
+			for _, tp := range req.TopicPartitions {
+				for _, p := range tp.Partitions {
+					owned.Add(tp.TopicId, p)
+				}
+			}
+			if owned.Subtract(m.Assigned).Size() == 0 {
+				return m, false, nil
+			}
+		}
+		return nil, false, newGroupError(constant.FENCED_MEMBER_EPOCH, "The consumer group member has a smaller member epoch (%d) than the one known by the group coordinator (%d). The member must abandon all its partitions and rejoin.",
+			req.MemberEpoch, m.MemberEpoch)
+	}
+
+	if memberId == "" {
+		memberId = newMemberId()
+	}
+	m := &Member{
+		MemberId:          memberId,
+		RebalanceTimeout:  time.Duration(req.RebalanceTimeoutMs) * time.Millisecond,
+		Assigned:          make(Assignment),
+		PendingRevocation: make(Assignment),
+	}
+
+	if req.InstanceId != nil {
+		instanceId := string(*req.InstanceId)
+		m.InstanceId = &instanceId
+		if previousId, ok := g.StaticMembers[instanceId]; ok && previousId != memberId {
+			previous := g.Members[previousId]
+			if previous.MemberEpoch != LeaveGroupStaticMemberEpoch {
+				return nil, false, newGroupError(constant.UNRELEASED_INSTANCE_ID, "Static member %s with instance id %s is not released yet.", previousId, instanceId)
+			}
+			// the new member takes over the assignment of the static member it replaces
+			m.SubscribedTopicNames = previous.SubscribedTopicNames
+			m.ServerAssignor = previous.ServerAssignor
+			m.MemberEpoch = previous.PreviousMemberEpoch
+			m.Assigned = previous.Assigned
+			delete(g.Members, previousId)
+			g.TargetAssignment[memberId] = g.TargetAssignment[previousId]
+			delete(g.TargetAssignment, previousId)
+			g.Members[memberId] = m
+			g.StaticMembers[instanceId] = memberId
+			return m, false, nil
+		}
+		g.StaticMembers[instanceId] = memberId
+	}
+
+	previous, rejoined := g.Members[memberId]
+	if rejoined {
+		// a rejoining member has lost its partitions; keep its target
+		// assignment so that it gets them back without a rebalance
+		m.SubscribedTopicNames = previous.SubscribedTopicNames
+		m.ServerAssignor = previous.ServerAssignor
+	}
+	g.Members[memberId] = m
+	return m, !rejoined, nil
+}
+
+func (c *Coordinator) leave(g *ConsumerGroup, req *request.ConsumerGroupHeartbeatV0) (*response.ConsumerGroupHeartbeatV0, error) {
+	memberId := string(req.MemberId)
+	m, ok := g.Members[memberId]
+	if !ok {
+		return nil, newGroupError(constant.UNKNOWN_MEMBER_ID, "Member %s is not a member of group %s.", memberId, g.GroupId)
+	}
+
+	if req.MemberEpoch == LeaveGroupStaticMemberEpoch {
+		// keep the member's partitions until it is replaced or its session expires
+		if m.MemberEpoch != LeaveGroupStaticMemberEpoch {
+			m.PreviousMemberEpoch = m.MemberEpoch
+			m.MemberEpoch = LeaveGroupStaticMemberEpoch
+		}
+	} else {
+		g.removeMember(memberId)
+		c.refreshSubscribedTopics(g)
+		g.computeTargetAssignment()
+	}
+
+	resMemberId := types.CompactString(memberId)
+	return &response.ConsumerGroupHeartbeatV0{
+		MemberId:            &resMemberId,
+		MemberEpoch:         req.MemberEpoch,
+		HeartbeatIntervalMs: 0,
+	}, nil
+}
+
+// refreshSubscribedTopics records the partition counts of the topics the
+// members subscribe to and reports whether they differ from the last ones.
+func (c *Coordinator) refreshSubscribedTopics(g *ConsumerGroup) bool {
+	topics := make(map[string]subscribedTopic)
+	for _, m := range g.Members {
+		for _, name := range m.SubscribedTopicNames {
+			if _, ok := topics[name]; ok {
+				continue
+			}
+			if t, ok := c.cluster.TopicByName(name); ok {
+				topics[name] = subscribedTopic{topicId: t.TopicId, numPartitions: int32(len(t.Partitions))}
+			}
+		}
+	}
+
+	changed := len(topics) != len(g.subscribedTopics)
+	for name, t := range topics {
+		if g.subscribedTopics[name] != t {
+			changed = true
+		}
+	}
+	g.subscribedTopics = topics
+	return changed
+}
+
+func (c *Coordinator) ConsumerGroupDescribe(req *request.ConsumerGroupDescribeV0) *response.ConsumerGroupDescribeV0 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	res := &response.ConsumerGroupDescribeV0{
+		Groups: make([]response.DescribedGroup, len(req.GroupIds)),
+	}
+	for i, groupId := range req.GroupIds {
+		described := &res.Groups[i]
+		described.GroupId = groupId
+		described.AuthorizedOperations = authorizedOperationsOmitted
+
+		g, ok := c.groups[string(groupId)]
+		if !ok {
+			message := types.CompactString(fmt.Sprintf("Group %s not found.", groupId))
+			described.ErrorCode = constant.GROUP_ID_NOT_FOUND
+			described.ErrorMessage = &message
+			continue
+		}
+		g.expireMembers(now, c.SessionTimeout)
+		if c.refreshSubscribedTopics(g) {
+			g.GroupEpoch++
+		}
+		if g.GroupEpoch > g.AssignmentEpoch {
+			g.computeTargetAssignment()
+		}
+
+		described.GroupState = types.CompactString(g.State())
+		described.GroupEpoch = g.GroupEpoch
+		described.AssignmentEpoch = g.AssignmentEpoch
+		described.AssignorName = types.CompactString(g.AssignorName())
+		for _, m := range g.SortedMembers() {
+			member := response.Member{
+				MemberId:         types.CompactString(m.MemberId),
+				InstanceId:       toCompactString(m.InstanceId),
+				RackId:           toCompactString(m.RackId),
+				MemberEpoch:      m.MemberEpoch,
+				ClientId:         types.CompactString(m.ClientId),
+				ClientHost:       types.CompactString(m.ClientHost),
+				Assignment:       c.toMemberAssignment(m.Assigned),
+				TargetAssignment: c.toMemberAssignment(g.TargetAssignment[m.MemberId]),
+			}
+			member.SubscribedTopicNames = make([]types.CompactString, len(m.SubscribedTopicNames))
+			for j, name := range m.SubscribedTopicNames {
+				member.SubscribedTopicNames[j] = types.CompactString(name)
+			}
+			described.Members = append(described.Members, member)
+		}
+	}
+	return res
+}
+
+// ListGroups lists the consumer groups, as well as the groups that only
+// have committed offsets, which are listed as empty classic groups.
+func (c *Coordinator) ListGroups(req *request.ListGroupsV5) *response.ListGroupsV5 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var listed []response.ListedGroup
+	for groupId, g := range c.groups {
+		g.expireMembers(now, c.SessionTimeout)
+		listed = append(listed, response.ListedGroup{
+			GroupId:      types.CompactString(groupId),
+			ProtocolType: "consumer",
+			GroupState:   types.CompactString(g.State()),
+			GroupType:    "consumer",
+		})
+	}
+	for groupId := range c.offsets {
+		if _, ok := c.groups[groupId]; !ok {
+			listed = append(listed, response.ListedGroup{
+				GroupId:    types.CompactString(groupId),
+				GroupState: "Empty",
+				GroupType:  "classic",
+			})
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].GroupId < listed[j].GroupId })
+
+	res := &response.ListGroupsV5{Groups: []response.ListedGroup{}}
+	for _, g := range listed {
+		if matchesFilter(req.StatesFilter, g.GroupState) && matchesFilter(req.TypesFilter, g.GroupType) {
+			res.Groups = append(res.Groups, g)
+		}
+	}
+	return res
+}
+
+// matchesFilter ignores case, as state and type names are matched in Kafka;
+// an empty filter matches everything.
+func matchesFilter(filter []types.CompactString, value types.CompactString) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if strings.EqualFold(string(f), string(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Coordinator) toMemberAssignment(a Assignment) response.MemberAssignment {
+	ma := response.MemberAssignment{}
+	for _, topicId := range a.TopicIds() {
+		tp := response.NamedTopicPartitions{
+			TopicId:    topicId,
+			Partitions: a[topicId],
+		}
+		if t, ok := c.cluster.TopicById(topicId); ok {
+			tp.TopicName = types.CompactString(t.Name)
+		}
+		ma.TopicPartitions = append(ma.TopicPartitions, tp)
+	}
+	return ma
+}
+
+func toResponseAssignment(a Assignment) *response.Assignment {
+	ra := &response.Assignment{
+		TopicPartitions: make([]response.TopicPartitions, 0, len(a)),
+	}
+	for _, topicId := range a.TopicIds() {
+		ra.TopicPartitions = append(ra.TopicPartitions, response.TopicPartitions{
+			TopicId:    topicId,
+			Partitions: append([]int32(nil), a[topicId]...),
+		})
+	}
+	return ra
+}
+
+func toCompactString(s *string) *types.CompactString {
+	if s == nil {
+		return nil
+	}
+	cs := types.CompactString(*s)
+	return &cs
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newMemberId returns a random id encoded the way Kafka encodes UUIDs.
+func newMemberId() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
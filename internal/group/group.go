@@ -0,0 +1,240 @@
+package group
+
+import (
+	"sort"
+	"time"
+)
+
+// Member epochs with a special meaning in ConsumerGroupHeartbeat requests.
+const (
+	JoinGroupMemberEpoch        int32 = 0
+	LeaveGroupMemberEpoch       int32 = -1
+	LeaveGroupStaticMemberEpoch int32 = -2
+)
+
+type MemberState int8
+
+const (
+	// MemberStable owns exactly its target assignment at the group's assignment epoch.
+	MemberStable MemberState = iota
+	// MemberUnrevokedPartitions must give up partitions before moving to the next epoch.
+	MemberUnrevokedPartitions
+	// MemberUnreleasedPartitions waits for other members to release partitions it is targeted with.
+	MemberUnreleasedPartitions
+)
+
+type Member struct {
+	MemberId             string
+	InstanceId           *string
+	RackId               *string
+	ClientId             string
+	ClientHost           string
+	SubscribedTopicNames []string
+	ServerAssignor       string
+	RebalanceTimeout     time.Duration
+
+	MemberEpoch         int32
+	PreviousMemberEpoch int32
+	State               MemberState
+	Assigned            Assignment
+	PendingRevocation   Assignment
+
+	lastHeartbeat      time.Time
+	revocationDeadline time.Time
+}
+
+type subscribedTopic struct {
+	topicId       [16]byte
+	numPartitions int32
+}
+
+type ConsumerGroup struct {
+	GroupId          string
+	GroupEpoch       int32
+	AssignmentEpoch  int32
+	Members          map[string]*Member
+	StaticMembers    map[string]string // instance id -> member id
+	TargetAssignment map[string]Assignment
+
+	// subscribed topics as they were when the group epoch was last bumped
+	subscribedTopics map[string]subscribedTopic
+}
+
+func newConsumerGroup(groupId string) *ConsumerGroup {
+	return &ConsumerGroup{
+		GroupId:          groupId,
+		Members:          make(map[string]*Member),
+		StaticMembers:    make(map[string]string),
+		TargetAssignment: make(map[string]Assignment),
+		subscribedTopics: make(map[string]subscribedTopic),
+	}
+}
+
+// State returns the group state as named by ConsumerGroupDescribe.
+func (g *ConsumerGroup) State() string {
+	if len(g.Members) == 0 {
+		return "Empty"
+	}
+	if g.GroupEpoch > g.AssignmentEpoch {
+		return "Assigning"
+	}
+	for _, m := range g.Members {
+		if m.MemberEpoch != g.AssignmentEpoch || m.State != MemberStable {
+			return "Reconciling"
+		}
+	}
+	return "Stable"
+}
+
+// AssignorName returns the server assignor most members asked for, or the
+// default one when none did.
+func (g *ConsumerGroup) AssignorName() string {
+	votes := make(map[string]int)
+	for _, m := range g.Members {
+		if m.ServerAssignor != "" {
+			votes[m.ServerAssignor]++
+		}
+	}
+	best := DefaultAssignorName
+	for name, n := range votes {
+		if n > votes[best] || (n == votes[best] && name < best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// SortedMembers returns the members ordered by member id.
+func (g *ConsumerGroup) SortedMembers() []*Member {
+	members := make([]*Member, 0, len(g.Members))
+	for _, m := range g.Members {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].MemberId < members[j].MemberId })
+	return members
+}
+
+func (g *ConsumerGroup) removeMember(memberId string) {
+	m, ok := g.Members[memberId]
+	if !ok {
+		return
+	}
+	if m.InstanceId != nil && g.StaticMembers[*m.InstanceId] == memberId {
+		delete(g.StaticMembers, *m.InstanceId)
+	}
+	delete(g.Members, memberId)
+	delete(g.TargetAssignment, memberId)
+	g.GroupEpoch++
+}
+
+// expireMembers removes members whose session expired or who did not revoke
+// partitions within their rebalance timeout.
+func (g *ConsumerGroup) expireMembers(now time.Time, sessionTimeout time.Duration) {
+	for _, m := range g.SortedMembers() {
+		if now.Sub(m.lastHeartbeat) > sessionTimeout {
+			g.removeMember(m.MemberId)
+			continue
+		}
+		if m.State == MemberUnrevokedPartitions && now.After(m.revocationDeadline) {
+			g.removeMember(m.MemberId)
+		}
+	}
+}
+
+// ownedByOther reports whether a member other than memberId still holds the partition.
+func (g *ConsumerGroup) ownedByOther(memberId string, topicId [16]byte, partition int32) bool {
+	for id, m := range g.Members {
+		if id == memberId {
+			continue
+		}
+		if m.Assigned.Contains(topicId, partition) || m.PendingRevocation.Contains(topicId, partition) {
+			return true
+		}
+	}
+	return false
+}
+
+// computeTargetAssignment runs the group's assignor and moves the assignment
+// epoch up to the group epoch.
+func (g *ConsumerGroup) computeTargetAssignment() {
+	assignor, ok := LookupAssignor(g.AssignorName())
+	if !ok {
+		assignor, _ = LookupAssignor(DefaultAssignorName)
+	}
+
+	partitionCounts := make(map[[16]byte]int32, len(g.subscribedTopics))
+	for _, t := range g.subscribedTopics {
+		partitionCounts[t.topicId] = t.numPartitions
+	}
+
+	specs := make([]MemberSpec, 0, len(g.Members))
+	for _, m := range g.SortedMembers() {
+		spec := MemberSpec{
+			MemberId:   m.MemberId,
+			Assignment: g.TargetAssignment[m.MemberId],
+		}
+		for _, name := range m.SubscribedTopicNames {
+			if t, ok := g.subscribedTopics[name]; ok {
+				spec.SubscribedTopics = append(spec.SubscribedTopics, t.topicId)
+			}
+		}
+		specs = append(specs, spec)
+	}
+
+	g.TargetAssignment = assignor.Assign(specs, partitionCounts)
+	g.AssignmentEpoch = g.GroupEpoch
+}
+
+// reconcile moves the member's current assignment towards its target
+// assignment. owned is the assignment reported by the member, nil when it
+// was not sent. It reports whether the member's epoch or assignment changed.
+func (g *ConsumerGroup) reconcile(m *Member, owned Assignment, now time.Time) bool {
+	if m.State == MemberUnrevokedPartitions {
+		// a member acknowledges a revocation by no longer reporting the partitions
+		if owned == nil || owned.Intersect(m.PendingRevocation).Size() > 0 {
+			return false
+		}
+		m.PendingRevocation = make(Assignment)
+		m.State = MemberStable
+	}
+	if m.State == MemberStable && m.MemberEpoch == g.AssignmentEpoch {
+		return false
+	}
+
+	before := m.Assigned.Clone()
+	beforeEpoch := m.MemberEpoch
+	target := g.TargetAssignment[m.MemberId]
+	kept := m.Assigned.Intersect(target)
+	revoking := m.Assigned.Subtract(target)
+
+	if revoking.Size() > 0 {
+		// the member stays at its epoch until it has let go of the partitions
+		m.Assigned = kept
+		m.PendingRevocation = revoking
+		m.State = MemberUnrevokedPartitions
+		m.revocationDeadline = now.Add(m.RebalanceTimeout)
+	} else {
+		unreleased := false
+		newlyAssigned := target.Subtract(m.Assigned)
+		for topicId, partitions := range newlyAssigned {
+			for _, p := range partitions {
+				if g.ownedByOther(m.MemberId, topicId, p) {
+					unreleased = true
+					continue
+				}
+				kept.Add(topicId, p)
+			}
+		}
+		m.Assigned = kept
+		if m.MemberEpoch != g.AssignmentEpoch {
+			m.PreviousMemberEpoch = m.MemberEpoch
+			m.MemberEpoch = g.AssignmentEpoch
+		}
+		m.State = MemberStable
+		if unreleased {
+			m.State = MemberUnreleasedPartitions
+		}
+	}
+
+	return beforeEpoch != m.MemberEpoch || !before.Equal(m.Assigned)
+}
@@ -0,0 +1,212 @@
+package group
+
+import (
+	"testing"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// newTestCoordinator returns a coordinator of a cluster with a topic "t" of
+// four partitions.
+func newTestCoordinator() *Coordinator {
+	cluster := metadata.NewCluster(1)
+	t := metadata.Topic{Name: "t", TopicId: topicA}
+	for p := int32(0); p < 4; p++ {
+		t.Partitions = append(t.Partitions, metadata.Partition{PartitionIndex: p, LeaderId: 1})
+	}
+	cluster.PutTopic(t)
+	return NewCoordinator(cluster)
+}
+
+func joinRequest(instanceId *string) *request.ConsumerGroupHeartbeatV0 {
+	req := &request.ConsumerGroupHeartbeatV0{
+		GroupId:              "g",
+		RebalanceTimeoutMs:   30000,
+		SubscribedTopicNames: []types.CompactString{"t"},
+	}
+	if instanceId != nil {
+		id := types.CompactString(*instanceId)
+		req.InstanceId = &id
+	}
+	return req
+}
+
+// heartbeatRequest reports owned as the partitions the member owns, or
+// nothing when owned is nil.
+func heartbeatRequest(memberId string, memberEpoch int32, owned Assignment) *request.ConsumerGroupHeartbeatV0 {
+	req := &request.ConsumerGroupHeartbeatV0{
+		GroupId:            "g",
+		MemberId:           types.CompactString(memberId),
+		MemberEpoch:        memberEpoch,
+		RebalanceTimeoutMs: -1,
+	}
+	if owned != nil {
+		req.TopicPartitions = []request.TopicPartitions{}
+		for _, topicId := range owned.TopicIds() {
+			req.TopicPartitions = append(req.TopicPartitions, request.TopicPartitions{TopicId: topicId, Partitions: owned[topicId]})
+		}
+	}
+	return req
+}
+
+func heartbeat(t *testing.T, c *Coordinator, req *request.ConsumerGroupHeartbeatV0) *response.ConsumerGroupHeartbeatV0 {
+	t.Helper()
+	res := c.ConsumerGroupHeartbeat("client", "/127.0.0.1", req)
+	if res.ErrorCode != 0 {
+		t.Fatalf("heartbeat of %q at epoch %d failed with error %d: %v", req.MemberId, req.MemberEpoch, res.ErrorCode, *res.ErrorMessage)
+	}
+	return res
+}
+
+// assigned returns the assignment of a heartbeat response, nil when it was
+// not sent.
+func assigned(res *response.ConsumerGroupHeartbeatV0) Assignment {
+	if res.Assignment == nil {
+		return nil
+	}
+	a := make(Assignment)
+	for _, tp := range res.Assignment.TopicPartitions {
+		for _, p := range tp.Partitions {
+			a.Add(tp.TopicId, p)
+		}
+	}
+	return a
+}
+
+func TestPartitionsHeldBackUntilRevoked(t *testing.T) {
+	c := newTestCoordinator()
+	all := Assignment{topicA: {0, 1, 2, 3}}
+
+	res := heartbeat(t, c, joinRequest(nil))
+	a := string(*res.MemberId)
+	if res.MemberEpoch != 1 || !assigned(res).Equal(all) {
+		t.Fatalf("first member got epoch %d and %v, want epoch 1 and %v", res.MemberEpoch, assigned(res), all)
+	}
+	heartbeat(t, c, heartbeatRequest(a, 1, all))
+
+	// the second member is targeted with half of the partitions, which the
+	// first one still owns
+	res = heartbeat(t, c, joinRequest(nil))
+	b := string(*res.MemberId)
+	if res.MemberEpoch != 2 || assigned(res).Size() != 0 {
+		t.Fatalf("second member got epoch %d and %v, want epoch 2 and no partitions", res.MemberEpoch, assigned(res))
+	}
+	target := c.groups["g"].TargetAssignment
+	if target[a].Size() != 2 || target[b].Size() != 2 {
+		t.Fatalf("target assignment %v does not split the partitions", target)
+	}
+
+	steps := []struct {
+		name      string
+		memberId  string
+		epoch     int32
+		owned     Assignment
+		wantEpoch int32
+		want      Assignment // nil when the member must not be sent an assignment
+		wantState MemberState
+	}{
+		{"first member is asked to revoke", a, 1, all, 1, target[a], MemberUnrevokedPartitions},
+		{"second member waits for the revocation", b, 2, Assignment{}, 2, nil, MemberUnreleasedPartitions},
+		{"revocation without owned partitions is not acknowledged", a, 1, nil, 1, nil, MemberUnrevokedPartitions},
+		{"revocation with partitions still owned is not acknowledged", a, 1, all, 1, target[a], MemberUnrevokedPartitions},
+		{"first member acknowledges the revocation", a, 1, target[a], 2, target[a], MemberStable},
+		{"second member gets the revoked partitions", b, 2, Assignment{}, 2, target[b], MemberStable},
+		{"second member is stable", b, 2, target[b], 2, nil, MemberStable},
+	}
+	for _, step := range steps {
+		res := heartbeat(t, c, heartbeatRequest(step.memberId, step.epoch, step.owned))
+		if res.MemberEpoch != step.wantEpoch {
+			t.Errorf("%s: epoch %d, want %d", step.name, res.MemberEpoch, step.wantEpoch)
+		}
+		if got := assigned(res); (got == nil) != (step.want == nil) || (got != nil && !got.Equal(step.want)) {
+			t.Errorf("%s: assignment %v, want %v", step.name, got, step.want)
+		}
+		if got := c.groups["g"].Members[step.memberId].State; got != step.wantState {
+			t.Errorf("%s: state %d, want %d", step.name, got, step.wantState)
+		}
+	}
+	if state := c.groups["g"].State(); state != "Stable" {
+		t.Errorf("group state %s, want Stable", state)
+	}
+}
+
+func TestRevocationTimeout(t *testing.T) {
+	c := newTestCoordinator()
+	now := time.Now()
+
+	res, err := c.heartbeat("client", "/127.0.0.1", joinRequest(nil), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := string(*res.MemberId)
+	if _, err := c.heartbeat("client", "/127.0.0.1", joinRequest(nil), now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.heartbeat("client", "/127.0.0.1", heartbeatRequest(a, 1, Assignment{topicA: {0, 1, 2, 3}}), now); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.groups["g"].Members[a].State; got != MemberUnrevokedPartitions {
+		t.Fatalf("state %d, want %d", got, MemberUnrevokedPartitions)
+	}
+
+	// a member that does not revoke within its rebalance timeout is removed
+	c.groups["g"].expireMembers(now.Add(31*time.Second), time.Hour)
+	if _, ok := c.groups["g"].Members[a]; ok {
+		t.Error("member with unrevoked partitions was not removed after its rebalance timeout")
+	}
+}
+
+func TestStaticMemberRejoin(t *testing.T) {
+	c := newTestCoordinator()
+	instanceId := "instance-1"
+
+	res := heartbeat(t, c, joinRequest(&instanceId))
+	first := string(*res.MemberId)
+	owned := assigned(res)
+	heartbeat(t, c, heartbeatRequest(first, res.MemberEpoch, owned))
+	groupEpoch := c.groups["g"].GroupEpoch
+
+	// the instance id is taken until the member leaves as a static member
+	res = c.ConsumerGroupHeartbeat("client", "/127.0.0.1", joinRequest(&instanceId))
+	if res.ErrorCode != constant.UNRELEASED_INSTANCE_ID {
+		t.Fatalf("join with a taken instance id: error %d, want %d", res.ErrorCode, constant.UNRELEASED_INSTANCE_ID)
+	}
+
+	leave := heartbeatRequest(first, LeaveGroupStaticMemberEpoch, nil)
+	leave.InstanceId = joinRequest(&instanceId).InstanceId
+	res = heartbeat(t, c, leave)
+	if res.MemberEpoch != LeaveGroupStaticMemberEpoch {
+		t.Fatalf("static leave: epoch %d, want %d", res.MemberEpoch, LeaveGroupStaticMemberEpoch)
+	}
+	if _, ok := c.groups["g"].Members[first]; !ok {
+		t.Fatal("static member was removed when it left")
+	}
+
+	// the rejoining instance takes over the partitions without a rebalance
+	res = heartbeat(t, c, joinRequest(&instanceId))
+	second := string(*res.MemberId)
+	if second == first {
+		t.Fatalf("rejoining instance kept member id %s", first)
+	}
+	if res.MemberEpoch != 1 || !assigned(res).Equal(owned) {
+		t.Errorf("rejoin: epoch %d and %v, want epoch 1 and %v", res.MemberEpoch, assigned(res), owned)
+	}
+	g := c.groups["g"]
+	if g.GroupEpoch != groupEpoch {
+		t.Errorf("group epoch %d, want %d", g.GroupEpoch, groupEpoch)
+	}
+	if _, ok := g.Members[first]; ok {
+		t.Errorf("replaced member %s is still in the group", first)
+	}
+	if g.StaticMembers[instanceId] != second {
+		t.Errorf("instance id maps to %s, want %s", g.StaticMembers[instanceId], second)
+	}
+	if !g.TargetAssignment[second].Equal(owned) {
+		t.Errorf("target assignment %v, want %v", g.TargetAssignment[second], owned)
+	}
+}
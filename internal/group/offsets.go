@@ -0,0 +1,342 @@
+package group
+
+import (
+	"sort"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// OffsetsTopic is the partition transactions write markers to when they
+// commit consumer offsets.
+const OffsetsTopic = "__consumer_offsets"
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type OffsetAndMetadata struct {
+	Offset          int64
+	LeaderEpoch     int32
+	Metadata        *string
+	CommitTimestamp int64
+}
+
+// CommittedOffset returns the offset committed by a group for a partition.
+func (c *Coordinator) CommittedOffset(groupId, topic string, partition int32) (OffsetAndMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	o, ok := c.offsets[groupId][topicPartition{topic, partition}]
+	return o, ok
+}
+
+// CommittedPartitionOffset is the offset a group committed for a partition.
+type CommittedPartitionOffset struct {
+	GroupId   string
+	Topic     string
+	Partition int32
+	Offset    int64
+}
+
+// CommittedOffsets returns the offsets committed by every group, leaving
+// out those of transactions that are still pending.
+func (c *Coordinator) CommittedOffsets() []CommittedPartitionOffset {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var offsets []CommittedPartitionOffset
+	for groupId, committed := range c.offsets {
+		for tp, o := range committed {
+			offsets = append(offsets, CommittedPartitionOffset{groupId, tp.topic, tp.partition, o.Offset})
+		}
+	}
+	return offsets
+}
+
+// OffsetCommit commits offsets outside of a transaction. Offsets may be
+// committed for groups that do not exist, as admin tools do.
+func (c *Coordinator) OffsetCommit(req *request.OffsetCommitV9) *response.OffsetCommitV9 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	errorCode := c.validateOffsetCommit(req, time.Now())
+	res := &response.OffsetCommitV9{
+		Topics: make([]response.TxnTopicResult, len(req.Topics)),
+	}
+	groupId := string(req.GroupId)
+	now := time.Now().UnixMilli()
+	for i, t := range req.Topics {
+		res.Topics[i].Name = t.Name
+		res.Topics[i].Results = make([]response.TxnPartitionResult, len(t.Partitions))
+		topic, topicExists := c.cluster.TopicByName(string(t.Name))
+		for j, p := range t.Partitions {
+			pr := &res.Topics[i].Results[j]
+			pr.PartitionIndex = p.PartitionIndex
+			pr.ErrorCode = errorCode
+			if errorCode != constant.NONE {
+				continue
+			}
+			if !topicExists || p.PartitionIndex < 0 || int(p.PartitionIndex) >= len(topic.Partitions) {
+				pr.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+				continue
+			}
+
+			offset := OffsetAndMetadata{
+				Offset:          p.CommittedOffset,
+				LeaderEpoch:     p.CommittedLeaderEpoch,
+				CommitTimestamp: now,
+			}
+			if p.CommittedMetadata != nil {
+				metadata := string(*p.CommittedMetadata)
+				offset.Metadata = &metadata
+			}
+			offsets, ok := c.offsets[groupId]
+			if !ok {
+				offsets = make(map[topicPartition]OffsetAndMetadata)
+				c.offsets[groupId] = offsets
+			}
+			offsets[topicPartition{string(t.Name), p.PartitionIndex}] = offset
+		}
+	}
+	return res
+}
+
+// validateOffsetCommit checks the member fields. A member epoch of -1 comes
+// from clients outside the group, which may only commit while the group has
+// no members.
+func (c *Coordinator) validateOffsetCommit(req *request.OffsetCommitV9, now time.Time) int16 {
+	if req.GroupId == "" {
+		return constant.INVALID_GROUP_ID
+	}
+	g, ok := c.groups[string(req.GroupId)]
+	if ok {
+		g.expireMembers(now, c.SessionTimeout)
+	}
+	if req.GenerationIdOrMemberEpoch < 0 && req.MemberId == "" {
+		if ok && len(g.Members) > 0 {
+			return constant.UNKNOWN_MEMBER_ID
+		}
+		return constant.NONE
+	}
+	if !ok {
+		return constant.UNKNOWN_MEMBER_ID
+	}
+	m, ok := g.Members[string(req.MemberId)]
+	if !ok {
+		return constant.UNKNOWN_MEMBER_ID
+	}
+	if req.GenerationIdOrMemberEpoch != m.MemberEpoch {
+		return constant.STALE_MEMBER_EPOCH
+	}
+	return constant.NONE
+}
+
+// OffsetFetch returns the offsets a group committed for the requested
+// partitions, or for every partition when no topic is given.
+func (c *Coordinator) OffsetFetch(req request.OffsetFetchGroup, requireStable bool) response.OffsetFetchGroup {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := response.OffsetFetchGroup{GroupId: req.GroupId, Topics: []response.OffsetFetchTopic{}}
+	groupId := string(req.GroupId)
+	if req.MemberId != nil && req.MemberEpoch >= 0 {
+		g, ok := c.groups[groupId]
+		if !ok {
+			res.ErrorCode = constant.UNKNOWN_MEMBER_ID
+			return res
+		}
+		m, ok := g.Members[string(*req.MemberId)]
+		switch {
+		case !ok:
+			res.ErrorCode = constant.UNKNOWN_MEMBER_ID
+			return res
+		case req.MemberEpoch != m.MemberEpoch:
+			res.ErrorCode = constant.STALE_MEMBER_EPOCH
+			return res
+		}
+	}
+
+	topics := req.Topics
+	if topics == nil {
+		topics = c.committedTopics(groupId)
+	}
+	for _, t := range topics {
+		topic := response.OffsetFetchTopic{Name: t.Name, Partitions: make([]response.OffsetFetchPartition, len(t.PartitionIndexes))}
+		for i, p := range t.PartitionIndexes {
+			tp := topicPartition{string(t.Name), p}
+			fp := response.OffsetFetchPartition{
+				PartitionIndex:       p,
+				CommittedOffset:      -1,
+				CommittedLeaderEpoch: -1,
+				Metadata:             toCompactString(new(string)),
+			}
+			if requireStable && c.hasPendingTxnOffset(groupId, tp) {
+				fp.ErrorCode = constant.UNSTABLE_OFFSET_COMMIT
+			} else if o, ok := c.offsets[groupId][tp]; ok {
+				fp.CommittedOffset = o.Offset
+				fp.CommittedLeaderEpoch = o.LeaderEpoch
+				if o.Metadata != nil {
+					fp.Metadata = toCompactString(o.Metadata)
+				}
+			}
+			topic.Partitions[i] = fp
+		}
+		res.Topics = append(res.Topics, topic)
+	}
+	return res
+}
+
+// committedTopics lists the partitions a group committed offsets for,
+// sorted by topic and partition.
+func (c *Coordinator) committedTopics(groupId string) []request.OffsetFetchTopic {
+	partitions := make(map[string][]int32)
+	for tp := range c.offsets[groupId] {
+		partitions[tp.topic] = append(partitions[tp.topic], tp.partition)
+	}
+	names := make([]string, 0, len(partitions))
+	for name := range partitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	topics := make([]request.OffsetFetchTopic, len(names))
+	for i, name := range names {
+		sort.Slice(partitions[name], func(a, b int) bool { return partitions[name][a] < partitions[name][b] })
+		topics[i] = request.OffsetFetchTopic{Name: types.CompactString(name), PartitionIndexes: partitions[name]}
+	}
+	return topics
+}
+
+func (c *Coordinator) hasPendingTxnOffset(groupId string, tp topicPartition) bool {
+	for _, groups := range c.pendingOffsets {
+		if _, ok := groups[groupId][tp]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteTopicOffsets forgets the offsets committed for a deleted topic.
+func (c *Coordinator) DeleteTopicOffsets(topic string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, offsets := range c.offsets {
+		for tp := range offsets {
+			if tp.topic == topic {
+				delete(offsets, tp)
+			}
+		}
+	}
+	for _, groups := range c.pendingOffsets {
+		for _, offsets := range groups {
+			for tp := range offsets {
+				if tp.topic == topic {
+					delete(offsets, tp)
+				}
+			}
+		}
+	}
+}
+
+// TxnOffsetCommit stages offsets committed as part of a transaction. They
+// become visible once the transaction commits and are dropped if it aborts.
+func (c *Coordinator) TxnOffsetCommit(req *request.TxnOffsetCommitV3) *response.TxnOffsetCommitV3 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	errorCode := c.validateTxnOffsetCommit(req)
+	res := &response.TxnOffsetCommitV3{
+		Topics: make([]response.TxnTopicResult, len(req.Topics)),
+	}
+	now := time.Now().UnixMilli()
+	for i, t := range req.Topics {
+		res.Topics[i].Name = t.Name
+		res.Topics[i].Results = make([]response.TxnPartitionResult, len(t.Partitions))
+		topic, topicExists := c.cluster.TopicByName(string(t.Name))
+		for j, p := range t.Partitions {
+			pr := &res.Topics[i].Results[j]
+			pr.PartitionIndex = p.PartitionIndex
+			pr.ErrorCode = errorCode
+			if errorCode != constant.NONE {
+				continue
+			}
+			if !topicExists || p.PartitionIndex < 0 || int(p.PartitionIndex) >= len(topic.Partitions) {
+				pr.ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+				continue
+			}
+
+			offset := OffsetAndMetadata{
+				Offset:          p.CommittedOffset,
+				LeaderEpoch:     p.CommittedLeaderEpoch,
+				CommitTimestamp: now,
+			}
+			if p.CommittedMetadata != nil {
+				metadata := string(*p.CommittedMetadata)
+				offset.Metadata = &metadata
+			}
+			c.pendingTxnOffsets(req.ProducerId, string(req.GroupId))[topicPartition{string(t.Name), p.PartitionIndex}] = offset
+		}
+	}
+	return res
+}
+
+// validateTxnOffsetCommit checks the member fields, which are only set by
+// consumers that are part of the group.
+func (c *Coordinator) validateTxnOffsetCommit(req *request.TxnOffsetCommitV3) int16 {
+	if req.GroupId == "" {
+		return constant.INVALID_GROUP_ID
+	}
+	if req.MemberId == "" {
+		return constant.NONE
+	}
+	g, ok := c.groups[string(req.GroupId)]
+	if !ok {
+		return constant.UNKNOWN_MEMBER_ID
+	}
+	m, ok := g.Members[string(req.MemberId)]
+	if !ok {
+		return constant.UNKNOWN_MEMBER_ID
+	}
+	if req.GenerationId != m.MemberEpoch {
+		return constant.ILLEGAL_GENERATION
+	}
+	return constant.NONE
+}
+
+func (c *Coordinator) pendingTxnOffsets(producerId int64, groupId string) map[topicPartition]OffsetAndMetadata {
+	groups, ok := c.pendingOffsets[producerId]
+	if !ok {
+		groups = make(map[string]map[topicPartition]OffsetAndMetadata)
+		c.pendingOffsets[producerId] = groups
+	}
+	offsets, ok := groups[groupId]
+	if !ok {
+		offsets = make(map[topicPartition]OffsetAndMetadata)
+		groups[groupId] = offsets
+	}
+	return offsets
+}
+
+// CompleteTxnOffsets applies or discards the offsets a producer staged in
+// its transaction, as instructed by a marker written to OffsetsTopic.
+func (c *Coordinator) CompleteTxnOffsets(producerId int64, commit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if commit {
+		for groupId, pending := range c.pendingOffsets[producerId] {
+			offsets, ok := c.offsets[groupId]
+			if !ok {
+				offsets = make(map[topicPartition]OffsetAndMetadata)
+				c.offsets[groupId] = offsets
+			}
+			for tp, o := range pending {
+				offsets[tp] = o
+			}
+		}
+	}
+	delete(c.pendingOffsets, producerId)
+}
@@ -0,0 +1,176 @@
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Security protocols a listener can use.
+const (
+	Plaintext     = "PLAINTEXT"
+	Ssl           = "SSL"
+	SaslPlaintext = "SASL_PLAINTEXT"
+	SaslSsl       = "SASL_SSL"
+)
+
+// Client certificate policies of TLS listeners, as in ssl.client.auth.
+const (
+	ClientAuthNone      = "none"
+	ClientAuthRequested = "requested"
+	ClientAuthRequired  = "required"
+)
+
+// Listener is an endpoint the broker accepts connections on.
+type Listener struct {
+	Name             string
+	SecurityProtocol string
+	Address          string
+	// Advertised is the address clients are told to connect to, empty when
+	// it is the address they connected to
+	Advertised string
+	// TLS is set on SSL and SASL_SSL listeners
+	TLS *tls.Config
+}
+
+// Sasl tells whether clients must authenticate with SASL.
+func (l *Listener) Sasl() bool {
+	return l.SecurityProtocol == SaslPlaintext || l.SecurityProtocol == SaslSsl
+}
+
+// Secure tells whether connections are encrypted with TLS.
+func (l *Listener) Secure() bool {
+	return l.SecurityProtocol == Ssl || l.SecurityProtocol == SaslSsl
+}
+
+// Parse parses a listeners configuration like
+// PLAINTEXT://:9092,SSL://0.0.0.0:9093. Listener names are mapped to their
+// security protocol by protocolMap, like INTERNAL:SSL,PLAINTEXT:PLAINTEXT.
+func Parse(listeners, protocolMap string) ([]*Listener, error) {
+	protocols := make(map[string]string)
+	for _, entry := range strings.Split(protocolMap, ",") {
+		name, protocol, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid listener security protocol map entry %q", entry)
+		}
+		switch protocol {
+		case Plaintext, Ssl, SaslPlaintext, SaslSsl:
+		default:
+			return nil, fmt.Errorf("unknown security protocol %s for listener %s", protocol, name)
+		}
+		protocols[name] = protocol
+	}
+
+	var result []*Listener
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(listeners, ",") {
+		name, address, ok := strings.Cut(strings.TrimSpace(entry), "://")
+		if !ok {
+			return nil, fmt.Errorf("invalid listener %q: expected NAME://host:port", entry)
+		}
+		protocol, ok := protocols[name]
+		if !ok {
+			return nil, fmt.Errorf("no security protocol defined for listener %s", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("listener %s is defined more than once", name)
+		}
+		seen[name] = true
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return nil, fmt.Errorf("invalid address of listener %s: %s", name, err)
+		}
+		result = append(result, &Listener{Name: name, SecurityProtocol: protocol, Address: address})
+	}
+	return result, nil
+}
+
+// Advertise sets the advertised addresses of listeners from an
+// advertised.listeners configuration like PLAINTEXT://broker1:9092. Every
+// advertised listener must be one of listeners, and must name a host
+// clients can connect to.
+func Advertise(listeners []*Listener, advertised string) error {
+	byName := make(map[string]*Listener, len(listeners))
+	for _, l := range listeners {
+		byName[l.Name] = l
+	}
+	for _, entry := range strings.Split(advertised, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, address, ok := strings.Cut(entry, "://")
+		if !ok {
+			return fmt.Errorf("invalid advertised listener %q: expected NAME://host:port", entry)
+		}
+		l, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("advertised listener %s is not one of the listeners", name)
+		}
+		if l.Advertised != "" {
+			return fmt.Errorf("advertised listener %s is defined more than once", name)
+		}
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("invalid advertised address of listener %s: %s", name, err)
+		}
+		if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+			return fmt.Errorf("advertised address of listener %s must name a host clients can connect to, not %q", name, host)
+		}
+		l.Advertised = address
+	}
+	return nil
+}
+
+// NewTLSConfig builds the server TLS configuration from a PEM keystore
+// holding the broker's private key and certificate chain, and a PEM
+// truststore with the CAs client certificates are verified against.
+func NewTLSConfig(keystore, truststore, clientAuth string) (*tls.Config, error) {
+	if keystore == "" {
+		return nil, fmt.Errorf("ssl.keystore.location is required by TLS listeners")
+	}
+	cert, err := tls.LoadX509KeyPair(keystore, keystore)
+	if err != nil {
+		return nil, fmt.Errorf("error loading keystore %s: %s", keystore, err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	switch clientAuth {
+	case ClientAuthNone:
+		return config, nil
+	case ClientAuthRequested:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequired:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("invalid ssl.client.auth %s", clientAuth)
+	}
+	if truststore == "" {
+		return nil, fmt.Errorf("ssl.truststore.location is required to verify client certificates")
+	}
+	pem, err := os.ReadFile(truststore)
+	if err != nil {
+		return nil, fmt.Errorf("error reading truststore: %s", err)
+	}
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in truststore %s", truststore)
+	}
+	return config, nil
+}
+
+// Listen opens the listener's socket, wrapped in TLS when it is secure.
+func (l *Listener) Listen() (net.Listener, error) {
+	ln, err := net.Listen("tcp", l.Address)
+	if err != nil {
+		return nil, err
+	}
+	if l.TLS != nil {
+		return tls.NewListener(ln, l.TLS), nil
+	}
+	return ln, nil
+}
@@ -0,0 +1,291 @@
+package listener
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate authority generated for a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue signs a certificate for subject, usable by a server at 127.0.0.1
+// or by a client.
+func (ca *testCA) issue(t *testing.T, subject pkix.Name) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writeKeystore writes the key and certificate of cert to a PEM file.
+func writeKeystore(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})...)
+	return writeFile(t, "keystore.pem", data)
+}
+
+// writeTruststore writes the certificate of ca to a PEM file.
+func writeTruststore(t *testing.T, ca *testCA) string {
+	t.Helper()
+	return writeFile(t, "truststore.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// handshake connects to a listener with the given client certificates and
+// returns the connection state the server ended up with, or the error of
+// its handshake.
+func handshake(t *testing.T, config *tls.Config, ca *testCA, clientCerts []tls.Certificate) (tls.ConnectionState, error) {
+	t.Helper()
+	l := &Listener{Name: "SSL", SecurityProtocol: Ssl, Address: "127.0.0.1:0", TLS: config}
+	ln, err := l.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		tc := conn.(*tls.Conn)
+		err = tc.Handshake()
+		results <- result{tc.ConnectionState(), err}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{
+		RootCAs: roots,
+		// send the certificate even when it is not signed by a CA the
+		// server asks for, which the server must then reject
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if len(clientCerts) == 0 {
+				return &tls.Certificate{}, nil
+			}
+			return &clientCerts[0], nil
+		},
+	}
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientConfig)
+	if err == nil {
+		defer conn.Close()
+	}
+	r := <-results
+	return r.state, r.err
+}
+
+func TestTLSClientAuth(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	otherCA := newTestCA(t, "Other CA")
+	keystore := writeKeystore(t, ca.issue(t, pkix.Name{CommonName: "broker"}))
+	truststore := writeTruststore(t, ca)
+	trusted := ca.issue(t, pkix.Name{CommonName: "alice"})
+	untrusted := otherCA.issue(t, pkix.Name{CommonName: "mallory"})
+
+	tests := []struct {
+		clientAuth string
+		cert       *tls.Certificate
+		wantErr    bool
+		wantPeer   string
+	}{
+		{ClientAuthNone, nil, false, ""},
+		{ClientAuthNone, &trusted, false, ""},
+		{ClientAuthRequested, nil, false, ""},
+		{ClientAuthRequested, &trusted, false, "CN=alice"},
+		{ClientAuthRequested, &untrusted, true, ""},
+		{ClientAuthRequired, nil, true, ""},
+		{ClientAuthRequired, &trusted, false, "CN=alice"},
+		{ClientAuthRequired, &untrusted, true, ""},
+	}
+	for _, tt := range tests {
+		name := tt.clientAuth + "/no certificate"
+		var certs []tls.Certificate
+		if tt.cert != nil {
+			name = tt.clientAuth + "/" + tt.cert.Leaf.Subject.CommonName
+			certs = []tls.Certificate{*tt.cert}
+		}
+		t.Run(name, func(t *testing.T) {
+			config, err := NewTLSConfig(keystore, truststore, tt.clientAuth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, err := handshake(t, config, ca, certs)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("handshake error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			peer := ""
+			if len(state.PeerCertificates) > 0 {
+				peer = state.PeerCertificates[0].Subject.String()
+			}
+			if peer != tt.wantPeer {
+				t.Errorf("peer certificate subject = %q, want %q", peer, tt.wantPeer)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	keystore := writeKeystore(t, ca.issue(t, pkix.Name{CommonName: "broker"}))
+	truststore := writeTruststore(t, ca)
+	empty := writeFile(t, "empty.pem", nil)
+
+	tests := []struct {
+		name                 string
+		keystore, truststore string
+		clientAuth           string
+	}{
+		{"no keystore", "", truststore, ClientAuthNone},
+		{"unreadable keystore", empty, truststore, ClientAuthNone},
+		{"unknown client auth", keystore, truststore, "sometimes"},
+		{"no truststore", keystore, "", ClientAuthRequired},
+		{"empty truststore", keystore, empty, ClientAuthRequested},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTLSConfig(tt.keystore, tt.truststore, tt.clientAuth); err == nil {
+				t.Error("NewTLSConfig succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestCertificatePrincipal(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	keystore := writeKeystore(t, ca.issue(t, pkix.Name{CommonName: "broker"}))
+	truststore := writeTruststore(t, ca)
+	client := ca.issue(t, pkix.Name{CommonName: "Alice", OrganizationalUnit: []string{"Eng"}, Organization: []string{"Example"}})
+	config, err := NewTLSConfig(keystore, truststore, ClientAuthRequired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := handshake(t, config, ca, []tls.Certificate{client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dn := state.PeerCertificates[0].Subject.String()
+	if want := "CN=Alice,OU=Eng,O=Example"; dn != want {
+		t.Fatalf("subject = %q, want %q", dn, want)
+	}
+
+	tests := []struct {
+		rules string
+		want  string
+	}{
+		{"DEFAULT", dn},
+		{"", dn},
+		{"RULE:^CN=(.*?),OU=(.*?),O=(.*)$/$1/", "Alice"},
+		{"RULE:^CN=(.*?),OU=(.*?),O=(.*)$/$1@$3/L", "alice@example"},
+		{"RULE:^CN=(.*?),OU=(.*?),O=(.*)$/$2/U", "ENG"},
+		{"RULE:^CN=Bob,.*$/bob/, RULE:^CN=(.*?),.*$/$1/", "Alice"},
+		{"RULE:^CN=Bob,.*$/bob/, DEFAULT", dn},
+		{"RULE:^CN=(.*?),OU=Eng\\/Ops,.*$/$1/, DEFAULT", dn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rules, func(t *testing.T) {
+			m, err := ParsePrincipalMappingRules(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Principal(dn); got != tt.want {
+				t.Errorf("Principal(%q) = %q, want %q", dn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrincipalMappingRulesErrors(t *testing.T) {
+	for _, rules := range []string{
+		"RULE:^CN=(.*)$",
+		"RULE:^CN=(.*)$/$1",
+		"RULE:^CN=((.*)$/$1/",
+		"RULE:^CN=(.*)$/$1/ DEFAULT",
+		"NONE",
+	} {
+		if _, err := ParsePrincipalMappingRules(rules); err == nil {
+			t.Errorf("ParsePrincipalMappingRules(%q) succeeded, want an error", rules)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package listener
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// principalRule rewrites a certificate subject when its pattern matches
+// the whole distinguished name.
+type principalRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+	toLower     bool
+	toUpper     bool
+}
+
+// PrincipalMapper maps the distinguished name of a client certificate to
+// the name of its principal, following ssl.principal.mapping.rules: a list
+// of RULE:pattern/replacement/[LU] entries tried in order, where DEFAULT
+// keeps the distinguished name as is.
+type PrincipalMapper struct {
+	rules []*principalRule // nil for DEFAULT
+}
+
+func ParsePrincipalMappingRules(rules string) (*PrincipalMapper, error) {
+	m := &PrincipalMapper{}
+	rest := strings.TrimSpace(rules)
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "DEFAULT"):
+			m.rules = append(m.rules, nil)
+			rest = rest[len("DEFAULT"):]
+		case strings.HasPrefix(rest, "RULE:"):
+			rule, remaining, err := parseRule(rest[len("RULE:"):])
+			if err != nil {
+				return nil, fmt.Errorf("invalid principal mapping rule %q: %s", rest, err)
+			}
+			m.rules = append(m.rules, rule)
+			rest = remaining
+		default:
+			return nil, fmt.Errorf("invalid principal mapping rule %q", rest)
+		}
+		rest = strings.TrimSpace(rest)
+		if rest != "" {
+			if rest[0] != ',' {
+				return nil, fmt.Errorf("invalid principal mapping rules %q: expected a comma", rules)
+			}
+			rest = strings.TrimSpace(rest[1:])
+		}
+	}
+	if len(m.rules) == 0 {
+		m.rules = append(m.rules, nil)
+	}
+	return m, nil
+}
+
+// parseRule parses pattern/replacement/[LU] and returns what follows.
+func parseRule(s string) (*principalRule, string, error) {
+	pattern, s, ok := cutUnescaped(s)
+	if !ok {
+		return nil, "", fmt.Errorf("missing replacement")
+	}
+	replacement, s, ok := cutUnescaped(s)
+	if !ok {
+		return nil, "", fmt.Errorf("missing closing /")
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, "", err
+	}
+	rule := &principalRule{pattern: re, replacement: replacement}
+	if strings.HasPrefix(s, "L") {
+		rule.toLower = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "U") {
+		rule.toUpper = true
+		s = s[1:]
+	}
+	return rule, s, nil
+}
+
+// cutUnescaped cuts s around the first / not preceded by a backslash.
+func cutUnescaped(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '/':
+			b.WriteByte('/')
+			i++
+		case s[i] == '/':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", false
+}
+
+// Principal returns the principal name of a distinguished name, or the
+// distinguished name itself when no rule matches.
+func (m *PrincipalMapper) Principal(dn string) string {
+	for _, rule := range m.rules {
+		if rule == nil {
+			return dn
+		}
+		match := rule.pattern.FindStringSubmatchIndex(dn)
+		if match == nil {
+			continue
+		}
+		name := string(rule.pattern.ExpandString(nil, rule.replacement, dn, match))
+		if rule.toLower {
+			name = strings.ToLower(name)
+		} else if rule.toUpper {
+			name = strings.ToUpper(name)
+		}
+		return name
+	}
+	return dn
+}
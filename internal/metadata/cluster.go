@@ -0,0 +1,127 @@
+package metadata
+
+import (
+	"sort"
+	"sync"
+)
+
+type Partition struct {
+	PartitionIndex int32
+	LeaderId       int32
+	LeaderEpoch    int32
+	ReplicaNodes   []int32
+	ISRNodes       []int32
+}
+
+type Topic struct {
+	Name       string
+	TopicId    [16]byte
+	IsInternal bool
+	Partitions []Partition
+}
+
+// Cluster is the broker's view of the topics it knows about.
+type Cluster struct {
+	mu         sync.RWMutex
+	NodeId     int32
+	topics     map[string]*Topic
+	topicsById map[[16]byte]*Topic
+}
+
+func NewCluster(nodeId int32) *Cluster {
+	return &Cluster{
+		NodeId:     nodeId,
+		topics:     make(map[string]*Topic),
+		topicsById: make(map[[16]byte]*Topic),
+	}
+}
+
+func (c *Cluster) PutTopic(t Topic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.topics[t.Name]; ok {
+		delete(c.topicsById, old.TopicId)
+	}
+	c.topics[t.Name] = &t
+	c.topicsById[t.TopicId] = &t
+}
+
+func (c *Cluster) RemoveTopic(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.topics[name]; ok {
+		delete(c.topicsById, t.TopicId)
+		delete(c.topics, name)
+	}
+}
+
+func (c *Cluster) TopicByName(name string) (Topic, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	t, ok := c.topics[name]
+	if !ok {
+		return Topic{}, false
+	}
+	return *t, true
+}
+
+func (c *Cluster) TopicById(id [16]byte) (Topic, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	t, ok := c.topicsById[id]
+	if !ok {
+		return Topic{}, false
+	}
+	return *t, true
+}
+
+// Topics returns every known topic sorted by name.
+func (c *Cluster) Topics() []Topic {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	topics := make([]Topic, 0, len(c.topics))
+	for _, t := range c.topics {
+		topics = append(topics, *t)
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics
+}
+
+// Replay applies topic and partition records from the metadata log.
+func (c *Cluster) Replay(rec Record) {
+	switch r := rec.(type) {
+	case *TopicRecord:
+		c.PutTopic(Topic{
+			Name:    string(r.Name),
+			TopicId: r.TopicId,
+		})
+	case *PartitionRecord:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		t, ok := c.topicsById[r.TopicId]
+		if !ok {
+			return
+		}
+		p := Partition{
+			PartitionIndex: r.PartitionId,
+			LeaderId:       r.Leader,
+			LeaderEpoch:    r.LeaderEpoch,
+			ReplicaNodes:   r.Replicas,
+			ISRNodes:       r.Isr,
+		}
+		partitions := append([]Partition(nil), t.Partitions...)
+		i := sort.Search(len(partitions), func(i int) bool { return partitions[i].PartitionIndex >= p.PartitionIndex })
+		if i < len(partitions) && partitions[i].PartitionIndex == p.PartitionIndex {
+			partitions[i] = p
+		} else {
+			partitions = append(partitions, Partition{})
+			copy(partitions[i+1:], partitions[i:])
+			partitions[i] = p
+		}
+		t.Partitions = partitions
+	case *RemoveTopicRecord:
+		if t, ok := c.TopicById(r.TopicId); ok {
+			c.RemoveTopic(t.Name)
+		}
+	}
+}
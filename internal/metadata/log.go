@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+)
+
+const ClusterMetadataTopic = "__cluster_metadata"
+
+// Log persists metadata records in the __cluster_metadata partition. Every
+// record, whether replayed at startup or appended later, is handed to the
+// registered listeners in log order.
+type Log struct {
+	mu        sync.Mutex
+	log       *storage.Log
+	listeners []func(Record)
+}
+
+func OpenLog(logs *storage.LogManager) (*Log, error) {
+	l, err := logs.GetOrCreateLog(ClusterMetadataTopic, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{log: l}, nil
+}
+
+// Register adds a listener; it must be called before Replay.
+func (l *Log) Register(fn func(Record)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// Replay hands every record already in the log to the listeners.
+func (l *Log) Replay() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for offset := l.log.LogStartOffset(); offset < l.log.LogEndOffset(); {
+		data, err := l.log.Read(offset, 1<<20, l.log.LogEndOffset())
+		if err != nil {
+			return err
+		}
+		batches, err := record.ReadRecordBatches(data)
+		if err != nil {
+			return err
+		}
+		if len(batches) == 0 {
+			return fmt.Errorf("error replaying metadata log: no batch at offset %d", offset)
+		}
+		for _, b := range batches {
+			offset = b.LastOffset() + 1
+			if b.IsControl() {
+				continue
+			}
+			for _, rec := range b.Records {
+				mr, err := DecodeRecord(rec.Value)
+				if err != nil {
+					return fmt.Errorf("error replaying metadata log at offset %d: %s", b.BaseOffset+int64(rec.OffsetDelta), err)
+				}
+				for _, fn := range l.listeners {
+					fn(mr)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Append writes the records as a single batch and applies them.
+func (l *Log) Append(records ...Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	b := &record.RecordBatch{
+		PartitionLeaderEpoch: 0,
+		LastOffsetDelta:      int32(len(records) - 1),
+		BaseTimestamp:        now,
+		MaxTimestamp:         now,
+		ProducerId:           record.NoProducerId,
+		ProducerEpoch:        record.NoProducerEpoch,
+		BaseSequence:         record.NoSequence,
+		Records:              make([]record.Record, len(records)),
+	}
+	for i, rec := range records {
+		b.Records[i] = record.Record{
+			OffsetDelta: int32(i),
+			Value:       EncodeRecord(rec),
+		}
+	}
+	if _, err := l.log.Append(b); err != nil {
+		return err
+	}
+	if err := l.log.Flush(); err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		for _, fn := range l.listeners {
+			fn(rec)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,703 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// Metadata record types as stored in the __cluster_metadata log.
+const (
+	TopicRecordType                     int8 = 2
+	PartitionRecordType                 int8 = 3
+	ConfigRecordType                    int8 = 4
+	AccessControlEntryRecordType        int8 = 6
+	RemoveAccessControlEntryRecordType  int8 = 7
+	RemoveTopicRecordType               int8 = 10
+	UserScramCredentialRecordType       int8 = 11
+	FeatureLevelRecordType              int8 = 12
+	ClientQuotaRecordType               int8 = 14
+	ProducerIdsRecordType               int8 = 15
+	RemoveUserScramCredentialRecordType int8 = 22
+
+	recordFrameVersion = 1
+)
+
+// Record is a metadata record. Its value in the log is a frame version,
+// record type and record version followed by the record's own fields.
+type Record interface {
+	Type() int8
+	Version() int8
+	Write(w io.Writer) error
+}
+
+// UnknownRecord keeps the fields of record types the broker does not use.
+type UnknownRecord struct {
+	RecordType    int8
+	RecordVersion int8
+	Data          []byte
+}
+
+func (r *UnknownRecord) Type() int8    { return r.RecordType }
+func (r *UnknownRecord) Version() int8 { return r.RecordVersion }
+func (r *UnknownRecord) Write(w io.Writer) error {
+	_, err := w.Write(r.Data)
+	return err
+}
+
+type TopicRecord struct {
+	Name      types.CompactString
+	TopicId   [16]byte
+	TagBuffer types.TaggedFields
+}
+
+func (r *TopicRecord) Type() int8    { return TopicRecordType }
+func (r *TopicRecord) Version() int8 { return 0 }
+
+func (r *TopicRecord) Write(w io.Writer) error {
+	if err := r.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if _, err := w.Write(r.TopicId[:]); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readTopicRecord(r *bytes.Reader) (*TopicRecord, error) {
+	rec := &TopicRecord{}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Name = *name
+	if rec.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+type PartitionRecord struct {
+	RecordVersion    int8
+	PartitionId      int32
+	TopicId          [16]byte
+	Replicas         []int32
+	Isr              []int32
+	RemovingReplicas []int32
+	AddingReplicas   []int32
+	Leader           int32
+	LeaderEpoch      int32
+	PartitionEpoch   int32
+	Directories      [][16]byte // version 1+
+	TagBuffer        types.TaggedFields
+}
+
+func (r *PartitionRecord) Type() int8    { return PartitionRecordType }
+func (r *PartitionRecord) Version() int8 { return r.RecordVersion }
+
+func (r *PartitionRecord) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.PartitionId); err != nil {
+		return err
+	}
+	if _, err := w.Write(r.TopicId[:]); err != nil {
+		return err
+	}
+	for _, arr := range [][]int32{r.Replicas, r.Isr, r.RemovingReplicas, r.AddingReplicas} {
+		if err := types.WriteCompactInt32Array(w, arr); err != nil {
+			return err
+		}
+	}
+	for _, v := range []int32{r.Leader, r.LeaderEpoch, r.PartitionEpoch} {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	if r.RecordVersion >= 1 {
+		if err := types.WriteCompactArrayLength(w, len(r.Directories)); err != nil {
+			return err
+		}
+		for _, dir := range r.Directories {
+			if _, err := w.Write(dir[:]); err != nil {
+				return err
+			}
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readPartitionRecord(r *bytes.Reader, version int8) (*PartitionRecord, error) {
+	var err error
+	rec := &PartitionRecord{RecordVersion: version}
+	if err = binary.Read(r, binary.BigEndian, &rec.PartitionId); err != nil {
+		return nil, err
+	}
+	if rec.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, err
+	}
+	for _, arr := range []*[]int32{&rec.Replicas, &rec.Isr, &rec.RemovingReplicas, &rec.AddingReplicas} {
+		if *arr, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range []*int32{&rec.Leader, &rec.LeaderEpoch, &rec.PartitionEpoch} {
+		if err = binary.Read(r, binary.BigEndian, v); err != nil {
+			return nil, err
+		}
+	}
+	if version >= 1 {
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			dir, err := types.ReadUuid(r)
+			if err != nil {
+				return nil, err
+			}
+			rec.Directories = append(rec.Directories, dir)
+		}
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+// ConfigRecord sets a configuration of a resource, or removes it when Value
+// is null.
+type ConfigRecord struct {
+	ResourceType int8
+	ResourceName types.CompactString
+	Name         types.CompactString
+	Value        *types.CompactString
+	TagBuffer    types.TaggedFields
+}
+
+func (r *ConfigRecord) Type() int8    { return ConfigRecordType }
+func (r *ConfigRecord) Version() int8 { return 0 }
+
+func (r *ConfigRecord) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ResourceType); err != nil {
+		return err
+	}
+	if err := r.ResourceName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := r.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, r.Value); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readConfigRecord(r *bytes.Reader) (*ConfigRecord, error) {
+	var err error
+	rec := &ConfigRecord{}
+	if err = binary.Read(r, binary.BigEndian, &rec.ResourceType); err != nil {
+		return nil, err
+	}
+	resourceName, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.ResourceName = *resourceName
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Name = *name
+	if rec.Value, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+type RemoveTopicRecord struct {
+	TopicId   [16]byte
+	TagBuffer types.TaggedFields
+}
+
+func (r *RemoveTopicRecord) Type() int8    { return RemoveTopicRecordType }
+func (r *RemoveTopicRecord) Version() int8 { return 0 }
+
+func (r *RemoveTopicRecord) Write(w io.Writer) error {
+	if _, err := w.Write(r.TopicId[:]); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readRemoveTopicRecord(r *bytes.Reader) (*RemoveTopicRecord, error) {
+	var err error
+	rec := &RemoveTopicRecord{}
+	if rec.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+// UserScramCredentialRecord sets the SCRAM credential of a user for one
+// mechanism. Only the keys derived from the salted password are stored.
+type UserScramCredentialRecord struct {
+	Name       types.CompactString
+	Mechanism  int8
+	Salt       []byte
+	StoredKey  []byte
+	ServerKey  []byte
+	Iterations int32
+	TagBuffer  types.TaggedFields
+}
+
+func (r *UserScramCredentialRecord) Type() int8    { return UserScramCredentialRecordType }
+func (r *UserScramCredentialRecord) Version() int8 { return 0 }
+
+func (r *UserScramCredentialRecord) Write(w io.Writer) error {
+	if err := r.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.Mechanism); err != nil {
+		return err
+	}
+	for _, b := range [][]byte{r.Salt, r.StoredKey, r.ServerKey} {
+		if err := types.WriteCompactBytes(w, b); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, r.Iterations); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readUserScramCredentialRecord(r *bytes.Reader) (*UserScramCredentialRecord, error) {
+	rec := &UserScramCredentialRecord{}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Name = *name
+	if err = binary.Read(r, binary.BigEndian, &rec.Mechanism); err != nil {
+		return nil, err
+	}
+	for _, b := range []*[]byte{&rec.Salt, &rec.StoredKey, &rec.ServerKey} {
+		if *b, err = types.ReadCompactBytes(r); err != nil {
+			return nil, err
+		}
+	}
+	if err = binary.Read(r, binary.BigEndian, &rec.Iterations); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+type RemoveUserScramCredentialRecord struct {
+	Name      types.CompactString
+	Mechanism int8
+	TagBuffer types.TaggedFields
+}
+
+func (r *RemoveUserScramCredentialRecord) Type() int8    { return RemoveUserScramCredentialRecordType }
+func (r *RemoveUserScramCredentialRecord) Version() int8 { return 0 }
+
+func (r *RemoveUserScramCredentialRecord) Write(w io.Writer) error {
+	if err := r.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.Mechanism); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readRemoveUserScramCredentialRecord(r *bytes.Reader) (*RemoveUserScramCredentialRecord, error) {
+	rec := &RemoveUserScramCredentialRecord{}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Name = *name
+	if err = binary.Read(r, binary.BigEndian, &rec.Mechanism); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+// ClientQuotaRecord sets or, when Remove is set, removes the quota Key of
+// an entity.
+type ClientQuotaRecord struct {
+	Entity    []EntityData
+	Key       types.CompactString
+	Value     float64
+	Remove    bool
+	TagBuffer types.TaggedFields
+}
+
+// EntityData is one component of a quota entity; a nil EntityName stands
+// for the default entity of its type.
+type EntityData struct {
+	EntityType types.CompactString
+	EntityName *types.CompactString
+	TagBuffer  types.TaggedFields
+}
+
+func (r *ClientQuotaRecord) Type() int8    { return ClientQuotaRecordType }
+func (r *ClientQuotaRecord) Version() int8 { return 0 }
+
+func (r *ClientQuotaRecord) Write(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(r.Entity)); err != nil {
+		return err
+	}
+	for _, e := range r.Entity {
+		if err := e.EntityType.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, e.EntityName); err != nil {
+			return err
+		}
+		if err := e.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := r.Key.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.Value); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, r.Remove); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readClientQuotaRecord(r *bytes.Reader) (*ClientQuotaRecord, error) {
+	rec := &ClientQuotaRecord{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Entity = make([]EntityData, max(n, 0))
+	for i := range rec.Entity {
+		entityType, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, err
+		}
+		rec.Entity[i].EntityType = *entityType
+		if rec.Entity[i].EntityName, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, err
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, err
+		}
+		rec.Entity[i].TagBuffer = *tagBuffer
+	}
+	key, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Key = *key
+	if err = binary.Read(r, binary.BigEndian, &rec.Value); err != nil {
+		return nil, err
+	}
+	if rec.Remove, err = types.ReadBool(r); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+// AccessControlEntryRecord adds an ACL, identified by Id so it can be
+// removed later.
+type AccessControlEntryRecord struct {
+	Id             [16]byte
+	ResourceType   int8
+	ResourceName   types.CompactString
+	PatternType    int8
+	PrincipalName  types.CompactString
+	Host           types.CompactString
+	Operation      int8
+	PermissionType int8
+	TagBuffer      types.TaggedFields
+}
+
+func (r *AccessControlEntryRecord) Type() int8    { return AccessControlEntryRecordType }
+func (r *AccessControlEntryRecord) Version() int8 { return 0 }
+
+func (r *AccessControlEntryRecord) Write(w io.Writer) error {
+	if _, err := w.Write(r.Id[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ResourceType); err != nil {
+		return err
+	}
+	if err := r.ResourceName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.PatternType); err != nil {
+		return err
+	}
+	if err := r.PrincipalName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := r.Host.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.Operation); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.PermissionType); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readAccessControlEntryRecord(r *bytes.Reader) (*AccessControlEntryRecord, error) {
+	var err error
+	rec := &AccessControlEntryRecord{}
+	if rec.Id, err = types.ReadUuid(r); err != nil {
+		return nil, err
+	}
+	if err = binary.Read(r, binary.BigEndian, &rec.ResourceType); err != nil {
+		return nil, err
+	}
+	resourceName, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.ResourceName = *resourceName
+	if err = binary.Read(r, binary.BigEndian, &rec.PatternType); err != nil {
+		return nil, err
+	}
+	principal, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.PrincipalName = *principal
+	host, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Host = *host
+	if err = binary.Read(r, binary.BigEndian, &rec.Operation); err != nil {
+		return nil, err
+	}
+	if err = binary.Read(r, binary.BigEndian, &rec.PermissionType); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+type RemoveAccessControlEntryRecord struct {
+	Id        [16]byte
+	TagBuffer types.TaggedFields
+}
+
+func (r *RemoveAccessControlEntryRecord) Type() int8    { return RemoveAccessControlEntryRecordType }
+func (r *RemoveAccessControlEntryRecord) Version() int8 { return 0 }
+
+func (r *RemoveAccessControlEntryRecord) Write(w io.Writer) error {
+	if _, err := w.Write(r.Id[:]); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readRemoveAccessControlEntryRecord(r *bytes.Reader) (*RemoveAccessControlEntryRecord, error) {
+	var err error
+	rec := &RemoveAccessControlEntryRecord{}
+	if rec.Id, err = types.ReadUuid(r); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+type FeatureLevelRecord struct {
+	Name         types.CompactString
+	FeatureLevel int16
+	TagBuffer    types.TaggedFields
+}
+
+func (r *FeatureLevelRecord) Type() int8    { return FeatureLevelRecordType }
+func (r *FeatureLevelRecord) Version() int8 { return 0 }
+
+func (r *FeatureLevelRecord) Write(w io.Writer) error {
+	if err := r.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.FeatureLevel); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readFeatureLevelRecord(r *bytes.Reader) (*FeatureLevelRecord, error) {
+	rec := &FeatureLevelRecord{}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.Name = *name
+	if err = binary.Read(r, binary.BigEndian, &rec.FeatureLevel); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+// ProducerIdsRecord reserves the producer ids below NextProducerId.
+type ProducerIdsRecord struct {
+	BrokerId       int32
+	BrokerEpoch    int64
+	NextProducerId int64
+	TagBuffer      types.TaggedFields
+}
+
+func (r *ProducerIdsRecord) Type() int8    { return ProducerIdsRecordType }
+func (r *ProducerIdsRecord) Version() int8 { return 0 }
+
+func (r *ProducerIdsRecord) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.BrokerId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.BrokerEpoch); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.NextProducerId); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func readProducerIdsRecord(r *bytes.Reader) (*ProducerIdsRecord, error) {
+	rec := &ProducerIdsRecord{}
+	if err := binary.Read(r, binary.BigEndian, &rec.BrokerId); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &rec.BrokerEpoch); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &rec.NextProducerId); err != nil {
+		return nil, err
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	rec.TagBuffer = *tagBuffer
+	return rec, nil
+}
+
+// DecodeRecord decodes the value of a record in the metadata log.
+func DecodeRecord(value []byte) (Record, error) {
+	r := bytes.NewReader(value)
+	frameVersion, err := types.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metadata record frame version: %s", err)
+	}
+	if frameVersion != recordFrameVersion {
+		return nil, fmt.Errorf("unsupported metadata record frame version %d", frameVersion)
+	}
+	recordType, err := types.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metadata record type: %s", err)
+	}
+	version, err := types.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metadata record version: %s", err)
+	}
+
+	var rec Record
+	switch int8(recordType) {
+	case TopicRecordType:
+		rec, err = readTopicRecord(r)
+	case PartitionRecordType:
+		rec, err = readPartitionRecord(r, int8(version))
+	case ConfigRecordType:
+		rec, err = readConfigRecord(r)
+	case RemoveTopicRecordType:
+		rec, err = readRemoveTopicRecord(r)
+	case UserScramCredentialRecordType:
+		rec, err = readUserScramCredentialRecord(r)
+	case FeatureLevelRecordType:
+		rec, err = readFeatureLevelRecord(r)
+	case ProducerIdsRecordType:
+		rec, err = readProducerIdsRecord(r)
+	case RemoveUserScramCredentialRecordType:
+		rec, err = readRemoveUserScramCredentialRecord(r)
+	case ClientQuotaRecordType:
+		rec, err = readClientQuotaRecord(r)
+	case AccessControlEntryRecordType:
+		rec, err = readAccessControlEntryRecord(r)
+	case RemoveAccessControlEntryRecordType:
+		rec, err = readRemoveAccessControlEntryRecord(r)
+	default:
+		data := make([]byte, r.Len())
+		r.Read(data)
+		rec = &UnknownRecord{RecordType: int8(recordType), RecordVersion: int8(version), Data: data}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading metadata record type %d: %s", recordType, err)
+	}
+	return rec, nil
+}
+
+// EncodeRecord encodes a record as the value of a metadata log record.
+func EncodeRecord(rec Record) []byte {
+	var buf bytes.Buffer
+	types.WriteUvarint(&buf, recordFrameVersion)
+	types.WriteUvarint(&buf, uint64(rec.Type()))
+	types.WriteUvarint(&buf, uint64(rec.Version()))
+	rec.Write(&buf)
+	return buf.Bytes()
+}
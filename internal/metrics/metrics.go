@@ -0,0 +1,275 @@
+// Package metrics exposes broker metrics in the Prometheus text exposition
+// format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of request latency
+// histograms.
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector writes the samples of one metric family.
+type collector interface {
+	describe() (name, help, kind string)
+	collect(emit func(suffix string, labels []string, values []string, value float64))
+}
+
+// Registry holds metric families, which are written in the order they
+// were registered.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText writes every metric family in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		name, help, kind := c.describe()
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, kind)
+		c.collect(func(suffix string, labels, values []string, value float64) {
+			bw.WriteString(name + suffix)
+			if len(labels) > 0 {
+				bw.WriteByte('{')
+				for i, label := range labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", label, escapeLabel(values[i]))
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(value))
+			bw.WriteByte('\n')
+		})
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics of the registry.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteText(w)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// series keys the label values of a sample; label values can't contain
+// the separator once escaped, as it is a control character.
+func seriesKey(values []string) string {
+	return strings.Join(values, "\x00")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+// NewCounterVec registers a family of counters with the given labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, series: make(map[string]*counterSeries)}
+	r.register(c)
+	return c
+}
+
+// Add adds v, which must not be negative, to the counter of the label
+// values.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", c.name, len(c.labels), len(values)))
+	}
+	key := seriesKey(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) describe() (string, string, string) {
+	return c.name, c.help, "counter"
+}
+
+func (c *CounterVec) collect(emit func(string, []string, []string, float64)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		emit("", c.labels, s.values, s.value)
+	}
+}
+
+// HistogramVec is a family of histograms partitioned by label values.
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a family of histograms with the given bucket
+// upper bounds, in increasing order, and labels.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogramSeries)}
+	r.register(h)
+	return h
+}
+
+// Observe adds a value to the histogram of the label values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	if len(values) != len(h.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", h.name, len(h.labels), len(values)))
+	}
+	key := seriesKey(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) describe() (string, string, string) {
+	return h.name, h.help, "histogram"
+}
+
+func (h *HistogramVec) collect(emit func(string, []string, []string, float64)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	labels := append(append([]string(nil), h.labels...), "le")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		values := append(append([]string(nil), s.values...), "")
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			values[len(values)-1] = formatValue(bound)
+			emit("_bucket", labels, values, float64(cumulative))
+		}
+		values[len(values)-1] = "+Inf"
+		emit("_bucket", labels, values, float64(s.count))
+		emit("_sum", h.labels, s.values, s.sum)
+		emit("_count", h.labels, s.values, float64(s.count))
+	}
+}
+
+// GaugeFunc is a family of gauges whose samples are read when the metrics
+// are collected.
+type GaugeFunc struct {
+	name, help string
+	labels     []string
+	fn         func(set func(v float64, values ...string))
+}
+
+// NewGaugeFunc registers a family of gauges; fn calls set once for each
+// series, with as many label values as labels.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, fn func(set func(v float64, values ...string))) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, labels: labels, fn: fn}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) describe() (string, string, string) {
+	return g.name, g.help, "gauge"
+}
+
+func (g *GaugeFunc) collect(emit func(string, []string, []string, float64)) {
+	g.fn(func(v float64, values ...string) {
+		if len(values) != len(g.labels) {
+			panic(fmt.Sprintf("metric %s has %d labels, got %d values", g.name, len(g.labels), len(values)))
+		}
+		emit("", g.labels, values, v)
+	})
+}
@@ -0,0 +1,63 @@
+package producer
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+)
+
+// BlockSize is the number of producer ids reserved in the metadata log at once.
+const BlockSize = 1000
+
+// IdManager hands out producer ids from blocks reserved through
+// ProducerIdsRecords, so ids are never reused across restarts.
+type IdManager struct {
+	mu             sync.Mutex
+	brokerId       int32
+	log            *metadata.Log
+	nextProducerId int64
+	blockEnd       int64
+	nextBlockStart atomic.Int64
+}
+
+func NewIdManager(brokerId int32, log *metadata.Log) *IdManager {
+	m := &IdManager{
+		brokerId: brokerId,
+		log:      log,
+	}
+	log.Register(m.replay)
+	return m
+}
+
+func (m *IdManager) replay(rec metadata.Record) {
+	if r, ok := rec.(*metadata.ProducerIdsRecord); ok {
+		m.nextBlockStart.Store(r.NextProducerId)
+	}
+}
+
+// GenerateProducerId returns an id no other producer has been given.
+func (m *IdManager) GenerateProducerId() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.generateProducerId()
+}
+
+func (m *IdManager) generateProducerId() (int64, error) {
+	if m.nextProducerId >= m.blockEnd {
+		start := m.nextBlockStart.Load()
+		err := m.log.Append(&metadata.ProducerIdsRecord{
+			BrokerId:       m.brokerId,
+			NextProducerId: start + BlockSize,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("error reserving producer id block: %s", err)
+		}
+		m.nextProducerId = start
+		m.blockEnd = start + BlockSize
+	}
+	id := m.nextProducerId
+	m.nextProducerId++
+	return id, nil
+}
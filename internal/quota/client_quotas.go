@@ -0,0 +1,266 @@
+package quota
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type quotaError struct {
+	code    int16
+	message string
+}
+
+func (e *quotaError) Error() string {
+	return e.message
+}
+
+func newQuotaError(code int16, format string, args ...any) *quotaError {
+	return &quotaError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func toNullableString(s string) *types.CompactString {
+	cs := types.CompactString(s)
+	return &cs
+}
+
+func validKey(key string) bool {
+	for _, t := range quotaTypes {
+		if t.Key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (c component) toEntityName() *types.CompactString {
+	if c.isDefault {
+		return nil
+	}
+	return toNullableString(c.name)
+}
+
+// toResponse lists the components of e, user first.
+func (e entity) toResponse() []response.QuotaEntity {
+	var components []response.QuotaEntity
+	if e.user.present {
+		components = append(components, response.QuotaEntity{EntityType: EntityUser, EntityName: e.user.toEntityName()})
+	}
+	if e.clientId.present {
+		components = append(components, response.QuotaEntity{EntityType: EntityClientId, EntityName: e.clientId.toEntityName()})
+	}
+	return components
+}
+
+func (e entity) toRecord() []metadata.EntityData {
+	var data []metadata.EntityData
+	for _, c := range e.toResponse() {
+		data = append(data, metadata.EntityData{EntityType: c.EntityType, EntityName: c.EntityName})
+	}
+	return data
+}
+
+func (e entity) less(o entity) bool {
+	key := func(c component) string {
+		switch {
+		case !c.present:
+			return ""
+		case c.isDefault:
+			return "\x00"
+		}
+		return "\x01" + c.name
+	}
+	if key(e.user) != key(o.user) {
+		return key(e.user) < key(o.user)
+	}
+	return key(e.clientId) < key(o.clientId)
+}
+
+// newEntity validates the components of an entity altered by a request.
+func newEntity(components []request.QuotaEntity) (entity, error) {
+	var e entity
+	if len(components) == 0 {
+		return e, newQuotaError(constant.INVALID_REQUEST, "Invalid empty client quota entity")
+	}
+	for _, c := range components {
+		var target *component
+		switch string(c.EntityType) {
+		case EntityUser:
+			target = &e.user
+		case EntityClientId:
+			target = &e.clientId
+		default:
+			return e, newQuotaError(constant.INVALID_REQUEST, "Unhandled client quota entity type: %s", c.EntityType)
+		}
+		if target.present {
+			return e, newQuotaError(constant.INVALID_REQUEST, "Duplicate %s entity type", c.EntityType)
+		}
+		*target = component{present: true, isDefault: c.EntityName == nil}
+		if c.EntityName != nil {
+			target.name = string(*c.EntityName)
+		}
+	}
+	return e, nil
+}
+
+// filter selects the entities matching the components of a
+// DescribeClientQuotas request.
+type filter struct {
+	user, clientId *request.QuotaFilterComponent
+	strict         bool
+}
+
+func newFilter(req *request.DescribeClientQuotasV1) (*filter, error) {
+	f := &filter{strict: req.Strict}
+	for i := range req.Components {
+		c := &req.Components[i]
+		var target **request.QuotaFilterComponent
+		switch string(c.EntityType) {
+		case EntityUser:
+			target = &f.user
+		case EntityClientId:
+			target = &f.clientId
+		default:
+			return nil, newQuotaError(constant.INVALID_REQUEST, "Custom entity type '%s' not supported", c.EntityType)
+		}
+		if *target != nil {
+			return nil, newQuotaError(constant.INVALID_REQUEST, "Duplicate %s filter component entity type", c.EntityType)
+		}
+		switch c.MatchType {
+		case request.QuotaMatchExact:
+			if c.Match == nil {
+				return nil, newQuotaError(constant.INVALID_REQUEST, "Exact match of entity type %s requires a name", c.EntityType)
+			}
+		case request.QuotaMatchDefault, request.QuotaMatchAny:
+		default:
+			return nil, newQuotaError(constant.INVALID_REQUEST, "Unknown match type %d", c.MatchType)
+		}
+		*target = c
+	}
+	return f, nil
+}
+
+func matchesComponent(c component, fc *request.QuotaFilterComponent, strict bool) bool {
+	if fc == nil {
+		return !strict || !c.present
+	}
+	if !c.present {
+		return false
+	}
+	switch fc.MatchType {
+	case request.QuotaMatchExact:
+		return !c.isDefault && c.name == string(*fc.Match)
+	case request.QuotaMatchDefault:
+		return c.isDefault
+	}
+	return true
+}
+
+func (f *filter) matches(e entity) bool {
+	return matchesComponent(e.user, f.user, f.strict) && matchesComponent(e.clientId, f.clientId, f.strict)
+}
+
+func (m *Manager) DescribeClientQuotas(req *request.DescribeClientQuotasV1) *response.DescribeClientQuotasV1 {
+	f, err := newFilter(req)
+	if err != nil {
+		qe := err.(*quotaError)
+		return &response.DescribeClientQuotasV1{ErrorCode: qe.code, ErrorMessage: toNullableString(qe.message)}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var entities []entity
+	for e := range m.quotas {
+		if f.matches(e) {
+			entities = append(entities, e)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].less(entities[j]) })
+
+	res := &response.DescribeClientQuotasV1{Entries: []response.QuotaEntry{}}
+	for _, e := range entities {
+		entry := response.QuotaEntry{Entity: e.toResponse()}
+		keys := make([]string, 0, len(m.quotas[e]))
+		for key := range m.quotas[e] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			entry.Values = append(entry.Values, response.QuotaValue{Key: types.CompactString(key), Value: m.quotas[e][key]})
+		}
+		res.Entries = append(res.Entries, entry)
+	}
+	return res
+}
+
+// AlterClientQuotas applies the operations of each entity atomically: none
+// of them is applied when one is invalid.
+func (m *Manager) AlterClientQuotas(req *request.AlterClientQuotasV1) *response.AlterClientQuotasV1 {
+	res := &response.AlterClientQuotasV1{Entries: make([]response.AlterClientQuotasEntry, len(req.Entries))}
+	errs := make([]error, len(req.Entries))
+	var records []metadata.Record
+	var altered []int
+	for i, entry := range req.Entries {
+		res.Entries[i].Entity = make([]response.QuotaEntity, len(entry.Entity))
+		for j, c := range entry.Entity {
+			res.Entries[i].Entity[j] = response.QuotaEntity{EntityType: c.EntityType, EntityName: c.EntityName}
+		}
+		e, err := newEntity(entry.Entity)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		var entryRecords []metadata.Record
+		seen := make(map[string]bool)
+		for _, op := range entry.Ops {
+			key := string(op.Key)
+			switch {
+			case !validKey(key):
+				err = newQuotaError(constant.INVALID_REQUEST, "Unknown quota key %s", key)
+			case seen[key]:
+				err = newQuotaError(constant.INVALID_REQUEST, "Duplicate quota key %s", key)
+			case !op.Remove && (op.Value <= 0 || math.IsInf(op.Value, 0) || math.IsNaN(op.Value)):
+				err = newQuotaError(constant.INVALID_REQUEST, "Quota %s must be a positive number, not %v", key, op.Value)
+			}
+			if err != nil {
+				break
+			}
+			seen[key] = true
+			entryRecords = append(entryRecords, &metadata.ClientQuotaRecord{
+				Entity: e.toRecord(),
+				Key:    op.Key,
+				Value:  op.Value,
+				Remove: op.Remove,
+			})
+		}
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if !req.ValidateOnly {
+			records = append(records, entryRecords...)
+			altered = append(altered, i)
+		}
+	}
+	if len(records) > 0 {
+		if err := m.log.Append(records...); err != nil {
+			for _, i := range altered {
+				errs[i] = newQuotaError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+			}
+		}
+	}
+
+	for i, err := range errs {
+		if qe, ok := err.(*quotaError); ok {
+			res.Entries[i].ErrorCode = qe.code
+			res.Entries[i].ErrorMessage = toNullableString(qe.message)
+		}
+	}
+	return res
+}
@@ -0,0 +1,193 @@
+package quota
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+)
+
+// Type is the kind of resource a quota bounds.
+type Type int
+
+const (
+	Produce Type = iota
+	Fetch
+	Request
+)
+
+// Key returns the configuration key of the quota type.
+func (t Type) Key() string {
+	switch t {
+	case Produce:
+		return "producer_byte_rate"
+	case Fetch:
+		return "consumer_byte_rate"
+	case Request:
+		return "request_percentage"
+	}
+	return ""
+}
+
+var quotaTypes = []Type{Produce, Fetch, Request}
+
+const (
+	EntityUser     = "user"
+	EntityClientId = "client-id"
+
+	// sensorExpiration is how long the usage of a client is remembered
+	// after its last request
+	sensorExpiration = time.Hour
+)
+
+// component is one part of an entity: absent, the default entity of its
+// type, or a named entity.
+type component struct {
+	present   bool
+	isDefault bool
+	name      string
+}
+
+// entity is a user, a client id, or a client id of a user.
+type entity struct {
+	user     component
+	clientId component
+}
+
+type sensorKey struct {
+	quotaType Type
+	user      string
+	clientId  string
+}
+
+// Manager keeps the quotas of users and client ids, persisted as
+// ClientQuotaRecords in the metadata log, and measures how much of them
+// clients use.
+type Manager struct {
+	mu         sync.Mutex
+	log        *metadata.Log
+	window     time.Duration
+	numSamples int
+	quotas     map[entity]map[string]float64
+	sensors    map[sensorKey]*rate
+	lastPurge  time.Time
+}
+
+// NewManager measures usage over numSamples windows of the given size.
+func NewManager(log *metadata.Log, numSamples int, window time.Duration) *Manager {
+	m := &Manager{
+		log:        log,
+		window:     window,
+		numSamples: numSamples,
+		quotas:     make(map[entity]map[string]float64),
+		sensors:    make(map[sensorKey]*rate),
+	}
+	log.Register(m.replay)
+	return m
+}
+
+func (m *Manager) replay(rec metadata.Record) {
+	r, ok := rec.(*metadata.ClientQuotaRecord)
+	if !ok {
+		return
+	}
+	var e entity
+	for _, d := range r.Entity {
+		c := component{present: true, isDefault: d.EntityName == nil}
+		if d.EntityName != nil {
+			c.name = string(*d.EntityName)
+		}
+		switch string(d.EntityType) {
+		case EntityUser:
+			e.user = c
+		case EntityClientId:
+			e.clientId = c
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r.Remove {
+		delete(m.quotas[e], string(r.Key))
+		if len(m.quotas[e]) == 0 {
+			delete(m.quotas, e)
+		}
+		return
+	}
+	if m.quotas[e] == nil {
+		m.quotas[e] = make(map[string]float64)
+	}
+	m.quotas[e][string(r.Key)] = r.Value
+}
+
+// resolve returns the most specific entity with a quota of type t for a
+// client, from a user's client id down to the default client id.
+func (m *Manager) resolve(t Type, user, clientId string) (entity, float64, bool) {
+	u := component{present: true, name: user}
+	c := component{present: true, name: clientId}
+	defaultComponent := component{present: true, isDefault: true}
+	candidates := []entity{
+		{user: u, clientId: c},
+		{user: u, clientId: defaultComponent},
+		{user: u},
+		{user: defaultComponent, clientId: c},
+		{user: defaultComponent, clientId: defaultComponent},
+		{user: defaultComponent},
+		{clientId: c},
+		{clientId: defaultComponent},
+	}
+	for _, e := range candidates {
+		if bound, ok := m.quotas[e][t.Key()]; ok {
+			return e, bound, true
+		}
+	}
+	return entity{}, 0, false
+}
+
+// Record adds value to the usage of a client for a quota type and returns
+// how long the client must be throttled for exceeding its quota. Usage is
+// shared by the clients that have the same user or client id as the quota's
+// entity has, so a default user quota bounds each user separately.
+func (m *Manager) Record(t Type, user, clientId string, value float64, now time.Time) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purgeSensors(now)
+	e, bound, ok := m.resolve(t, user, clientId)
+	if !ok {
+		return 0
+	}
+	key := sensorKey{quotaType: t}
+	if e.user.present {
+		key.user = user
+	}
+	if e.clientId.present {
+		key.clientId = clientId
+	}
+	s := m.sensors[key]
+	if s == nil {
+		s = &rate{}
+		m.sensors[key] = s
+	}
+	s.record(value, now, m.window, m.numSamples)
+	observed, elapsed := s.measure(now, m.window, m.numSamples)
+	if observed <= bound {
+		return 0
+	}
+	throttle := time.Duration((observed - bound) / bound * float64(elapsed))
+	return min(throttle, time.Duration(m.numSamples)*m.window)
+}
+
+// purgeSensors forgets clients that have been idle for a while, at most
+// once per window.
+func (m *Manager) purgeSensors(now time.Time) {
+	if now.Sub(m.lastPurge) < m.window {
+		return
+	}
+	m.lastPurge = now
+	for key, s := range m.sensors {
+		if now.Sub(s.lastRecord) > sensorExpiration {
+			delete(m.sensors, key)
+		}
+	}
+}
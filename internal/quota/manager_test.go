@@ -0,0 +1,182 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+const (
+	testWindow     = time.Second
+	testNumSamples = 3
+)
+
+func TestRate(t *testing.T) {
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	type record struct {
+		at    time.Duration
+		value float64
+	}
+	tests := []struct {
+		name        string
+		records     []record
+		measureAt   time.Duration
+		wantRate    float64
+		wantElapsed time.Duration
+		wantSamples int
+	}{
+		{name: "nothing recorded", measureAt: 0, wantRate: 0, wantElapsed: 2 * time.Second},
+		{name: "burst measured over all but one sample", records: []record{{0, 100}},
+			measureAt: 0, wantRate: 50, wantElapsed: 2 * time.Second, wantSamples: 1},
+		{name: "same sample", records: []record{{0, 100}, {500 * time.Millisecond, 100}},
+			measureAt: 500 * time.Millisecond, wantRate: 100, wantElapsed: 2 * time.Second, wantSamples: 1},
+		{name: "one sample per window", records: []record{{0, 100}, {time.Second, 100}, {2 * time.Second, 100}},
+			measureAt: 2500 * time.Millisecond, wantRate: 120, wantElapsed: 2500 * time.Millisecond, wantSamples: 3},
+		{name: "expired sample", records: []record{{0, 100}, {time.Second, 100}, {2 * time.Second, 100}},
+			measureAt: 3 * time.Second, wantRate: 100, wantElapsed: 2 * time.Second, wantSamples: 2},
+		{name: "everything expired", records: []record{{0, 100}},
+			measureAt: 10 * time.Second, wantRate: 0, wantElapsed: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &rate{}
+			for _, rec := range tt.records {
+				r.record(rec.value, at(rec.at), testWindow, testNumSamples)
+			}
+			got, elapsed := r.measure(at(tt.measureAt), testWindow, testNumSamples)
+			if got != tt.wantRate || elapsed != tt.wantElapsed {
+				t.Errorf("measured %v/s over %v, want %v/s over %v", got, elapsed, tt.wantRate, tt.wantElapsed)
+			}
+			if len(r.samples) != tt.wantSamples {
+				t.Errorf("%d samples, want %d", len(r.samples), tt.wantSamples)
+			}
+		})
+	}
+}
+
+// quotaEntity is an entity as a ClientQuotaRecord names it: nil for the
+// default entity, "" for an absent component.
+type quotaEntity struct {
+	user, clientId *string
+}
+
+func named(s string) *string { return &s }
+
+var absent = named("")
+
+func newTestManager(t *testing.T, quotas map[quotaEntity]float64, quotaType Type) *Manager {
+	t.Helper()
+	logs, err := storage.NewLogManager(t.TempDir(), storage.DefaultLogConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logs.Close() })
+	log, err := metadata.OpenLog(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(log, testNumSamples, testWindow)
+	for e, bound := range quotas {
+		rec := &metadata.ClientQuotaRecord{Key: types.CompactString(quotaType.Key()), Value: bound}
+		for _, c := range []struct {
+			entityType string
+			name       *string
+		}{{EntityUser, e.user}, {EntityClientId, e.clientId}} {
+			if c.name == absent {
+				continue
+			}
+			d := metadata.EntityData{EntityType: types.CompactString(c.entityType)}
+			if c.name != nil {
+				d.EntityName = (*types.CompactString)(c.name)
+			}
+			rec.Entity = append(rec.Entity, d)
+		}
+		m.replay(rec)
+	}
+	return m
+}
+
+func TestResolve(t *testing.T) {
+	alice, app := named("alice"), named("app")
+	all := map[quotaEntity]float64{
+		{alice, app}:        1,
+		{alice, nil}:        2,
+		{alice, absent}:     3,
+		{nil, app}:          4,
+		{nil, nil}:          5,
+		{nil, absent}:       6,
+		{absent, app}:       7,
+		{absent, nil}:       8,
+		{named("bob"), app}: 9,
+	}
+	// each case removes the entities that would resolve before the next one
+	order := []quotaEntity{{alice, app}, {alice, nil}, {alice, absent}, {nil, app}, {nil, nil}, {nil, absent}, {absent, app}, {absent, nil}}
+	for i, e := range order {
+		quotas := make(map[quotaEntity]float64)
+		for k, v := range all {
+			quotas[k] = v
+		}
+		for _, removed := range order[:i] {
+			delete(quotas, removed)
+		}
+		m := newTestManager(t, quotas, Produce)
+		if _, bound, ok := m.resolve(Produce, "alice", "app"); !ok || bound != all[e] {
+			t.Errorf("with the %d most specific quotas removed: bound %v (%t), want %v", i, bound, ok, all[e])
+		}
+	}
+
+	m := newTestManager(t, map[quotaEntity]float64{{alice, absent}: 1}, Produce)
+	if _, _, ok := m.resolve(Produce, "bob", "app"); ok {
+		t.Error("quota of alice applies to bob")
+	}
+	if _, _, ok := m.resolve(Fetch, "alice", "app"); ok {
+		t.Error("produce quota applies to fetches")
+	}
+}
+
+func TestRecordThrottle(t *testing.T) {
+	type usage struct {
+		user, clientId string
+		value          float64
+		wantThrottle   time.Duration
+	}
+	tests := []struct {
+		name   string
+		quotas map[quotaEntity]float64
+		usage  []usage
+	}{
+		{name: "within quota", quotas: map[quotaEntity]float64{{named("alice"), absent}: 100},
+			usage: []usage{{"alice", "app", 200, 0}}},
+		{name: "over quota", quotas: map[quotaEntity]float64{{named("alice"), absent}: 100},
+			// 400 bytes over 2s is 200/s, twice the quota: it takes
+			// another 2s for the rate to get back to 100/s
+			usage: []usage{{"alice", "app", 400, 2 * time.Second}}},
+		{name: "accumulated", quotas: map[quotaEntity]float64{{named("alice"), absent}: 100},
+			usage: []usage{{"alice", "app", 200, 0}, {"alice", "app", 100, time.Second}}},
+		{name: "throttle bounded by the window", quotas: map[quotaEntity]float64{{named("alice"), absent}: 100},
+			usage: []usage{{"alice", "app", 100000, testNumSamples * testWindow}}},
+		{name: "no quota", quotas: map[quotaEntity]float64{{named("alice"), absent}: 100},
+			usage: []usage{{"bob", "app", 100000, 0}}},
+		{name: "default user quota per user", quotas: map[quotaEntity]float64{{nil, absent}: 100},
+			usage: []usage{{"alice", "app", 300, time.Second}, {"bob", "app", 300, time.Second}}},
+		{name: "client id quota shared by users", quotas: map[quotaEntity]float64{{absent, named("app")}: 100},
+			usage: []usage{{"alice", "app", 200, 0}, {"bob", "app", 200, 2 * time.Second}}},
+		{name: "user quota shared by client ids", quotas: map[quotaEntity]float64{{named("alice"), absent}: 100},
+			usage: []usage{{"alice", "app", 200, 0}, {"alice", "other", 200, 2 * time.Second}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, tt.quotas, Produce)
+			now := time.Now()
+			for _, u := range tt.usage {
+				if got := m.Record(Produce, u.user, u.clientId, u.value, now); got != u.wantThrottle {
+					t.Errorf("recording %v for %s/%s: throttle %v, want %v", u.value, u.user, u.clientId, got, u.wantThrottle)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package quota
+
+import "time"
+
+type sample struct {
+	start time.Time
+	value float64
+}
+
+// rate measures how much of a value is recorded per second over a sliding
+// window made of a fixed number of samples.
+type rate struct {
+	samples    []sample
+	lastRecord time.Time
+}
+
+func (r *rate) record(value float64, now time.Time, window time.Duration, numSamples int) {
+	r.purge(now, window, numSamples)
+	if n := len(r.samples); n == 0 || now.Sub(r.samples[n-1].start) >= window {
+		r.samples = append(r.samples, sample{start: now})
+	}
+	r.samples[len(r.samples)-1].value += value
+	r.lastRecord = now
+}
+
+// measure returns the rate per second and the time it was measured over,
+// which is at least all but one of the samples so that a burst right after
+// a quiet period is not overestimated.
+func (r *rate) measure(now time.Time, window time.Duration, numSamples int) (float64, time.Duration) {
+	r.purge(now, window, numSamples)
+	var total float64
+	for _, s := range r.samples {
+		total += s.value
+	}
+	elapsed := time.Duration(numSamples-1) * window
+	if len(r.samples) > 0 {
+		elapsed = max(elapsed, now.Sub(r.samples[0].start))
+	}
+	if elapsed <= 0 {
+		elapsed = window
+	}
+	return total / elapsed.Seconds(), elapsed
+}
+
+// purge drops the samples that fell out of the window.
+func (r *rate) purge(now time.Time, window time.Duration, numSamples int) {
+	expired := now.Add(-time.Duration(numSamples) * window)
+	i := 0
+	for i < len(r.samples) && !r.samples[i].start.After(expired) {
+		i++
+	}
+	r.samples = r.samples[i:]
+}
@@ -0,0 +1,229 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// BatchHeaderSize is the size of a v2 batch header, up to and including the record count.
+	BatchHeaderSize = 61
+	// LogOverhead is the size of the base offset and batch length fields.
+	LogOverhead = 12
+
+	// crcOffset is where the crc starts; it covers everything after it.
+	crcOffset        = 17
+	attributesOffset = 21
+
+	CompressionCodecMask  int16 = 0x07
+	TimestampTypeMask     int16 = 0x08
+	TransactionalFlagMask int16 = 0x10
+	ControlFlagMask       int16 = 0x20
+
+	NoProducerId           int64 = -1
+	NoProducerEpoch        int16 = -1
+	NoSequence             int32 = -1
+	NoPartitionLeaderEpoch int32 = -1
+)
+
+var (
+	ErrCorruptBatch     = errors.New("corrupt record batch")
+	ErrUnsupportedMagic = errors.New("unsupported record batch magic")
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// RecordBatch is a v2 record batch. Records are decoded only for uncompressed
+// batches; RecordsData always holds the encoded records section.
+type RecordBatch struct {
+	BaseOffset           int64
+	BatchLength          int32
+	PartitionLeaderEpoch int32
+	Magic                int8
+	CRC                  uint32
+	Attributes           int16
+	LastOffsetDelta      int32
+	BaseTimestamp        int64
+	MaxTimestamp         int64
+	ProducerId           int64
+	ProducerEpoch        int16
+	BaseSequence         int32
+	RecordCount          int32
+	Records              []Record
+	RecordsData          []byte
+}
+
+// ReadRecordBatch decodes the batch at the reader's position and verifies its crc.
+func ReadRecordBatch(r *bytes.Reader) (*RecordBatch, error) {
+	if r.Len() < LogOverhead {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var header [LogOverhead]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	batchLength := int32(binary.BigEndian.Uint32(header[8:]))
+	if batchLength < BatchHeaderSize-LogOverhead {
+		return nil, fmt.Errorf("%w: batch length %d is smaller than the header", ErrCorruptBatch, batchLength)
+	}
+	if int(batchLength) > r.Len() {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	buf := make([]byte, LogOverhead+int(batchLength))
+	copy(buf, header[:])
+	if _, err := io.ReadFull(r, buf[LogOverhead:]); err != nil {
+		return nil, err
+	}
+	return DecodeRecordBatch(buf)
+}
+
+// DecodeRecordBatch decodes a single batch held entirely in buf.
+func DecodeRecordBatch(buf []byte) (*RecordBatch, error) {
+	b, err := DecodeBatchHeader(buf)
+	if err != nil {
+		return nil, err
+	}
+	if b.Magic != 2 {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedMagic, b.Magic)
+	}
+	if int(b.BatchLength)+LogOverhead != len(buf) {
+		return nil, fmt.Errorf("%w: batch length %d does not match %d bytes", ErrCorruptBatch, b.BatchLength, len(buf)-LogOverhead)
+	}
+	if crc := crc32.Checksum(buf[attributesOffset:], crc32c); crc != b.CRC {
+		return nil, fmt.Errorf("%w: crc mismatch, expected %d got %d", ErrCorruptBatch, b.CRC, crc)
+	}
+	b.RecordsData = buf[BatchHeaderSize:]
+
+	if b.Compression() == 0 {
+		rr := bytes.NewReader(b.RecordsData)
+		if b.RecordCount < 0 || int(b.RecordCount) > rr.Len() {
+			return nil, fmt.Errorf("%w: invalid record count %d", ErrCorruptBatch, b.RecordCount)
+		}
+		b.Records = make([]Record, b.RecordCount)
+		for i := range b.Records {
+			rec, err := ReadRecord(rr)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrCorruptBatch, err)
+			}
+			b.Records[i] = *rec
+		}
+	}
+	return b, nil
+}
+
+// DecodeBatchHeader decodes the header of the batch at the start of buf
+// without checking it, which lets tools show the headers of corrupt
+// batches.
+func DecodeBatchHeader(buf []byte) (*RecordBatch, error) {
+	if len(buf) < BatchHeaderSize {
+		return nil, fmt.Errorf("%w: %d bytes is smaller than the header", ErrCorruptBatch, len(buf))
+	}
+	return &RecordBatch{
+		BaseOffset:           int64(binary.BigEndian.Uint64(buf[0:])),
+		BatchLength:          int32(binary.BigEndian.Uint32(buf[8:])),
+		PartitionLeaderEpoch: int32(binary.BigEndian.Uint32(buf[12:])),
+		Magic:                int8(buf[16]),
+		CRC:                  binary.BigEndian.Uint32(buf[17:]),
+		Attributes:           int16(binary.BigEndian.Uint16(buf[21:])),
+		LastOffsetDelta:      int32(binary.BigEndian.Uint32(buf[23:])),
+		BaseTimestamp:        int64(binary.BigEndian.Uint64(buf[27:])),
+		MaxTimestamp:         int64(binary.BigEndian.Uint64(buf[35:])),
+		ProducerId:           int64(binary.BigEndian.Uint64(buf[43:])),
+		ProducerEpoch:        int16(binary.BigEndian.Uint16(buf[51:])),
+		BaseSequence:         int32(binary.BigEndian.Uint32(buf[53:])),
+		RecordCount:          int32(binary.BigEndian.Uint32(buf[57:])),
+	}, nil
+}
+
+func (b *RecordBatch) Compression() int16 {
+	return b.Attributes & CompressionCodecMask
+}
+
+func (b *RecordBatch) IsTransactional() bool {
+	return b.Attributes&TransactionalFlagMask != 0
+}
+
+func (b *RecordBatch) IsControl() bool {
+	return b.Attributes&ControlFlagMask != 0
+}
+
+func (b *RecordBatch) LastOffset() int64 {
+	return b.BaseOffset + int64(b.LastOffsetDelta)
+}
+
+// LastSequence returns the sequence of the last record, wrapping around at
+// the int32 maximum as producers do.
+func (b *RecordBatch) LastSequence() int32 {
+	if b.BaseSequence == NoSequence {
+		return NoSequence
+	}
+	return int32((int64(b.BaseSequence) + int64(b.LastOffsetDelta)) % (1 << 31))
+}
+
+// Size returns the number of bytes the batch occupies in the log.
+func (b *RecordBatch) Size() int {
+	return BatchHeaderSize + len(b.RecordsData)
+}
+
+// Bytes encodes the batch, recomputing its length and crc. Records take
+// precedence over RecordsData when set.
+func (b *RecordBatch) Bytes() []byte {
+	if b.Records != nil {
+		var records bytes.Buffer
+		for _, rec := range b.Records {
+			rec.Write(&records)
+		}
+		b.RecordsData = records.Bytes()
+		b.RecordCount = int32(len(b.Records))
+	}
+	if b.Magic == 0 {
+		b.Magic = 2
+	}
+
+	buf := make([]byte, BatchHeaderSize+len(b.RecordsData))
+	b.BatchLength = int32(len(buf) - LogOverhead)
+	binary.BigEndian.PutUint64(buf[0:], uint64(b.BaseOffset))
+	binary.BigEndian.PutUint32(buf[8:], uint32(b.BatchLength))
+	binary.BigEndian.PutUint32(buf[12:], uint32(b.PartitionLeaderEpoch))
+	buf[16] = byte(b.Magic)
+	binary.BigEndian.PutUint16(buf[21:], uint16(b.Attributes))
+	binary.BigEndian.PutUint32(buf[23:], uint32(b.LastOffsetDelta))
+	binary.BigEndian.PutUint64(buf[27:], uint64(b.BaseTimestamp))
+	binary.BigEndian.PutUint64(buf[35:], uint64(b.MaxTimestamp))
+	binary.BigEndian.PutUint64(buf[43:], uint64(b.ProducerId))
+	binary.BigEndian.PutUint16(buf[51:], uint16(b.ProducerEpoch))
+	binary.BigEndian.PutUint32(buf[53:], uint32(b.BaseSequence))
+	binary.BigEndian.PutUint32(buf[57:], uint32(b.RecordCount))
+	copy(buf[BatchHeaderSize:], b.RecordsData)
+	b.CRC = crc32.Checksum(buf[attributesOffset:], crc32c)
+	binary.BigEndian.PutUint32(buf[crcOffset:], b.CRC)
+	return buf
+}
+
+func (b *RecordBatch) Write(w io.Writer) error {
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadRecordBatches decodes every complete batch in data. A trailing partial
+// batch, as returned by Fetch when it hits its byte limit, is ignored.
+func ReadRecordBatches(data []byte) ([]*RecordBatch, error) {
+	r := bytes.NewReader(data)
+	var batches []*RecordBatch
+	for r.Len() > 0 {
+		b, err := ReadRecordBatch(r)
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return batches, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, nil
+}
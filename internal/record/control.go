@@ -0,0 +1,52 @@
+package record
+
+import "encoding/binary"
+
+// Control record types, stored in the key of a control batch's only record.
+const (
+	ControlTypeAbort  int16 = 0
+	ControlTypeCommit int16 = 1
+)
+
+// NewEndTxnMarker builds the control batch that commits or aborts the
+// ongoing transaction of a producer in a partition.
+func NewEndTxnMarker(producerId int64, producerEpoch int16, coordinatorEpoch int32, commit bool, timestamp int64) *RecordBatch {
+	controlType := ControlTypeAbort
+	if commit {
+		controlType = ControlTypeCommit
+	}
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint16(key[0:], 0) // version
+	binary.BigEndian.PutUint16(key[2:], uint16(controlType))
+	value := make([]byte, 6)
+	binary.BigEndian.PutUint16(value[0:], 0) // version
+	binary.BigEndian.PutUint32(value[2:], uint32(coordinatorEpoch))
+
+	return &RecordBatch{
+		Attributes:    TransactionalFlagMask | ControlFlagMask,
+		BaseTimestamp: timestamp,
+		MaxTimestamp:  timestamp,
+		ProducerId:    producerId,
+		ProducerEpoch: producerEpoch,
+		BaseSequence:  NoSequence,
+		Records:       []Record{{Key: key, Value: value}},
+	}
+}
+
+// ControlType returns the type of a control batch, or false when the batch
+// is not a well-formed control batch.
+func (b *RecordBatch) ControlType() (int16, bool) {
+	if !b.IsControl() || len(b.Records) == 0 || len(b.Records[0].Key) < 4 {
+		return 0, false
+	}
+	return int16(binary.BigEndian.Uint16(b.Records[0].Key[2:])), true
+}
+
+// CoordinatorEpoch returns the epoch of the transaction coordinator that
+// wrote a control batch.
+func (b *RecordBatch) CoordinatorEpoch() (int32, bool) {
+	if !b.IsControl() || len(b.Records) == 0 || len(b.Records[0].Value) < 6 {
+		return 0, false
+	}
+	return int32(binary.BigEndian.Uint32(b.Records[0].Value[2:])), true
+}
@@ -0,0 +1,142 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Record is a single entry of a v2 record batch. Key and Value are nil when null.
+type Record struct {
+	Attributes     int8
+	TimestampDelta int64
+	OffsetDelta    int32
+	Key            []byte
+	Value          []byte
+	Headers        []Header
+}
+
+func readVarint(r *bytes.Reader) (int64, error) {
+	return binary.ReadVarint(r)
+}
+
+func writeVarint(w io.Writer, val int64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], val)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// readVarintBytes reads a varint length followed by that many bytes; a
+// length of -1 yields nil.
+func readVarintBytes(r *bytes.Reader) ([]byte, error) {
+	length, err := readVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, nil
+	}
+	if length > int64(r.Len()) {
+		return nil, fmt.Errorf("length %d exceeds remaining %d bytes", length, r.Len())
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func writeVarintBytes(w io.Writer, data []byte) error {
+	if data == nil {
+		return writeVarint(w, -1)
+	}
+	if err := writeVarint(w, int64(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func ReadRecord(r *bytes.Reader) (*Record, error) {
+	length, err := readVarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading record length: %s", err)
+	}
+	if length < 0 || length > int64(r.Len()) {
+		return nil, fmt.Errorf("error reading record: length %d exceeds remaining %d bytes", length, r.Len())
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("error reading record: %s", err)
+	}
+
+	br := bytes.NewReader(body)
+	rec := &Record{}
+	attributes, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("error reading record attributes: %s", err)
+	}
+	rec.Attributes = int8(attributes)
+	if rec.TimestampDelta, err = readVarint(br); err != nil {
+		return nil, fmt.Errorf("error reading record timestamp delta: %s", err)
+	}
+	offsetDelta, err := readVarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("error reading record offset delta: %s", err)
+	}
+	rec.OffsetDelta = int32(offsetDelta)
+	if rec.Key, err = readVarintBytes(br); err != nil {
+		return nil, fmt.Errorf("error reading record key: %s", err)
+	}
+	if rec.Value, err = readVarintBytes(br); err != nil {
+		return nil, fmt.Errorf("error reading record value: %s", err)
+	}
+
+	numHeaders, err := readVarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("error reading record header count: %s", err)
+	}
+	if numHeaders < 0 || numHeaders > int64(br.Len()) {
+		return nil, fmt.Errorf("error reading record headers: invalid count %d", numHeaders)
+	}
+	rec.Headers = make([]Header, numHeaders)
+	for i := range rec.Headers {
+		key, err := readVarintBytes(br)
+		if err != nil {
+			return nil, fmt.Errorf("error reading record header %d key: %s", i, err)
+		}
+		value, err := readVarintBytes(br)
+		if err != nil {
+			return nil, fmt.Errorf("error reading record header %d value: %s", i, err)
+		}
+		rec.Headers[i] = Header{Key: string(key), Value: value}
+	}
+	return rec, nil
+}
+
+func (rec *Record) Write(w io.Writer) error {
+	var body bytes.Buffer
+	body.WriteByte(byte(rec.Attributes))
+	writeVarint(&body, rec.TimestampDelta)
+	writeVarint(&body, int64(rec.OffsetDelta))
+	writeVarintBytes(&body, rec.Key)
+	writeVarintBytes(&body, rec.Value)
+	writeVarint(&body, int64(len(rec.Headers)))
+	for _, h := range rec.Headers {
+		writeVarintBytes(&body, []byte(h.Key))
+		writeVarintBytes(&body, h.Value)
+	}
+
+	if err := writeVarint(w, int64(body.Len())); err != nil {
+		return err
+	}
+	_, err := w.Write(body.Bytes())
+	return err
+}
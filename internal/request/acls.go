@@ -0,0 +1,245 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// CreateAclsV2 covers versions 2 and 3.
+type CreateAclsV2 struct {
+	Creations []AclCreation
+	TagBuffer types.TaggedFields
+}
+
+type AclCreation struct {
+	ResourceType        int8
+	ResourceName        types.CompactString
+	ResourcePatternType int8
+	Principal           types.CompactString
+	Host                types.CompactString
+	Operation           int8
+	PermissionType      int8
+	TagBuffer           types.TaggedFields
+}
+
+func ReadCreateAcls(r *bytes.Reader) (*CreateAclsV2, error) {
+	c := &CreateAclsV2{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "creations")
+	}
+	c.Creations = make([]AclCreation, max(n, 0))
+	for i := range c.Creations {
+		ac := &c.Creations[i]
+		if err = binary.Read(r, binary.BigEndian, &ac.ResourceType); err != nil {
+			return nil, types.Fieldf(err, "creations[%d].resourceType", i)
+		}
+		resourceName, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "creations[%d].resourceName", i)
+		}
+		ac.ResourceName = *resourceName
+		if err = binary.Read(r, binary.BigEndian, &ac.ResourcePatternType); err != nil {
+			return nil, types.Fieldf(err, "creations[%d].resourcePatternType", i)
+		}
+		principal, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "creations[%d].principal", i)
+		}
+		ac.Principal = *principal
+		host, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "creations[%d].host", i)
+		}
+		ac.Host = *host
+		if err = binary.Read(r, binary.BigEndian, &ac.Operation); err != nil {
+			return nil, types.Fieldf(err, "creations[%d].operation", i)
+		}
+		if err = binary.Read(r, binary.BigEndian, &ac.PermissionType); err != nil {
+			return nil, types.Fieldf(err, "creations[%d].permissionType", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "creations[%d].taggedFields", i)
+		}
+		ac.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	c.TagBuffer = *tagBuffer
+	return c, nil
+}
+
+func (c *CreateAclsV2) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(c.Creations)); err != nil {
+		return err
+	}
+	for _, ac := range c.Creations {
+		if err := binary.Write(w, binary.BigEndian, ac.ResourceType); err != nil {
+			return err
+		}
+		if err := ac.ResourceName.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, ac.ResourcePatternType); err != nil {
+			return err
+		}
+		if err := ac.Principal.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := ac.Host.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, ac.Operation); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, ac.PermissionType); err != nil {
+			return err
+		}
+		if err := ac.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return c.TagBuffer.WriteTaggedFields(w)
+}
+
+// AclFilter selects ACLs; null strings match any value.
+type AclFilter struct {
+	ResourceTypeFilter int8
+	ResourceNameFilter *types.CompactString
+	PatternTypeFilter  int8
+	PrincipalFilter    *types.CompactString
+	HostFilter         *types.CompactString
+	Operation          int8
+	PermissionType     int8
+}
+
+func readAclFilter(r *bytes.Reader) (AclFilter, error) {
+	var f AclFilter
+	var err error
+	if err = binary.Read(r, binary.BigEndian, &f.ResourceTypeFilter); err != nil {
+		return f, types.Fieldf(err, "resourceTypeFilter")
+	}
+	if f.ResourceNameFilter, err = types.ReadCompactNullableString(r); err != nil {
+		return f, types.Fieldf(err, "resourceNameFilter")
+	}
+	if err = binary.Read(r, binary.BigEndian, &f.PatternTypeFilter); err != nil {
+		return f, types.Fieldf(err, "patternTypeFilter")
+	}
+	if f.PrincipalFilter, err = types.ReadCompactNullableString(r); err != nil {
+		return f, types.Fieldf(err, "principalFilter")
+	}
+	if f.HostFilter, err = types.ReadCompactNullableString(r); err != nil {
+		return f, types.Fieldf(err, "hostFilter")
+	}
+	if err = binary.Read(r, binary.BigEndian, &f.Operation); err != nil {
+		return f, types.Fieldf(err, "operation")
+	}
+	err = binary.Read(r, binary.BigEndian, &f.PermissionType)
+	return f, types.Fieldf(err, "permissionType")
+}
+
+func (f *AclFilter) write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, f.ResourceTypeFilter); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, f.ResourceNameFilter); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, f.PatternTypeFilter); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, f.PrincipalFilter); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, f.HostFilter); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, f.Operation); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, f.PermissionType)
+}
+
+// DescribeAclsV2 covers versions 2 and 3.
+type DescribeAclsV2 struct {
+	AclFilter
+	TagBuffer types.TaggedFields
+}
+
+func ReadDescribeAcls(r *bytes.Reader) (*DescribeAclsV2, error) {
+	d := &DescribeAclsV2{}
+	var err error
+	if d.AclFilter, err = readAclFilter(r); err != nil {
+		return nil, types.Fieldf(err, "aclFilter")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (d *DescribeAclsV2) WriteRequestBody(w io.Writer) error {
+	if err := d.AclFilter.write(w); err != nil {
+		return err
+	}
+	return d.TagBuffer.WriteTaggedFields(w)
+}
+
+// DeleteAclsV2 covers versions 2 and 3.
+type DeleteAclsV2 struct {
+	Filters   []DeleteAclsFilter
+	TagBuffer types.TaggedFields
+}
+
+type DeleteAclsFilter struct {
+	AclFilter
+	TagBuffer types.TaggedFields
+}
+
+func ReadDeleteAcls(r *bytes.Reader) (*DeleteAclsV2, error) {
+	d := &DeleteAclsV2{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "filters")
+	}
+	d.Filters = make([]DeleteAclsFilter, max(n, 0))
+	for i := range d.Filters {
+		if d.Filters[i].AclFilter, err = readAclFilter(r); err != nil {
+			return nil, types.Fieldf(err, "filters[%d].aclFilter", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "filters[%d].taggedFields", i)
+		}
+		d.Filters[i].TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (d *DeleteAclsV2) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(d.Filters)); err != nil {
+		return err
+	}
+	for _, f := range d.Filters {
+		if err := f.AclFilter.write(w); err != nil {
+			return err
+		}
+		if err := f.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return d.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,135 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type AlterUserScramCredentialsV0 struct {
+	Deletions  []ScramCredentialDeletion
+	Upsertions []ScramCredentialUpsertion
+	TagBuffer  types.TaggedFields
+}
+
+type ScramCredentialDeletion struct {
+	Name      types.CompactString
+	Mechanism int8
+	TagBuffer types.TaggedFields
+}
+
+// ScramCredentialUpsertion carries the salted password computed by the
+// client, so the password itself never reaches the broker.
+type ScramCredentialUpsertion struct {
+	Name           types.CompactString
+	Mechanism      int8
+	Iterations     int32
+	Salt           []byte
+	SaltedPassword []byte
+	TagBuffer      types.TaggedFields
+}
+
+func ReadAlterUserScramCredentials(r *bytes.Reader) (*AlterUserScramCredentialsV0, error) {
+	as := &AlterUserScramCredentialsV0{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "deletions")
+	}
+	as.Deletions = make([]ScramCredentialDeletion, max(n, 0))
+	for i := range as.Deletions {
+		d := &as.Deletions[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "deletions[%d].name", i)
+		}
+		d.Name = *name
+		if err := binary.Read(r, binary.BigEndian, &d.Mechanism); err != nil {
+			return nil, types.Fieldf(err, "deletions[%d].mechanism", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "deletions[%d].taggedFields", i)
+		}
+		d.TagBuffer = *tagBuffer
+	}
+
+	if n, err = types.ReadCompactArrayLength(r); err != nil {
+		return nil, types.Fieldf(err, "upsertions")
+	}
+	as.Upsertions = make([]ScramCredentialUpsertion, max(n, 0))
+	for i := range as.Upsertions {
+		u := &as.Upsertions[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "upsertions[%d].name", i)
+		}
+		u.Name = *name
+		if err := binary.Read(r, binary.BigEndian, &u.Mechanism); err != nil {
+			return nil, types.Fieldf(err, "upsertions[%d].mechanism", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &u.Iterations); err != nil {
+			return nil, types.Fieldf(err, "upsertions[%d].iterations", i)
+		}
+		if u.Salt, err = types.ReadCompactBytes(r); err != nil {
+			return nil, types.Fieldf(err, "upsertions[%d].salt", i)
+		}
+		if u.SaltedPassword, err = types.ReadCompactBytes(r); err != nil {
+			return nil, types.Fieldf(err, "upsertions[%d].saltedPassword", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "upsertions[%d].taggedFields", i)
+		}
+		u.TagBuffer = *tagBuffer
+	}
+
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	as.TagBuffer = *tagBuffer
+	return as, nil
+}
+
+func (as *AlterUserScramCredentialsV0) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(as.Deletions)); err != nil {
+		return err
+	}
+	for _, d := range as.Deletions {
+		if err := d.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, d.Mechanism); err != nil {
+			return err
+		}
+		if err := d.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteCompactArrayLength(w, len(as.Upsertions)); err != nil {
+		return err
+	}
+	for _, u := range as.Upsertions {
+		if err := u.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, u.Mechanism); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, u.Iterations); err != nil {
+			return err
+		}
+		if err := types.WriteCompactBytes(w, u.Salt); err != nil {
+			return err
+		}
+		if err := types.WriteCompactBytes(w, u.SaltedPassword); err != nil {
+			return err
+		}
+		if err := u.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return as.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,54 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// ApiVersionsV4 covers versions 0 to 4. The body is empty before version 3,
+// which is the first flexible version. The body of later versions is not
+// read so that the broker can still answer with UNSUPPORTED_VERSION.
+type ApiVersionsV4 struct {
+	Version               int16
+	ClientSoftwareName    types.CompactString
+	ClientSoftwareVersion types.CompactString
+	TagBuffer             types.TaggedFields
+}
+
+func ReadApiVersions(r *bytes.Reader, version int16) (*ApiVersionsV4, error) {
+	a := &ApiVersionsV4{Version: version}
+	if version < 3 || version > 4 {
+		return a, nil
+	}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "clientSoftwareName")
+	}
+	a.ClientSoftwareName = *name
+	softwareVersion, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "clientSoftwareVersion")
+	}
+	a.ClientSoftwareVersion = *softwareVersion
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (a *ApiVersionsV4) WriteRequestBody(w io.Writer) error {
+	if a.Version < 3 {
+		return nil
+	}
+	if err := a.ClientSoftwareName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := a.ClientSoftwareVersion.WriteCompactString(w); err != nil {
+		return err
+	}
+	return a.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,248 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+const (
+	QuotaMatchExact   int8 = 0
+	QuotaMatchDefault int8 = 1
+	QuotaMatchAny     int8 = 2
+)
+
+// DescribeClientQuotasV1 describes the quotas of the entities matching all
+// components; with Strict, entities with other components are left out.
+type DescribeClientQuotasV1 struct {
+	Components []QuotaFilterComponent
+	Strict     bool
+	TagBuffer  types.TaggedFields
+}
+
+type QuotaFilterComponent struct {
+	EntityType types.CompactString
+	MatchType  int8
+	Match      *types.CompactString
+	TagBuffer  types.TaggedFields
+}
+
+func ReadDescribeClientQuotas(r *bytes.Reader) (*DescribeClientQuotasV1, error) {
+	d := &DescribeClientQuotasV1{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "components")
+	}
+	d.Components = make([]QuotaFilterComponent, max(n, 0))
+	for i := range d.Components {
+		c := &d.Components[i]
+		entityType, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "components[%d].entityType", i)
+		}
+		c.EntityType = *entityType
+		if err = binary.Read(r, binary.BigEndian, &c.MatchType); err != nil {
+			return nil, types.Fieldf(err, "components[%d].matchType", i)
+		}
+		if c.Match, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "components[%d].match", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "components[%d].taggedFields", i)
+		}
+		c.TagBuffer = *tagBuffer
+	}
+	if d.Strict, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "strict")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (d *DescribeClientQuotasV1) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(d.Components)); err != nil {
+		return err
+	}
+	for _, c := range d.Components {
+		if err := c.EntityType.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, c.MatchType); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, c.Match); err != nil {
+			return err
+		}
+		if err := c.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteBool(w, d.Strict); err != nil {
+		return err
+	}
+	return d.TagBuffer.WriteTaggedFields(w)
+}
+
+type AlterClientQuotasV1 struct {
+	Entries      []QuotaAlteration
+	ValidateOnly bool
+	TagBuffer    types.TaggedFields
+}
+
+type QuotaAlteration struct {
+	Entity    []QuotaEntity
+	Ops       []QuotaOp
+	TagBuffer types.TaggedFields
+}
+
+// QuotaEntity is one component of a quota entity; a nil EntityName stands
+// for the default entity of its type.
+type QuotaEntity struct {
+	EntityType types.CompactString
+	EntityName *types.CompactString
+	TagBuffer  types.TaggedFields
+}
+
+type QuotaOp struct {
+	Key       types.CompactString
+	Value     float64
+	Remove    bool
+	TagBuffer types.TaggedFields
+}
+
+func readQuotaEntity(r *bytes.Reader) ([]QuotaEntity, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, err
+	}
+	entity := make([]QuotaEntity, max(n, 0))
+	for i := range entity {
+		entityType, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].entityType", i)
+		}
+		entity[i].EntityType = *entityType
+		if entity[i].EntityName, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "[%d].entityName", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].taggedFields", i)
+		}
+		entity[i].TagBuffer = *tagBuffer
+	}
+	return entity, nil
+}
+
+func writeQuotaEntity(w io.Writer, entity []QuotaEntity) error {
+	if err := types.WriteCompactArrayLength(w, len(entity)); err != nil {
+		return err
+	}
+	for _, e := range entity {
+		if err := e.EntityType.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, e.EntityName); err != nil {
+			return err
+		}
+		if err := e.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ReadAlterClientQuotas(r *bytes.Reader) (*AlterClientQuotasV1, error) {
+	a := &AlterClientQuotasV1{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "entries")
+	}
+	a.Entries = make([]QuotaAlteration, max(n, 0))
+	for i := range a.Entries {
+		e := &a.Entries[i]
+		if e.Entity, err = readQuotaEntity(r); err != nil {
+			return nil, types.Fieldf(err, "entries[%d].entity", i)
+		}
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "entries[%d].ops", i)
+		}
+		e.Ops = make([]QuotaOp, max(n, 0))
+		for j := range e.Ops {
+			op := &e.Ops[j]
+			key, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "entries[%d].ops[%d].key", i, j)
+			}
+			op.Key = *key
+			if err = binary.Read(r, binary.BigEndian, &op.Value); err != nil {
+				return nil, types.Fieldf(err, "entries[%d].ops[%d].value", i, j)
+			}
+			if op.Remove, err = types.ReadBool(r); err != nil {
+				return nil, types.Fieldf(err, "entries[%d].ops[%d].remove", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "entries[%d].ops[%d].taggedFields", i, j)
+			}
+			op.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "entries[%d].taggedFields", i)
+		}
+		e.TagBuffer = *tagBuffer
+	}
+	if a.ValidateOnly, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "validateOnly")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (a *AlterClientQuotasV1) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(a.Entries)); err != nil {
+		return err
+	}
+	for _, e := range a.Entries {
+		if err := writeQuotaEntity(w, e.Entity); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(e.Ops)); err != nil {
+			return err
+		}
+		for _, op := range e.Ops {
+			if err := op.Key.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, op.Value); err != nil {
+				return err
+			}
+			if err := types.WriteBool(w, op.Remove); err != nil {
+				return err
+			}
+			if err := op.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := e.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteBool(w, a.ValidateOnly); err != nil {
+		return err
+	}
+	return a.TagBuffer.WriteTaggedFields(w)
+}
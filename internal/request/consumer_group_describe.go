@@ -0,0 +1,41 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ConsumerGroupDescribeV0 struct {
+	GroupIds                    []types.CompactString
+	IncludeAuthorizedOperations bool
+	TagBuffer                   types.TaggedFields
+}
+
+func ReadConsumerGroupDescribe(r *bytes.Reader) (*ConsumerGroupDescribeV0, error) {
+	var err error
+	cgd := &ConsumerGroupDescribeV0{}
+	if cgd.GroupIds, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "groupIds")
+	}
+	if cgd.IncludeAuthorizedOperations, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "includeAuthorizedOperations")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	cgd.TagBuffer = *tagBuffer
+	return cgd, nil
+}
+
+func (cgd *ConsumerGroupDescribeV0) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactStringArray(w, cgd.GroupIds); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, cgd.IncludeAuthorizedOperations); err != nil {
+		return err
+	}
+	return cgd.TagBuffer.WriteTaggedFields(w)
+}
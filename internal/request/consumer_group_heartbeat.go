@@ -0,0 +1,155 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ConsumerGroupHeartbeatV0 struct {
+	GroupId              types.CompactString
+	MemberId             types.CompactString
+	MemberEpoch          int32
+	InstanceId           *types.CompactString
+	RackId               *types.CompactString
+	RebalanceTimeoutMs   int32
+	SubscribedTopicNames []types.CompactString // nil when unchanged since the last heartbeat
+	ServerAssignor       *types.CompactString
+	TopicPartitions      []TopicPartitions // nil when unchanged since the last heartbeat
+	TagBuffer            types.TaggedFields
+}
+
+type TopicPartitions struct {
+	TopicId    [16]byte
+	Partitions []int32
+	TagBuffer  types.TaggedFields
+}
+
+func ReadTopicPartitions(r *bytes.Reader) (*TopicPartitions, error) {
+	var err error
+	tp := &TopicPartitions{}
+	if tp.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, types.Fieldf(err, "topicId")
+	}
+	if tp.Partitions, err = types.ReadCompactInt32Array(r); err != nil {
+		return nil, types.Fieldf(err, "partitions")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	tp.TagBuffer = *tagBuffer
+	return tp, nil
+}
+
+func (tp *TopicPartitions) WriteTopicPartitions(w io.Writer) error {
+	if _, err := w.Write(tp.TopicId[:]); err != nil {
+		return err
+	}
+	if err := types.WriteCompactInt32Array(w, tp.Partitions); err != nil {
+		return err
+	}
+	return tp.TagBuffer.WriteTaggedFields(w)
+}
+
+func ReadConsumerGroupHeartbeat(r *bytes.Reader) (*ConsumerGroupHeartbeatV0, error) {
+	var err error
+	hb := &ConsumerGroupHeartbeatV0{}
+
+	groupId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groupId")
+	}
+	hb.GroupId = *groupId
+	memberId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "memberId")
+	}
+	hb.MemberId = *memberId
+	if err = binary.Read(r, binary.BigEndian, &hb.MemberEpoch); err != nil {
+		return nil, types.Fieldf(err, "memberEpoch")
+	}
+	if hb.InstanceId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "instanceId")
+	}
+	if hb.RackId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "rackId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &hb.RebalanceTimeoutMs); err != nil {
+		return nil, types.Fieldf(err, "rebalanceTimeoutMs")
+	}
+	if hb.SubscribedTopicNames, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "subscribedTopicNames")
+	}
+	if hb.ServerAssignor, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "serverAssignor")
+	}
+
+	numTopicPartitions, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topicPartitions")
+	}
+	if numTopicPartitions >= 0 {
+		hb.TopicPartitions = make([]TopicPartitions, numTopicPartitions)
+		for i := range hb.TopicPartitions {
+			tp, err := ReadTopicPartitions(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topicPartitions[%d]", i)
+			}
+			hb.TopicPartitions[i] = *tp
+		}
+	}
+
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	hb.TagBuffer = *tagBuffer
+	return hb, nil
+}
+
+func (hb *ConsumerGroupHeartbeatV0) WriteRequestBody(w io.Writer) error {
+	if err := hb.GroupId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := hb.MemberId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, hb.MemberEpoch); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, hb.InstanceId); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, hb.RackId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, hb.RebalanceTimeoutMs); err != nil {
+		return err
+	}
+	if hb.SubscribedTopicNames == nil {
+		if err := types.WriteCompactArrayLength(w, -1); err != nil {
+			return err
+		}
+	} else if err := types.WriteCompactStringArray(w, hb.SubscribedTopicNames); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, hb.ServerAssignor); err != nil {
+		return err
+	}
+	numTopicPartitions := len(hb.TopicPartitions)
+	if hb.TopicPartitions == nil {
+		numTopicPartitions = -1
+	}
+	if err := types.WriteCompactArrayLength(w, numTopicPartitions); err != nil {
+		return err
+	}
+	for _, tp := range hb.TopicPartitions {
+		if err := tp.WriteTopicPartitions(w); err != nil {
+			return err
+		}
+	}
+	return hb.TagBuffer.WriteTaggedFields(w)
+}
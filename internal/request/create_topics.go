@@ -0,0 +1,187 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type CreateTopicsV7 struct {
+	Topics       []CreatableTopic
+	TimeoutMs    int32
+	ValidateOnly bool
+	TagBuffer    types.TaggedFields
+}
+
+// CreatableTopic leaves NumPartitions and ReplicationFactor to the broker
+// defaults when they are -1, and must then have no Assignments.
+type CreatableTopic struct {
+	Name              types.CompactString
+	NumPartitions     int32
+	ReplicationFactor int16
+	Assignments       []CreatableReplicaAssignment
+	Configs           []CreatableTopicConfig
+	TagBuffer         types.TaggedFields
+}
+
+type CreatableReplicaAssignment struct {
+	PartitionIndex int32
+	BrokerIds      []int32
+	TagBuffer      types.TaggedFields
+}
+
+type CreatableTopicConfig struct {
+	Name      types.CompactString
+	Value     *types.CompactString
+	TagBuffer types.TaggedFields
+}
+
+func ReadCreateTopics(r *bytes.Reader) (*CreateTopicsV7, error) {
+	ct := &CreateTopicsV7{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	ct.Topics = make([]CreatableTopic, max(n, 0))
+	for i := range ct.Topics {
+		t, err := readCreatableTopic(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d]", i)
+		}
+		ct.Topics[i] = *t
+	}
+	if err = binary.Read(r, binary.BigEndian, &ct.TimeoutMs); err != nil {
+		return nil, types.Fieldf(err, "timeoutMs")
+	}
+	if ct.ValidateOnly, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "validateOnly")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	ct.TagBuffer = *tagBuffer
+	return ct, nil
+}
+
+func readCreatableTopic(r *bytes.Reader) (*CreatableTopic, error) {
+	t := &CreatableTopic{}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "name")
+	}
+	t.Name = *name
+	if err = binary.Read(r, binary.BigEndian, &t.NumPartitions); err != nil {
+		return nil, types.Fieldf(err, "numPartitions")
+	}
+	if err = binary.Read(r, binary.BigEndian, &t.ReplicationFactor); err != nil {
+		return nil, types.Fieldf(err, "replicationFactor")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "assignments")
+	}
+	t.Assignments = make([]CreatableReplicaAssignment, max(n, 0))
+	for i := range t.Assignments {
+		a := &t.Assignments[i]
+		if err := binary.Read(r, binary.BigEndian, &a.PartitionIndex); err != nil {
+			return nil, types.Fieldf(err, "assignments[%d].partitionIndex", i)
+		}
+		if a.BrokerIds, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "assignments[%d].brokerIds", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "assignments[%d].taggedFields", i)
+		}
+		a.TagBuffer = *tagBuffer
+	}
+	n, err = types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "configs")
+	}
+	t.Configs = make([]CreatableTopicConfig, max(n, 0))
+	for i := range t.Configs {
+		c := &t.Configs[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "configs[%d].name", i)
+		}
+		c.Name = *name
+		if c.Value, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "configs[%d].value", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "configs[%d].taggedFields", i)
+		}
+		c.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	t.TagBuffer = *tagBuffer
+	return t, nil
+}
+
+func (ct *CreateTopicsV7) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(ct.Topics)); err != nil {
+		return err
+	}
+	for _, t := range ct.Topics {
+		if err := t.Write(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, ct.TimeoutMs); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, ct.ValidateOnly); err != nil {
+		return err
+	}
+	return ct.TagBuffer.WriteTaggedFields(w)
+}
+
+func (t *CreatableTopic) Write(w io.Writer) error {
+	if err := t.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.NumPartitions); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.ReplicationFactor); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(t.Assignments)); err != nil {
+		return err
+	}
+	for _, a := range t.Assignments {
+		if err := binary.Write(w, binary.BigEndian, a.PartitionIndex); err != nil {
+			return err
+		}
+		if err := types.WriteCompactInt32Array(w, a.BrokerIds); err != nil {
+			return err
+		}
+		if err := a.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteCompactArrayLength(w, len(t.Configs)); err != nil {
+		return err
+	}
+	for _, c := range t.Configs {
+		if err := c.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, c.Value); err != nil {
+			return err
+		}
+		if err := c.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return t.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,76 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DeleteTopicsV6 struct {
+	Topics    []DeleteTopicState
+	TimeoutMs int32
+	TagBuffer types.TaggedFields
+}
+
+// DeleteTopicState names a topic, or identifies it by TopicId when Name is
+// nil.
+type DeleteTopicState struct {
+	Name      *types.CompactString
+	TopicId   [16]byte
+	TagBuffer types.TaggedFields
+}
+
+func ReadDeleteTopics(r *bytes.Reader) (*DeleteTopicsV6, error) {
+	dt := &DeleteTopicsV6{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	dt.Topics = make([]DeleteTopicState, max(n, 0))
+	for i := range dt.Topics {
+		t := &dt.Topics[i]
+		if t.Name, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		if t.TopicId, err = types.ReadUuid(r); err != nil {
+			return nil, types.Fieldf(err, "topics[%d].topicId", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	if err = binary.Read(r, binary.BigEndian, &dt.TimeoutMs); err != nil {
+		return nil, types.Fieldf(err, "timeoutMs")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	dt.TagBuffer = *tagBuffer
+	return dt, nil
+}
+
+func (dt *DeleteTopicsV6) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(dt.Topics)); err != nil {
+		return err
+	}
+	for _, t := range dt.Topics {
+		if err := types.WriteCompactNullableString(w, t.Name); err != nil {
+			return err
+		}
+		if _, err := w.Write(t.TopicId[:]); err != nil {
+			return err
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, dt.TimeoutMs); err != nil {
+		return err
+	}
+	return dt.TagBuffer.WriteTaggedFields(w)
+}
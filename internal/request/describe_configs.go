@@ -0,0 +1,94 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeConfigsV4 struct {
+	Resources            []DescribeConfigsResource
+	IncludeSynonyms      bool
+	IncludeDocumentation bool
+	TagBuffer            types.TaggedFields
+}
+
+type DescribeConfigsResource struct {
+	ResourceType      int8
+	ResourceName      types.CompactString
+	ConfigurationKeys []types.CompactString // nil for every configuration
+	TagBuffer         types.TaggedFields
+}
+
+func ReadDescribeConfigs(r *bytes.Reader) (*DescribeConfigsV4, error) {
+	dc := &DescribeConfigsV4{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "resources")
+	}
+	dc.Resources = make([]DescribeConfigsResource, max(n, 0))
+	for i := range dc.Resources {
+		res := &dc.Resources[i]
+		if err = binary.Read(r, binary.BigEndian, &res.ResourceType); err != nil {
+			return nil, types.Fieldf(err, "resources[%d].resourceType", i)
+		}
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].resourceName", i)
+		}
+		res.ResourceName = *name
+		if res.ConfigurationKeys, err = types.ReadCompactStringArray(r); err != nil {
+			return nil, types.Fieldf(err, "resources[%d].configurationKeys", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	if dc.IncludeSynonyms, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "includeSynonyms")
+	}
+	if dc.IncludeDocumentation, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "includeDocumentation")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	dc.TagBuffer = *tagBuffer
+	return dc, nil
+}
+
+func (dc *DescribeConfigsV4) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(dc.Resources)); err != nil {
+		return err
+	}
+	for _, res := range dc.Resources {
+		if err := binary.Write(w, binary.BigEndian, res.ResourceType); err != nil {
+			return err
+		}
+		if err := res.ResourceName.WriteCompactString(w); err != nil {
+			return err
+		}
+		if res.ConfigurationKeys == nil {
+			if err := types.WriteCompactArrayLength(w, -1); err != nil {
+				return err
+			}
+		} else if err := types.WriteCompactStringArray(w, res.ConfigurationKeys); err != nil {
+			return err
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteBool(w, dc.IncludeSynonyms); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, dc.IncludeDocumentation); err != nil {
+		return err
+	}
+	return dc.TagBuffer.WriteTaggedFields(w)
+}
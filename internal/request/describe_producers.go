@@ -0,0 +1,67 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeProducersV0 struct {
+	Topics    []DescribeProducersTopic
+	TagBuffer types.TaggedFields
+}
+
+type DescribeProducersTopic struct {
+	Name             types.CompactString
+	PartitionIndexes []int32
+	TagBuffer        types.TaggedFields
+}
+
+func ReadDescribeProducers(r *bytes.Reader) (*DescribeProducersV0, error) {
+	dp := &DescribeProducersV0{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	dp.Topics = make([]DescribeProducersTopic, max(n, 0))
+	for i := range dp.Topics {
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		dp.Topics[i].Name = *name
+		if dp.Topics[i].PartitionIndexes, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitionIndexes", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		dp.Topics[i].TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	dp.TagBuffer = *tagBuffer
+	return dp, nil
+}
+
+func (dp *DescribeProducersV0) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(dp.Topics)); err != nil {
+		return err
+	}
+	for _, t := range dp.Topics {
+		if err := t.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactInt32Array(w, t.PartitionIndexes); err != nil {
+			return err
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return dp.TagBuffer.WriteTaggedFields(w)
+}
@@ -11,10 +11,59 @@ import (
 type DescribeTopicPartitionsV0 struct {
 	Topics                 []Topic
 	ResponsePartitionLimit int32
-	Cursor                 int8 // nullable used for pagination
+	Cursor                 *Cursor // nil on the first page
 	TagBuffer              types.TaggedFields
 }
 
+// Cursor is the first partition to describe, as returned in the NextCursor
+// of the previous page.
+type Cursor struct {
+	TopicName      types.CompactString
+	PartitionIndex int32
+	TagBuffer      types.TaggedFields
+}
+
+// readCursor reads a nullable struct: -1 marks null, 1 marks present.
+func readCursor(r *bytes.Reader) (*Cursor, error) {
+	var present int8
+	if err := binary.Read(r, binary.BigEndian, &present); err != nil {
+		return nil, err
+	}
+	if present < 0 {
+		return nil, nil
+	}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topicName")
+	}
+	c := &Cursor{TopicName: *name}
+	if err := binary.Read(r, binary.BigEndian, &c.PartitionIndex); err != nil {
+		return nil, types.Fieldf(err, "partitionIndex")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	c.TagBuffer = *tagBuffer
+	return c, nil
+}
+
+func writeCursor(w io.Writer, c *Cursor) error {
+	if c == nil {
+		return binary.Write(w, binary.BigEndian, int8(-1))
+	}
+	if err := binary.Write(w, binary.BigEndian, int8(1)); err != nil {
+		return err
+	}
+	if err := c.TopicName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, c.PartitionIndex); err != nil {
+		return err
+	}
+	return c.TagBuffer.WriteTaggedFields(w)
+}
+
 type Topic struct {
 	Name      types.CompactString
 	TagBuffer types.TaggedFields
@@ -23,11 +72,11 @@ type Topic struct {
 func ReadTopic(r *bytes.Reader) (*Topic, error) {
 	name, err := types.ReadCompactString(r)
 	if err != nil {
-		return nil, err
+		return nil, types.Fieldf(err, "name")
 	}
 	tagBuffer, err := types.ReadTaggedFields(r)
 	if err != nil {
-		return nil, err
+		return nil, types.Fieldf(err, "taggedFields")
 	}
 	return &Topic{
 		Name:      *name,
@@ -43,27 +92,30 @@ func (t *Topic) WriteTopic(w io.Writer) error {
 }
 
 func ReadDescribeTopicPartitions(r *bytes.Reader) (*DescribeTopicPartitionsV0, error) {
-	numTopics, err := types.ReadUvarint(r)
+	numTopics, err := types.ReadCompactArrayLength(r)
 	if err != nil {
-		return nil, err
+		return nil, types.Fieldf(err, "topics")
 	}
 
-	numTopics -= 1
 	describeTopicPartitions := &DescribeTopicPartitionsV0{
-		Topics: make([]Topic, numTopics),
+		Topics: make([]Topic, max(numTopics, 0)),
 	}
-	for i := range numTopics {
+	for i := range describeTopicPartitions.Topics {
 		topic, err := ReadTopic(r)
 		if err != nil {
-			return nil, err
+			return nil, types.Fieldf(err, "topics[%d]", i)
 		}
 		describeTopicPartitions.Topics[i] = *topic
 	}
-	binary.Read(r, binary.BigEndian, &describeTopicPartitions.ResponsePartitionLimit)
-	binary.Read(r, binary.BigEndian, &describeTopicPartitions.Cursor)
+	if err := binary.Read(r, binary.BigEndian, &describeTopicPartitions.ResponsePartitionLimit); err != nil {
+		return nil, types.Fieldf(err, "responsePartitionLimit")
+	}
+	if describeTopicPartitions.Cursor, err = readCursor(r); err != nil {
+		return nil, types.Fieldf(err, "cursor")
+	}
 	tagBuffer, err := types.ReadTaggedFields(r)
 	if err != nil {
-		return nil, err
+		return nil, types.Fieldf(err, "taggedFields")
 	}
 	describeTopicPartitions.TagBuffer = *tagBuffer
 	return describeTopicPartitions, nil
@@ -78,7 +130,11 @@ func (r *DescribeTopicPartitionsV0) WriteRequestBody(w io.Writer) error {
 			return err
 		}
 	}
-	binary.Write(w, binary.BigEndian, r.ResponsePartitionLimit)
-	binary.Write(w, binary.BigEndian, r.Cursor)
+	if err := binary.Write(w, binary.BigEndian, r.ResponsePartitionLimit); err != nil {
+		return err
+	}
+	if err := writeCursor(w, r.Cursor); err != nil {
+		return err
+	}
 	return r.TagBuffer.WriteTaggedFields(w)
 }
@@ -0,0 +1,34 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeTransactionsV0 struct {
+	TransactionalIds []types.CompactString
+	TagBuffer        types.TaggedFields
+}
+
+func ReadDescribeTransactions(r *bytes.Reader) (*DescribeTransactionsV0, error) {
+	var err error
+	dt := &DescribeTransactionsV0{}
+	if dt.TransactionalIds, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "transactionalIds")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	dt.TagBuffer = *tagBuffer
+	return dt, nil
+}
+
+func (dt *DescribeTransactionsV0) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactStringArray(w, dt.TransactionalIds); err != nil {
+		return err
+	}
+	return dt.TagBuffer.WriteTaggedFields(w)
+}
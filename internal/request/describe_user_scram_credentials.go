@@ -0,0 +1,68 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// DescribeUserScramCredentialsV0 describes every user when Users is null
+// or empty.
+type DescribeUserScramCredentialsV0 struct {
+	Users     []UserName
+	TagBuffer types.TaggedFields
+}
+
+type UserName struct {
+	Name      types.CompactString
+	TagBuffer types.TaggedFields
+}
+
+func ReadDescribeUserScramCredentials(r *bytes.Reader) (*DescribeUserScramCredentialsV0, error) {
+	ds := &DescribeUserScramCredentialsV0{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "users")
+	}
+	if n >= 0 {
+		ds.Users = make([]UserName, n)
+	}
+	for i := range ds.Users {
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "users[%d].name", i)
+		}
+		ds.Users[i].Name = *name
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "users[%d].taggedFields", i)
+		}
+		ds.Users[i].TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	ds.TagBuffer = *tagBuffer
+	return ds, nil
+}
+
+func (ds *DescribeUserScramCredentialsV0) WriteRequestBody(w io.Writer) error {
+	n := len(ds.Users)
+	if ds.Users == nil {
+		n = -1
+	}
+	if err := types.WriteCompactArrayLength(w, n); err != nil {
+		return err
+	}
+	for _, u := range ds.Users {
+		if err := u.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := u.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return ds.TagBuffer.WriteTaggedFields(w)
+}
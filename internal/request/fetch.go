@@ -0,0 +1,259 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// FetchV12 covers versions 12 to 16. Topics are named up to version 12 and
+// identified by TopicId from version 13; ReplicaId is gone from version 15.
+type FetchV12 struct {
+	Version             int16
+	ReplicaId           int32
+	MaxWaitMs           int32
+	MinBytes            int32
+	MaxBytes            int32
+	IsolationLevel      int8
+	SessionId           int32
+	SessionEpoch        int32
+	Topics              []FetchTopic
+	ForgottenTopicsData []ForgottenTopic
+	RackId              types.CompactString
+	TagBuffer           types.TaggedFields
+}
+
+type FetchTopic struct {
+	Topic      types.CompactString // version 12
+	TopicId    [16]byte            // version 13+
+	Partitions []FetchPartition
+	TagBuffer  types.TaggedFields
+}
+
+type FetchPartition struct {
+	Partition          int32
+	CurrentLeaderEpoch int32
+	FetchOffset        int64
+	LastFetchedEpoch   int32
+	LogStartOffset     int64
+	PartitionMaxBytes  int32
+	TagBuffer          types.TaggedFields
+}
+
+type ForgottenTopic struct {
+	Topic      types.CompactString
+	TopicId    [16]byte
+	Partitions []int32
+	TagBuffer  types.TaggedFields
+}
+
+func readFetchTopicName(r *bytes.Reader, version int16, name *types.CompactString, topicId *[16]byte) error {
+	if version >= 13 {
+		id, err := types.ReadUuid(r)
+		*topicId = id
+		return types.Fieldf(err, "topicId")
+	}
+	cs, err := types.ReadCompactString(r)
+	if err != nil {
+		return types.Fieldf(err, "name")
+	}
+	*name = *cs
+	return nil
+}
+
+func writeFetchTopicName(w io.Writer, version int16, name types.CompactString, topicId [16]byte) error {
+	if version >= 13 {
+		_, err := w.Write(topicId[:])
+		return err
+	}
+	return name.WriteCompactString(w)
+}
+
+func ReadFetch(r *bytes.Reader, version int16) (*FetchV12, error) {
+	f := &FetchV12{Version: version, ReplicaId: -1}
+	if version < 15 {
+		if err := binary.Read(r, binary.BigEndian, &f.ReplicaId); err != nil {
+			return nil, types.Fieldf(err, "replicaId")
+		}
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.MaxWaitMs); err != nil {
+		return nil, types.Fieldf(err, "maxWaitMs")
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.MinBytes); err != nil {
+		return nil, types.Fieldf(err, "minBytes")
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.MaxBytes); err != nil {
+		return nil, types.Fieldf(err, "maxBytes")
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.IsolationLevel); err != nil {
+		return nil, types.Fieldf(err, "isolationLevel")
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.SessionId); err != nil {
+		return nil, types.Fieldf(err, "sessionId")
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.SessionEpoch); err != nil {
+		return nil, types.Fieldf(err, "sessionEpoch")
+	}
+
+	numTopics, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	f.Topics = make([]FetchTopic, max(numTopics, 0))
+	for i := range f.Topics {
+		t := &f.Topics[i]
+		if err := readFetchTopicName(r, version, &t.Topic, &t.TopicId); err != nil {
+			return nil, types.Fieldf(err, "topics[%d]", i)
+		}
+		numPartitions, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		t.Partitions = make([]FetchPartition, max(numPartitions, 0))
+		for j := range t.Partitions {
+			p := &t.Partitions[j]
+			if err := binary.Read(r, binary.BigEndian, &p.Partition); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].partition", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.CurrentLeaderEpoch); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].currentLeaderEpoch", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.FetchOffset); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].fetchOffset", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.LastFetchedEpoch); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].lastFetchedEpoch", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.LogStartOffset); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].logStartOffset", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.PartitionMaxBytes); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].partitionMaxBytes", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].taggedFields", i, j)
+			}
+			p.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+
+	numForgotten, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "forgottenTopicsData")
+	}
+	f.ForgottenTopicsData = make([]ForgottenTopic, max(numForgotten, 0))
+	for i := range f.ForgottenTopicsData {
+		t := &f.ForgottenTopicsData[i]
+		if err := readFetchTopicName(r, version, &t.Topic, &t.TopicId); err != nil {
+			return nil, types.Fieldf(err, "forgottenTopicsData[%d]", i)
+		}
+		if t.Partitions, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "forgottenTopicsData[%d].partitions", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "forgottenTopicsData[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+
+	rackId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "rackId")
+	}
+	f.RackId = *rackId
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	f.TagBuffer = *tagBuffer
+	return f, nil
+}
+
+func (f *FetchV12) WriteRequestBody(w io.Writer) error {
+	if f.Version < 15 {
+		if err := binary.Write(w, binary.BigEndian, f.ReplicaId); err != nil {
+			return err
+		}
+	}
+	for _, v := range []int32{f.MaxWaitMs, f.MinBytes, f.MaxBytes} {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, f.IsolationLevel); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, f.SessionId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, f.SessionEpoch); err != nil {
+		return err
+	}
+
+	if err := types.WriteCompactArrayLength(w, len(f.Topics)); err != nil {
+		return err
+	}
+	for _, t := range f.Topics {
+		if err := writeFetchTopicName(w, f.Version, t.Topic, t.TopicId); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			if err := binary.Write(w, binary.BigEndian, p.Partition); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CurrentLeaderEpoch); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.FetchOffset); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.LastFetchedEpoch); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.LogStartOffset); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.PartitionMaxBytes); err != nil {
+				return err
+			}
+			if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+
+	if err := types.WriteCompactArrayLength(w, len(f.ForgottenTopicsData)); err != nil {
+		return err
+	}
+	for _, t := range f.ForgottenTopicsData {
+		if err := writeFetchTopicName(w, f.Version, t.Topic, t.TopicId); err != nil {
+			return err
+		}
+		if err := types.WriteCompactInt32Array(w, t.Partitions); err != nil {
+			return err
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+
+	if err := f.RackId.WriteCompactString(w); err != nil {
+		return err
+	}
+	return f.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,133 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// Operations of an IncrementalAlterConfigs entry.
+const (
+	ConfigOperationSet      int8 = 0
+	ConfigOperationDelete   int8 = 1
+	ConfigOperationAppend   int8 = 2
+	ConfigOperationSubtract int8 = 3
+)
+
+type IncrementalAlterConfigsV1 struct {
+	Resources    []AlterConfigsResource
+	ValidateOnly bool
+	TagBuffer    types.TaggedFields
+}
+
+type AlterConfigsResource struct {
+	ResourceType int8
+	ResourceName types.CompactString
+	Configs      []AlterableConfig
+	TagBuffer    types.TaggedFields
+}
+
+type AlterableConfig struct {
+	Name            types.CompactString
+	ConfigOperation int8
+	Value           *types.CompactString
+	TagBuffer       types.TaggedFields
+}
+
+func ReadIncrementalAlterConfigs(r *bytes.Reader) (*IncrementalAlterConfigsV1, error) {
+	ia := &IncrementalAlterConfigsV1{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "resources")
+	}
+	ia.Resources = make([]AlterConfigsResource, max(n, 0))
+	for i := range ia.Resources {
+		res := &ia.Resources[i]
+		if err = binary.Read(r, binary.BigEndian, &res.ResourceType); err != nil {
+			return nil, types.Fieldf(err, "resources[%d].resourceType", i)
+		}
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].resourceName", i)
+		}
+		res.ResourceName = *name
+		numConfigs, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].configs", i)
+		}
+		res.Configs = make([]AlterableConfig, max(numConfigs, 0))
+		for j := range res.Configs {
+			c := &res.Configs[j]
+			name, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "resources[%d].configs[%d].name", i, j)
+			}
+			c.Name = *name
+			if err = binary.Read(r, binary.BigEndian, &c.ConfigOperation); err != nil {
+				return nil, types.Fieldf(err, "resources[%d].configs[%d].configOperation", i, j)
+			}
+			if c.Value, err = types.ReadCompactNullableString(r); err != nil {
+				return nil, types.Fieldf(err, "resources[%d].configs[%d].value", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "resources[%d].configs[%d].taggedFields", i, j)
+			}
+			c.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	if ia.ValidateOnly, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "validateOnly")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	ia.TagBuffer = *tagBuffer
+	return ia, nil
+}
+
+func (ia *IncrementalAlterConfigsV1) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(ia.Resources)); err != nil {
+		return err
+	}
+	for _, res := range ia.Resources {
+		if err := binary.Write(w, binary.BigEndian, res.ResourceType); err != nil {
+			return err
+		}
+		if err := res.ResourceName.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(res.Configs)); err != nil {
+			return err
+		}
+		for _, c := range res.Configs {
+			if err := c.Name.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, c.ConfigOperation); err != nil {
+				return err
+			}
+			if err := types.WriteCompactNullableString(w, c.Value); err != nil {
+				return err
+			}
+			if err := c.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteBool(w, ia.ValidateOnly); err != nil {
+		return err
+	}
+	return ia.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,67 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// InitProducerIdV2 covers versions 2 to 4; ProducerId and ProducerEpoch are
+// only sent from version 3 on.
+type InitProducerIdV2 struct {
+	Version              int16
+	TransactionalId      *types.CompactString
+	TransactionTimeoutMs int32
+	ProducerId           int64
+	ProducerEpoch        int16
+	TagBuffer            types.TaggedFields
+}
+
+func ReadInitProducerId(r *bytes.Reader, version int16) (*InitProducerIdV2, error) {
+	var err error
+	ip := &InitProducerIdV2{
+		Version:       version,
+		ProducerId:    -1,
+		ProducerEpoch: -1,
+	}
+	if ip.TransactionalId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "transactionalId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &ip.TransactionTimeoutMs); err != nil {
+		return nil, types.Fieldf(err, "transactionTimeoutMs")
+	}
+	if version >= 3 {
+		if err = binary.Read(r, binary.BigEndian, &ip.ProducerId); err != nil {
+			return nil, types.Fieldf(err, "producerId")
+		}
+		if err = binary.Read(r, binary.BigEndian, &ip.ProducerEpoch); err != nil {
+			return nil, types.Fieldf(err, "producerEpoch")
+		}
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	ip.TagBuffer = *tagBuffer
+	return ip, nil
+}
+
+func (ip *InitProducerIdV2) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactNullableString(w, ip.TransactionalId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, ip.TransactionTimeoutMs); err != nil {
+		return err
+	}
+	if ip.Version >= 3 {
+		if err := binary.Write(w, binary.BigEndian, ip.ProducerId); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, ip.ProducerEpoch); err != nil {
+			return err
+		}
+	}
+	return ip.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,42 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// ListGroupsV5 lists every group when the filters are empty.
+type ListGroupsV5 struct {
+	StatesFilter []types.CompactString
+	TypesFilter  []types.CompactString
+	TagBuffer    types.TaggedFields
+}
+
+func ReadListGroups(r *bytes.Reader) (*ListGroupsV5, error) {
+	var err error
+	lg := &ListGroupsV5{}
+	if lg.StatesFilter, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "statesFilter")
+	}
+	if lg.TypesFilter, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "typesFilter")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	lg.TagBuffer = *tagBuffer
+	return lg, nil
+}
+
+func (lg *ListGroupsV5) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactStringArray(w, lg.StatesFilter); err != nil {
+		return err
+	}
+	if err := types.WriteCompactStringArray(w, lg.TypesFilter); err != nil {
+		return err
+	}
+	return lg.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,133 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// Timestamps with a special meaning in ListOffsets requests.
+const (
+	LatestTimestamp        int64 = -1
+	EarliestTimestamp      int64 = -2
+	MaxTimestamp           int64 = -3 // version 7+
+	EarliestLocalTimestamp int64 = -4 // version 8+
+)
+
+// ListOffsetsV6 covers versions 6 to 8, which only differ in the special
+// timestamps they accept.
+type ListOffsetsV6 struct {
+	ReplicaId      int32
+	IsolationLevel int8
+	Topics         []ListOffsetsTopic
+	TagBuffer      types.TaggedFields
+}
+
+type ListOffsetsTopic struct {
+	Name       types.CompactString
+	Partitions []ListOffsetsPartition
+	TagBuffer  types.TaggedFields
+}
+
+type ListOffsetsPartition struct {
+	PartitionIndex     int32
+	CurrentLeaderEpoch int32
+	Timestamp          int64
+	TagBuffer          types.TaggedFields
+}
+
+func ReadListOffsets(r *bytes.Reader) (*ListOffsetsV6, error) {
+	lo := &ListOffsetsV6{}
+	if err := binary.Read(r, binary.BigEndian, &lo.ReplicaId); err != nil {
+		return nil, types.Fieldf(err, "replicaId")
+	}
+	if err := binary.Read(r, binary.BigEndian, &lo.IsolationLevel); err != nil {
+		return nil, types.Fieldf(err, "isolationLevel")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	lo.Topics = make([]ListOffsetsTopic, max(n, 0))
+	for i := range lo.Topics {
+		t := &lo.Topics[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		t.Name = *name
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		t.Partitions = make([]ListOffsetsPartition, max(n, 0))
+		for j := range t.Partitions {
+			p := &t.Partitions[j]
+			if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].partitionIndex", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.CurrentLeaderEpoch); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].currentLeaderEpoch", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.Timestamp); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].timestamp", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].taggedFields", i, j)
+			}
+			p.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	lo.TagBuffer = *tagBuffer
+	return lo, nil
+}
+
+func (lo *ListOffsetsV6) WriteRequestBody(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, lo.ReplicaId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, lo.IsolationLevel); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(lo.Topics)); err != nil {
+		return err
+	}
+	for _, t := range lo.Topics {
+		if err := t.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CurrentLeaderEpoch); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.Timestamp); err != nil {
+				return err
+			}
+			if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return lo.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,56 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// ListTransactionsV0 covers versions 0 and 1; DurationFilter is only sent
+// from version 1 on.
+type ListTransactionsV0 struct {
+	Version           int16
+	StateFilters      []types.CompactString
+	ProducerIdFilters []int64
+	DurationFilter    int64
+	TagBuffer         types.TaggedFields
+}
+
+func ReadListTransactions(r *bytes.Reader, version int16) (*ListTransactionsV0, error) {
+	var err error
+	lt := &ListTransactionsV0{Version: version, DurationFilter: -1}
+	if lt.StateFilters, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "stateFilters")
+	}
+	if lt.ProducerIdFilters, err = types.ReadCompactInt64Array(r); err != nil {
+		return nil, types.Fieldf(err, "producerIdFilters")
+	}
+	if version >= 1 {
+		if err = binary.Read(r, binary.BigEndian, &lt.DurationFilter); err != nil {
+			return nil, types.Fieldf(err, "durationFilter")
+		}
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	lt.TagBuffer = *tagBuffer
+	return lt, nil
+}
+
+func (lt *ListTransactionsV0) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactStringArray(w, lt.StateFilters); err != nil {
+		return err
+	}
+	if err := types.WriteCompactInt64Array(w, lt.ProducerIdFilters); err != nil {
+		return err
+	}
+	if lt.Version >= 1 {
+		if err := binary.Write(w, binary.BigEndian, lt.DurationFilter); err != nil {
+			return err
+		}
+	}
+	return lt.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,89 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// MetadataV12 describes the brokers of the cluster and the requested
+// topics; a nil Topics asks for every topic.
+type MetadataV12 struct {
+	Topics                           []MetadataTopic
+	AllowAutoTopicCreation           bool
+	IncludeTopicAuthorizedOperations bool
+	TagBuffer                        types.TaggedFields
+}
+
+// MetadataTopic names a topic, or identifies it by TopicId when Name is nil.
+type MetadataTopic struct {
+	TopicId   [16]byte
+	Name      *types.CompactString
+	TagBuffer types.TaggedFields
+}
+
+func ReadMetadata(r *bytes.Reader) (*MetadataV12, error) {
+	m := &MetadataV12{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	if n >= 0 {
+		m.Topics = make([]MetadataTopic, n)
+	}
+	for i := range m.Topics {
+		t := &m.Topics[i]
+		if t.TopicId, err = types.ReadUuid(r); err != nil {
+			return nil, types.Fieldf(err, "topics[%d].topicId", i)
+		}
+		if t.Name, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	if m.AllowAutoTopicCreation, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "allowAutoTopicCreation")
+	}
+	if m.IncludeTopicAuthorizedOperations, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "includeTopicAuthorizedOperations")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	m.TagBuffer = *tagBuffer
+	return m, nil
+}
+
+func (m *MetadataV12) WriteRequestBody(w io.Writer) error {
+	numTopics := len(m.Topics)
+	if m.Topics == nil {
+		numTopics = -1
+	}
+	if err := types.WriteCompactArrayLength(w, numTopics); err != nil {
+		return err
+	}
+	for _, t := range m.Topics {
+		if _, err := w.Write(t.TopicId[:]); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, t.Name); err != nil {
+			return err
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteBool(w, m.AllowAutoTopicCreation); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, m.IncludeTopicAuthorizedOperations); err != nil {
+		return err
+	}
+	return m.TagBuffer.WriteTaggedFields(w)
+}
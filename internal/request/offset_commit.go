@@ -0,0 +1,151 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// OffsetCommitV9 commits offsets for a group. Members of a consumer group
+// send their member id and epoch; other clients, such as admin tools, send
+// an empty member id and an epoch of -1.
+type OffsetCommitV9 struct {
+	GroupId                   types.CompactString
+	GenerationIdOrMemberEpoch int32
+	MemberId                  types.CompactString
+	GroupInstanceId           *types.CompactString
+	Topics                    []OffsetCommitTopic
+	TagBuffer                 types.TaggedFields
+}
+
+type OffsetCommitTopic struct {
+	Name       types.CompactString
+	Partitions []OffsetCommitPartition
+	TagBuffer  types.TaggedFields
+}
+
+type OffsetCommitPartition struct {
+	PartitionIndex       int32
+	CommittedOffset      int64
+	CommittedLeaderEpoch int32
+	CommittedMetadata    *types.CompactString
+	TagBuffer            types.TaggedFields
+}
+
+func ReadOffsetCommit(r *bytes.Reader) (*OffsetCommitV9, error) {
+	oc := &OffsetCommitV9{}
+	groupId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groupId")
+	}
+	oc.GroupId = *groupId
+	if err = binary.Read(r, binary.BigEndian, &oc.GenerationIdOrMemberEpoch); err != nil {
+		return nil, types.Fieldf(err, "generationIdOrMemberEpoch")
+	}
+	memberId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "memberId")
+	}
+	oc.MemberId = *memberId
+	if oc.GroupInstanceId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "groupInstanceId")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	oc.Topics = make([]OffsetCommitTopic, max(n, 0))
+	for i := range oc.Topics {
+		t := &oc.Topics[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		t.Name = *name
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		t.Partitions = make([]OffsetCommitPartition, max(n, 0))
+		for j := range t.Partitions {
+			p := &t.Partitions[j]
+			if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].partitionIndex", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.CommittedOffset); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedOffset", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.CommittedLeaderEpoch); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedLeaderEpoch", i, j)
+			}
+			if p.CommittedMetadata, err = types.ReadCompactNullableString(r); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedMetadata", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].taggedFields", i, j)
+			}
+			p.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	oc.TagBuffer = *tagBuffer
+	return oc, nil
+}
+
+func (oc *OffsetCommitV9) WriteRequestBody(w io.Writer) error {
+	if err := oc.GroupId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, oc.GenerationIdOrMemberEpoch); err != nil {
+		return err
+	}
+	if err := oc.MemberId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, oc.GroupInstanceId); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(oc.Topics)); err != nil {
+		return err
+	}
+	for _, t := range oc.Topics {
+		if err := t.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CommittedOffset); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CommittedLeaderEpoch); err != nil {
+				return err
+			}
+			if err := types.WriteCompactNullableString(w, p.CommittedMetadata); err != nil {
+				return err
+			}
+			if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return oc.TagBuffer.WriteTaggedFields(w)
+}
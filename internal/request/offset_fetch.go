@@ -0,0 +1,136 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// OffsetFetchV9 fetches the committed offsets of several groups.
+// RequireStable asks for an error instead of the offsets of partitions with
+// offsets pending in a transaction.
+type OffsetFetchV9 struct {
+	Groups        []OffsetFetchGroup
+	RequireStable bool
+	TagBuffer     types.TaggedFields
+}
+
+// OffsetFetchGroup asks for the offsets of every partition the group
+// committed when Topics is nil.
+type OffsetFetchGroup struct {
+	GroupId     types.CompactString
+	MemberId    *types.CompactString
+	MemberEpoch int32
+	Topics      []OffsetFetchTopic
+	TagBuffer   types.TaggedFields
+}
+
+type OffsetFetchTopic struct {
+	Name             types.CompactString
+	PartitionIndexes []int32
+	TagBuffer        types.TaggedFields
+}
+
+func ReadOffsetFetch(r *bytes.Reader) (*OffsetFetchV9, error) {
+	of := &OffsetFetchV9{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groups")
+	}
+	of.Groups = make([]OffsetFetchGroup, max(n, 0))
+	for i := range of.Groups {
+		g := &of.Groups[i]
+		groupId, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].groupId", i)
+		}
+		g.GroupId = *groupId
+		if g.MemberId, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "groups[%d].memberId", i)
+		}
+		if err = binary.Read(r, binary.BigEndian, &g.MemberEpoch); err != nil {
+			return nil, types.Fieldf(err, "groups[%d].memberEpoch", i)
+		}
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].topics", i)
+		}
+		if n >= 0 {
+			g.Topics = make([]OffsetFetchTopic, n)
+		}
+		for j := range g.Topics {
+			t := &g.Topics[j]
+			name, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "groups[%d].topics[%d].name", i, j)
+			}
+			t.Name = *name
+			if t.PartitionIndexes, err = types.ReadCompactInt32Array(r); err != nil {
+				return nil, types.Fieldf(err, "groups[%d].topics[%d].partitionIndexes", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "groups[%d].topics[%d].taggedFields", i, j)
+			}
+			t.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].taggedFields", i)
+		}
+		g.TagBuffer = *tagBuffer
+	}
+	if of.RequireStable, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "requireStable")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	of.TagBuffer = *tagBuffer
+	return of, nil
+}
+
+func (of *OffsetFetchV9) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(of.Groups)); err != nil {
+		return err
+	}
+	for _, g := range of.Groups {
+		if err := g.GroupId.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, g.MemberId); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, g.MemberEpoch); err != nil {
+			return err
+		}
+		numTopics := len(g.Topics)
+		if g.Topics == nil {
+			numTopics = -1
+		}
+		if err := types.WriteCompactArrayLength(w, numTopics); err != nil {
+			return err
+		}
+		for _, t := range g.Topics {
+			if err := t.Name.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := types.WriteCompactInt32Array(w, t.PartitionIndexes); err != nil {
+				return err
+			}
+			if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := g.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteBool(w, of.RequireStable); err != nil {
+		return err
+	}
+	return of.TagBuffer.WriteTaggedFields(w)
+}
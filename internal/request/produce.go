@@ -0,0 +1,129 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// ProduceV9 covers versions 9 to 11, which only differ in tagged fields.
+type ProduceV9 struct {
+	TransactionalId *types.CompactString
+	Acks            int16
+	TimeoutMs       int32
+	TopicData       []ProduceTopicData
+	TagBuffer       types.TaggedFields
+}
+
+type ProduceTopicData struct {
+	Name          types.CompactString
+	PartitionData []ProducePartitionData
+	TagBuffer     types.TaggedFields
+}
+
+type ProducePartitionData struct {
+	Index     int32
+	Records   []byte // encoded record batches, nil when null
+	TagBuffer types.TaggedFields
+}
+
+func ReadProduce(r *bytes.Reader) (*ProduceV9, error) {
+	var err error
+	p := &ProduceV9{}
+	if p.TransactionalId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "transactionalId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &p.Acks); err != nil {
+		return nil, types.Fieldf(err, "acks")
+	}
+	if err = binary.Read(r, binary.BigEndian, &p.TimeoutMs); err != nil {
+		return nil, types.Fieldf(err, "timeoutMs")
+	}
+
+	numTopics, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topicData")
+	}
+	p.TopicData = make([]ProduceTopicData, max(numTopics, 0))
+	for i := range p.TopicData {
+		td := &p.TopicData[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topicData[%d].name", i)
+		}
+		td.Name = *name
+
+		numPartitions, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topicData[%d].partitionData", i)
+		}
+		td.PartitionData = make([]ProducePartitionData, max(numPartitions, 0))
+		for j := range td.PartitionData {
+			pd := &td.PartitionData[j]
+			if err = binary.Read(r, binary.BigEndian, &pd.Index); err != nil {
+				return nil, types.Fieldf(err, "topicData[%d].partitionData[%d].index", i, j)
+			}
+			if pd.Records, err = types.ReadCompactNullableBytes(r); err != nil {
+				return nil, types.Fieldf(err, "topicData[%d].partitionData[%d].records", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topicData[%d].partitionData[%d].taggedFields", i, j)
+			}
+			pd.TagBuffer = *tagBuffer
+		}
+
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topicData[%d].taggedFields", i)
+		}
+		td.TagBuffer = *tagBuffer
+	}
+
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	p.TagBuffer = *tagBuffer
+	return p, nil
+}
+
+func (p *ProduceV9) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactNullableString(w, p.TransactionalId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.Acks); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.TimeoutMs); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(p.TopicData)); err != nil {
+		return err
+	}
+	for _, td := range p.TopicData {
+		if err := td.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(td.PartitionData)); err != nil {
+			return err
+		}
+		for _, pd := range td.PartitionData {
+			if err := binary.Write(w, binary.BigEndian, pd.Index); err != nil {
+				return err
+			}
+			if err := types.WriteCompactNullableBytes(w, pd.Records); err != nil {
+				return err
+			}
+			if err := pd.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := td.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return p.TagBuffer.WriteTaggedFields(w)
+}
@@ -3,6 +3,7 @@ package request
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 
 	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
@@ -12,6 +13,7 @@ import (
 type RequestHeader interface {
 	WriteRequestHeader() []byte
 	GetAPIKey() int16
+	GetAPIVersion() int16
 }
 
 type RequestBody interface {
@@ -34,17 +36,26 @@ type Request struct {
 
 func ReadRequestHeaderV4(r *bytes.Reader) (*RequestHeaderV2, error) {
 	rh := &RequestHeaderV2{}
-	binary.Read(r, binary.BigEndian, &rh.RequestApiKey)
-	binary.Read(r, binary.BigEndian, &rh.RequestApiVersion)
-	binary.Read(r, binary.BigEndian, &rh.CorrelationId)
+	if err := binary.Read(r, binary.BigEndian, &rh.RequestApiKey); err != nil {
+		return nil, types.Fieldf(err, "requestApiKey")
+	}
+	if err := binary.Read(r, binary.BigEndian, &rh.RequestApiVersion); err != nil {
+		return nil, types.Fieldf(err, "requestApiVersion")
+	}
+	if err := binary.Read(r, binary.BigEndian, &rh.CorrelationId); err != nil {
+		return nil, types.Fieldf(err, "correlationId")
+	}
 	ci, err := types.ReadNullableString(r)
 	if err != nil {
-		return nil, err
+		return nil, types.Fieldf(err, "clientId")
 	}
 	rh.ClientId = *ci
+	if !flexibleVersion(rh.RequestApiKey, rh.RequestApiVersion) {
+		return rh, nil
+	}
 	tb, err := types.ReadTaggedFields(r)
 	if err != nil {
-		return nil, err
+		return nil, types.Fieldf(err, "taggedFields")
 	}
 	rh.TagBuffer = *tb
 	return rh, nil
@@ -56,28 +67,46 @@ func (rh *RequestHeaderV2) WriteRequestHeader() []byte {
 	binary.Write(&buf, binary.BigEndian, rh.RequestApiVersion)
 	binary.Write(&buf, binary.BigEndian, rh.CorrelationId)
 	rh.ClientId.WriteNullableString(&buf)
-	rh.TagBuffer.WriteTaggedFields(&buf)
+	if flexibleVersion(rh.RequestApiKey, rh.RequestApiVersion) {
+		rh.TagBuffer.WriteTaggedFields(&buf)
+	}
 	return buf.Bytes()
 }
 
+// flexibleVersion tells whether a request is sent with header version 2,
+// which ends with tagged fields, rather than header version 1.
+func flexibleVersion(apiKey, version int16) bool {
+	return constant.FlexibleVersion(apiKey, version)
+}
+
 func (rh *RequestHeaderV2) GetAPIKey() int16 {
 	return rh.RequestApiKey
 }
 
-func (r Request) MarshallRequest() []byte {
+func (rh *RequestHeaderV2) GetAPIVersion() int16 {
+	return rh.RequestApiVersion
+}
+
+// MarshallRequest encodes the request with its size prefix, which is
+// computed rather than taken from MessageSize.
+func (r Request) MarshallRequest() ([]byte, error) {
 	var buf bytes.Buffer
-	binary.Write(&buf, binary.BigEndian, r.MessageSize)
-	// binary.Write(&buf, binary.BigEndian, r.Header.WriteRequestHeader()) // TBC
-	// binary.Write(&buf, binary.BigEndian, r.Body.WriteRequestBody())
+	buf.Write(make([]byte, 4))
 	buf.Write(r.Header.WriteRequestHeader())
-	r.Body.WriteRequestBody(&buf)
-	return buf.Bytes()
+	if err := r.Body.WriteRequestBody(&buf); err != nil {
+		return nil, err
+	}
+	b := buf.Bytes()
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	return b, nil
 }
 
 func UnmarshallRequest(b []byte) (*Request, error) {
 	buf := bytes.NewReader(b)
 	req := &Request{}
-	binary.Read(buf, binary.BigEndian, &req.MessageSize)
+	if err := binary.Read(buf, binary.BigEndian, &req.MessageSize); err != nil {
+		return nil, err
+	}
 	header, err := ReadRequestHeaderV4(buf) // TBC
 	if err != nil {
 		return nil, err
@@ -87,10 +116,87 @@ func UnmarshallRequest(b []byte) (*Request, error) {
 	return req, err
 }
 
+// ParseRequestBody decodes the body of a request, which must take up the
+// rest of r. It returns nil for an API it does not know.
 func ParseRequestBody(h RequestHeader, r *bytes.Reader) (RequestBody, error) {
+	body, err := readRequestBody(h, r)
+	if err != nil || body == nil {
+		return body, err
+	}
+	if r.Len() > 0 {
+		return nil, types.Fieldf(fmt.Errorf("%w: %d bytes", types.ErrTrailingBytes, r.Len()), "body")
+	}
+	return body, nil
+}
+
+func readRequestBody(h RequestHeader, r *bytes.Reader) (RequestBody, error) {
 	switch h.GetAPIKey() {
+	case constant.ApiVersions:
+		return ReadApiVersions(r, h.GetAPIVersion())
+	case constant.Metadata:
+		return ReadMetadata(r)
 	case constant.DescribeTopicPartitions:
 		return ReadDescribeTopicPartitions(r)
+	case constant.ConsumerGroupHeartbeat:
+		return ReadConsumerGroupHeartbeat(r)
+	case constant.ConsumerGroupDescribe:
+		return ReadConsumerGroupDescribe(r)
+	case constant.InitProducerId:
+		return ReadInitProducerId(r, h.GetAPIVersion())
+	case constant.Produce:
+		return ReadProduce(r)
+	case constant.Fetch:
+		return ReadFetch(r, h.GetAPIVersion())
+	case constant.AddPartitionsToTxn:
+		return ReadAddPartitionsToTxn(r)
+	case constant.AddOffsetsToTxn:
+		return ReadAddOffsetsToTxn(r)
+	case constant.EndTxn:
+		return ReadEndTxn(r)
+	case constant.TxnOffsetCommit:
+		return ReadTxnOffsetCommit(r)
+	case constant.WriteTxnMarkers:
+		return ReadWriteTxnMarkers(r)
+	case constant.DescribeProducers:
+		return ReadDescribeProducers(r)
+	case constant.DescribeTransactions:
+		return ReadDescribeTransactions(r)
+	case constant.ListTransactions:
+		return ReadListTransactions(r, h.GetAPIVersion())
+	case constant.DescribeConfigs:
+		return ReadDescribeConfigs(r)
+	case constant.IncrementalAlterConfigs:
+		return ReadIncrementalAlterConfigs(r)
+	case constant.SaslHandshake:
+		return ReadSaslHandshake(r)
+	case constant.SaslAuthenticate:
+		return ReadSaslAuthenticate(r, h.GetAPIVersion())
+	case constant.DescribeUserScramCredentials:
+		return ReadDescribeUserScramCredentials(r)
+	case constant.AlterUserScramCredentials:
+		return ReadAlterUserScramCredentials(r)
+	case constant.CreateAcls:
+		return ReadCreateAcls(r)
+	case constant.DescribeAcls:
+		return ReadDescribeAcls(r)
+	case constant.DeleteAcls:
+		return ReadDeleteAcls(r)
+	case constant.DescribeClientQuotas:
+		return ReadDescribeClientQuotas(r)
+	case constant.AlterClientQuotas:
+		return ReadAlterClientQuotas(r)
+	case constant.CreateTopics:
+		return ReadCreateTopics(r)
+	case constant.DeleteTopics:
+		return ReadDeleteTopics(r)
+	case constant.ListGroups:
+		return ReadListGroups(r)
+	case constant.OffsetCommit:
+		return ReadOffsetCommit(r)
+	case constant.OffsetFetch:
+		return ReadOffsetFetch(r)
+	case constant.ListOffsets:
+		return ReadListOffsets(r)
 	default:
 		return nil, nil
 	}
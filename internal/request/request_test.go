@@ -0,0 +1,292 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types/typestest"
+)
+
+// cat concatenates byte slices.
+func cat(parts ...[]byte) []byte {
+	return slices.Concat(parts...)
+}
+
+// decode parses a request body of the given API and version, turning a
+// panic into an error so that one bad decoder does not hide the others.
+func decode(apiKey, version int16, body []byte) (rb RequestBody, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	h := &RequestHeaderV2{RequestApiKey: apiKey, RequestApiVersion: version}
+	return ParseRequestBody(h, bytes.NewReader(body))
+}
+
+// checkDecodeError fails unless err is a DecodeError of the field at path,
+// wrapping want when it is not nil.
+func checkDecodeError(t *testing.T, err error, path string, want error) {
+	t.Helper()
+	var de *types.DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("error %v is not a decode error", err)
+	}
+	if de.Path != path {
+		t.Errorf("error %q is at %q, want %q", err, de.Path, path)
+	}
+	if want != nil && !errors.Is(err, want) {
+		t.Errorf("error %q, want %q", err, want)
+	}
+}
+
+var (
+	uuid     = make([]byte, 16)
+	metadata = cat(
+		[]byte{0x02},           // one topic
+		uuid,                   // topicId
+		[]byte{0x03, 't', '1'}, // name
+		[]byte{0x00},           // topic tagged fields
+		[]byte{0x01, 0x00},     // allowAutoTopicCreation, includeTopicAuthorizedOperations
+		[]byte{0x00},           // tagged fields
+	)
+	produce = cat(
+		[]byte{0x00},                   // null transactionalId
+		[]byte{0xff, 0xff},             // acks
+		[]byte{0x00, 0x00, 0x03, 0xe8}, // timeoutMs
+		[]byte{0x02, 0x03, 't', '1'},   // one topic named t1
+		[]byte{0x02, 0, 0, 0, 0},       // one partition with index 0
+		[]byte{0x04, 1, 2, 3},          // records
+		[]byte{0x00, 0x00, 0x00},       // partition, topic and request tagged fields
+	)
+	handshake = []byte{0x00, 0x05, 'P', 'L', 'A', 'I', 'N'}
+)
+
+func TestDecodeValid(t *testing.T) {
+	tests := []struct {
+		apiKey, version int16
+		body            []byte
+	}{
+		{constant.Metadata, 12, metadata},
+		{constant.Produce, 9, produce},
+		{constant.SaslHandshake, 1, handshake},
+		{constant.SaslAuthenticate, 0, []byte{0, 0, 0, 1, 'x'}},
+	}
+	for _, tt := range tests {
+		if _, err := decode(tt.apiKey, tt.version, tt.body); err != nil {
+			t.Errorf("%s v%d: %v", constant.ApiKeyNames[tt.apiKey], tt.version, err)
+		}
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	overflow := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+	maxUvarint := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+
+	tests := []struct {
+		name            string
+		apiKey, version int16
+		body            []byte
+		path            string
+		err             error // nil when any error will do
+	}{
+		{"oversized array", constant.Metadata, 12, []byte{0x7f, 0x00}, "topics", types.ErrInvalidLength},
+		{"array length past the int range", constant.Metadata, 12, maxUvarint, "topics", types.ErrInvalidLength},
+		{"varint overflow", constant.Metadata, 12, overflow, "topics", nil},
+		{"varint cut short", constant.Metadata, 12, []byte{0x80}, "topics", types.ErrTruncated},
+		{"empty body", constant.Metadata, 12, nil, "topics", types.ErrTruncated},
+		{"oversized string", constant.Metadata, 12, cat([]byte{0x02}, uuid, []byte{0x10, 't', '1'}), "topics[0].name", types.ErrInvalidLength},
+		{"truncated uuid", constant.Metadata, 12, cat([]byte{0x02}, uuid[:8]), "topics[0].topicId", types.ErrTruncated},
+		{"truncated after the topics", constant.Metadata, 12, metadata[:21], "allowAutoTopicCreation", types.ErrTruncated},
+		{"missing tagged fields", constant.Metadata, 12, metadata[:len(metadata)-1], "taggedFields", types.ErrTruncated},
+		{"trailing byte", constant.Metadata, 12, cat(metadata, []byte{0x00}), "body", types.ErrTrailingBytes},
+		{"tagged fields out of order", constant.Metadata, 12,
+			cat([]byte{0x02}, uuid, []byte{0x03, 't', '1', 0x02, 0x05, 0x00, 0x01, 0x00}), "topics[0].taggedFields", nil},
+		{"oversized tagged field", constant.Metadata, 12,
+			cat([]byte{0x02}, uuid, []byte{0x03, 't', '1', 0x01, 0x00, 0x7f}), "topics[0].taggedFields", types.ErrInvalidLength},
+		{"oversized records", constant.Produce, 9, cat(produce[:16], []byte{0x7f, 1, 2, 3}), "topicData[0].partitionData[0].records", types.ErrInvalidLength},
+		{"null topic name", constant.Produce, 9, cat(produce[:7], []byte{0x02, 0x00}), "topicData[0].name", types.ErrUnexpectedNull},
+		{"negative string length", constant.SaslHandshake, 1, []byte{0xff, 0xfe}, "mechanism", types.ErrInvalidLength},
+		{"oversized string length", constant.SaslHandshake, 1, []byte{0x00, 0x09, 'P'}, "mechanism", types.ErrInvalidLength},
+		{"negative bytes length", constant.SaslAuthenticate, 0, []byte{0xff, 0xff, 0xff, 0xfe}, "authBytes", types.ErrInvalidLength},
+		{"trailing byte after bytes", constant.SaslAuthenticate, 0, []byte{0, 0, 0, 1, 'x', 'y'}, "body", types.ErrTrailingBytes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rb, err := decode(tt.apiKey, tt.version, tt.body)
+			if err == nil {
+				t.Fatalf("decoded %+v, want an error", rb)
+			}
+			checkDecodeError(t, err, tt.path, tt.err)
+		})
+	}
+}
+
+// TestDecodeTruncated cuts valid bodies at every byte: each cut must fail
+// with an error naming a field rather than panic or succeed.
+func TestDecodeTruncated(t *testing.T) {
+	tests := []struct {
+		apiKey, version int16
+		body            []byte
+	}{
+		{constant.Metadata, 12, metadata},
+		{constant.Produce, 9, produce},
+		{constant.SaslHandshake, 1, handshake},
+	}
+	for _, tt := range tests {
+		for n := range len(tt.body) {
+			_, err := decode(tt.apiKey, tt.version, tt.body[:n])
+			if err == nil {
+				t.Errorf("%s v%d cut at %d bytes decoded", constant.ApiKeyNames[tt.apiKey], tt.version, n)
+				continue
+			}
+			var de *types.DecodeError
+			if !errors.As(err, &de) || de.Path == "" {
+				t.Errorf("%s v%d cut at %d bytes: %v does not name a field", constant.ApiKeyNames[tt.apiKey], tt.version, n, err)
+			}
+			if !errors.Is(err, types.ErrTruncated) && !errors.Is(err, types.ErrInvalidLength) {
+				t.Errorf("%s v%d cut at %d bytes: %v, want %v or %v", constant.ApiKeyNames[tt.apiKey], tt.version, n, err, types.ErrTruncated, types.ErrInvalidLength)
+			}
+		}
+	}
+}
+
+func TestReadRequestHeaderMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		path   string
+		err    error
+	}{
+		{"truncated api key", []byte{0x00}, "requestApiKey", types.ErrTruncated},
+		{"truncated correlation id", []byte{0, 3, 0, 12, 0, 0}, "correlationId", types.ErrTruncated},
+		{"negative client id length", []byte{0, 3, 0, 12, 0, 0, 0, 1, 0xff, 0xfe}, "clientId", types.ErrInvalidLength},
+		{"oversized client id", []byte{0, 3, 0, 12, 0, 0, 0, 1, 0x00, 0x08, 'x'}, "clientId", types.ErrInvalidLength},
+		{"missing tagged fields", []byte{0, 3, 0, 12, 0, 0, 0, 1, 0xff, 0xff}, "taggedFields", types.ErrTruncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadRequestHeaderV4(bytes.NewReader(tt.header))
+			if err == nil {
+				t.Fatal("header decoded, want an error")
+			}
+			checkDecodeError(t, err, tt.path, tt.err)
+			if !strings.Contains(err.Error(), tt.path) {
+				t.Errorf("error %q does not name %s", err, tt.path)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		apiKey   int16
+		min, max int16
+		new      func() RequestBody
+		// absent resets the fields a version does not carry to the values
+		// they are decoded with
+		absent func(rb RequestBody, version int16)
+	}{
+		{apiKey: constant.ApiVersions, min: 0, max: 4, new: func() RequestBody { return &ApiVersionsV4{} },
+			absent: func(rb RequestBody, version int16) {
+				if version < 3 {
+					*rb.(*ApiVersionsV4) = ApiVersionsV4{Version: version}
+				}
+			}},
+		{apiKey: constant.Metadata, min: 12, max: 12, new: func() RequestBody { return &MetadataV12{} }},
+		{apiKey: constant.DescribeTopicPartitions, min: 0, max: 0, new: func() RequestBody { return &DescribeTopicPartitionsV0{} }},
+		{apiKey: constant.ConsumerGroupHeartbeat, min: 0, max: 0, new: func() RequestBody { return &ConsumerGroupHeartbeatV0{} }},
+		{apiKey: constant.ConsumerGroupDescribe, min: 0, max: 0, new: func() RequestBody { return &ConsumerGroupDescribeV0{} }},
+		{apiKey: constant.InitProducerId, min: 2, max: 4, new: func() RequestBody { return &InitProducerIdV2{} },
+			absent: func(rb RequestBody, version int16) {
+				if version < 3 {
+					rb.(*InitProducerIdV2).ProducerId, rb.(*InitProducerIdV2).ProducerEpoch = -1, -1
+				}
+			}},
+		{apiKey: constant.Produce, min: 9, max: 11, new: func() RequestBody { return &ProduceV9{} }},
+		{apiKey: constant.Fetch, min: 12, max: 16, new: func() RequestBody { return &FetchV12{} },
+			absent: func(rb RequestBody, version int16) {
+				f := rb.(*FetchV12)
+				if version >= 15 {
+					f.ReplicaId = -1
+				}
+				for i := range f.Topics {
+					if version >= 13 {
+						f.Topics[i].Topic = ""
+					} else {
+						f.Topics[i].TopicId = [16]byte{}
+					}
+				}
+				for i := range f.ForgottenTopicsData {
+					if version >= 13 {
+						f.ForgottenTopicsData[i].Topic = ""
+					} else {
+						f.ForgottenTopicsData[i].TopicId = [16]byte{}
+					}
+				}
+			}},
+		{apiKey: constant.AddPartitionsToTxn, min: 3, max: 3, new: func() RequestBody { return &AddPartitionsToTxnV3{} }},
+		{apiKey: constant.AddOffsetsToTxn, min: 3, max: 3, new: func() RequestBody { return &AddOffsetsToTxnV3{} }},
+		{apiKey: constant.EndTxn, min: 3, max: 4, new: func() RequestBody { return &EndTxnV3{} }},
+		{apiKey: constant.WriteTxnMarkers, min: 1, max: 1, new: func() RequestBody { return &WriteTxnMarkersV1{} }},
+		{apiKey: constant.TxnOffsetCommit, min: 3, max: 3, new: func() RequestBody { return &TxnOffsetCommitV3{} }},
+		{apiKey: constant.DescribeConfigs, min: 4, max: 4, new: func() RequestBody { return &DescribeConfigsV4{} }},
+		{apiKey: constant.IncrementalAlterConfigs, min: 1, max: 1, new: func() RequestBody { return &IncrementalAlterConfigsV1{} }},
+		{apiKey: constant.SaslHandshake, min: 1, max: 1, new: func() RequestBody { return &SaslHandshakeV1{} }},
+		{apiKey: constant.SaslAuthenticate, min: 0, max: 2, new: func() RequestBody { return &SaslAuthenticateV2{} }},
+		{apiKey: constant.DescribeUserScramCredentials, min: 0, max: 0, new: func() RequestBody { return &DescribeUserScramCredentialsV0{} }},
+		{apiKey: constant.AlterUserScramCredentials, min: 0, max: 0, new: func() RequestBody { return &AlterUserScramCredentialsV0{} }},
+		{apiKey: constant.CreateAcls, min: 2, max: 3, new: func() RequestBody { return &CreateAclsV2{} }},
+		{apiKey: constant.DescribeAcls, min: 2, max: 3, new: func() RequestBody { return &DescribeAclsV2{} }},
+		{apiKey: constant.DeleteAcls, min: 2, max: 3, new: func() RequestBody { return &DeleteAclsV2{} }},
+		{apiKey: constant.DescribeClientQuotas, min: 1, max: 1, new: func() RequestBody { return &DescribeClientQuotasV1{} }},
+		{apiKey: constant.AlterClientQuotas, min: 1, max: 1, new: func() RequestBody { return &AlterClientQuotasV1{} }},
+		{apiKey: constant.DescribeProducers, min: 0, max: 0, new: func() RequestBody { return &DescribeProducersV0{} }},
+		{apiKey: constant.DescribeTransactions, min: 0, max: 0, new: func() RequestBody { return &DescribeTransactionsV0{} }},
+		{apiKey: constant.ListTransactions, min: 0, max: 1, new: func() RequestBody { return &ListTransactionsV0{} },
+			absent: func(rb RequestBody, version int16) {
+				if version < 1 {
+					rb.(*ListTransactionsV0).DurationFilter = -1
+				}
+			}},
+		{apiKey: constant.CreateTopics, min: 7, max: 7, new: func() RequestBody { return &CreateTopicsV7{} }},
+		{apiKey: constant.DeleteTopics, min: 6, max: 6, new: func() RequestBody { return &DeleteTopicsV6{} }},
+		{apiKey: constant.ListOffsets, min: 6, max: 8, new: func() RequestBody { return &ListOffsetsV6{} }},
+		{apiKey: constant.ListGroups, min: 5, max: 5, new: func() RequestBody { return &ListGroupsV5{} }},
+		{apiKey: constant.OffsetCommit, min: 9, max: 9, new: func() RequestBody { return &OffsetCommitV9{} }},
+		{apiKey: constant.OffsetFetch, min: 9, max: 9, new: func() RequestBody { return &OffsetFetchV9{} }},
+	}
+	for _, tt := range tests {
+		for version := tt.min; version <= tt.max; version++ {
+			t.Run(fmt.Sprintf("%s v%d", constant.ApiKeyNames[tt.apiKey], version), func(t *testing.T) {
+				want := tt.new()
+				typestest.Populate(want)
+				if f := reflect.ValueOf(want).Elem().FieldByName("Version"); f.IsValid() {
+					f.SetInt(int64(version))
+				}
+				if tt.absent != nil {
+					tt.absent(want, version)
+				}
+
+				var buf bytes.Buffer
+				if err := want.WriteRequestBody(&buf); err != nil {
+					t.Fatal(err)
+				}
+				got, err := decode(tt.apiKey, version, buf.Bytes())
+				if err != nil {
+					t.Fatalf("decoding %x: %v", buf.Bytes(), err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("decoded %+v, want %+v", got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// SaslAuthenticateV2 covers versions 0 to 2; only version 2 is flexible.
+type SaslAuthenticateV2 struct {
+	Version   int16
+	AuthBytes []byte
+	TagBuffer types.TaggedFields
+}
+
+func ReadSaslAuthenticate(r *bytes.Reader, version int16) (*SaslAuthenticateV2, error) {
+	var err error
+	sa := &SaslAuthenticateV2{Version: version}
+	if version < 2 {
+		if sa.AuthBytes, err = types.ReadBytes(r); err != nil {
+			return nil, types.Fieldf(err, "authBytes")
+		}
+		return sa, nil
+	}
+	if sa.AuthBytes, err = types.ReadCompactBytes(r); err != nil {
+		return nil, types.Fieldf(err, "authBytes")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	sa.TagBuffer = *tagBuffer
+	return sa, nil
+}
+
+func (sa *SaslAuthenticateV2) WriteRequestBody(w io.Writer) error {
+	if sa.Version < 2 {
+		return types.WriteBytes(w, sa.AuthBytes)
+	}
+	if err := types.WriteCompactBytes(w, sa.AuthBytes); err != nil {
+		return err
+	}
+	return sa.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,26 @@
+package request
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// SaslHandshakeV1 covers versions 0 and 1. It has no flexible version, so
+// it is sent with a request header without tagged fields.
+type SaslHandshakeV1 struct {
+	Mechanism types.NullableString
+}
+
+func ReadSaslHandshake(r *bytes.Reader) (*SaslHandshakeV1, error) {
+	mechanism, err := types.ReadNullableString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "mechanism")
+	}
+	return &SaslHandshakeV1{Mechanism: *mechanism}, nil
+}
+
+func (sh *SaslHandshakeV1) WriteRequestBody(w io.Writer) error {
+	return sh.Mechanism.WriteNullableString(w)
+}
@@ -0,0 +1,449 @@
+package request
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type TxnTopic struct {
+	Name       types.CompactString
+	Partitions []int32
+	TagBuffer  types.TaggedFields
+}
+
+func ReadTxnTopics(r *bytes.Reader) ([]TxnTopic, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, err
+	}
+	topics := make([]TxnTopic, max(n, 0))
+	for i := range topics {
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].name", i)
+		}
+		topics[i].Name = *name
+		if topics[i].Partitions, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "[%d].partitions", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].taggedFields", i)
+		}
+		topics[i].TagBuffer = *tagBuffer
+	}
+	return topics, nil
+}
+
+func WriteTxnTopics(w io.Writer, topics []TxnTopic) error {
+	if err := types.WriteCompactArrayLength(w, len(topics)); err != nil {
+		return err
+	}
+	for _, t := range topics {
+		if err := t.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactInt32Array(w, t.Partitions); err != nil {
+			return err
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddPartitionsToTxnV3 is the last version sent by clients; version 4
+// batches several transactions for brokers.
+type AddPartitionsToTxnV3 struct {
+	TransactionalId types.CompactString
+	ProducerId      int64
+	ProducerEpoch   int16
+	Topics          []TxnTopic
+	TagBuffer       types.TaggedFields
+}
+
+func ReadAddPartitionsToTxn(r *bytes.Reader) (*AddPartitionsToTxnV3, error) {
+	a := &AddPartitionsToTxnV3{}
+	transactionalId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionalId")
+	}
+	a.TransactionalId = *transactionalId
+	if err = binary.Read(r, binary.BigEndian, &a.ProducerId); err != nil {
+		return nil, types.Fieldf(err, "producerId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &a.ProducerEpoch); err != nil {
+		return nil, types.Fieldf(err, "producerEpoch")
+	}
+	if a.Topics, err = ReadTxnTopics(r); err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (a *AddPartitionsToTxnV3) WriteRequestBody(w io.Writer) error {
+	if err := a.TransactionalId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, a.ProducerId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, a.ProducerEpoch); err != nil {
+		return err
+	}
+	if err := WriteTxnTopics(w, a.Topics); err != nil {
+		return err
+	}
+	return a.TagBuffer.WriteTaggedFields(w)
+}
+
+type AddOffsetsToTxnV3 struct {
+	TransactionalId types.CompactString
+	ProducerId      int64
+	ProducerEpoch   int16
+	GroupId         types.CompactString
+	TagBuffer       types.TaggedFields
+}
+
+func ReadAddOffsetsToTxn(r *bytes.Reader) (*AddOffsetsToTxnV3, error) {
+	a := &AddOffsetsToTxnV3{}
+	transactionalId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionalId")
+	}
+	a.TransactionalId = *transactionalId
+	if err = binary.Read(r, binary.BigEndian, &a.ProducerId); err != nil {
+		return nil, types.Fieldf(err, "producerId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &a.ProducerEpoch); err != nil {
+		return nil, types.Fieldf(err, "producerEpoch")
+	}
+	groupId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groupId")
+	}
+	a.GroupId = *groupId
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (a *AddOffsetsToTxnV3) WriteRequestBody(w io.Writer) error {
+	if err := a.TransactionalId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, a.ProducerId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, a.ProducerEpoch); err != nil {
+		return err
+	}
+	if err := a.GroupId.WriteCompactString(w); err != nil {
+		return err
+	}
+	return a.TagBuffer.WriteTaggedFields(w)
+}
+
+// EndTxnV3 covers versions 3 and 4.
+type EndTxnV3 struct {
+	TransactionalId types.CompactString
+	ProducerId      int64
+	ProducerEpoch   int16
+	Committed       bool
+	TagBuffer       types.TaggedFields
+}
+
+func ReadEndTxn(r *bytes.Reader) (*EndTxnV3, error) {
+	e := &EndTxnV3{}
+	transactionalId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionalId")
+	}
+	e.TransactionalId = *transactionalId
+	if err = binary.Read(r, binary.BigEndian, &e.ProducerId); err != nil {
+		return nil, types.Fieldf(err, "producerId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &e.ProducerEpoch); err != nil {
+		return nil, types.Fieldf(err, "producerEpoch")
+	}
+	if e.Committed, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "committed")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	e.TagBuffer = *tagBuffer
+	return e, nil
+}
+
+func (e *EndTxnV3) WriteRequestBody(w io.Writer) error {
+	if err := e.TransactionalId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, e.ProducerId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, e.ProducerEpoch); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, e.Committed); err != nil {
+		return err
+	}
+	return e.TagBuffer.WriteTaggedFields(w)
+}
+
+type TxnOffsetCommitV3 struct {
+	TransactionalId types.CompactString
+	GroupId         types.CompactString
+	ProducerId      int64
+	ProducerEpoch   int16
+	GenerationId    int32
+	MemberId        types.CompactString
+	GroupInstanceId *types.CompactString
+	Topics          []TxnOffsetCommitTopic
+	TagBuffer       types.TaggedFields
+}
+
+type TxnOffsetCommitTopic struct {
+	Name       types.CompactString
+	Partitions []TxnOffsetCommitPartition
+	TagBuffer  types.TaggedFields
+}
+
+type TxnOffsetCommitPartition struct {
+	PartitionIndex       int32
+	CommittedOffset      int64
+	CommittedLeaderEpoch int32
+	CommittedMetadata    *types.CompactString
+	TagBuffer            types.TaggedFields
+}
+
+func ReadTxnOffsetCommit(r *bytes.Reader) (*TxnOffsetCommitV3, error) {
+	t := &TxnOffsetCommitV3{}
+	transactionalId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionalId")
+	}
+	t.TransactionalId = *transactionalId
+	groupId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groupId")
+	}
+	t.GroupId = *groupId
+	if err = binary.Read(r, binary.BigEndian, &t.ProducerId); err != nil {
+		return nil, types.Fieldf(err, "producerId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &t.ProducerEpoch); err != nil {
+		return nil, types.Fieldf(err, "producerEpoch")
+	}
+	if err = binary.Read(r, binary.BigEndian, &t.GenerationId); err != nil {
+		return nil, types.Fieldf(err, "generationId")
+	}
+	memberId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "memberId")
+	}
+	t.MemberId = *memberId
+	if t.GroupInstanceId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "groupInstanceId")
+	}
+
+	numTopics, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	t.Topics = make([]TxnOffsetCommitTopic, max(numTopics, 0))
+	for i := range t.Topics {
+		topic := &t.Topics[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		topic.Name = *name
+		numPartitions, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		topic.Partitions = make([]TxnOffsetCommitPartition, max(numPartitions, 0))
+		for j := range topic.Partitions {
+			p := &topic.Partitions[j]
+			if err = binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].partitionIndex", i, j)
+			}
+			if err = binary.Read(r, binary.BigEndian, &p.CommittedOffset); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedOffset", i, j)
+			}
+			if err = binary.Read(r, binary.BigEndian, &p.CommittedLeaderEpoch); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedLeaderEpoch", i, j)
+			}
+			if p.CommittedMetadata, err = types.ReadCompactNullableString(r); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedMetadata", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].taggedFields", i, j)
+			}
+			p.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		topic.TagBuffer = *tagBuffer
+	}
+
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	t.TagBuffer = *tagBuffer
+	return t, nil
+}
+
+func (t *TxnOffsetCommitV3) WriteRequestBody(w io.Writer) error {
+	if err := t.TransactionalId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := t.GroupId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.ProducerId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.ProducerEpoch); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.GenerationId); err != nil {
+		return err
+	}
+	if err := t.MemberId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, t.GroupInstanceId); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(t.Topics)); err != nil {
+		return err
+	}
+	for _, topic := range t.Topics {
+		if err := topic.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(topic.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range topic.Partitions {
+			if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CommittedOffset); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CommittedLeaderEpoch); err != nil {
+				return err
+			}
+			if err := types.WriteCompactNullableString(w, p.CommittedMetadata); err != nil {
+				return err
+			}
+			if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := topic.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return t.TagBuffer.WriteTaggedFields(w)
+}
+
+type WriteTxnMarkersV1 struct {
+	Markers   []TxnMarker
+	TagBuffer types.TaggedFields
+}
+
+type TxnMarker struct {
+	ProducerId        int64
+	ProducerEpoch     int16
+	TransactionResult bool
+	Topics            []TxnTopic
+	CoordinatorEpoch  int32
+	TagBuffer         types.TaggedFields
+}
+
+func ReadWriteTxnMarkers(r *bytes.Reader) (*WriteTxnMarkersV1, error) {
+	wm := &WriteTxnMarkersV1{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "markers")
+	}
+	wm.Markers = make([]TxnMarker, max(n, 0))
+	for i := range wm.Markers {
+		m := &wm.Markers[i]
+		if err = binary.Read(r, binary.BigEndian, &m.ProducerId); err != nil {
+			return nil, types.Fieldf(err, "markers[%d].producerId", i)
+		}
+		if err = binary.Read(r, binary.BigEndian, &m.ProducerEpoch); err != nil {
+			return nil, types.Fieldf(err, "markers[%d].producerEpoch", i)
+		}
+		if m.TransactionResult, err = types.ReadBool(r); err != nil {
+			return nil, types.Fieldf(err, "markers[%d].transactionResult", i)
+		}
+		if m.Topics, err = ReadTxnTopics(r); err != nil {
+			return nil, types.Fieldf(err, "markers[%d].topics", i)
+		}
+		if err = binary.Read(r, binary.BigEndian, &m.CoordinatorEpoch); err != nil {
+			return nil, types.Fieldf(err, "markers[%d].coordinatorEpoch", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "markers[%d].taggedFields", i)
+		}
+		m.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	wm.TagBuffer = *tagBuffer
+	return wm, nil
+}
+
+func (wm *WriteTxnMarkersV1) WriteRequestBody(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(wm.Markers)); err != nil {
+		return err
+	}
+	for _, m := range wm.Markers {
+		if err := binary.Write(w, binary.BigEndian, m.ProducerId); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, m.ProducerEpoch); err != nil {
+			return err
+		}
+		if err := types.WriteBool(w, m.TransactionResult); err != nil {
+			return err
+		}
+		if err := WriteTxnTopics(w, m.Topics); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, m.CoordinatorEpoch); err != nil {
+			return err
+		}
+		if err := m.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return wm.TagBuffer.WriteTaggedFields(w)
+}
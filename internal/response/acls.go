@@ -0,0 +1,420 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type CreateAclsV2 struct {
+	ThrottleTime int32
+	Results      []AclCreationResult
+	TagBuffer    types.TaggedFields
+}
+
+type AclCreationResult struct {
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	TagBuffer    types.TaggedFields
+}
+
+func ReadCreateAcls(r *bytes.Reader) (*CreateAclsV2, error) {
+	c := &CreateAclsV2{}
+	if err := binary.Read(r, binary.BigEndian, &c.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "results")
+	}
+	c.Results = make([]AclCreationResult, max(n, 0))
+	for i := range c.Results {
+		res := &c.Results[i]
+		if err := binary.Read(r, binary.BigEndian, &res.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "results[%d].errorCode", i)
+		}
+		if res.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "results[%d].errorMessage", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "results[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	c.TagBuffer = *tagBuffer
+	return c, nil
+}
+
+func (r *CreateAclsV2) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Results)); err != nil {
+		return err
+	}
+	for _, res := range r.Results {
+		if err := binary.Write(w, binary.BigEndian, res.ErrorCode); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, res.ErrorMessage); err != nil {
+			return err
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *CreateAclsV2) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *CreateAclsV2) ErrorCodes() []int16 {
+	var codes []int16
+	for _, res := range r.Results {
+		codes = appendError(codes, res.ErrorCode)
+	}
+	return codes
+}
+
+type DescribeAclsV2 struct {
+	ThrottleTime int32
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	Resources    []DescribeAclsResource
+	TagBuffer    types.TaggedFields
+}
+
+type DescribeAclsResource struct {
+	ResourceType int8
+	ResourceName types.CompactString
+	PatternType  int8
+	Acls         []AclDescription
+	TagBuffer    types.TaggedFields
+}
+
+type AclDescription struct {
+	Principal      types.CompactString
+	Host           types.CompactString
+	Operation      int8
+	PermissionType int8
+	TagBuffer      types.TaggedFields
+}
+
+func ReadDescribeAcls(r *bytes.Reader) (*DescribeAclsV2, error) {
+	d := &DescribeAclsV2{}
+	if err := binary.Read(r, binary.BigEndian, &d.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err := binary.Read(r, binary.BigEndian, &d.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	var err error
+	if d.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "resources")
+	}
+	d.Resources = make([]DescribeAclsResource, max(n, 0))
+	for i := range d.Resources {
+		res := &d.Resources[i]
+		if err := binary.Read(r, binary.BigEndian, &res.ResourceType); err != nil {
+			return nil, types.Fieldf(err, "resources[%d].resourceType", i)
+		}
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].resourceName", i)
+		}
+		res.ResourceName = *name
+		if err := binary.Read(r, binary.BigEndian, &res.PatternType); err != nil {
+			return nil, types.Fieldf(err, "resources[%d].patternType", i)
+		}
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].acls", i)
+		}
+		res.Acls = make([]AclDescription, max(n, 0))
+		for j := range res.Acls {
+			a := &res.Acls[j]
+			principal, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "resources[%d].acls[%d].principal", i, j)
+			}
+			a.Principal = *principal
+			host, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "resources[%d].acls[%d].host", i, j)
+			}
+			a.Host = *host
+			if err := binary.Read(r, binary.BigEndian, &a.Operation); err != nil {
+				return nil, types.Fieldf(err, "resources[%d].acls[%d].operation", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &a.PermissionType); err != nil {
+				return nil, types.Fieldf(err, "resources[%d].acls[%d].permissionType", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "resources[%d].acls[%d].taggedFields", i, j)
+			}
+			a.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "resources[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (r *DescribeAclsV2) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Resources)); err != nil {
+		return err
+	}
+	for _, res := range r.Resources {
+		if err := binary.Write(w, binary.BigEndian, res.ResourceType); err != nil {
+			return err
+		}
+		if err := res.ResourceName.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, res.PatternType); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(res.Acls)); err != nil {
+			return err
+		}
+		for _, a := range res.Acls {
+			if err := a.Principal.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := a.Host.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, a.Operation); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, a.PermissionType); err != nil {
+				return err
+			}
+			if err := a.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DescribeAclsV2) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DescribeAclsV2) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *DescribeAclsV2) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
+
+type DeleteAclsV2 struct {
+	ThrottleTime  int32
+	FilterResults []DeleteAclsFilterResult
+	TagBuffer     types.TaggedFields
+}
+
+type DeleteAclsFilterResult struct {
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	MatchingAcls []DeleteAclsMatchingAcl
+	TagBuffer    types.TaggedFields
+}
+
+type DeleteAclsMatchingAcl struct {
+	ErrorCode      int16
+	ErrorMessage   *types.CompactString
+	ResourceType   int8
+	ResourceName   types.CompactString
+	PatternType    int8
+	Principal      types.CompactString
+	Host           types.CompactString
+	Operation      int8
+	PermissionType int8
+	TagBuffer      types.TaggedFields
+}
+
+func ReadDeleteAcls(r *bytes.Reader) (*DeleteAclsV2, error) {
+	d := &DeleteAclsV2{}
+	if err := binary.Read(r, binary.BigEndian, &d.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "filterResults")
+	}
+	d.FilterResults = make([]DeleteAclsFilterResult, max(n, 0))
+	for i := range d.FilterResults {
+		fr := &d.FilterResults[i]
+		if err := binary.Read(r, binary.BigEndian, &fr.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "filterResults[%d].errorCode", i)
+		}
+		if fr.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "filterResults[%d].errorMessage", i)
+		}
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "filterResults[%d].matchingAcls", i)
+		}
+		fr.MatchingAcls = make([]DeleteAclsMatchingAcl, max(n, 0))
+		for j := range fr.MatchingAcls {
+			m := &fr.MatchingAcls[j]
+			if err := binary.Read(r, binary.BigEndian, &m.ErrorCode); err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].errorCode", i, j)
+			}
+			if m.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].errorMessage", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &m.ResourceType); err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].resourceType", i, j)
+			}
+			name, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].resourceName", i, j)
+			}
+			m.ResourceName = *name
+			if err := binary.Read(r, binary.BigEndian, &m.PatternType); err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].patternType", i, j)
+			}
+			principal, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].principal", i, j)
+			}
+			m.Principal = *principal
+			host, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].host", i, j)
+			}
+			m.Host = *host
+			if err := binary.Read(r, binary.BigEndian, &m.Operation); err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].operation", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &m.PermissionType); err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].permissionType", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "filterResults[%d].matchingAcls[%d].taggedFields", i, j)
+			}
+			m.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "filterResults[%d].taggedFields", i)
+		}
+		fr.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (r *DeleteAclsV2) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.FilterResults)); err != nil {
+		return err
+	}
+	for _, fr := range r.FilterResults {
+		if err := binary.Write(w, binary.BigEndian, fr.ErrorCode); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, fr.ErrorMessage); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(fr.MatchingAcls)); err != nil {
+			return err
+		}
+		for _, m := range fr.MatchingAcls {
+			if err := binary.Write(w, binary.BigEndian, m.ErrorCode); err != nil {
+				return err
+			}
+			if err := types.WriteCompactNullableString(w, m.ErrorMessage); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, m.ResourceType); err != nil {
+				return err
+			}
+			if err := m.ResourceName.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, m.PatternType); err != nil {
+				return err
+			}
+			if err := m.Principal.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := m.Host.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, m.Operation); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, m.PermissionType); err != nil {
+				return err
+			}
+			if err := m.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := fr.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DeleteAclsV2) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DeleteAclsV2) ErrorCodes() []int16 {
+	var codes []int16
+	for _, fr := range r.FilterResults {
+		codes = appendError(codes, fr.ErrorCode)
+		for _, m := range fr.MatchingAcls {
+			codes = appendError(codes, m.ErrorCode)
+		}
+	}
+	return codes
+}
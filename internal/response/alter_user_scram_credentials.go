@@ -0,0 +1,95 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type AlterUserScramCredentialsV0 struct {
+	ThrottleTime int32
+	Results      []AlterUserScramCredentialsResult
+	TagBuffer    types.TaggedFields
+}
+
+type AlterUserScramCredentialsResult struct {
+	User         types.CompactString
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	TagBuffer    types.TaggedFields
+}
+
+func ReadAlterUserScramCredentials(r *bytes.Reader) (*AlterUserScramCredentialsV0, error) {
+	a := &AlterUserScramCredentialsV0{}
+	if err := binary.Read(r, binary.BigEndian, &a.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "results")
+	}
+	a.Results = make([]AlterUserScramCredentialsResult, max(n, 0))
+	for i := range a.Results {
+		res := &a.Results[i]
+		user, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "results[%d].user", i)
+		}
+		res.User = *user
+		if err := binary.Read(r, binary.BigEndian, &res.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "results[%d].errorCode", i)
+		}
+		if res.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "results[%d].errorMessage", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "results[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (r *AlterUserScramCredentialsV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Results)); err != nil {
+		return err
+	}
+	for _, res := range r.Results {
+		if err := res.User.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, res.ErrorCode); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, res.ErrorMessage); err != nil {
+			return err
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *AlterUserScramCredentialsV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *AlterUserScramCredentialsV0) ErrorCodes() []int16 {
+	var codes []int16
+	for _, res := range r.Results {
+		codes = appendError(codes, res.ErrorCode)
+	}
+	return codes
+}
@@ -0,0 +1,276 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeClientQuotasV1 struct {
+	ThrottleTime int32
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	Entries      []QuotaEntry // nil on error
+	TagBuffer    types.TaggedFields
+}
+
+type QuotaEntry struct {
+	Entity    []QuotaEntity
+	Values    []QuotaValue
+	TagBuffer types.TaggedFields
+}
+
+// QuotaEntity is one component of a quota entity; a nil EntityName stands
+// for the default entity of its type.
+type QuotaEntity struct {
+	EntityType types.CompactString
+	EntityName *types.CompactString
+	TagBuffer  types.TaggedFields
+}
+
+type QuotaValue struct {
+	Key       types.CompactString
+	Value     float64
+	TagBuffer types.TaggedFields
+}
+
+func readQuotaEntity(r *bytes.Reader) ([]QuotaEntity, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, err
+	}
+	entity := make([]QuotaEntity, max(n, 0))
+	for i := range entity {
+		entityType, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].entityType", i)
+		}
+		entity[i].EntityType = *entityType
+		if entity[i].EntityName, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "[%d].entityName", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].taggedFields", i)
+		}
+		entity[i].TagBuffer = *tagBuffer
+	}
+	return entity, nil
+}
+
+func writeQuotaEntity(w io.Writer, entity []QuotaEntity) error {
+	if err := types.WriteCompactArrayLength(w, len(entity)); err != nil {
+		return err
+	}
+	for _, e := range entity {
+		if err := e.EntityType.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, e.EntityName); err != nil {
+			return err
+		}
+		if err := e.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ReadDescribeClientQuotas(r *bytes.Reader) (*DescribeClientQuotasV1, error) {
+	d := &DescribeClientQuotasV1{}
+	if err := binary.Read(r, binary.BigEndian, &d.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err := binary.Read(r, binary.BigEndian, &d.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	var err error
+	if d.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "entries")
+	}
+	if n >= 0 {
+		d.Entries = make([]QuotaEntry, n)
+	}
+	for i := range d.Entries {
+		e := &d.Entries[i]
+		if e.Entity, err = readQuotaEntity(r); err != nil {
+			return nil, types.Fieldf(err, "entries[%d].entity", i)
+		}
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "entries[%d].values", i)
+		}
+		e.Values = make([]QuotaValue, max(n, 0))
+		for j := range e.Values {
+			v := &e.Values[j]
+			key, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "entries[%d].values[%d].key", i, j)
+			}
+			v.Key = *key
+			if err := binary.Read(r, binary.BigEndian, &v.Value); err != nil {
+				return nil, types.Fieldf(err, "entries[%d].values[%d].value", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "entries[%d].values[%d].taggedFields", i, j)
+			}
+			v.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "entries[%d].taggedFields", i)
+		}
+		e.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (r *DescribeClientQuotasV1) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, r.ErrorMessage); err != nil {
+		return err
+	}
+	n := len(r.Entries)
+	if r.Entries == nil {
+		n = -1
+	}
+	if err := types.WriteCompactArrayLength(w, n); err != nil {
+		return err
+	}
+	for _, e := range r.Entries {
+		if err := writeQuotaEntity(w, e.Entity); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(e.Values)); err != nil {
+			return err
+		}
+		for _, v := range e.Values {
+			if err := v.Key.WriteCompactString(w); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, v.Value); err != nil {
+				return err
+			}
+			if err := v.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := e.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DescribeClientQuotasV1) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DescribeClientQuotasV1) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *DescribeClientQuotasV1) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
+
+type AlterClientQuotasV1 struct {
+	ThrottleTime int32
+	Entries      []AlterClientQuotasEntry
+	TagBuffer    types.TaggedFields
+}
+
+type AlterClientQuotasEntry struct {
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	Entity       []QuotaEntity
+	TagBuffer    types.TaggedFields
+}
+
+func ReadAlterClientQuotas(r *bytes.Reader) (*AlterClientQuotasV1, error) {
+	a := &AlterClientQuotasV1{}
+	if err := binary.Read(r, binary.BigEndian, &a.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "entries")
+	}
+	a.Entries = make([]AlterClientQuotasEntry, max(n, 0))
+	for i := range a.Entries {
+		e := &a.Entries[i]
+		if err := binary.Read(r, binary.BigEndian, &e.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "entries[%d].errorCode", i)
+		}
+		if e.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "entries[%d].errorMessage", i)
+		}
+		if e.Entity, err = readQuotaEntity(r); err != nil {
+			return nil, types.Fieldf(err, "entries[%d].entity", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "entries[%d].taggedFields", i)
+		}
+		e.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (r *AlterClientQuotasV1) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Entries)); err != nil {
+		return err
+	}
+	for _, e := range r.Entries {
+		if err := binary.Write(w, binary.BigEndian, e.ErrorCode); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, e.ErrorMessage); err != nil {
+			return err
+		}
+		if err := writeQuotaEntity(w, e.Entity); err != nil {
+			return err
+		}
+		if err := e.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *AlterClientQuotasV1) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *AlterClientQuotasV1) ErrorCodes() []int16 {
+	var codes []int16
+	for _, e := range r.Entries {
+		codes = appendError(codes, e.ErrorCode)
+	}
+	return codes
+}
@@ -0,0 +1,348 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ConsumerGroupDescribeV0 struct {
+	ThrottleTime int32
+	Groups       []DescribedGroup
+	TagBuffer    types.TaggedFields
+}
+
+func ReadConsumerGroupDescribe(r *bytes.Reader) (*ConsumerGroupDescribeV0, error) {
+	c := &ConsumerGroupDescribeV0{}
+	if err := binary.Read(r, binary.BigEndian, &c.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groups")
+	}
+	c.Groups = make([]DescribedGroup, max(n, 0))
+	for i := range c.Groups {
+		group, err := ReadDescribedGroup(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d]", i)
+		}
+		c.Groups[i] = *group
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	c.TagBuffer = *tagBuffer
+	return c, nil
+}
+
+func (r *ConsumerGroupDescribeV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Groups)); err != nil {
+		return err
+	}
+	for _, group := range r.Groups {
+		if err := group.Write(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *ConsumerGroupDescribeV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *ConsumerGroupDescribeV0) ErrorCodes() []int16 {
+	var codes []int16
+	for _, g := range r.Groups {
+		codes = appendError(codes, g.ErrorCode)
+	}
+	return codes
+}
+
+type DescribedGroup struct {
+	ErrorCode            int16
+	ErrorMessage         *types.CompactString
+	GroupId              types.CompactString
+	GroupState           types.CompactString
+	GroupEpoch           int32
+	AssignmentEpoch      int32
+	AssignorName         types.CompactString
+	Members              []Member
+	AuthorizedOperations int32
+	TagBuffer            types.TaggedFields
+}
+
+func ReadDescribedGroup(r *bytes.Reader) (*DescribedGroup, error) {
+	g := &DescribedGroup{}
+	var err error
+	if err = binary.Read(r, binary.BigEndian, &g.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if g.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	groupId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groupId")
+	}
+	g.GroupId = *groupId
+	groupState, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groupState")
+	}
+	g.GroupState = *groupState
+	if err = binary.Read(r, binary.BigEndian, &g.GroupEpoch); err != nil {
+		return nil, types.Fieldf(err, "groupEpoch")
+	}
+	if err = binary.Read(r, binary.BigEndian, &g.AssignmentEpoch); err != nil {
+		return nil, types.Fieldf(err, "assignmentEpoch")
+	}
+	assignorName, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "assignorName")
+	}
+	g.AssignorName = *assignorName
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "members")
+	}
+	g.Members = make([]Member, max(n, 0))
+	for i := range g.Members {
+		member, err := ReadMember(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "members[%d]", i)
+		}
+		g.Members[i] = *member
+	}
+	if err = binary.Read(r, binary.BigEndian, &g.AuthorizedOperations); err != nil {
+		return nil, types.Fieldf(err, "authorizedOperations")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	g.TagBuffer = *tagBuffer
+	return g, nil
+}
+
+func (g *DescribedGroup) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, g.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, g.ErrorMessage); err != nil {
+		return err
+	}
+	if err := g.GroupId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := g.GroupState.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, g.GroupEpoch); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, g.AssignmentEpoch); err != nil {
+		return err
+	}
+	if err := g.AssignorName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(g.Members)); err != nil {
+		return err
+	}
+	for _, member := range g.Members {
+		if err := member.Write(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, g.AuthorizedOperations); err != nil {
+		return err
+	}
+	return g.TagBuffer.WriteTaggedFields(w)
+}
+
+type Member struct {
+	MemberId             types.CompactString
+	InstanceId           *types.CompactString
+	RackId               *types.CompactString
+	MemberEpoch          int32
+	ClientId             types.CompactString
+	ClientHost           types.CompactString
+	SubscribedTopicNames []types.CompactString
+	SubscribedTopicRegex *types.CompactString
+	Assignment           MemberAssignment
+	TargetAssignment     MemberAssignment
+	TagBuffer            types.TaggedFields
+}
+
+func ReadMember(r *bytes.Reader) (*Member, error) {
+	m := &Member{}
+	memberId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "memberId")
+	}
+	m.MemberId = *memberId
+	if m.InstanceId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "instanceId")
+	}
+	if m.RackId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "rackId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &m.MemberEpoch); err != nil {
+		return nil, types.Fieldf(err, "memberEpoch")
+	}
+	clientId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "clientId")
+	}
+	m.ClientId = *clientId
+	clientHost, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "clientHost")
+	}
+	m.ClientHost = *clientHost
+	if m.SubscribedTopicNames, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "subscribedTopicNames")
+	}
+	if m.SubscribedTopicRegex, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "subscribedTopicRegex")
+	}
+	assignment, err := ReadMemberAssignment(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "assignment")
+	}
+	m.Assignment = *assignment
+	targetAssignment, err := ReadMemberAssignment(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "targetAssignment")
+	}
+	m.TargetAssignment = *targetAssignment
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	m.TagBuffer = *tagBuffer
+	return m, nil
+}
+
+func (m *Member) Write(w io.Writer) error {
+	if err := m.MemberId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, m.InstanceId); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, m.RackId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, m.MemberEpoch); err != nil {
+		return err
+	}
+	if err := m.ClientId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := m.ClientHost.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactStringArray(w, m.SubscribedTopicNames); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, m.SubscribedTopicRegex); err != nil {
+		return err
+	}
+	if err := m.Assignment.Write(w); err != nil {
+		return err
+	}
+	if err := m.TargetAssignment.Write(w); err != nil {
+		return err
+	}
+	return m.TagBuffer.WriteTaggedFields(w)
+}
+
+// MemberAssignment differs from Assignment in that it also carries topic names.
+type MemberAssignment struct {
+	TopicPartitions []NamedTopicPartitions
+	TagBuffer       types.TaggedFields
+}
+
+func ReadMemberAssignment(r *bytes.Reader) (*MemberAssignment, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topicPartitions")
+	}
+	a := &MemberAssignment{TopicPartitions: make([]NamedTopicPartitions, max(n, 0))}
+	for i := range a.TopicPartitions {
+		tp, err := ReadNamedTopicPartitions(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topicPartitions[%d]", i)
+		}
+		a.TopicPartitions[i] = *tp
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (a *MemberAssignment) Write(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(a.TopicPartitions)); err != nil {
+		return err
+	}
+	for _, tp := range a.TopicPartitions {
+		if err := tp.Write(w); err != nil {
+			return err
+		}
+	}
+	return a.TagBuffer.WriteTaggedFields(w)
+}
+
+type NamedTopicPartitions struct {
+	TopicId    [16]byte
+	TopicName  types.CompactString
+	Partitions []int32
+	TagBuffer  types.TaggedFields
+}
+
+func ReadNamedTopicPartitions(r *bytes.Reader) (*NamedTopicPartitions, error) {
+	var err error
+	tp := &NamedTopicPartitions{}
+	if tp.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, types.Fieldf(err, "topicId")
+	}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topicName")
+	}
+	tp.TopicName = *name
+	if tp.Partitions, err = types.ReadCompactInt32Array(r); err != nil {
+		return nil, types.Fieldf(err, "partitions")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	tp.TagBuffer = *tagBuffer
+	return tp, nil
+}
+
+func (tp *NamedTopicPartitions) Write(w io.Writer) error {
+	if _, err := w.Write(tp.TopicId[:]); err != nil {
+		return err
+	}
+	if err := tp.TopicName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactInt32Array(w, tp.Partitions); err != nil {
+		return err
+	}
+	return tp.TagBuffer.WriteTaggedFields(w)
+}
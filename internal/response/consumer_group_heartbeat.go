@@ -0,0 +1,176 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ConsumerGroupHeartbeatV0 struct {
+	ThrottleTime        int32
+	ErrorCode           int16
+	ErrorMessage        *types.CompactString
+	MemberId            *types.CompactString
+	MemberEpoch         int32
+	HeartbeatIntervalMs int32
+	Assignment          *Assignment // nil when the assignment did not change
+	TagBuffer           types.TaggedFields
+}
+
+func ReadConsumerGroupHeartbeat(r *bytes.Reader) (*ConsumerGroupHeartbeatV0, error) {
+	var err error
+	hb := &ConsumerGroupHeartbeatV0{}
+	if err = binary.Read(r, binary.BigEndian, &hb.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err = binary.Read(r, binary.BigEndian, &hb.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if hb.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	if hb.MemberId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "memberId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &hb.MemberEpoch); err != nil {
+		return nil, types.Fieldf(err, "memberEpoch")
+	}
+	if err = binary.Read(r, binary.BigEndian, &hb.HeartbeatIntervalMs); err != nil {
+		return nil, types.Fieldf(err, "heartbeatIntervalMs")
+	}
+	var present int8
+	if err = binary.Read(r, binary.BigEndian, &present); err != nil {
+		return nil, types.Fieldf(err, "assignment")
+	}
+	if present >= 0 {
+		if hb.Assignment, err = ReadAssignment(r); err != nil {
+			return nil, types.Fieldf(err, "assignment")
+		}
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	hb.TagBuffer = *tagBuffer
+	return hb, nil
+}
+
+func (r *ConsumerGroupHeartbeatV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, r.MemberId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.MemberEpoch); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.HeartbeatIntervalMs); err != nil {
+		return err
+	}
+	// nullable struct: -1 marks null, 1 marks present
+	if r.Assignment == nil {
+		if err := binary.Write(w, binary.BigEndian, int8(-1)); err != nil {
+			return err
+		}
+	} else {
+		if err := binary.Write(w, binary.BigEndian, int8(1)); err != nil {
+			return err
+		}
+		if err := r.Assignment.Write(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *ConsumerGroupHeartbeatV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *ConsumerGroupHeartbeatV0) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *ConsumerGroupHeartbeatV0) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
+
+type Assignment struct {
+	TopicPartitions []TopicPartitions
+	TagBuffer       types.TaggedFields
+}
+
+func ReadAssignment(r *bytes.Reader) (*Assignment, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topicPartitions")
+	}
+	a := &Assignment{TopicPartitions: make([]TopicPartitions, max(n, 0))}
+	for i := range a.TopicPartitions {
+		tp, err := ReadTopicPartitions(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topicPartitions[%d]", i)
+		}
+		a.TopicPartitions[i] = *tp
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (a *Assignment) Write(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(a.TopicPartitions)); err != nil {
+		return err
+	}
+	for _, tp := range a.TopicPartitions {
+		if err := tp.Write(w); err != nil {
+			return err
+		}
+	}
+	return a.TagBuffer.WriteTaggedFields(w)
+}
+
+type TopicPartitions struct {
+	TopicId    [16]byte
+	Partitions []int32
+	TagBuffer  types.TaggedFields
+}
+
+func ReadTopicPartitions(r *bytes.Reader) (*TopicPartitions, error) {
+	var err error
+	tp := &TopicPartitions{}
+	if tp.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, types.Fieldf(err, "topicId")
+	}
+	if tp.Partitions, err = types.ReadCompactInt32Array(r); err != nil {
+		return nil, types.Fieldf(err, "partitions")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	tp.TagBuffer = *tagBuffer
+	return tp, nil
+}
+
+func (tp *TopicPartitions) Write(w io.Writer) error {
+	if _, err := w.Write(tp.TopicId[:]); err != nil {
+		return err
+	}
+	if err := types.WriteCompactInt32Array(w, tp.Partitions); err != nil {
+		return err
+	}
+	return tp.TagBuffer.WriteTaggedFields(w)
+}
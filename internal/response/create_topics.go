@@ -0,0 +1,199 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type CreateTopicsV7 struct {
+	ThrottleTime int32
+	Topics       []CreatableTopicResult
+	TagBuffer    types.TaggedFields
+}
+
+// CreatableTopicResult has null Configs when the topic was not created.
+type CreatableTopicResult struct {
+	Name              types.CompactString
+	TopicId           [16]byte
+	ErrorCode         int16
+	ErrorMessage      *types.CompactString
+	NumPartitions     int32
+	ReplicationFactor int16
+	Configs           []CreatableTopicConfigs
+	TagBuffer         types.TaggedFields
+}
+
+type CreatableTopicConfigs struct {
+	Name         types.CompactString
+	Value        *types.CompactString
+	ReadOnly     bool
+	ConfigSource int8
+	IsSensitive  bool
+	TagBuffer    types.TaggedFields
+}
+
+func ReadCreateTopics(r *bytes.Reader) (*CreateTopicsV7, error) {
+	ct := &CreateTopicsV7{}
+	if err := binary.Read(r, binary.BigEndian, &ct.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	ct.Topics = make([]CreatableTopicResult, max(n, 0))
+	for i := range ct.Topics {
+		t, err := ReadCreatableTopicResult(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d]", i)
+		}
+		ct.Topics[i] = *t
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	ct.TagBuffer = *tagBuffer
+	return ct, nil
+}
+
+func (r *CreateTopicsV7) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Topics)); err != nil {
+		return err
+	}
+	for _, t := range r.Topics {
+		if err := t.Write(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *CreateTopicsV7) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *CreateTopicsV7) ErrorCodes() []int16 {
+	var codes []int16
+	for _, t := range r.Topics {
+		codes = appendError(codes, t.ErrorCode)
+	}
+	return codes
+}
+
+func ReadCreatableTopicResult(r *bytes.Reader) (*CreatableTopicResult, error) {
+	t := &CreatableTopicResult{}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "name")
+	}
+	t.Name = *name
+	if t.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, types.Fieldf(err, "topicId")
+	}
+	if err = binary.Read(r, binary.BigEndian, &t.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if t.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	if err = binary.Read(r, binary.BigEndian, &t.NumPartitions); err != nil {
+		return nil, types.Fieldf(err, "numPartitions")
+	}
+	if err = binary.Read(r, binary.BigEndian, &t.ReplicationFactor); err != nil {
+		return nil, types.Fieldf(err, "replicationFactor")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "configs")
+	}
+	if n >= 0 {
+		t.Configs = make([]CreatableTopicConfigs, n)
+	}
+	for i := range t.Configs {
+		c := &t.Configs[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "configs[%d].name", i)
+		}
+		c.Name = *name
+		if c.Value, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "configs[%d].value", i)
+		}
+		if c.ReadOnly, err = types.ReadBool(r); err != nil {
+			return nil, types.Fieldf(err, "configs[%d].readOnly", i)
+		}
+		if err = binary.Read(r, binary.BigEndian, &c.ConfigSource); err != nil {
+			return nil, types.Fieldf(err, "configs[%d].configSource", i)
+		}
+		if c.IsSensitive, err = types.ReadBool(r); err != nil {
+			return nil, types.Fieldf(err, "configs[%d].isSensitive", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "configs[%d].taggedFields", i)
+		}
+		c.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	t.TagBuffer = *tagBuffer
+	return t, nil
+}
+
+func (t *CreatableTopicResult) Write(w io.Writer) error {
+	if err := t.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if _, err := w.Write(t.TopicId[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, t.ErrorMessage); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.NumPartitions); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, t.ReplicationFactor); err != nil {
+		return err
+	}
+	numConfigs := len(t.Configs)
+	if t.Configs == nil {
+		numConfigs = -1
+	}
+	if err := types.WriteCompactArrayLength(w, numConfigs); err != nil {
+		return err
+	}
+	for _, c := range t.Configs {
+		if err := c.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, c.Value); err != nil {
+			return err
+		}
+		if err := types.WriteBool(w, c.ReadOnly); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, c.ConfigSource); err != nil {
+			return err
+		}
+		if err := types.WriteBool(w, c.IsSensitive); err != nil {
+			return err
+		}
+		if err := c.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return t.TagBuffer.WriteTaggedFields(w)
+}
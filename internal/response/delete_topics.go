@@ -0,0 +1,100 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DeleteTopicsV6 struct {
+	ThrottleTime int32
+	Responses    []DeletableTopicResult
+	TagBuffer    types.TaggedFields
+}
+
+type DeletableTopicResult struct {
+	Name         *types.CompactString
+	TopicId      [16]byte
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	TagBuffer    types.TaggedFields
+}
+
+func ReadDeleteTopics(r *bytes.Reader) (*DeleteTopicsV6, error) {
+	dt := &DeleteTopicsV6{}
+	if err := binary.Read(r, binary.BigEndian, &dt.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "responses")
+	}
+	dt.Responses = make([]DeletableTopicResult, max(n, 0))
+	for i := range dt.Responses {
+		res := &dt.Responses[i]
+		if res.Name, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "responses[%d].name", i)
+		}
+		if res.TopicId, err = types.ReadUuid(r); err != nil {
+			return nil, types.Fieldf(err, "responses[%d].topicId", i)
+		}
+		if err = binary.Read(r, binary.BigEndian, &res.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "responses[%d].errorCode", i)
+		}
+		if res.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "responses[%d].errorMessage", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "responses[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	dt.TagBuffer = *tagBuffer
+	return dt, nil
+}
+
+func (r *DeleteTopicsV6) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Responses)); err != nil {
+		return err
+	}
+	for _, res := range r.Responses {
+		if err := types.WriteCompactNullableString(w, res.Name); err != nil {
+			return err
+		}
+		if _, err := w.Write(res.TopicId[:]); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, res.ErrorCode); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, res.ErrorMessage); err != nil {
+			return err
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DeleteTopicsV6) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DeleteTopicsV6) ErrorCodes() []int16 {
+	var codes []int16
+	for _, t := range r.Responses {
+		codes = appendError(codes, t.ErrorCode)
+	}
+	return codes
+}
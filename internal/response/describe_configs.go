@@ -0,0 +1,255 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeConfigsV4 struct {
+	ThrottleTime int32
+	Results      []DescribeConfigsResult
+	TagBuffer    types.TaggedFields
+}
+
+type DescribeConfigsResult struct {
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	ResourceType int8
+	ResourceName types.CompactString
+	Configs      []DescribeConfigsResourceResult
+	TagBuffer    types.TaggedFields
+}
+
+type DescribeConfigsResourceResult struct {
+	Name          types.CompactString
+	Value         *types.CompactString
+	ReadOnly      bool
+	ConfigSource  int8
+	IsSensitive   bool
+	Synonyms      []DescribeConfigsSynonym
+	ConfigType    int8
+	Documentation *types.CompactString
+	TagBuffer     types.TaggedFields
+}
+
+type DescribeConfigsSynonym struct {
+	Name      types.CompactString
+	Value     *types.CompactString
+	Source    int8
+	TagBuffer types.TaggedFields
+}
+
+func ReadDescribeConfigs(r *bytes.Reader) (*DescribeConfigsV4, error) {
+	d := &DescribeConfigsV4{}
+	if err := binary.Read(r, binary.BigEndian, &d.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "results")
+	}
+	d.Results = make([]DescribeConfigsResult, max(n, 0))
+	for i := range d.Results {
+		result, err := ReadDescribeConfigsResult(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "results[%d]", i)
+		}
+		d.Results[i] = *result
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (r *DescribeConfigsV4) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Results)); err != nil {
+		return err
+	}
+	for _, result := range r.Results {
+		if err := result.Write(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DescribeConfigsV4) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DescribeConfigsV4) ErrorCodes() []int16 {
+	var codes []int16
+	for _, res := range r.Results {
+		codes = appendError(codes, res.ErrorCode)
+	}
+	return codes
+}
+
+func ReadDescribeConfigsResult(r *bytes.Reader) (*DescribeConfigsResult, error) {
+	var err error
+	res := &DescribeConfigsResult{}
+	if err = binary.Read(r, binary.BigEndian, &res.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if res.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	if err = binary.Read(r, binary.BigEndian, &res.ResourceType); err != nil {
+		return nil, types.Fieldf(err, "resourceType")
+	}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "resourceName")
+	}
+	res.ResourceName = *name
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "configs")
+	}
+	res.Configs = make([]DescribeConfigsResourceResult, max(n, 0))
+	for i := range res.Configs {
+		c, err := ReadDescribeConfigsResourceResult(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "configs[%d]", i)
+		}
+		res.Configs[i] = *c
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	res.TagBuffer = *tagBuffer
+	return res, nil
+}
+
+func (r *DescribeConfigsResult) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ResourceType); err != nil {
+		return err
+	}
+	if err := r.ResourceName.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Configs)); err != nil {
+		return err
+	}
+	for _, c := range r.Configs {
+		if err := c.Write(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func ReadDescribeConfigsResourceResult(r *bytes.Reader) (*DescribeConfigsResourceResult, error) {
+	c := &DescribeConfigsResourceResult{}
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "name")
+	}
+	c.Name = *name
+	if c.Value, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "value")
+	}
+	if c.ReadOnly, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "readOnly")
+	}
+	if err = binary.Read(r, binary.BigEndian, &c.ConfigSource); err != nil {
+		return nil, types.Fieldf(err, "configSource")
+	}
+	if c.IsSensitive, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "isSensitive")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "synonyms")
+	}
+	c.Synonyms = make([]DescribeConfigsSynonym, max(n, 0))
+	for i := range c.Synonyms {
+		s := &c.Synonyms[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "synonyms[%d].name", i)
+		}
+		s.Name = *name
+		if s.Value, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "synonyms[%d].value", i)
+		}
+		if err = binary.Read(r, binary.BigEndian, &s.Source); err != nil {
+			return nil, types.Fieldf(err, "synonyms[%d].source", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "synonyms[%d].taggedFields", i)
+		}
+		s.TagBuffer = *tagBuffer
+	}
+	if err = binary.Read(r, binary.BigEndian, &c.ConfigType); err != nil {
+		return nil, types.Fieldf(err, "configType")
+	}
+	if c.Documentation, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "documentation")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	c.TagBuffer = *tagBuffer
+	return c, nil
+}
+
+func (c *DescribeConfigsResourceResult) Write(w io.Writer) error {
+	if err := c.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, c.Value); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, c.ReadOnly); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, c.ConfigSource); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, c.IsSensitive); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(c.Synonyms)); err != nil {
+		return err
+	}
+	for _, s := range c.Synonyms {
+		if err := s.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, s.Value); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, s.Source); err != nil {
+			return err
+		}
+		if err := s.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, c.ConfigType); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, c.Documentation); err != nil {
+		return err
+	}
+	return c.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,199 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeProducersV0 struct {
+	ThrottleTime int32
+	Topics       []DescribeProducersTopic
+	TagBuffer    types.TaggedFields
+}
+
+type DescribeProducersTopic struct {
+	Name       types.CompactString
+	Partitions []DescribeProducersPartition
+	TagBuffer  types.TaggedFields
+}
+
+type DescribeProducersPartition struct {
+	PartitionIndex  int32
+	ErrorCode       int16
+	ErrorMessage    *types.CompactString
+	ActiveProducers []ProducerState
+	TagBuffer       types.TaggedFields
+}
+
+type ProducerState struct {
+	ProducerId            int64
+	ProducerEpoch         int32
+	LastSequence          int32
+	LastTimestamp         int64
+	CoordinatorEpoch      int32
+	CurrentTxnStartOffset int64
+	TagBuffer             types.TaggedFields
+}
+
+func ReadDescribeProducers(r *bytes.Reader) (*DescribeProducersV0, error) {
+	dp := &DescribeProducersV0{}
+	if err := binary.Read(r, binary.BigEndian, &dp.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	dp.Topics = make([]DescribeProducersTopic, max(n, 0))
+	for i := range dp.Topics {
+		t := &dp.Topics[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		t.Name = *name
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		t.Partitions = make([]DescribeProducersPartition, max(n, 0))
+		for j := range t.Partitions {
+			p, err := ReadDescribeProducersPartition(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d]", i, j)
+			}
+			t.Partitions[j] = *p
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	dp.TagBuffer = *tagBuffer
+	return dp, nil
+}
+
+func (r *DescribeProducersV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Topics)); err != nil {
+		return err
+	}
+	for _, t := range r.Topics {
+		if err := t.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			if err := p.Write(w); err != nil {
+				return err
+			}
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DescribeProducersV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DescribeProducersV0) ErrorCodes() []int16 {
+	var codes []int16
+	for _, t := range r.Topics {
+		for _, p := range t.Partitions {
+			codes = appendError(codes, p.ErrorCode)
+		}
+	}
+	return codes
+}
+
+func ReadDescribeProducersPartition(r *bytes.Reader) (*DescribeProducersPartition, error) {
+	var err error
+	p := &DescribeProducersPartition{}
+	if err = binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+		return nil, types.Fieldf(err, "partitionIndex")
+	}
+	if err = binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if p.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "activeProducers")
+	}
+	p.ActiveProducers = make([]ProducerState, max(n, 0))
+	for i := range p.ActiveProducers {
+		ps := &p.ActiveProducers[i]
+		if err := binary.Read(r, binary.BigEndian, &ps.ProducerId); err != nil {
+			return nil, types.Fieldf(err, "activeProducers[%d].producerId", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &ps.ProducerEpoch); err != nil {
+			return nil, types.Fieldf(err, "activeProducers[%d].producerEpoch", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &ps.LastSequence); err != nil {
+			return nil, types.Fieldf(err, "activeProducers[%d].lastSequence", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &ps.LastTimestamp); err != nil {
+			return nil, types.Fieldf(err, "activeProducers[%d].lastTimestamp", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &ps.CoordinatorEpoch); err != nil {
+			return nil, types.Fieldf(err, "activeProducers[%d].coordinatorEpoch", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &ps.CurrentTxnStartOffset); err != nil {
+			return nil, types.Fieldf(err, "activeProducers[%d].currentTxnStartOffset", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "activeProducers[%d].taggedFields", i)
+		}
+		ps.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	p.TagBuffer = *tagBuffer
+	return p, nil
+}
+
+func (p *DescribeProducersPartition) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, p.ErrorMessage); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(p.ActiveProducers)); err != nil {
+		return err
+	}
+	for _, ps := range p.ActiveProducers {
+		for _, v := range []any{ps.ProducerId, ps.ProducerEpoch, ps.LastSequence, ps.LastTimestamp, ps.CoordinatorEpoch, ps.CurrentTxnStartOffset} {
+			if err := binary.Write(w, binary.BigEndian, v); err != nil {
+				return err
+			}
+		}
+		if err := ps.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return p.TagBuffer.WriteTaggedFields(w)
+}
@@ -1,6 +1,7 @@
 package response
 
 import (
+	"bytes"
 	"encoding/binary"
 	"io"
 
@@ -10,10 +11,44 @@ import (
 type DescribeTopicPartitionsV0 struct {
 	ThrottleTime int32
 	Topics       []Topic
-	NextCursor   Cursor
+	NextCursor   *Cursor // nil on the last page
 	TagBuffer    types.TaggedFields
 }
 
+func ReadDescribeTopicPartitions(r *bytes.Reader) (*DescribeTopicPartitionsV0, error) {
+	d := &DescribeTopicPartitionsV0{}
+	if err := binary.Read(r, binary.BigEndian, &d.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	d.Topics = make([]Topic, max(n, 0))
+	for i := range d.Topics {
+		topic, err := ReadTopic(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d]", i)
+		}
+		d.Topics[i] = *topic
+	}
+	var present int8
+	if err := binary.Read(r, binary.BigEndian, &present); err != nil {
+		return nil, types.Fieldf(err, "nextCursor")
+	}
+	if present >= 0 {
+		if d.NextCursor, err = ReadCursor(r); err != nil {
+			return nil, types.Fieldf(err, "nextCursor")
+		}
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
 func (r *DescribeTopicPartitionsV0) Write(w io.Writer) error {
 	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
 		return err
@@ -26,18 +61,60 @@ func (r *DescribeTopicPartitionsV0) Write(w io.Writer) error {
 			return err
 		}
 	}
-	if err := r.NextCursor.Write(w); err != nil {
-		return nil
+	// nullable struct: -1 marks null, 1 marks present
+	if r.NextCursor == nil {
+		if err := binary.Write(w, binary.BigEndian, int8(-1)); err != nil {
+			return err
+		}
+	} else {
+		if err := binary.Write(w, binary.BigEndian, int8(1)); err != nil {
+			return err
+		}
+		if err := r.NextCursor.Write(w); err != nil {
+			return err
+		}
 	}
 	return r.TagBuffer.WriteTaggedFields(w)
 }
 
+func (r *DescribeTopicPartitionsV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DescribeTopicPartitionsV0) ErrorCodes() []int16 {
+	var codes []int16
+	for _, t := range r.Topics {
+		codes = appendError(codes, t.ErrorCode)
+		for _, p := range t.Partitions.Partitions {
+			codes = appendError(codes, p.ErrorCode)
+		}
+	}
+	return codes
+}
+
 type Cursor struct {
 	TopicName      types.CompactString
 	PartitionIndex int32
 	TagBuffer      types.TaggedFields
 }
 
+func ReadCursor(r *bytes.Reader) (*Cursor, error) {
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "name")
+	}
+	c := &Cursor{TopicName: *name}
+	if err := binary.Read(r, binary.BigEndian, &c.PartitionIndex); err != nil {
+		return nil, types.Fieldf(err, "partitionIndex")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	c.TagBuffer = *tagBuffer
+	return c, nil
+}
+
 func (c *Cursor) Write(w io.Writer) error {
 	if err := c.TopicName.WriteCompactString(w); err != nil {
 		return err
@@ -58,6 +135,40 @@ type Topic struct {
 	TagBuffer                types.TaggedFields
 }
 
+func ReadTopic(r *bytes.Reader) (*Topic, error) {
+	t := &Topic{}
+	if err := binary.Read(r, binary.BigEndian, &t.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	name, err := types.ReadCompactNullableString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topicName")
+	}
+	if name != nil {
+		t.TopicName = *name
+	}
+	if t.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, types.Fieldf(err, "topicId")
+	}
+	if t.IsInternal, err = r.ReadByte(); err != nil {
+		return nil, types.Fieldf(err, "isInternal")
+	}
+	partitions, err := ReadPartitions(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "partitions")
+	}
+	t.Partitions = *partitions
+	if err := binary.Read(r, binary.BigEndian, &t.TopicAuthorizeOperations); err != nil {
+		return nil, types.Fieldf(err, "topicAuthorizeOperations")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	t.TagBuffer = *tagBuffer
+	return t, nil
+}
+
 func (t *Topic) Write(w io.Writer) error {
 	if err := binary.Write(w, binary.BigEndian, t.ErrorCode); err != nil {
 		return err
@@ -69,10 +180,10 @@ func (t *Topic) Write(w io.Writer) error {
 		return err
 	}
 	if _, err := w.Write([]byte{t.IsInternal}); err != nil {
-		return nil
+		return err
 	}
 	if err := t.Partitions.Write(w); err != nil {
-		return nil
+		return err
 	}
 	if err := binary.Write(w, binary.BigEndian, t.TopicAuthorizeOperations); err != nil {
 		return err
@@ -82,7 +193,22 @@ func (t *Topic) Write(w io.Writer) error {
 
 type Partitions struct {
 	Partitions []Partition
-	TagBuffer  types.TaggedFields
+}
+
+func ReadPartitions(r *bytes.Reader) (*Partitions, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &Partitions{Partitions: make([]Partition, max(n, 0))}
+	for i := range p.Partitions {
+		partition, err := ReadPartition(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d]", i)
+		}
+		p.Partitions[i] = *partition
+	}
+	return p, nil
 }
 
 func (p *Partitions) Write(w io.Writer) error {
@@ -94,7 +220,7 @@ func (p *Partitions) Write(w io.Writer) error {
 			return err
 		}
 	}
-	return p.TagBuffer.WriteTaggedFields(w)
+	return nil
 }
 
 type Partition struct {
@@ -107,6 +233,45 @@ type Partition struct {
 	EligibleLeaderReplicas Nodes
 	LastKnownELRs          Nodes
 	OfflineReplicas        Nodes
+	TagBuffer              types.TaggedFields
+}
+
+func ReadPartition(r *bytes.Reader) (*Partition, error) {
+	var err error
+	p := &Partition{}
+	if err = binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+		return nil, types.Fieldf(err, "partitionIndex")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.LeaderId); err != nil {
+		return nil, types.Fieldf(err, "leaderId")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.LeaderEpoch); err != nil {
+		return nil, types.Fieldf(err, "leaderEpoch")
+	}
+	if p.ReplicaNodes, err = ReadNodes(r); err != nil {
+		return nil, types.Fieldf(err, "replicaNodes")
+	}
+	if p.ISRNodes, err = ReadNodes(r); err != nil {
+		return nil, types.Fieldf(err, "isrNodes")
+	}
+	if p.EligibleLeaderReplicas, err = ReadNodes(r); err != nil {
+		return nil, types.Fieldf(err, "eligibleLeaderReplicas")
+	}
+	if p.LastKnownELRs, err = ReadNodes(r); err != nil {
+		return nil, types.Fieldf(err, "lastKnownElr")
+	}
+	if p.OfflineReplicas, err = ReadNodes(r); err != nil {
+		return nil, types.Fieldf(err, "offlineReplicas")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	p.TagBuffer = *tagBuffer
+	return p, nil
 }
 
 func (p *Partition) Write(w io.Writer) error {
@@ -134,7 +299,10 @@ func (p *Partition) Write(w io.Writer) error {
 	if err := p.LastKnownELRs.Write(w); err != nil {
 		return err
 	}
-	return p.OfflineReplicas.Write(w)
+	if err := p.OfflineReplicas.Write(w); err != nil {
+		return err
+	}
+	return p.TagBuffer.WriteTaggedFields(w)
 }
 
 type Node struct {
@@ -142,6 +310,19 @@ type Node struct {
 }
 type Nodes []Node
 
+// ReadNodes reads a compact array of node ids; a null array reads as empty.
+func ReadNodes(r *bytes.Reader) (Nodes, error) {
+	ids, err := types.ReadCompactInt32Array(r)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(Nodes, len(ids))
+	for i, id := range ids {
+		nodes[i].NodeId = id
+	}
+	return nodes, nil
+}
+
 func (n *Nodes) Write(w io.Writer) error {
 	if err := types.WriteUvarint(w, uint64(len(*n)+1)); err != nil {
 		return err
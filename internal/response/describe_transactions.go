@@ -0,0 +1,180 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeTransactionsV0 struct {
+	ThrottleTime      int32
+	TransactionStates []TransactionState
+	TagBuffer         types.TaggedFields
+}
+
+type TransactionState struct {
+	ErrorCode              int16
+	TransactionalId        types.CompactString
+	TransactionState       types.CompactString
+	TransactionTimeoutMs   int32
+	TransactionStartTimeMs int64
+	ProducerId             int64
+	ProducerEpoch          int16
+	Topics                 []TransactionTopic
+	TagBuffer              types.TaggedFields
+}
+
+type TransactionTopic struct {
+	Topic      types.CompactString
+	Partitions []int32
+	TagBuffer  types.TaggedFields
+}
+
+func ReadDescribeTransactions(r *bytes.Reader) (*DescribeTransactionsV0, error) {
+	dt := &DescribeTransactionsV0{}
+	if err := binary.Read(r, binary.BigEndian, &dt.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionStates")
+	}
+	dt.TransactionStates = make([]TransactionState, max(n, 0))
+	for i := range dt.TransactionStates {
+		s, err := ReadTransactionState(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "transactionStates[%d]", i)
+		}
+		dt.TransactionStates[i] = *s
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	dt.TagBuffer = *tagBuffer
+	return dt, nil
+}
+
+func (r *DescribeTransactionsV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.TransactionStates)); err != nil {
+		return err
+	}
+	for _, s := range r.TransactionStates {
+		if err := s.Write(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DescribeTransactionsV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DescribeTransactionsV0) ErrorCodes() []int16 {
+	var codes []int16
+	for _, s := range r.TransactionStates {
+		codes = appendError(codes, s.ErrorCode)
+	}
+	return codes
+}
+
+func ReadTransactionState(r *bytes.Reader) (*TransactionState, error) {
+	s := &TransactionState{}
+	if err := binary.Read(r, binary.BigEndian, &s.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	transactionalId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionalId")
+	}
+	s.TransactionalId = *transactionalId
+	state, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionState")
+	}
+	s.TransactionState = *state
+	if err := binary.Read(r, binary.BigEndian, &s.TransactionTimeoutMs); err != nil {
+		return nil, types.Fieldf(err, "transactionTimeoutMs")
+	}
+	if err := binary.Read(r, binary.BigEndian, &s.TransactionStartTimeMs); err != nil {
+		return nil, types.Fieldf(err, "transactionStartTimeMs")
+	}
+	if err := binary.Read(r, binary.BigEndian, &s.ProducerId); err != nil {
+		return nil, types.Fieldf(err, "producerId")
+	}
+	if err := binary.Read(r, binary.BigEndian, &s.ProducerEpoch); err != nil {
+		return nil, types.Fieldf(err, "producerEpoch")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	s.Topics = make([]TransactionTopic, max(n, 0))
+	for i := range s.Topics {
+		t := &s.Topics[i]
+		topic, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].topic", i)
+		}
+		t.Topic = *topic
+		if t.Partitions, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	s.TagBuffer = *tagBuffer
+	return s, nil
+}
+
+func (s *TransactionState) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, s.ErrorCode); err != nil {
+		return err
+	}
+	if err := s.TransactionalId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := s.TransactionState.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, s.TransactionTimeoutMs); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, s.TransactionStartTimeMs); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, s.ProducerId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, s.ProducerEpoch); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(s.Topics)); err != nil {
+		return err
+	}
+	for _, t := range s.Topics {
+		if err := t.Topic.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactInt32Array(w, t.Partitions); err != nil {
+			return err
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return s.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,155 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type DescribeUserScramCredentialsV0 struct {
+	ThrottleTime int32
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	Results      []DescribeUserScramCredentialsResult
+	TagBuffer    types.TaggedFields
+}
+
+type DescribeUserScramCredentialsResult struct {
+	User            types.CompactString
+	ErrorCode       int16
+	ErrorMessage    *types.CompactString
+	CredentialInfos []CredentialInfo
+	TagBuffer       types.TaggedFields
+}
+
+type CredentialInfo struct {
+	Mechanism  int8
+	Iterations int32
+	TagBuffer  types.TaggedFields
+}
+
+func ReadDescribeUserScramCredentials(r *bytes.Reader) (*DescribeUserScramCredentialsV0, error) {
+	d := &DescribeUserScramCredentialsV0{}
+	if err := binary.Read(r, binary.BigEndian, &d.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err := binary.Read(r, binary.BigEndian, &d.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	var err error
+	if d.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "results")
+	}
+	d.Results = make([]DescribeUserScramCredentialsResult, max(n, 0))
+	for i := range d.Results {
+		res := &d.Results[i]
+		user, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "results[%d].user", i)
+		}
+		res.User = *user
+		if err := binary.Read(r, binary.BigEndian, &res.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "results[%d].errorCode", i)
+		}
+		if res.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "results[%d].errorMessage", i)
+		}
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "results[%d].credentialInfos", i)
+		}
+		res.CredentialInfos = make([]CredentialInfo, max(n, 0))
+		for j := range res.CredentialInfos {
+			c := &res.CredentialInfos[j]
+			if err := binary.Read(r, binary.BigEndian, &c.Mechanism); err != nil {
+				return nil, types.Fieldf(err, "results[%d].credentialInfos[%d].mechanism", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &c.Iterations); err != nil {
+				return nil, types.Fieldf(err, "results[%d].credentialInfos[%d].iterations", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "results[%d].credentialInfos[%d].taggedFields", i, j)
+			}
+			c.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "results[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	d.TagBuffer = *tagBuffer
+	return d, nil
+}
+
+func (r *DescribeUserScramCredentialsV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Results)); err != nil {
+		return err
+	}
+	for _, res := range r.Results {
+		if err := res.User.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, res.ErrorCode); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, res.ErrorMessage); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(res.CredentialInfos)); err != nil {
+			return err
+		}
+		for _, c := range res.CredentialInfos {
+			if err := binary.Write(w, binary.BigEndian, c.Mechanism); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, c.Iterations); err != nil {
+				return err
+			}
+			if err := c.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *DescribeUserScramCredentialsV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *DescribeUserScramCredentialsV0) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *DescribeUserScramCredentialsV0) ErrorCodes() []int16 {
+	var codes []int16
+	codes = appendError(codes, r.ErrorCode)
+	for _, res := range r.Results {
+		codes = appendError(codes, res.ErrorCode)
+	}
+	return codes
+}
@@ -0,0 +1,273 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// FetchV12 covers versions 12 to 16; topics are named in version 12 and
+// identified by TopicId from version 13.
+type FetchV12 struct {
+	Version      int16
+	ThrottleTime int32
+	ErrorCode    int16
+	SessionId    int32
+	Responses    []FetchTopicResponse
+	TagBuffer    types.TaggedFields
+}
+
+type FetchTopicResponse struct {
+	Topic      types.CompactString
+	TopicId    [16]byte
+	Partitions []FetchPartitionResponse
+	TagBuffer  types.TaggedFields
+}
+
+type FetchPartitionResponse struct {
+	PartitionIndex       int32
+	ErrorCode            int16
+	HighWatermark        int64
+	LastStableOffset     int64
+	LogStartOffset       int64
+	AbortedTransactions  []AbortedTransaction // nil unless fetching read_committed
+	PreferredReadReplica int32
+	Records              []byte
+	FileRecords          []types.FileRegion // written instead of Records when set
+	TagBuffer            types.TaggedFields
+}
+
+type AbortedTransaction struct {
+	ProducerId  int64
+	FirstOffset int64
+	TagBuffer   types.TaggedFields
+}
+
+func ReadFetch(r *bytes.Reader, version int16) (*FetchV12, error) {
+	f := &FetchV12{Version: version}
+	if err := binary.Read(r, binary.BigEndian, &f.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if err := binary.Read(r, binary.BigEndian, &f.SessionId); err != nil {
+		return nil, types.Fieldf(err, "sessionId")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "responses")
+	}
+	f.Responses = make([]FetchTopicResponse, max(n, 0))
+	for i := range f.Responses {
+		t := &f.Responses[i]
+		if version >= 13 {
+			if t.TopicId, err = types.ReadUuid(r); err != nil {
+				return nil, types.Fieldf(err, "responses[%d].topicId", i)
+			}
+		} else {
+			name, err := types.ReadCompactString(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "responses[%d].topic", i)
+			}
+			t.Topic = *name
+		}
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "responses[%d].partitions", i)
+		}
+		t.Partitions = make([]FetchPartitionResponse, max(n, 0))
+		for j := range t.Partitions {
+			p, err := ReadFetchPartitionResponse(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "responses[%d].partitions[%d]", i, j)
+			}
+			t.Partitions[j] = *p
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "responses[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	f.TagBuffer = *tagBuffer
+	return f, nil
+}
+
+func (r *FetchV12) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.SessionId); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Responses)); err != nil {
+		return err
+	}
+	for _, t := range r.Responses {
+		if r.Version >= 13 {
+			if _, err := w.Write(t.TopicId[:]); err != nil {
+				return err
+			}
+		} else if err := t.Topic.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			if err := p.Write(w); err != nil {
+				return err
+			}
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *FetchV12) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *FetchV12) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *FetchV12) ErrorCodes() []int16 {
+	var codes []int16
+	codes = appendError(codes, r.ErrorCode)
+	for _, t := range r.Responses {
+		for _, p := range t.Partitions {
+			codes = appendError(codes, p.ErrorCode)
+		}
+	}
+	return codes
+}
+
+func ReadFetchPartitionResponse(r *bytes.Reader) (*FetchPartitionResponse, error) {
+	p := &FetchPartitionResponse{}
+	if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+		return nil, types.Fieldf(err, "partitionIndex")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.HighWatermark); err != nil {
+		return nil, types.Fieldf(err, "highWatermark")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.LastStableOffset); err != nil {
+		return nil, types.Fieldf(err, "lastStableOffset")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.LogStartOffset); err != nil {
+		return nil, types.Fieldf(err, "logStartOffset")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "abortedTransactions")
+	}
+	if n >= 0 {
+		p.AbortedTransactions = make([]AbortedTransaction, n)
+	}
+	for i := range p.AbortedTransactions {
+		txn := &p.AbortedTransactions[i]
+		if err := binary.Read(r, binary.BigEndian, &txn.ProducerId); err != nil {
+			return nil, types.Fieldf(err, "abortedTransactions[%d].producerId", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &txn.FirstOffset); err != nil {
+			return nil, types.Fieldf(err, "abortedTransactions[%d].firstOffset", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "abortedTransactions[%d].taggedFields", i)
+		}
+		txn.TagBuffer = *tagBuffer
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.PreferredReadReplica); err != nil {
+		return nil, types.Fieldf(err, "preferredReadReplica")
+	}
+	if p.Records, err = types.ReadCompactNullableBytes(r); err != nil {
+		return nil, types.Fieldf(err, "records")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	p.TagBuffer = *tagBuffer
+	return p, nil
+}
+
+func (p *FetchPartitionResponse) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.ErrorCode); err != nil {
+		return err
+	}
+	for _, v := range []int64{p.HighWatermark, p.LastStableOffset, p.LogStartOffset} {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	numAborted := len(p.AbortedTransactions)
+	if p.AbortedTransactions == nil {
+		numAborted = -1
+	}
+	if err := types.WriteCompactArrayLength(w, numAborted); err != nil {
+		return err
+	}
+	for _, txn := range p.AbortedTransactions {
+		if err := binary.Write(w, binary.BigEndian, txn.ProducerId); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, txn.FirstOffset); err != nil {
+			return err
+		}
+		if err := txn.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, p.PreferredReadReplica); err != nil {
+		return err
+	}
+	if p.FileRecords != nil {
+		if err := types.WriteCompactRegions(w, p.FileRecords); err != nil {
+			return err
+		}
+	} else if err := types.WriteCompactNullableBytes(w, p.Records); err != nil {
+		return err
+	}
+	return p.TagBuffer.WriteTaggedFields(w)
+}
+
+// RecordsSize returns the size of the records of the partition, whether
+// they are held in memory or in segment files.
+func (p *FetchPartitionResponse) RecordsSize() int {
+	size := len(p.Records)
+	for _, fr := range p.FileRecords {
+		size += int(fr.Size)
+	}
+	return size
+}
+
+// CloseFileRecords closes the files of the records of every partition, for
+// responses that are not encoded.
+func (r *FetchV12) CloseFileRecords() {
+	for _, t := range r.Responses {
+		for _, p := range t.Partitions {
+			for _, fr := range p.FileRecords {
+				fr.File.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type IncrementalAlterConfigsV1 struct {
+	ThrottleTime int32
+	Responses    []AlterConfigsResourceResponse
+	TagBuffer    types.TaggedFields
+}
+
+type AlterConfigsResourceResponse struct {
+	ErrorCode    int16
+	ErrorMessage *types.CompactString
+	ResourceType int8
+	ResourceName types.CompactString
+	TagBuffer    types.TaggedFields
+}
+
+func ReadIncrementalAlterConfigs(r *bytes.Reader) (*IncrementalAlterConfigsV1, error) {
+	ia := &IncrementalAlterConfigsV1{}
+	if err := binary.Read(r, binary.BigEndian, &ia.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "responses")
+	}
+	ia.Responses = make([]AlterConfigsResourceResponse, max(n, 0))
+	for i := range ia.Responses {
+		res := &ia.Responses[i]
+		if err := binary.Read(r, binary.BigEndian, &res.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "responses[%d].errorCode", i)
+		}
+		if res.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "responses[%d].errorMessage", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &res.ResourceType); err != nil {
+			return nil, types.Fieldf(err, "responses[%d].resourceType", i)
+		}
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "responses[%d].resourceName", i)
+		}
+		res.ResourceName = *name
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "responses[%d].taggedFields", i)
+		}
+		res.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	ia.TagBuffer = *tagBuffer
+	return ia, nil
+}
+
+func (r *IncrementalAlterConfigsV1) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Responses)); err != nil {
+		return err
+	}
+	for _, res := range r.Responses {
+		if err := binary.Write(w, binary.BigEndian, res.ErrorCode); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, res.ErrorMessage); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, res.ResourceType); err != nil {
+			return err
+		}
+		if err := res.ResourceName.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := res.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *IncrementalAlterConfigsV1) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *IncrementalAlterConfigsV1) ErrorCodes() []int16 {
+	var codes []int16
+	for _, res := range r.Responses {
+		codes = appendError(codes, res.ErrorCode)
+	}
+	return codes
+}
@@ -0,0 +1,67 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type InitProducerIdV2 struct {
+	ThrottleTime  int32
+	ErrorCode     int16
+	ProducerId    int64
+	ProducerEpoch int16
+	TagBuffer     types.TaggedFields
+}
+
+func ReadInitProducerId(r *bytes.Reader) (*InitProducerIdV2, error) {
+	ip := &InitProducerIdV2{}
+	if err := binary.Read(r, binary.BigEndian, &ip.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err := binary.Read(r, binary.BigEndian, &ip.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if err := binary.Read(r, binary.BigEndian, &ip.ProducerId); err != nil {
+		return nil, types.Fieldf(err, "producerId")
+	}
+	if err := binary.Read(r, binary.BigEndian, &ip.ProducerEpoch); err != nil {
+		return nil, types.Fieldf(err, "producerEpoch")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	ip.TagBuffer = *tagBuffer
+	return ip, nil
+}
+
+func (r *InitProducerIdV2) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ProducerId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ProducerEpoch); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *InitProducerIdV2) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *InitProducerIdV2) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *InitProducerIdV2) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
@@ -0,0 +1,108 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ListGroupsV5 struct {
+	ThrottleTime int32
+	ErrorCode    int16
+	Groups       []ListedGroup
+	TagBuffer    types.TaggedFields
+}
+
+type ListedGroup struct {
+	GroupId      types.CompactString
+	ProtocolType types.CompactString
+	GroupState   types.CompactString
+	GroupType    types.CompactString
+	TagBuffer    types.TaggedFields
+}
+
+func ReadListGroups(r *bytes.Reader) (*ListGroupsV5, error) {
+	lg := &ListGroupsV5{}
+	if err := binary.Read(r, binary.BigEndian, &lg.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err := binary.Read(r, binary.BigEndian, &lg.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groups")
+	}
+	lg.Groups = make([]ListedGroup, max(n, 0))
+	for i := range lg.Groups {
+		g := &lg.Groups[i]
+		groupId, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].groupId", i)
+		}
+		g.GroupId = *groupId
+		protocolType, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].protocolType", i)
+		}
+		g.ProtocolType = *protocolType
+		groupState, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].groupState", i)
+		}
+		g.GroupState = *groupState
+		groupType, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].groupType", i)
+		}
+		g.GroupType = *groupType
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d].taggedFields", i)
+		}
+		g.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	lg.TagBuffer = *tagBuffer
+	return lg, nil
+}
+
+func (r *ListGroupsV5) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Groups)); err != nil {
+		return err
+	}
+	for _, g := range r.Groups {
+		for _, field := range []types.CompactString{g.GroupId, g.ProtocolType, g.GroupState, g.GroupType} {
+			if err := field.WriteCompactString(w); err != nil {
+				return err
+			}
+		}
+		if err := g.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *ListGroupsV5) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *ListGroupsV5) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *ListGroupsV5) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
@@ -0,0 +1,145 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ListOffsetsV6 struct {
+	ThrottleTime int32
+	Topics       []ListOffsetsTopic
+	TagBuffer    types.TaggedFields
+}
+
+type ListOffsetsTopic struct {
+	Name       types.CompactString
+	Partitions []ListOffsetsPartition
+	TagBuffer  types.TaggedFields
+}
+
+// ListOffsetsPartition has an Offset and Timestamp of -1 when no record
+// matches the requested timestamp.
+type ListOffsetsPartition struct {
+	PartitionIndex int32
+	ErrorCode      int16
+	Timestamp      int64
+	Offset         int64
+	LeaderEpoch    int32
+	TagBuffer      types.TaggedFields
+}
+
+func ReadListOffsets(r *bytes.Reader) (*ListOffsetsV6, error) {
+	lo := &ListOffsetsV6{}
+	if err := binary.Read(r, binary.BigEndian, &lo.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	lo.Topics = make([]ListOffsetsTopic, max(n, 0))
+	for i := range lo.Topics {
+		t := &lo.Topics[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		t.Name = *name
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		t.Partitions = make([]ListOffsetsPartition, max(n, 0))
+		for j := range t.Partitions {
+			p := &t.Partitions[j]
+			if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].partitionIndex", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].errorCode", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.Timestamp); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].timestamp", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.Offset); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].offset", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.LeaderEpoch); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].leaderEpoch", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].taggedFields", i, j)
+			}
+			p.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	lo.TagBuffer = *tagBuffer
+	return lo, nil
+}
+
+func (r *ListOffsetsV6) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Topics)); err != nil {
+		return err
+	}
+	for _, t := range r.Topics {
+		if err := t.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.ErrorCode); err != nil {
+				return err
+			}
+			for _, field := range []int64{p.Timestamp, p.Offset} {
+				if err := binary.Write(w, binary.BigEndian, field); err != nil {
+					return err
+				}
+			}
+			if err := binary.Write(w, binary.BigEndian, p.LeaderEpoch); err != nil {
+				return err
+			}
+			if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *ListOffsetsV6) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *ListOffsetsV6) ErrorCodes() []int16 {
+	var codes []int16
+	for _, t := range r.Topics {
+		for _, p := range t.Partitions {
+			codes = appendError(codes, p.ErrorCode)
+		}
+	}
+	return codes
+}
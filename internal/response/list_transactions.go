@@ -0,0 +1,112 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ListTransactionsV0 struct {
+	ThrottleTime        int32
+	ErrorCode           int16
+	UnknownStateFilters []types.CompactString
+	TransactionStates   []ListedTransaction
+	TagBuffer           types.TaggedFields
+}
+
+type ListedTransaction struct {
+	TransactionalId  types.CompactString
+	ProducerId       int64
+	TransactionState types.CompactString
+	TagBuffer        types.TaggedFields
+}
+
+func ReadListTransactions(r *bytes.Reader) (*ListTransactionsV0, error) {
+	var err error
+	lt := &ListTransactionsV0{}
+	if err = binary.Read(r, binary.BigEndian, &lt.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err = binary.Read(r, binary.BigEndian, &lt.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if lt.UnknownStateFilters, err = types.ReadCompactStringArray(r); err != nil {
+		return nil, types.Fieldf(err, "unknownStateFilters")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "transactionStates")
+	}
+	lt.TransactionStates = make([]ListedTransaction, max(n, 0))
+	for i := range lt.TransactionStates {
+		t := &lt.TransactionStates[i]
+		transactionalId, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "transactionStates[%d].transactionalId", i)
+		}
+		t.TransactionalId = *transactionalId
+		if err := binary.Read(r, binary.BigEndian, &t.ProducerId); err != nil {
+			return nil, types.Fieldf(err, "transactionStates[%d].producerId", i)
+		}
+		state, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "transactionStates[%d].transactionState", i)
+		}
+		t.TransactionState = *state
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "transactionStates[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	lt.TagBuffer = *tagBuffer
+	return lt, nil
+}
+
+func (r *ListTransactionsV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactStringArray(w, r.UnknownStateFilters); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.TransactionStates)); err != nil {
+		return err
+	}
+	for _, t := range r.TransactionStates {
+		if err := t.TransactionalId.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, t.ProducerId); err != nil {
+			return err
+		}
+		if err := t.TransactionState.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *ListTransactionsV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *ListTransactionsV0) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *ListTransactionsV0) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
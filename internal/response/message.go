@@ -0,0 +1,104 @@
+package response
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// Message is an encoded response, ready to be written to a connection.
+// Record sets read from segment files are kept as file regions rather than
+// read into memory, and are sent straight from their files by WriteTo; the
+// size of the message is known up front all the same.
+type Message struct {
+	chunks []chunk
+	size   int
+}
+
+// chunk is either bytes or, when its region has a file, a file region.
+type chunk struct {
+	data   []byte
+	region types.FileRegion
+}
+
+// Encode encodes the response, prefixed with its size.
+func (r Response) Encode() (*Message, error) {
+	m := &Message{}
+	if err := binary.Write(m, binary.BigEndian, int32(0)); err != nil {
+		return nil, err
+	}
+	if err := r.Header.Write(m); err != nil {
+		return nil, err
+	}
+	if err := r.Body.Write(m); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(m.chunks[0].data[0:4], uint32(m.size-4))
+	return m, nil
+}
+
+func (m *Message) Write(b []byte) (int, error) {
+	if n := len(m.chunks); n > 0 && m.chunks[n-1].region.File == nil {
+		m.chunks[n-1].data = append(m.chunks[n-1].data, b...)
+	} else {
+		m.chunks = append(m.chunks, chunk{data: append([]byte(nil), b...)})
+	}
+	m.size += len(b)
+	return len(b), nil
+}
+
+// WriteRegion adds a file region to the message without reading it.
+func (m *Message) WriteRegion(fr types.FileRegion) error {
+	m.chunks = append(m.chunks, chunk{region: fr})
+	m.size += int(fr.Size)
+	return nil
+}
+
+// Size returns the size of the message, including its size prefix.
+func (m *Message) Size() int {
+	return m.size
+}
+
+// WriteTo writes the message to w. File regions are copied from their file
+// with w reading from it, which TCP connections do with sendfile on Linux.
+// Every region must have a file of its own, as the file offset is moved.
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	written := int64(0)
+	for _, c := range m.chunks {
+		if c.region.File == nil {
+			n, err := w.Write(c.data)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+			continue
+		}
+		if _, err := c.region.File.Seek(c.region.Position, io.SeekStart); err != nil {
+			return written, err
+		}
+		n, err := io.Copy(w, io.LimitReader(c.region.File, c.region.Size))
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n < c.region.Size {
+			return written, io.ErrUnexpectedEOF
+		}
+	}
+	return written, nil
+}
+
+// Close closes the files of the regions of the message.
+func (m *Message) Close() error {
+	var firstErr error
+	for _, c := range m.chunks {
+		if c.region.File == nil {
+			continue
+		}
+		if err := c.region.File.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
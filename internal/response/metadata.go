@@ -0,0 +1,261 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type MetadataV12 struct {
+	ThrottleTime int32
+	Brokers      []MetadataBroker
+	ClusterId    *types.CompactString
+	ControllerId int32
+	Topics       []MetadataTopic
+	TagBuffer    types.TaggedFields
+}
+
+type MetadataBroker struct {
+	NodeId    int32
+	Host      types.CompactString
+	Port      int32
+	Rack      *types.CompactString
+	TagBuffer types.TaggedFields
+}
+
+type MetadataTopic struct {
+	ErrorCode                 int16
+	Name                      *types.CompactString
+	TopicId                   [16]byte
+	IsInternal                bool
+	Partitions                []MetadataPartition
+	TopicAuthorizedOperations int32
+	TagBuffer                 types.TaggedFields
+}
+
+type MetadataPartition struct {
+	ErrorCode       int16
+	PartitionIndex  int32
+	LeaderId        int32
+	LeaderEpoch     int32
+	ReplicaNodes    []int32
+	IsrNodes        []int32
+	OfflineReplicas []int32
+	TagBuffer       types.TaggedFields
+}
+
+func ReadMetadata(r *bytes.Reader) (*MetadataV12, error) {
+	m := &MetadataV12{}
+	if err := binary.Read(r, binary.BigEndian, &m.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "brokers")
+	}
+	m.Brokers = make([]MetadataBroker, max(n, 0))
+	for i := range m.Brokers {
+		b := &m.Brokers[i]
+		if err := binary.Read(r, binary.BigEndian, &b.NodeId); err != nil {
+			return nil, types.Fieldf(err, "brokers[%d].nodeId", i)
+		}
+		host, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "brokers[%d].host", i)
+		}
+		b.Host = *host
+		if err := binary.Read(r, binary.BigEndian, &b.Port); err != nil {
+			return nil, types.Fieldf(err, "brokers[%d].port", i)
+		}
+		if b.Rack, err = types.ReadCompactNullableString(r); err != nil {
+			return nil, types.Fieldf(err, "brokers[%d].rack", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "brokers[%d].taggedFields", i)
+		}
+		b.TagBuffer = *tagBuffer
+	}
+	if m.ClusterId, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "clusterId")
+	}
+	if err := binary.Read(r, binary.BigEndian, &m.ControllerId); err != nil {
+		return nil, types.Fieldf(err, "controllerId")
+	}
+	n, err = types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	m.Topics = make([]MetadataTopic, max(n, 0))
+	for i := range m.Topics {
+		topic, err := ReadMetadataTopic(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d]", i)
+		}
+		m.Topics[i] = *topic
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	m.TagBuffer = *tagBuffer
+	return m, nil
+}
+
+func (m *MetadataV12) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, m.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(m.Brokers)); err != nil {
+		return err
+	}
+	for _, b := range m.Brokers {
+		if err := binary.Write(w, binary.BigEndian, b.NodeId); err != nil {
+			return err
+		}
+		if err := b.Host.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, b.Port); err != nil {
+			return err
+		}
+		if err := types.WriteCompactNullableString(w, b.Rack); err != nil {
+			return err
+		}
+		if err := b.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteCompactNullableString(w, m.ClusterId); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, m.ControllerId); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(m.Topics)); err != nil {
+		return err
+	}
+	for _, t := range m.Topics {
+		if err := t.Write(w); err != nil {
+			return err
+		}
+	}
+	return m.TagBuffer.WriteTaggedFields(w)
+}
+
+func (m *MetadataV12) SetThrottleTime(ms int32) {
+	m.ThrottleTime = ms
+}
+
+func (m *MetadataV12) ErrorCodes() []int16 {
+	var codes []int16
+	for _, t := range m.Topics {
+		codes = appendError(codes, t.ErrorCode)
+		for _, p := range t.Partitions {
+			codes = appendError(codes, p.ErrorCode)
+		}
+	}
+	return codes
+}
+
+func ReadMetadataTopic(r *bytes.Reader) (*MetadataTopic, error) {
+	t := &MetadataTopic{}
+	var err error
+	if err = binary.Read(r, binary.BigEndian, &t.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if t.Name, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "name")
+	}
+	if t.TopicId, err = types.ReadUuid(r); err != nil {
+		return nil, types.Fieldf(err, "topicId")
+	}
+	if t.IsInternal, err = types.ReadBool(r); err != nil {
+		return nil, types.Fieldf(err, "isInternal")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "partitions")
+	}
+	t.Partitions = make([]MetadataPartition, max(n, 0))
+	for i := range t.Partitions {
+		p := &t.Partitions[i]
+		if err := binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].errorCode", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].partitionIndex", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &p.LeaderId); err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].leaderId", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &p.LeaderEpoch); err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].leaderEpoch", i)
+		}
+		if p.ReplicaNodes, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].replicaNodes", i)
+		}
+		if p.IsrNodes, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].isrNodes", i)
+		}
+		if p.OfflineReplicas, err = types.ReadCompactInt32Array(r); err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].offlineReplicas", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "partitions[%d].taggedFields", i)
+		}
+		p.TagBuffer = *tagBuffer
+	}
+	if err := binary.Read(r, binary.BigEndian, &t.TopicAuthorizedOperations); err != nil {
+		return nil, types.Fieldf(err, "topicAuthorizedOperations")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	t.TagBuffer = *tagBuffer
+	return t, nil
+}
+
+func (t *MetadataTopic) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, t.ErrorCode); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, t.Name); err != nil {
+		return err
+	}
+	if _, err := w.Write(t.TopicId[:]); err != nil {
+		return err
+	}
+	if err := types.WriteBool(w, t.IsInternal); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+		return err
+	}
+	for _, p := range t.Partitions {
+		if err := binary.Write(w, binary.BigEndian, p.ErrorCode); err != nil {
+			return err
+		}
+		for _, v := range []int32{p.PartitionIndex, p.LeaderId, p.LeaderEpoch} {
+			if err := binary.Write(w, binary.BigEndian, v); err != nil {
+				return err
+			}
+		}
+		for _, nodes := range [][]int32{p.ReplicaNodes, p.IsrNodes, p.OfflineReplicas} {
+			if err := types.WriteCompactInt32Array(w, nodes); err != nil {
+				return err
+			}
+		}
+		if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, t.TopicAuthorizedOperations); err != nil {
+		return err
+	}
+	return t.TagBuffer.WriteTaggedFields(w)
+}
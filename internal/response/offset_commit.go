@@ -0,0 +1,51 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// OffsetCommitV9 reports an error code per partition, like TxnOffsetCommit.
+type OffsetCommitV9 struct {
+	ThrottleTime int32
+	Topics       []TxnTopicResult
+	TagBuffer    types.TaggedFields
+}
+
+func ReadOffsetCommit(r *bytes.Reader) (*OffsetCommitV9, error) {
+	var err error
+	oc := &OffsetCommitV9{}
+	if err = binary.Read(r, binary.BigEndian, &oc.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if oc.Topics, err = readTxnTopicResults(r); err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	oc.TagBuffer = *tagBuffer
+	return oc, nil
+}
+
+func (r *OffsetCommitV9) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := writeTxnTopicResults(w, r.Topics); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *OffsetCommitV9) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *OffsetCommitV9) ErrorCodes() []int16 {
+	return appendTxnErrors(nil, r.Topics)
+}
@@ -0,0 +1,204 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type OffsetFetchV9 struct {
+	ThrottleTime int32
+	Groups       []OffsetFetchGroup
+	TagBuffer    types.TaggedFields
+}
+
+type OffsetFetchGroup struct {
+	GroupId   types.CompactString
+	Topics    []OffsetFetchTopic
+	ErrorCode int16
+	TagBuffer types.TaggedFields
+}
+
+type OffsetFetchTopic struct {
+	Name       types.CompactString
+	Partitions []OffsetFetchPartition
+	TagBuffer  types.TaggedFields
+}
+
+// OffsetFetchPartition has a CommittedOffset of -1 when the group committed
+// no offset for the partition.
+type OffsetFetchPartition struct {
+	PartitionIndex       int32
+	CommittedOffset      int64
+	CommittedLeaderEpoch int32
+	Metadata             *types.CompactString
+	ErrorCode            int16
+	TagBuffer            types.TaggedFields
+}
+
+func ReadOffsetFetch(r *bytes.Reader) (*OffsetFetchV9, error) {
+	of := &OffsetFetchV9{}
+	if err := binary.Read(r, binary.BigEndian, &of.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groups")
+	}
+	of.Groups = make([]OffsetFetchGroup, max(n, 0))
+	for i := range of.Groups {
+		g, err := ReadOffsetFetchGroup(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "groups[%d]", i)
+		}
+		of.Groups[i] = *g
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	of.TagBuffer = *tagBuffer
+	return of, nil
+}
+
+func (r *OffsetFetchV9) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(r.Groups)); err != nil {
+		return err
+	}
+	for _, g := range r.Groups {
+		if err := g.Write(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *OffsetFetchV9) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *OffsetFetchV9) ErrorCodes() []int16 {
+	var codes []int16
+	for _, g := range r.Groups {
+		codes = appendError(codes, g.ErrorCode)
+		for _, t := range g.Topics {
+			for _, p := range t.Partitions {
+				codes = appendError(codes, p.ErrorCode)
+			}
+		}
+	}
+	return codes
+}
+
+func ReadOffsetFetchGroup(r *bytes.Reader) (*OffsetFetchGroup, error) {
+	g := &OffsetFetchGroup{}
+	groupId, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "groupId")
+	}
+	g.GroupId = *groupId
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	g.Topics = make([]OffsetFetchTopic, max(n, 0))
+	for i := range g.Topics {
+		t := &g.Topics[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].name", i)
+		}
+		t.Name = *name
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].partitions", i)
+		}
+		t.Partitions = make([]OffsetFetchPartition, max(n, 0))
+		for j := range t.Partitions {
+			p := &t.Partitions[j]
+			if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].partitionIndex", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.CommittedOffset); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedOffset", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.CommittedLeaderEpoch); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].committedLeaderEpoch", i, j)
+			}
+			if p.Metadata, err = types.ReadCompactNullableString(r); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].metadata", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].errorCode", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "topics[%d].partitions[%d].taggedFields", i, j)
+			}
+			p.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "topics[%d].taggedFields", i)
+		}
+		t.TagBuffer = *tagBuffer
+	}
+	if err = binary.Read(r, binary.BigEndian, &g.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	g.TagBuffer = *tagBuffer
+	return g, nil
+}
+
+func (g *OffsetFetchGroup) Write(w io.Writer) error {
+	if err := g.GroupId.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(g.Topics)); err != nil {
+		return err
+	}
+	for _, t := range g.Topics {
+		if err := t.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CommittedOffset); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.CommittedLeaderEpoch); err != nil {
+				return err
+			}
+			if err := types.WriteCompactNullableString(w, p.Metadata); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.ErrorCode); err != nil {
+				return err
+			}
+			if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := t.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, g.ErrorCode); err != nil {
+		return err
+	}
+	return g.TagBuffer.WriteTaggedFields(w)
+}
@@ -0,0 +1,230 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type ProduceV9 struct {
+	Responses    []ProduceTopicResponse
+	ThrottleTime int32
+	TagBuffer    types.TaggedFields
+}
+
+func ReadProduce(r *bytes.Reader) (*ProduceV9, error) {
+	p := &ProduceV9{}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "responses")
+	}
+	p.Responses = make([]ProduceTopicResponse, max(n, 0))
+	for i := range p.Responses {
+		topic, err := ReadProduceTopicResponse(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "responses[%d]", i)
+		}
+		p.Responses[i] = *topic
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	p.TagBuffer = *tagBuffer
+	return p, nil
+}
+
+func (r *ProduceV9) Write(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(r.Responses)); err != nil {
+		return err
+	}
+	for _, topic := range r.Responses {
+		if err := topic.Write(w); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *ProduceV9) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *ProduceV9) ErrorCodes() []int16 {
+	var codes []int16
+	for _, t := range r.Responses {
+		for _, p := range t.PartitionResponses {
+			codes = appendError(codes, p.ErrorCode)
+		}
+	}
+	return codes
+}
+
+type ProduceTopicResponse struct {
+	Name               types.CompactString
+	PartitionResponses []ProducePartitionResponse
+	TagBuffer          types.TaggedFields
+}
+
+func ReadProduceTopicResponse(r *bytes.Reader) (*ProduceTopicResponse, error) {
+	name, err := types.ReadCompactString(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "name")
+	}
+	t := &ProduceTopicResponse{Name: *name}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "partitionResponses")
+	}
+	t.PartitionResponses = make([]ProducePartitionResponse, max(n, 0))
+	for i := range t.PartitionResponses {
+		partition, err := ReadProducePartitionResponse(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "partitionResponses[%d]", i)
+		}
+		t.PartitionResponses[i] = *partition
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	t.TagBuffer = *tagBuffer
+	return t, nil
+}
+
+func (t *ProduceTopicResponse) Write(w io.Writer) error {
+	if err := t.Name.WriteCompactString(w); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(t.PartitionResponses)); err != nil {
+		return err
+	}
+	for _, partition := range t.PartitionResponses {
+		if err := partition.Write(w); err != nil {
+			return err
+		}
+	}
+	return t.TagBuffer.WriteTaggedFields(w)
+}
+
+type ProducePartitionResponse struct {
+	Index           int32
+	ErrorCode       int16
+	BaseOffset      int64
+	LogAppendTimeMs int64
+	LogStartOffset  int64
+	RecordErrors    []BatchIndexAndErrorMessage
+	ErrorMessage    *types.CompactString
+	TagBuffer       types.TaggedFields
+}
+
+func ReadProducePartitionResponse(r *bytes.Reader) (*ProducePartitionResponse, error) {
+	p := &ProducePartitionResponse{}
+	if err := binary.Read(r, binary.BigEndian, &p.Index); err != nil {
+		return nil, types.Fieldf(err, "index")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.BaseOffset); err != nil {
+		return nil, types.Fieldf(err, "baseOffset")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.LogAppendTimeMs); err != nil {
+		return nil, types.Fieldf(err, "logAppendTimeMs")
+	}
+	if err := binary.Read(r, binary.BigEndian, &p.LogStartOffset); err != nil {
+		return nil, types.Fieldf(err, "logStartOffset")
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "recordErrors")
+	}
+	p.RecordErrors = make([]BatchIndexAndErrorMessage, max(n, 0))
+	for i := range p.RecordErrors {
+		e, err := ReadBatchIndexAndErrorMessage(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "recordErrors[%d]", i)
+		}
+		p.RecordErrors[i] = *e
+	}
+	if p.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	p.TagBuffer = *tagBuffer
+	return p, nil
+}
+
+func (p *ProducePartitionResponse) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, p.Index); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.ErrorCode); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.BaseOffset); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.LogAppendTimeMs); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, p.LogStartOffset); err != nil {
+		return err
+	}
+	if err := types.WriteCompactArrayLength(w, len(p.RecordErrors)); err != nil {
+		return err
+	}
+	for _, recordError := range p.RecordErrors {
+		if err := recordError.Write(w); err != nil {
+			return err
+		}
+	}
+	if err := types.WriteCompactNullableString(w, p.ErrorMessage); err != nil {
+		return err
+	}
+	return p.TagBuffer.WriteTaggedFields(w)
+}
+
+type BatchIndexAndErrorMessage struct {
+	BatchIndex             int32
+	BatchIndexErrorMessage *types.CompactString
+	TagBuffer              types.TaggedFields
+}
+
+func ReadBatchIndexAndErrorMessage(r *bytes.Reader) (*BatchIndexAndErrorMessage, error) {
+	var err error
+	e := &BatchIndexAndErrorMessage{}
+	if err = binary.Read(r, binary.BigEndian, &e.BatchIndex); err != nil {
+		return nil, types.Fieldf(err, "batchIndex")
+	}
+	if e.BatchIndexErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "batchIndexErrorMessage")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	e.TagBuffer = *tagBuffer
+	return e, nil
+}
+
+func (e *BatchIndexAndErrorMessage) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, e.BatchIndex); err != nil {
+		return err
+	}
+	if err := types.WriteCompactNullableString(w, e.BatchIndexErrorMessage); err != nil {
+		return err
+	}
+	return e.TagBuffer.WriteTaggedFields(w)
+}
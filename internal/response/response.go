@@ -3,8 +3,10 @@ package response
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
 	"github.com/codecrafters-io/kafka-starter-go/internal/types"
 )
 
@@ -16,10 +18,45 @@ type ResponseBody interface {
 	Write(io.Writer) error
 }
 
+// Throttled is implemented by response bodies that report how long the
+// client is throttled for.
+type Throttled interface {
+	SetThrottleTime(ms int32)
+}
+
+// ErrorReporter is implemented by response bodies, which return the error
+// codes they carry: one for each resource, or the response itself, that
+// failed.
+type ErrorReporter interface {
+	ErrorCodes() []int16
+}
+
+// appendError appends code to codes unless it reports success.
+func appendError(codes []int16, code int16) []int16 {
+	if code == 0 {
+		return codes
+	}
+	return append(codes, code)
+}
+
+// ErrorCoded is implemented by response bodies with a top-level error code.
+type ErrorCoded interface {
+	ResponseBody
+	SetErrorCode(code int16)
+}
+
 type ResponseHeaderV0 struct {
 	CorrelationId int32
 }
 
+func ReadResponseHeaderV0(r *bytes.Reader) (*ResponseHeaderV0, error) {
+	rh := &ResponseHeaderV0{}
+	if err := binary.Read(r, binary.BigEndian, &rh.CorrelationId); err != nil {
+		return nil, types.Fieldf(err, "correlationId")
+	}
+	return rh, nil
+}
+
 func (rh *ResponseHeaderV0) Write(w io.Writer) error {
 	return binary.Write(w, binary.BigEndian, rh.CorrelationId)
 }
@@ -29,6 +66,19 @@ type ResponseHeaderV1 struct {
 	TagBuffer     types.TaggedFields
 }
 
+func ReadResponseHeaderV1(r *bytes.Reader) (*ResponseHeaderV1, error) {
+	rh := &ResponseHeaderV1{}
+	if err := binary.Read(r, binary.BigEndian, &rh.CorrelationId); err != nil {
+		return nil, types.Fieldf(err, "correlationId")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	rh.TagBuffer = *tagBuffer
+	return rh, nil
+}
+
 func (rh *ResponseHeaderV1) Write(w io.Writer) error {
 	if err := binary.Write(w, binary.BigEndian, rh.CorrelationId); err != nil {
 		return err
@@ -45,22 +95,135 @@ type Response struct {
 	Body        ResponseBody
 }
 
-func (r Response) MarshallResponse() []byte {
-	buf := bytes.Buffer{}
-	binary.Write(&buf, binary.BigEndian, r.MessageSize)
-	r.Header.Write(&buf)
-	r.Body.Write(&buf)
-	bufBytes := buf.Bytes()
-	binary.BigEndian.PutUint32(bufBytes[0:4], uint32(len(bufBytes)-4))
-	return bufBytes
+// UnmarshallResponse decodes a size-prefixed response to a request of the
+// given API and version, which the response itself does not carry.
+func UnmarshallResponse(b []byte, apiKey, version int16) (*Response, error) {
+	buf := bytes.NewReader(b)
+	res := &Response{}
+	if err := binary.Read(buf, binary.BigEndian, &res.MessageSize); err != nil {
+		return nil, err
+	}
+	header, err := ReadResponseHeader(buf, apiKey, version)
+	if err != nil {
+		return nil, err
+	}
+	res.Header = header
+	res.Body, err = ParseResponseBody(apiKey, version, buf)
+	return res, err
+}
+
+// ReadResponseHeader reads the header of a response to a request of the
+// given API and version.
+func ReadResponseHeader(r *bytes.Reader, apiKey, version int16) (ResponseHeader, error) {
+	if headerV0(apiKey, version) {
+		return ReadResponseHeaderV0(r)
+	}
+	return ReadResponseHeaderV1(r)
+}
+
+// NewResponseHeader returns the header of a response to a request of the
+// given API and version.
+func NewResponseHeader(apiKey, version int16, correlationId int32) ResponseHeader {
+	if headerV0(apiKey, version) {
+		return &ResponseHeaderV0{CorrelationId: correlationId}
+	}
+	return &ResponseHeaderV1{CorrelationId: correlationId}
+}
+
+// headerV0 tells whether a response has header version 0, as responses
+// that are not flexible do, rather than header version 1, which ends with
+// tagged fields. ApiVersions responses always have header version 0 so
+// that clients can read them whatever version they asked for.
+func headerV0(apiKey, version int16) bool {
+	return apiKey == constant.ApiVersions || !constant.FlexibleVersion(apiKey, version)
+}
+
+func ParseResponseBody(apiKey, version int16, r *bytes.Reader) (ResponseBody, error) {
+	switch apiKey {
+	case constant.ApiVersions:
+		return ReadAPIVersions(r, version)
+	case constant.Metadata:
+		return ReadMetadata(r)
+	case constant.DescribeTopicPartitions:
+		return ReadDescribeTopicPartitions(r)
+	case constant.ConsumerGroupHeartbeat:
+		return ReadConsumerGroupHeartbeat(r)
+	case constant.ConsumerGroupDescribe:
+		return ReadConsumerGroupDescribe(r)
+	case constant.InitProducerId:
+		return ReadInitProducerId(r)
+	case constant.Produce:
+		return ReadProduce(r)
+	case constant.Fetch:
+		return ReadFetch(r, version)
+	case constant.AddPartitionsToTxn:
+		return ReadAddPartitionsToTxn(r)
+	case constant.AddOffsetsToTxn, constant.EndTxn:
+		return ReadErrorOnly(r)
+	case constant.TxnOffsetCommit:
+		return ReadTxnOffsetCommit(r)
+	case constant.WriteTxnMarkers:
+		return ReadWriteTxnMarkers(r)
+	case constant.DescribeProducers:
+		return ReadDescribeProducers(r)
+	case constant.DescribeTransactions:
+		return ReadDescribeTransactions(r)
+	case constant.ListTransactions:
+		return ReadListTransactions(r)
+	case constant.DescribeConfigs:
+		return ReadDescribeConfigs(r)
+	case constant.IncrementalAlterConfigs:
+		return ReadIncrementalAlterConfigs(r)
+	case constant.SaslHandshake:
+		return ReadSaslHandshake(r)
+	case constant.SaslAuthenticate:
+		return ReadSaslAuthenticate(r, version)
+	case constant.DescribeUserScramCredentials:
+		return ReadDescribeUserScramCredentials(r)
+	case constant.AlterUserScramCredentials:
+		return ReadAlterUserScramCredentials(r)
+	case constant.CreateAcls:
+		return ReadCreateAcls(r)
+	case constant.DescribeAcls:
+		return ReadDescribeAcls(r)
+	case constant.DeleteAcls:
+		return ReadDeleteAcls(r)
+	case constant.DescribeClientQuotas:
+		return ReadDescribeClientQuotas(r)
+	case constant.AlterClientQuotas:
+		return ReadAlterClientQuotas(r)
+	case constant.CreateTopics:
+		return ReadCreateTopics(r)
+	case constant.DeleteTopics:
+		return ReadDeleteTopics(r)
+	case constant.ListGroups:
+		return ReadListGroups(r)
+	case constant.OffsetCommit:
+		return ReadOffsetCommit(r)
+	case constant.OffsetFetch:
+		return ReadOffsetFetch(r)
+	case constant.ListOffsets:
+		return ReadListOffsets(r)
+	default:
+		return nil, fmt.Errorf("no response decoder for API key %d", apiKey)
+	}
 }
 
-// API Versions Response
+// APIVersionsResponseV4 covers versions 0 to 4. Versions before 3 are not
+// flexible and have no tagged fields, and version 0 has no throttle time.
 type APIVersionsResponseV4 struct {
+	Version      int16
 	ErrorCode    int16
 	ApiVersions  []APIVersion
 	ThrottleTime int32
-	TagBuffer    types.TaggedFields
+
+	// tagged fields
+	SupportedFeatures      []SupportedFeature
+	FinalizedFeaturesEpoch int64 // -1 when there are no finalized features
+	FinalizedFeatures      []FinalizedFeature
+	ZkMigrationReady       bool
+
+	TagBuffer types.TaggedFields
 }
 
 type APIVersion struct {
@@ -70,10 +233,229 @@ type APIVersion struct {
 	TagBuffer  types.TaggedFields
 }
 
+// SupportedFeature is a feature and the range of its versions that the
+// broker supports.
+type SupportedFeature struct {
+	Name       types.CompactString
+	MinVersion int16
+	MaxVersion int16
+	TagBuffer  types.TaggedFields
+}
+
+// FinalizedFeature is a feature and the range of its versions that the
+// cluster has enabled.
+type FinalizedFeature struct {
+	Name            types.CompactString
+	MaxVersionLevel int16
+	MinVersionLevel int16
+	TagBuffer       types.TaggedFields
+}
+
+// taggedFields declares the tagged fields of the response.
+func (rb *APIVersionsResponseV4) taggedFields() []types.TaggedField {
+	return []types.TaggedField{
+		{
+			Tag:  0,
+			Name: "supportedFeatures",
+			Read: func(r *bytes.Reader) error {
+				n, err := types.ReadCompactArrayLength(r)
+				if err != nil {
+					return err
+				}
+				rb.SupportedFeatures = make([]SupportedFeature, max(n, 0))
+				for i := range rb.SupportedFeatures {
+					f := &rb.SupportedFeatures[i]
+					name, err := types.ReadCompactString(r)
+					if err != nil {
+						return types.Fieldf(err, "[%d].name", i)
+					}
+					f.Name = *name
+					if err := binary.Read(r, binary.BigEndian, &f.MinVersion); err != nil {
+						return types.Fieldf(err, "[%d].minVersion", i)
+					}
+					if err := binary.Read(r, binary.BigEndian, &f.MaxVersion); err != nil {
+						return types.Fieldf(err, "[%d].maxVersion", i)
+					}
+					tagBuffer, err := types.ReadTaggedFields(r)
+					if err != nil {
+						return types.Fieldf(err, "[%d].taggedFields", i)
+					}
+					f.TagBuffer = *tagBuffer
+				}
+				return nil
+			},
+			Write: func(w io.Writer) error {
+				if len(rb.SupportedFeatures) == 0 {
+					return nil
+				}
+				types.WriteCompactArrayLength(w, len(rb.SupportedFeatures))
+				for _, f := range rb.SupportedFeatures {
+					f.Name.WriteCompactString(w)
+					binary.Write(w, binary.BigEndian, f.MinVersion)
+					binary.Write(w, binary.BigEndian, f.MaxVersion)
+					if err := f.TagBuffer.WriteTaggedFields(w); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Tag:  1,
+			Name: "finalizedFeaturesEpoch",
+			Read: func(r *bytes.Reader) error {
+				return binary.Read(r, binary.BigEndian, &rb.FinalizedFeaturesEpoch)
+			},
+			Write: func(w io.Writer) error {
+				if rb.FinalizedFeaturesEpoch == -1 {
+					return nil
+				}
+				return binary.Write(w, binary.BigEndian, rb.FinalizedFeaturesEpoch)
+			},
+		},
+		{
+			Tag:  2,
+			Name: "finalizedFeatures",
+			Read: func(r *bytes.Reader) error {
+				n, err := types.ReadCompactArrayLength(r)
+				if err != nil {
+					return err
+				}
+				rb.FinalizedFeatures = make([]FinalizedFeature, max(n, 0))
+				for i := range rb.FinalizedFeatures {
+					f := &rb.FinalizedFeatures[i]
+					name, err := types.ReadCompactString(r)
+					if err != nil {
+						return types.Fieldf(err, "[%d].name", i)
+					}
+					f.Name = *name
+					if err := binary.Read(r, binary.BigEndian, &f.MaxVersionLevel); err != nil {
+						return types.Fieldf(err, "[%d].maxVersionLevel", i)
+					}
+					if err := binary.Read(r, binary.BigEndian, &f.MinVersionLevel); err != nil {
+						return types.Fieldf(err, "[%d].minVersionLevel", i)
+					}
+					tagBuffer, err := types.ReadTaggedFields(r)
+					if err != nil {
+						return types.Fieldf(err, "[%d].taggedFields", i)
+					}
+					f.TagBuffer = *tagBuffer
+				}
+				return nil
+			},
+			Write: func(w io.Writer) error {
+				if len(rb.FinalizedFeatures) == 0 {
+					return nil
+				}
+				types.WriteCompactArrayLength(w, len(rb.FinalizedFeatures))
+				for _, f := range rb.FinalizedFeatures {
+					f.Name.WriteCompactString(w)
+					binary.Write(w, binary.BigEndian, f.MaxVersionLevel)
+					binary.Write(w, binary.BigEndian, f.MinVersionLevel)
+					if err := f.TagBuffer.WriteTaggedFields(w); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Tag:  3,
+			Name: "zkMigrationReady",
+			Read: func(r *bytes.Reader) error {
+				var err error
+				rb.ZkMigrationReady, err = types.ReadBool(r)
+				return err
+			},
+			Write: func(w io.Writer) error {
+				if !rb.ZkMigrationReady {
+					return nil
+				}
+				return types.WriteBool(w, true)
+			},
+		},
+	}
+}
+
+func ReadAPIVersions(r *bytes.Reader, version int16) (*APIVersionsResponseV4, error) {
+	rb := &APIVersionsResponseV4{Version: version, FinalizedFeaturesEpoch: -1}
+	if err := binary.Read(r, binary.BigEndian, &rb.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if version < 3 {
+		return rb, rb.readV0(r)
+	}
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "apiVersions")
+	}
+	rb.ApiVersions = make([]APIVersion, max(n, 0))
+	for i := range rb.ApiVersions {
+		v := &rb.ApiVersions[i]
+		if err := binary.Read(r, binary.BigEndian, &v.ApiKey); err != nil {
+			return nil, types.Fieldf(err, "apiVersions[%d].apiKey", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &v.MinVersion); err != nil {
+			return nil, types.Fieldf(err, "apiVersions[%d].minVersion", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &v.MaxVersion); err != nil {
+			return nil, types.Fieldf(err, "apiVersions[%d].maxVersion", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "apiVersions[%d].taggedFields", i)
+		}
+		v.TagBuffer = *tagBuffer
+	}
+	if err := binary.Read(r, binary.BigEndian, &rb.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r, rb.taggedFields()...)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	rb.TagBuffer = *tagBuffer
+	return rb, nil
+}
+
+// readV0 reads the rest of a response of a version before 3.
+func (rb *APIVersionsResponseV4) readV0(r *bytes.Reader) error {
+	var n int32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return types.Fieldf(err, "apiVersions")
+	}
+	// every entry takes 6 bytes
+	if n < 0 || int64(n)*6 > int64(r.Len()) {
+		return types.Fieldf(types.ErrInvalidLength, "apiVersions")
+	}
+	rb.ApiVersions = make([]APIVersion, n)
+	for i := range rb.ApiVersions {
+		v := &rb.ApiVersions[i]
+		if err := binary.Read(r, binary.BigEndian, &v.ApiKey); err != nil {
+			return types.Fieldf(err, "apiVersions[%d].apiKey", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &v.MinVersion); err != nil {
+			return types.Fieldf(err, "apiVersions[%d].minVersion", i)
+		}
+		if err := binary.Read(r, binary.BigEndian, &v.MaxVersion); err != nil {
+			return types.Fieldf(err, "apiVersions[%d].maxVersion", i)
+		}
+	}
+	if rb.Version >= 1 {
+		if err := binary.Read(r, binary.BigEndian, &rb.ThrottleTime); err != nil {
+			return types.Fieldf(err, "throttleTime")
+		}
+	}
+	return nil
+}
+
 func (rb *APIVersionsResponseV4) Write(w io.Writer) error {
 	if err := binary.Write(w, binary.BigEndian, rb.ErrorCode); err != nil {
 		return err
 	}
+	if rb.Version < 3 {
+		return rb.writeV0(w)
+	}
 	// apiVersions is a compact array
 	types.WriteUvarint(w, uint64(len(rb.ApiVersions)+1))
 	for _, apiVersion := range rb.ApiVersions {
@@ -83,6 +465,35 @@ func (rb *APIVersionsResponseV4) Write(w io.Writer) error {
 		apiVersion.TagBuffer.WriteTaggedFields(w)
 	}
 	binary.Write(w, binary.BigEndian, rb.ThrottleTime)
-	rb.TagBuffer.WriteTaggedFields(w)
-	return nil
+	return rb.TagBuffer.WriteTaggedFields(w, rb.taggedFields()...)
+}
+
+func (rb *APIVersionsResponseV4) SetThrottleTime(ms int32) {
+	rb.ThrottleTime = ms
+}
+
+func (rb *APIVersionsResponseV4) SetErrorCode(code int16) {
+	rb.ErrorCode = code
+}
+
+func (rb *APIVersionsResponseV4) ErrorCodes() []int16 {
+	return appendError(nil, rb.ErrorCode)
+}
+
+// writeV0 writes the rest of a response of a version before 3.
+func (rb *APIVersionsResponseV4) writeV0(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, int32(len(rb.ApiVersions))); err != nil {
+		return err
+	}
+	for _, apiVersion := range rb.ApiVersions {
+		for _, v := range []int16{apiVersion.ApiKey, apiVersion.MinVersion, apiVersion.MaxVersion} {
+			if err := binary.Write(w, binary.BigEndian, v); err != nil {
+				return err
+			}
+		}
+	}
+	if rb.Version < 1 {
+		return nil
+	}
+	return binary.Write(w, binary.BigEndian, rb.ThrottleTime)
 }
@@ -0,0 +1,114 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types/typestest"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		apiKey   int16
+		min, max int16
+		new      func() ResponseBody
+		// absent resets the fields a version does not carry to the values
+		// they are decoded with
+		absent func(rb ResponseBody, version int16)
+	}{
+		{apiKey: constant.ApiVersions, min: 0, max: 4, new: func() ResponseBody { return &APIVersionsResponseV4{} },
+			absent: func(rb ResponseBody, version int16) {
+				a := rb.(*APIVersionsResponseV4)
+				if version < 3 {
+					a.SupportedFeatures, a.FinalizedFeaturesEpoch, a.FinalizedFeatures, a.ZkMigrationReady = nil, -1, nil, false
+				}
+				if version < 1 {
+					a.ThrottleTime = 0
+				}
+			}},
+		{apiKey: constant.Metadata, min: 12, max: 12, new: func() ResponseBody { return &MetadataV12{} }},
+		{apiKey: constant.DescribeTopicPartitions, min: 0, max: 0, new: func() ResponseBody { return &DescribeTopicPartitionsV0{} }},
+		{apiKey: constant.ConsumerGroupHeartbeat, min: 0, max: 0, new: func() ResponseBody { return &ConsumerGroupHeartbeatV0{} }},
+		{apiKey: constant.ConsumerGroupDescribe, min: 0, max: 0, new: func() ResponseBody { return &ConsumerGroupDescribeV0{} }},
+		{apiKey: constant.InitProducerId, min: 2, max: 4, new: func() ResponseBody { return &InitProducerIdV2{} }},
+		{apiKey: constant.Produce, min: 9, max: 11, new: func() ResponseBody { return &ProduceV9{} }},
+		{apiKey: constant.Fetch, min: 12, max: 16, new: func() ResponseBody { return &FetchV12{} },
+			absent: func(rb ResponseBody, version int16) {
+				f := rb.(*FetchV12)
+				for i := range f.Responses {
+					if version >= 13 {
+						f.Responses[i].Topic = ""
+					} else {
+						f.Responses[i].TopicId = [16]byte{}
+					}
+					// file regions are only written, and decoded as records
+					for j := range f.Responses[i].Partitions {
+						f.Responses[i].Partitions[j].FileRecords = nil
+					}
+				}
+			}},
+		{apiKey: constant.AddPartitionsToTxn, min: 3, max: 3, new: func() ResponseBody { return &AddPartitionsToTxnV3{} }},
+		{apiKey: constant.AddOffsetsToTxn, min: 3, max: 3, new: func() ResponseBody { return &ErrorOnlyV0{} }},
+		{apiKey: constant.EndTxn, min: 3, max: 4, new: func() ResponseBody { return &ErrorOnlyV0{} }},
+		{apiKey: constant.WriteTxnMarkers, min: 1, max: 1, new: func() ResponseBody { return &WriteTxnMarkersV1{} }},
+		{apiKey: constant.TxnOffsetCommit, min: 3, max: 3, new: func() ResponseBody { return &TxnOffsetCommitV3{} }},
+		{apiKey: constant.DescribeConfigs, min: 4, max: 4, new: func() ResponseBody { return &DescribeConfigsV4{} }},
+		{apiKey: constant.IncrementalAlterConfigs, min: 1, max: 1, new: func() ResponseBody { return &IncrementalAlterConfigsV1{} }},
+		{apiKey: constant.SaslHandshake, min: 1, max: 1, new: func() ResponseBody { return &SaslHandshakeV1{} }},
+		{apiKey: constant.SaslAuthenticate, min: 0, max: 2, new: func() ResponseBody { return &SaslAuthenticateV2{} },
+			absent: func(rb ResponseBody, version int16) {
+				if version < 1 {
+					rb.(*SaslAuthenticateV2).SessionLifetimeMs = 0
+				}
+			}},
+		{apiKey: constant.DescribeUserScramCredentials, min: 0, max: 0, new: func() ResponseBody { return &DescribeUserScramCredentialsV0{} }},
+		{apiKey: constant.AlterUserScramCredentials, min: 0, max: 0, new: func() ResponseBody { return &AlterUserScramCredentialsV0{} }},
+		{apiKey: constant.CreateAcls, min: 2, max: 3, new: func() ResponseBody { return &CreateAclsV2{} }},
+		{apiKey: constant.DescribeAcls, min: 2, max: 3, new: func() ResponseBody { return &DescribeAclsV2{} }},
+		{apiKey: constant.DeleteAcls, min: 2, max: 3, new: func() ResponseBody { return &DeleteAclsV2{} }},
+		{apiKey: constant.DescribeClientQuotas, min: 1, max: 1, new: func() ResponseBody { return &DescribeClientQuotasV1{} }},
+		{apiKey: constant.AlterClientQuotas, min: 1, max: 1, new: func() ResponseBody { return &AlterClientQuotasV1{} }},
+		{apiKey: constant.DescribeProducers, min: 0, max: 0, new: func() ResponseBody { return &DescribeProducersV0{} }},
+		{apiKey: constant.DescribeTransactions, min: 0, max: 0, new: func() ResponseBody { return &DescribeTransactionsV0{} }},
+		{apiKey: constant.ListTransactions, min: 0, max: 1, new: func() ResponseBody { return &ListTransactionsV0{} }},
+		{apiKey: constant.CreateTopics, min: 7, max: 7, new: func() ResponseBody { return &CreateTopicsV7{} }},
+		{apiKey: constant.DeleteTopics, min: 6, max: 6, new: func() ResponseBody { return &DeleteTopicsV6{} }},
+		{apiKey: constant.ListOffsets, min: 6, max: 8, new: func() ResponseBody { return &ListOffsetsV6{} }},
+		{apiKey: constant.ListGroups, min: 5, max: 5, new: func() ResponseBody { return &ListGroupsV5{} }},
+		{apiKey: constant.OffsetCommit, min: 9, max: 9, new: func() ResponseBody { return &OffsetCommitV9{} }},
+		{apiKey: constant.OffsetFetch, min: 9, max: 9, new: func() ResponseBody { return &OffsetFetchV9{} }},
+	}
+	for _, tt := range tests {
+		for version := tt.min; version <= tt.max; version++ {
+			t.Run(fmt.Sprintf("%s v%d", constant.ApiKeyNames[tt.apiKey], version), func(t *testing.T) {
+				want := tt.new()
+				typestest.Populate(want)
+				if f := reflect.ValueOf(want).Elem().FieldByName("Version"); f.IsValid() {
+					f.SetInt(int64(version))
+				}
+				if tt.absent != nil {
+					tt.absent(want, version)
+				}
+
+				var buf bytes.Buffer
+				if err := want.Write(&buf); err != nil {
+					t.Fatal(err)
+				}
+				r := bytes.NewReader(buf.Bytes())
+				got, err := ParseResponseBody(tt.apiKey, version, r)
+				if err != nil {
+					t.Fatalf("decoding %x: %v", buf.Bytes(), err)
+				}
+				if r.Len() > 0 {
+					t.Errorf("%d bytes left after the body", r.Len())
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("decoded %+v, want %+v", got, want)
+				}
+			})
+		}
+	}
+}
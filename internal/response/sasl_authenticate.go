@@ -0,0 +1,103 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// SaslAuthenticateV2 covers versions 0 to 2. Versions before 2 are sent
+// with ResponseHeaderV0 and SessionLifetimeMs is only sent from version 1.
+type SaslAuthenticateV2 struct {
+	Version           int16
+	ErrorCode         int16
+	ErrorMessage      *types.CompactString
+	AuthBytes         []byte
+	SessionLifetimeMs int64
+	TagBuffer         types.TaggedFields
+}
+
+func ReadSaslAuthenticate(r *bytes.Reader, version int16) (*SaslAuthenticateV2, error) {
+	var err error
+	sa := &SaslAuthenticateV2{Version: version}
+	if err = binary.Read(r, binary.BigEndian, &sa.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	if version < 2 {
+		message, err := types.ReadNullableString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "errorMessage")
+		}
+		if message.Length >= 0 {
+			cs := types.CompactString(message.Data)
+			sa.ErrorMessage = &cs
+		}
+		if sa.AuthBytes, err = types.ReadBytes(r); err != nil {
+			return nil, types.Fieldf(err, "authBytes")
+		}
+		if version < 1 {
+			return sa, nil
+		}
+		if err = binary.Read(r, binary.BigEndian, &sa.SessionLifetimeMs); err != nil {
+			return nil, types.Fieldf(err, "sessionLifetimeMs")
+		}
+		return sa, nil
+	}
+	if sa.ErrorMessage, err = types.ReadCompactNullableString(r); err != nil {
+		return nil, types.Fieldf(err, "errorMessage")
+	}
+	if sa.AuthBytes, err = types.ReadCompactBytes(r); err != nil {
+		return nil, types.Fieldf(err, "authBytes")
+	}
+	if err = binary.Read(r, binary.BigEndian, &sa.SessionLifetimeMs); err != nil {
+		return nil, types.Fieldf(err, "sessionLifetimeMs")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	sa.TagBuffer = *tagBuffer
+	return sa, nil
+}
+
+func (r *SaslAuthenticateV2) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if r.Version < 2 {
+		message := types.NullableString{Length: -1}
+		if r.ErrorMessage != nil {
+			message = types.NullableString{Length: int16(len(*r.ErrorMessage)), Data: string(*r.ErrorMessage)}
+		}
+		if err := message.WriteNullableString(w); err != nil {
+			return err
+		}
+		if err := types.WriteBytes(w, r.AuthBytes); err != nil {
+			return err
+		}
+		if r.Version < 1 {
+			return nil
+		}
+		return binary.Write(w, binary.BigEndian, r.SessionLifetimeMs)
+	}
+	if err := types.WriteCompactNullableString(w, r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := types.WriteCompactBytes(w, r.AuthBytes); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.SessionLifetimeMs); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *SaslAuthenticateV2) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *SaslAuthenticateV2) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
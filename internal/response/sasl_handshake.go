@@ -0,0 +1,62 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// SaslHandshakeV1 is sent with ResponseHeaderV0.
+type SaslHandshakeV1 struct {
+	ErrorCode  int16
+	Mechanisms []types.NullableString
+}
+
+func ReadSaslHandshake(r *bytes.Reader) (*SaslHandshakeV1, error) {
+	sh := &SaslHandshakeV1{}
+	if err := binary.Read(r, binary.BigEndian, &sh.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	var n int32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, types.Fieldf(err, "mechanisms")
+	}
+	// each mechanism takes at least the two bytes of its length
+	if n > int32(r.Len()/2) {
+		return nil, types.Fieldf(types.ErrInvalidLength, "mechanisms")
+	}
+	sh.Mechanisms = make([]types.NullableString, max(n, 0))
+	for i := range sh.Mechanisms {
+		m, err := types.ReadNullableString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "mechanisms[%d]", i)
+		}
+		sh.Mechanisms[i] = *m
+	}
+	return sh, nil
+}
+
+func (r *SaslHandshakeV1) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, int32(len(r.Mechanisms))); err != nil {
+		return err
+	}
+	for _, m := range r.Mechanisms {
+		if err := m.WriteNullableString(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SaslHandshakeV1) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *SaslHandshakeV1) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
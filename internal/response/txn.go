@@ -0,0 +1,294 @@
+package response
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type AddPartitionsToTxnV3 struct {
+	ThrottleTime int32
+	Results      []TxnTopicResult
+	TagBuffer    types.TaggedFields
+}
+
+func ReadAddPartitionsToTxn(r *bytes.Reader) (*AddPartitionsToTxnV3, error) {
+	var err error
+	a := &AddPartitionsToTxnV3{}
+	if err = binary.Read(r, binary.BigEndian, &a.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if a.Results, err = readTxnTopicResults(r); err != nil {
+		return nil, types.Fieldf(err, "results")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	a.TagBuffer = *tagBuffer
+	return a, nil
+}
+
+func (r *AddPartitionsToTxnV3) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := writeTxnTopicResults(w, r.Results); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *AddPartitionsToTxnV3) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *AddPartitionsToTxnV3) ErrorCodes() []int16 {
+	return appendTxnErrors(nil, r.Results)
+}
+
+type TxnTopicResult struct {
+	Name      types.CompactString
+	Results   []TxnPartitionResult
+	TagBuffer types.TaggedFields
+}
+
+// appendTxnErrors appends the error codes of the partitions of topics.
+func appendTxnErrors(codes []int16, topics []TxnTopicResult) []int16 {
+	for _, t := range topics {
+		for _, p := range t.Results {
+			codes = appendError(codes, p.ErrorCode)
+		}
+	}
+	return codes
+}
+
+type TxnPartitionResult struct {
+	PartitionIndex int32
+	ErrorCode      int16
+	TagBuffer      types.TaggedFields
+}
+
+func readTxnTopicResults(r *bytes.Reader) ([]TxnTopicResult, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TxnTopicResult, max(n, 0))
+	for i := range results {
+		topic := &results[i]
+		name, err := types.ReadCompactString(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].name", i)
+		}
+		topic.Name = *name
+		n, err := types.ReadCompactArrayLength(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].results", i)
+		}
+		topic.Results = make([]TxnPartitionResult, max(n, 0))
+		for j := range topic.Results {
+			p := &topic.Results[j]
+			if err := binary.Read(r, binary.BigEndian, &p.PartitionIndex); err != nil {
+				return nil, types.Fieldf(err, "[%d].results[%d].partitionIndex", i, j)
+			}
+			if err := binary.Read(r, binary.BigEndian, &p.ErrorCode); err != nil {
+				return nil, types.Fieldf(err, "[%d].results[%d].errorCode", i, j)
+			}
+			tagBuffer, err := types.ReadTaggedFields(r)
+			if err != nil {
+				return nil, types.Fieldf(err, "[%d].results[%d].taggedFields", i, j)
+			}
+			p.TagBuffer = *tagBuffer
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "[%d].taggedFields", i)
+		}
+		topic.TagBuffer = *tagBuffer
+	}
+	return results, nil
+}
+
+func writeTxnTopicResults(w io.Writer, results []TxnTopicResult) error {
+	if err := types.WriteCompactArrayLength(w, len(results)); err != nil {
+		return err
+	}
+	for _, topic := range results {
+		if err := topic.Name.WriteCompactString(w); err != nil {
+			return err
+		}
+		if err := types.WriteCompactArrayLength(w, len(topic.Results)); err != nil {
+			return err
+		}
+		for _, p := range topic.Results {
+			if err := binary.Write(w, binary.BigEndian, p.PartitionIndex); err != nil {
+				return err
+			}
+			if err := binary.Write(w, binary.BigEndian, p.ErrorCode); err != nil {
+				return err
+			}
+			if err := p.TagBuffer.WriteTaggedFields(w); err != nil {
+				return err
+			}
+		}
+		if err := topic.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrorOnlyV0 is the body of responses that only carry a throttle time and
+// an error code, such as AddOffsetsToTxn and EndTxn.
+type ErrorOnlyV0 struct {
+	ThrottleTime int32
+	ErrorCode    int16
+	TagBuffer    types.TaggedFields
+}
+
+func ReadErrorOnly(r *bytes.Reader) (*ErrorOnlyV0, error) {
+	e := &ErrorOnlyV0{}
+	if err := binary.Read(r, binary.BigEndian, &e.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if err := binary.Read(r, binary.BigEndian, &e.ErrorCode); err != nil {
+		return nil, types.Fieldf(err, "errorCode")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	e.TagBuffer = *tagBuffer
+	return e, nil
+}
+
+func (r *ErrorOnlyV0) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, r.ErrorCode); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *ErrorOnlyV0) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *ErrorOnlyV0) SetErrorCode(code int16) {
+	r.ErrorCode = code
+}
+
+func (r *ErrorOnlyV0) ErrorCodes() []int16 {
+	return appendError(nil, r.ErrorCode)
+}
+
+type TxnOffsetCommitV3 struct {
+	ThrottleTime int32
+	Topics       []TxnTopicResult
+	TagBuffer    types.TaggedFields
+}
+
+func ReadTxnOffsetCommit(r *bytes.Reader) (*TxnOffsetCommitV3, error) {
+	var err error
+	t := &TxnOffsetCommitV3{}
+	if err = binary.Read(r, binary.BigEndian, &t.ThrottleTime); err != nil {
+		return nil, types.Fieldf(err, "throttleTime")
+	}
+	if t.Topics, err = readTxnTopicResults(r); err != nil {
+		return nil, types.Fieldf(err, "topics")
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	t.TagBuffer = *tagBuffer
+	return t, nil
+}
+
+func (r *TxnOffsetCommitV3) Write(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, r.ThrottleTime); err != nil {
+		return err
+	}
+	if err := writeTxnTopicResults(w, r.Topics); err != nil {
+		return err
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *TxnOffsetCommitV3) SetThrottleTime(ms int32) {
+	r.ThrottleTime = ms
+}
+
+func (r *TxnOffsetCommitV3) ErrorCodes() []int16 {
+	return appendTxnErrors(nil, r.Topics)
+}
+
+type WriteTxnMarkersV1 struct {
+	Markers   []TxnMarkerResult
+	TagBuffer types.TaggedFields
+}
+
+type TxnMarkerResult struct {
+	ProducerId int64
+	Topics     []TxnTopicResult
+	TagBuffer  types.TaggedFields
+}
+
+func ReadWriteTxnMarkers(r *bytes.Reader) (*WriteTxnMarkersV1, error) {
+	n, err := types.ReadCompactArrayLength(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "markers")
+	}
+	wm := &WriteTxnMarkersV1{Markers: make([]TxnMarkerResult, max(n, 0))}
+	for i := range wm.Markers {
+		m := &wm.Markers[i]
+		if err := binary.Read(r, binary.BigEndian, &m.ProducerId); err != nil {
+			return nil, types.Fieldf(err, "markers[%d].producerId", i)
+		}
+		if m.Topics, err = readTxnTopicResults(r); err != nil {
+			return nil, types.Fieldf(err, "markers[%d].topics", i)
+		}
+		tagBuffer, err := types.ReadTaggedFields(r)
+		if err != nil {
+			return nil, types.Fieldf(err, "markers[%d].taggedFields", i)
+		}
+		m.TagBuffer = *tagBuffer
+	}
+	tagBuffer, err := types.ReadTaggedFields(r)
+	if err != nil {
+		return nil, types.Fieldf(err, "taggedFields")
+	}
+	wm.TagBuffer = *tagBuffer
+	return wm, nil
+}
+
+func (r *WriteTxnMarkersV1) Write(w io.Writer) error {
+	if err := types.WriteCompactArrayLength(w, len(r.Markers)); err != nil {
+		return err
+	}
+	for _, m := range r.Markers {
+		if err := binary.Write(w, binary.BigEndian, m.ProducerId); err != nil {
+			return err
+		}
+		if err := writeTxnTopicResults(w, m.Topics); err != nil {
+			return err
+		}
+		if err := m.TagBuffer.WriteTaggedFields(w); err != nil {
+			return err
+		}
+	}
+	return r.TagBuffer.WriteTaggedFields(w)
+}
+
+func (r *WriteTxnMarkersV1) ErrorCodes() []int16 {
+	var codes []int16
+	for _, m := range r.Markers {
+		codes = appendTxnErrors(codes, m.Topics)
+	}
+	return codes
+}
@@ -0,0 +1,156 @@
+package sasl
+
+import (
+	"slices"
+	"sync"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+const Plain = "PLAIN"
+
+// AnonymousPrincipal is the principal of connections that don't
+// authenticate.
+const AnonymousPrincipal = "User:ANONYMOUS"
+
+type state int
+
+const (
+	// stateHandshake expects SaslHandshake, possibly after ApiVersions
+	stateHandshake state = iota
+	// stateAuthenticate expects SaslAuthenticate until the exchange completes
+	stateAuthenticate
+	stateAuthenticated
+	// stateFailed connections must be closed once the response is sent
+	stateFailed
+)
+
+type server interface {
+	// evaluate processes a message from the client and returns the
+	// challenge to send back, or the reason authentication failed
+	evaluate(response []byte) ([]byte, error)
+	complete() bool
+	user() string
+}
+
+// Authenticator tracks the SASL state of a connection. Until it is
+// authenticated, a connection may only send ApiVersions, SaslHandshake and
+// SaslAuthenticate requests. It is safe for concurrent use, as the
+// requests of a connection may be handled concurrently.
+type Authenticator struct {
+	mu          sync.Mutex
+	credentials *CredentialStore
+	mechanisms  []string
+	state       state
+	server      server
+	principal   string
+}
+
+// NewAuthenticator returns the authenticator of a new connection to a
+// SASL listener.
+func NewAuthenticator(credentials *CredentialStore, mechanisms []string) *Authenticator {
+	return &Authenticator{credentials: credentials, mechanisms: mechanisms}
+}
+
+// Authenticated returns the authenticator of a connection to a listener
+// without SASL, whose principal is known from the start.
+func Authenticated(principal string) *Authenticator {
+	return &Authenticator{state: stateAuthenticated, principal: principal}
+}
+
+// Allowed tells whether a request with the given API key may be handled.
+// A request that is not fails the authentication, so that the connection
+// is closed once it is answered with ILLEGAL_SASL_STATE.
+func (a *Authenticator) Allowed(apiKey int16) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch a.state {
+	case stateAuthenticated:
+		return true
+	case stateFailed:
+		return false
+	}
+	if apiKey == constant.ApiVersions || apiKey == constant.SaslHandshake || apiKey == constant.SaslAuthenticate {
+		return true
+	}
+	a.state = stateFailed
+	return false
+}
+
+// Failed tells whether the connection must be closed.
+func (a *Authenticator) Failed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state == stateFailed
+}
+
+// Complete tells whether the connection is authenticated.
+func (a *Authenticator) Complete() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state == stateAuthenticated
+}
+
+// Principal returns the authenticated principal, like User:alice.
+func (a *Authenticator) Principal() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.principal
+}
+
+func (a *Authenticator) Handshake(req *request.SaslHandshakeV1) *response.SaslHandshakeV1 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res := &response.SaslHandshakeV1{Mechanisms: make([]types.NullableString, len(a.mechanisms))}
+	for i, m := range a.mechanisms {
+		res.Mechanisms[i] = types.NullableString{Length: int16(len(m)), Data: m}
+	}
+	if a.state != stateHandshake {
+		a.state = stateFailed
+		res.ErrorCode = constant.ILLEGAL_SASL_STATE
+		return res
+	}
+
+	mechanism := req.Mechanism.Data
+	if !slices.Contains(a.mechanisms, mechanism) {
+		a.state = stateFailed
+		res.ErrorCode = constant.UNSUPPORTED_SASL_MECHANISM
+		return res
+	}
+	if mechanism == Plain {
+		a.server = &plainServer{credentials: a.credentials}
+	} else {
+		a.server = &scramServer{mechanism: ParseMechanism(mechanism), credentials: a.credentials}
+	}
+	a.state = stateAuthenticate
+	return res
+}
+
+func (a *Authenticator) Authenticate(req *request.SaslAuthenticateV2) *response.SaslAuthenticateV2 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res := &response.SaslAuthenticateV2{Version: req.Version, AuthBytes: []byte{}}
+	if a.state != stateAuthenticate {
+		a.state = stateFailed
+		res.ErrorCode = constant.ILLEGAL_SASL_STATE
+		res.ErrorMessage = toNullableString("Unexpected SaslAuthenticate request")
+		return res
+	}
+
+	challenge, err := a.server.evaluate(req.AuthBytes)
+	if err != nil {
+		a.state = stateFailed
+		res.ErrorCode = constant.SASL_AUTHENTICATION_FAILED
+		res.ErrorMessage = toNullableString(err.Error())
+		return res
+	}
+	res.AuthBytes = challenge
+	if a.server.complete() {
+		a.state = stateAuthenticated
+		a.principal = "User:" + a.server.user()
+	}
+	return res
+}
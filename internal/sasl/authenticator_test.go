@@ -0,0 +1,273 @@
+package sasl
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"testing"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+const password = "alice-secret"
+
+// newCredentials returns a credential store where alice has a
+// SCRAM-SHA-256 password.
+func newCredentials(t *testing.T) *CredentialStore {
+	t.Helper()
+	logs, err := storage.NewLogManager(t.TempDir(), storage.DefaultLogConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logs.Close() })
+	log, err := metadata.OpenLog(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials := NewCredentialStore(log)
+
+	salt := []byte("salt")
+	saltedPassword, err := ScramSha256.SaltedPassword(password, salt, MinIterations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := credentials.AlterUserScramCredentials(&request.AlterUserScramCredentialsV0{
+		Upsertions: []request.ScramCredentialUpsertion{{
+			Name:           "alice",
+			Mechanism:      int8(ScramSha256),
+			Iterations:     MinIterations,
+			Salt:           salt,
+			SaltedPassword: saltedPassword,
+		}},
+	})
+	if code := res.Results[0].ErrorCode; code != 0 {
+		t.Fatalf("setting the credential of alice: error %d", code)
+	}
+	return credentials
+}
+
+func handshake(t *testing.T, a *Authenticator, mechanism string) int16 {
+	t.Helper()
+	return a.Handshake(&request.SaslHandshakeV1{
+		Mechanism: types.NullableString{Length: int16(len(mechanism)), Data: mechanism},
+	}).ErrorCode
+}
+
+func authenticate(a *Authenticator, authBytes string) (string, int16) {
+	res := a.Authenticate(&request.SaslAuthenticateV2{Version: 2, AuthBytes: []byte(authBytes)})
+	return string(res.AuthBytes), res.ErrorCode
+}
+
+// checkState checks the outcome of an exchange, and that only a
+// successful one lets other requests through.
+func checkState(t *testing.T, a *Authenticator, wantPrincipal string) {
+	t.Helper()
+	if wantPrincipal == "" {
+		if a.Complete() || !a.Failed() {
+			t.Errorf("complete %t, failed %t after a failed exchange", a.Complete(), a.Failed())
+		}
+		if a.Allowed(constant.Metadata) {
+			t.Error("Metadata allowed after a failed exchange")
+		}
+		return
+	}
+	if !a.Complete() || a.Failed() {
+		t.Errorf("complete %t, failed %t after a successful exchange", a.Complete(), a.Failed())
+	}
+	if p := a.Principal(); p != wantPrincipal {
+		t.Errorf("principal %q, want %q", p, wantPrincipal)
+	}
+	if !a.Allowed(constant.Metadata) {
+		t.Error("Metadata not allowed after a successful exchange")
+	}
+}
+
+func TestPlain(t *testing.T) {
+	credentials := newCredentials(t)
+	tests := []struct {
+		name          string
+		message       string
+		wantCode      int16
+		wantPrincipal string
+	}{
+		{"valid", "\x00alice\x00" + password, 0, "User:alice"},
+		{"authorization id of the user", "alice\x00alice\x00" + password, 0, "User:alice"},
+		{"bad password", "\x00alice\x00wrong", constant.SASL_AUTHENTICATION_FAILED, ""},
+		{"unknown user", "\x00bob\x00" + password, constant.SASL_AUTHENTICATION_FAILED, ""},
+		{"other authorization id", "bob\x00alice\x00" + password, constant.SASL_AUTHENTICATION_FAILED, ""},
+		{"malformed", "alice", constant.SASL_AUTHENTICATION_FAILED, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuthenticator(credentials, []string{Plain})
+			if code := handshake(t, a, Plain); code != 0 {
+				t.Fatalf("handshake error %d", code)
+			}
+			if _, code := authenticate(a, tt.message); code != tt.wantCode {
+				t.Errorf("authenticate error %d, want %d", code, tt.wantCode)
+			}
+			checkState(t, a, tt.wantPrincipal)
+		})
+	}
+}
+
+// scramClient runs the client side of a SCRAM-SHA-256 exchange, letting
+// tests tamper with the client-final message.
+type scramClient struct {
+	user, password, nonce string
+	clientFirstBare       string
+	saltedPassword        []byte
+	authMessage           string
+}
+
+func (c *scramClient) first() string {
+	c.clientFirstBare = "n=" + c.user + ",r=" + c.nonce
+	return "n,," + c.clientFirstBare
+}
+
+// final answers the server-first message with the nonce given, or the
+// server's when empty, and a proof of the client's password.
+func (c *scramClient) final(t *testing.T, serverFirst, nonce string) string {
+	t.Helper()
+	attributes, err := parseAttributes(serverFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(attributes["r"], c.nonce) {
+		t.Fatalf("server nonce %q does not extend the client's %q", attributes["r"], c.nonce)
+	}
+	salt, err := base64.StdEncoding.DecodeString(attributes["s"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	iterations, err := strconv.Atoi(attributes["i"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.saltedPassword, err = ScramSha256.SaltedPassword(c.password, salt, iterations); err != nil {
+		t.Fatal(err)
+	}
+	if nonce == "" {
+		nonce = attributes["r"]
+	}
+
+	withoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte("n,,")) + ",r=" + nonce
+	c.authMessage = c.clientFirstBare + "," + serverFirst + "," + withoutProof
+	clientKey := ScramSha256.hmac(c.saltedPassword, "Client Key")
+	proof := ScramSha256.hmac(ScramSha256.digest(clientKey), c.authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)
+}
+
+func (c *scramClient) serverSignature() string {
+	return "v=" + base64.StdEncoding.EncodeToString(ScramSha256.hmac(ScramSha256.hmac(c.saltedPassword, "Server Key"), c.authMessage))
+}
+
+func TestScram(t *testing.T) {
+	credentials := newCredentials(t)
+	tests := []struct {
+		name          string
+		user          string
+		password      string
+		nonce         string
+		wantFirstCode int16
+		wantCode      int16
+		wantPrincipal string
+	}{
+		{name: "valid", user: "alice", password: password, wantPrincipal: "User:alice"},
+		{name: "bad proof", user: "alice", password: "wrong", wantCode: constant.SASL_AUTHENTICATION_FAILED},
+		{name: "nonce mismatch", user: "alice", password: password, nonce: "client-nonce-forged",
+			wantCode: constant.SASL_AUTHENTICATION_FAILED},
+		{name: "unknown user", user: "bob", password: password, wantFirstCode: constant.SASL_AUTHENTICATION_FAILED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuthenticator(credentials, []string{"SCRAM-SHA-256"})
+			if code := handshake(t, a, "SCRAM-SHA-256"); code != 0 {
+				t.Fatalf("handshake error %d", code)
+			}
+			c := &scramClient{user: tt.user, password: tt.password, nonce: "client-nonce"}
+			serverFirst, code := authenticate(a, c.first())
+			if code != tt.wantFirstCode {
+				t.Fatalf("client-first error %d, want %d", code, tt.wantFirstCode)
+			}
+			if code == 0 {
+				serverFinal, code := authenticate(a, c.final(t, serverFirst, tt.nonce))
+				if code != tt.wantCode {
+					t.Errorf("client-final error %d, want %d", code, tt.wantCode)
+				}
+				if code == 0 && serverFinal != c.serverSignature() {
+					t.Errorf("server-final %q, want %q", serverFinal, c.serverSignature())
+				}
+			}
+			checkState(t, a, tt.wantPrincipal)
+		})
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	credentials := newCredentials(t)
+
+	t.Run("unsupported mechanism", func(t *testing.T) {
+		a := NewAuthenticator(credentials, []string{Plain})
+		res := a.Handshake(&request.SaslHandshakeV1{Mechanism: types.NullableString{Length: 13, Data: "SCRAM-SHA-256"}})
+		if res.ErrorCode != constant.UNSUPPORTED_SASL_MECHANISM {
+			t.Errorf("error %d, want %d", res.ErrorCode, constant.UNSUPPORTED_SASL_MECHANISM)
+		}
+		if len(res.Mechanisms) != 1 || res.Mechanisms[0].Data != Plain {
+			t.Errorf("mechanisms %+v, want [%s]", res.Mechanisms, Plain)
+		}
+		checkState(t, a, "")
+	})
+
+	t.Run("twice", func(t *testing.T) {
+		a := NewAuthenticator(credentials, []string{Plain})
+		if code := handshake(t, a, Plain); code != 0 {
+			t.Fatalf("handshake error %d", code)
+		}
+		if code := handshake(t, a, Plain); code != constant.ILLEGAL_SASL_STATE {
+			t.Errorf("second handshake error %d, want %d", code, constant.ILLEGAL_SASL_STATE)
+		}
+		checkState(t, a, "")
+	})
+
+	t.Run("authenticate first", func(t *testing.T) {
+		a := NewAuthenticator(credentials, []string{Plain})
+		if _, code := authenticate(a, "\x00alice\x00"+password); code != constant.ILLEGAL_SASL_STATE {
+			t.Errorf("error %d, want %d", code, constant.ILLEGAL_SASL_STATE)
+		}
+		checkState(t, a, "")
+	})
+}
+
+func TestAllowed(t *testing.T) {
+	a := NewAuthenticator(newCredentials(t), []string{Plain})
+	for _, apiKey := range []int16{constant.ApiVersions, constant.SaslHandshake, constant.SaslAuthenticate} {
+		if !a.Allowed(apiKey) {
+			t.Errorf("%s not allowed before authentication", constant.ApiKeyNames[apiKey])
+		}
+	}
+	if a.Failed() {
+		t.Fatal("failed after SASL requests")
+	}
+	if a.Allowed(constant.Produce) {
+		t.Error("Produce allowed before authentication")
+	}
+	if !a.Failed() {
+		t.Error("not failed after a non-SASL request, so the connection would stay open")
+	}
+	// nothing more is handled on the failed connection
+	if a.Allowed(constant.ApiVersions) {
+		t.Error("ApiVersions allowed after a failed authentication")
+	}
+
+	if !Authenticated(AnonymousPrincipal).Allowed(constant.Produce) {
+		t.Error("Produce not allowed without SASL")
+	}
+}
@@ -0,0 +1,239 @@
+package sasl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+type credentialError struct {
+	code    int16
+	message string
+}
+
+func (e *credentialError) Error() string {
+	return e.message
+}
+
+func newCredentialError(code int16, format string, args ...any) *credentialError {
+	return &credentialError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// CredentialStore keeps the SCRAM credentials of users, persisted as
+// UserScramCredentialRecords in the metadata log.
+type CredentialStore struct {
+	mu    sync.RWMutex
+	log   *metadata.Log
+	users map[string]map[Mechanism]Credential
+}
+
+func NewCredentialStore(log *metadata.Log) *CredentialStore {
+	s := &CredentialStore{
+		log:   log,
+		users: make(map[string]map[Mechanism]Credential),
+	}
+	log.Register(s.replay)
+	return s
+}
+
+func (s *CredentialStore) replay(rec metadata.Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r := rec.(type) {
+	case *metadata.UserScramCredentialRecord:
+		user := s.users[string(r.Name)]
+		if user == nil {
+			user = make(map[Mechanism]Credential)
+			s.users[string(r.Name)] = user
+		}
+		user[Mechanism(r.Mechanism)] = Credential{
+			Salt:       r.Salt,
+			StoredKey:  r.StoredKey,
+			ServerKey:  r.ServerKey,
+			Iterations: r.Iterations,
+		}
+	case *metadata.RemoveUserScramCredentialRecord:
+		user := s.users[string(r.Name)]
+		delete(user, Mechanism(r.Mechanism))
+		if len(user) == 0 {
+			delete(s.users, string(r.Name))
+		}
+	}
+}
+
+// Get returns the credential of a user for a mechanism.
+func (s *CredentialStore) Get(user string, m Mechanism) (Credential, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.users[user][m]
+	return c, ok
+}
+
+// VerifyPassword checks a clear text password against the credentials of
+// a user, whichever mechanism they were set for.
+func (s *CredentialStore) VerifyPassword(user, password string) bool {
+	for _, m := range scramMechanisms {
+		if c, ok := s.Get(user, m); ok {
+			return m.verifyPassword(c, password)
+		}
+	}
+	return false
+}
+
+func toNullableString(s string) *types.CompactString {
+	cs := types.CompactString(s)
+	return &cs
+}
+
+func (s *CredentialStore) DescribeUserScramCredentials(req *request.DescribeUserScramCredentialsV0) *response.DescribeUserScramCredentialsV0 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := &response.DescribeUserScramCredentialsV0{Results: []response.DescribeUserScramCredentialsResult{}}
+	var users []string
+	if len(req.Users) == 0 {
+		for user := range s.users {
+			users = append(users, user)
+		}
+		sort.Strings(users)
+	} else {
+		for _, u := range req.Users {
+			users = append(users, string(u.Name))
+		}
+	}
+
+	count := make(map[string]int)
+	for _, user := range users {
+		count[user]++
+	}
+	for _, user := range users {
+		result := response.DescribeUserScramCredentialsResult{
+			User:            types.CompactString(user),
+			CredentialInfos: []response.CredentialInfo{},
+		}
+		credentials, ok := s.users[user]
+		switch {
+		case count[user] > 1:
+			result.ErrorCode = constant.DUPLICATE_RESOURCE
+			result.ErrorMessage = toNullableString("Cannot describe SCRAM credentials for the same user twice in a single request: " + user)
+		case !ok:
+			result.ErrorCode = constant.RESOURCE_NOT_FOUND
+			result.ErrorMessage = toNullableString("Attempt to describe nonexistent SCRAM credential for user " + user)
+		default:
+			for _, m := range scramMechanisms {
+				if c, ok := credentials[m]; ok {
+					result.CredentialInfos = append(result.CredentialInfos, response.CredentialInfo{
+						Mechanism:  int8(m),
+						Iterations: c.Iterations,
+					})
+				}
+			}
+		}
+		res.Results = append(res.Results, result)
+	}
+	return res
+}
+
+// AlterUserScramCredentials applies the changes of each user atomically:
+// a user's deletions and upsertions are all rejected when one of them is
+// invalid.
+func (s *CredentialStore) AlterUserScramCredentials(req *request.AlterUserScramCredentialsV0) *response.AlterUserScramCredentialsV0 {
+	type change struct {
+		user      string
+		mechanism Mechanism
+		record    metadata.Record
+		err       error
+	}
+	var changes []change
+	for _, d := range req.Deletions {
+		changes = append(changes, change{
+			user:      string(d.Name),
+			mechanism: Mechanism(d.Mechanism),
+			record:    &metadata.RemoveUserScramCredentialRecord{Name: d.Name, Mechanism: d.Mechanism},
+		})
+	}
+	for _, u := range req.Upsertions {
+		m := Mechanism(u.Mechanism)
+		c := change{user: string(u.Name), mechanism: m}
+		switch {
+		case u.Iterations < MinIterations:
+			c.err = newCredentialError(constant.UNACCEPTABLE_CREDENTIAL, "Too few iterations")
+		case u.Iterations > MaxIterations:
+			c.err = newCredentialError(constant.UNACCEPTABLE_CREDENTIAL, "Too many iterations")
+		case len(u.Salt) == 0 || len(u.SaltedPassword) == 0:
+			c.err = newCredentialError(constant.UNACCEPTABLE_CREDENTIAL, "Salt and salted password must not be empty")
+		default:
+			credential := m.NewCredential(u.Salt, u.SaltedPassword, u.Iterations)
+			c.record = &metadata.UserScramCredentialRecord{
+				Name:       u.Name,
+				Mechanism:  u.Mechanism,
+				Salt:       credential.Salt,
+				StoredKey:  credential.StoredKey,
+				ServerKey:  credential.ServerKey,
+				Iterations: credential.Iterations,
+			}
+		}
+		changes = append(changes, c)
+	}
+
+	var users []string
+	errs := make(map[string]error)
+	seen := make(map[string]map[Mechanism]bool)
+	s.mu.RLock()
+	for i := range changes {
+		c := &changes[i]
+		if _, ok := seen[c.user]; !ok {
+			users = append(users, c.user)
+			seen[c.user] = make(map[Mechanism]bool)
+		}
+		switch {
+		case c.user == "":
+			c.err = newCredentialError(constant.UNACCEPTABLE_CREDENTIAL, "Username must not be empty")
+		case c.mechanism != ScramSha256 && c.mechanism != ScramSha512:
+			c.err = newCredentialError(constant.UNSUPPORTED_SASL_MECHANISM, "Unknown SCRAM mechanism")
+		case seen[c.user][c.mechanism]:
+			c.err = newCredentialError(constant.DUPLICATE_RESOURCE, "A user credential cannot be altered twice in the same request")
+		case c.err == nil && c.record.Type() == metadata.RemoveUserScramCredentialRecordType:
+			if _, ok := s.users[c.user][c.mechanism]; !ok {
+				c.err = newCredentialError(constant.RESOURCE_NOT_FOUND, "Attempt to delete a user credential that does not exist")
+			}
+		}
+		seen[c.user][c.mechanism] = true
+		if c.err != nil && errs[c.user] == nil {
+			errs[c.user] = c.err
+		}
+	}
+	s.mu.RUnlock()
+
+	var records []metadata.Record
+	for _, c := range changes {
+		if errs[c.user] == nil {
+			records = append(records, c.record)
+		}
+	}
+	if len(records) > 0 {
+		if err := s.log.Append(records...); err != nil {
+			for _, user := range users {
+				if errs[user] == nil {
+					errs[user] = newCredentialError(constant.UNKNOWN_SERVER_ERROR, "%s", err)
+				}
+			}
+		}
+	}
+
+	res := &response.AlterUserScramCredentialsV0{Results: make([]response.AlterUserScramCredentialsResult, len(users))}
+	for i, user := range users {
+		res.Results[i].User = types.CompactString(user)
+		if ce, ok := errs[user].(*credentialError); ok {
+			res.Results[i].ErrorCode = ce.code
+			res.Results[i].ErrorMessage = toNullableString(ce.message)
+		}
+	}
+	return res
+}
@@ -0,0 +1,35 @@
+package sasl
+
+import (
+	"errors"
+	"strings"
+)
+
+// plainServer checks the username and password sent in a single PLAIN
+// message against the SCRAM credentials of the user, so that PLAIN and
+// SCRAM users are managed the same way.
+type plainServer struct {
+	credentials *CredentialStore
+	username    string
+	done        bool
+}
+
+func (s *plainServer) evaluate(response []byte) ([]byte, error) {
+	// authzid NUL authcid NUL passwd
+	parts := strings.Split(string(response), "\x00")
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, errors.New("Invalid SASL/PLAIN response: expected 3 tokens and a non-empty username")
+	}
+	if parts[0] != "" && parts[0] != parts[1] {
+		return nil, errors.New("Authentication failed: Client requested an authorization id that is different from username")
+	}
+	if !s.credentials.VerifyPassword(parts[1], parts[2]) {
+		return nil, errors.New("Authentication failed: Invalid username or password")
+	}
+	s.username = parts[1]
+	s.done = true
+	return []byte{}, nil
+}
+
+func (s *plainServer) complete() bool { return s.done }
+func (s *plainServer) user() string   { return s.username }
@@ -0,0 +1,247 @@
+package sasl
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// Mechanism is a SCRAM mechanism as identified in the
+// DescribeUserScramCredentials and AlterUserScramCredentials APIs.
+type Mechanism int8
+
+const (
+	MechanismUnknown Mechanism = iota
+	ScramSha256
+	ScramSha512
+)
+
+// Iteration counts accepted for SCRAM credentials.
+const (
+	MinIterations = 4096
+	MaxIterations = 16384
+)
+
+var scramMechanisms = []Mechanism{ScramSha256, ScramSha512}
+
+func (m Mechanism) String() string {
+	switch m {
+	case ScramSha256:
+		return "SCRAM-SHA-256"
+	case ScramSha512:
+		return "SCRAM-SHA-512"
+	}
+	return "UNKNOWN"
+}
+
+// ParseMechanism returns the SCRAM mechanism with the given SASL name.
+func ParseMechanism(name string) Mechanism {
+	for _, m := range scramMechanisms {
+		if m.String() == name {
+			return m
+		}
+	}
+	return MechanismUnknown
+}
+
+func (m Mechanism) hash() func() hash.Hash {
+	if m == ScramSha512 {
+		return sha512.New
+	}
+	return sha256.New
+}
+
+func (m Mechanism) hmac(key []byte, data string) []byte {
+	mac := hmac.New(m.hash(), key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func (m Mechanism) digest(data []byte) []byte {
+	h := m.hash()()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// SaltedPassword computes Hi(password, salt, iterations) as defined in
+// RFC 5802, which clients send in AlterUserScramCredentials.
+func (m Mechanism) SaltedPassword(password string, salt []byte, iterations int) ([]byte, error) {
+	h := m.hash()
+	return pbkdf2.Key(h, password, salt, iterations, h().Size())
+}
+
+// Credential is what the broker keeps of a SCRAM password: enough to
+// verify a client proof and sign the server's reply, but not to
+// impersonate the user.
+type Credential struct {
+	Salt       []byte
+	StoredKey  []byte
+	ServerKey  []byte
+	Iterations int32
+}
+
+func (m Mechanism) NewCredential(salt, saltedPassword []byte, iterations int32) Credential {
+	return Credential{
+		Salt:       salt,
+		StoredKey:  m.digest(m.hmac(saltedPassword, "Client Key")),
+		ServerKey:  m.hmac(saltedPassword, "Server Key"),
+		Iterations: iterations,
+	}
+}
+
+// verifyPassword checks a clear text password against the credential.
+func (m Mechanism) verifyPassword(c Credential, password string) bool {
+	saltedPassword, err := m.SaltedPassword(password, c.Salt, int(c.Iterations))
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(m.NewCredential(c.Salt, saltedPassword, c.Iterations).StoredKey, c.StoredKey)
+}
+
+// scramServer runs the server side of a SCRAM exchange: the client-first
+// message is answered with the salt, iterations and a nonce, and the
+// client-final message with the server signature once its proof checks
+// out.
+type scramServer struct {
+	mechanism   Mechanism
+	credentials *CredentialStore
+
+	username        string
+	credential      Credential
+	gs2Header       string
+	nonce           string
+	clientFirstBare string
+	serverFirst     string
+	done            bool
+}
+
+func (s *scramServer) invalidCredentials() error {
+	return fmt.Errorf("Authentication failed during authentication due to invalid credentials with SASL mechanism %s", s.mechanism)
+}
+
+func (s *scramServer) evaluate(response []byte) ([]byte, error) {
+	if s.serverFirst == "" {
+		return s.clientFirst(string(response))
+	}
+	return s.clientFinal(string(response))
+}
+
+func (s *scramServer) clientFirst(message string) ([]byte, error) {
+	// gs2-header "," client-first-message-bare
+	parts := strings.SplitN(message, ",", 3)
+	if len(parts) != 3 {
+		return nil, errors.New("Invalid SCRAM client first message")
+	}
+	if parts[0] != "n" && parts[0] != "y" {
+		return nil, errors.New("Channel binding is not supported")
+	}
+	s.gs2Header = parts[0] + "," + parts[1] + ","
+	s.clientFirstBare = parts[2]
+
+	attributes, err := parseAttributes(s.clientFirstBare)
+	if err != nil {
+		return nil, err
+	}
+	username, ok := attributes["n"]
+	if !ok {
+		return nil, errors.New("Invalid SCRAM client first message: missing username")
+	}
+	if s.username, err = unescapeUsername(username); err != nil {
+		return nil, err
+	}
+	if authzid, ok := strings.CutPrefix(parts[1], "a="); ok && authzid != username {
+		return nil, errors.New("Authentication failed: Client requested an authorization id that is different from username")
+	}
+	clientNonce, ok := attributes["r"]
+	if !ok || clientNonce == "" {
+		return nil, errors.New("Invalid SCRAM client first message: missing nonce")
+	}
+
+	if s.credential, ok = s.credentials.Get(s.username, s.mechanism); !ok {
+		return nil, s.invalidCredentials()
+	}
+	serverNonce := make([]byte, 24)
+	rand.Read(serverNonce)
+	s.nonce = clientNonce + base64.RawStdEncoding.EncodeToString(serverNonce)
+	s.serverFirst = "r=" + s.nonce +
+		",s=" + base64.StdEncoding.EncodeToString(s.credential.Salt) +
+		",i=" + strconv.Itoa(int(s.credential.Iterations))
+	return []byte(s.serverFirst), nil
+}
+
+func (s *scramServer) clientFinal(message string) ([]byte, error) {
+	withoutProof, proof, ok := strings.Cut(message, ",p=")
+	if !ok {
+		return nil, errors.New("Invalid SCRAM client final message: missing proof")
+	}
+	attributes, err := parseAttributes(withoutProof)
+	if err != nil {
+		return nil, err
+	}
+	if attributes["c"] != base64.StdEncoding.EncodeToString([]byte(s.gs2Header)) {
+		return nil, errors.New("Invalid SCRAM client final message: channel binding does not match")
+	}
+	if attributes["r"] != s.nonce {
+		return nil, errors.New("Invalid SCRAM client final message: nonce does not match")
+	}
+	clientProof, err := base64.StdEncoding.DecodeString(proof)
+	if err != nil || len(clientProof) != len(s.credential.StoredKey) {
+		return nil, errors.New("Invalid SCRAM client final message: malformed proof")
+	}
+
+	authMessage := s.clientFirstBare + "," + s.serverFirst + "," + withoutProof
+	clientKey := s.mechanism.hmac(s.credential.StoredKey, authMessage)
+	for i := range clientKey {
+		clientKey[i] ^= clientProof[i]
+	}
+	if !hmac.Equal(s.mechanism.digest(clientKey), s.credential.StoredKey) {
+		return nil, s.invalidCredentials()
+	}
+	s.done = true
+	serverSignature := s.mechanism.hmac(s.credential.ServerKey, authMessage)
+	return []byte("v=" + base64.StdEncoding.EncodeToString(serverSignature)), nil
+}
+
+func (s *scramServer) complete() bool { return s.done }
+func (s *scramServer) user() string   { return s.username }
+
+// parseAttributes splits a SCRAM message into its single letter attributes.
+func parseAttributes(message string) (map[string]string, error) {
+	attributes := make(map[string]string)
+	for _, attribute := range strings.Split(message, ",") {
+		name, value, ok := strings.Cut(attribute, "=")
+		if !ok || len(name) != 1 {
+			return nil, fmt.Errorf("Invalid SCRAM attribute %q", attribute)
+		}
+		attributes[name] = value
+	}
+	return attributes, nil
+}
+
+func unescapeUsername(username string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(username); i++ {
+		if username[i] != '=' {
+			b.WriteByte(username[i])
+			continue
+		}
+		switch {
+		case strings.HasPrefix(username[i:], "=2C"):
+			b.WriteByte(',')
+		case strings.HasPrefix(username[i:], "=3D"):
+			b.WriteByte('=')
+		default:
+			return "", fmt.Errorf("Invalid SCRAM username %q", username)
+		}
+		i += 2
+	}
+	return b.String(), nil
+}
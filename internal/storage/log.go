@@ -0,0 +1,566 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+var (
+	ErrOffsetOutOfRange = errors.New("offset out of range")
+	ErrRecordTooLarge   = errors.New("record batch too large")
+)
+
+// LogConfig holds the settings of a partition log. Retention of -1 keeps
+// data forever.
+type LogConfig struct {
+	SegmentBytes       int64
+	SegmentMs          int64
+	IndexIntervalBytes int
+	MaxMessageBytes    int
+	RetentionMs        int64
+	RetentionBytes     int64
+}
+
+// DefaultLogConfig is used for logs without a topic configuration, such as
+// the logs the broker keeps for itself, so nothing is deleted by default.
+var DefaultLogConfig = LogConfig{
+	SegmentBytes:       1 << 30,
+	SegmentMs:          7 * 24 * 60 * 60 * 1000,
+	IndexIntervalBytes: 4096,
+	MaxMessageBytes:    1048588,
+	RetentionMs:        -1,
+	RetentionBytes:     -1,
+}
+
+// ErrorCode maps a storage error to the protocol error code sent to clients.
+func ErrorCode(err error) int16 {
+	switch {
+	case err == nil:
+		return constant.NONE
+	case errors.Is(err, ErrOutOfOrderSequence):
+		return constant.OUT_OF_ORDER_SEQUENCE_NUMBER
+	case errors.Is(err, ErrInvalidProducerEpoch):
+		return constant.INVALID_PRODUCER_EPOCH
+	case errors.Is(err, ErrOffsetOutOfRange):
+		return constant.OFFSET_OUT_OF_RANGE
+	case errors.Is(err, ErrRecordTooLarge):
+		return constant.MESSAGE_TOO_LARGE
+	case errors.Is(err, record.ErrCorruptBatch), errors.Is(err, record.ErrUnsupportedMagic):
+		return constant.CORRUPT_MESSAGE
+	default:
+		return constant.KAFKA_STORAGE_ERROR
+	}
+}
+
+// AppendInfo describes where a batch ended up in the log.
+type AppendInfo struct {
+	FirstOffset    int64
+	LastOffset     int64
+	LogStartOffset int64
+	Duplicate      bool
+}
+
+// Log is the ordered, segmented log of a single partition.
+type Log struct {
+	mu             sync.RWMutex
+	Dir            string
+	config         LogConfig
+	segments       []*Segment
+	logStartOffset int64
+	logEndOffset   int64
+	producerState  *ProducerStateManager
+}
+
+func segmentBaseOffsets(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var offsets []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, LogFileSuffix) {
+			continue
+		}
+		offset, err := strconv.ParseInt(strings.TrimSuffix(name, LogFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets, nil
+}
+
+// OpenLog opens or creates the log in dir, recovering the active segment and
+// the producer state. The segments of a log that was closed cleanly are
+// trusted instead of being scanned.
+func OpenLog(dir string, config LogConfig, cleanShutdown bool) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	l := &Log{
+		Dir:           dir,
+		config:        config,
+		producerState: newProducerStateManager(dir),
+	}
+
+	baseOffsets, err := segmentBaseOffsets(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(baseOffsets) == 0 {
+		baseOffsets = []int64{0}
+	}
+	for i, baseOffset := range baseOffsets {
+		s, err := openSegment(dir, baseOffset)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.segments = append(l.segments, s)
+
+		switch active := i == len(baseOffsets)-1; {
+		case !cleanShutdown && (active || len(s.indexEntries) == 0 && s.size > 0):
+			err = s.recover(config.IndexIntervalBytes)
+		case active:
+			err = s.loadActive(config.IndexIntervalBytes)
+		default:
+			err = s.loadIndexes()
+		}
+		if err != nil {
+			l.Close()
+			return nil, fmt.Errorf("error recovering segment %d of %s: %s", baseOffset, dir, err)
+		}
+	}
+	l.logStartOffset = l.segments[0].BaseOffset
+	l.logEndOffset = l.activeSegment().nextOffset
+
+	if err := l.recoverProducerState(); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// recoverProducerState loads the latest snapshot and replays the batches
+// appended after it.
+func (l *Log) recoverProducerState() error {
+	snapshotOffset, err := l.producerState.loadLatestSnapshot(l.logEndOffset)
+	if err != nil {
+		return err
+	}
+	for i, s := range l.segments {
+		if i+1 < len(l.segments) && l.segments[i+1].BaseOffset <= snapshotOffset {
+			continue
+		}
+		_, err := s.readBatches(0, func(b *record.RecordBatch, _ int64) {
+			if b.BaseOffset < snapshotOffset {
+				return
+			}
+			if txn := l.abortedTxn(b); txn != nil && !s.hasAbortedTxn(txn.LastOffset) {
+				l.producerState.update(b)
+				// the last stable offset as of the marker, not of the
+				// recovered log end
+				txn.LastStableOffset = l.producerState.firstUnstableOffset()
+				if txn.LastStableOffset < 0 {
+					txn.LastStableOffset = b.LastOffset() + 1
+				}
+				s.abortedTxns = append(s.abortedTxns, *txn)
+				return
+			}
+			l.producerState.update(b)
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.writeTxnIndex(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// abortedTxn returns the transaction index entry an abort marker about to be
+// applied completes, if any.
+func (l *Log) abortedTxn(b *record.RecordBatch) *AbortedTxn {
+	controlType, ok := b.ControlType()
+	if !ok || controlType != record.ControlTypeAbort {
+		return nil
+	}
+	firstOffset := l.producerState.currentTxnFirstOffset(b.ProducerId)
+	if firstOffset < 0 {
+		return nil
+	}
+	return &AbortedTxn{
+		ProducerId:  b.ProducerId,
+		FirstOffset: firstOffset,
+		LastOffset:  b.BaseOffset,
+	}
+}
+
+func (l *Log) activeSegment() *Segment {
+	return l.segments[len(l.segments)-1]
+}
+
+func (l *Log) LogStartOffset() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.logStartOffset
+}
+
+func (l *Log) LogEndOffset() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.logEndOffset
+}
+
+// HighWatermark equals the log end offset since every partition has a single replica.
+func (l *Log) HighWatermark() int64 {
+	return l.LogEndOffset()
+}
+
+// LastStableOffset is the offset below which no transaction is still ongoing.
+func (l *Log) LastStableOffset() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lastStableOffset()
+}
+
+func (l *Log) lastStableOffset() int64 {
+	if first := l.producerState.firstUnstableOffset(); first >= 0 {
+		return first
+	}
+	return l.logEndOffset
+}
+
+// AbortedTransactions returns the aborted transactions overlapping the
+// offsets in [startOffset, endOffset).
+func (l *Log) AbortedTransactions(startOffset, endOffset int64) []AbortedTxn {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var txns []AbortedTxn
+	for _, s := range l.segments {
+		for _, txn := range s.abortedTxns {
+			if txn.LastOffset >= startOffset && txn.FirstOffset < endOffset {
+				txns = append(txns, txn)
+			}
+		}
+	}
+	return txns
+}
+
+// Append assigns offsets to the batch and writes it to the active segment.
+// Batches from idempotent producers that were already appended are not
+// written again; their original offsets are returned instead.
+func (l *Log) Append(b *record.RecordBatch) (*AppendInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b.Size() > l.config.MaxMessageBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds %d", ErrRecordTooLarge, b.Size(), l.config.MaxMessageBytes)
+	}
+	dup, err := l.producerState.checkBatch(b)
+	if err != nil {
+		return nil, err
+	}
+	if dup != nil {
+		return &AppendInfo{
+			FirstOffset:    dup.firstOffset(),
+			LastOffset:     dup.lastOffset,
+			LogStartOffset: l.logStartOffset,
+			Duplicate:      true,
+		}, nil
+	}
+
+	b.BaseOffset = l.logEndOffset
+	data := b.Bytes()
+	if l.shouldRoll(len(data), time.Now().UnixMilli()) {
+		if err := l.roll(); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.activeSegment().append(b, data, l.config.IndexIntervalBytes); err != nil {
+		return nil, err
+	}
+	abortedTxn := l.abortedTxn(b)
+	l.producerState.update(b)
+	l.logEndOffset = b.LastOffset() + 1
+	if abortedTxn != nil {
+		abortedTxn.LastStableOffset = l.lastStableOffset()
+		if err := l.activeSegment().appendAbortedTxn(*abortedTxn); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AppendInfo{
+		FirstOffset:    b.BaseOffset,
+		LastOffset:     b.LastOffset(),
+		LogStartOffset: l.logStartOffset,
+	}, nil
+}
+
+// shouldRoll reports whether a batch of size bytes must go to a new segment
+// because the active one would grow too large or has been open too long.
+func (l *Log) shouldRoll(size int, now int64) bool {
+	s := l.activeSegment()
+	if s.size == 0 {
+		return false
+	}
+	if s.size+int64(size) > l.config.SegmentBytes {
+		return true
+	}
+	first := s.firstTimestamp()
+	return l.config.SegmentMs > 0 && first >= 0 && now-first > l.config.SegmentMs
+}
+
+// roll starts a new segment at the log end offset, snapshotting the producer
+// state as of that offset first.
+func (l *Log) roll() error {
+	if err := l.activeSegment().sync(); err != nil {
+		return err
+	}
+	if err := l.producerState.takeSnapshot(l.logEndOffset); err != nil {
+		return err
+	}
+	s, err := openSegment(l.Dir, l.logEndOffset)
+	if err != nil {
+		return err
+	}
+	l.segments = append(l.segments, s)
+	return nil
+}
+
+// Read returns whole batches starting with the one containing offset, up to
+// maxBytes and stopping before maxOffset. At least one batch is returned even
+// if it is larger than maxBytes.
+func (l *Log) Read(offset int64, maxBytes int, maxOffset int64) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	ranges, err := l.batchRanges(offset, maxBytes, maxOffset)
+	if err != nil || ranges == nil {
+		return nil, err
+	}
+	var out []byte
+	for _, br := range ranges {
+		data := make([]byte, br.size)
+		if _, err := br.segment.log.ReadAt(data, br.position); err != nil {
+			return nil, err
+		}
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+// ReadRegions returns the batches Read would, as regions of the segment
+// files rather than their bytes, or nil when there are none. Every region
+// has a file of its own, opened for reading, which the caller must close;
+// the region stays readable even if its segment is deleted meanwhile.
+func (l *Log) ReadRegions(offset int64, maxBytes int, maxOffset int64) ([]types.FileRegion, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	ranges, err := l.batchRanges(offset, maxBytes, maxOffset)
+	if err != nil {
+		return nil, err
+	}
+	var regions []types.FileRegion
+	for _, br := range ranges {
+		f, err := os.Open(br.segment.log.Name())
+		if err != nil {
+			for _, fr := range regions {
+				fr.File.Close()
+			}
+			return nil, err
+		}
+		regions = append(regions, types.FileRegion{File: f, Position: br.position, Size: br.size})
+	}
+	return regions, nil
+}
+
+// batchRange is a run of consecutive batches in a segment file.
+type batchRange struct {
+	segment  *Segment
+	position int64
+	size     int64
+}
+
+// batchRanges finds the batches to read, with one range per segment they
+// are in. It must be called with the lock held.
+func (l *Log) batchRanges(offset int64, maxBytes int, maxOffset int64) ([]batchRange, error) {
+	if offset < l.logStartOffset || offset > l.logEndOffset {
+		return nil, fmt.Errorf("%w: %d not in [%d, %d]", ErrOffsetOutOfRange, offset, l.logStartOffset, l.logEndOffset)
+	}
+	if offset >= maxOffset {
+		return nil, nil
+	}
+
+	i := sort.Search(len(l.segments), func(i int) bool { return l.segments[i].BaseOffset > offset }) - 1
+	var ranges []batchRange
+	total := int64(0)
+	for ; i < len(l.segments); i++ {
+		s := l.segments[i]
+		position, err := s.findBatch(offset)
+		if err != nil {
+			return nil, err
+		}
+		if position < 0 {
+			continue
+		}
+		br := batchRange{segment: s, position: position}
+		for position < s.size {
+			var header [record.LogOverhead]byte
+			if _, err := s.log.ReadAt(header[:], position); err != nil {
+				return nil, err
+			}
+			batchSize := int64(record.LogOverhead) + int64(binary.BigEndian.Uint32(header[8:]))
+			if int64(binary.BigEndian.Uint64(header[0:])) >= maxOffset ||
+				(total > 0 && total+batchSize > int64(maxBytes)) {
+				return appendRange(ranges, br), nil
+			}
+			br.size += batchSize
+			total += batchSize
+			position += batchSize
+		}
+		ranges = appendRange(ranges, br)
+		if i+1 < len(l.segments) {
+			offset = l.segments[i+1].BaseOffset
+		}
+	}
+	return ranges, nil
+}
+
+func appendRange(ranges []batchRange, br batchRange) []batchRange {
+	if br.size == 0 {
+		return ranges
+	}
+	return append(ranges, br)
+}
+
+// SetConfig applies a new configuration; it takes effect from the next
+// append or retention check.
+func (l *Log) SetConfig(config LogConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+}
+
+// DeleteExpiredSegments deletes the oldest segments that fall outside the
+// retention time or size and moves the log start offset past them. The
+// active segment is never deleted.
+func (l *Log) DeleteExpiredSegments(now int64) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	size := int64(0)
+	for _, s := range l.segments {
+		size += s.size
+	}
+	deleted := 0
+	for len(l.segments) > 1 {
+		s := l.segments[0]
+		expired := l.config.RetentionMs >= 0 && s.maxTimestamp >= 0 && now-s.maxTimestamp > l.config.RetentionMs
+		tooLarge := l.config.RetentionBytes >= 0 && size-s.size >= l.config.RetentionBytes
+		if !expired && !tooLarge {
+			break
+		}
+		if err := s.delete(); err != nil {
+			return deleted, fmt.Errorf("error deleting segment %d of %s: %s", s.BaseOffset, l.Dir, err)
+		}
+		size -= s.size
+		l.segments = l.segments[1:]
+		l.logStartOffset = l.segments[0].BaseOffset
+		deleted++
+	}
+	return deleted, nil
+}
+
+// OffsetForTimestamp returns the offset and timestamp of the first record
+// with a timestamp at or after timestamp, or -1 and -1 when there is none.
+func (l *Log) OffsetForTimestamp(timestamp int64) (int64, int64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, s := range l.segments {
+		offset, t, ok, err := s.offsetForTimestamp(timestamp)
+		if err != nil {
+			return -1, -1, err
+		}
+		if ok {
+			return max(offset, l.logStartOffset), t, nil
+		}
+	}
+	return -1, -1, nil
+}
+
+// OffsetOfMaxTimestamp returns the offset and timestamp of the first record
+// with the largest timestamp, or -1 and -1 when the log is empty.
+func (l *Log) OffsetOfMaxTimestamp() (int64, int64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var latest *Segment
+	for _, s := range l.segments {
+		if s.maxTimestamp >= 0 && (latest == nil || s.maxTimestamp > latest.maxTimestamp) {
+			latest = s
+		}
+	}
+	if latest == nil {
+		return -1, -1, nil
+	}
+	offset, t, _, err := latest.offsetForTimestamp(latest.maxTimestamp)
+	if err != nil {
+		return -1, -1, err
+	}
+	return offset, t, nil
+}
+
+// ProducerState returns the idempotent producers known to the partition.
+func (l *Log) ProducerState() []ProducerStateEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.producerState.Producers()
+}
+
+// Flush writes everything appended so far to disk.
+func (l *Log) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.activeSegment().sync()
+}
+
+// Close snapshots the producer state and closes every segment.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var firstErr error
+	if len(l.segments) > 0 {
+		if err := l.activeSegment().sync(); err != nil {
+			firstErr = err
+		}
+		if err := l.producerState.takeSnapshot(l.logEndOffset); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, s := range l.segments {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	l.segments = nil
+	return firstErr
+}
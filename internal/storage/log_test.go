@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+)
+
+// txnBatch returns a transactional batch of n records from the producer.
+func txnBatch(producerId int64, baseSeq int32, n int) *record.RecordBatch {
+	b := idempotentBatch(producerId, 0, baseSeq, n)
+	b.Attributes |= record.TransactionalFlagMask
+	return b
+}
+
+// interleavedTxns appends transactions of producers 1 and 2 interleaved with
+// each other and with an idempotent producer 3, checking the last stable
+// offset after each append.
+func interleavedTxns(t *testing.T, l *Log) {
+	t.Helper()
+	steps := []struct {
+		name    string
+		batch   *record.RecordBatch
+		wantLSO int64
+	}{
+		{"producer 1 begins at 0", txnBatch(1, 0, 2), 0},
+		{"idempotent batch at 2", idempotentBatch(3, 0, 0, 1), 0},
+		{"producer 2 begins at 3", txnBatch(2, 0, 1), 0},
+		{"producer 1 commits at 4", record.NewEndTxnMarker(1, 0, 0, true, 0), 3},
+		{"producer 1 begins again at 5", txnBatch(1, 2, 1), 3},
+		{"producer 2 aborts at 6", record.NewEndTxnMarker(2, 0, 0, false, 0), 5},
+		{"producer 1 aborts at 7", record.NewEndTxnMarker(1, 0, 0, false, 0), 8},
+		{"idempotent batch at 8", idempotentBatch(3, 0, 1, 1), 9},
+	}
+	now := time.Now().UnixMilli()
+	for _, step := range steps {
+		// recent timestamps, so that segments are not rolled for their age
+		step.batch.BaseTimestamp, step.batch.MaxTimestamp = now, now
+		appendBatch(t, l, step.batch)
+		if lso := l.LastStableOffset(); lso != step.wantLSO {
+			t.Errorf("%s: last stable offset %d, want %d", step.name, lso, step.wantLSO)
+		}
+	}
+}
+
+// wantAborted are the transactions interleavedTxns aborts.
+var wantAborted = []AbortedTxn{
+	{ProducerId: 2, FirstOffset: 3, LastOffset: 6, LastStableOffset: 5},
+	{ProducerId: 1, FirstOffset: 5, LastOffset: 7, LastStableOffset: 8},
+}
+
+func checkAbortedTransactions(t *testing.T, l *Log) {
+	t.Helper()
+	tests := []struct {
+		start, end int64
+		want       []AbortedTxn
+	}{
+		{0, 9, wantAborted},
+		{0, 3, nil},
+		{0, 4, wantAborted[:1]},
+		{4, 5, wantAborted[:1]},
+		{6, 7, wantAborted},
+		{7, 9, wantAborted[1:]},
+		{8, 9, nil},
+	}
+	for _, tt := range tests {
+		if got := l.AbortedTransactions(tt.start, tt.end); !slices.Equal(got, tt.want) {
+			t.Errorf("aborted transactions in [%d, %d) = %+v, want %+v", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestTransactionsInterleaved(t *testing.T) {
+	l := openTestLog(t, t.TempDir())
+	interleavedTxns(t, l)
+	checkAbortedTransactions(t, l)
+}
+
+func TestTransactionsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultLogConfig
+	// a segment per batch or two, so that transactions span segments
+	config.SegmentBytes = 100
+	l, err := OpenLog(dir, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interleavedTxns(t, l)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenLog(dir, config, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { reopened.Close() })
+	if len(reopened.segments) < 3 {
+		t.Errorf("log of %d segments, want transactions spanning several", len(reopened.segments))
+	}
+	if lso := reopened.LastStableOffset(); lso != 9 {
+		t.Errorf("last stable offset %d, want 9", lso)
+	}
+	checkAbortedTransactions(t, reopened)
+}
+
+func TestTransactionIndexRebuilt(t *testing.T) {
+	dir := t.TempDir()
+	l, err := OpenLog(dir, DefaultLogConfig, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	interleavedTxns(t, l)
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// as after a crash before the index was written: without a producer
+	// snapshot either, the whole segment is replayed
+	if err := os.Remove(filepath.Join(dir, "00000000000000000000"+TxnIndexFileSuffix)); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenLog(dir, DefaultLogConfig, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { reopened.Close() })
+	if lso := reopened.LastStableOffset(); lso != 9 {
+		t.Errorf("last stable offset %d, want 9", lso)
+	}
+	checkAbortedTransactions(t, reopened)
+}
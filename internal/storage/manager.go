@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RetentionCheckInterval is how often logs are checked for segments to delete.
+	RetentionCheckInterval = 5 * time.Minute
+
+	// CleanShutdownFile marks a log directory whose logs were all flushed
+	// and closed, so they need no recovery when they are opened again.
+	CleanShutdownFile = ".kafka_cleanshutdown"
+)
+
+// LogManager owns the partition logs under a log directory, one
+// <topic>-<partition> subdirectory per partition.
+type LogManager struct {
+	mu     sync.Mutex
+	Dir    string
+	config LogConfig
+	logs   map[string]*Log
+	// cleanShutdown is set when the directory was closed cleanly
+	cleanShutdown bool
+
+	// TopicConfig, when set, returns the configuration of a topic's logs
+	// instead of the manager's default one.
+	TopicConfig func(topic string) LogConfig
+}
+
+// NewLogManager opens a log directory. The clean shutdown marker is
+// removed right away, so that the logs are recovered should the broker
+// stop before it closes them again.
+func NewLogManager(dir string, config LogConfig) (*LogManager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	m := &LogManager{
+		Dir:    dir,
+		config: config,
+		logs:   make(map[string]*Log),
+	}
+	marker := filepath.Join(dir, CleanShutdownFile)
+	if _, err := os.Stat(marker); err == nil {
+		if err := os.Remove(marker); err != nil {
+			return nil, err
+		}
+		m.cleanShutdown = true
+	}
+	return m, nil
+}
+
+// CleanShutdown reports whether the logs were closed cleanly when the
+// broker last stopped.
+func (m *LogManager) CleanShutdown() bool {
+	return m.cleanShutdown
+}
+
+func partitionDirName(topic string, partition int32) string {
+	return fmt.Sprintf("%s-%d", topic, partition)
+}
+
+// parsePartitionDirName splits a partition directory name into its topic and
+// partition.
+func parsePartitionDirName(name string) (string, int32, bool) {
+	i := strings.LastIndexByte(name, '-')
+	if i <= 0 {
+		return "", 0, false
+	}
+	partition, err := strconv.ParseInt(name[i+1:], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:i], int32(partition), true
+}
+
+func (m *LogManager) topicConfig(topic string) LogConfig {
+	if m.TopicConfig != nil {
+		return m.TopicConfig(topic)
+	}
+	return m.config
+}
+
+// GetOrCreateLog opens the partition log, creating it if it does not exist yet.
+func (m *LogManager) GetOrCreateLog(topic string, partition int32) (*Log, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := partitionDirName(topic, partition)
+	if l, ok := m.logs[name]; ok {
+		return l, nil
+	}
+	l, err := OpenLog(filepath.Join(m.Dir, name), m.topicConfig(topic), m.cleanShutdown)
+	if err != nil {
+		return nil, err
+	}
+	m.logs[name] = l
+	return l, nil
+}
+
+// GetLog opens the partition log only if it already exists on disk.
+func (m *LogManager) GetLog(topic string, partition int32) (*Log, bool) {
+	m.mu.Lock()
+	_, open := m.logs[partitionDirName(topic, partition)]
+	m.mu.Unlock()
+	if !open {
+		if _, err := os.Stat(filepath.Join(m.Dir, partitionDirName(topic, partition))); err != nil {
+			return nil, false
+		}
+	}
+	l, err := m.GetOrCreateLog(topic, partition)
+	if err != nil {
+		return nil, false
+	}
+	return l, true
+}
+
+// DeleteTopic closes the logs of every partition of a topic and removes
+// them from disk.
+func (m *LogManager) DeleteTopic(topic string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var firstErr error
+	for name, l := range m.logs {
+		if t, _, ok := parsePartitionDirName(name); ok && t == topic {
+			if err := l.Close(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("error closing %s: %s", name, err)
+			}
+			delete(m.logs, name)
+		}
+	}
+	entries, err := os.ReadDir(m.Dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if t, _, ok := parsePartitionDirName(e.Name()); ok && e.IsDir() && t == topic {
+			if err := os.RemoveAll(filepath.Join(m.Dir, e.Name())); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Reconfigure applies the current configuration of a topic to its open
+// logs, or to every open log when topic is empty.
+func (m *LogManager) Reconfigure(topic string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, l := range m.logs {
+		t, _, ok := parsePartitionDirName(name)
+		if ok && (topic == "" || t == topic) {
+			l.SetConfig(m.topicConfig(t))
+		}
+	}
+}
+
+// DeleteExpiredSegments enforces retention on every open log.
+func (m *LogManager) DeleteExpiredSegments(now time.Time) {
+	m.mu.Lock()
+	logs := make(map[string]*Log, len(m.logs))
+	for name, l := range m.logs {
+		logs[name] = l
+	}
+	m.mu.Unlock()
+
+	for name, l := range logs {
+		deleted, err := l.DeleteExpiredSegments(now.UnixMilli())
+		if err != nil {
+			slog.Error("Failed to enforce retention", "log", name, "error", err)
+		}
+		if deleted > 0 {
+			slog.Info("Deleted segments", "log", name, "segments", deleted)
+		}
+	}
+}
+
+// Start enforces retention periodically until stop is closed.
+func (m *LogManager) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(RetentionCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				m.DeleteExpiredSegments(now)
+			}
+		}
+	}()
+}
+
+// Close flushes and closes every open log, then marks the directory as
+// closed cleanly unless a log failed to close.
+func (m *LogManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var firstErr error
+	for name, l := range m.logs {
+		if err := l.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("error closing %s: %s", name, err)
+		}
+		delete(m.logs, name)
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return writeCleanShutdownFile(m.Dir)
+}
+
+func writeCleanShutdownFile(dir string) error {
+	f, err := os.Create(filepath.Join(dir, CleanShutdownFile))
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// the marker only counts once its directory entry is durable too
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
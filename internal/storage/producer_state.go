@@ -0,0 +1,363 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+)
+
+const (
+	SnapshotFileSuffix = ".snapshot"
+
+	// NumBatchesToRetain is how many recent batches per producer are kept to
+	// detect duplicates.
+	NumBatchesToRetain = 5
+
+	producerSnapshotVersion int16 = 1
+	snapshotEntrySize             = 8 + 2 + 4 + 8 + 4 + 8 + 4 + 8
+)
+
+var (
+	ErrOutOfOrderSequence   = errors.New("out of order sequence number")
+	ErrInvalidProducerEpoch = errors.New("invalid producer epoch")
+	ErrCorruptSnapshot      = errors.New("corrupt producer state snapshot")
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+type batchMetadata struct {
+	firstSeq    int32
+	lastSeq     int32
+	lastOffset  int64
+	offsetDelta int32
+	timestamp   int64
+}
+
+func (b batchMetadata) firstOffset() int64 {
+	return b.lastOffset - int64(b.offsetDelta)
+}
+
+// ProducerStateEntry is what a partition remembers about one producer.
+type ProducerStateEntry struct {
+	ProducerId       int64
+	ProducerEpoch    int16
+	CoordinatorEpoch int32
+	// CurrentTxnFirstOffset is the first offset of the ongoing transaction, -1 when there is none
+	CurrentTxnFirstOffset int64
+	LastTimestamp         int64
+
+	batches []batchMetadata // oldest first
+}
+
+func (e *ProducerStateEntry) LastSequence() int32 {
+	if len(e.batches) == 0 {
+		return record.NoSequence
+	}
+	return e.batches[len(e.batches)-1].lastSeq
+}
+
+func (e *ProducerStateEntry) LastOffset() int64 {
+	if len(e.batches) == 0 {
+		return -1
+	}
+	return e.batches[len(e.batches)-1].lastOffset
+}
+
+func (e *ProducerStateEntry) findDuplicate(b *record.RecordBatch) *batchMetadata {
+	if b.ProducerEpoch != e.ProducerEpoch {
+		return nil
+	}
+	for i := range e.batches {
+		if e.batches[i].firstSeq == b.BaseSequence && e.batches[i].lastSeq == b.LastSequence() {
+			return &e.batches[i]
+		}
+	}
+	return nil
+}
+
+func (e *ProducerStateEntry) addBatch(m batchMetadata) {
+	e.batches = append(e.batches, m)
+	if len(e.batches) > NumBatchesToRetain {
+		e.batches = e.batches[len(e.batches)-NumBatchesToRetain:]
+	}
+}
+
+func inSequence(lastSeq, nextSeq int32) bool {
+	return nextSeq == lastSeq+1 || (nextSeq == 0 && lastSeq == math.MaxInt32)
+}
+
+// ProducerStateManager tracks idempotent producers for one partition and
+// persists their state in snapshot files next to the log segments.
+type ProducerStateManager struct {
+	dir       string
+	producers map[int64]*ProducerStateEntry
+}
+
+func newProducerStateManager(dir string) *ProducerStateManager {
+	return &ProducerStateManager{
+		dir:       dir,
+		producers: make(map[int64]*ProducerStateEntry),
+	}
+}
+
+// checkBatch validates the producer epoch and sequence of a batch about to be
+// appended. A non-nil batchMetadata means the batch was already appended.
+func (m *ProducerStateManager) checkBatch(b *record.RecordBatch) (*batchMetadata, error) {
+	if b.ProducerId == record.NoProducerId {
+		return nil, nil
+	}
+	e, ok := m.producers[b.ProducerId]
+	if !ok {
+		// the state may have been lost to retention; accept whatever comes first
+		return nil, nil
+	}
+	if b.IsControl() {
+		if b.ProducerEpoch < e.ProducerEpoch {
+			return nil, fmt.Errorf("%w: marker for producer %d has epoch %d, current epoch is %d",
+				ErrInvalidProducerEpoch, b.ProducerId, b.ProducerEpoch, e.ProducerEpoch)
+		}
+		return nil, nil
+	}
+	if dup := e.findDuplicate(b); dup != nil {
+		return dup, nil
+	}
+
+	if b.ProducerEpoch < e.ProducerEpoch {
+		return nil, fmt.Errorf("%w: producer %d epoch %d is older than current epoch %d",
+			ErrInvalidProducerEpoch, b.ProducerId, b.ProducerEpoch, e.ProducerEpoch)
+	}
+	if b.BaseSequence == record.NoSequence {
+		return nil, nil
+	}
+	if b.ProducerEpoch > e.ProducerEpoch {
+		if b.BaseSequence != 0 {
+			return nil, fmt.Errorf("%w: producer %d started epoch %d at sequence %d, expected 0",
+				ErrOutOfOrderSequence, b.ProducerId, b.ProducerEpoch, b.BaseSequence)
+		}
+		return nil, nil
+	}
+	if lastSeq := e.LastSequence(); lastSeq != record.NoSequence && !inSequence(lastSeq, b.BaseSequence) {
+		return nil, fmt.Errorf("%w: producer %d sent sequence %d, expected %d",
+			ErrOutOfOrderSequence, b.ProducerId, b.BaseSequence, lastSeq+1)
+	}
+	return nil, nil
+}
+
+// update records a batch that was appended at its base offset.
+func (m *ProducerStateManager) update(b *record.RecordBatch) {
+	if b.ProducerId == record.NoProducerId {
+		return
+	}
+	e, ok := m.producers[b.ProducerId]
+	if !ok {
+		e = &ProducerStateEntry{
+			ProducerId:            b.ProducerId,
+			ProducerEpoch:         b.ProducerEpoch,
+			CoordinatorEpoch:      -1,
+			CurrentTxnFirstOffset: -1,
+		}
+		m.producers[b.ProducerId] = e
+	}
+	if b.ProducerEpoch > e.ProducerEpoch {
+		e.ProducerEpoch = b.ProducerEpoch
+		e.batches = nil
+	}
+	e.LastTimestamp = b.MaxTimestamp
+	if b.IsControl() {
+		// a commit or abort marker ends the ongoing transaction
+		e.CurrentTxnFirstOffset = -1
+		if coordinatorEpoch, ok := b.CoordinatorEpoch(); ok {
+			e.CoordinatorEpoch = coordinatorEpoch
+		}
+	} else if b.IsTransactional() && e.CurrentTxnFirstOffset == -1 {
+		e.CurrentTxnFirstOffset = b.BaseOffset
+	}
+	if b.BaseSequence != record.NoSequence && !b.IsControl() {
+		e.addBatch(batchMetadata{
+			firstSeq:    b.BaseSequence,
+			lastSeq:     b.LastSequence(),
+			lastOffset:  b.LastOffset(),
+			offsetDelta: b.LastOffsetDelta,
+			timestamp:   b.MaxTimestamp,
+		})
+	}
+}
+
+// currentTxnFirstOffset returns the first offset of the producer's ongoing
+// transaction, or -1.
+func (m *ProducerStateManager) currentTxnFirstOffset(producerId int64) int64 {
+	if e, ok := m.producers[producerId]; ok {
+		return e.CurrentTxnFirstOffset
+	}
+	return -1
+}
+
+// firstUnstableOffset returns the first offset of the oldest ongoing
+// transaction, or -1 when there is none.
+func (m *ProducerStateManager) firstUnstableOffset() int64 {
+	first := int64(-1)
+	for _, e := range m.producers {
+		if e.CurrentTxnFirstOffset >= 0 && (first == -1 || e.CurrentTxnFirstOffset < first) {
+			first = e.CurrentTxnFirstOffset
+		}
+	}
+	return first
+}
+
+// Producers returns the state of every known producer ordered by producer id.
+func (m *ProducerStateManager) Producers() []ProducerStateEntry {
+	entries := make([]ProducerStateEntry, 0, len(m.producers))
+	for _, e := range m.producers {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ProducerId < entries[j].ProducerId })
+	return entries
+}
+
+func snapshotFileName(dir string, offset int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", offset, SnapshotFileSuffix))
+}
+
+// snapshotOffsets lists the offsets of the snapshot files in ascending order.
+func (m *ProducerStateManager) snapshotOffsets() ([]int64, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	var offsets []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, SnapshotFileSuffix) {
+			continue
+		}
+		offset, err := strconv.ParseInt(strings.TrimSuffix(name, SnapshotFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets, nil
+}
+
+// takeSnapshot writes the state as of offset, keeping only the previous
+// snapshot around.
+func (m *ProducerStateManager) takeSnapshot(offset int64) error {
+	entries := m.Producers()
+	buf := make([]byte, 0, 10+len(entries)*snapshotEntrySize)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(producerSnapshotVersion))
+	buf = binary.BigEndian.AppendUint32(buf, 0) // crc, filled in below
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(entries)))
+	for _, e := range entries {
+		offsetDelta := int32(0)
+		if n := len(e.batches); n > 0 {
+			offsetDelta = e.batches[n-1].offsetDelta
+		}
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.ProducerId))
+		buf = binary.BigEndian.AppendUint16(buf, uint16(e.ProducerEpoch))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(e.LastSequence()))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.LastOffset()))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(offsetDelta))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.LastTimestamp))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(e.CoordinatorEpoch))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.CurrentTxnFirstOffset))
+	}
+	binary.BigEndian.PutUint32(buf[2:], crc32.Checksum(buf[6:], crc32c))
+
+	path := snapshotFileName(m.dir, offset)
+	if err := os.WriteFile(path+".tmp", buf, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	offsets, err := m.snapshotOffsets()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(offsets)-2; i++ {
+		os.Remove(snapshotFileName(m.dir, offsets[i]))
+	}
+	return nil
+}
+
+// loadLatestSnapshot restores the newest snapshot taken at or before
+// maxOffset and returns its offset, or 0 when there is none.
+func (m *ProducerStateManager) loadLatestSnapshot(maxOffset int64) (int64, error) {
+	offsets, err := m.snapshotOffsets()
+	if err != nil {
+		return 0, err
+	}
+	for i := len(offsets) - 1; i >= 0; i-- {
+		if offsets[i] > maxOffset {
+			// written past what survived recovery
+			os.Remove(snapshotFileName(m.dir, offsets[i]))
+			continue
+		}
+		producers, err := ReadSnapshot(snapshotFileName(m.dir, offsets[i]))
+		if err != nil {
+			os.Remove(snapshotFileName(m.dir, offsets[i]))
+			continue
+		}
+		m.producers = producers
+		return offsets[i], nil
+	}
+	m.producers = make(map[int64]*ProducerStateEntry)
+	return 0, nil
+}
+
+// ReadSnapshot reads the producer state snapshot at path.
+func ReadSnapshot(path string) (map[int64]*ProducerStateEntry, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < 10 {
+		return nil, fmt.Errorf("%w: %s is too short", ErrCorruptSnapshot, path)
+	}
+	if version := int16(binary.BigEndian.Uint16(buf)); version != producerSnapshotVersion {
+		return nil, fmt.Errorf("%w: %s has unknown version %d", ErrCorruptSnapshot, path, version)
+	}
+	if crc := crc32.Checksum(buf[6:], crc32c); crc != binary.BigEndian.Uint32(buf[2:]) {
+		return nil, fmt.Errorf("%w: %s crc mismatch", ErrCorruptSnapshot, path)
+	}
+	count := int(binary.BigEndian.Uint32(buf[6:]))
+	if len(buf) != 10+count*snapshotEntrySize {
+		return nil, fmt.Errorf("%w: %s has %d bytes for %d entries", ErrCorruptSnapshot, path, len(buf), count)
+	}
+
+	producers := make(map[int64]*ProducerStateEntry, count)
+	for i := 10; i < len(buf); i += snapshotEntrySize {
+		e := &ProducerStateEntry{
+			ProducerId:            int64(binary.BigEndian.Uint64(buf[i:])),
+			ProducerEpoch:         int16(binary.BigEndian.Uint16(buf[i+8:])),
+			LastTimestamp:         int64(binary.BigEndian.Uint64(buf[i+26:])),
+			CoordinatorEpoch:      int32(binary.BigEndian.Uint32(buf[i+34:])),
+			CurrentTxnFirstOffset: int64(binary.BigEndian.Uint64(buf[i+38:])),
+		}
+		lastSeq := int32(binary.BigEndian.Uint32(buf[i+10:]))
+		lastOffset := int64(binary.BigEndian.Uint64(buf[i+14:]))
+		offsetDelta := int32(binary.BigEndian.Uint32(buf[i+22:]))
+		if lastSeq != record.NoSequence {
+			e.batches = []batchMetadata{{
+				firstSeq:    int32((int64(lastSeq) - int64(offsetDelta) + (1 << 31)) % (1 << 31)),
+				lastSeq:     lastSeq,
+				lastOffset:  lastOffset,
+				offsetDelta: offsetDelta,
+				timestamp:   e.LastTimestamp,
+			}}
+		}
+		producers[e.ProducerId] = e
+	}
+	return producers, nil
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+)
+
+// idempotentBatch returns a batch of n records from the producer, starting
+// at sequence baseSeq.
+func idempotentBatch(producerId int64, producerEpoch int16, baseSeq int32, n int) *record.RecordBatch {
+	b := &record.RecordBatch{
+		LastOffsetDelta: int32(n - 1),
+		ProducerId:      producerId,
+		ProducerEpoch:   producerEpoch,
+		BaseSequence:    baseSeq,
+	}
+	for i := 0; i < n; i++ {
+		b.Records = append(b.Records, record.Record{OffsetDelta: int32(i), Value: []byte("v")})
+	}
+	return b
+}
+
+func openTestLog(t *testing.T, dir string) *Log {
+	t.Helper()
+	l, err := OpenLog(dir, DefaultLogConfig, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func appendBatch(t *testing.T, l *Log, b *record.RecordBatch) *AppendInfo {
+	t.Helper()
+	info, err := l.Append(b)
+	if err != nil {
+		t.Fatalf("append of sequence %d failed: %v", b.BaseSequence, err)
+	}
+	return info
+}
+
+func TestDuplicateBatch(t *testing.T) {
+	l := openTestLog(t, t.TempDir())
+	// six batches of two records, of which the last five are retained
+	var infos []*AppendInfo
+	for i := int32(0); i < 6; i++ {
+		infos = append(infos, appendBatch(t, l, idempotentBatch(1, 0, 2*i, 2)))
+	}
+	endOffset := l.logEndOffset
+
+	tests := []struct {
+		name    string
+		batch   int
+		wantErr error
+	}{
+		{"last batch", 5, nil},
+		{"oldest retained batch", 1, nil},
+		{"batch no longer retained", 0, ErrOutOfOrderSequence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := l.Append(idempotentBatch(1, 0, 2*int32(tt.batch), 2))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("append error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			original := infos[tt.batch]
+			if !info.Duplicate || info.FirstOffset != original.FirstOffset || info.LastOffset != original.LastOffset {
+				t.Errorf("append = %+v, want a duplicate at offsets %d-%d", info, original.FirstOffset, original.LastOffset)
+			}
+			if l.logEndOffset != endOffset {
+				t.Errorf("log end offset moved from %d to %d", endOffset, l.logEndOffset)
+			}
+		})
+	}
+}
+
+func TestSequenceValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		batch   *record.RecordBatch
+		wantErr error
+	}{
+		{"next sequence", idempotentBatch(1, 1, 2, 1), nil},
+		{"sequence gap", idempotentBatch(1, 1, 3, 1), ErrOutOfOrderSequence},
+		{"sequence going back", idempotentBatch(1, 1, 1, 1), ErrOutOfOrderSequence},
+		{"older epoch", idempotentBatch(1, 0, 2, 1), ErrInvalidProducerEpoch},
+		{"newer epoch from sequence 0", idempotentBatch(1, 2, 0, 1), nil},
+		{"newer epoch from a later sequence", idempotentBatch(1, 2, 2, 1), ErrOutOfOrderSequence},
+		{"unknown producer", idempotentBatch(2, 0, 7, 1), nil},
+		{"no producer", idempotentBatch(record.NoProducerId, record.NoProducerEpoch, record.NoSequence, 1), nil},
+		{"older epoch marker", record.NewEndTxnMarker(1, 0, 0, true, 0), ErrInvalidProducerEpoch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := openTestLog(t, t.TempDir())
+			appendBatch(t, l, idempotentBatch(1, 1, 0, 2))
+			if _, err := l.Append(tt.batch); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("append error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSequenceWrapsAround(t *testing.T) {
+	m := newProducerStateManager(t.TempDir())
+	b := idempotentBatch(1, 0, 1<<31-2, 2)
+	m.update(b)
+	if _, err := m.checkBatch(idempotentBatch(1, 0, 0, 1)); err != nil {
+		t.Errorf("sequence 0 after %d: %v", b.LastSequence(), err)
+	}
+}
+
+// stateManager returns a manager that saw an idempotent producer and a
+// producer with an ongoing transaction.
+func stateManager(t *testing.T, dir string) *ProducerStateManager {
+	t.Helper()
+	m := newProducerStateManager(dir)
+	offset := int64(0)
+	for _, b := range []*record.RecordBatch{
+		idempotentBatch(1, 3, 0, 2),
+		idempotentBatch(1, 3, 2, 3),
+		idempotentBatch(2, 0, 0, 1),
+	} {
+		b.BaseOffset = offset
+		b.MaxTimestamp = 1000 + offset
+		if b.ProducerId == 2 {
+			b.Attributes |= record.TransactionalFlagMask
+		}
+		m.update(b)
+		offset = b.LastOffset() + 1
+	}
+	return m
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	m := stateManager(t, dir)
+	if err := m.takeSnapshot(6); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newProducerStateManager(dir)
+	offset, err := restored.loadLatestSnapshot(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 6 {
+		t.Fatalf("loaded snapshot at offset %d, want 6", offset)
+	}
+
+	want, got := m.Producers(), restored.Producers()
+	if len(got) != len(want) {
+		t.Fatalf("restored %d producers, want %d", len(got), len(want))
+	}
+	for i := range want {
+		w, g := want[i], got[i]
+		if g.ProducerId != w.ProducerId || g.ProducerEpoch != w.ProducerEpoch || g.CoordinatorEpoch != w.CoordinatorEpoch ||
+			g.CurrentTxnFirstOffset != w.CurrentTxnFirstOffset || g.LastTimestamp != w.LastTimestamp ||
+			g.LastSequence() != w.LastSequence() || g.LastOffset() != w.LastOffset() {
+			t.Errorf("restored producer %+v, want %+v", g, w)
+		}
+	}
+	if first := restored.firstUnstableOffset(); first != 5 {
+		t.Errorf("first unstable offset %d, want 5", first)
+	}
+
+	// the last batch of a producer is still recognized as a duplicate
+	dup, err := restored.checkBatch(idempotentBatch(1, 3, 2, 3))
+	if err != nil || dup == nil || dup.firstOffset() != 2 || dup.lastOffset != 4 {
+		t.Errorf("checkBatch of the last batch = %+v, %v, want a duplicate at offsets 2-4", dup, err)
+	}
+	if _, err := restored.checkBatch(idempotentBatch(1, 3, 6, 1)); !errors.Is(err, ErrOutOfOrderSequence) {
+		t.Errorf("checkBatch of a sequence gap: %v, want %v", err, ErrOutOfOrderSequence)
+	}
+}
+
+func TestSnapshotsRetained(t *testing.T) {
+	dir := t.TempDir()
+	m := stateManager(t, dir)
+	for _, offset := range []int64{6, 10, 20} {
+		if err := m.takeSnapshot(offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	offsets, err := m.snapshotOffsets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offsets) != 2 || offsets[0] != 10 || offsets[1] != 20 {
+		t.Errorf("snapshots at %v, want [10 20]", offsets)
+	}
+}
+
+func TestCorruptSnapshot(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(buf []byte) []byte
+	}{
+		{"flipped byte", func(buf []byte) []byte { buf[len(buf)-1] ^= 0xff; return buf }},
+		{"wrong crc", func(buf []byte) []byte { buf[2] ^= 0xff; return buf }},
+		{"truncated", func(buf []byte) []byte { return buf[:len(buf)-1] }},
+		{"too short", func(buf []byte) []byte { return buf[:4] }},
+		{"unknown version", func(buf []byte) []byte { buf[1] = 9; return buf }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			m := stateManager(t, dir)
+			if err := m.takeSnapshot(6); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.takeSnapshot(10); err != nil {
+				t.Fatal(err)
+			}
+			path := snapshotFileName(dir, 10)
+			buf, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.corrupt(buf), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ReadSnapshot(path); !errors.Is(err, ErrCorruptSnapshot) {
+				t.Fatalf("ReadSnapshot error = %v, want %v", err, ErrCorruptSnapshot)
+			}
+
+			// the corrupt snapshot is deleted and the previous one loaded
+			restored := newProducerStateManager(dir)
+			offset, err := restored.loadLatestSnapshot(10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if offset != 6 {
+				t.Errorf("loaded snapshot at offset %d, want 6", offset)
+			}
+			if len(restored.Producers()) != 2 {
+				t.Errorf("restored %d producers, want 2", len(restored.Producers()))
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("corrupt snapshot was not deleted: %v", err)
+			}
+		})
+	}
+}
+
+func TestSnapshotPastLogEnd(t *testing.T) {
+	dir := t.TempDir()
+	m := stateManager(t, dir)
+	if err := m.takeSnapshot(20); err != nil {
+		t.Fatal(err)
+	}
+
+	// a snapshot past what survived recovery is deleted and the state rebuilt from scratch
+	restored := newProducerStateManager(dir)
+	offset, err := restored.loadLatestSnapshot(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 0 || len(restored.Producers()) != 0 {
+		t.Errorf("loaded snapshot at offset %d with %d producers, want none", offset, len(restored.Producers()))
+	}
+	if _, err := os.Stat(snapshotFileName(dir, 20)); !os.IsNotExist(err) {
+		t.Errorf("snapshot past the log end was not deleted: %v", err)
+	}
+}
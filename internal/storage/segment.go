@@ -0,0 +1,496 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+)
+
+const (
+	LogFileSuffix       = ".log"
+	IndexFileSuffix     = ".index"
+	TimeIndexFileSuffix = ".timeindex"
+	TxnIndexFileSuffix  = ".txnindex"
+
+	IndexEntrySize     = 8  // relative offset int32, position int32
+	TimeIndexEntrySize = 12 // timestamp int64, relative offset int32
+	TxnIndexEntrySize  = 34 // version int16, producer id, first, last and last stable offsets int64
+)
+
+// AbortedTxn is an entry of the transaction index: the offsets spanned by a
+// transaction that was aborted.
+type AbortedTxn struct {
+	ProducerId       int64
+	FirstOffset      int64
+	LastOffset       int64
+	LastStableOffset int64
+}
+
+// IndexEntry maps the offset of a batch, relative to the segment's base
+// offset, to its position in the segment file.
+type IndexEntry struct {
+	RelativeOffset int32
+	Position       int32
+}
+
+// TimeIndexEntry maps a timestamp to the relative offset of the last record
+// of the first batch whose max timestamp reaches it.
+type TimeIndexEntry struct {
+	Timestamp      int64
+	RelativeOffset int32
+}
+
+// DecodeIndex decodes an offset index file, ignoring a trailing partial entry.
+func DecodeIndex(data []byte) []IndexEntry {
+	entries := make([]IndexEntry, 0, len(data)/IndexEntrySize)
+	for i := 0; i+IndexEntrySize <= len(data); i += IndexEntrySize {
+		entries = append(entries, IndexEntry{
+			RelativeOffset: int32(binary.BigEndian.Uint32(data[i:])),
+			Position:       int32(binary.BigEndian.Uint32(data[i+4:])),
+		})
+	}
+	return entries
+}
+
+// DecodeTimeIndex decodes a time index file, ignoring a trailing partial entry.
+func DecodeTimeIndex(data []byte) []TimeIndexEntry {
+	entries := make([]TimeIndexEntry, 0, len(data)/TimeIndexEntrySize)
+	for i := 0; i+TimeIndexEntrySize <= len(data); i += TimeIndexEntrySize {
+		entries = append(entries, TimeIndexEntry{
+			Timestamp:      int64(binary.BigEndian.Uint64(data[i:])),
+			RelativeOffset: int32(binary.BigEndian.Uint32(data[i+8:])),
+		})
+	}
+	return entries
+}
+
+// DecodeTxnIndex decodes a transaction index file, ignoring a trailing
+// partial entry.
+func DecodeTxnIndex(data []byte) []AbortedTxn {
+	txns := make([]AbortedTxn, 0, len(data)/TxnIndexEntrySize)
+	for i := 0; i+TxnIndexEntrySize <= len(data); i += TxnIndexEntrySize {
+		txns = append(txns, AbortedTxn{
+			ProducerId:       int64(binary.BigEndian.Uint64(data[i+2:])),
+			FirstOffset:      int64(binary.BigEndian.Uint64(data[i+10:])),
+			LastOffset:       int64(binary.BigEndian.Uint64(data[i+18:])),
+			LastStableOffset: int64(binary.BigEndian.Uint64(data[i+26:])),
+		})
+	}
+	return txns
+}
+
+// Segment is one file of a partition log together with its sparse offset
+// and time indexes.
+type Segment struct {
+	BaseOffset int64
+
+	log       *os.File
+	index     *os.File
+	timeIndex *os.File
+	txnIndex  *os.File
+
+	size                     int64
+	nextOffset               int64
+	maxTimestamp             int64
+	bytesSinceLastIndexEntry int
+	indexEntries             []IndexEntry
+	timeIndexEntries         []TimeIndexEntry
+	abortedTxns              []AbortedTxn
+}
+
+func segmentFileName(dir string, baseOffset int64, suffix string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", baseOffset, suffix))
+}
+
+func openSegment(dir string, baseOffset int64) (*Segment, error) {
+	s := &Segment{BaseOffset: baseOffset, nextOffset: baseOffset, maxTimestamp: -1}
+	var err error
+	if s.log, err = os.OpenFile(segmentFileName(dir, baseOffset, LogFileSuffix), os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		return nil, err
+	}
+	if s.index, err = os.OpenFile(segmentFileName(dir, baseOffset, IndexFileSuffix), os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if s.timeIndex, err = os.OpenFile(segmentFileName(dir, baseOffset, TimeIndexFileSuffix), os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if s.txnIndex, err = os.OpenFile(segmentFileName(dir, baseOffset, TxnIndexFileSuffix), os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err = s.loadTxnIndex(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	info, err := s.log.Stat()
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	s.size = info.Size()
+	return s, nil
+}
+
+// readBatches calls fn for every valid batch in the segment from position
+// from on, and returns the position the valid batches end at.
+func (s *Segment) readBatches(from int64, fn func(b *record.RecordBatch, position int64)) (int64, error) {
+	data := make([]byte, s.size-from)
+	if _, err := s.log.ReadAt(data, from); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	r := bytes.NewReader(data)
+	position := from
+	for r.Len() > 0 {
+		b, err := record.ReadRecordBatch(r)
+		if err != nil {
+			break
+		}
+		fn(b, position)
+		position = s.size - int64(r.Len())
+	}
+	return position, nil
+}
+
+// recover truncates any trailing partial or corrupt batch and rebuilds both
+// indexes.
+func (s *Segment) recover(indexIntervalBytes int) error {
+	s.indexEntries = s.indexEntries[:0]
+	s.timeIndexEntries = s.timeIndexEntries[:0]
+	s.bytesSinceLastIndexEntry = 0
+	s.nextOffset = s.BaseOffset
+	s.maxTimestamp = -1
+
+	validBytes, err := s.readBatches(0, func(b *record.RecordBatch, position int64) {
+		s.trackBatch(b, int32(position), indexIntervalBytes)
+	})
+	if err != nil {
+		return err
+	}
+	if validBytes != s.size {
+		if err := s.log.Truncate(validBytes); err != nil {
+			return err
+		}
+		s.size = validBytes
+	}
+
+	// drop aborted transactions whose markers did not survive
+	kept := s.abortedTxns[:0]
+	for _, txn := range s.abortedTxns {
+		if txn.LastOffset < s.nextOffset {
+			kept = append(kept, txn)
+		}
+	}
+	s.abortedTxns = kept
+	if err := s.writeTxnIndex(); err != nil {
+		return err
+	}
+	return s.writeIndexes()
+}
+
+func (s *Segment) loadTxnIndex() error {
+	data, err := io.ReadAll(io.NewSectionReader(s.txnIndex, 0, 1<<40))
+	if err != nil {
+		return err
+	}
+	s.abortedTxns = DecodeTxnIndex(data)
+	return nil
+}
+
+func encodeAbortedTxn(buf []byte, txn AbortedTxn) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, 0) // version
+	buf = binary.BigEndian.AppendUint64(buf, uint64(txn.ProducerId))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(txn.FirstOffset))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(txn.LastOffset))
+	return binary.BigEndian.AppendUint64(buf, uint64(txn.LastStableOffset))
+}
+
+func (s *Segment) writeTxnIndex() error {
+	buf := make([]byte, 0, len(s.abortedTxns)*TxnIndexEntrySize)
+	for _, txn := range s.abortedTxns {
+		buf = encodeAbortedTxn(buf, txn)
+	}
+	if err := s.txnIndex.Truncate(0); err != nil {
+		return err
+	}
+	_, err := s.txnIndex.WriteAt(buf, 0)
+	return err
+}
+
+func (s *Segment) hasAbortedTxn(lastOffset int64) bool {
+	for _, txn := range s.abortedTxns {
+		if txn.LastOffset == lastOffset {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Segment) appendAbortedTxn(txn AbortedTxn) error {
+	buf := encodeAbortedTxn(make([]byte, 0, TxnIndexEntrySize), txn)
+	if _, err := s.txnIndex.WriteAt(buf, int64(len(s.abortedTxns))*TxnIndexEntrySize); err != nil {
+		return err
+	}
+	s.abortedTxns = append(s.abortedTxns, txn)
+	return nil
+}
+
+// loadIndexes reads the index files of a segment that was closed cleanly.
+func (s *Segment) loadIndexes() error {
+	data, err := io.ReadAll(io.NewSectionReader(s.index, 0, 1<<40))
+	if err != nil {
+		return err
+	}
+	s.indexEntries = DecodeIndex(data)
+
+	data, err = io.ReadAll(io.NewSectionReader(s.timeIndex, 0, 1<<40))
+	if err != nil {
+		return err
+	}
+	s.timeIndexEntries = DecodeTimeIndex(data)
+	if n := len(s.timeIndexEntries); n > 0 {
+		s.maxTimestamp = s.timeIndexEntries[n-1].Timestamp
+	}
+	return nil
+}
+
+// loadActive loads the indexes of the active segment of a log that was
+// closed cleanly, and reads the batches after the last indexed one to find
+// where the next batch goes. A segment whose batches do not end where the
+// file does was not closed cleanly after all, and is recovered.
+func (s *Segment) loadActive(indexIntervalBytes int) error {
+	if err := s.loadIndexes(); err != nil {
+		return err
+	}
+	var from int64
+	if n := len(s.indexEntries); n > 0 {
+		from = int64(s.indexEntries[n-1].Position)
+	}
+	if from > s.size {
+		return s.recover(indexIntervalBytes)
+	}
+	end, err := s.readBatches(from, func(b *record.RecordBatch, _ int64) {
+		s.nextOffset = b.LastOffset() + 1
+	})
+	if err != nil {
+		return err
+	}
+	if end != s.size {
+		return s.recover(indexIntervalBytes)
+	}
+	s.bytesSinceLastIndexEntry = int(end - from)
+	return nil
+}
+
+func (s *Segment) writeIndexes() error {
+	index := make([]byte, 0, len(s.indexEntries)*IndexEntrySize)
+	for _, e := range s.indexEntries {
+		index = binary.BigEndian.AppendUint32(index, uint32(e.RelativeOffset))
+		index = binary.BigEndian.AppendUint32(index, uint32(e.Position))
+	}
+	if err := s.index.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.index.WriteAt(index, 0); err != nil {
+		return err
+	}
+
+	timeIndex := make([]byte, 0, len(s.timeIndexEntries)*TimeIndexEntrySize)
+	for _, e := range s.timeIndexEntries {
+		timeIndex = binary.BigEndian.AppendUint64(timeIndex, uint64(e.Timestamp))
+		timeIndex = binary.BigEndian.AppendUint32(timeIndex, uint32(e.RelativeOffset))
+	}
+	if err := s.timeIndex.Truncate(0); err != nil {
+		return err
+	}
+	_, err := s.timeIndex.WriteAt(timeIndex, 0)
+	return err
+}
+
+// trackBatch updates the in-memory state for a batch stored at position and
+// reports the index entries it added.
+func (s *Segment) trackBatch(b *record.RecordBatch, position int32, indexIntervalBytes int) (*IndexEntry, *TimeIndexEntry) {
+	var ie *IndexEntry
+	var tie *TimeIndexEntry
+	relativeOffset := int32(b.LastOffset() - s.BaseOffset)
+
+	if len(s.indexEntries) == 0 || s.bytesSinceLastIndexEntry >= indexIntervalBytes {
+		s.indexEntries = append(s.indexEntries, IndexEntry{RelativeOffset: int32(b.BaseOffset - s.BaseOffset), Position: position})
+		ie = &s.indexEntries[len(s.indexEntries)-1]
+		s.bytesSinceLastIndexEntry = 0
+	}
+	s.bytesSinceLastIndexEntry += b.Size()
+
+	if b.MaxTimestamp > s.maxTimestamp {
+		s.maxTimestamp = b.MaxTimestamp
+		s.timeIndexEntries = append(s.timeIndexEntries, TimeIndexEntry{Timestamp: b.MaxTimestamp, RelativeOffset: relativeOffset})
+		tie = &s.timeIndexEntries[len(s.timeIndexEntries)-1]
+	}
+	s.nextOffset = b.LastOffset() + 1
+	return ie, tie
+}
+
+func (s *Segment) append(b *record.RecordBatch, data []byte, indexIntervalBytes int) error {
+	position := s.size
+	if _, err := s.log.WriteAt(data, position); err != nil {
+		return err
+	}
+	s.size += int64(len(data))
+
+	ie, tie := s.trackBatch(b, int32(position), indexIntervalBytes)
+	if ie != nil {
+		var buf [IndexEntrySize]byte
+		binary.BigEndian.PutUint32(buf[0:], uint32(ie.RelativeOffset))
+		binary.BigEndian.PutUint32(buf[4:], uint32(ie.Position))
+		if _, err := s.index.WriteAt(buf[:], int64(len(s.indexEntries)-1)*IndexEntrySize); err != nil {
+			return err
+		}
+	}
+	if tie != nil {
+		var buf [TimeIndexEntrySize]byte
+		binary.BigEndian.PutUint64(buf[0:], uint64(tie.Timestamp))
+		binary.BigEndian.PutUint32(buf[8:], uint32(tie.RelativeOffset))
+		if _, err := s.timeIndex.WriteAt(buf[:], int64(len(s.timeIndexEntries)-1)*TimeIndexEntrySize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the position of the last indexed batch starting at or
+// before offset.
+func (s *Segment) lookup(offset int64) int64 {
+	relativeOffset := int32(offset - s.BaseOffset)
+	i := sort.Search(len(s.indexEntries), func(i int) bool { return s.indexEntries[i].RelativeOffset > relativeOffset })
+	if i == 0 {
+		return 0
+	}
+	return int64(s.indexEntries[i-1].Position)
+}
+
+// findBatch returns the position of the batch containing offset, or -1 when
+// the segment holds no such batch.
+func (s *Segment) findBatch(offset int64) (int64, error) {
+	var header [record.LogOverhead]byte
+	for position := s.lookup(offset); position < s.size; {
+		if _, err := s.log.ReadAt(header[:], position); err != nil {
+			return -1, err
+		}
+		batchSize := int64(record.LogOverhead) + int64(binary.BigEndian.Uint32(header[8:]))
+		// the last offset delta lives in the batch header
+		var lastOffsetDelta [4]byte
+		if _, err := s.log.ReadAt(lastOffsetDelta[:], position+23); err != nil {
+			return -1, err
+		}
+		baseOffset := int64(binary.BigEndian.Uint64(header[0:]))
+		if baseOffset+int64(binary.BigEndian.Uint32(lastOffsetDelta[:])) >= offset {
+			return position, nil
+		}
+		position += batchSize
+	}
+	return -1, nil
+}
+
+// readBatch decodes the batch containing offset, or returns nil when the
+// segment holds no such batch.
+func (s *Segment) readBatch(offset int64) (*record.RecordBatch, error) {
+	position, err := s.findBatch(offset)
+	if err != nil || position < 0 {
+		return nil, err
+	}
+	var header [record.LogOverhead]byte
+	if _, err := s.log.ReadAt(header[:], position); err != nil {
+		return nil, err
+	}
+	data := make([]byte, int64(record.LogOverhead)+int64(binary.BigEndian.Uint32(header[8:])))
+	if _, err := s.log.ReadAt(data, position); err != nil {
+		return nil, err
+	}
+	return record.ReadRecordBatch(bytes.NewReader(data))
+}
+
+// offsetForTimestamp returns the offset and timestamp of the first record
+// with a timestamp at or after timestamp, and whether there is one. The
+// time index points at the first batch whose max timestamp reaches it.
+func (s *Segment) offsetForTimestamp(timestamp int64) (int64, int64, bool, error) {
+	i := sort.Search(len(s.timeIndexEntries), func(i int) bool { return s.timeIndexEntries[i].Timestamp >= timestamp })
+	if i == len(s.timeIndexEntries) {
+		return -1, -1, false, nil
+	}
+	b, err := s.readBatch(s.BaseOffset + int64(s.timeIndexEntries[i].RelativeOffset))
+	if err != nil {
+		return -1, -1, false, err
+	}
+	if b == nil {
+		return -1, -1, false, nil
+	}
+	// records of compressed batches are not decoded, so such a batch is
+	// found as a whole
+	if b.Attributes&record.TimestampTypeMask != 0 || len(b.Records) == 0 {
+		return b.BaseOffset, b.MaxTimestamp, true, nil
+	}
+	for _, rec := range b.Records {
+		if t := b.BaseTimestamp + rec.TimestampDelta; t >= timestamp {
+			return b.BaseOffset + int64(rec.OffsetDelta), t, true, nil
+		}
+	}
+	return b.BaseOffset, b.MaxTimestamp, true, nil
+}
+
+func (s *Segment) Size() int64 {
+	return s.size
+}
+
+// firstTimestamp returns the timestamp of the first batch, which is what
+// time-based rolling is measured from, or -1 for an empty segment.
+func (s *Segment) firstTimestamp() int64 {
+	if len(s.timeIndexEntries) == 0 {
+		return -1
+	}
+	return s.timeIndexEntries[0].Timestamp
+}
+
+func (s *Segment) sync() error {
+	if err := s.log.Sync(); err != nil {
+		return err
+	}
+	if err := s.index.Sync(); err != nil {
+		return err
+	}
+	if err := s.txnIndex.Sync(); err != nil {
+		return err
+	}
+	return s.timeIndex.Sync()
+}
+
+// delete closes the segment and removes its files.
+func (s *Segment) delete() error {
+	firstErr := s.Close()
+	for _, f := range []*os.File{s.log, s.index, s.timeIndex, s.txnIndex} {
+		if err := os.Remove(f.Name()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *Segment) Close() error {
+	var firstErr error
+	for _, f := range []*os.File{s.log, s.index, s.timeIndex, s.txnIndex} {
+		if f == nil {
+			continue
+		}
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,502 @@
+package txn
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/group"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/producer"
+	"github.com/codecrafters-io/kafka-starter-go/internal/record"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+)
+
+const (
+	TransactionStateTopic = "__transaction_state"
+
+	// MaxTimeoutMs is the default transaction.max.timeout.ms.
+	MaxTimeoutMs = 900000
+
+	// AbortTimedOutTransactionsInterval is how often expired transactions are
+	// looked for.
+	AbortTimedOutTransactionsInterval = 10 * time.Second
+)
+
+// MarkerWriter appends a commit or abort marker for a producer to a
+// partition that took part in its transaction.
+type MarkerWriter func(producerId int64, producerEpoch int16, commit bool, tp TopicPartition) error
+
+type txnError struct {
+	code    int16
+	message string
+}
+
+func (e *txnError) Error() string {
+	return e.message
+}
+
+func newTxnError(code int16, format string, args ...any) *txnError {
+	return &txnError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode maps an error returned by the coordinator to a protocol error code.
+func ErrorCode(err error) int16 {
+	if err == nil {
+		return constant.NONE
+	}
+	if te, ok := err.(*txnError); ok {
+		return te.code
+	}
+	return constant.UNKNOWN_SERVER_ERROR
+}
+
+// Coordinator drives transactions from InitProducerId to the markers that
+// end them. Every state change is written to the __transaction_state log
+// before it is acted upon, so a restarted broker finishes what it started.
+type Coordinator struct {
+	mu           sync.Mutex
+	cluster      *metadata.Cluster
+	producerIds  *producer.IdManager
+	log          *storage.Log
+	writeMarker  MarkerWriter
+	transactions map[string]*TransactionMetadata
+}
+
+// NewCoordinator loads the transaction log and completes the transactions
+// that were left between preparing and writing their markers.
+func NewCoordinator(cluster *metadata.Cluster, producerIds *producer.IdManager, logs *storage.LogManager, writeMarker MarkerWriter) (*Coordinator, error) {
+	l, err := logs.GetOrCreateLog(TransactionStateTopic, 0)
+	if err != nil {
+		return nil, err
+	}
+	c := &Coordinator{
+		cluster:      cluster,
+		producerIds:  producerIds,
+		log:          l,
+		writeMarker:  writeMarker,
+		transactions: make(map[string]*TransactionMetadata),
+	}
+	if err := c.load(); err != nil {
+		return nil, fmt.Errorf("error loading %s: %s", TransactionStateTopic, err)
+	}
+
+	now := time.Now().UnixMilli()
+	for _, m := range c.transactions {
+		if m.State == PrepareCommit || m.State == PrepareAbort {
+			if err := c.completeTransaction(m, now); err != nil {
+				slog.Error("Failed to complete transaction", "transactionalId", m.TransactionalId, "error", err)
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *Coordinator) load() error {
+	for offset := c.log.LogStartOffset(); offset < c.log.LogEndOffset(); {
+		data, err := c.log.Read(offset, 1<<20, c.log.LogEndOffset())
+		if err != nil {
+			return err
+		}
+		batches, err := record.ReadRecordBatches(data)
+		if err != nil {
+			return err
+		}
+		if len(batches) == 0 {
+			return fmt.Errorf("no batch at offset %d", offset)
+		}
+		for _, b := range batches {
+			offset = b.LastOffset() + 1
+			for _, rec := range b.Records {
+				transactionalId, err := decodeKey(rec.Key)
+				if err != nil {
+					return err
+				}
+				if rec.Value == nil {
+					delete(c.transactions, transactionalId)
+					continue
+				}
+				m, err := decodeValue(transactionalId, rec.Value)
+				if err != nil {
+					return fmt.Errorf("error decoding transaction %s: %s", transactionalId, err)
+				}
+				c.transactions[transactionalId] = m
+			}
+		}
+	}
+	return nil
+}
+
+// persist writes the new state of a transaction to the log and only then
+// makes it current.
+func (c *Coordinator) persist(m *TransactionMetadata) error {
+	b := &record.RecordBatch{
+		BaseTimestamp: m.LastUpdateMs,
+		MaxTimestamp:  m.LastUpdateMs,
+		ProducerId:    record.NoProducerId,
+		ProducerEpoch: record.NoProducerEpoch,
+		BaseSequence:  record.NoSequence,
+		Records: []record.Record{{
+			Key:   encodeKey(m.TransactionalId),
+			Value: encodeValue(m),
+		}},
+	}
+	if _, err := c.log.Append(b); err != nil {
+		return err
+	}
+	if err := c.log.Flush(); err != nil {
+		return err
+	}
+	c.transactions[m.TransactionalId] = m
+	return nil
+}
+
+// Transactions returns a copy of every transaction known to the coordinator.
+func (c *Coordinator) Transactions() []*TransactionMetadata {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	transactions := make([]*TransactionMetadata, 0, len(c.transactions))
+	for _, m := range c.transactions {
+		transactions = append(transactions, m.clone())
+	}
+	return transactions
+}
+
+// Start aborts transactions that outlive their timeout until stop is closed.
+func (c *Coordinator) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(AbortTimedOutTransactionsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				c.AbortTimedOutTransactions(now)
+			}
+		}
+	}()
+}
+
+// AbortTimedOutTransactions aborts every ongoing transaction started more
+// than its timeout before now. The epoch is bumped first so the producer
+// is fenced from the partitions it was writing to. Transactions stuck
+// before their markers are retried.
+func (c *Coordinator) AbortTimedOutTransactions(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nowMs := now.UnixMilli()
+	for _, m := range c.transactions {
+		var err error
+		switch m.State {
+		case Ongoing:
+			if m.StartMs+int64(m.TimeoutMs) > nowMs {
+				continue
+			}
+			err = c.fenceAndAbort(m, nowMs)
+		case PrepareCommit, PrepareAbort:
+			err = c.completeTransaction(m, nowMs)
+		}
+		if err != nil {
+			slog.Error("Failed to abort transaction", "transactionalId", m.TransactionalId, "error", err)
+		}
+	}
+}
+
+// fenceAndAbort bumps the epoch of an ongoing transaction and aborts it.
+func (c *Coordinator) fenceAndAbort(m *TransactionMetadata, now int64) error {
+	next := m.clone()
+	if next.ProducerEpoch < math.MaxInt16 {
+		next.ProducerEpoch++
+	}
+	next.State = PrepareAbort
+	next.LastUpdateMs = now
+	if err := c.persist(next); err != nil {
+		return err
+	}
+	return c.completeTransaction(next, now)
+}
+
+// completeTransaction writes the markers of a prepared transaction and
+// records its completion. On failure the transaction stays prepared and is
+// retried later.
+func (c *Coordinator) completeTransaction(m *TransactionMetadata, now int64) error {
+	commit := m.State == PrepareCommit
+	for _, tp := range m.SortedPartitions() {
+		if err := c.writeMarker(m.ProducerId, m.ProducerEpoch, commit, tp); err != nil {
+			return fmt.Errorf("error writing marker to %s-%d: %s", tp.Topic, tp.Partition, err)
+		}
+	}
+
+	next := m.clone()
+	next.State = CompleteAbort
+	if commit {
+		next.State = CompleteCommit
+	}
+	next.Partitions = make(map[TopicPartition]struct{})
+	next.LastUpdateMs = now
+	next.StartMs = -1
+	return c.persist(next)
+}
+
+func (c *Coordinator) InitProducerId(req *request.InitProducerIdV2) *response.InitProducerIdV2 {
+	producerId, producerEpoch, err := c.initProducerId(req)
+	if err != nil {
+		return &response.InitProducerIdV2{
+			ErrorCode:     ErrorCode(err),
+			ProducerId:    -1,
+			ProducerEpoch: -1,
+		}
+	}
+	return &response.InitProducerIdV2{
+		ProducerId:    producerId,
+		ProducerEpoch: producerEpoch,
+	}
+}
+
+// initProducerId gives idempotent producers a fresh id. Producers with a
+// transactional id keep their id and get the next epoch, fencing off older
+// instances and aborting the transaction they left open; a producer that
+// restarts can pass its current id and epoch to have them checked first.
+func (c *Coordinator) initProducerId(req *request.InitProducerIdV2) (int64, int16, error) {
+	if req.TransactionalId == nil {
+		id, err := c.producerIds.GenerateProducerId()
+		return id, 0, err
+	}
+	transactionalId := string(*req.TransactionalId)
+	if transactionalId == "" {
+		return 0, 0, newTxnError(constant.INVALID_REQUEST, "TransactionalId can't be empty.")
+	}
+	if req.TransactionTimeoutMs <= 0 || req.TransactionTimeoutMs > MaxTimeoutMs {
+		return 0, 0, newTxnError(constant.INVALID_TRANSACTION_TIMEOUT,
+			"Transaction timeout %d ms must be between 1 and %d ms.", req.TransactionTimeoutMs, MaxTimeoutMs)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	current, ok := c.transactions[transactionalId]
+	if !ok {
+		id, err := c.producerIds.GenerateProducerId()
+		if err != nil {
+			return 0, 0, err
+		}
+		m := &TransactionMetadata{
+			TransactionalId: transactionalId,
+			ProducerId:      id,
+			TimeoutMs:       req.TransactionTimeoutMs,
+			State:           Empty,
+			Partitions:      make(map[TopicPartition]struct{}),
+			LastUpdateMs:    now,
+			StartMs:         -1,
+		}
+		if err := c.persist(m); err != nil {
+			return 0, 0, err
+		}
+		return m.ProducerId, m.ProducerEpoch, nil
+	}
+
+	if req.ProducerId != -1 && (req.ProducerId != current.ProducerId || req.ProducerEpoch != current.ProducerEpoch) {
+		if req.ProducerId == current.ProducerId && req.ProducerEpoch > current.ProducerEpoch {
+			return 0, 0, newTxnError(constant.INVALID_PRODUCER_EPOCH,
+				"Epoch %d is newer than the current epoch %d.", req.ProducerEpoch, current.ProducerEpoch)
+		}
+		return 0, 0, newTxnError(constant.PRODUCER_FENCED,
+			"Producer %d with epoch %d is no longer the current producer of %s.", req.ProducerId, req.ProducerEpoch, transactionalId)
+	}
+
+	switch current.State {
+	case PrepareCommit, PrepareAbort:
+		if err := c.completeTransaction(current, now); err != nil {
+			return 0, 0, newTxnError(constant.CONCURRENT_TRANSACTIONS, "%s", err)
+		}
+	case Ongoing:
+		if err := c.fenceAndAbort(current, now); err != nil {
+			return 0, 0, newTxnError(constant.CONCURRENT_TRANSACTIONS, "%s", err)
+		}
+	}
+
+	next := c.transactions[transactionalId].clone()
+	if next.ProducerEpoch >= math.MaxInt16-1 {
+		id, err := c.producerIds.GenerateProducerId()
+		if err != nil {
+			return 0, 0, err
+		}
+		next.ProducerId = id
+		next.ProducerEpoch = 0
+	} else {
+		next.ProducerEpoch++
+	}
+	next.TimeoutMs = req.TransactionTimeoutMs
+	next.State = Empty
+	next.LastUpdateMs = now
+	if err := c.persist(next); err != nil {
+		return 0, 0, err
+	}
+	return next.ProducerId, next.ProducerEpoch, nil
+}
+
+// checkProducer looks up the transaction of a producer, checking that it
+// is still the current one.
+func (c *Coordinator) checkProducer(transactionalId string, producerId int64, producerEpoch int16) (*TransactionMetadata, error) {
+	m, ok := c.transactions[transactionalId]
+	if !ok || m.ProducerId != producerId {
+		return nil, newTxnError(constant.INVALID_PRODUCER_ID_MAPPING,
+			"Producer %d is not assigned to transactional id %s.", producerId, transactionalId)
+	}
+	if producerEpoch != m.ProducerEpoch {
+		return nil, newTxnError(constant.PRODUCER_FENCED,
+			"Epoch %d of producer %d is not the current epoch %d.", producerEpoch, producerId, m.ProducerEpoch)
+	}
+	return m, nil
+}
+
+// addPartitions adds partitions to the ongoing transaction of a producer,
+// starting a new transaction if none is.
+func (c *Coordinator) addPartitions(transactionalId string, producerId int64, producerEpoch int16, partitions []TopicPartition) error {
+	m, err := c.checkProducer(transactionalId, producerId, producerEpoch)
+	if err != nil {
+		return err
+	}
+	switch m.State {
+	case PrepareCommit, PrepareAbort:
+		return newTxnError(constant.CONCURRENT_TRANSACTIONS, "Transaction %s is being completed.", transactionalId)
+	case Dead, PrepareEpochFence:
+		return newTxnError(constant.INVALID_TXN_STATE, "Transaction %s is %s.", transactionalId, m.State)
+	}
+
+	next := m.clone()
+	now := time.Now().UnixMilli()
+	if m.State != Ongoing {
+		next.State = Ongoing
+		next.StartMs = now
+	}
+	added := false
+	for _, tp := range partitions {
+		if _, ok := next.Partitions[tp]; !ok {
+			next.Partitions[tp] = struct{}{}
+			added = true
+		}
+	}
+	if !added && m.State == Ongoing {
+		return nil
+	}
+	next.LastUpdateMs = now
+	return c.persist(next)
+}
+
+func (c *Coordinator) AddPartitionsToTxn(req *request.AddPartitionsToTxnV3) *response.AddPartitionsToTxnV3 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := &response.AddPartitionsToTxnV3{
+		Results: make([]response.TxnTopicResult, len(req.Topics)),
+	}
+	var partitions []TopicPartition
+	unknown := false
+	for i, t := range req.Topics {
+		res.Results[i].Name = t.Name
+		res.Results[i].Results = make([]response.TxnPartitionResult, len(t.Partitions))
+		topic, ok := c.cluster.TopicByName(string(t.Name))
+		for j, p := range t.Partitions {
+			res.Results[i].Results[j].PartitionIndex = p
+			if !ok || p < 0 || int(p) >= len(topic.Partitions) {
+				res.Results[i].Results[j].ErrorCode = constant.UNKNOWN_TOPIC_OR_PARTITION
+				unknown = true
+				continue
+			}
+			partitions = append(partitions, TopicPartition{Topic: string(t.Name), Partition: p})
+		}
+	}
+
+	// partitions are added all together or not at all
+	var errorCode int16
+	if unknown {
+		errorCode = constant.OPERATION_NOT_ATTEMPTED
+	} else {
+		err := c.addPartitions(string(req.TransactionalId), req.ProducerId, req.ProducerEpoch, partitions)
+		errorCode = ErrorCode(err)
+	}
+	for i := range res.Results {
+		for j := range res.Results[i].Results {
+			if res.Results[i].Results[j].ErrorCode == constant.NONE {
+				res.Results[i].Results[j].ErrorCode = errorCode
+			}
+		}
+	}
+	return res
+}
+
+// AddOffsetsToTxn adds the group's offsets partition to the transaction, so
+// that the offsets committed through TxnOffsetCommit follow its outcome.
+func (c *Coordinator) AddOffsetsToTxn(req *request.AddOffsetsToTxnV3) *response.ErrorOnlyV0 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if req.GroupId == "" {
+		return &response.ErrorOnlyV0{ErrorCode: constant.INVALID_GROUP_ID}
+	}
+	err := c.addPartitions(string(req.TransactionalId), req.ProducerId, req.ProducerEpoch,
+		[]TopicPartition{{Topic: group.OffsetsTopic, Partition: 0}})
+	return &response.ErrorOnlyV0{ErrorCode: ErrorCode(err)}
+}
+
+func (c *Coordinator) EndTxn(req *request.EndTxnV3) *response.ErrorOnlyV0 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.endTxn(string(req.TransactionalId), req.ProducerId, req.ProducerEpoch, req.Committed)
+	return &response.ErrorOnlyV0{ErrorCode: ErrorCode(err)}
+}
+
+// endTxn commits or aborts the ongoing transaction. Retrying a request that
+// already went through succeeds without doing anything.
+func (c *Coordinator) endTxn(transactionalId string, producerId int64, producerEpoch int16, commit bool) error {
+	m, err := c.checkProducer(transactionalId, producerId, producerEpoch)
+	if err != nil {
+		return err
+	}
+
+	prepared, completed := PrepareAbort, CompleteAbort
+	if commit {
+		prepared, completed = PrepareCommit, CompleteCommit
+	}
+	now := time.Now().UnixMilli()
+	switch m.State {
+	case Ongoing:
+		next := m.clone()
+		next.State = prepared
+		next.LastUpdateMs = now
+		if err := c.persist(next); err != nil {
+			return err
+		}
+		if err := c.completeTransaction(next, now); err != nil {
+			// the outcome is decided; the markers are retried in the background
+			slog.Error("Failed to complete transaction", "transactionalId", transactionalId, "error", err)
+		}
+		return nil
+	case prepared, completed:
+		if m.State == prepared {
+			if err := c.completeTransaction(m, now); err != nil {
+				return newTxnError(constant.CONCURRENT_TRANSACTIONS, "%s", err)
+			}
+		}
+		return nil
+	case PrepareCommit, PrepareAbort:
+		return newTxnError(constant.CONCURRENT_TRANSACTIONS, "Transaction %s is %s.", transactionalId, m.State)
+	default:
+		action := "abort"
+		if commit {
+			action = "commit"
+		}
+		return newTxnError(constant.INVALID_TXN_STATE, "Can't %s transaction %s in state %s.", action, transactionalId, m.State)
+	}
+}
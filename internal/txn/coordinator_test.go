@@ -0,0 +1,300 @@
+package txn
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/metadata"
+	"github.com/codecrafters-io/kafka-starter-go/internal/producer"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+// marker is an end transaction marker written to a partition.
+type marker struct {
+	producerId    int64
+	producerEpoch int16
+	commit        bool
+	tp            TopicPartition
+}
+
+// testCoordinator is a coordinator of a cluster with a topic "t" of two
+// partitions, which records the markers it writes and fails to write them
+// while fail is set.
+type testCoordinator struct {
+	*Coordinator
+	logs    *storage.LogManager
+	markers []marker
+	fail    bool
+}
+
+func newTestCoordinator(t *testing.T, dir string) *testCoordinator {
+	t.Helper()
+	logs, err := storage.NewLogManager(dir, storage.DefaultLogConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logs.Close() })
+	metadataLog, err := metadata.OpenLog(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := metadata.NewCluster(1)
+	cluster.PutTopic(metadata.Topic{Name: "t", Partitions: []metadata.Partition{{PartitionIndex: 0}, {PartitionIndex: 1}}})
+	producerIds := producer.NewIdManager(1, metadataLog)
+	if err := metadataLog.Replay(); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := &testCoordinator{logs: logs}
+	tc.Coordinator, err = NewCoordinator(cluster, producerIds, logs, func(producerId int64, producerEpoch int16, commit bool, tp TopicPartition) error {
+		if tc.fail {
+			return errors.New("partition unavailable")
+		}
+		tc.markers = append(tc.markers, marker{producerId, producerEpoch, commit, tp})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tc
+}
+
+func (tc *testCoordinator) initProducer(t *testing.T, producerId int64, producerEpoch int16) (int64, int16) {
+	t.Helper()
+	id := types.CompactString("txn")
+	res := tc.InitProducerId(&request.InitProducerIdV2{
+		TransactionalId:      &id,
+		TransactionTimeoutMs: 60000,
+		ProducerId:           producerId,
+		ProducerEpoch:        producerEpoch,
+	})
+	if res.ErrorCode != constant.NONE {
+		t.Fatalf("InitProducerId of %d at epoch %d failed with error %d", producerId, producerEpoch, res.ErrorCode)
+	}
+	return res.ProducerId, res.ProducerEpoch
+}
+
+func (tc *testCoordinator) addPartitions(producerId int64, producerEpoch int16, partitions ...int32) int16 {
+	res := tc.AddPartitionsToTxn(&request.AddPartitionsToTxnV3{
+		TransactionalId: "txn",
+		ProducerId:      producerId,
+		ProducerEpoch:   producerEpoch,
+		Topics:          []request.TxnTopic{{Name: "t", Partitions: partitions}},
+	})
+	return res.Results[0].Results[0].ErrorCode
+}
+
+func (tc *testCoordinator) endTxn(producerId int64, producerEpoch int16, commit bool) int16 {
+	return tc.EndTxn(&request.EndTxnV3{
+		TransactionalId: "txn",
+		ProducerId:      producerId,
+		ProducerEpoch:   producerEpoch,
+		Committed:       commit,
+	}).ErrorCode
+}
+
+func (tc *testCoordinator) state() *TransactionMetadata {
+	return tc.transactions["txn"]
+}
+
+func TestEndTxn(t *testing.T) {
+	for _, commit := range []bool{true, false} {
+		completed := CompleteAbort
+		if commit {
+			completed = CompleteCommit
+		}
+		t.Run(completed.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			tc := newTestCoordinator(t, dir)
+			id, epoch := tc.initProducer(t, -1, -1)
+			if s := tc.state().State; s != Empty {
+				t.Fatalf("state after InitProducerId %s, want Empty", s)
+			}
+
+			if code := tc.addPartitions(id, epoch, 0); code != constant.NONE {
+				t.Fatalf("AddPartitionsToTxn failed with error %d", code)
+			}
+			if code := tc.addPartitions(id, epoch, 1, 0); code != constant.NONE {
+				t.Fatalf("AddPartitionsToTxn failed with error %d", code)
+			}
+			m := tc.state()
+			if m.State != Ongoing || m.StartMs < 0 || len(m.Partitions) != 2 {
+				t.Fatalf("transaction %+v, want ongoing in two partitions", m)
+			}
+
+			if code := tc.endTxn(id, epoch, commit); code != constant.NONE {
+				t.Fatalf("EndTxn failed with error %d", code)
+			}
+			want := []marker{{id, epoch, commit, TopicPartition{"t", 0}}, {id, epoch, commit, TopicPartition{"t", 1}}}
+			if !slices.Equal(tc.markers, want) {
+				t.Errorf("markers %+v, want %+v", tc.markers, want)
+			}
+			m = tc.state()
+			if m.State != completed || m.StartMs != -1 || len(m.Partitions) != 0 {
+				t.Errorf("transaction %+v, want %s without partitions", m, completed)
+			}
+
+			// a retry succeeds without writing markers again, while the
+			// opposite outcome is refused
+			if code := tc.endTxn(id, epoch, commit); code != constant.NONE {
+				t.Errorf("retried EndTxn failed with error %d", code)
+			}
+			if code := tc.endTxn(id, epoch, !commit); code != constant.INVALID_TXN_STATE {
+				t.Errorf("EndTxn of the other outcome: error %d, want %d", code, constant.INVALID_TXN_STATE)
+			}
+			if len(tc.markers) != 2 {
+				t.Errorf("%d markers written, want 2", len(tc.markers))
+			}
+
+			// the next transaction starts from the completed one, and the
+			// state survives a restart
+			if code := tc.addPartitions(id, epoch, 1); code != constant.NONE {
+				t.Fatalf("AddPartitionsToTxn of the next transaction failed with error %d", code)
+			}
+			tc.logs.Close()
+			restarted := newTestCoordinator(t, dir)
+			m = restarted.state()
+			if m.State != Ongoing || m.ProducerId != id || m.ProducerEpoch != epoch || len(m.Partitions) != 1 {
+				t.Errorf("restored transaction %+v, want ongoing in one partition", m)
+			}
+		})
+	}
+}
+
+func TestEndTxnWithoutTransaction(t *testing.T) {
+	tc := newTestCoordinator(t, t.TempDir())
+	id, epoch := tc.initProducer(t, -1, -1)
+	tests := []struct {
+		name          string
+		producerId    int64
+		producerEpoch int16
+		want          int16
+	}{
+		{"nothing to commit", id, epoch, constant.INVALID_TXN_STATE},
+		{"unknown producer", id + 1, epoch, constant.INVALID_PRODUCER_ID_MAPPING},
+		{"old epoch", id, epoch - 1, constant.PRODUCER_FENCED},
+	}
+	for _, tt := range tests {
+		if code := tc.endTxn(tt.producerId, tt.producerEpoch, true); code != tt.want {
+			t.Errorf("%s: error %d, want %d", tt.name, code, tt.want)
+		}
+	}
+}
+
+func TestMarkersRetried(t *testing.T) {
+	dir := t.TempDir()
+	tc := newTestCoordinator(t, dir)
+	id, epoch := tc.initProducer(t, -1, -1)
+	if code := tc.addPartitions(id, epoch, 0); code != constant.NONE {
+		t.Fatalf("AddPartitionsToTxn failed with error %d", code)
+	}
+
+	// the outcome is decided even though the markers can't be written yet
+	tc.fail = true
+	if code := tc.endTxn(id, epoch, true); code != constant.NONE {
+		t.Fatalf("EndTxn failed with error %d", code)
+	}
+	if s := tc.state().State; s != PrepareCommit {
+		t.Fatalf("state %s, want PrepareCommit", s)
+	}
+	if code := tc.addPartitions(id, epoch, 1); code != constant.CONCURRENT_TRANSACTIONS {
+		t.Errorf("AddPartitionsToTxn while completing: error %d, want %d", code, constant.CONCURRENT_TRANSACTIONS)
+	}
+
+	// a restarted coordinator completes the prepared transaction
+	tc.logs.Close()
+	restarted := newTestCoordinator(t, dir)
+	if s := restarted.state().State; s != CompleteCommit {
+		t.Errorf("state after restart %s, want CompleteCommit", s)
+	}
+	if want := []marker{{id, epoch, true, TopicPartition{"t", 0}}}; !slices.Equal(restarted.markers, want) {
+		t.Errorf("markers %+v, want %+v", restarted.markers, want)
+	}
+}
+
+func TestInitProducerIdBumpsEpoch(t *testing.T) {
+	tc := newTestCoordinator(t, t.TempDir())
+	id, epoch := tc.initProducer(t, -1, -1)
+	if epoch != 0 {
+		t.Fatalf("first epoch %d, want 0", epoch)
+	}
+
+	// a re-sent InitProducerId keeps the id and fences the previous epoch
+	again, bumped := tc.initProducer(t, -1, -1)
+	if again != id || bumped != epoch+1 {
+		t.Fatalf("re-sent InitProducerId gave %d at epoch %d, want %d at epoch %d", again, bumped, id, epoch+1)
+	}
+	if code := tc.addPartitions(id, epoch, 0); code != constant.PRODUCER_FENCED {
+		t.Errorf("AddPartitionsToTxn at the fenced epoch: error %d, want %d", code, constant.PRODUCER_FENCED)
+	}
+
+	// an ongoing transaction is aborted at a fencing epoch before the next
+	// epoch is handed out
+	epoch = bumped
+	if code := tc.addPartitions(id, epoch, 0); code != constant.NONE {
+		t.Fatalf("AddPartitionsToTxn failed with error %d", code)
+	}
+	_, bumped = tc.initProducer(t, id, epoch)
+	if want := []marker{{id, epoch + 1, false, TopicPartition{"t", 0}}}; !slices.Equal(tc.markers, want) {
+		t.Errorf("markers %+v, want %+v", tc.markers, want)
+	}
+	if bumped != epoch+2 || tc.state().State != Empty {
+		t.Errorf("InitProducerId during a transaction gave epoch %d in state %s, want epoch %d in state Empty", bumped, tc.state().State, epoch+2)
+	}
+	epoch = bumped
+
+	transactionalId := types.CompactString("txn")
+	tests := []struct {
+		name          string
+		producerEpoch int16
+		want          int16
+	}{
+		{"older epoch", epoch - 1, constant.PRODUCER_FENCED},
+		{"newer epoch", epoch + 1, constant.INVALID_PRODUCER_EPOCH},
+	}
+	for _, tt := range tests {
+		res := tc.InitProducerId(&request.InitProducerIdV2{
+			TransactionalId:      &transactionalId,
+			TransactionTimeoutMs: 60000,
+			ProducerId:           id,
+			ProducerEpoch:        tt.producerEpoch,
+		})
+		if res.ErrorCode != tt.want {
+			t.Errorf("%s: error %d, want %d", tt.name, res.ErrorCode, tt.want)
+		}
+	}
+
+	// a producer out of epochs is given a new id
+	tc.state().ProducerEpoch = math.MaxInt16 - 1
+	newId, newEpoch := tc.initProducer(t, -1, -1)
+	if newId == id || newEpoch != 0 {
+		t.Errorf("exhausted epochs gave %d at epoch %d, want a new id at epoch 0", newId, newEpoch)
+	}
+}
+
+func TestTransactionTimeout(t *testing.T) {
+	tc := newTestCoordinator(t, t.TempDir())
+	id, epoch := tc.initProducer(t, -1, -1)
+	if code := tc.addPartitions(id, epoch, 0); code != constant.NONE {
+		t.Fatalf("AddPartitionsToTxn failed with error %d", code)
+	}
+
+	tc.AbortTimedOutTransactions(time.Now())
+	if s := tc.state().State; s != Ongoing {
+		t.Fatalf("state before the timeout %s, want Ongoing", s)
+	}
+	tc.AbortTimedOutTransactions(time.Now().Add(61 * time.Second))
+	m := tc.state()
+	if m.State != CompleteAbort || m.ProducerEpoch != epoch+1 {
+		t.Errorf("timed out transaction %+v, want aborted at epoch %d", m, epoch+1)
+	}
+	if code := tc.endTxn(id, epoch, true); code != constant.PRODUCER_FENCED {
+		t.Errorf("EndTxn after the timeout: error %d, want %d", code, constant.PRODUCER_FENCED)
+	}
+}
@@ -0,0 +1,100 @@
+package txn
+
+import (
+	"sort"
+	"time"
+
+	constant "github.com/codecrafters-io/kafka-starter-go/internal/constants"
+	"github.com/codecrafters-io/kafka-starter-go/internal/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+func (c *Coordinator) DescribeTransactions(req *request.DescribeTransactionsV0) *response.DescribeTransactionsV0 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := &response.DescribeTransactionsV0{
+		TransactionStates: make([]response.TransactionState, len(req.TransactionalIds)),
+	}
+	for i, transactionalId := range req.TransactionalIds {
+		s := &res.TransactionStates[i]
+		s.TransactionalId = transactionalId
+		m, ok := c.transactions[string(transactionalId)]
+		if !ok || m.State == Dead {
+			s.ErrorCode = constant.TRANSACTIONAL_ID_NOT_FOUND
+			s.TransactionStartTimeMs = -1
+			s.ProducerId = -1
+			s.ProducerEpoch = -1
+			continue
+		}
+		s.TransactionState = types.CompactString(m.State.String())
+		s.TransactionTimeoutMs = m.TimeoutMs
+		s.TransactionStartTimeMs = m.StartMs
+		s.ProducerId = m.ProducerId
+		s.ProducerEpoch = m.ProducerEpoch
+		for _, tp := range m.SortedPartitions() {
+			if n := len(s.Topics); n == 0 || string(s.Topics[n-1].Topic) != tp.Topic {
+				s.Topics = append(s.Topics, response.TransactionTopic{Topic: types.CompactString(tp.Topic)})
+			}
+			t := &s.Topics[len(s.Topics)-1]
+			t.Partitions = append(t.Partitions, tp.Partition)
+		}
+	}
+	return res
+}
+
+// ListTransactions lists the transactional ids matching every filter given:
+// their state, their producer id and how long their transaction has been
+// running.
+func (c *Coordinator) ListTransactions(req *request.ListTransactionsV0) *response.ListTransactionsV0 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := &response.ListTransactionsV0{
+		UnknownStateFilters: []types.CompactString{},
+		TransactionStates:   []response.ListedTransaction{},
+	}
+	states := make(map[State]bool)
+	for _, name := range req.StateFilters {
+		s, ok := ParseState(string(name))
+		if !ok {
+			res.UnknownStateFilters = append(res.UnknownStateFilters, name)
+			continue
+		}
+		states[s] = true
+	}
+	if len(req.StateFilters) > 0 && len(states) == 0 {
+		// only unknown states were asked for
+		return res
+	}
+	producerIds := make(map[int64]bool)
+	for _, id := range req.ProducerIdFilters {
+		producerIds[id] = true
+	}
+
+	now := time.Now().UnixMilli()
+	for _, m := range c.transactions {
+		if m.State == Dead {
+			continue
+		}
+		if len(states) > 0 && !states[m.State] {
+			continue
+		}
+		if len(producerIds) > 0 && !producerIds[m.ProducerId] {
+			continue
+		}
+		if req.DurationFilter >= 0 && (m.StartMs < 0 || now-m.StartMs < req.DurationFilter) {
+			continue
+		}
+		res.TransactionStates = append(res.TransactionStates, response.ListedTransaction{
+			TransactionalId:  types.CompactString(m.TransactionalId),
+			ProducerId:       m.ProducerId,
+			TransactionState: types.CompactString(m.State.String()),
+		})
+	}
+	sort.Slice(res.TransactionStates, func(i, j int) bool {
+		return res.TransactionStates[i].TransactionalId < res.TransactionStates[j].TransactionalId
+	})
+	return res
+}
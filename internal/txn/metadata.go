@@ -0,0 +1,225 @@
+package txn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type State int8
+
+// The states match the status stored by Kafka in __transaction_state.
+const (
+	Empty State = iota
+	Ongoing
+	PrepareCommit
+	PrepareAbort
+	CompleteCommit
+	CompleteAbort
+	Dead
+	PrepareEpochFence
+)
+
+func (s State) String() string {
+	switch s {
+	case Empty:
+		return "Empty"
+	case Ongoing:
+		return "Ongoing"
+	case PrepareCommit:
+		return "PrepareCommit"
+	case PrepareAbort:
+		return "PrepareAbort"
+	case CompleteCommit:
+		return "CompleteCommit"
+	case CompleteAbort:
+		return "CompleteAbort"
+	case Dead:
+		return "Dead"
+	case PrepareEpochFence:
+		return "PrepareEpochFence"
+	default:
+		return fmt.Sprintf("Unknown(%d)", int8(s))
+	}
+}
+
+// ParseState returns the state with the given name.
+func ParseState(name string) (State, bool) {
+	for s := Empty; s <= PrepareEpochFence; s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+type TopicPartition struct {
+	Topic     string
+	Partition int32
+}
+
+// TransactionMetadata is the coordinator's view of a transactional id.
+type TransactionMetadata struct {
+	TransactionalId string
+	ProducerId      int64
+	ProducerEpoch   int16
+	TimeoutMs       int32
+	State           State
+	Partitions      map[TopicPartition]struct{}
+	LastUpdateMs    int64
+	StartMs         int64 // -1 when no transaction is ongoing
+}
+
+// SortedPartitions returns the partitions of the transaction ordered by
+// topic and partition.
+func (m *TransactionMetadata) SortedPartitions() []TopicPartition {
+	partitions := make([]TopicPartition, 0, len(m.Partitions))
+	for tp := range m.Partitions {
+		partitions = append(partitions, tp)
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Topic != partitions[j].Topic {
+			return partitions[i].Topic < partitions[j].Topic
+		}
+		return partitions[i].Partition < partitions[j].Partition
+	})
+	return partitions
+}
+
+func (m *TransactionMetadata) clone() *TransactionMetadata {
+	c := *m
+	c.Partitions = make(map[TopicPartition]struct{}, len(m.Partitions))
+	for tp := range m.Partitions {
+		c.Partitions[tp] = struct{}{}
+	}
+	return &c
+}
+
+// The key and value layouts below are version 0 of TransactionLogKey and
+// TransactionLogValue.
+
+func encodeKey(transactionalId string) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, int16(0))
+	writeString(&buf, transactionalId)
+	return buf.Bytes()
+}
+
+func decodeKey(data []byte) (string, error) {
+	r := bytes.NewReader(data)
+	var version int16
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return "", err
+	}
+	if version != 0 {
+		return "", fmt.Errorf("unsupported transaction log key version %d", version)
+	}
+	return readString(r)
+}
+
+func encodeValue(m *TransactionMetadata) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, int16(0))
+	binary.Write(&buf, binary.BigEndian, m.ProducerId)
+	binary.Write(&buf, binary.BigEndian, m.ProducerEpoch)
+	binary.Write(&buf, binary.BigEndian, m.TimeoutMs)
+	binary.Write(&buf, binary.BigEndian, int8(m.State))
+
+	byTopic := make(map[string][]int32)
+	var topics []string
+	for _, tp := range m.SortedPartitions() {
+		if _, ok := byTopic[tp.Topic]; !ok {
+			topics = append(topics, tp.Topic)
+		}
+		byTopic[tp.Topic] = append(byTopic[tp.Topic], tp.Partition)
+	}
+	binary.Write(&buf, binary.BigEndian, int32(len(topics)))
+	for _, topic := range topics {
+		writeString(&buf, topic)
+		binary.Write(&buf, binary.BigEndian, int32(len(byTopic[topic])))
+		for _, p := range byTopic[topic] {
+			binary.Write(&buf, binary.BigEndian, p)
+		}
+	}
+
+	binary.Write(&buf, binary.BigEndian, m.LastUpdateMs)
+	binary.Write(&buf, binary.BigEndian, m.StartMs)
+	return buf.Bytes()
+}
+
+func decodeValue(transactionalId string, data []byte) (*TransactionMetadata, error) {
+	r := bytes.NewReader(data)
+	m := &TransactionMetadata{
+		TransactionalId: transactionalId,
+		Partitions:      make(map[TopicPartition]struct{}),
+	}
+	var version int16
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != 0 {
+		return nil, fmt.Errorf("unsupported transaction log value version %d", version)
+	}
+	var state int8
+	for _, field := range []any{&m.ProducerId, &m.ProducerEpoch, &m.TimeoutMs, &state} {
+		if err := binary.Read(r, binary.BigEndian, field); err != nil {
+			return nil, err
+		}
+	}
+	m.State = State(state)
+
+	var numTopics int32
+	if err := binary.Read(r, binary.BigEndian, &numTopics); err != nil {
+		return nil, err
+	}
+	for i := int32(0); i < numTopics; i++ {
+		topic, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		var numPartitions int32
+		if err := binary.Read(r, binary.BigEndian, &numPartitions); err != nil {
+			return nil, err
+		}
+		if numPartitions < 0 || int(numPartitions)*4 > r.Len() {
+			return nil, fmt.Errorf("invalid partition count %d for topic %s", numPartitions, topic)
+		}
+		for j := int32(0); j < numPartitions; j++ {
+			var p int32
+			if err := binary.Read(r, binary.BigEndian, &p); err != nil {
+				return nil, err
+			}
+			m.Partitions[TopicPartition{Topic: topic, Partition: p}] = struct{}{}
+		}
+	}
+
+	if err := binary.Read(r, binary.BigEndian, &m.LastUpdateMs); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &m.StartMs); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func writeString(w io.Writer, s string) {
+	binary.Write(w, binary.BigEndian, int16(len(s)))
+	io.WriteString(w, s)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	var length int16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
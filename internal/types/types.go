@@ -3,23 +3,121 @@ package types
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 )
 
+var (
+	// ErrTruncated is returned when a message ends in the middle of a field.
+	ErrTruncated = errors.New("message is truncated")
+	// ErrInvalidLength is returned for a length that is negative or longer
+	// than the rest of the message.
+	ErrInvalidLength = errors.New("invalid length")
+	// ErrTrailingBytes is returned for a message that goes on after its
+	// last field.
+	ErrTrailingBytes = errors.New("trailing bytes")
+	// ErrUnexpectedNull is returned for a null value in a field that can't
+	// be null.
+	ErrUnexpectedNull = errors.New("unexpected null")
+)
+
+// DecodeError is an error decoding a message, with the path of the field
+// that could not be read, like "topics[0].partitions[2].records".
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return "decoding " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Fieldf returns err as a DecodeError of the field named by format, which
+// is prepended to the path when err is already one. The end of the input is
+// reported as ErrTruncated. It returns nil when err is nil.
+func Fieldf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	field := fmt.Sprintf(format, args...)
+	var de *DecodeError
+	if errors.As(err, &de) {
+		if de.Path == "" {
+			return &DecodeError{Path: field, Err: de.Err}
+		}
+		sep := "."
+		if field == "" || strings.HasPrefix(de.Path, "[") {
+			sep = ""
+		}
+		return &DecodeError{Path: field + sep + de.Path, Err: de.Err}
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = ErrTruncated
+	}
+	return &DecodeError{Path: field, Err: err}
+}
+
+// readFull reads len(b) bytes, failing with ErrTruncated when fewer remain.
+func readFull(r io.Reader, b []byte) error {
+	if _, err := io.ReadFull(r, b); err != nil {
+		return ErrTruncated
+	}
+	return nil
+}
+
+// checkLength fails with ErrInvalidLength unless length bytes remain in r,
+// so that no buffer is allocated for a length the message can't hold.
+func checkLength(r *bytes.Reader, length uint64) error {
+	if length > uint64(r.Len()) {
+		return fmt.Errorf("%w: %d with %d bytes remaining", ErrInvalidLength, length, r.Len())
+	}
+	return nil
+}
+
 type NullableString struct {
 	Length int16
 	Data   string
 }
 type CompactString string
 
+// TaggedFields are the tagged fields of a flexible message that the
+// message does not declare, kept verbatim by tag so that they are written
+// back as they were read.
 type TaggedFields struct {
-	Length uint64
-	Fields map[uint64][]byte // map used for easy lookup by tag
+	Fields map[uint64][]byte
+}
+
+// TaggedField is a tagged field declared by a message, which is decoded
+// into and encoded from a typed value of the message rather than kept as
+// bytes.
+type TaggedField struct {
+	Tag  uint64
+	Name string
+	// Read decodes the field from its data, which it must consume.
+	Read func(r *bytes.Reader) error
+	// Write encodes the field, writing nothing when the value is the
+	// default, so that the field is left out.
+	Write func(w io.Writer) error
 }
 
 func ReadUvarint(r io.ByteReader) (uint64, error) {
-	return binary.ReadUvarint(r)
+	v, err := binary.ReadUvarint(r)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, ErrTruncated
+	}
+	return v, err
 }
 
 func WriteUvarint(w io.Writer, val uint64) error {
@@ -31,23 +129,23 @@ func WriteUvarint(w io.Writer, val uint64) error {
 
 func ReadNullableString(r *bytes.Reader) (*NullableString, error) {
 	ns := &NullableString{}
-
-	binary.Read(r, binary.BigEndian, &ns.Length) // TBC
-
-	if ns.Length != -1 {
-		str := make([]byte, ns.Length)
-		n, err := r.Read(str)
-		if err != nil {
-			return nil, fmt.Errorf("error reading nullable string: %s", err)
-		}
-
-		if n != int(ns.Length) {
-			return nil, fmt.Errorf("error reading nullable string: Expected: %d, Got: %d", ns.Length, n)
-		}
-
-		ns.Data = string(str)
+	if err := binary.Read(r, binary.BigEndian, &ns.Length); err != nil {
+		return nil, ErrTruncated
 	}
-
+	if ns.Length == -1 {
+		return ns, nil
+	}
+	if ns.Length < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidLength, ns.Length)
+	}
+	if err := checkLength(r, uint64(ns.Length)); err != nil {
+		return nil, err
+	}
+	str := make([]byte, ns.Length)
+	if err := readFull(r, str); err != nil {
+		return nil, err
+	}
+	ns.Data = string(str)
 	return ns, nil
 }
 
@@ -63,31 +161,16 @@ func (ns *NullableString) WriteNullableString(w io.Writer) error {
 	return err
 }
 
+// ReadCompactString reads a compact string that can't be null.
 func ReadCompactString(r *bytes.Reader) (*CompactString, error) {
-	var length uint64
-	var err error
-	if length, err = ReadUvarint(r); err != nil {
+	cs, err := ReadCompactNullableString(r)
+	if err != nil {
 		return nil, err
 	}
-
-	var cs CompactString
-	length -= 1
-	if length != 0 {
-		csBytes := make([]byte, length)
-
-		n, err := r.Read(csBytes)
-		if err != nil {
-			return nil, fmt.Errorf("error reading compact string: %s", err)
-		}
-
-		if n != int(length) {
-			return nil, fmt.Errorf("error reading compact string: Expected: %d, Got: %d", length, n)
-		}
-
-		cs = CompactString(csBytes)
+	if cs == nil {
+		return nil, ErrUnexpectedNull
 	}
-
-	return &cs, nil
+	return cs, nil
 }
 
 func (cs *CompactString) WriteCompactString(w io.Writer) error {
@@ -105,66 +188,367 @@ func (cs *CompactString) WriteCompactString(w io.Writer) error {
 	return nil
 }
 
-func ReadTaggedFields(r *bytes.Reader) (*TaggedFields, error) {
-	taggedFieldCount, err := ReadUvarint(r)
+// ReadTaggedFields reads tagged fields, whose tags must be ascending. The
+// declared fields are decoded into their values; the others are kept.
+func ReadTaggedFields(r *bytes.Reader, declared ...TaggedField) (*TaggedFields, error) {
+	count, err := ReadUvarint(r)
 	if err != nil {
-		return nil, fmt.Errorf("error reading tagged field count: %s", err)
+		return nil, err
 	}
-
-	taggedFields := &TaggedFields{
-		Length: taggedFieldCount,
-		Fields: make(map[uint64][]byte),
+	// each field takes at least a byte for its tag and one for its length
+	if count > uint64(r.Len())/2 {
+		return nil, fmt.Errorf("%w: %d tagged fields with %d bytes remaining", ErrInvalidLength, count, r.Len())
 	}
-	for i := uint64(0); i < taggedFieldCount; i++ {
+
+	taggedFields := &TaggedFields{}
+	var last uint64
+	for i := uint64(0); i < count; i++ {
 		tag, err := ReadUvarint(r)
 		if err != nil {
-			return nil, fmt.Errorf("error reading field %d tag: %s", i+1, err)
+			return nil, err
 		}
-
+		if i > 0 && tag <= last {
+			return nil, fmt.Errorf("tagged field %d is out of order", tag)
+		}
+		last = tag
 		length, err := ReadUvarint(r)
 		if err != nil {
-			return nil, fmt.Errorf("error reading field %d length: %s", i+1, err)
+			return nil, err
+		}
+		if err := checkLength(r, length); err != nil {
+			return nil, fmt.Errorf("tagged field %d: %w", tag, err)
 		}
-
 		data := make([]byte, length)
-		// io.ReadFull(r, data)
-		if _, err = r.Read(data); err != nil { // TBC
-			return nil, fmt.Errorf("error reading field %d data: %s", i+1, err)
+		if err := readFull(r, data); err != nil {
+			return nil, err
 		}
 
+		if f, ok := declaredField(declared, tag); ok {
+			fr := bytes.NewReader(data)
+			if err := f.Read(fr); err != nil {
+				return nil, Fieldf(err, "%s", f.Name)
+			}
+			if fr.Len() > 0 {
+				return nil, Fieldf(fmt.Errorf("%d bytes left over", fr.Len()), "%s", f.Name)
+			}
+			continue
+		}
+		if taggedFields.Fields == nil {
+			taggedFields.Fields = make(map[uint64][]byte)
+		}
 		taggedFields.Fields[tag] = data
 	}
 
 	return taggedFields, nil
 }
 
-func (t *TaggedFields) WriteTaggedFields(w io.Writer) error {
-	buf := bytes.Buffer{}
+func declaredField(declared []TaggedField, tag uint64) (TaggedField, bool) {
+	for _, f := range declared {
+		if f.Tag == tag {
+			return f, true
+		}
+	}
+	return TaggedField{}, false
+}
+
+// WriteTaggedFields writes the declared fields that don't have their
+// default value along with the kept ones, in ascending order of tag as the
+// protocol requires. A kept field is dropped when a declared field has its
+// tag.
+func (t *TaggedFields) WriteTaggedFields(w io.Writer, declared ...TaggedField) error {
+	fields := make(map[uint64][]byte, len(t.Fields)+len(declared))
+	for tag, data := range t.Fields {
+		fields[tag] = data
+	}
+	for _, f := range declared {
+		delete(fields, f.Tag)
+		var buf bytes.Buffer
+		if err := f.Write(&buf); err != nil {
+			return fmt.Errorf("error writing tagged field %s: %w", f.Name, err)
+		}
+		if buf.Len() > 0 {
+			fields[f.Tag] = buf.Bytes()
+		}
+	}
 
-	err := WriteUvarint(&buf, t.Length)
+	var buf bytes.Buffer
+	WriteUvarint(&buf, uint64(len(fields)))
+	for _, tag := range slices.Sorted(maps.Keys(fields)) {
+		WriteUvarint(&buf, tag)
+		WriteUvarint(&buf, uint64(len(fields[tag])))
+		buf.Write(fields[tag])
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadCompactNullableString returns nil when the encoded string is null.
+func ReadCompactNullableString(r *bytes.Reader) (*CompactString, error) {
+	length, err := ReadUvarint(r)
 	if err != nil {
-		return fmt.Errorf("error writing tagged field count: %s", err)
+		return nil, err
+	}
+	if length == 0 {
+		return nil, nil
 	}
+	length -= 1
+	if err := checkLength(r, length); err != nil {
+		return nil, err
+	}
+	csBytes := make([]byte, length)
+	if err := readFull(r, csBytes); err != nil {
+		return nil, err
+	}
+	cs := CompactString(csBytes)
+	return &cs, nil
+}
 
-	i := 0
-	for tag, data := range t.Fields {
-		err := WriteUvarint(&buf, tag)
-		if err != nil {
-			return fmt.Errorf("error writing field %d tag: %s", i+1, err)
+func WriteCompactNullableString(w io.Writer, cs *CompactString) error {
+	if cs == nil {
+		return WriteUvarint(w, 0)
+	}
+	return cs.WriteCompactString(w)
+}
+
+// ReadCompactArrayLength returns the number of elements in a compact array,
+// or -1 when the array is null.
+func ReadCompactArrayLength(r *bytes.Reader) (int, error) {
+	length, err := ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if length == 0 {
+		return -1, nil
+	}
+	// each element takes at least a byte
+	if err := checkLength(r, length-1); err != nil {
+		return 0, fmt.Errorf("array of %d elements: %w", length-1, err)
+	}
+	return int(length - 1), nil
+}
+
+// WriteCompactArrayLength writes n elements; a negative n encodes a null array.
+func WriteCompactArrayLength(w io.Writer, n int) error {
+	if n < 0 {
+		return WriteUvarint(w, 0)
+	}
+	return WriteUvarint(w, uint64(n)+1)
+}
+
+func ReadUuid(r *bytes.Reader) ([16]byte, error) {
+	var uuid [16]byte
+	err := readFull(r, uuid[:])
+	return uuid, err
+}
+
+func ReadBool(r *bytes.Reader) (bool, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return false, ErrTruncated
+	}
+	return b != 0, nil
+}
+
+func WriteBool(w io.Writer, b bool) error {
+	var v byte
+	if b {
+		v = 1
+	}
+	_, err := w.Write([]byte{v})
+	return err
+}
+
+func ReadCompactInt32Array(r *bytes.Reader) ([]int32, error) {
+	n, err := ReadCompactArrayLength(r)
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	arr := make([]int32, n)
+	for i := range arr {
+		if err := binary.Read(r, binary.BigEndian, &arr[i]); err != nil {
+			return nil, Fieldf(err, "[%d]", i)
 		}
+	}
+	return arr, nil
+}
 
-		err = WriteUvarint(&buf, uint64(len(data)))
+func WriteCompactInt32Array(w io.Writer, arr []int32) error {
+	if err := WriteCompactArrayLength(w, len(arr)); err != nil {
+		return err
+	}
+	for _, v := range arr {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ReadCompactInt64Array(r *bytes.Reader) ([]int64, error) {
+	n, err := ReadCompactArrayLength(r)
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	arr := make([]int64, n)
+	for i := range arr {
+		if err := binary.Read(r, binary.BigEndian, &arr[i]); err != nil {
+			return nil, Fieldf(err, "[%d]", i)
+		}
+	}
+	return arr, nil
+}
+
+func WriteCompactInt64Array(w io.Writer, arr []int64) error {
+	if err := WriteCompactArrayLength(w, len(arr)); err != nil {
+		return err
+	}
+	for _, v := range arr {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ReadCompactStringArray(r *bytes.Reader) ([]CompactString, error) {
+	n, err := ReadCompactArrayLength(r)
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	arr := make([]CompactString, n)
+	for i := range arr {
+		cs, err := ReadCompactString(r)
 		if err != nil {
-			return fmt.Errorf("error writing field %d length: %s", i+1, err)
+			return nil, Fieldf(err, "[%d]", i)
 		}
+		arr[i] = *cs
+	}
+	return arr, nil
+}
 
-		if _, err = buf.Write(data); err != nil { // TBC (does buf content get modified or not)
-			return fmt.Errorf("error writing field %d data: %s", i+1, err)
+func WriteCompactStringArray(w io.Writer, arr []CompactString) error {
+	if err := WriteCompactArrayLength(w, len(arr)); err != nil {
+		return err
+	}
+	for _, cs := range arr {
+		if err := cs.WriteCompactString(w); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		i++
+// ReadCompactNullableBytes returns nil when the encoded bytes are null.
+func ReadCompactNullableBytes(r *bytes.Reader) ([]byte, error) {
+	length, err := ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		return nil, nil
+	}
+	length -= 1
+	if err := checkLength(r, length); err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	if err := readFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func WriteCompactNullableBytes(w io.Writer, data []byte) error {
+	if data == nil {
+		return WriteUvarint(w, 0)
+	}
+	if err := WriteUvarint(w, uint64(len(data))+1); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// FileRegion is a part of a file that is written to a message as is, such
+// as the record batches of a segment file.
+type FileRegion struct {
+	File     *os.File
+	Position int64
+	Size     int64
+}
+
+// RegionWriter is a writer that takes file regions without reading them
+// into memory, so that they can be sent straight from their file.
+type RegionWriter interface {
+	io.Writer
+	WriteRegion(FileRegion) error
+}
+
+// WriteCompactRegions writes file regions as compact bytes. The regions are
+// handed over when w is a RegionWriter and read into w otherwise.
+func WriteCompactRegions(w io.Writer, regions []FileRegion) error {
+	size := int64(0)
+	for _, fr := range regions {
+		size += fr.Size
+	}
+	if err := WriteUvarint(w, uint64(size)+1); err != nil {
+		return err
 	}
+	for _, fr := range regions {
+		if rw, ok := w.(RegionWriter); ok {
+			if err := rw.WriteRegion(fr); err != nil {
+				return err
+			}
+		} else if _, err := io.Copy(w, io.NewSectionReader(fr.File, fr.Position, fr.Size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadCompactBytes reads compact bytes that can't be null.
+func ReadCompactBytes(r *bytes.Reader) ([]byte, error) {
+	data, err := ReadCompactNullableBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrUnexpectedNull
+	}
+	return data, nil
+}
 
-	_, err = w.Write(buf.Bytes())
+func WriteCompactBytes(w io.Writer, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	return WriteCompactNullableBytes(w, data)
+}
+
+// ReadBytes reads bytes prefixed with an int32 length, as used by
+// non-flexible versions.
+func ReadBytes(r *bytes.Reader) ([]byte, error) {
+	var length int32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, ErrTruncated
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidLength, length)
+	}
+	if err := checkLength(r, uint64(length)); err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	if err := readFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func WriteBytes(w io.Writer, data []byte) error {
+	if err := binary.Write(w, binary.BigEndian, int32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
 	return err
 }
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// int32Field declares a tagged int32 at tag, left out when it is zero.
+func int32Field(tag uint64, v *int32) TaggedField {
+	return TaggedField{
+		Tag:  tag,
+		Name: "value",
+		Read: func(r *bytes.Reader) error {
+			return binary.Read(r, binary.BigEndian, v)
+		},
+		Write: func(w io.Writer) error {
+			if *v == 0 {
+				return nil
+			}
+			return binary.Write(w, binary.BigEndian, *v)
+		},
+	}
+}
+
+func TestWriteTaggedFieldsSorted(t *testing.T) {
+	a, b := int32(1), int32(2)
+	kept := &TaggedFields{Fields: map[uint64][]byte{4: {0xaa}, 0: {0xbb, 0xcc}}}
+
+	var buf bytes.Buffer
+	// declared out of order, between the kept tags
+	if err := kept.WriteTaggedFields(&buf, int32Field(7, &b), int32Field(2, &a)); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		4,
+		0, 2, 0xbb, 0xcc,
+		2, 4, 0, 0, 0, 1,
+		4, 1, 0xaa,
+		7, 4, 0, 0, 0, 2,
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("wrote %x, want %x", buf.Bytes(), want)
+	}
+
+	// a declared field with its default value is left out, and takes the
+	// place of a kept field with its tag
+	var zero int32
+	buf.Reset()
+	if err := kept.WriteTaggedFields(&buf, int32Field(4, &zero)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{1, 0, 2, 0xbb, 0xcc}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("wrote %x, want %x", buf.Bytes(), want)
+	}
+}
+
+func TestTaggedFieldsRoundTrip(t *testing.T) {
+	// unknown tags 1 and 9 around the declared tag 3
+	encoded := []byte{
+		3,
+		1, 3, 'a', 'b', 'c',
+		3, 4, 0, 0, 1, 0,
+		9, 0,
+	}
+	var v int32
+	fields, err := ReadTaggedFields(bytes.NewReader(encoded), int32Field(3, &v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 256 {
+		t.Errorf("declared field read as %d, want 256", v)
+	}
+	if len(fields.Fields) != 2 || string(fields.Fields[1]) != "abc" || len(fields.Fields[9]) != 0 {
+		t.Errorf("kept fields %v, want tags 1 and 9", fields.Fields)
+	}
+
+	var buf bytes.Buffer
+	if err := fields.WriteTaggedFields(&buf, int32Field(3, &v)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Errorf("wrote back %x, want %x", buf.Bytes(), encoded)
+	}
+
+	// without the declaration every field is kept verbatim
+	fields, err = ReadTaggedFields(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := fields.WriteTaggedFields(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Errorf("wrote back %x, want %x", buf.Bytes(), encoded)
+	}
+}
+
+func TestReadTaggedFieldsMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded []byte
+		wantErr error // nil when any error will do
+	}{
+		{"duplicate tag", []byte{2, 1, 0, 1, 0}, nil},
+		{"descending tags", []byte{2, 5, 0, 1, 0}, nil},
+		{"duplicate declared tag", []byte{2, 3, 4, 0, 0, 0, 1, 3, 4, 0, 0, 0, 2}, nil},
+		{"more fields than bytes", []byte{3, 1, 0}, ErrInvalidLength},
+		{"oversized field", []byte{1, 1, 5, 'a'}, ErrInvalidLength},
+		{"truncated tag", []byte{1, 0x80, 0x80}, ErrTruncated},
+		{"declared field too short", []byte{1, 3, 2, 0, 1}, ErrTruncated},
+		{"declared field too long", []byte{1, 3, 5, 0, 0, 0, 1, 0}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v int32
+			fields, err := ReadTaggedFields(bytes.NewReader(tt.encoded), int32Field(3, &v))
+			if err == nil {
+				t.Fatalf("read %v, want an error", fields.Fields)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
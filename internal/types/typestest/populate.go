@@ -0,0 +1,68 @@
+// Package typestest fills protocol messages with values for round-trip
+// tests of their encoders and decoders.
+package typestest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/types"
+)
+
+var (
+	taggedFieldsType   = reflect.TypeFor[types.TaggedFields]()
+	nullableStringType = reflect.TypeFor[types.NullableString]()
+)
+
+// Populate sets every exported field of the message v points to, so that
+// no field can be left out by an encoder without a round trip noticing:
+// numbers get distinct values, booleans are true, strings and bytes are
+// not empty, pointers are set and slices hold two elements. A Version
+// field is left alone, as are the kept tagged fields.
+func Populate(v any) {
+	n := 0
+	populate(reflect.ValueOf(v).Elem(), &n)
+}
+
+func populate(v reflect.Value, n *int) {
+	*n++
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(*n % 100))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(*n % 100))
+	case reflect.Float64:
+		v.SetFloat(float64(*n) + 0.5)
+	case reflect.String:
+		v.SetString(fmt.Sprintf("s%d", *n))
+	case reflect.Array:
+		for i := range v.Len() {
+			populate(v.Index(i), n)
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := range v.Len() {
+			populate(v.Index(i), n)
+		}
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem(), n)
+	case reflect.Struct:
+		switch v.Type() {
+		case taggedFieldsType:
+			return
+		case nullableStringType:
+			s := fmt.Sprintf("s%d", *n)
+			v.Set(reflect.ValueOf(types.NullableString{Length: int16(len(s)), Data: s}))
+			return
+		}
+		for i := range v.NumField() {
+			f := v.Type().Field(i)
+			if f.IsExported() && f.Name != "Version" {
+				populate(v.Field(i), n)
+			}
+		}
+	}
+}